@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/saeid-a/CoachAppBack/internal/database"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+// reconcile compares local payments with a gateway settlement export and exits with
+// status 2 when mismatches are found, so it can run from cron or CI.
+func main() {
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	fromFlag := flag.String("from", yesterday, "first day to reconcile (YYYY-MM-DD, UTC)")
+	toFlag := flag.String("to", "", "last day to reconcile, inclusive (YYYY-MM-DD, UTC); defaults to -from")
+	gatewayFile := flag.String("gateway-file", "", "path to the gateway's JSON payment export")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	dbUrl := os.Getenv("DB_URL")
+	if dbUrl == "" {
+		log.Fatal("DB_URL environment variable is required")
+	}
+	if *gatewayFile == "" {
+		log.Fatal("-gateway-file is required")
+	}
+
+	from, err := time.Parse(time.DateOnly, *fromFlag)
+	if err != nil {
+		log.Fatalf("Invalid -from: %v", err)
+	}
+	lastDay := from
+	if *toFlag != "" {
+		if lastDay, err = time.Parse(time.DateOnly, *toFlag); err != nil {
+			log.Fatalf("Invalid -to: %v", err)
+		}
+	}
+	to := lastDay.AddDate(0, 0, 1)
+
+	records, err := loadGatewayRecords(*gatewayFile)
+	if err != nil {
+		log.Fatalf("Failed to read gateway export: %v", err)
+	}
+
+	if err := database.ConnectDB(dbUrl); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.CloseDB()
+
+	service := services.NewReconciliationService(repository.NewPaymentRepository(database.DB))
+	report, err := service.Reconcile(context.Background(), from, to, records)
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		printReport(report)
+	}
+
+	if len(report.Mismatches) > 0 {
+		database.CloseDB()
+		os.Exit(2)
+	}
+}
+
+func loadGatewayRecords(path string) ([]services.GatewayPaymentRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var records []services.GatewayPaymentRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func printReport(report *services.ReconciliationReport) {
+	fmt.Printf(
+		"Reconciled %d local payments from %s to %s: %d mismatches\n",
+		report.Checked,
+		report.From.Format(time.DateOnly),
+		report.To.AddDate(0, 0, -1).Format(time.DateOnly),
+		len(report.Mismatches),
+	)
+	if len(report.Mismatches) == 0 {
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "KIND\tPAYMENT\tREFERENCE\tLOCAL\tGATEWAY")
+	for _, mismatch := range report.Mismatches {
+		local, gateway := mismatch.LocalStatus, mismatch.GatewayStatus
+		if mismatch.Kind == services.MismatchAmount {
+			local = fmt.Sprintf("%.2f", *mismatch.LocalAmount)
+			gateway = fmt.Sprintf("%.2f", *mismatch.GatewayAmount)
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%s\t%s\n", mismatch.Kind, mismatch.PaymentID, mismatch.Reference, local, gateway)
+	}
+	_ = writer.Flush()
+}
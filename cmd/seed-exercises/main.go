@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/saeid-a/CoachAppBack/internal/database"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+// seed-exercises loads a JSON or CSV exercise catalog into the shared library. Existing catalog
+// exercises are matched by name and updated, so the command is safe to re-run.
+func main() {
+	catalogFile := flag.String("file", "", "path to the catalog file (.json or .csv)")
+	format := flag.String("format", "", "catalog format: json or csv; defaults to the file extension")
+	dryRun := flag.Bool("dry-run", false, "parse and validate the catalog without writing to the database")
+	flag.Parse()
+
+	if *catalogFile == "" {
+		log.Fatal("-file is required")
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*catalogFile)), ".")
+	}
+
+	file, err := os.Open(*catalogFile)
+	if err != nil {
+		log.Fatalf("Failed to open catalog: %v", err)
+	}
+	entries, err := services.ParseExerciseCatalog(file, *format, filepath.Dir(*catalogFile))
+	file.Close()
+	if err != nil {
+		log.Fatalf("Failed to parse catalog: %v", err)
+	}
+
+	if *dryRun {
+		fmt.Printf("Parsed %d exercises from %s\n", len(entries), *catalogFile)
+		return
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	dbUrl := os.Getenv("DB_URL")
+	if dbUrl == "" {
+		log.Fatal("DB_URL environment variable is required")
+	}
+
+	var storageService services.StorageService
+	supabaseURL, bucket, serviceKey := os.Getenv("SUPABASE_URL"), os.Getenv("SUPABASE_BUCKET"), os.Getenv("SUPABASE_SERVICE_KEY")
+	if supabaseURL != "" && bucket != "" && serviceKey != "" {
+		storageService = services.NewSupabaseStorageService(supabaseURL, bucket, serviceKey)
+	}
+
+	if err := database.ConnectDB(dbUrl); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.CloseDB()
+
+	service := services.NewExerciseService(database.DB, repository.NewExerciseRepository(database.DB), storageService)
+	result, err := service.ImportCatalog(context.Background(), entries)
+	if err != nil {
+		database.CloseDB()
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	fmt.Printf("Imported %d exercises: %d new, %d updated\n", len(entries), result.Inserted, result.Updated)
+}
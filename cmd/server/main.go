@@ -29,7 +29,8 @@ func main() {
 	defer database.CloseDB()
 
 	// 3. Setup Fiber
-	app := fiber.New()
+	// The body limit covers the largest uploads: exercise media and health app export archives.
+	app := fiber.New(fiber.Config{BodyLimit: 100 * 1024 * 1024})
 
 	// Middleware
 	app.Use(cors.New())
@@ -23,6 +23,14 @@ type Config struct {
 	DefaultUserRole      string
 	DefaultCoachEmail    string
 	DefaultCoachPassword string
+	DefaultAdminEmail    string
+	DefaultAdminPassword string
+	PaymentWebhookSecret string
+	ChatBroker           string
+	// ModerationContactAction is what chat moderation does with phone numbers and email addresses:
+	// flag, mask, block, or off.
+	ModerationContactAction string
+	ModerationRulesFile     string
 }
 
 func LoadConfig() (*Config, error) {
@@ -35,6 +43,18 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("JWT_SECRET is required")
 	}
 
+	chatBroker := strings.ToLower(strings.TrimSpace(getEnv("CHAT_BROKER", "postgres")))
+	if chatBroker != "postgres" && chatBroker != "memory" {
+		return nil, fmt.Errorf("CHAT_BROKER must be postgres or memory")
+	}
+
+	contactAction := strings.ToLower(strings.TrimSpace(getEnv("CHAT_MODERATION_CONTACT_ACTION", "mask")))
+	switch contactAction {
+	case "flag", "mask", "block", "off":
+	default:
+		return nil, fmt.Errorf("CHAT_MODERATION_CONTACT_ACTION must be flag, mask, block, or off")
+	}
+
 	return &Config{
 		Port:                 getEnv("PORT", "8080"),
 		DBUrl:                getEnv("DB_URL", ""),
@@ -49,6 +69,13 @@ func LoadConfig() (*Config, error) {
 		DefaultUserRole:      getEnv("DEFAULT_USER_ROLE", ""),
 		DefaultCoachEmail:    getEnv("DEFAULT_COACH_EMAIL", ""),
 		DefaultCoachPassword: getEnv("DEFAULT_COACH_PASSWORD", ""),
+		DefaultAdminEmail:    getEnv("DEFAULT_ADMIN_EMAIL", ""),
+		DefaultAdminPassword: getEnv("DEFAULT_ADMIN_PASSWORD", ""),
+		PaymentWebhookSecret: getEnv("PAYMENT_WEBHOOK_SECRET", ""),
+		ChatBroker:           chatBroker,
+
+		ModerationContactAction: contactAction,
+		ModerationRulesFile:     strings.TrimSpace(getEnv("CHAT_MODERATION_RULES_FILE", "")),
 	}, nil
 }
 
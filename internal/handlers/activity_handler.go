@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+// maxActivityFileSizeBytes leaves room for full Apple Health export archives.
+const maxActivityFileSizeBytes = 100 * 1024 * 1024
+
+type activityApplicationService interface {
+	ImportFile(ctx context.Context, userID int64, upload services.ActivityUpload) (*models.ActivityImport, error)
+	ListImports(ctx context.Context, userID int64, limit int, offset int) ([]models.ActivityImport, int, error)
+	ListActivities(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		filter repository.ActivityFilter,
+	) ([]models.Activity, int, error)
+	GetActivity(ctx context.Context, actorID int64, role string, activityID int64) (*models.Activity, error)
+	DeleteActivity(ctx context.Context, userID int64, activityID int64) error
+}
+
+type ActivityHandler struct {
+	service activityApplicationService
+}
+
+func NewActivityHandler(service activityApplicationService) *ActivityHandler {
+	return &ActivityHandler{service: service}
+}
+
+func (h *ActivityHandler) ImportFile(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+	}
+	if fileHeader.Size <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is empty"})
+	}
+	if fileHeader.Size > maxActivityFileSizeBytes {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file exceeds 100MB limit"})
+	}
+	switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+	case ".gpx", ".tcx", ".fit", ".xml", ".zip":
+	default:
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "file must be a gpx, tcx, fit, xml, or zip file"})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to open activity file"})
+	}
+	defer file.Close()
+
+	result, err := h.service.ImportFile(c.Context(), userID, services.ActivityUpload{
+		File:     file,
+		Filename: fileHeader.Filename,
+		Size:     fileHeader.Size,
+	})
+	if err != nil {
+		return mapActivityError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"import": result})
+}
+
+func (h *ActivityHandler) ListImports(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	page := parsePositiveInt(c.Query("page"), 1)
+	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	imports, total, err := h.service.ListImports(c.Context(), userID, limit, (page-1)*limit)
+	if err != nil {
+		return mapActivityError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"imports":    imports,
+		"pagination": buildPaginationMeta(page, limit, total),
+	})
+}
+
+func (h *ActivityHandler) ListActivities(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	userID, errMessage := parseBodyMetricSubject(c, role)
+	if errMessage != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errMessage})
+	}
+	from, err := parseQueryTimestamp(c.Query("from"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from must be a valid RFC3339 timestamp"})
+	}
+	to, err := parseQueryTimestamp(c.Query("to"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "to must be a valid RFC3339 timestamp"})
+	}
+
+	page := parsePositiveInt(c.Query("page"), 1)
+	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	filter := repository.ActivityFilter{
+		UserID:       userID,
+		ActivityType: strings.ToLower(strings.TrimSpace(c.Query("type"))),
+		From:         from,
+		To:           to,
+		Limit:        limit,
+		Offset:       (page - 1) * limit,
+	}
+	activities, total, err := h.service.ListActivities(c.Context(), actorID, role, filter)
+	if err != nil {
+		return mapActivityError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"activities": activities,
+		"pagination": buildPaginationMeta(page, limit, total),
+	})
+}
+
+func (h *ActivityHandler) GetActivity(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	activityID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || activityID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid activity id"})
+	}
+
+	activity, err := h.service.GetActivity(c.Context(), actorID, role, activityID)
+	if err != nil {
+		return mapActivityError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"activity": activity})
+}
+
+func (h *ActivityHandler) DeleteActivity(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	activityID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || activityID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid activity id"})
+	}
+
+	if err := h.service.DeleteActivity(c.Context(), userID, activityID); err != nil {
+		return mapActivityError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func mapActivityError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, services.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "This file has already been imported"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Activity not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process activity request"})
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubActivityService struct {
+	importErr  error
+	lastUpload services.ActivityUpload
+	lastFilter repository.ActivityFilter
+}
+
+func (s *stubActivityService) ImportFile(
+	_ context.Context,
+	userID int64,
+	upload services.ActivityUpload,
+) (*models.ActivityImport, error) {
+	s.lastUpload = upload
+	if s.importErr != nil {
+		return nil, s.importErr
+	}
+	return &models.ActivityImport{ID: 1, UserID: userID, Filename: upload.Filename}, nil
+}
+
+func (s *stubActivityService) ListImports(_ context.Context, _ int64, _ int, _ int) ([]models.ActivityImport, int, error) {
+	return []models.ActivityImport{}, 0, nil
+}
+
+func (s *stubActivityService) ListActivities(
+	_ context.Context,
+	_ int64,
+	_ string,
+	filter repository.ActivityFilter,
+) ([]models.Activity, int, error) {
+	s.lastFilter = filter
+	return []models.Activity{}, 0, nil
+}
+
+func (s *stubActivityService) GetActivity(_ context.Context, _ int64, _ string, activityID int64) (*models.Activity, error) {
+	return &models.Activity{ID: activityID}, nil
+}
+
+func (s *stubActivityService) DeleteActivity(_ context.Context, _ int64, _ int64) error {
+	return nil
+}
+
+func newActivityTestApp(service *stubActivityService, role string) *fiber.App {
+	handler := NewActivityHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Post("/api/v1/activities/imports", handler.ImportFile)
+	app.Get("/api/v1/activities", handler.ListActivities)
+	app.Get("/api/v1/activities/:id", handler.GetActivity)
+	return app
+}
+
+func TestImportActivityFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		filename   string
+		importErr  error
+		wantStatus int
+	}{
+		{name: "gpx file", role: "user", filename: "morning-run.gpx", wantStatus: http.StatusCreated},
+		{name: "health export archive", role: "user", filename: "export.zip", wantStatus: http.StatusCreated},
+		{name: "coach cannot import", role: "coach", filename: "ride.fit", wantStatus: http.StatusForbidden},
+		{name: "unsupported extension", role: "user", filename: "notes.csv", wantStatus: http.StatusBadRequest},
+		{name: "already imported", role: "user", filename: "ride.tcx", importErr: services.ErrConflict, wantStatus: http.StatusConflict},
+		{name: "unreadable file", role: "user", filename: "ride.fit", importErr: services.ErrInvalidInput, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, err := writer.CreateFormFile("file", tt.filename)
+			if err != nil {
+				t.Fatalf("create form file: %v", err)
+			}
+			_, _ = part.Write([]byte("activity data"))
+			writer.Close()
+
+			service := &stubActivityService{importErr: tt.importErr}
+			app := newActivityTestApp(service, tt.role)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/activities/imports", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus == http.StatusCreated && service.lastUpload.Size != int64(len("activity data")) {
+				t.Fatalf("unexpected upload: %+v", service.lastUpload)
+			}
+		})
+	}
+}
+
+func TestActivityQueries(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		target     string
+		wantStatus int
+	}{
+		{name: "user lists own activities", role: "user", target: "/api/v1/activities?type=Run&limit=5", wantStatus: http.StatusOK},
+		{name: "coach needs client", role: "coach", target: "/api/v1/activities", wantStatus: http.StatusBadRequest},
+		{name: "coach lists client", role: "coach", target: "/api/v1/activities?user_id=7", wantStatus: http.StatusOK},
+		{name: "bad from", role: "user", target: "/api/v1/activities?from=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "get activity", role: "coach", target: "/api/v1/activities/3", wantStatus: http.StatusOK},
+		{name: "bad activity id", role: "user", target: "/api/v1/activities/abc", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubActivityService{}
+			app := newActivityTestApp(service, tt.role)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.name == "user lists own activities" && (service.lastFilter.ActivityType != "run" || service.lastFilter.Limit != 5) {
+				t.Fatalf("unexpected filter: %+v", service.lastFilter)
+			}
+		})
+	}
+}
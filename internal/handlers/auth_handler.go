@@ -211,6 +211,17 @@ func (h *AuthHandler) Me(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user"})
 	}
 
+	// Admins are provisioned from config and have no profile.
+	if role == "admin" {
+		return c.JSON(fiber.Map{
+			"user": fiber.Map{
+				"id":    user.ID,
+				"email": user.Email,
+				"role":  user.Role,
+			},
+		})
+	}
+
 	if role == "user" {
 		profile, err := h.userProfileRepo.GetByUserID(c.Context(), userID)
 		if err != nil {
@@ -0,0 +1,487 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+const maxProgressPhotoSizeBytes = 10 * 1024 * 1024
+
+type bodyMetricApplicationService interface {
+	CreateMeasurement(
+		ctx context.Context,
+		userID int64,
+		input repository.BodyMeasurementInput,
+	) (*models.BodyMeasurement, error)
+	UpdateMeasurement(
+		ctx context.Context,
+		userID int64,
+		measurementID int64,
+		input repository.BodyMeasurementInput,
+	) (*models.BodyMeasurement, error)
+	DeleteMeasurement(ctx context.Context, userID int64, measurementID int64) error
+	ListMeasurements(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		filter repository.BodyMetricFilter,
+	) ([]models.BodyMeasurement, int, error)
+	GetTrend(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		userID int64,
+		metric string,
+		windowDays int,
+		from *time.Time,
+		to *time.Time,
+	) (*models.BodyMetricTrend, error)
+	UploadPhoto(ctx context.Context, userID int64, upload services.ProgressPhotoUpload) (*models.ProgressPhoto, error)
+	ListPhotos(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		filter repository.BodyMetricFilter,
+	) ([]models.ProgressPhoto, int, error)
+	UpdatePhoto(
+		ctx context.Context,
+		userID int64,
+		photoID int64,
+		pose string,
+		visibility string,
+	) (*models.ProgressPhoto, error)
+	DeletePhoto(ctx context.Context, userID int64, photoID int64) error
+}
+
+type BodyMetricHandler struct {
+	service bodyMetricApplicationService
+}
+
+type bodyMeasurementRequest struct {
+	MeasuredAt       *string  `json:"measured_at"`
+	WeightKg         *float64 `json:"weight_kg"`
+	BodyFatPct       *float64 `json:"body_fat_pct"`
+	ChestCm          *float64 `json:"chest_cm"`
+	WaistCm          *float64 `json:"waist_cm"`
+	HipsCm           *float64 `json:"hips_cm"`
+	ArmCm            *float64 `json:"arm_cm"`
+	ThighCm          *float64 `json:"thigh_cm"`
+	RestingHeartRate *int     `json:"resting_heart_rate"`
+	Notes            *string  `json:"notes"`
+	Visibility       string   `json:"visibility"`
+}
+
+type updateProgressPhotoRequest struct {
+	Pose       string `json:"pose"`
+	Visibility string `json:"visibility"`
+}
+
+func NewBodyMetricHandler(service bodyMetricApplicationService) *BodyMetricHandler {
+	return &BodyMetricHandler{service: service}
+}
+
+func (h *BodyMetricHandler) CreateMeasurement(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	input, errMessage := parseBodyMeasurementRequest(c)
+	if errMessage != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errMessage})
+	}
+
+	measurement, err := h.service.CreateMeasurement(c.Context(), userID, input)
+	if err != nil {
+		return mapBodyMetricError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"measurement": measurement})
+}
+
+func (h *BodyMetricHandler) UpdateMeasurement(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	measurementID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || measurementID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid measurement id"})
+	}
+
+	input, errMessage := parseBodyMeasurementRequest(c)
+	if errMessage != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errMessage})
+	}
+
+	measurement, err := h.service.UpdateMeasurement(c.Context(), userID, measurementID, input)
+	if err != nil {
+		return mapBodyMetricError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"measurement": measurement})
+}
+
+func (h *BodyMetricHandler) DeleteMeasurement(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	measurementID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || measurementID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid measurement id"})
+	}
+
+	if err := h.service.DeleteMeasurement(c.Context(), userID, measurementID); err != nil {
+		return mapBodyMetricError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *BodyMetricHandler) ListMeasurements(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	filter, page, errMessage := parseBodyMetricFilter(c, role)
+	if errMessage != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errMessage})
+	}
+
+	measurements, total, err := h.service.ListMeasurements(c.Context(), actorID, role, filter)
+	if err != nil {
+		return mapBodyMetricError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"measurements": measurements,
+		"pagination":   buildPaginationMeta(page, filter.Limit, total),
+	})
+}
+
+func (h *BodyMetricHandler) GetTrend(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	userID, errMessage := parseBodyMetricSubject(c, role)
+	if errMessage != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errMessage})
+	}
+
+	metric := strings.ToLower(strings.TrimSpace(c.Query("metric", "weight_kg")))
+	if !repository.IsBodyMetric(metric) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unsupported metric"})
+	}
+
+	windowDays := 0
+	if raw := strings.TrimSpace(c.Query("window")); raw != "" {
+		windowDays, err = strconv.Atoi(raw)
+		if err != nil || windowDays < 1 || windowDays > 90 {
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "window must be between 1 and 90 days"})
+		}
+	}
+
+	from, err := parseQueryTimestamp(c.Query("from"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from must be a valid RFC3339 timestamp"})
+	}
+	to, err := parseQueryTimestamp(c.Query("to"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "to must be a valid RFC3339 timestamp"})
+	}
+
+	trend, err := h.service.GetTrend(c.Context(), actorID, role, userID, metric, windowDays, from, to)
+	if err != nil {
+		return mapBodyMetricError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"trend": trend})
+}
+
+func (h *BodyMetricHandler) UploadPhoto(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	fileHeader, err := c.FormFile("photo")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "photo file is required"})
+	}
+	if fileHeader.Size <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "photo file is empty"})
+	}
+	if fileHeader.Size > maxProgressPhotoSizeBytes {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "photo file exceeds 10MB limit"})
+	}
+	switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+	case ".jpg", ".jpeg", ".png", ".webp", ".heic":
+	default:
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "photo must be a jpg, jpeg, png, webp, or heic file"})
+	}
+
+	input := repository.ProgressPhotoInput{
+		Pose:       strings.ToLower(strings.TrimSpace(c.FormValue("pose"))),
+		Visibility: c.FormValue("visibility"),
+	}
+	if raw := strings.TrimSpace(c.FormValue("taken_at")); raw != "" {
+		takenAt, err := parseOptionalTimestamp(&raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "taken_at must be a valid RFC3339 timestamp"})
+		}
+		input.TakenAt = *takenAt
+	}
+	if raw := strings.TrimSpace(c.FormValue("measurement_id")); raw != "" {
+		measurementID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || measurementID <= 0 {
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "measurement_id must be a positive integer"})
+		}
+		input.MeasurementID = &measurementID
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to open photo file"})
+	}
+	defer file.Close()
+
+	photo, err := h.service.UploadPhoto(c.Context(), userID, services.ProgressPhotoUpload{
+		File:     file,
+		Filename: fileHeader.Filename,
+		Input:    input,
+	})
+	if err != nil {
+		return mapBodyMetricError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"photo": photo})
+}
+
+func (h *BodyMetricHandler) ListPhotos(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	filter, page, errMessage := parseBodyMetricFilter(c, role)
+	if errMessage != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errMessage})
+	}
+
+	photos, total, err := h.service.ListPhotos(c.Context(), actorID, role, filter)
+	if err != nil {
+		return mapBodyMetricError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"photos":     photos,
+		"pagination": buildPaginationMeta(page, filter.Limit, total),
+	})
+}
+
+func (h *BodyMetricHandler) UpdatePhoto(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	photoID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || photoID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid photo id"})
+	}
+
+	var req updateProgressPhotoRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	photo, err := h.service.UpdatePhoto(
+		c.Context(),
+		userID,
+		photoID,
+		strings.ToLower(strings.TrimSpace(req.Pose)),
+		req.Visibility,
+	)
+	if err != nil {
+		return mapBodyMetricError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"photo": photo})
+}
+
+func (h *BodyMetricHandler) DeletePhoto(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	photoID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || photoID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid photo id"})
+	}
+
+	if err := h.service.DeletePhoto(c.Context(), userID, photoID); err != nil {
+		return mapBodyMetricError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func parseBodyMeasurementRequest(c *fiber.Ctx) (repository.BodyMeasurementInput, string) {
+	var req bodyMeasurementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return repository.BodyMeasurementInput{}, "Invalid request body"
+	}
+
+	measuredAt, err := parseOptionalTimestamp(req.MeasuredAt)
+	if err != nil {
+		return repository.BodyMeasurementInput{}, "measured_at must be a valid RFC3339 timestamp"
+	}
+
+	input := repository.BodyMeasurementInput{
+		WeightKg:         req.WeightKg,
+		BodyFatPct:       req.BodyFatPct,
+		ChestCm:          req.ChestCm,
+		WaistCm:          req.WaistCm,
+		HipsCm:           req.HipsCm,
+		ArmCm:            req.ArmCm,
+		ThighCm:          req.ThighCm,
+		RestingHeartRate: req.RestingHeartRate,
+		Notes:            req.Notes,
+		Visibility:       req.Visibility,
+	}
+	if measuredAt != nil {
+		input.MeasuredAt = *measuredAt
+	}
+	if input.WeightKg == nil && input.BodyFatPct == nil && input.ChestCm == nil && input.WaistCm == nil &&
+		input.HipsCm == nil && input.ArmCm == nil && input.ThighCm == nil && input.RestingHeartRate == nil {
+		return repository.BodyMeasurementInput{}, "At least one measurement is required"
+	}
+	return input, ""
+}
+
+// parseBodyMetricSubject reads user_id, which coaches must provide to pick a client.
+func parseBodyMetricSubject(c *fiber.Ctx, role string) (int64, string) {
+	var userID int64
+	if raw := strings.TrimSpace(c.Query("user_id")); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			return 0, "user_id must be a positive integer"
+		}
+		userID = parsed
+	}
+	if role == "coach" && userID == 0 {
+		return 0, "user_id is required"
+	}
+	return userID, ""
+}
+
+func parseBodyMetricFilter(c *fiber.Ctx, role string) (repository.BodyMetricFilter, int, string) {
+	userID, errMessage := parseBodyMetricSubject(c, role)
+	if errMessage != "" {
+		return repository.BodyMetricFilter{}, 0, errMessage
+	}
+
+	from, err := parseQueryTimestamp(c.Query("from"))
+	if err != nil {
+		return repository.BodyMetricFilter{}, 0, "from must be a valid RFC3339 timestamp"
+	}
+	to, err := parseQueryTimestamp(c.Query("to"))
+	if err != nil {
+		return repository.BodyMetricFilter{}, 0, "to must be a valid RFC3339 timestamp"
+	}
+
+	page := parsePositiveInt(c.Query("page"), 1)
+	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	return repository.BodyMetricFilter{
+		UserID: userID,
+		From:   from,
+		To:     to,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}, page, ""
+}
+
+func mapBodyMetricError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, services.ErrStorageUnavailable):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Storage service is not configured"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Measurement or photo not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process body metric request"})
+	}
+}
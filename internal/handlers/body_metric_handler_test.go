@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubBodyMetricService struct {
+	lastInput  repository.BodyMeasurementInput
+	lastMetric string
+	lastWindow int
+}
+
+func (s *stubBodyMetricService) CreateMeasurement(
+	_ context.Context,
+	userID int64,
+	input repository.BodyMeasurementInput,
+) (*models.BodyMeasurement, error) {
+	s.lastInput = input
+	return &models.BodyMeasurement{ID: 1, UserID: userID}, nil
+}
+
+func (s *stubBodyMetricService) UpdateMeasurement(
+	_ context.Context,
+	_ int64,
+	measurementID int64,
+	input repository.BodyMeasurementInput,
+) (*models.BodyMeasurement, error) {
+	s.lastInput = input
+	return &models.BodyMeasurement{ID: measurementID}, nil
+}
+
+func (s *stubBodyMetricService) DeleteMeasurement(_ context.Context, _ int64, _ int64) error {
+	return nil
+}
+
+func (s *stubBodyMetricService) ListMeasurements(
+	_ context.Context,
+	_ int64,
+	_ string,
+	_ repository.BodyMetricFilter,
+) ([]models.BodyMeasurement, int, error) {
+	return []models.BodyMeasurement{}, 0, nil
+}
+
+func (s *stubBodyMetricService) GetTrend(
+	_ context.Context,
+	_ int64,
+	_ string,
+	userID int64,
+	metric string,
+	windowDays int,
+	_ *time.Time,
+	_ *time.Time,
+) (*models.BodyMetricTrend, error) {
+	s.lastMetric = metric
+	s.lastWindow = windowDays
+	return &models.BodyMetricTrend{UserID: userID, Metric: metric}, nil
+}
+
+func (s *stubBodyMetricService) UploadPhoto(
+	_ context.Context,
+	_ int64,
+	_ services.ProgressPhotoUpload,
+) (*models.ProgressPhoto, error) {
+	return &models.ProgressPhoto{ID: 1}, nil
+}
+
+func (s *stubBodyMetricService) ListPhotos(
+	_ context.Context,
+	_ int64,
+	_ string,
+	_ repository.BodyMetricFilter,
+) ([]models.ProgressPhoto, int, error) {
+	return []models.ProgressPhoto{}, 0, nil
+}
+
+func (s *stubBodyMetricService) UpdatePhoto(
+	_ context.Context,
+	_ int64,
+	photoID int64,
+	_ string,
+	_ string,
+) (*models.ProgressPhoto, error) {
+	return &models.ProgressPhoto{ID: photoID}, nil
+}
+
+func (s *stubBodyMetricService) DeletePhoto(_ context.Context, _ int64, _ int64) error {
+	return nil
+}
+
+func newBodyMetricTestApp(service *stubBodyMetricService, role string) *fiber.App {
+	handler := NewBodyMetricHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Post("/api/v1/body-metrics", handler.CreateMeasurement)
+	app.Get("/api/v1/body-metrics", handler.ListMeasurements)
+	app.Get("/api/v1/body-metrics/trends", handler.GetTrend)
+	return app
+}
+
+func TestCreateBodyMeasurement(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "valid measurement",
+			role:       "user",
+			body:       `{"measured_at":"2030-03-05T07:00:00Z","weight_kg":81.2,"waist_cm":84,"visibility":"private"}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "coach cannot record",
+			role:       "coach",
+			body:       `{"weight_kg":81.2}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "empty measurement",
+			role:       "user",
+			body:       `{"notes":"felt good"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "bad timestamp",
+			role:       "user",
+			body:       `{"weight_kg":81.2,"measured_at":"this morning"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubBodyMetricService{}
+			app := newBodyMetricTestApp(service, tt.role)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/body-metrics", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			if service.lastInput.WeightKg == nil || service.lastInput.MeasuredAt.IsZero() ||
+				service.lastInput.Visibility != "private" {
+				t.Fatalf("unexpected input: %+v", service.lastInput)
+			}
+		})
+	}
+}
+
+func TestBodyMetricQueries(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		target     string
+		wantStatus int
+	}{
+		{name: "user lists own history", role: "user", target: "/api/v1/body-metrics?limit=5", wantStatus: http.StatusOK},
+		{name: "coach needs client", role: "coach", target: "/api/v1/body-metrics", wantStatus: http.StatusBadRequest},
+		{name: "coach lists client", role: "coach", target: "/api/v1/body-metrics?user_id=7", wantStatus: http.StatusOK},
+		{name: "trend defaults", role: "user", target: "/api/v1/body-metrics/trends", wantStatus: http.StatusOK},
+		{name: "unknown metric", role: "user", target: "/api/v1/body-metrics/trends?metric=height_cm", wantStatus: http.StatusBadRequest},
+		{name: "window too wide", role: "user", target: "/api/v1/body-metrics/trends?window=120", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubBodyMetricService{}
+			app := newBodyMetricTestApp(service, tt.role)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	service := &stubBodyMetricService{}
+	app := newBodyMetricTestApp(service, "coach")
+	resp, err := app.Test(httptest.NewRequest(
+		http.MethodGet,
+		"/api/v1/body-metrics/trends?user_id=7&metric=waist_cm&window=14",
+		nil,
+	))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	if service.lastMetric != "waist_cm" || service.lastWindow != 14 {
+		t.Fatalf("expected metric and window to be forwarded, got %q %d", service.lastMetric, service.lastWindow)
+	}
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type broadcastApplicationService interface {
+	CreateBroadcast(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		input repository.BroadcastInput,
+	) (*models.ChatBroadcast, error)
+	GetBroadcast(ctx context.Context, actorID int64, role string, broadcastID int64) (*models.ChatBroadcast, error)
+	ListBroadcasts(ctx context.Context, actorID int64, role string, limit int, offset int) ([]models.ChatBroadcast, int, error)
+	ListRecipients(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		broadcastID int64,
+		status string,
+		limit int,
+		offset int,
+	) ([]models.ChatBroadcastRecipient, int, error)
+}
+
+type BroadcastHandler struct {
+	service broadcastApplicationService
+}
+
+func NewBroadcastHandler(service broadcastApplicationService) *BroadcastHandler {
+	return &BroadcastHandler{service: service}
+}
+
+type createBroadcastRequest struct {
+	Content           string  `json:"content"`
+	Segment           string  `json:"segment"`
+	SessionsFrom      *string `json:"sessions_from"`
+	SessionsTo        *string `json:"sessions_to"`
+	ProgramTemplateID *int64  `json:"program_template_id"`
+}
+
+// CreateBroadcast queues a message to a segment of the coach's clients. It answers 202 because
+// delivery happens in the background; the broadcast reports its progress.
+func (h *BroadcastHandler) CreateBroadcast(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req createBroadcastRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	sessionsFrom, err := parseOptionalTimestamp(req.SessionsFrom)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sessions_from must be a valid RFC3339 timestamp"})
+	}
+	sessionsTo, err := parseOptionalTimestamp(req.SessionsTo)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sessions_to must be a valid RFC3339 timestamp"})
+	}
+
+	broadcast, err := h.service.CreateBroadcast(c.Context(), coachID, role, repository.BroadcastInput{
+		Content:           req.Content,
+		Segment:           strings.ToLower(strings.TrimSpace(req.Segment)),
+		SessionsFrom:      sessionsFrom,
+		SessionsTo:        sessionsTo,
+		ProgramTemplateID: req.ProgramTemplateID,
+	})
+	if err != nil {
+		return mapBroadcastError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"broadcast": broadcast})
+}
+
+func (h *BroadcastHandler) ListBroadcasts(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	page := parsePositiveInt(c.Query("page"), 1)
+	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	broadcasts, total, err := h.service.ListBroadcasts(c.Context(), coachID, role, limit, (page-1)*limit)
+	if err != nil {
+		return mapBroadcastError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"broadcasts": broadcasts,
+		"pagination": buildPaginationMeta(page, limit, total),
+	})
+}
+
+func (h *BroadcastHandler) GetBroadcast(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	broadcastID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || broadcastID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid broadcast id"})
+	}
+
+	broadcast, err := h.service.GetBroadcast(c.Context(), coachID, role, broadcastID)
+	if err != nil {
+		return mapBroadcastError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"broadcast": broadcast})
+}
+
+func (h *BroadcastHandler) ListRecipients(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	broadcastID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || broadcastID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid broadcast id"})
+	}
+
+	page := parsePositiveInt(c.Query("page"), 1)
+	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	status := strings.ToLower(strings.TrimSpace(c.Query("status")))
+
+	recipients, total, err := h.service.ListRecipients(
+		c.Context(),
+		coachID,
+		role,
+		broadcastID,
+		status,
+		limit,
+		(page-1)*limit,
+	)
+	if err != nil {
+		return mapBroadcastError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"recipients": recipients,
+		"pagination": buildPaginationMeta(page, limit, total),
+	})
+}
+
+func mapBroadcastError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrMessageBlocked):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "Message blocked by moderation"})
+	case errors.Is(err, services.ErrEmptySegment):
+		return c.Status(fiber.StatusUnprocessableEntity).
+			JSON(fiber.Map{"error": "No active clients match this segment"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Broadcast not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process broadcast request"})
+	}
+}
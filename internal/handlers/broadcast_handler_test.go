@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubBroadcastService struct {
+	createErr  error
+	lastInput  repository.BroadcastInput
+	lastStatus string
+}
+
+func (s *stubBroadcastService) CreateBroadcast(
+	_ context.Context,
+	actorID int64,
+	_ string,
+	input repository.BroadcastInput,
+) (*models.ChatBroadcast, error) {
+	s.lastInput = input
+	if s.createErr != nil {
+		return nil, s.createErr
+	}
+	return &models.ChatBroadcast{ID: 1, CoachID: actorID, Segment: input.Segment, Status: "pending"}, nil
+}
+
+func (s *stubBroadcastService) GetBroadcast(_ context.Context, _ int64, _ string, broadcastID int64) (*models.ChatBroadcast, error) {
+	return &models.ChatBroadcast{ID: broadcastID}, nil
+}
+
+func (s *stubBroadcastService) ListBroadcasts(_ context.Context, _ int64, _ string, _ int, _ int) ([]models.ChatBroadcast, int, error) {
+	return []models.ChatBroadcast{}, 0, nil
+}
+
+func (s *stubBroadcastService) ListRecipients(
+	_ context.Context,
+	_ int64,
+	_ string,
+	_ int64,
+	status string,
+	_ int,
+	_ int,
+) ([]models.ChatBroadcastRecipient, int, error) {
+	s.lastStatus = status
+	return []models.ChatBroadcastRecipient{}, 0, nil
+}
+
+func newBroadcastTestApp(service *stubBroadcastService, role string) *fiber.App {
+	handler := NewBroadcastHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "7")
+		return c.Next()
+	})
+	app.Post("/api/v1/broadcasts", handler.CreateBroadcast)
+	app.Get("/api/v1/broadcasts/:id/recipients", handler.ListRecipients)
+	return app
+}
+
+func TestCreateBroadcast(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		body       string
+		createErr  error
+		wantStatus int
+	}{
+		{name: "active clients", role: "coach", body: `{"content":"Gym closed Friday","segment":"active_clients"}`, wantStatus: http.StatusAccepted},
+		{name: "session range", role: "coach", body: `{"content":"See you","segment":"session_range","sessions_from":"2026-03-01T00:00:00Z","sessions_to":"2026-03-08T00:00:00Z"}`, wantStatus: http.StatusAccepted},
+		{name: "client cannot broadcast", role: "user", body: `{"content":"Hi","segment":"active_clients"}`, wantStatus: http.StatusForbidden},
+		{name: "bad sessions_from", role: "coach", body: `{"content":"Hi","segment":"session_range","sessions_from":"monday"}`, wantStatus: http.StatusBadRequest},
+		{name: "empty segment", role: "coach", body: `{"content":"Hi","segment":"program","program_template_id":3}`, createErr: services.ErrEmptySegment, wantStatus: http.StatusUnprocessableEntity},
+		{name: "invalid input", role: "coach", body: `{"content":"","segment":"active_clients"}`, createErr: services.ErrInvalidInput, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubBroadcastService{createErr: tt.createErr}
+			app := newBroadcastTestApp(service, tt.role)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/broadcasts", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.name == "session range" && (service.lastInput.SessionsFrom == nil || service.lastInput.SessionsTo == nil) {
+				t.Fatalf("session range not passed through: %+v", service.lastInput)
+			}
+		})
+	}
+}
+
+func TestListBroadcastRecipientsFiltersByStatus(t *testing.T) {
+	service := &stubBroadcastService{}
+	app := newBroadcastTestApp(service, "coach")
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/broadcasts/4/recipients?status=Failed", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || service.lastStatus != "failed" {
+		t.Fatalf("expected 200 with failed filter, got %d %q", resp.StatusCode, service.lastStatus)
+	}
+}
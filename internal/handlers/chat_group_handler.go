@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type createGroupRequest struct {
+	Title     string  `json:"title"`
+	MemberIDs []int64 `json:"member_ids"`
+}
+
+type addGroupMembersRequest struct {
+	UserIDs []int64 `json:"user_ids"`
+}
+
+type groupMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// CreateGroup opens a group conversation for a coach and some of their clients.
+func (h *ChatHandler) CreateGroup(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req createGroupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	conversation, change, err := h.service.CreateGroup(c.Context(), userID, role, req.Title, req.MemberIDs)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	h.deliverMembership(change)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"conversation": conversation})
+}
+
+func (h *ChatHandler) ListGroupMembers(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+
+	members, err := h.service.ListGroupMembers(c.Context(), userID, role, conversationID)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"members": members})
+}
+
+func (h *ChatHandler) AddGroupMembers(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+
+	var req addGroupMembersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	change, err := h.service.AddGroupMembers(c.Context(), userID, role, conversationID, req.UserIDs)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	h.deliverMembership(change)
+
+	members, err := h.service.ListGroupMembers(c.Context(), userID, role, conversationID)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"members": members})
+}
+
+func (h *ChatHandler) UpdateGroupMember(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+	memberID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil || memberID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user id"})
+	}
+
+	var req groupMemberRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	member, err := h.service.SetGroupMemberRole(c.Context(), userID, role, conversationID, memberID, req.Role)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"member": member})
+}
+
+// RemoveGroupMember removes a member from a group. Members leave by removing themselves.
+func (h *ChatHandler) RemoveGroupMember(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+	memberID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil || memberID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user id"})
+	}
+
+	change, err := h.service.RemoveGroupMember(c.Context(), userID, role, conversationID, memberID)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	h.deliverMembership(change)
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// deliverMembership announces joins and leaves. The change is stored either way; clients that miss
+// the frames see the new member list when they reload the group.
+func (h *ChatHandler) deliverMembership(change *services.MembershipChange) {
+	if change == nil {
+		return
+	}
+	if err := h.hub.DeliverMembership(change); err != nil {
+		log.Printf("chat membership publish: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"errors"
+	"log"
 	"strconv"
 	"strings"
 
@@ -17,19 +18,122 @@ import (
 
 type chatApplicationService interface {
 	ListConversations(ctx context.Context, actorID int64, role string) ([]models.ConversationSummary, error)
-	CreateConversation(ctx context.Context, actorID int64, role string, coachID int64) (*models.Conversation, error)
+	CreateConversation(ctx context.Context, actorID int64, role string, participantID int64) (*models.Conversation, error)
 	ListMessages(ctx context.Context, actorID int64, role string, conversationID int64, page int, limit int) ([]models.ChatMessage, int, error)
+	ListMessagesByCursor(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		conversationID int64,
+		cursor services.MessageCursor,
+		limit int,
+	) ([]models.ChatMessage, bool, error)
+	SearchMessages(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		query string,
+		conversationID int64,
+		beforeID int64,
+		limit int,
+	) ([]models.ChatSearchResult, bool, error)
 	SendMessage(ctx context.Context, actorID int64, role string, conversationID int64, content string) (*services.ChatDelivery, error)
+	MarkMessagesRead(ctx context.Context, actorID int64, role string, conversationID int64, messageIDs []int64) (*services.ReadReceipt, error)
+	ConversationPeers(ctx context.Context, actorID int64, role string, conversationID int64) ([]int64, error)
+	SendAttachment(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		conversationID int64,
+		upload services.ChatAttachmentUpload,
+	) (*services.ChatDelivery, error)
+	GetAttachment(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		conversationID int64,
+		attachmentID int64,
+	) (*models.MessageAttachment, error)
+	EditMessage(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		conversationID int64,
+		messageID int64,
+		content string,
+	) (*services.ChatDelivery, error)
+	DeleteMessage(ctx context.Context, actorID int64, role string, conversationID int64, messageID int64) (*services.ChatDelivery, error)
+	ListMessageEdits(ctx context.Context, actorID int64, role string, conversationID int64, messageID int64) ([]models.MessageEdit, error)
+	SetReaction(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		conversationID int64,
+		messageID int64,
+		emoji string,
+	) (*services.ReactionChange, error)
+	RemoveReaction(ctx context.Context, actorID int64, role string, conversationID int64, messageID int64) (*services.ReactionChange, error)
+	CreateGroup(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		title string,
+		memberIDs []int64,
+	) (*models.Conversation, *services.MembershipChange, error)
+	ListGroupMembers(ctx context.Context, actorID int64, role string, conversationID int64) ([]models.ConversationMember, error)
+	AddGroupMembers(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		conversationID int64,
+		userIDs []int64,
+	) (*services.MembershipChange, error)
+	RemoveGroupMember(ctx context.Context, actorID int64, role string, conversationID int64, userID int64) (*services.MembershipChange, error)
+	SetGroupMemberRole(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		conversationID int64,
+		userID int64,
+		memberRole string,
+	) (*models.ConversationMember, error)
+	ReportConversation(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		conversationID int64,
+		messageID *int64,
+		reason string,
+		details string,
+	) (*models.ModerationItem, error)
+	BlockUser(ctx context.Context, actorID int64, role string, userID int64) error
+	UnblockUser(ctx context.Context, actorID int64, role string, userID int64) error
+	ListBlockedUsers(ctx context.Context, actorID int64, role string) ([]models.UserBlock, error)
 }
 
+// maxChatAttachmentSizeBytes is the largest per-type limit; the service applies the exact one.
+const maxChatAttachmentSizeBytes = 100 * 1024 * 1024
+
+const defaultChatSearchLimit = 20
+
 type ChatHandler struct {
 	service   chatApplicationService
 	hub       *chatws.Hub
 	jwtSecret string
 }
 
+// createConversationRequest names the other participant: coach_id for clients, user_id for coaches.
 type createConversationRequest struct {
 	CoachID int64 `json:"coach_id"`
+	UserID  int64 `json:"user_id"`
+}
+
+type editMessageRequest struct {
+	Content string `json:"content"`
+}
+
+type reactionRequest struct {
+	Emoji string `json:"emoji"`
 }
 
 func NewChatHandler(service chatApplicationService, hub *chatws.Hub, jwtSecret string) *ChatHandler {
@@ -61,7 +165,7 @@ func (h *ChatHandler) ListConversations(c *fiber.Ctx) error {
 
 func (h *ChatHandler) CreateConversation(c *fiber.Ctx) error {
 	role, ok := c.Locals("role").(string)
-	if !ok || role != "user" {
+	if !ok || (role != "user" && role != "coach") {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
 	}
 
@@ -75,7 +179,12 @@ func (h *ChatHandler) CreateConversation(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	conversation, err := h.service.CreateConversation(c.Context(), userID, role, req.CoachID)
+	participantID := req.CoachID
+	if role == "coach" {
+		participantID = req.UserID
+	}
+
+	conversation, err := h.service.CreateConversation(c.Context(), userID, role, participantID)
 	if err != nil {
 		return mapChatError(c, err)
 	}
@@ -99,23 +208,370 @@ func (h *ChatHandler) GetMessages(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
 	}
 
-	page := parsePositiveInt(c.Query("page"), 1)
 	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
 	if limit > maxPageLimit {
 		limit = maxPageLimit
 	}
 
-	messages, total, err := h.service.ListMessages(c.Context(), userID, role, conversationID, page, limit)
+	// Offset pages are kept for older clients; without page, history is paged by message id.
+	if c.Query("page") != "" {
+		page := parsePositiveInt(c.Query("page"), 1)
+		messages, total, err := h.service.ListMessages(c.Context(), userID, role, conversationID, page, limit)
+		if err != nil {
+			return mapChatError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"messages":   messages,
+			"pagination": buildPaginationMeta(page, limit, total),
+		})
+	}
+
+	var cursor services.MessageCursor
+	if cursor.Before, err = parseOptionalID(c.Query("before")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid before id"})
+	}
+	if cursor.After, err = parseOptionalID(c.Query("after")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid after id"})
+	}
+	if cursor.Before > 0 && cursor.After > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Use either before or after, not both"})
+	}
+
+	messages, hasMore, err := h.service.ListMessagesByCursor(c.Context(), userID, role, conversationID, cursor, limit)
 	if err != nil {
 		return mapChatError(c, err)
 	}
 
+	pageInfo := models.MessagePageInfo{HasMore: hasMore}
+	if len(messages) > 0 {
+		pageInfo.After = &messages[0].ID
+		pageInfo.Before = &messages[len(messages)-1].ID
+	}
+
 	return c.JSON(fiber.Map{
-		"messages":   messages,
-		"pagination": buildPaginationMeta(page, limit, total),
+		"messages":  messages,
+		"page_info": pageInfo,
 	})
 }
 
+func (h *ChatHandler) SearchMessages(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "q is required"})
+	}
+	conversationID, err := parseOptionalID(c.Query("conversation_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+	beforeID, err := parseOptionalID(c.Query("before"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid before id"})
+	}
+	limit := parsePositiveInt(c.Query("limit"), defaultChatSearchLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	results, hasMore, err := h.service.SearchMessages(c.Context(), userID, role, query, conversationID, beforeID, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "q must be at most 200 characters"})
+		}
+		return mapChatError(c, err)
+	}
+
+	pageInfo := models.MessagePageInfo{HasMore: hasMore}
+	if len(results) > 0 {
+		pageInfo.Before = &results[len(results)-1].Message.ID
+	}
+
+	return c.JSON(fiber.Map{
+		"results":   results,
+		"page_info": pageInfo,
+	})
+}
+
+// parseOptionalID parses an optional positive id query parameter; an empty value is 0.
+func parseOptionalID(raw string) (int64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid id")
+	}
+	return id, nil
+}
+
+func (h *ChatHandler) SendAttachment(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+	}
+	if fileHeader.Size <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is empty"})
+	}
+	if fileHeader.Size > maxChatAttachmentSizeBytes {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file exceeds 100MB limit"})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to open attachment"})
+	}
+	defer file.Close()
+
+	delivery, err := h.service.SendAttachment(c.Context(), userID, role, conversationID, services.ChatAttachmentUpload{
+		File:     file,
+		Filename: fileHeader.Filename,
+		Size:     fileHeader.Size,
+		Caption:  c.FormValue("caption"),
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "file must be an image, video, voice note, pdf, zip, or text file"})
+		}
+		return mapChatError(c, err)
+	}
+
+	// The attachment is stored either way; clients that miss the frame see it in the history.
+	if err := h.hub.DeliverAttachment(delivery); err != nil {
+		log.Printf("chat attachment publish: %v", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": delivery.Message})
+}
+
+func (h *ChatHandler) GetAttachment(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+	attachmentID, err := strconv.ParseInt(c.Params("attachmentId"), 10, 64)
+	if err != nil || attachmentID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid attachment id"})
+	}
+
+	attachment, err := h.service.GetAttachment(c.Context(), userID, role, conversationID, attachmentID)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"attachment": attachment})
+}
+
+func (h *ChatHandler) EditMessage(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+	messageID, err := strconv.ParseInt(c.Params("messageId"), 10, 64)
+	if err != nil || messageID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid message id"})
+	}
+
+	var req editMessageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	delivery, err := h.service.EditMessage(c.Context(), userID, role, conversationID, messageID, req.Content)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	if err := h.hub.DeliverEdit(delivery); err != nil {
+		log.Printf("chat edit publish: %v", err)
+	}
+
+	return c.JSON(fiber.Map{"message": delivery.Message})
+}
+
+func (h *ChatHandler) DeleteMessage(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+	messageID, err := strconv.ParseInt(c.Params("messageId"), 10, 64)
+	if err != nil || messageID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid message id"})
+	}
+
+	delivery, err := h.service.DeleteMessage(c.Context(), userID, role, conversationID, messageID)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	if err := h.hub.DeliverDeletion(delivery); err != nil {
+		log.Printf("chat delete publish: %v", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *ChatHandler) ListMessageEdits(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+	messageID, err := strconv.ParseInt(c.Params("messageId"), 10, 64)
+	if err != nil || messageID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid message id"})
+	}
+
+	edits, err := h.service.ListMessageEdits(c.Context(), userID, role, conversationID, messageID)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"edits": edits})
+}
+
+func (h *ChatHandler) SetReaction(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+	messageID, err := strconv.ParseInt(c.Params("messageId"), 10, 64)
+	if err != nil || messageID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid message id"})
+	}
+
+	var req reactionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	change, err := h.service.SetReaction(c.Context(), userID, role, conversationID, messageID, req.Emoji)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "emoji must be a single emoji"})
+		}
+		return mapChatError(c, err)
+	}
+
+	if err := h.hub.DeliverReaction(change); err != nil {
+		log.Printf("chat reaction publish: %v", err)
+	}
+
+	return c.JSON(fiber.Map{"reaction": models.MessageReaction{
+		MessageID: change.MessageID,
+		UserID:    change.UserID,
+		Emoji:     change.Emoji,
+		CreatedAt: change.At,
+	}})
+}
+
+func (h *ChatHandler) RemoveReaction(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+	messageID, err := strconv.ParseInt(c.Params("messageId"), 10, 64)
+	if err != nil || messageID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid message id"})
+	}
+
+	change, err := h.service.RemoveReaction(c.Context(), userID, role, conversationID, messageID)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	if change != nil {
+		if err := h.hub.DeliverReaction(change); err != nil {
+			log.Printf("chat reaction publish: %v", err)
+		}
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
 func (h *ChatHandler) WebSocketAuth(c *fiber.Ctx) error {
 	if !websocket.IsWebSocketUpgrade(c) {
 		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{"error": "WebSocket upgrade required"})
@@ -136,6 +592,24 @@ func (h *ChatHandler) HandleWebSocket(conn *websocket.Conn) {
 	role, _ := conn.Locals("role").(string)
 	client := chatws.NewClient(h.hub, conn, userID)
 
+	// The client sees the presence of everyone it has a direct conversation with.
+	if actorID, err := strconv.ParseInt(userID, 10, 64); err == nil {
+		conversations, err := h.service.ListConversations(context.Background(), actorID, role)
+		if err != nil {
+			log.Printf("chat websocket list conversations: %v", err)
+		}
+		for _, conversation := range conversations {
+			if conversation.Kind == models.ConversationKindGroup {
+				continue
+			}
+			peerID := conversation.CoachID
+			if peerID == actorID {
+				peerID = conversation.UserID
+			}
+			client.Watch(strconv.FormatInt(peerID, 10))
+		}
+	}
+
 	h.hub.Register(client)
 	go client.WritePump()
 	client.ReadPump(h.service, role)
@@ -166,8 +640,30 @@ func mapChatError(c *fiber.Ctx, err error) error {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
 	case errors.Is(err, services.ErrInvalidInput):
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrAttachmentTooLarge):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrSubscriptionRequired):
+		return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrStorageUnavailable):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Storage service is not configured"})
+	case errors.Is(err, services.ErrMessageRemoved), errors.Is(err, services.ErrEditWindowExpired):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
 	case errors.Is(err, services.ErrCoachNotFound):
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Coach not found"})
+	case errors.Is(err, services.ErrMessageNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Message not found"})
+	case errors.Is(err, services.ErrMemberNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Member not found"})
+	case errors.Is(err, services.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	case errors.Is(err, services.ErrNotBlocked):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User is not blocked"})
+	case errors.Is(err, services.ErrUserBlocked):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Messaging is blocked between you and this user"})
+	case errors.Is(err, services.ErrMessageBlocked):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "Message blocked by moderation"})
+	case errors.Is(err, services.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "You have already reported this"})
 	case errors.Is(err, pgx.ErrNoRows):
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Conversation not found"})
 	default:
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -30,6 +32,28 @@ type stubChatService struct {
 	lastConversationID  int64
 	lastPage            int
 	lastLimit           int
+	attachmentErr       error
+	lastUpload          services.ChatAttachmentUpload
+	changeErr           error
+	lastMessageID       int64
+	lastContent         string
+	lastCursor          services.MessageCursor
+	hasMore             bool
+	searchResults       []models.ChatSearchResult
+	lastQuery           string
+	lastBeforeID        int64
+	lastTitle           string
+	lastMemberIDs       []int64
+	lastMemberID        int64
+	groupErr            error
+	lastReport          *stubReport
+	moderationErr       error
+}
+
+type stubReport struct {
+	messageID *int64
+	reason    string
+	details   string
 }
 
 func (s *stubChatService) ListConversations(_ context.Context, actorID int64, role string) ([]models.ConversationSummary, error) {
@@ -54,10 +78,261 @@ func (s *stubChatService) ListMessages(_ context.Context, actorID int64, role st
 	return s.messagesResult, s.messagesTotal, s.messagesErr
 }
 
+func (s *stubChatService) ListMessagesByCursor(
+	_ context.Context,
+	_ int64,
+	_ string,
+	conversationID int64,
+	cursor services.MessageCursor,
+	limit int,
+) ([]models.ChatMessage, bool, error) {
+	s.lastConversationID = conversationID
+	s.lastCursor = cursor
+	s.lastLimit = limit
+	return s.messagesResult, s.hasMore, s.messagesErr
+}
+
+func (s *stubChatService) SearchMessages(
+	_ context.Context,
+	_ int64,
+	_ string,
+	query string,
+	conversationID int64,
+	beforeID int64,
+	limit int,
+) ([]models.ChatSearchResult, bool, error) {
+	s.lastQuery = query
+	s.lastConversationID = conversationID
+	s.lastBeforeID = beforeID
+	s.lastLimit = limit
+	return s.searchResults, s.hasMore, nil
+}
+
 func (s *stubChatService) SendMessage(_ context.Context, _ int64, _ string, _ int64, _ string) (*services.ChatDelivery, error) {
 	return nil, nil
 }
 
+func (s *stubChatService) MarkMessagesRead(_ context.Context, _ int64, _ string, _ int64, _ []int64) (*services.ReadReceipt, error) {
+	return nil, nil
+}
+
+func (s *stubChatService) ConversationPeers(_ context.Context, _ int64, _ string, _ int64) ([]int64, error) {
+	return nil, nil
+}
+
+func (s *stubChatService) SendAttachment(
+	_ context.Context,
+	actorID int64,
+	_ string,
+	conversationID int64,
+	upload services.ChatAttachmentUpload,
+) (*services.ChatDelivery, error) {
+	s.lastConversationID = conversationID
+	s.lastUpload = upload
+	if s.attachmentErr != nil {
+		return nil, s.attachmentErr
+	}
+	return &services.ChatDelivery{
+		Message: &models.ChatMessage{
+			ID:             30,
+			ConversationID: conversationID,
+			SenderID:       actorID,
+			Content:        upload.Caption,
+			Attachments:    []models.MessageAttachment{{ID: 4, MessageID: 30, Kind: "image"}},
+		},
+		RecipientIDs: []int64{7},
+	}, nil
+}
+
+func (s *stubChatService) GetAttachment(
+	_ context.Context,
+	_ int64,
+	_ string,
+	_ int64,
+	attachmentID int64,
+) (*models.MessageAttachment, error) {
+	return &models.MessageAttachment{ID: attachmentID}, s.attachmentErr
+}
+
+func (s *stubChatService) EditMessage(
+	_ context.Context,
+	actorID int64,
+	_ string,
+	conversationID int64,
+	messageID int64,
+	content string,
+) (*services.ChatDelivery, error) {
+	s.lastMessageID = messageID
+	s.lastContent = content
+	if s.changeErr != nil {
+		return nil, s.changeErr
+	}
+	editedAt := time.Date(2026, 3, 1, 9, 5, 0, 0, time.UTC)
+	return &services.ChatDelivery{
+		Message: &models.ChatMessage{
+			ID:             messageID,
+			ConversationID: conversationID,
+			SenderID:       actorID,
+			Content:        content,
+			EditedAt:       &editedAt,
+		},
+		RecipientIDs: []int64{7},
+	}, nil
+}
+
+func (s *stubChatService) DeleteMessage(_ context.Context, actorID int64, _ string, conversationID int64, messageID int64) (*services.ChatDelivery, error) {
+	s.lastMessageID = messageID
+	if s.changeErr != nil {
+		return nil, s.changeErr
+	}
+	deletedAt := time.Now().UTC()
+	return &services.ChatDelivery{
+		Message:      &models.ChatMessage{ID: messageID, ConversationID: conversationID, SenderID: actorID, DeletedAt: &deletedAt},
+		RecipientIDs: []int64{7},
+	}, nil
+}
+
+func (s *stubChatService) ListMessageEdits(_ context.Context, _ int64, _ string, _ int64, messageID int64) ([]models.MessageEdit, error) {
+	s.lastMessageID = messageID
+	return []models.MessageEdit{{ID: 1, MessageID: messageID, Content: "Se you tomorow"}}, s.changeErr
+}
+
+func (s *stubChatService) SetReaction(
+	_ context.Context,
+	actorID int64,
+	_ string,
+	conversationID int64,
+	messageID int64,
+	emoji string,
+) (*services.ReactionChange, error) {
+	s.lastMessageID = messageID
+	s.lastContent = emoji
+	if s.changeErr != nil {
+		return nil, s.changeErr
+	}
+	return &services.ReactionChange{
+		ConversationID: conversationID,
+		MessageID:      messageID,
+		UserID:         actorID,
+		RecipientIDs:   []int64{7},
+		Emoji:          emoji,
+		At:             time.Now().UTC(),
+	}, nil
+}
+
+func (s *stubChatService) RemoveReaction(_ context.Context, _ int64, _ string, _ int64, messageID int64) (*services.ReactionChange, error) {
+	s.lastMessageID = messageID
+	return nil, s.changeErr
+}
+
+func (s *stubChatService) CreateGroup(
+	_ context.Context,
+	actorID int64,
+	_ string,
+	title string,
+	memberIDs []int64,
+) (*models.Conversation, *services.MembershipChange, error) {
+	s.lastTitle = title
+	s.lastMemberIDs = memberIDs
+	if s.groupErr != nil {
+		return nil, nil, s.groupErr
+	}
+	conversation := &models.Conversation{ID: 12, Kind: models.ConversationKindGroup, Title: title, CoachID: actorID}
+	return conversation, &services.MembershipChange{
+		ConversationID: conversation.ID,
+		ActorID:        actorID,
+		UserIDs:        memberIDs,
+		Joined:         true,
+		RecipientIDs:   memberIDs,
+		At:             time.Now().UTC(),
+	}, nil
+}
+
+func (s *stubChatService) ListGroupMembers(_ context.Context, _ int64, _ string, conversationID int64) ([]models.ConversationMember, error) {
+	s.lastConversationID = conversationID
+	return []models.ConversationMember{{ConversationID: conversationID, UserID: 7, Role: models.ConversationRoleAdmin}}, s.groupErr
+}
+
+func (s *stubChatService) AddGroupMembers(_ context.Context, _ int64, _ string, conversationID int64, userIDs []int64) (*services.MembershipChange, error) {
+	s.lastConversationID = conversationID
+	s.lastMemberIDs = userIDs
+	return nil, s.groupErr
+}
+
+func (s *stubChatService) RemoveGroupMember(_ context.Context, actorID int64, _ string, conversationID int64, userID int64) (*services.MembershipChange, error) {
+	s.lastConversationID = conversationID
+	s.lastMemberID = userID
+	if s.groupErr != nil {
+		return nil, s.groupErr
+	}
+	return &services.MembershipChange{
+		ConversationID: conversationID,
+		ActorID:        actorID,
+		UserIDs:        []int64{userID},
+		RecipientIDs:   []int64{7},
+		At:             time.Now().UTC(),
+	}, nil
+}
+
+func (s *stubChatService) SetGroupMemberRole(
+	_ context.Context,
+	_ int64,
+	_ string,
+	conversationID int64,
+	userID int64,
+	memberRole string,
+) (*models.ConversationMember, error) {
+	s.lastMemberID = userID
+	s.lastContent = memberRole
+	if s.groupErr != nil {
+		return nil, s.groupErr
+	}
+	return &models.ConversationMember{ConversationID: conversationID, UserID: userID, Role: memberRole}, nil
+}
+
+func (s *stubChatService) ReportConversation(
+	_ context.Context,
+	actorID int64,
+	_ string,
+	conversationID int64,
+	messageID *int64,
+	reason string,
+	details string,
+) (*models.ModerationItem, error) {
+	s.lastActorID = actorID
+	s.lastConversationID = conversationID
+	s.lastReport = &stubReport{messageID: messageID, reason: reason, details: details}
+	if s.moderationErr != nil {
+		return nil, s.moderationErr
+	}
+	return &models.ModerationItem{
+		ID:             1,
+		Source:         models.ModerationSourceReport,
+		ConversationID: conversationID,
+		MessageID:      messageID,
+		ReporterID:     &actorID,
+		Reason:         &reason,
+		Status:         services.ModerationStatusOpen,
+	}, nil
+}
+
+func (s *stubChatService) BlockUser(_ context.Context, actorID int64, _ string, userID int64) error {
+	s.lastActorID = actorID
+	s.lastMemberID = userID
+	return s.moderationErr
+}
+
+func (s *stubChatService) UnblockUser(_ context.Context, actorID int64, _ string, userID int64) error {
+	s.lastActorID = actorID
+	s.lastMemberID = userID
+	return s.moderationErr
+}
+
+func (s *stubChatService) ListBlockedUsers(_ context.Context, actorID int64, _ string) ([]models.UserBlock, error) {
+	s.lastActorID = actorID
+	return []models.UserBlock{{UserID: 9}}, s.moderationErr
+}
+
 func TestListConversationsReturnsConversationSummaries(t *testing.T) {
 	service := &stubChatService{
 		conversationsResult: []models.ConversationSummary{
@@ -74,7 +349,7 @@ func TestListConversationsReturnsConversationSummaries(t *testing.T) {
 			},
 		},
 	}
-	handler := NewChatHandler(service, chatws.NewHub(), "secret")
+	handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
 
 	app := fiber.New()
 	app.Use(func(c *fiber.Ctx) error {
@@ -113,7 +388,7 @@ func TestCreateConversationReturnsCreatedConversation(t *testing.T) {
 	service := &stubChatService{
 		createResult: &models.Conversation{ID: 9, UserID: 42, CoachID: 7},
 	}
-	handler := NewChatHandler(service, chatws.NewHub(), "secret")
+	handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
 
 	app := fiber.New()
 	app.Use(func(c *fiber.Ctx) error {
@@ -140,6 +415,37 @@ func TestCreateConversationReturnsCreatedConversation(t *testing.T) {
 	}
 }
 
+func TestCreateConversationAsCoachUsesClientID(t *testing.T) {
+	service := &stubChatService{
+		createResult: &models.Conversation{ID: 9, UserID: 42, CoachID: 7},
+	}
+	handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", "coach")
+		c.Locals("user_id", "7")
+		return c.Next()
+	})
+	app.Post("/api/v1/conversations", handler.CreateConversation)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/conversations", strings.NewReader(`{"user_id":42,"coach_id":3}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	if service.lastRole != "coach" || service.lastCoachID != 42 {
+		t.Fatalf("expected client 42 forwarded for coach, got role=%q id=%d", service.lastRole, service.lastCoachID)
+	}
+}
+
 func TestGetMessagesReturnsPagination(t *testing.T) {
 	service := &stubChatService{
 		messagesResult: []models.ChatMessage{
@@ -147,7 +453,7 @@ func TestGetMessagesReturnsPagination(t *testing.T) {
 		},
 		messagesTotal: 12,
 	}
-	handler := NewChatHandler(service, chatws.NewHub(), "secret")
+	handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
 
 	app := fiber.New()
 	app.Use(func(c *fiber.Ctx) error {
@@ -185,7 +491,7 @@ func TestGetMessagesReturnsPagination(t *testing.T) {
 
 func TestGetMessagesReturnsNotFound(t *testing.T) {
 	service := &stubChatService{messagesErr: pgx.ErrNoRows}
-	handler := NewChatHandler(service, chatws.NewHub(), "secret")
+	handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
 
 	app := fiber.New()
 	app.Use(func(c *fiber.Ctx) error {
@@ -206,3 +512,486 @@ func TestGetMessagesReturnsNotFound(t *testing.T) {
 		t.Fatalf("expected 404, got %d", resp.StatusCode)
 	}
 }
+
+func TestSendAttachment(t *testing.T) {
+	tests := []struct {
+		name       string
+		filename   string
+		err        error
+		wantStatus int
+	}{
+		{name: "image with caption", filename: "squat.jpg", wantStatus: http.StatusCreated},
+		{name: "unsupported file", filename: "setup.exe", err: services.ErrInvalidInput, wantStatus: http.StatusBadRequest},
+		{name: "too large for type", filename: "squat.png", err: services.ErrAttachmentTooLarge, wantStatus: http.StatusBadRequest},
+		{name: "not a participant", filename: "squat.jpg", err: services.ErrForbidden, wantStatus: http.StatusForbidden},
+		{name: "storage missing", filename: "squat.jpg", err: services.ErrStorageUnavailable, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, err := writer.CreateFormFile("file", tt.filename)
+			if err != nil {
+				t.Fatalf("create form file: %v", err)
+			}
+			_, _ = part.Write([]byte("attachment data"))
+			_ = writer.WriteField("caption", "Depth check")
+			writer.Close()
+
+			service := &stubChatService{attachmentErr: tt.err}
+			handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("role", "user")
+				c.Locals("user_id", "42")
+				return c.Next()
+			})
+			app.Post("/api/v1/conversations/:id/attachments", handler.SendAttachment)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/conversations/11/attachments", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if service.lastConversationID != 11 || service.lastUpload.Caption != "Depth check" || service.lastUpload.Size != int64(len("attachment data")) {
+				t.Fatalf("unexpected upload forwarded: conversation=%d upload=%+v", service.lastConversationID, service.lastUpload)
+			}
+		})
+	}
+}
+
+func TestEditMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "within the window", wantStatus: http.StatusOK},
+		{name: "window expired", err: services.ErrEditWindowExpired, wantStatus: http.StatusConflict},
+		{name: "removed message", err: services.ErrMessageRemoved, wantStatus: http.StatusConflict},
+		{name: "someone else's message", err: services.ErrForbidden, wantStatus: http.StatusForbidden},
+		{name: "unknown message", err: services.ErrMessageNotFound, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubChatService{changeErr: tt.err}
+			handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("role", "user")
+				c.Locals("user_id", "42")
+				return c.Next()
+			})
+			app.Patch("/api/v1/conversations/:id/messages/:messageId", handler.EditMessage)
+
+			req := httptest.NewRequest(
+				http.MethodPatch,
+				"/api/v1/conversations/11/messages/30",
+				strings.NewReader(`{"content":"See you tomorrow"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if service.lastMessageID != 30 || service.lastContent != "See you tomorrow" {
+				t.Fatalf("unexpected edit forwarded: message=%d content=%q", service.lastMessageID, service.lastContent)
+			}
+			if tt.err != nil {
+				return
+			}
+
+			var body struct {
+				Message models.ChatMessage `json:"message"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body.Message.EditedAt == nil || body.Message.Content != "See you tomorrow" {
+				t.Fatalf("unexpected edited message: %+v", body.Message)
+			}
+		})
+	}
+}
+
+func TestDeleteMessageReturnsNoContent(t *testing.T) {
+	service := &stubChatService{}
+	handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", "coach")
+		c.Locals("user_id", "7")
+		return c.Next()
+	})
+	app.Delete("/api/v1/conversations/:id/messages/:messageId", handler.DeleteMessage)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/conversations/11/messages/abc", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed id, got %d", resp.StatusCode)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/conversations/11/messages/30", nil)
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent || service.lastMessageID != 30 {
+		t.Fatalf("expected 204 for message 30, got %d for %d", resp.StatusCode, service.lastMessageID)
+	}
+}
+
+func TestSetReaction(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "emoji", wantStatus: http.StatusOK},
+		{name: "not an emoji", err: services.ErrInvalidInput, wantStatus: http.StatusBadRequest},
+		{name: "removed message", err: services.ErrMessageRemoved, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubChatService{changeErr: tt.err}
+			handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("role", "user")
+				c.Locals("user_id", "42")
+				return c.Next()
+			})
+			app.Put("/api/v1/conversations/:id/messages/:messageId/reaction", handler.SetReaction)
+
+			req := httptest.NewRequest(
+				http.MethodPut,
+				"/api/v1/conversations/11/messages/30/reaction",
+				strings.NewReader(`{"emoji":"💪"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if service.lastContent != "💪" {
+				t.Fatalf("expected emoji to be forwarded, got %q", service.lastContent)
+			}
+		})
+	}
+}
+
+func TestGetMessagesByCursor(t *testing.T) {
+	service := &stubChatService{
+		messagesResult: []models.ChatMessage{
+			{ID: 48, ConversationID: 11, SenderID: 7, Content: "Newer"},
+			{ID: 45, ConversationID: 11, SenderID: 42, Content: "Older"},
+		},
+		hasMore: true,
+	}
+	handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", "coach")
+		c.Locals("user_id", "7")
+		return c.Next()
+	})
+	app.Get("/api/v1/conversations/:id/messages", handler.GetMessages)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/conversations/11/messages?before=50&limit=2", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if service.lastCursor.Before != 50 || service.lastCursor.After != 0 || service.lastLimit != 2 {
+		t.Fatalf("unexpected cursor forwarded: %+v limit=%d", service.lastCursor, service.lastLimit)
+	}
+
+	var body struct {
+		Messages []models.ChatMessage   `json:"messages"`
+		PageInfo models.MessagePageInfo `json:"page_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !body.PageInfo.HasMore || body.PageInfo.Before == nil || *body.PageInfo.Before != 45 ||
+		body.PageInfo.After == nil || *body.PageInfo.After != 48 {
+		t.Fatalf("unexpected page info: %+v", body.PageInfo)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/conversations/11/messages?before=50&after=40", nil)
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for both cursors, got %d", resp.StatusCode)
+	}
+}
+
+func TestSearchMessages(t *testing.T) {
+	service := &stubChatService{
+		searchResults: []models.ChatSearchResult{
+			{Message: models.ChatMessage{ID: 30, ConversationID: 11, Content: "Squat depth looks good"}, Snippet: "<mark>Squat</mark> depth looks good"},
+		},
+	}
+	handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", "user")
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Get("/api/v1/conversations/search", handler.SearchMessages)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/conversations/search?q=squat&conversation_id=11&before=90", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if service.lastQuery != "squat" || service.lastConversationID != 11 || service.lastBeforeID != 90 || service.lastLimit != defaultChatSearchLimit {
+		t.Fatalf("unexpected search forwarded: %+v", service)
+	}
+
+	var body struct {
+		Results  []models.ChatSearchResult `json:"results"`
+		PageInfo models.MessagePageInfo    `json:"page_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(body.Results) != 1 || body.Results[0].Snippet == "" || body.PageInfo.HasMore || *body.PageInfo.Before != 30 {
+		t.Fatalf("unexpected response: %+v", body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/conversations/search?q=%20", nil)
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a query, got %d", resp.StatusCode)
+	}
+}
+
+func TestCreateGroup(t *testing.T) {
+	service := &stubChatService{}
+	handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
+
+	app := fiber.New()
+	role := "coach"
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "7")
+		return c.Next()
+	})
+	app.Post("/api/v1/conversations/groups", handler.CreateGroup)
+
+	send := func() *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/conversations/groups",
+			strings.NewReader(`{"title":"Spring challenge","member_ids":[42,43]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		return resp
+	}
+
+	resp := send()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	if service.lastTitle != "Spring challenge" || len(service.lastMemberIDs) != 2 {
+		t.Fatalf("unexpected group input: %q %v", service.lastTitle, service.lastMemberIDs)
+	}
+	var body struct {
+		Conversation models.Conversation `json:"conversation"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if body.Conversation.Kind != models.ConversationKindGroup || body.Conversation.CoachID != 7 {
+		t.Fatalf("unexpected conversation: %+v", body.Conversation)
+	}
+
+	role = "user"
+	if resp := send(); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for clients, got %d", resp.StatusCode)
+	}
+}
+
+func TestRemoveGroupMember(t *testing.T) {
+	service := &stubChatService{}
+	handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", "user")
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Delete("/api/v1/conversations/:id/members/:userId", handler.RemoveGroupMember)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/api/v1/conversations/12/members/42", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+	if service.lastConversationID != 12 || service.lastMemberID != 42 {
+		t.Fatalf("unexpected member removal: %d %d", service.lastConversationID, service.lastMemberID)
+	}
+
+	service.groupErr = services.ErrMemberNotFound
+	resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/api/v1/conversations/12/members/43", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/api/v1/conversations/12/members/abc", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestReportConversation(t *testing.T) {
+	service := &stubChatService{}
+	handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", "user")
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Post("/api/v1/conversations/:id/reports", handler.ReportConversation)
+
+	report := func(body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/conversations/12/reports", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		return resp
+	}
+
+	resp := report(`{"message_id":5,"reason":" Contact_Info ","details":"asked me to pay by bank transfer"}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	if service.lastConversationID != 12 || service.lastReport.messageID == nil || *service.lastReport.messageID != 5 {
+		t.Fatalf("unexpected report target: %d %+v", service.lastConversationID, service.lastReport)
+	}
+	if service.lastReport.reason != "contact_info" {
+		t.Fatalf("expected normalized reason, got %q", service.lastReport.reason)
+	}
+
+	service.moderationErr = services.ErrConflict
+	if resp := report(`{"reason":"spam"}`); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 for a duplicate report, got %d", resp.StatusCode)
+	}
+}
+
+func TestBlockAndUnblockUser(t *testing.T) {
+	service := &stubChatService{}
+	handler := NewChatHandler(service, chatws.NewHub(chatws.NewMemoryBroker(), nil), "secret")
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", "coach")
+		c.Locals("user_id", "8")
+		return c.Next()
+	})
+	app.Get("/api/v1/blocks", handler.ListBlockedUsers)
+	app.Put("/api/v1/blocks/:userId", handler.BlockUser)
+	app.Delete("/api/v1/blocks/:userId", handler.UnblockUser)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPut, "/api/v1/blocks/9", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+	if service.lastActorID != 8 || service.lastMemberID != 9 {
+		t.Fatalf("unexpected block: %d %d", service.lastActorID, service.lastMemberID)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/blocks", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	var body struct {
+		Blocks []models.UserBlock `json:"blocks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(body.Blocks) != 1 || body.Blocks[0].UserID != 9 {
+		t.Fatalf("unexpected blocks: %+v", body.Blocks)
+	}
+
+	service.moderationErr = services.ErrNotBlocked
+	resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/api/v1/blocks/10", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodPut, "/api/v1/blocks/abc", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+}
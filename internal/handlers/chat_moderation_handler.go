@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type reportConversationRequest struct {
+	MessageID *int64 `json:"message_id"`
+	Reason    string `json:"reason"`
+	Details   string `json:"details"`
+}
+
+// ReportConversation reports a conversation, or one message in it, to the moderation team.
+func (h *ChatHandler) ReportConversation(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+
+	var req reportConversationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	report, err := h.service.ReportConversation(
+		c.Context(),
+		userID,
+		role,
+		conversationID,
+		req.MessageID,
+		strings.ToLower(strings.TrimSpace(req.Reason)),
+		req.Details,
+	)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"report": report})
+}
+
+func (h *ChatHandler) ListBlockedUsers(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	blocks, err := h.service.ListBlockedUsers(c.Context(), userID, role)
+	if err != nil {
+		return mapChatError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"blocks": blocks})
+}
+
+// BlockUser stops another user from messaging the caller directly. Blocking twice is harmless.
+func (h *ChatHandler) BlockUser(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	blockedID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil || blockedID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user id"})
+	}
+
+	if err := h.service.BlockUser(c.Context(), userID, role, blockedID); err != nil {
+		return mapChatError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *ChatHandler) UnblockUser(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	blockedID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil || blockedID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user id"})
+	}
+
+	if err := h.service.UnblockUser(c.Context(), userID, role, blockedID); err != nil {
+		return mapChatError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,532 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+// checkInPhotoFieldPrefix prefixes the multipart field carrying a photo answer, e.g. photo_front.
+const checkInPhotoFieldPrefix = "photo_"
+
+type checkInApplicationService interface {
+	CreateForm(ctx context.Context, coachID int64, input repository.CheckInFormInput) (*models.CheckInForm, error)
+	ListForms(ctx context.Context, coachID int64) ([]models.CheckInForm, error)
+	GetForm(ctx context.Context, actorID int64, role string, formID int64) (*models.CheckInForm, error)
+	UpdateForm(
+		ctx context.Context,
+		coachID int64,
+		formID int64,
+		input repository.CheckInFormInput,
+	) (*models.CheckInForm, error)
+	DeleteForm(ctx context.Context, coachID int64, formID int64) error
+	AssignForm(
+		ctx context.Context,
+		coachID int64,
+		formID int64,
+		assignment services.CheckInAssignment,
+	) (*models.CheckInSchedule, error)
+	ListSchedules(ctx context.Context, actorID int64, role string, userID int64) ([]models.CheckInSchedule, error)
+	UpdateSchedule(
+		ctx context.Context,
+		coachID int64,
+		scheduleID int64,
+		update services.CheckInScheduleUpdate,
+	) (*models.CheckInSchedule, error)
+	DeleteSchedule(ctx context.Context, coachID int64, scheduleID int64) error
+	Submit(
+		ctx context.Context,
+		userID int64,
+		scheduleID int64,
+		input services.CheckInSubmissionInput,
+	) (*models.CheckInSubmission, error)
+	ListSubmissions(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		filter repository.CheckInSubmissionFilter,
+	) ([]models.CheckInSubmission, int, error)
+	GetSubmission(ctx context.Context, actorID int64, role string, submissionID int64) (*models.CheckInSubmission, error)
+	GetDashboard(ctx context.Context, coachID int64) (*models.CheckInDashboard, error)
+}
+
+type CheckInHandler struct {
+	service checkInApplicationService
+}
+
+type checkInFormRequest struct {
+	Title       string                   `json:"title"`
+	Description *string                  `json:"description"`
+	Questions   []models.CheckInQuestion `json:"questions"`
+}
+
+type assignCheckInRequest struct {
+	UserID       int64   `json:"user_id"`
+	IntervalDays int     `json:"interval_days"`
+	FirstDueOn   *string `json:"first_due_on"`
+}
+
+type updateCheckInScheduleRequest struct {
+	IntervalDays *int    `json:"interval_days"`
+	NextDueOn    *string `json:"next_due_on"`
+	Active       *bool   `json:"active"`
+}
+
+type submitCheckInRequest struct {
+	Answers []models.CheckInAnswer `json:"answers"`
+}
+
+func NewCheckInHandler(service checkInApplicationService) *CheckInHandler {
+	return &CheckInHandler{service: service}
+}
+
+func (h *CheckInHandler) CreateForm(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req checkInFormRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if strings.TrimSpace(req.Title) == "" || len(req.Questions) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "title and questions are required"})
+	}
+
+	form, err := h.service.CreateForm(c.Context(), coachID, repository.CheckInFormInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Questions:   req.Questions,
+	})
+	if err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"form": form})
+}
+
+func (h *CheckInHandler) ListForms(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	forms, err := h.service.ListForms(c.Context(), coachID)
+	if err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"forms": forms})
+}
+
+func (h *CheckInHandler) GetForm(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	formID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || formID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid check-in form id"})
+	}
+
+	form, err := h.service.GetForm(c.Context(), actorID, role, formID)
+	if err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"form": form})
+}
+
+func (h *CheckInHandler) UpdateForm(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	formID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || formID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid check-in form id"})
+	}
+
+	var req checkInFormRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if strings.TrimSpace(req.Title) == "" || len(req.Questions) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "title and questions are required"})
+	}
+
+	form, err := h.service.UpdateForm(c.Context(), coachID, formID, repository.CheckInFormInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Questions:   req.Questions,
+	})
+	if err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"form": form})
+}
+
+func (h *CheckInHandler) DeleteForm(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	formID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || formID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid check-in form id"})
+	}
+
+	if err := h.service.DeleteForm(c.Context(), coachID, formID); err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *CheckInHandler) AssignForm(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	formID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || formID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid check-in form id"})
+	}
+
+	var req assignCheckInRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.UserID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id must be a positive integer"})
+	}
+	if req.IntervalDays < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "interval_days must be a positive integer"})
+	}
+	firstDueOn, err := parseCalendarDate(req.FirstDueOn)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "first_due_on must be a YYYY-MM-DD date"})
+	}
+
+	schedule, err := h.service.AssignForm(c.Context(), coachID, formID, services.CheckInAssignment{
+		UserID:       req.UserID,
+		IntervalDays: req.IntervalDays,
+		FirstDueOn:   firstDueOn,
+	})
+	if err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"schedule": schedule})
+}
+
+func (h *CheckInHandler) ListSchedules(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var userID int64
+	if raw := strings.TrimSpace(c.Query("user_id")); raw != "" {
+		userID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || userID <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id must be a positive integer"})
+		}
+	}
+
+	schedules, err := h.service.ListSchedules(c.Context(), actorID, role, userID)
+	if err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"schedules": schedules})
+}
+
+func (h *CheckInHandler) UpdateSchedule(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	scheduleID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || scheduleID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid check-in schedule id"})
+	}
+
+	var req updateCheckInScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	nextDueOn, err := parseCalendarDate(req.NextDueOn)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "next_due_on must be a YYYY-MM-DD date"})
+	}
+
+	schedule, err := h.service.UpdateSchedule(c.Context(), coachID, scheduleID, services.CheckInScheduleUpdate{
+		IntervalDays: req.IntervalDays,
+		NextDueOn:    nextDueOn,
+		Active:       req.Active,
+	})
+	if err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"schedule": schedule})
+}
+
+func (h *CheckInHandler) DeleteSchedule(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	scheduleID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || scheduleID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid check-in schedule id"})
+	}
+
+	if err := h.service.DeleteSchedule(c.Context(), coachID, scheduleID); err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Submit accepts a JSON body, or multipart form data with the answers as a JSON `answers` field
+// and one `photo_<question id>` file per photo question.
+func (h *CheckInHandler) Submit(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	scheduleID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || scheduleID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid check-in schedule id"})
+	}
+
+	input := services.CheckInSubmissionInput{Photos: map[string]services.CheckInPhotoUpload{}}
+	form, err := c.MultipartForm()
+	if err != nil {
+		var req submitCheckInRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+		input.Answers = req.Answers
+	} else {
+		if raw := strings.TrimSpace(c.FormValue("answers")); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &input.Answers); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "answers must be a JSON array"})
+			}
+		}
+		for field, headers := range form.File {
+			questionID, isPhoto := strings.CutPrefix(field, checkInPhotoFieldPrefix)
+			if !isPhoto || questionID == "" || len(headers) != 1 {
+				return c.Status(fiber.StatusBadRequest).
+					JSON(fiber.Map{"error": "each photo must be sent once as photo_<question id>"})
+			}
+			if errMessage := validateCheckInPhoto(headers[0]); errMessage != "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errMessage})
+			}
+
+			file, err := headers[0].Open()
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to open photo file"})
+			}
+			defer file.Close()
+			input.Photos[questionID] = services.CheckInPhotoUpload{File: file, Filename: headers[0].Filename}
+		}
+	}
+
+	submission, err := h.service.Submit(c.Context(), userID, scheduleID, input)
+	if err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"submission": submission})
+}
+
+func (h *CheckInHandler) ListSubmissions(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	page := parsePositiveInt(c.Query("page"), 1)
+	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	filter := repository.CheckInSubmissionFilter{Limit: limit, Offset: (page - 1) * limit}
+	if raw := strings.TrimSpace(c.Query("user_id")); raw != "" {
+		userID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || userID <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id must be a positive integer"})
+		}
+		filter.UserID = &userID
+	}
+	if raw := strings.TrimSpace(c.Query("form_id")); raw != "" {
+		formID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || formID <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "form_id must be a positive integer"})
+		}
+		filter.FormID = &formID
+	}
+
+	submissions, total, err := h.service.ListSubmissions(c.Context(), actorID, role, filter)
+	if err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"submissions": submissions,
+		"pagination":  buildPaginationMeta(page, limit, total),
+	})
+}
+
+func (h *CheckInHandler) GetSubmission(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	submissionID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || submissionID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid check-in submission id"})
+	}
+
+	submission, err := h.service.GetSubmission(c.Context(), actorID, role, submissionID)
+	if err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"submission": submission})
+}
+
+func (h *CheckInHandler) GetDashboard(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	dashboard, err := h.service.GetDashboard(c.Context(), coachID)
+	if err != nil {
+		return mapCheckInError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"dashboard": dashboard})
+}
+
+func validateCheckInPhoto(header *multipart.FileHeader) string {
+	if header.Size <= 0 {
+		return "photo file is empty"
+	}
+	if header.Size > maxProgressPhotoSizeBytes {
+		return "photo file exceeds 10MB limit"
+	}
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".jpg", ".jpeg", ".png", ".webp", ".heic":
+		return ""
+	default:
+		return "photo must be a jpg, jpeg, png, webp, or heic file"
+	}
+}
+
+func mapCheckInError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, services.ErrConflict):
+		return c.Status(fiber.StatusConflict).
+			JSON(fiber.Map{"error": "Check-in is already scheduled, paused, or not open yet"})
+	case errors.Is(err, services.ErrStorageUnavailable):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Storage service is not configured"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Check-in form, schedule, or submission not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process check-in request"})
+	}
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubCheckInService struct {
+	lastAssignment services.CheckInAssignment
+	lastSubmission services.CheckInSubmissionInput
+	lastFilter     repository.CheckInSubmissionFilter
+}
+
+func (s *stubCheckInService) CreateForm(
+	_ context.Context,
+	coachID int64,
+	input repository.CheckInFormInput,
+) (*models.CheckInForm, error) {
+	return &models.CheckInForm{ID: 1, CoachID: coachID, Title: input.Title, Questions: input.Questions}, nil
+}
+
+func (s *stubCheckInService) ListForms(_ context.Context, _ int64) ([]models.CheckInForm, error) {
+	return []models.CheckInForm{}, nil
+}
+
+func (s *stubCheckInService) GetForm(_ context.Context, _ int64, _ string, formID int64) (*models.CheckInForm, error) {
+	return &models.CheckInForm{ID: formID}, nil
+}
+
+func (s *stubCheckInService) UpdateForm(
+	_ context.Context,
+	_ int64,
+	formID int64,
+	input repository.CheckInFormInput,
+) (*models.CheckInForm, error) {
+	return &models.CheckInForm{ID: formID, Title: input.Title}, nil
+}
+
+func (s *stubCheckInService) DeleteForm(_ context.Context, _ int64, _ int64) error {
+	return nil
+}
+
+func (s *stubCheckInService) AssignForm(
+	_ context.Context,
+	coachID int64,
+	formID int64,
+	assignment services.CheckInAssignment,
+) (*models.CheckInSchedule, error) {
+	s.lastAssignment = assignment
+	return &models.CheckInSchedule{ID: 1, FormID: formID, CoachID: coachID, UserID: assignment.UserID}, nil
+}
+
+func (s *stubCheckInService) ListSchedules(_ context.Context, _ int64, _ string, _ int64) ([]models.CheckInSchedule, error) {
+	return []models.CheckInSchedule{}, nil
+}
+
+func (s *stubCheckInService) UpdateSchedule(
+	_ context.Context,
+	_ int64,
+	scheduleID int64,
+	_ services.CheckInScheduleUpdate,
+) (*models.CheckInSchedule, error) {
+	return &models.CheckInSchedule{ID: scheduleID}, nil
+}
+
+func (s *stubCheckInService) DeleteSchedule(_ context.Context, _ int64, _ int64) error {
+	return nil
+}
+
+func (s *stubCheckInService) Submit(
+	_ context.Context,
+	userID int64,
+	_ int64,
+	input services.CheckInSubmissionInput,
+) (*models.CheckInSubmission, error) {
+	s.lastSubmission = input
+	return &models.CheckInSubmission{ID: 1, UserID: userID, Answers: input.Answers}, nil
+}
+
+func (s *stubCheckInService) ListSubmissions(
+	_ context.Context,
+	_ int64,
+	_ string,
+	filter repository.CheckInSubmissionFilter,
+) ([]models.CheckInSubmission, int, error) {
+	s.lastFilter = filter
+	return []models.CheckInSubmission{}, 0, nil
+}
+
+func (s *stubCheckInService) GetSubmission(
+	_ context.Context,
+	_ int64,
+	_ string,
+	submissionID int64,
+) (*models.CheckInSubmission, error) {
+	return &models.CheckInSubmission{ID: submissionID}, nil
+}
+
+func (s *stubCheckInService) GetDashboard(_ context.Context, _ int64) (*models.CheckInDashboard, error) {
+	return &models.CheckInDashboard{}, nil
+}
+
+func newCheckInTestApp(service *stubCheckInService, role string) *fiber.App {
+	handler := NewCheckInHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Get("/api/v1/check-ins/dashboard", handler.GetDashboard)
+	app.Post("/api/v1/check-ins/forms", handler.CreateForm)
+	app.Get("/api/v1/check-ins/forms/:id", handler.GetForm)
+	app.Post("/api/v1/check-ins/forms/:id/assign", handler.AssignForm)
+	app.Put("/api/v1/check-ins/schedules/:id", handler.UpdateSchedule)
+	app.Post("/api/v1/check-ins/schedules/:id/submissions", handler.Submit)
+	app.Get("/api/v1/check-ins/submissions", handler.ListSubmissions)
+	return app
+}
+
+func TestCheckInRoutes(t *testing.T) {
+	form := `{"title":"Weekly","questions":[{"type":"scale","prompt":"Sleep"}]}`
+
+	tests := []struct {
+		name       string
+		role       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{name: "coach creates form", role: "coach", method: http.MethodPost, target: "/api/v1/check-ins/forms", body: form, wantStatus: http.StatusCreated},
+		{name: "client cannot create form", role: "user", method: http.MethodPost, target: "/api/v1/check-ins/forms", body: form, wantStatus: http.StatusForbidden},
+		{name: "form needs questions", role: "coach", method: http.MethodPost, target: "/api/v1/check-ins/forms", body: `{"title":"Weekly"}`, wantStatus: http.StatusBadRequest},
+		{name: "client reads form", role: "user", method: http.MethodGet, target: "/api/v1/check-ins/forms/3", wantStatus: http.StatusOK},
+		{name: "assign needs user", role: "coach", method: http.MethodPost, target: "/api/v1/check-ins/forms/3/assign", body: `{"interval_days":7}`, wantStatus: http.StatusBadRequest},
+		{name: "assign bad date", role: "coach", method: http.MethodPost, target: "/api/v1/check-ins/forms/3/assign", body: `{"user_id":7,"first_due_on":"next monday"}`, wantStatus: http.StatusBadRequest},
+		{name: "pause schedule", role: "coach", method: http.MethodPut, target: "/api/v1/check-ins/schedules/5", body: `{"active":false}`, wantStatus: http.StatusOK},
+		{name: "client submits json", role: "user", method: http.MethodPost, target: "/api/v1/check-ins/schedules/5/submissions", body: `{"answers":[{"question_id":"q1","number":7}]}`, wantStatus: http.StatusCreated},
+		{name: "coach cannot submit", role: "coach", method: http.MethodPost, target: "/api/v1/check-ins/schedules/5/submissions", body: `{"answers":[]}`, wantStatus: http.StatusForbidden},
+		{name: "bad submission filter", role: "coach", method: http.MethodGet, target: "/api/v1/check-ins/submissions?form_id=abc", wantStatus: http.StatusBadRequest},
+		{name: "coach dashboard", role: "coach", method: http.MethodGet, target: "/api/v1/check-ins/dashboard", wantStatus: http.StatusOK},
+		{name: "client dashboard forbidden", role: "user", method: http.MethodGet, target: "/api/v1/check-ins/dashboard", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubCheckInService{}
+			app := newCheckInTestApp(service, tt.role)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestCheckInSubmitMultipart(t *testing.T) {
+	buildRequest := func(t *testing.T, photoField string, filename string) *http.Request {
+		t.Helper()
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		if err := writer.WriteField("answers", `[{"question_id":"q1","number":7}]`); err != nil {
+			t.Fatalf("write answers: %v", err)
+		}
+		part, err := writer.CreateFormFile(photoField, filename)
+		if err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		_, _ = part.Write([]byte("fake image"))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/check-ins/schedules/5/submissions", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return req
+	}
+
+	service := &stubCheckInService{}
+	app := newCheckInTestApp(service, "user")
+	resp, err := app.Test(buildRequest(t, "photo_front", "front.jpg"))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	if len(service.lastSubmission.Answers) != 1 || service.lastSubmission.Answers[0].QuestionID != "q1" {
+		t.Fatalf("unexpected answers: %+v", service.lastSubmission.Answers)
+	}
+	if _, ok := service.lastSubmission.Photos["front"]; !ok {
+		t.Fatalf("expected photo keyed by question id, got %+v", service.lastSubmission.Photos)
+	}
+
+	for _, tc := range []struct{ field, filename string }{{"front", "front.jpg"}, {"photo_front", "front.gif"}} {
+		resp, err := app.Test(buildRequest(t, tc.field, tc.filename))
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s/%s: expected 400, got %d", tc.field, tc.filename, resp.StatusCode)
+		}
+	}
+}
+
+func TestCheckInAssignDefaults(t *testing.T) {
+	service := &stubCheckInService{}
+	app := newCheckInTestApp(service, "coach")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/check-ins/forms/3/assign", strings.NewReader(`{"user_id":7}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	if service.lastAssignment.UserID != 7 || service.lastAssignment.IntervalDays != 0 || service.lastAssignment.FirstDueOn != nil {
+		t.Fatalf("expected service defaults to apply, got %+v", service.lastAssignment)
+	}
+}
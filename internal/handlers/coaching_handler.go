@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type coachingApplicationService interface {
+	StartRelationship(ctx context.Context, userID int64, coachID int64) (*models.CoachingRelationship, error)
+	ListRelationships(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		filter repository.CoachingRelationshipFilter,
+	) ([]models.CoachingRelationship, int, error)
+	GetRelationship(ctx context.Context, actorID int64, role string, relationshipID int64) (*models.CoachingRelationship, error)
+	UpdateStatus(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		relationshipID int64,
+		status string,
+	) (*models.CoachingRelationship, error)
+}
+
+type CoachingHandler struct {
+	service coachingApplicationService
+}
+
+func NewCoachingHandler(service coachingApplicationService) *CoachingHandler {
+	return &CoachingHandler{service: service}
+}
+
+type startRelationshipRequest struct {
+	CoachID int64 `json:"coach_id"`
+}
+
+type updateRelationshipRequest struct {
+	Status string `json:"status"`
+}
+
+func (h *CoachingHandler) StartRelationship(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req startRelationshipRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.CoachID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "coach_id is required"})
+	}
+
+	relationship, err := h.service.StartRelationship(c.Context(), userID, req.CoachID)
+	if err != nil {
+		return mapCoachingError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"relationship": relationship})
+}
+
+func (h *CoachingHandler) ListRelationships(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	page := parsePositiveInt(c.Query("page"), 1)
+	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	relationships, total, err := h.service.ListRelationships(c.Context(), actorID, role, repository.CoachingRelationshipFilter{
+		Status: strings.ToLower(strings.TrimSpace(c.Query("status"))),
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	})
+	if err != nil {
+		return mapCoachingError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"relationships": relationships,
+		"pagination":    buildPaginationMeta(page, limit, total),
+	})
+}
+
+func (h *CoachingHandler) GetRelationship(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	relationshipID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || relationshipID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid relationship id"})
+	}
+
+	relationship, err := h.service.GetRelationship(c.Context(), actorID, role, relationshipID)
+	if err != nil {
+		return mapCoachingError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"relationship": relationship})
+}
+
+func (h *CoachingHandler) UpdateRelationship(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	relationshipID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || relationshipID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid relationship id"})
+	}
+
+	var req updateRelationshipRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	status := strings.ToLower(strings.TrimSpace(req.Status))
+	if status == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status is required"})
+	}
+
+	relationship, err := h.service.UpdateStatus(c.Context(), actorID, role, relationshipID, status)
+	if err != nil {
+		return mapCoachingError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"relationship": relationship})
+}
+
+func mapCoachingError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrCoachNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Coach not found"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, services.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Relationship has ended; only the coach can reopen it"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Relationship not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).
+			JSON(fiber.Map{"error": "Failed to process coaching relationship request"})
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubCoachingService struct {
+	err        error
+	lastStatus string
+	lastFilter repository.CoachingRelationshipFilter
+}
+
+func (s *stubCoachingService) StartRelationship(
+	_ context.Context,
+	userID int64,
+	coachID int64,
+) (*models.CoachingRelationship, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.CoachingRelationship{ID: 1, CoachID: coachID, UserID: userID, Status: "active"}, nil
+}
+
+func (s *stubCoachingService) ListRelationships(
+	_ context.Context,
+	_ int64,
+	_ string,
+	filter repository.CoachingRelationshipFilter,
+) ([]models.CoachingRelationship, int, error) {
+	s.lastFilter = filter
+	return []models.CoachingRelationship{}, 0, s.err
+}
+
+func (s *stubCoachingService) GetRelationship(
+	_ context.Context,
+	_ int64,
+	_ string,
+	relationshipID int64,
+) (*models.CoachingRelationship, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.CoachingRelationship{ID: relationshipID}, nil
+}
+
+func (s *stubCoachingService) UpdateStatus(
+	_ context.Context,
+	_ int64,
+	_ string,
+	relationshipID int64,
+	status string,
+) (*models.CoachingRelationship, error) {
+	s.lastStatus = status
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.CoachingRelationship{ID: relationshipID, Status: status}, nil
+}
+
+func newCoachingTestApp(service *stubCoachingService, role string) *fiber.App {
+	handler := NewCoachingHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Post("/api/v1/relationships", handler.StartRelationship)
+	app.Get("/api/v1/relationships", handler.ListRelationships)
+	app.Get("/api/v1/relationships/:id", handler.GetRelationship)
+	app.Put("/api/v1/relationships/:id", handler.UpdateRelationship)
+	return app
+}
+
+func TestCoachingRelationshipRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		method     string
+		target     string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{name: "client starts relationship", role: "user", method: http.MethodPost, target: "/api/v1/relationships", body: `{"coach_id":7}`, wantStatus: http.StatusCreated},
+		{name: "coach cannot start relationship", role: "coach", method: http.MethodPost, target: "/api/v1/relationships", body: `{"coach_id":7}`, wantStatus: http.StatusForbidden},
+		{name: "missing coach", role: "user", method: http.MethodPost, target: "/api/v1/relationships", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown coach", role: "user", method: http.MethodPost, target: "/api/v1/relationships", body: `{"coach_id":7}`, err: services.ErrCoachNotFound, wantStatus: http.StatusNotFound},
+		{name: "ended relationship not reopened", role: "user", method: http.MethodPost, target: "/api/v1/relationships", body: `{"coach_id":7}`, err: services.ErrConflict, wantStatus: http.StatusConflict},
+		{name: "list by status", role: "coach", method: http.MethodGet, target: "/api/v1/relationships?status=Paused&limit=5", wantStatus: http.StatusOK},
+		{name: "bad relationship id", role: "user", method: http.MethodGet, target: "/api/v1/relationships/abc", wantStatus: http.StatusBadRequest},
+		{name: "coach pauses", role: "coach", method: http.MethodPut, target: "/api/v1/relationships/3", body: `{"status":"paused"}`, wantStatus: http.StatusOK},
+		{name: "client cannot pause", role: "user", method: http.MethodPut, target: "/api/v1/relationships/3", body: `{"status":"paused"}`, err: services.ErrForbidden, wantStatus: http.StatusForbidden},
+		{name: "missing status", role: "user", method: http.MethodPut, target: "/api/v1/relationships/3", body: `{}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubCoachingService{err: tt.err}
+			app := newCoachingTestApp(service, tt.role)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.name == "list by status" && (service.lastFilter.Status != "paused" || service.lastFilter.Limit != 5) {
+				t.Fatalf("unexpected filter: %+v", service.lastFilter)
+			}
+			if tt.name == "coach pauses" && service.lastStatus != "paused" {
+				t.Fatalf("expected paused status forwarded, got %q", service.lastStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type couponApplicationService interface {
+	CreateCoupon(ctx context.Context, coachID int64, input services.CouponInput) (*models.Coupon, error)
+	ListCoupons(ctx context.Context, coachID int64) ([]models.Coupon, error)
+	UpdateCoupon(ctx context.Context, coachID int64, couponID int64, input repository.UpdateCouponInput) (*models.Coupon, error)
+}
+
+type CouponHandler struct {
+	service couponApplicationService
+}
+
+type createCouponRequest struct {
+	Code             string  `json:"code"`
+	DiscountType     string  `json:"discount_type"`
+	DiscountValue    float64 `json:"discount_value"`
+	MaxRedemptions   *int    `json:"max_redemptions"`
+	PerUserLimit     *int    `json:"per_user_limit"`
+	FirstSessionOnly bool    `json:"first_session_only"`
+	ValidFrom        *string `json:"valid_from"`
+	ValidUntil       *string `json:"valid_until"`
+}
+
+type updateCouponRequest struct {
+	MaxRedemptions *int    `json:"max_redemptions"`
+	PerUserLimit   *int    `json:"per_user_limit"`
+	ValidFrom      *string `json:"valid_from"`
+	ValidUntil     *string `json:"valid_until"`
+	IsActive       *bool   `json:"is_active"`
+}
+
+func NewCouponHandler(service couponApplicationService) *CouponHandler {
+	return &CouponHandler{service: service}
+}
+
+func (h *CouponHandler) CreateCoupon(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req createCouponRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "code is required"})
+	}
+	discountType := strings.ToLower(strings.TrimSpace(req.DiscountType))
+	if discountType != "percent" && discountType != "fixed" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "discount_type must be percent or fixed"})
+	}
+	if req.DiscountValue <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "discount_value must be greater than 0"})
+	}
+
+	validFrom, err := parseOptionalTimestamp(req.ValidFrom)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "valid_from must be a valid RFC3339 timestamp"})
+	}
+	validUntil, err := parseOptionalTimestamp(req.ValidUntil)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "valid_until must be a valid RFC3339 timestamp"})
+	}
+
+	coupon, err := h.service.CreateCoupon(c.Context(), coachID, services.CouponInput{
+		Code:             req.Code,
+		DiscountType:     discountType,
+		DiscountValue:    req.DiscountValue,
+		MaxRedemptions:   req.MaxRedemptions,
+		PerUserLimit:     req.PerUserLimit,
+		FirstSessionOnly: req.FirstSessionOnly,
+		ValidFrom:        validFrom,
+		ValidUntil:       validUntil,
+	})
+	if err != nil {
+		return mapCouponError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"coupon": coupon})
+}
+
+func (h *CouponHandler) ListCoupons(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	coupons, err := h.service.ListCoupons(c.Context(), coachID)
+	if err != nil {
+		return mapCouponError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"coupons": coupons})
+}
+
+func (h *CouponHandler) UpdateCoupon(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	couponID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || couponID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid coupon id"})
+	}
+
+	var req updateCouponRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	validFrom, err := parseOptionalTimestamp(req.ValidFrom)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "valid_from must be a valid RFC3339 timestamp"})
+	}
+	validUntil, err := parseOptionalTimestamp(req.ValidUntil)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "valid_until must be a valid RFC3339 timestamp"})
+	}
+
+	coupon, err := h.service.UpdateCoupon(c.Context(), coachID, couponID, repository.UpdateCouponInput{
+		MaxRedemptions: req.MaxRedemptions,
+		PerUserLimit:   req.PerUserLimit,
+		ValidFrom:      validFrom,
+		ValidUntil:     validUntil,
+		IsActive:       req.IsActive,
+	})
+	if err != nil {
+		return mapCouponError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"coupon": coupon})
+}
+
+func parseOptionalTimestamp(value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(*value))
+	if err != nil {
+		return nil, err
+	}
+	utc := parsed.UTC()
+	return &utc, nil
+}
+
+func mapCouponError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, services.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "A coupon with this code already exists"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Coupon not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process coupon request"})
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubCouponService struct {
+	err         error
+	lastCreate  services.CouponInput
+	lastUpdate  repository.UpdateCouponInput
+	lastCoachID int64
+}
+
+func (s *stubCouponService) CreateCoupon(
+	_ context.Context,
+	coachID int64,
+	input services.CouponInput,
+) (*models.Coupon, error) {
+	s.lastCoachID = coachID
+	s.lastCreate = input
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.Coupon{ID: 1, Code: input.Code, CoachID: &coachID}, nil
+}
+
+func (s *stubCouponService) ListCoupons(_ context.Context, coachID int64) ([]models.Coupon, error) {
+	s.lastCoachID = coachID
+	return []models.Coupon{}, s.err
+}
+
+func (s *stubCouponService) UpdateCoupon(
+	_ context.Context,
+	coachID int64,
+	couponID int64,
+	input repository.UpdateCouponInput,
+) (*models.Coupon, error) {
+	s.lastCoachID = coachID
+	s.lastUpdate = input
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.Coupon{ID: couponID, CoachID: &coachID}, nil
+}
+
+func newCouponTestApp(service *stubCouponService, role string) *fiber.App {
+	handler := NewCouponHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Post("/api/v1/coupons", handler.CreateCoupon)
+	app.Get("/api/v1/coupons", handler.ListCoupons)
+	app.Put("/api/v1/coupons/:id", handler.UpdateCoupon)
+	return app
+}
+
+func TestCouponRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		method     string
+		target     string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{name: "coach creates coupon", role: "coach", method: http.MethodPost, target: "/api/v1/coupons", body: `{"code":"spring","discount_type":" Percent ","discount_value":15,"max_redemptions":10,"valid_until":"2030-01-01T00:00:00+02:00"}`, wantStatus: http.StatusCreated},
+		{name: "client cannot create coupon", role: "user", method: http.MethodPost, target: "/api/v1/coupons", body: `{"code":"spring","discount_type":"fixed","discount_value":5}`, wantStatus: http.StatusForbidden},
+		{name: "missing code", role: "coach", method: http.MethodPost, target: "/api/v1/coupons", body: `{"discount_type":"fixed","discount_value":5}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown discount type", role: "coach", method: http.MethodPost, target: "/api/v1/coupons", body: `{"code":"spring","discount_type":"bogo","discount_value":5}`, wantStatus: http.StatusBadRequest},
+		{name: "zero discount", role: "coach", method: http.MethodPost, target: "/api/v1/coupons", body: `{"code":"spring","discount_type":"fixed","discount_value":0}`, wantStatus: http.StatusBadRequest},
+		{name: "bad timestamp", role: "coach", method: http.MethodPost, target: "/api/v1/coupons", body: `{"code":"spring","discount_type":"fixed","discount_value":5,"valid_from":"tomorrow"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid limits", role: "coach", method: http.MethodPost, target: "/api/v1/coupons", body: `{"code":"spring","discount_type":"fixed","discount_value":5,"max_redemptions":0}`, err: services.ErrInvalidInput, wantStatus: http.StatusBadRequest},
+		{name: "duplicate code", role: "coach", method: http.MethodPost, target: "/api/v1/coupons", body: `{"code":"spring","discount_type":"fixed","discount_value":5}`, err: services.ErrConflict, wantStatus: http.StatusConflict},
+		{name: "coach lists coupons", role: "coach", method: http.MethodGet, target: "/api/v1/coupons", wantStatus: http.StatusOK},
+		{name: "client cannot list coupons", role: "user", method: http.MethodGet, target: "/api/v1/coupons", wantStatus: http.StatusForbidden},
+		{name: "coach deactivates coupon", role: "coach", method: http.MethodPut, target: "/api/v1/coupons/3", body: `{"is_active":false}`, wantStatus: http.StatusOK},
+		{name: "bad coupon id", role: "coach", method: http.MethodPut, target: "/api/v1/coupons/abc", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "another coach's coupon", role: "coach", method: http.MethodPut, target: "/api/v1/coupons/3", body: `{}`, err: services.ErrForbidden, wantStatus: http.StatusForbidden},
+		{name: "unknown coupon", role: "coach", method: http.MethodPut, target: "/api/v1/coupons/3", body: `{}`, err: pgx.ErrNoRows, wantStatus: http.StatusNotFound},
+		{name: "client cannot update coupon", role: "user", method: http.MethodPut, target: "/api/v1/coupons/3", body: `{}`, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubCouponService{err: tt.err}
+			app := newCouponTestApp(service, tt.role)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.name == "coach creates coupon" {
+				input := service.lastCreate
+				if service.lastCoachID != 42 || input.DiscountType != "percent" || input.MaxRedemptions == nil || *input.MaxRedemptions != 10 {
+					t.Fatalf("unexpected create input for coach %d: %+v", service.lastCoachID, input)
+				}
+				if input.ValidUntil == nil || input.ValidUntil.Location().String() != "UTC" || input.ValidUntil.Hour() != 22 {
+					t.Fatalf("expected valid_until in UTC, got %v", input.ValidUntil)
+				}
+			}
+			if tt.name == "coach deactivates coupon" && (service.lastUpdate.IsActive == nil || *service.lastUpdate.IsActive) {
+				t.Fatalf("expected is_active=false forwarded, got %+v", service.lastUpdate)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type disputeApplicationService interface {
+	ListDisputes(ctx context.Context, actorID int64, role string) ([]models.Dispute, error)
+	GetDispute(ctx context.Context, actorID int64, role string, disputeID int64) (*models.DisputeDetail, error)
+	SubmitEvidence(ctx context.Context, coachID int64, disputeID int64, statement *string) (*models.Dispute, error)
+}
+
+type DisputeHandler struct {
+	service disputeApplicationService
+}
+
+type submitDisputeEvidenceRequest struct {
+	Statement *string `json:"statement"`
+}
+
+func NewDisputeHandler(service disputeApplicationService) *DisputeHandler {
+	return &DisputeHandler{service: service}
+}
+
+func (h *DisputeHandler) ListDisputes(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	disputes, err := h.service.ListDisputes(c.Context(), actorID, role)
+	if err != nil {
+		return mapDisputeError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"disputes": disputes})
+}
+
+func (h *DisputeHandler) GetDispute(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	disputeID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || disputeID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid dispute id"})
+	}
+
+	dispute, err := h.service.GetDispute(c.Context(), actorID, role, disputeID)
+	if err != nil {
+		return mapDisputeError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"dispute": dispute})
+}
+
+func (h *DisputeHandler) SubmitEvidence(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	disputeID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || disputeID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid dispute id"})
+	}
+
+	var req submitDisputeEvidenceRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+	if req.Statement != nil && len(*req.Statement) > 5000 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "statement must be at most 5000 characters"})
+	}
+
+	dispute, err := h.service.SubmitEvidence(c.Context(), coachID, disputeID, req.Statement)
+	if err != nil {
+		return mapDisputeError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"dispute": dispute})
+}
+
+func mapDisputeError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, services.ErrInvalidStateTransition):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "Evidence can no longer be submitted for this dispute"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Dispute not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process dispute request"})
+	}
+}
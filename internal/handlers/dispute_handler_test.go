@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubDisputeService struct {
+	lastStatement *string
+	submitErr     error
+	submitCalls   int
+}
+
+func (s *stubDisputeService) ListDisputes(_ context.Context, _ int64, _ string) ([]models.Dispute, error) {
+	return []models.Dispute{}, nil
+}
+
+func (s *stubDisputeService) GetDispute(_ context.Context, _ int64, _ string, disputeID int64) (*models.DisputeDetail, error) {
+	return &models.DisputeDetail{Dispute: models.Dispute{ID: disputeID}}, nil
+}
+
+func (s *stubDisputeService) SubmitEvidence(
+	_ context.Context,
+	_ int64,
+	disputeID int64,
+	statement *string,
+) (*models.Dispute, error) {
+	s.submitCalls++
+	s.lastStatement = statement
+	if s.submitErr != nil {
+		return nil, s.submitErr
+	}
+	return &models.Dispute{ID: disputeID, Status: "under_review"}, nil
+}
+
+func newDisputeTestApp(service *stubDisputeService, role string) *fiber.App {
+	handler := NewDisputeHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "2")
+		return c.Next()
+	})
+	app.Post("/disputes/:id/evidence", handler.SubmitEvidence)
+	return app
+}
+
+func TestSubmitDisputeEvidence(t *testing.T) {
+	service := &stubDisputeService{}
+	app := newDisputeTestApp(service, "coach")
+
+	req := httptest.NewRequest(http.MethodPost, "/disputes/4/evidence", strings.NewReader(`{"statement":"Session was delivered"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if service.lastStatement == nil || *service.lastStatement != "Session was delivered" {
+		t.Fatalf("expected statement to be forwarded, got %v", service.lastStatement)
+	}
+}
+
+func TestSubmitDisputeEvidenceRequiresCoach(t *testing.T) {
+	service := &stubDisputeService{}
+	app := newDisputeTestApp(service, "user")
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/disputes/4/evidence", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", resp.StatusCode)
+	}
+	if service.submitCalls != 0 {
+		t.Fatalf("expected service not to be called")
+	}
+}
+
+func TestSubmitDisputeEvidenceMapsClosedDispute(t *testing.T) {
+	service := &stubDisputeService{submitErr: services.ErrInvalidStateTransition}
+	app := newDisputeTestApp(service, "coach")
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/disputes/4/evidence", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+const maxExerciseMediaSizeBytes = 50 * 1024 * 1024
+
+type exerciseApplicationService interface {
+	SearchExercises(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		filter repository.ExerciseSearchFilter,
+	) ([]models.Exercise, int, error)
+	GetExercise(ctx context.Context, actorID int64, role string, exerciseID int64) (*models.Exercise, error)
+	CreateExercise(ctx context.Context, coachID int64, input repository.ExerciseInput) (*models.Exercise, error)
+	UpdateExercise(
+		ctx context.Context,
+		coachID int64,
+		exerciseID int64,
+		input repository.ExerciseInput,
+	) (*models.Exercise, error)
+	DeleteExercise(ctx context.Context, coachID int64, exerciseID int64) error
+	UploadMedia(
+		ctx context.Context,
+		coachID int64,
+		exerciseID int64,
+		file multipart.File,
+		filename string,
+	) (*models.Exercise, error)
+}
+
+type ExerciseHandler struct {
+	service exerciseApplicationService
+}
+
+type exerciseRequest struct {
+	Name         string   `json:"name"`
+	Aliases      []string `json:"aliases"`
+	MuscleGroups []string `json:"muscle_groups"`
+	Equipment    []string `json:"equipment"`
+	Difficulty   string   `json:"difficulty"`
+	Instructions *string  `json:"instructions"`
+}
+
+func NewExerciseHandler(service exerciseApplicationService) *ExerciseHandler {
+	return &ExerciseHandler{service: service}
+}
+
+func (h *ExerciseHandler) ListExercises(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	page := parsePositiveInt(c.Query("page"), 1)
+	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	difficulty := strings.ToLower(strings.TrimSpace(c.Query("difficulty")))
+	switch difficulty {
+	case "", "beginner", "intermediate", "advanced":
+	default:
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "difficulty must be beginner, intermediate, or advanced"})
+	}
+
+	exercises, total, err := h.service.SearchExercises(c.Context(), actorID, role, repository.ExerciseSearchFilter{
+		Query:       strings.TrimSpace(c.Query("q")),
+		MuscleGroup: strings.TrimSpace(c.Query("muscle_group")),
+		Equipment:   strings.TrimSpace(c.Query("equipment")),
+		Difficulty:  difficulty,
+		CustomOnly:  c.QueryBool("mine"),
+		Offset:      (page - 1) * limit,
+		Limit:       limit,
+	})
+	if err != nil {
+		return mapExerciseError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"exercises":  exercises,
+		"pagination": buildPaginationMeta(page, limit, total),
+	})
+}
+
+func (h *ExerciseHandler) GetExercise(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	exerciseID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || exerciseID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid exercise id"})
+	}
+
+	exercise, err := h.service.GetExercise(c.Context(), actorID, role, exerciseID)
+	if err != nil {
+		return mapExerciseError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"exercise": exercise})
+}
+
+func (h *ExerciseHandler) CreateExercise(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req exerciseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name is required"})
+	}
+
+	exercise, err := h.service.CreateExercise(c.Context(), coachID, req.exerciseInput())
+	if err != nil {
+		return mapExerciseError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"exercise": exercise})
+}
+
+func (h *ExerciseHandler) UpdateExercise(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	exerciseID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || exerciseID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid exercise id"})
+	}
+
+	var req exerciseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name is required"})
+	}
+
+	exercise, err := h.service.UpdateExercise(c.Context(), coachID, exerciseID, req.exerciseInput())
+	if err != nil {
+		return mapExerciseError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"exercise": exercise})
+}
+
+func (h *ExerciseHandler) DeleteExercise(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	exerciseID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || exerciseID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid exercise id"})
+	}
+
+	if err := h.service.DeleteExercise(c.Context(), coachID, exerciseID); err != nil {
+		return mapExerciseError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *ExerciseHandler) UploadMedia(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	exerciseID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || exerciseID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid exercise id"})
+	}
+
+	fileHeader, err := c.FormFile("media")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "media file is required"})
+	}
+	if fileHeader.Size <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "media file is empty"})
+	}
+	if fileHeader.Size > maxExerciseMediaSizeBytes {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "media file exceeds 50MB limit"})
+	}
+	switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+	case ".jpg", ".jpeg", ".png", ".webp", ".gif", ".mp4", ".mov", ".webm":
+	default:
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "media must be a jpg, jpeg, png, webp, gif, mp4, mov, or webm file"})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to open media file"})
+	}
+	defer file.Close()
+
+	exercise, err := h.service.UploadMedia(c.Context(), coachID, exerciseID, file, fileHeader.Filename)
+	if err != nil {
+		return mapExerciseError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"exercise": exercise})
+}
+
+func (r exerciseRequest) exerciseInput() repository.ExerciseInput {
+	return repository.ExerciseInput{
+		Name:         r.Name,
+		Aliases:      r.Aliases,
+		MuscleGroups: r.MuscleGroups,
+		Equipment:    r.Equipment,
+		Difficulty:   r.Difficulty,
+		Instructions: r.Instructions,
+	}
+}
+
+func mapExerciseError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, services.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "An exercise with this name already exists"})
+	case errors.Is(err, services.ErrStorageUnavailable):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Storage service is not configured"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Exercise not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process exercise request"})
+	}
+}
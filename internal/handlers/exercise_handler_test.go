@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubExerciseService struct {
+	searchResult []models.Exercise
+	searchTotal  int
+	createResult *models.Exercise
+	createErr    error
+	uploadCalled bool
+	lastRole     string
+	lastFilter   repository.ExerciseSearchFilter
+	lastInput    repository.ExerciseInput
+}
+
+func (s *stubExerciseService) SearchExercises(
+	_ context.Context,
+	_ int64,
+	role string,
+	filter repository.ExerciseSearchFilter,
+) ([]models.Exercise, int, error) {
+	s.lastRole = role
+	s.lastFilter = filter
+	return s.searchResult, s.searchTotal, nil
+}
+
+func (s *stubExerciseService) GetExercise(_ context.Context, _ int64, _ string, _ int64) (*models.Exercise, error) {
+	return nil, nil
+}
+
+func (s *stubExerciseService) CreateExercise(
+	_ context.Context,
+	_ int64,
+	input repository.ExerciseInput,
+) (*models.Exercise, error) {
+	s.lastInput = input
+	return s.createResult, s.createErr
+}
+
+func (s *stubExerciseService) UpdateExercise(
+	_ context.Context,
+	_ int64,
+	_ int64,
+	_ repository.ExerciseInput,
+) (*models.Exercise, error) {
+	return nil, nil
+}
+
+func (s *stubExerciseService) DeleteExercise(_ context.Context, _ int64, _ int64) error {
+	return nil
+}
+
+func (s *stubExerciseService) UploadMedia(
+	_ context.Context,
+	_ int64,
+	_ int64,
+	_ multipart.File,
+	_ string,
+) (*models.Exercise, error) {
+	s.uploadCalled = true
+	return &models.Exercise{}, nil
+}
+
+func newExerciseTestApp(service *stubExerciseService, role string) *fiber.App {
+	handler := NewExerciseHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "7")
+		return c.Next()
+	})
+	app.Get("/api/v1/exercises", handler.ListExercises)
+	app.Post("/api/v1/exercises", handler.CreateExercise)
+	app.Post("/api/v1/exercises/:id/media", handler.UploadMedia)
+	return app
+}
+
+func TestListExercisesPassesSearchFilters(t *testing.T) {
+	service := &stubExerciseService{
+		searchResult: []models.Exercise{{ID: 1, Name: "Back Squat"}},
+		searchTotal:  21,
+	}
+	app := newExerciseTestApp(service, "coach")
+
+	req := httptest.NewRequest(
+		http.MethodGet,
+		"/api/v1/exercises?q=squat&muscle_group=quads&difficulty=Intermediate&mine=true&page=3&limit=10",
+		nil,
+	)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	filter := service.lastFilter
+	if filter.Query != "squat" || filter.MuscleGroup != "quads" || filter.Difficulty != "intermediate" {
+		t.Fatalf("unexpected filter: %+v", filter)
+	}
+	if !filter.CustomOnly || filter.Offset != 20 || filter.Limit != 10 {
+		t.Fatalf("unexpected paging or scope: %+v", filter)
+	}
+
+	var body struct {
+		Exercises  []models.Exercise     `json:"exercises"`
+		Pagination models.PaginationMeta `json:"pagination"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Exercises) != 1 || body.Pagination.TotalPages != 3 {
+		t.Fatalf("unexpected body: %+v", body)
+	}
+}
+
+func TestListExercisesRejectsUnknownDifficulty(t *testing.T) {
+	app := newExerciseTestApp(&stubExerciseService{}, "user")
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/exercises?difficulty=expert", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestCreateExerciseMapsConflict(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		createErr  error
+		wantStatus int
+	}{
+		{name: "created", role: "coach", wantStatus: http.StatusCreated},
+		{name: "duplicate name", role: "coach", createErr: services.ErrConflict, wantStatus: http.StatusConflict},
+		{name: "user cannot create", role: "user", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubExerciseService{createResult: &models.Exercise{ID: 5}, createErr: tt.createErr}
+			app := newExerciseTestApp(service, tt.role)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/v1/exercises",
+				bytes.NewBufferString(`{"name":"Sled Push","equipment":["sled"],"difficulty":"advanced"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestUploadExerciseMediaRejectsUnsupportedType(t *testing.T) {
+	service := &stubExerciseService{}
+	app := newExerciseTestApp(service, "coach")
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("media", "demo.exe")
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	if _, err := part.Write([]byte("binary")); err != nil {
+		t.Fatalf("part.Write: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("writer.Close: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/exercises/3/media", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+	if service.uploadCalled {
+		t.Fatal("expected upload to be skipped")
+	}
+}
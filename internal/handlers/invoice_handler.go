@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type invoiceApplicationService interface {
+	GetBillingDetails(ctx context.Context, coachID int64) (*models.CoachBillingDetails, error)
+	UpdateBillingDetails(ctx context.Context, coachID int64, input repository.UpdateBillingDetailsInput) (*models.CoachBillingDetails, error)
+	GetInvoiceDownload(ctx context.Context, actorID int64, role string, paymentID int64, kind string) (*services.InvoiceDownload, error)
+}
+
+type InvoiceHandler struct {
+	service invoiceApplicationService
+}
+
+type billingDetailsRequest struct {
+	LegalName *string  `json:"legal_name"`
+	TaxID     *string  `json:"tax_id"`
+	Address   *string  `json:"address"`
+	TaxRate   *float64 `json:"tax_rate"`
+}
+
+func NewInvoiceHandler(service invoiceApplicationService) *InvoiceHandler {
+	return &InvoiceHandler{service: service}
+}
+
+func (h *InvoiceHandler) GetBillingDetails(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	details, err := h.service.GetBillingDetails(c.Context(), coachID)
+	if err != nil {
+		return mapInvoiceError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"billing_details": details})
+}
+
+func (h *InvoiceHandler) UpdateBillingDetails(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req billingDetailsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	taxRate := 0.0
+	if req.TaxRate != nil {
+		taxRate = *req.TaxRate
+	}
+	if taxRate < 0 || taxRate >= 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "tax_rate must be between 0 and 100"})
+	}
+
+	details, err := h.service.UpdateBillingDetails(c.Context(), coachID, repository.UpdateBillingDetailsInput{
+		LegalName: req.LegalName,
+		TaxID:     req.TaxID,
+		Address:   req.Address,
+		TaxRate:   taxRate,
+	})
+	if err != nil {
+		return mapInvoiceError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"billing_details": details})
+}
+
+func (h *InvoiceHandler) GetInvoice(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	paymentID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || paymentID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid payment id"})
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(c.Query("kind", "invoice")))
+	if kind != "invoice" && kind != "credit_note" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "kind must be invoice or credit_note"})
+	}
+
+	download, err := h.service.GetInvoiceDownload(c.Context(), actorID, role, paymentID, kind)
+	if err != nil {
+		return mapInvoiceError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"invoice":            download.Invoice,
+		"download_url":       download.DownloadURL,
+		"expires_in_seconds": 3600,
+	})
+}
+
+func mapInvoiceError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, services.ErrStorageUnavailable):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Storage service is not configured"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Invoice not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process invoice request"})
+	}
+}
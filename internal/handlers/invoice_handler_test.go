@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubInvoiceService struct {
+	lastKind    string
+	lastBilling *repository.UpdateBillingDetailsInput
+	downloadErr error
+}
+
+func (s *stubInvoiceService) GetBillingDetails(_ context.Context, coachID int64) (*models.CoachBillingDetails, error) {
+	return &models.CoachBillingDetails{CoachID: coachID}, nil
+}
+
+func (s *stubInvoiceService) UpdateBillingDetails(
+	_ context.Context,
+	coachID int64,
+	input repository.UpdateBillingDetailsInput,
+) (*models.CoachBillingDetails, error) {
+	s.lastBilling = &input
+	return &models.CoachBillingDetails{CoachID: coachID, TaxRate: input.TaxRate}, nil
+}
+
+func (s *stubInvoiceService) GetInvoiceDownload(
+	_ context.Context,
+	_ int64,
+	_ string,
+	paymentID int64,
+	kind string,
+) (*services.InvoiceDownload, error) {
+	s.lastKind = kind
+	if s.downloadErr != nil {
+		return nil, s.downloadErr
+	}
+	return &services.InvoiceDownload{
+		Invoice:     &models.Invoice{ID: 1, PaymentID: paymentID, Kind: kind, InvoiceNumber: "INV-2-000001"},
+		DownloadURL: "https://storage.example.com/signed",
+	}, nil
+}
+
+func newInvoiceTestApp(service *stubInvoiceService, role string) *fiber.App {
+	handler := NewInvoiceHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", "2")
+		c.Locals("role", role)
+		return c.Next()
+	})
+	app.Get("/payments/:id/invoice", handler.GetInvoice)
+	app.Put("/coaches/billing-details", handler.UpdateBillingDetails)
+	return app
+}
+
+func TestGetInvoiceReturnsSignedURL(t *testing.T) {
+	service := &stubInvoiceService{}
+	app := newInvoiceTestApp(service, "user")
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/payments/5/invoice?kind=credit_note", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if service.lastKind != "credit_note" {
+		t.Fatalf("expected credit_note kind, got %q", service.lastKind)
+	}
+
+	var body struct {
+		Invoice     models.Invoice `json:"invoice"`
+		DownloadURL string         `json:"download_url"`
+		ExpiresIn   int            `json:"expires_in_seconds"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Invoice.PaymentID != 5 || body.DownloadURL == "" || body.ExpiresIn != 3600 {
+		t.Fatalf("unexpected response: %+v", body)
+	}
+}
+
+func TestGetInvoiceMapsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		err      error
+		expected int
+	}{
+		{name: "invalid kind", path: "/payments/5/invoice?kind=receipt", expected: http.StatusBadRequest},
+		{name: "invalid id", path: "/payments/abc/invoice", expected: http.StatusBadRequest},
+		{name: "forbidden", path: "/payments/5/invoice", err: services.ErrForbidden, expected: http.StatusForbidden},
+		{name: "storage missing", path: "/payments/5/invoice", err: services.ErrStorageUnavailable, expected: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newInvoiceTestApp(&stubInvoiceService{downloadErr: tt.err}, "coach")
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.expected {
+				t.Fatalf("expected %d, got %d", tt.expected, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestUpdateBillingDetailsRejectsInvalidTaxRate(t *testing.T) {
+	service := &stubInvoiceService{}
+	app := newInvoiceTestApp(service, "coach")
+
+	req := httptest.NewRequest(http.MethodPut, "/coaches/billing-details", strings.NewReader(`{"tax_rate":120}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+	if service.lastBilling != nil {
+		t.Fatalf("expected service not to be called")
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+	chatws "github.com/saeid-a/CoachAppBack/internal/websocket"
+)
+
+type moderationApplicationService interface {
+	ListQueue(ctx context.Context, role string, filter repository.ModerationFilter) ([]models.ModerationItem, int, error)
+	GetItem(ctx context.Context, role string, itemID int64) (*models.ModerationItem, error)
+	ReviewItem(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		itemID int64,
+		review services.ModerationReview,
+	) (*models.ModerationItem, *services.ChatDelivery, error)
+}
+
+type ModerationHandler struct {
+	service moderationApplicationService
+	hub     *chatws.Hub
+}
+
+func NewModerationHandler(service moderationApplicationService, hub *chatws.Hub) *ModerationHandler {
+	return &ModerationHandler{service: service, hub: hub}
+}
+
+type reviewModerationItemRequest struct {
+	Status        string `json:"status"`
+	Note          string `json:"note"`
+	RemoveMessage bool   `json:"remove_message"`
+}
+
+func (h *ModerationHandler) ListQueue(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	page := parsePositiveInt(c.Query("page"), 1)
+	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	items, total, err := h.service.ListQueue(c.Context(), role, repository.ModerationFilter{
+		Status: strings.ToLower(strings.TrimSpace(c.Query("status"))),
+		Source: strings.ToLower(strings.TrimSpace(c.Query("source"))),
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	})
+	if err != nil {
+		return mapModerationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"items":      items,
+		"pagination": buildPaginationMeta(page, limit, total),
+	})
+}
+
+func (h *ModerationHandler) GetItem(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	itemID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || itemID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid moderation item id"})
+	}
+
+	item, err := h.service.GetItem(c.Context(), role, itemID)
+	if err != nil {
+		return mapModerationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"item": item})
+}
+
+// ReviewItem closes a queue item. When the review removes the message, the conversation's
+// participants see it disappear in real time.
+func (h *ModerationHandler) ReviewItem(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	adminID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	itemID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || itemID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid moderation item id"})
+	}
+
+	var req reviewModerationItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	item, delivery, err := h.service.ReviewItem(c.Context(), adminID, role, itemID, services.ModerationReview{
+		Status:        strings.ToLower(strings.TrimSpace(req.Status)),
+		Note:          req.Note,
+		RemoveMessage: req.RemoveMessage,
+	})
+	if err != nil {
+		return mapModerationError(c, err)
+	}
+
+	if delivery != nil && h.hub != nil {
+		if err := h.hub.DeliverDeletion(delivery); err != nil {
+			log.Printf("chat hub publish moderated deletion: %v", err)
+		}
+	}
+
+	return c.JSON(fiber.Map{"item": item})
+}
+
+func mapModerationError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, services.ErrAlreadyReviewed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Moderation item was already reviewed"})
+	case errors.Is(err, services.ErrMessageNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Message not found"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Moderation item not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process moderation request"})
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubModerationService struct {
+	lastFilter repository.ModerationFilter
+	lastReview services.ModerationReview
+	reviewErr  error
+}
+
+func (s *stubModerationService) ListQueue(
+	_ context.Context,
+	_ string,
+	filter repository.ModerationFilter,
+) ([]models.ModerationItem, int, error) {
+	s.lastFilter = filter
+	return []models.ModerationItem{}, 0, nil
+}
+
+func (s *stubModerationService) GetItem(_ context.Context, _ string, itemID int64) (*models.ModerationItem, error) {
+	return &models.ModerationItem{ID: itemID}, nil
+}
+
+func (s *stubModerationService) ReviewItem(
+	_ context.Context,
+	_ int64,
+	_ string,
+	itemID int64,
+	review services.ModerationReview,
+) (*models.ModerationItem, *services.ChatDelivery, error) {
+	s.lastReview = review
+	if s.reviewErr != nil {
+		return nil, nil, s.reviewErr
+	}
+	return &models.ModerationItem{ID: itemID, Status: review.Status}, nil, nil
+}
+
+func newModerationTestApp(service *stubModerationService, role string) *fiber.App {
+	handler := NewModerationHandler(service, nil)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "1")
+		return c.Next()
+	})
+	app.Get("/api/v1/moderation/items", handler.ListQueue)
+	app.Put("/api/v1/moderation/items/:id", handler.ReviewItem)
+	return app
+}
+
+func TestListModerationQueue(t *testing.T) {
+	service := &stubModerationService{}
+
+	resp, err := newModerationTestApp(service, "coach").
+		Test(httptest.NewRequest(http.MethodGet, "/api/v1/moderation/items", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for coaches, got %d", resp.StatusCode)
+	}
+
+	resp, err = newModerationTestApp(service, "admin").
+		Test(httptest.NewRequest(http.MethodGet, "/api/v1/moderation/items?status=Open&source=report&page=2&limit=10", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	want := repository.ModerationFilter{Status: "open", Source: "report", Limit: 10, Offset: 10}
+	if service.lastFilter != want {
+		t.Fatalf("unexpected filter %+v", service.lastFilter)
+	}
+}
+
+func TestReviewModerationItem(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		reviewErr  error
+		wantStatus int
+	}{
+		{name: "resolve and remove", body: `{"status":"resolved","note":"contact info","remove_message":true}`, wantStatus: http.StatusOK},
+		{name: "already reviewed", body: `{"status":"dismissed"}`, reviewErr: services.ErrAlreadyReviewed, wantStatus: http.StatusConflict},
+		{name: "invalid review", body: `{"status":"open"}`, reviewErr: services.ErrInvalidInput, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubModerationService{reviewErr: tt.reviewErr}
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/moderation/items/4", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := newModerationTestApp(service, "admin").Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+}
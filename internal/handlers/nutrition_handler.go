@@ -0,0 +1,410 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type nutritionApplicationService interface {
+	CreatePlan(
+		ctx context.Context,
+		coachID int64,
+		userID int64,
+		input repository.NutritionPlanInput,
+	) (*models.NutritionPlan, error)
+	ListPlans(ctx context.Context, actorID int64, role string, userID int64) ([]models.NutritionPlan, error)
+	GetPlan(ctx context.Context, actorID int64, role string, planID int64) (*models.NutritionPlan, error)
+	UpdatePlan(
+		ctx context.Context,
+		coachID int64,
+		planID int64,
+		input repository.NutritionPlanInput,
+	) (*models.NutritionPlan, error)
+	DeletePlan(ctx context.Context, coachID int64, planID int64) error
+	GetAdherence(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		planID int64,
+		from *time.Time,
+		to *time.Time,
+	) (*models.NutritionAdherence, error)
+	LogMeal(ctx context.Context, userID int64, input repository.MealLogInput) (*models.MealLog, error)
+	ListMealLogs(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		filter repository.MealLogFilter,
+	) ([]models.MealLog, int, error)
+	DeleteMealLog(ctx context.Context, userID int64, logID int64) error
+}
+
+type NutritionHandler struct {
+	service nutritionApplicationService
+}
+
+type nutritionPlanRequest struct {
+	UserID    int64                 `json:"user_id"`
+	Title     string                `json:"title"`
+	Notes     *string               `json:"notes"`
+	Targets   models.MacroTargets   `json:"targets"`
+	Meals     []models.MealTemplate `json:"meals"`
+	StartDate *string               `json:"start_date"`
+	EndDate   *string               `json:"end_date"`
+}
+
+type createMealLogRequest struct {
+	Meal         string   `json:"meal"`
+	FoodName     string   `json:"food_name"`
+	Quantity     *string  `json:"quantity"`
+	CaloriesKcal int      `json:"calories_kcal"`
+	ProteinG     *float64 `json:"protein_g"`
+	CarbsG       *float64 `json:"carbs_g"`
+	FatG         *float64 `json:"fat_g"`
+	EatenAt      *string  `json:"eaten_at"`
+	Notes        *string  `json:"notes"`
+}
+
+func NewNutritionHandler(service nutritionApplicationService) *NutritionHandler {
+	return &NutritionHandler{service: service}
+}
+
+func (h *NutritionHandler) CreatePlan(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req nutritionPlanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.UserID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id must be a positive integer"})
+	}
+	input, errMessage := req.toInput()
+	if errMessage != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errMessage})
+	}
+
+	plan, err := h.service.CreatePlan(c.Context(), coachID, req.UserID, input)
+	if err != nil {
+		return mapNutritionError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"plan": plan})
+}
+
+func (h *NutritionHandler) ListPlans(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var userID int64
+	if raw := strings.TrimSpace(c.Query("user_id")); raw != "" {
+		userID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || userID <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id must be a positive integer"})
+		}
+	}
+
+	plans, err := h.service.ListPlans(c.Context(), actorID, role, userID)
+	if err != nil {
+		return mapNutritionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"plans": plans})
+}
+
+func (h *NutritionHandler) GetPlan(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	planID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || planID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid nutrition plan id"})
+	}
+
+	plan, err := h.service.GetPlan(c.Context(), actorID, role, planID)
+	if err != nil {
+		return mapNutritionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"plan": plan})
+}
+
+func (h *NutritionHandler) UpdatePlan(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	planID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || planID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid nutrition plan id"})
+	}
+
+	var req nutritionPlanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	input, errMessage := req.toInput()
+	if errMessage != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errMessage})
+	}
+
+	plan, err := h.service.UpdatePlan(c.Context(), coachID, planID, input)
+	if err != nil {
+		return mapNutritionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"plan": plan})
+}
+
+func (h *NutritionHandler) DeletePlan(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	planID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || planID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid nutrition plan id"})
+	}
+
+	if err := h.service.DeletePlan(c.Context(), coachID, planID); err != nil {
+		return mapNutritionError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *NutritionHandler) GetAdherence(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	planID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || planID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid nutrition plan id"})
+	}
+
+	rawFrom := c.Query("from")
+	from, err := parseCalendarDate(&rawFrom)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from must be a YYYY-MM-DD date"})
+	}
+	rawTo := c.Query("to")
+	to, err := parseCalendarDate(&rawTo)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "to must be a YYYY-MM-DD date"})
+	}
+
+	report, err := h.service.GetAdherence(c.Context(), actorID, role, planID, from, to)
+	if err != nil {
+		return mapNutritionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"adherence": report})
+}
+
+func (h *NutritionHandler) LogMeal(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req createMealLogRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if strings.TrimSpace(req.FoodName) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "food_name is required"})
+	}
+	eatenAt, err := parseOptionalTimestamp(req.EatenAt)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "eaten_at must be a valid RFC3339 timestamp"})
+	}
+
+	input := repository.MealLogInput{
+		Meal:         req.Meal,
+		FoodName:     req.FoodName,
+		Quantity:     req.Quantity,
+		CaloriesKcal: req.CaloriesKcal,
+		Notes:        req.Notes,
+	}
+	if eatenAt != nil {
+		input.EatenAt = *eatenAt
+	}
+	if req.ProteinG != nil {
+		input.ProteinG = *req.ProteinG
+	}
+	if req.CarbsG != nil {
+		input.CarbsG = *req.CarbsG
+	}
+	if req.FatG != nil {
+		input.FatG = *req.FatG
+	}
+
+	log, err := h.service.LogMeal(c.Context(), userID, input)
+	if err != nil {
+		return mapNutritionError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"log": log})
+}
+
+func (h *NutritionHandler) ListMealLogs(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	userID, errMessage := parseBodyMetricSubject(c, role)
+	if errMessage != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errMessage})
+	}
+	from, err := parseQueryTimestamp(c.Query("from"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from must be a valid RFC3339 timestamp"})
+	}
+	to, err := parseQueryTimestamp(c.Query("to"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "to must be a valid RFC3339 timestamp"})
+	}
+
+	page := parsePositiveInt(c.Query("page"), 1)
+	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	logs, total, err := h.service.ListMealLogs(c.Context(), actorID, role, repository.MealLogFilter{
+		UserID: userID,
+		From:   from,
+		To:     to,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	})
+	if err != nil {
+		return mapNutritionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"logs":       logs,
+		"pagination": buildPaginationMeta(page, limit, total),
+	})
+}
+
+func (h *NutritionHandler) DeleteMealLog(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	logID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || logID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid meal log id"})
+	}
+
+	if err := h.service.DeleteMealLog(c.Context(), userID, logID); err != nil {
+		return mapNutritionError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (req nutritionPlanRequest) toInput() (repository.NutritionPlanInput, string) {
+	if strings.TrimSpace(req.Title) == "" {
+		return repository.NutritionPlanInput{}, "title is required"
+	}
+	startDate, err := parseCalendarDate(req.StartDate)
+	if err != nil || startDate == nil {
+		return repository.NutritionPlanInput{}, "start_date must be a YYYY-MM-DD date"
+	}
+	endDate, err := parseCalendarDate(req.EndDate)
+	if err != nil {
+		return repository.NutritionPlanInput{}, "end_date must be a YYYY-MM-DD date"
+	}
+
+	return repository.NutritionPlanInput{
+		Title:     req.Title,
+		Notes:     req.Notes,
+		Targets:   req.Targets,
+		Meals:     req.Meals,
+		StartDate: *startDate,
+		EndDate:   endDate,
+	}, ""
+}
+
+func mapNutritionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Nutrition plan or meal log not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process nutrition request"})
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+type stubNutritionService struct {
+	lastPlanInput repository.NutritionPlanInput
+	lastLogFilter repository.MealLogFilter
+	lastFrom      *time.Time
+}
+
+func (s *stubNutritionService) CreatePlan(
+	_ context.Context,
+	coachID int64,
+	userID int64,
+	input repository.NutritionPlanInput,
+) (*models.NutritionPlan, error) {
+	s.lastPlanInput = input
+	return &models.NutritionPlan{ID: 1, CoachID: coachID, UserID: userID, Title: input.Title}, nil
+}
+
+func (s *stubNutritionService) ListPlans(_ context.Context, _ int64, _ string, _ int64) ([]models.NutritionPlan, error) {
+	return []models.NutritionPlan{}, nil
+}
+
+func (s *stubNutritionService) GetPlan(_ context.Context, _ int64, _ string, planID int64) (*models.NutritionPlan, error) {
+	return &models.NutritionPlan{ID: planID}, nil
+}
+
+func (s *stubNutritionService) UpdatePlan(
+	_ context.Context,
+	_ int64,
+	planID int64,
+	input repository.NutritionPlanInput,
+) (*models.NutritionPlan, error) {
+	s.lastPlanInput = input
+	return &models.NutritionPlan{ID: planID, Title: input.Title}, nil
+}
+
+func (s *stubNutritionService) DeletePlan(_ context.Context, _ int64, _ int64) error {
+	return nil
+}
+
+func (s *stubNutritionService) GetAdherence(
+	_ context.Context,
+	_ int64,
+	_ string,
+	planID int64,
+	from *time.Time,
+	_ *time.Time,
+) (*models.NutritionAdherence, error) {
+	s.lastFrom = from
+	return &models.NutritionAdherence{PlanID: planID}, nil
+}
+
+func (s *stubNutritionService) LogMeal(
+	_ context.Context,
+	userID int64,
+	input repository.MealLogInput,
+) (*models.MealLog, error) {
+	return &models.MealLog{ID: 1, UserID: userID, Meal: input.Meal, FoodName: input.FoodName}, nil
+}
+
+func (s *stubNutritionService) ListMealLogs(
+	_ context.Context,
+	_ int64,
+	_ string,
+	filter repository.MealLogFilter,
+) ([]models.MealLog, int, error) {
+	s.lastLogFilter = filter
+	return []models.MealLog{}, 0, nil
+}
+
+func (s *stubNutritionService) DeleteMealLog(_ context.Context, _ int64, _ int64) error {
+	return nil
+}
+
+func newNutritionTestApp(service *stubNutritionService, role string) *fiber.App {
+	handler := NewNutritionHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Post("/api/v1/nutrition/plans", handler.CreatePlan)
+	app.Put("/api/v1/nutrition/plans/:id", handler.UpdatePlan)
+	app.Get("/api/v1/nutrition/plans/:id/adherence", handler.GetAdherence)
+	app.Post("/api/v1/nutrition/logs", handler.LogMeal)
+	app.Get("/api/v1/nutrition/logs", handler.ListMealLogs)
+	app.Delete("/api/v1/nutrition/logs/:id", handler.DeleteMealLog)
+	return app
+}
+
+func TestNutritionRoutes(t *testing.T) {
+	validPlan := `{"user_id":7,"title":"Cut","targets":{"calories_kcal":2200,"protein_g":160},"start_date":"2030-03-01"}`
+
+	tests := []struct {
+		name       string
+		role       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{name: "coach creates plan", role: "coach", method: http.MethodPost, target: "/api/v1/nutrition/plans", body: validPlan, wantStatus: http.StatusCreated},
+		{name: "client cannot create plan", role: "user", method: http.MethodPost, target: "/api/v1/nutrition/plans", body: validPlan, wantStatus: http.StatusForbidden},
+		{name: "plan needs user", role: "coach", method: http.MethodPost, target: "/api/v1/nutrition/plans", body: `{"title":"Cut","start_date":"2030-03-01"}`, wantStatus: http.StatusBadRequest},
+		{name: "plan needs start date", role: "coach", method: http.MethodPost, target: "/api/v1/nutrition/plans", body: `{"user_id":7,"title":"Cut"}`, wantStatus: http.StatusBadRequest},
+		{name: "plan bad end date", role: "coach", method: http.MethodPut, target: "/api/v1/nutrition/plans/3", body: `{"title":"Cut","start_date":"2030-03-01","end_date":"March"}`, wantStatus: http.StatusBadRequest},
+		{name: "update plan", role: "coach", method: http.MethodPut, target: "/api/v1/nutrition/plans/3", body: `{"title":"Cut","start_date":"2030-03-01"}`, wantStatus: http.StatusOK},
+		{name: "client adherence", role: "user", method: http.MethodGet, target: "/api/v1/nutrition/plans/3/adherence?from=2030-03-01", wantStatus: http.StatusOK},
+		{name: "adherence timestamp", role: "user", method: http.MethodGet, target: "/api/v1/nutrition/plans/3/adherence?from=2030-03-01T00:00:00Z", wantStatus: http.StatusBadRequest},
+		{name: "client logs meal", role: "user", method: http.MethodPost, target: "/api/v1/nutrition/logs", body: `{"meal":"lunch","food_name":"Rice","calories_kcal":300}`, wantStatus: http.StatusCreated},
+		{name: "coach cannot log meal", role: "coach", method: http.MethodPost, target: "/api/v1/nutrition/logs", body: `{"meal":"lunch","food_name":"Rice"}`, wantStatus: http.StatusForbidden},
+		{name: "meal needs food", role: "user", method: http.MethodPost, target: "/api/v1/nutrition/logs", body: `{"meal":"lunch"}`, wantStatus: http.StatusBadRequest},
+		{name: "coach lists logs without user", role: "coach", method: http.MethodGet, target: "/api/v1/nutrition/logs", wantStatus: http.StatusBadRequest},
+		{name: "coach lists client logs", role: "coach", method: http.MethodGet, target: "/api/v1/nutrition/logs?user_id=7", wantStatus: http.StatusOK},
+		{name: "delete log", role: "user", method: http.MethodDelete, target: "/api/v1/nutrition/logs/9", wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubNutritionService{}
+			app := newNutritionTestApp(service, tt.role)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestNutritionCreatePlanParsesDates(t *testing.T) {
+	service := &stubNutritionService{}
+	app := newNutritionTestApp(service, "coach")
+
+	body := `{"user_id":7,"title":"Cut","targets":{"calories_kcal":2200},"start_date":"2030-03-01","end_date":"2030-04-30"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/nutrition/plans", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+
+	input := service.lastPlanInput
+	if !input.StartDate.Equal(time.Date(2030, 3, 1, 0, 0, 0, 0, time.UTC)) || input.EndDate == nil ||
+		!input.EndDate.Equal(time.Date(2030, 4, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected dates: %+v", input)
+	}
+	if input.Targets.CaloriesKcal != 2200 {
+		t.Fatalf("unexpected targets: %+v", input.Targets)
+	}
+}
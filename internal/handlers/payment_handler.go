@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type paymentApplicationService interface {
+	ListPayments(ctx context.Context, actorID int64, role string, query services.PaymentListQuery) (*services.PaymentPage, error)
+	ExportPayments(ctx context.Context, actorID int64, role string, query services.PaymentListQuery) ([]models.Payment, error)
+}
+
+type PaymentHandler struct {
+	service paymentApplicationService
+}
+
+func NewPaymentHandler(service paymentApplicationService) *PaymentHandler {
+	return &PaymentHandler{service: service}
+}
+
+func (h *PaymentHandler) ListPayments(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	query, err := parsePaymentListQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	query.Limit = parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if query.Limit > maxPageLimit {
+		query.Limit = maxPageLimit
+	}
+	query.Cursor = strings.TrimSpace(c.Query("cursor"))
+
+	page, err := h.service.ListPayments(c.Context(), actorID, role, query)
+	if err != nil {
+		return mapPaymentError(c, err)
+	}
+
+	return c.JSON(page)
+}
+
+func (h *PaymentHandler) ExportPayments(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	query, err := parsePaymentListQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	payments, err := h.service.ExportPayments(c.Context(), actorID, role, query)
+	if err != nil {
+		return mapPaymentError(c, err)
+	}
+
+	body, err := encodePaymentsCSV(payments)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to export payments"})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="payments.csv"`)
+	return c.Send(body)
+}
+
+func parsePaymentListQuery(c *fiber.Ctx) (services.PaymentListQuery, error) {
+	query := services.PaymentListQuery{Status: strings.TrimSpace(c.Query("status"))}
+	switch query.Status {
+	case "", "placeholder", "paid", "refunded", "disputed", "charged_back":
+	default:
+		return query, errors.New("status must be placeholder, paid, refunded, disputed, or charged_back")
+	}
+
+	if raw := strings.TrimSpace(c.Query("counterpart_id")); raw != "" {
+		counterpartID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || counterpartID <= 0 {
+			return query, errors.New("counterpart_id must be a positive integer")
+		}
+		query.CounterpartID = &counterpartID
+	}
+
+	var err error
+	if query.From, err = parseQueryTimestamp(c.Query("from")); err != nil {
+		return query, errors.New("from must be a valid RFC3339 timestamp")
+	}
+	if query.To, err = parseQueryTimestamp(c.Query("to")); err != nil {
+		return query, errors.New("to must be a valid RFC3339 timestamp")
+	}
+	if query.From != nil && query.To != nil && !query.To.After(*query.From) {
+		return query, errors.New("to must be after from")
+	}
+
+	return query, nil
+}
+
+func parseQueryTimestamp(raw string) (*time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	return parseOptionalTimestamp(&raw)
+}
+
+func encodePaymentsCSV(payments []models.Payment) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write([]string{
+		"id", "created_at", "status", "user_id", "coach_id", "session_id", "subscription_id",
+		"amount", "original_amount", "discount_amount", "coupon_id",
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, payment := range payments {
+		if err := writer.Write([]string{
+			strconv.FormatInt(payment.ID, 10),
+			payment.CreatedAt.UTC().Format(time.RFC3339),
+			payment.Status,
+			strconv.FormatInt(payment.UserID, 10),
+			strconv.FormatInt(payment.CoachID, 10),
+			formatOptionalID(payment.SessionID),
+			formatOptionalID(payment.SubscriptionID),
+			strconv.FormatFloat(payment.Amount, 'f', 2, 64),
+			formatOptionalAmount(payment.OriginalAmount),
+			strconv.FormatFloat(payment.DiscountAmount, 'f', 2, 64),
+			formatOptionalID(payment.CouponID),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func formatOptionalID(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+func formatOptionalAmount(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', 2, 64)
+}
+
+func mapPaymentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, services.ErrExportTooLarge):
+		return c.Status(fiber.StatusUnprocessableEntity).
+			JSON(fiber.Map{"error": "Too many payments to export; narrow the filters, for example with from and to"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process payment request"})
+	}
+}
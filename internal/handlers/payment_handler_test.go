@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubPaymentService struct {
+	lastQuery services.PaymentListQuery
+	lastRole  string
+	exportErr error
+}
+
+func (s *stubPaymentService) ListPayments(
+	_ context.Context,
+	_ int64,
+	role string,
+	query services.PaymentListQuery,
+) (*services.PaymentPage, error) {
+	s.lastQuery = query
+	s.lastRole = role
+	cursor := "next"
+	return &services.PaymentPage{
+		Payments:   []models.Payment{{ID: 3, Amount: 40, Status: "paid"}},
+		NextCursor: &cursor,
+	}, nil
+}
+
+func (s *stubPaymentService) ExportPayments(
+	_ context.Context,
+	_ int64,
+	_ string,
+	query services.PaymentListQuery,
+) ([]models.Payment, error) {
+	s.lastQuery = query
+	if s.exportErr != nil {
+		return nil, s.exportErr
+	}
+	sessionID := int64(9)
+	original := 50.0
+	return []models.Payment{{
+		ID:             3,
+		SessionID:      &sessionID,
+		UserID:         2,
+		CoachID:        4,
+		Amount:         40,
+		OriginalAmount: &original,
+		DiscountAmount: 10,
+		Status:         "paid",
+		CreatedAt:      time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+	}}, nil
+}
+
+func newPaymentTestApp(service *stubPaymentService) *fiber.App {
+	handler := NewPaymentHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", "coach")
+		c.Locals("user_id", "4")
+		return c.Next()
+	})
+	app.Get("/payments", handler.ListPayments)
+	app.Get("/payments/export", handler.ExportPayments)
+	return app
+}
+
+func TestListPaymentsParsesFiltersAndReturnsCursor(t *testing.T) {
+	service := &stubPaymentService{}
+	app := newPaymentTestApp(service)
+
+	resp, err := app.Test(httptest.NewRequest(
+		http.MethodGet,
+		"/payments?status=paid&counterpart_id=2&from=2030-01-01T00:00:00Z&limit=500&cursor=abc",
+		nil,
+	))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	query := service.lastQuery
+	if service.lastRole != "coach" || query.Status != "paid" || query.Cursor != "abc" || query.Limit != maxPageLimit {
+		t.Fatalf("unexpected query: role=%s %+v", service.lastRole, query)
+	}
+	if query.CounterpartID == nil || *query.CounterpartID != 2 || query.From == nil || query.To != nil {
+		t.Fatalf("unexpected filters: %+v", query)
+	}
+
+	var body struct {
+		Payments   []models.Payment `json:"payments"`
+		NextCursor *string          `json:"next_cursor"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Payments) != 1 || body.NextCursor == nil || *body.NextCursor != "next" {
+		t.Fatalf("unexpected body: %+v", body)
+	}
+}
+
+func TestListPaymentsRejectsInvalidFilters(t *testing.T) {
+	app := newPaymentTestApp(&stubPaymentService{})
+
+	for _, target := range []string{
+		"/payments?status=pending",
+		"/payments?counterpart_id=abc",
+		"/payments?from=yesterday",
+		"/payments?from=2030-01-02T00:00:00Z&to=2030-01-01T00:00:00Z",
+	} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", target, resp.StatusCode)
+		}
+	}
+}
+
+func TestExportPaymentsWritesCSV(t *testing.T) {
+	app := newPaymentTestApp(&stubPaymentService{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/payments/export", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(contentType, "text/csv") {
+		t.Fatalf("expected text/csv, got %q", contentType)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected header and one row, got %q", body)
+	}
+	if lines[1] != "3,2030-01-02T03:04:05Z,paid,2,4,9,,40.00,50.00,10.00," {
+		t.Fatalf("unexpected row: %q", lines[1])
+	}
+}
+
+func TestExportPaymentsRefusesTruncatedExport(t *testing.T) {
+	app := newPaymentTestApp(&stubPaymentService{exportErr: services.ErrExportTooLarge})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/payments/export", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", resp.StatusCode)
+	}
+}
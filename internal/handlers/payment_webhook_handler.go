@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+const paymentSignatureHeader = "X-Payment-Signature"
+
+type gatewayEventProcessor interface {
+	HandleGatewayEvent(ctx context.Context, event services.GatewayEvent) error
+}
+
+type PaymentWebhookHandler struct {
+	processor gatewayEventProcessor
+	secret    string
+}
+
+func NewPaymentWebhookHandler(processor gatewayEventProcessor, secret string) *PaymentWebhookHandler {
+	return &PaymentWebhookHandler{
+		processor: processor,
+		secret:    secret,
+	}
+}
+
+func (h *PaymentWebhookHandler) HandleEvent(c *fiber.Ctx) error {
+	if h.secret == "" {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Payment webhooks are not configured"})
+	}
+
+	body := c.Body()
+	if !validPaymentSignature(h.secret, body, c.Get(paymentSignatureHeader)) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid signature"})
+	}
+
+	var event services.GatewayEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event payload"})
+	}
+
+	if err := h.processor.HandleGatewayEvent(c.Context(), event); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInput):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event payload"})
+		case errors.Is(err, services.ErrUnsupportedGatewayEvent):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unsupported event type"})
+		case errors.Is(err, services.ErrInvalidStateTransition):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, pgx.ErrNoRows):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Referenced resource not found"})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process payment event"})
+		}
+	}
+
+	return c.JSON(fiber.Map{"received": true})
+}
+
+func validPaymentSignature(secret string, body []byte, signature string) bool {
+	signature = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+	provided, err := hex.DecodeString(signature)
+	if err != nil || len(provided) == 0 {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), provided)
+}
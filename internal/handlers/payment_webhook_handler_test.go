@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubGatewayEventProcessor struct {
+	err       error
+	lastEvent *services.GatewayEvent
+}
+
+func (p *stubGatewayEventProcessor) HandleGatewayEvent(_ context.Context, event services.GatewayEvent) error {
+	p.lastEvent = &event
+	return p.err
+}
+
+func signPaymentPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestPaymentWebhookAcceptsSignedEvent(t *testing.T) {
+	processor := &stubGatewayEventProcessor{}
+	handler := NewPaymentWebhookHandler(processor, "whsec")
+
+	app := fiber.New()
+	app.Post("/api/webhooks/payments", handler.HandleEvent)
+
+	body := []byte(`{"id":"evt_1","type":"subscription.renewed","data":{"subscription_id":"sub_1"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks/payments", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(paymentSignatureHeader, "sha256="+signPaymentPayload("whsec", body))
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if processor.lastEvent == nil || processor.lastEvent.Type != "subscription.renewed" ||
+		processor.lastEvent.Data.SubscriptionID != "sub_1" {
+		t.Fatalf("unexpected event: %+v", processor.lastEvent)
+	}
+}
+
+func TestPaymentWebhookRejectsInvalidSignature(t *testing.T) {
+	processor := &stubGatewayEventProcessor{}
+	handler := NewPaymentWebhookHandler(processor, "whsec")
+
+	app := fiber.New()
+	app.Post("/api/webhooks/payments", handler.HandleEvent)
+
+	body := []byte(`{"id":"evt_1","type":"subscription.renewed","data":{"subscription_id":"sub_1"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks/payments", bytes.NewReader(body))
+	req.Header.Set(paymentSignatureHeader, signPaymentPayload("other-secret", body))
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+	if processor.lastEvent != nil {
+		t.Fatalf("expected event not to be processed")
+	}
+}
+
+func TestPaymentWebhookRequiresConfiguredSecret(t *testing.T) {
+	handler := NewPaymentWebhookHandler(&stubGatewayEventProcessor{}, "")
+
+	app := fiber.New()
+	app.Post("/api/webhooks/payments", handler.HandleEvent)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks/payments", bytes.NewReader([]byte(`{}`)))
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", resp.StatusCode)
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"errors"
+	"mime/multipart"
 	"strconv"
 	"strings"
 	"time"
@@ -29,16 +30,58 @@ type programApplicationService interface {
 		programID int64,
 	) (*models.WorkoutProgram, error)
 	GetDownloadURL(ctx context.Context, actorID int64, role string, programID int64) (string, error)
+	CreateStructuredProgram(
+		ctx context.Context,
+		coachID int64,
+		input services.StructuredProgramInput,
+	) (*models.WorkoutProgram, error)
+	UpdateProgram(
+		ctx context.Context,
+		coachID int64,
+		programID int64,
+		input services.UpdateProgramInput,
+	) (*models.WorkoutProgram, error)
+	DeleteProgram(ctx context.Context, coachID int64, programID int64) error
+	AttachFile(
+		ctx context.Context,
+		coachID int64,
+		programID int64,
+		file multipart.File,
+		filename string,
+		changeNote *string,
+	) (*models.WorkoutProgram, error)
+}
+
+type createProgramRequest struct {
+	UserID      int64                 `json:"user_id"`
+	SessionID   int64                 `json:"session_id"`
+	Title       string                `json:"title"`
+	Description *string               `json:"description"`
+	Phases      []models.ProgramPhase `json:"phases"`
+}
+
+type updateProgramRequest struct {
+	Title       *string                `json:"title"`
+	Description *string                `json:"description"`
+	Phases      *[]models.ProgramPhase `json:"phases"`
+	ChangeNote  *string                `json:"change_note"`
 }
 
 type workoutProgramResponse struct {
-	ID          int64     `json:"id"`
-	CoachID     int64     `json:"coach_id"`
-	UserID      int64     `json:"user_id"`
-	SessionID   int64     `json:"session_id"`
-	Title       string    `json:"title"`
-	Description *string   `json:"description,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID             int64                 `json:"id"`
+	CoachID        int64                 `json:"coach_id"`
+	UserID         int64                 `json:"user_id"`
+	RelationshipID *int64                `json:"relationship_id,omitempty"`
+	SessionID      int64                 `json:"session_id,omitempty"`
+	TemplateID     *int64                `json:"template_id,omitempty"`
+	Version        int                   `json:"version"`
+	StartDate      *time.Time            `json:"start_date,omitempty"`
+	Title          string                `json:"title"`
+	Description    *string               `json:"description,omitempty"`
+	HasAttachment  bool                  `json:"has_attachment"`
+	Phases         []models.ProgramPhase `json:"phases,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
 }
 
 type ProgramHandler struct {
@@ -60,16 +103,23 @@ func (h *ProgramHandler) CreateProgram(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
 	}
 
+	if !strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		return h.createStructuredProgram(c, coachID)
+	}
+
 	userID, err := strconv.ParseInt(strings.TrimSpace(c.FormValue("user_id")), 10, 64)
 	if err != nil || userID <= 0 {
 		return c.Status(fiber.StatusBadRequest).
 			JSON(fiber.Map{"error": "user_id must be a positive integer"})
 	}
 
-	sessionID, err := strconv.ParseInt(strings.TrimSpace(c.FormValue("session_id")), 10, 64)
-	if err != nil || sessionID <= 0 {
-		return c.Status(fiber.StatusBadRequest).
-			JSON(fiber.Map{"error": "session_id must be a positive integer"})
+	var sessionID int64
+	if raw := strings.TrimSpace(c.FormValue("session_id")); raw != "" {
+		sessionID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || sessionID <= 0 {
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "session_id must be a positive integer"})
+		}
 	}
 
 	title := strings.TrimSpace(c.FormValue("title"))
@@ -86,11 +136,8 @@ func (h *ProgramHandler) CreateProgram(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
 	}
-	if fileHeader.Size <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is empty"})
-	}
-	if fileHeader.Size > maxProgramSizeBytes {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file exceeds 25MB limit"})
+	if message := validateProgramFile(fileHeader); message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": message})
 	}
 
 	file, err := fileHeader.Open()
@@ -116,6 +163,143 @@ func (h *ProgramHandler) CreateProgram(c *fiber.Ctx) error {
 		JSON(fiber.Map{"program": newWorkoutProgramResponse(program)})
 }
 
+func (h *ProgramHandler) createStructuredProgram(c *fiber.Ctx, coachID int64) error {
+	var req createProgramRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.UserID <= 0 {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "user_id must be a positive integer"})
+	}
+	if req.SessionID < 0 {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "session_id must be a positive integer"})
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "title is required"})
+	}
+
+	program, err := h.service.CreateStructuredProgram(c.Context(), coachID, services.StructuredProgramInput{
+		UserID:      req.UserID,
+		SessionID:   req.SessionID,
+		Title:       req.Title,
+		Description: req.Description,
+		Phases:      req.Phases,
+	})
+	if err != nil {
+		return mapProgramError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).
+		JSON(fiber.Map{"program": newWorkoutProgramResponse(program)})
+}
+
+func (h *ProgramHandler) UpdateProgram(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	programID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || programID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid program id"})
+	}
+
+	var req updateProgramRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Title == nil && req.Description == nil && req.Phases == nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "At least one of title, description or phases is required"})
+	}
+
+	program, err := h.service.UpdateProgram(c.Context(), coachID, programID, services.UpdateProgramInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Phases:      req.Phases,
+		ChangeNote:  req.ChangeNote,
+	})
+	if err != nil {
+		return mapProgramError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"program": newWorkoutProgramResponse(program)})
+}
+
+func (h *ProgramHandler) DeleteProgram(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	programID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || programID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid program id"})
+	}
+
+	if err := h.service.DeleteProgram(c.Context(), coachID, programID); err != nil {
+		return mapProgramError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *ProgramHandler) UploadAttachment(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	programID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || programID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid program id"})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+	}
+	if message := validateProgramFile(fileHeader); message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": message})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).
+			JSON(fiber.Map{"error": "Failed to open file"})
+	}
+	defer file.Close()
+
+	var changeNote *string
+	if note := c.FormValue("change_note"); note != "" {
+		changeNote = &note
+	}
+
+	program, err := h.service.AttachFile(c.Context(), coachID, programID, file, fileHeader.Filename, changeNote)
+	if err != nil {
+		return mapProgramError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"program": newWorkoutProgramResponse(program)})
+}
+
 func (h *ProgramHandler) ListPrograms(c *fiber.Ctx) error {
 	role, ok := c.Locals("role").(string)
 	if !ok || (role != "user" && role != "coach") {
@@ -201,18 +385,35 @@ func mapProgramError(c *fiber.Ctx, err error) error {
 	}
 }
 
+func validateProgramFile(fileHeader *multipart.FileHeader) string {
+	if fileHeader.Size <= 0 {
+		return "file is empty"
+	}
+	if fileHeader.Size > maxProgramSizeBytes {
+		return "file exceeds 25MB limit"
+	}
+	return ""
+}
+
 func newWorkoutProgramResponse(program *models.WorkoutProgram) *workoutProgramResponse {
 	if program == nil {
 		return nil
 	}
 	return &workoutProgramResponse{
-		ID:          program.ID,
-		CoachID:     program.CoachID,
-		UserID:      program.UserID,
-		SessionID:   program.SessionID,
-		Title:       program.Title,
-		Description: program.Description,
-		CreatedAt:   program.CreatedAt,
+		ID:             program.ID,
+		CoachID:        program.CoachID,
+		UserID:         program.UserID,
+		RelationshipID: program.RelationshipID,
+		SessionID:      program.SessionID,
+		TemplateID:     program.TemplateID,
+		Version:        program.Version,
+		StartDate:      program.StartDate,
+		Title:          program.Title,
+		Description:    program.Description,
+		HasAttachment:  program.FileURL != "",
+		Phases:         program.Phases,
+		CreatedAt:      program.CreatedAt,
+		UpdatedAt:      program.UpdatedAt,
 	}
 }
 
@@ -222,16 +423,7 @@ func newWorkoutProgramResponses(programs []models.WorkoutProgram) []workoutProgr
 	}
 	responses := make([]workoutProgramResponse, 0, len(programs))
 	for i := range programs {
-		program := programs[i]
-		responses = append(responses, workoutProgramResponse{
-			ID:          program.ID,
-			CoachID:     program.CoachID,
-			UserID:      program.UserID,
-			SessionID:   program.SessionID,
-			Title:       program.Title,
-			Description: program.Description,
-			CreatedAt:   program.CreatedAt,
-		})
+		responses = append(responses, *newWorkoutProgramResponse(&programs[i]))
 	}
 	return responses
 }
@@ -29,6 +29,7 @@ type stubProgramService struct {
 	lastRole        string
 	lastProgramID   int64
 	lastCreateInput services.CreateProgramInput
+	lastStructured  services.StructuredProgramInput
 }
 
 func (s *stubProgramService) CreateProgram(
@@ -75,6 +76,46 @@ func (s *stubProgramService) GetDownloadURL(
 	return s.downloadURL, s.downloadErr
 }
 
+func (s *stubProgramService) CreateStructuredProgram(
+	_ context.Context,
+	coachID int64,
+	input services.StructuredProgramInput,
+) (*models.WorkoutProgram, error) {
+	s.lastCoachID = coachID
+	s.lastStructured = input
+	return s.createResult, s.createErr
+}
+
+func (s *stubProgramService) UpdateProgram(
+	_ context.Context,
+	coachID int64,
+	programID int64,
+	_ services.UpdateProgramInput,
+) (*models.WorkoutProgram, error) {
+	s.lastCoachID = coachID
+	s.lastProgramID = programID
+	return s.getResult, s.getErr
+}
+
+func (s *stubProgramService) DeleteProgram(_ context.Context, coachID int64, programID int64) error {
+	s.lastCoachID = coachID
+	s.lastProgramID = programID
+	return s.getErr
+}
+
+func (s *stubProgramService) AttachFile(
+	_ context.Context,
+	coachID int64,
+	programID int64,
+	_ multipart.File,
+	_ string,
+	_ *string,
+) (*models.WorkoutProgram, error) {
+	s.lastCoachID = coachID
+	s.lastProgramID = programID
+	return s.getResult, s.getErr
+}
+
 func TestCreateProgramParsesMultipartRequest(t *testing.T) {
 	service := &stubProgramService{
 		createResult: &models.WorkoutProgram{
@@ -304,3 +345,59 @@ func TestDownloadProgramReturnsSignedURL(t *testing.T) {
 		t.Fatalf("unexpected download url: %q", payload.DownloadURL)
 	}
 }
+
+func TestCreateProgramAcceptsStructuredJSON(t *testing.T) {
+	service := &stubProgramService{
+		createResult: &models.WorkoutProgram{
+			ID:        18,
+			CoachID:   7,
+			UserID:    42,
+			SessionID: 99,
+			Title:     "Hypertrophy block",
+			Phases:    []models.ProgramPhase{{ID: 1, Name: "Accumulation"}},
+		},
+	}
+	handler := NewProgramHandler(service)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", "coach")
+		c.Locals("user_id", "7")
+		return c.Next()
+	})
+	app.Post("/api/v1/programs", handler.CreateProgram)
+
+	payload := `{"user_id":42,"session_id":99,"title":"Hypertrophy block","phases":[{"name":"Accumulation",` +
+		`"weeks":[{"week_number":1,"days":[{"day_number":1,"exercises":[{"name":"Back squat","sets":4,"reps":"8-10","rpe":7.5}]}]}]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/programs", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	if len(service.lastStructured.Phases) != 1 {
+		t.Fatalf("expected one phase, got %+v", service.lastStructured.Phases)
+	}
+	exercises := service.lastStructured.Phases[0].Weeks[0].Days[0].Exercises
+	if len(exercises) != 1 || exercises[0].Name != "Back squat" || *exercises[0].Sets != 4 || *exercises[0].RPE != 7.5 {
+		t.Fatalf("unexpected exercises: %+v", exercises)
+	}
+
+	var body struct {
+		Program map[string]any `json:"program"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Program["has_attachment"] != false {
+		t.Fatalf("expected has_attachment false, got %v", body.Program["has_attachment"])
+	}
+	if _, ok := body.Program["phases"]; !ok {
+		t.Fatalf("expected phases in response: %v", body.Program)
+	}
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type programTemplateApplicationService interface {
+	CreateTemplate(
+		ctx context.Context,
+		coachID int64,
+		input services.ProgramTemplateInput,
+	) (*models.ProgramTemplate, error)
+	ListTemplates(ctx context.Context, coachID int64) ([]models.ProgramTemplate, error)
+	GetTemplate(ctx context.Context, coachID int64, templateID int64) (*models.ProgramTemplate, error)
+	UpdateTemplate(
+		ctx context.Context,
+		coachID int64,
+		templateID int64,
+		input services.UpdateProgramInput,
+	) (*models.ProgramTemplate, error)
+	DeleteTemplate(ctx context.Context, coachID int64, templateID int64) error
+	AssignTemplate(
+		ctx context.Context,
+		coachID int64,
+		templateID int64,
+		startDate *time.Time,
+		assignments []services.ProgramAssignment,
+	) ([]models.WorkoutProgram, error)
+}
+
+type ProgramTemplateHandler struct {
+	service programTemplateApplicationService
+}
+
+type createProgramTemplateRequest struct {
+	Title           string                `json:"title"`
+	Description     *string               `json:"description"`
+	Phases          []models.ProgramPhase `json:"phases"`
+	SourceProgramID *int64                `json:"source_program_id"`
+}
+
+type exerciseSubstitutionRequest struct {
+	Name        string `json:"name"`
+	Replacement string `json:"replacement"`
+	ExerciseID  *int64 `json:"exercise_id"`
+}
+
+type programAssignmentRequest struct {
+	UserID           int64                         `json:"user_id"`
+	SessionID        int64                         `json:"session_id"`
+	StartDate        *string                       `json:"start_date"`
+	Title            *string                       `json:"title"`
+	WeightMultiplier *float64                      `json:"weight_multiplier"`
+	Substitutions    []exerciseSubstitutionRequest `json:"substitutions"`
+}
+
+type assignProgramTemplateRequest struct {
+	StartDate   *string                    `json:"start_date"`
+	Assignments []programAssignmentRequest `json:"assignments"`
+}
+
+func NewProgramTemplateHandler(service programTemplateApplicationService) *ProgramTemplateHandler {
+	return &ProgramTemplateHandler{service: service}
+}
+
+func (h *ProgramTemplateHandler) CreateTemplate(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req createProgramTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "title is required"})
+	}
+	if req.SourceProgramID != nil && len(req.Phases) > 0 {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "provide either phases or source_program_id, not both"})
+	}
+
+	template, err := h.service.CreateTemplate(c.Context(), coachID, services.ProgramTemplateInput{
+		Title:           req.Title,
+		Description:     req.Description,
+		Phases:          req.Phases,
+		SourceProgramID: req.SourceProgramID,
+	})
+	if err != nil {
+		return mapProgramTemplateError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"template": template})
+}
+
+func (h *ProgramTemplateHandler) ListTemplates(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	templates, err := h.service.ListTemplates(c.Context(), coachID)
+	if err != nil {
+		return mapProgramTemplateError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"templates": templates})
+}
+
+func (h *ProgramTemplateHandler) GetTemplate(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	templateID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || templateID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template id"})
+	}
+
+	template, err := h.service.GetTemplate(c.Context(), coachID, templateID)
+	if err != nil {
+		return mapProgramTemplateError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"template": template})
+}
+
+func (h *ProgramTemplateHandler) UpdateTemplate(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	templateID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || templateID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template id"})
+	}
+
+	var req updateProgramRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Title == nil && req.Description == nil && req.Phases == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Nothing to update"})
+	}
+
+	template, err := h.service.UpdateTemplate(c.Context(), coachID, templateID, services.UpdateProgramInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Phases:      req.Phases,
+	})
+	if err != nil {
+		return mapProgramTemplateError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"template": template})
+}
+
+func (h *ProgramTemplateHandler) DeleteTemplate(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	templateID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || templateID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template id"})
+	}
+
+	if err := h.service.DeleteTemplate(c.Context(), coachID, templateID); err != nil {
+		return mapProgramTemplateError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *ProgramTemplateHandler) AssignTemplate(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	templateID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || templateID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template id"})
+	}
+
+	var req assignProgramTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if len(req.Assignments) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "assignments are required"})
+	}
+
+	startDate, err := parseCalendarDate(req.StartDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "start_date must be a YYYY-MM-DD date"})
+	}
+
+	assignments := make([]services.ProgramAssignment, 0, len(req.Assignments))
+	for _, item := range req.Assignments {
+		if item.UserID <= 0 {
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "each assignment needs a positive user_id"})
+		}
+		assignmentStart, err := parseCalendarDate(item.StartDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "start_date must be a YYYY-MM-DD date"})
+		}
+		if assignmentStart == nil && startDate == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "start_date is required"})
+		}
+
+		substitutions := make([]services.ExerciseSubstitution, 0, len(item.Substitutions))
+		for _, substitution := range item.Substitutions {
+			substitutions = append(substitutions, services.ExerciseSubstitution{
+				Name:        substitution.Name,
+				Replacement: substitution.Replacement,
+				ExerciseID:  substitution.ExerciseID,
+			})
+		}
+		assignments = append(assignments, services.ProgramAssignment{
+			UserID:           item.UserID,
+			SessionID:        item.SessionID,
+			StartDate:        assignmentStart,
+			Title:            item.Title,
+			WeightMultiplier: item.WeightMultiplier,
+			Substitutions:    substitutions,
+		})
+	}
+
+	programs, err := h.service.AssignTemplate(c.Context(), coachID, templateID, startDate, assignments)
+	if err != nil {
+		return mapProgramTemplateError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"programs": newWorkoutProgramResponses(programs)})
+}
+
+// parseCalendarDate parses an optional YYYY-MM-DD date as midnight UTC.
+func parseCalendarDate(value *string) (*time.Time, error) {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.DateOnly, strings.TrimSpace(*value))
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func mapProgramTemplateError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Template, program, client, or session not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process program template request"})
+	}
+}
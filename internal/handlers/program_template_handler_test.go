@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubProgramTemplateService struct {
+	lastInput       services.ProgramTemplateInput
+	lastStartDate   *time.Time
+	lastAssignments []services.ProgramAssignment
+}
+
+func (s *stubProgramTemplateService) CreateTemplate(
+	_ context.Context,
+	coachID int64,
+	input services.ProgramTemplateInput,
+) (*models.ProgramTemplate, error) {
+	s.lastInput = input
+	return &models.ProgramTemplate{ID: 1, CoachID: coachID, Title: input.Title}, nil
+}
+
+func (s *stubProgramTemplateService) ListTemplates(_ context.Context, _ int64) ([]models.ProgramTemplate, error) {
+	return []models.ProgramTemplate{}, nil
+}
+
+func (s *stubProgramTemplateService) GetTemplate(
+	_ context.Context,
+	coachID int64,
+	templateID int64,
+) (*models.ProgramTemplate, error) {
+	return &models.ProgramTemplate{ID: templateID, CoachID: coachID}, nil
+}
+
+func (s *stubProgramTemplateService) UpdateTemplate(
+	_ context.Context,
+	coachID int64,
+	templateID int64,
+	_ services.UpdateProgramInput,
+) (*models.ProgramTemplate, error) {
+	return &models.ProgramTemplate{ID: templateID, CoachID: coachID}, nil
+}
+
+func (s *stubProgramTemplateService) DeleteTemplate(_ context.Context, _ int64, _ int64) error {
+	return nil
+}
+
+func (s *stubProgramTemplateService) AssignTemplate(
+	_ context.Context,
+	coachID int64,
+	templateID int64,
+	startDate *time.Time,
+	assignments []services.ProgramAssignment,
+) ([]models.WorkoutProgram, error) {
+	s.lastStartDate = startDate
+	s.lastAssignments = assignments
+	programs := make([]models.WorkoutProgram, 0, len(assignments))
+	for i, assignment := range assignments {
+		programs = append(programs, models.WorkoutProgram{
+			ID:         int64(i + 1),
+			CoachID:    coachID,
+			UserID:     assignment.UserID,
+			TemplateID: &templateID,
+		})
+	}
+	return programs, nil
+}
+
+func newProgramTemplateTestApp(service *stubProgramTemplateService, role string) *fiber.App {
+	handler := NewProgramTemplateHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Post("/api/v1/program-templates", handler.CreateTemplate)
+	app.Post("/api/v1/program-templates/:id/assign", handler.AssignTemplate)
+	return app
+}
+
+func TestCreateProgramTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "from phases",
+			role:       "coach",
+			body:       `{"title":"Beginner strength","phases":[{"name":"Base","weeks":[]}]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "from existing program",
+			role:       "coach",
+			body:       `{"title":"Beginner strength","source_program_id":9}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "users cannot create templates",
+			role:       "user",
+			body:       `{"title":"Beginner strength"}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "missing title",
+			role:       "coach",
+			body:       `{"title":"  "}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "phases and source together",
+			role:       "coach",
+			body:       `{"title":"Beginner strength","source_program_id":9,"phases":[{"name":"Base","weeks":[]}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubProgramTemplateService{}
+			app := newProgramTemplateTestApp(service, tt.role)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/program-templates", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestAssignProgramTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name: "shared start date",
+			role: "coach",
+			body: `{"start_date":"2030-04-01","assignments":[{"user_id":7},` +
+				`{"user_id":8,"start_date":"2030-04-08","weight_multiplier":0.8,` +
+				`"substitutions":[{"name":"Back Squat","replacement":"Goblet Squat"}]}]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "users cannot assign",
+			role:       "user",
+			body:       `{"start_date":"2030-04-01","assignments":[{"user_id":7}]}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "no assignments",
+			role:       "coach",
+			body:       `{"start_date":"2030-04-01","assignments":[]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing start date",
+			role:       "coach",
+			body:       `{"assignments":[{"user_id":7}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "timestamp instead of date",
+			role:       "coach",
+			body:       `{"start_date":"2030-04-01T00:00:00Z","assignments":[{"user_id":7}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing user",
+			role:       "coach",
+			body:       `{"start_date":"2030-04-01","assignments":[{"session_id":3}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubProgramTemplateService{}
+			app := newProgramTemplateTestApp(service, tt.role)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/v1/program-templates/5/assign",
+				bytes.NewBufferString(tt.body),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			if len(service.lastAssignments) != 2 || service.lastStartDate == nil {
+				t.Fatalf("unexpected assignments: %+v", service.lastAssignments)
+			}
+			second := service.lastAssignments[1]
+			if second.StartDate == nil || second.StartDate.Day() != 8 || len(second.Substitutions) != 1 ||
+				second.WeightMultiplier == nil {
+				t.Fatalf("unexpected second assignment: %+v", second)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type programVersionApplicationService interface {
+	ListVersions(ctx context.Context, actorID int64, role string, programID int64) ([]models.ProgramVersion, error)
+	GetVersion(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		programID int64,
+		version int,
+	) (*models.ProgramVersion, error)
+	GetVersionOn(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		programID int64,
+		day time.Time,
+	) (*models.ProgramVersion, error)
+	DiffVersions(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		programID int64,
+		fromVersion int,
+		toVersion int,
+	) (*models.ProgramVersionDiff, error)
+	GetVersionDownloadURL(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		programID int64,
+		version int,
+	) (string, error)
+}
+
+type ProgramVersionHandler struct {
+	service programVersionApplicationService
+}
+
+func NewProgramVersionHandler(service programVersionApplicationService) *ProgramVersionHandler {
+	return &ProgramVersionHandler{service: service}
+}
+
+func (h *ProgramVersionHandler) ListVersions(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	programID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || programID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid program id"})
+	}
+
+	versions, err := h.service.ListVersions(c.Context(), actorID, role, programID)
+	if err != nil {
+		return mapProgramVersionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"versions": versions})
+}
+
+func (h *ProgramVersionHandler) GetVersion(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	programID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || programID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid program id"})
+	}
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil || version <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid version"})
+	}
+
+	snapshot, err := h.service.GetVersion(c.Context(), actorID, role, programID, version)
+	if err != nil {
+		return mapProgramVersionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"version": snapshot})
+}
+
+// GetVersionOn answers which version was in effect on the `date` query parameter (YYYY-MM-DD).
+func (h *ProgramVersionHandler) GetVersionOn(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	programID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || programID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid program id"})
+	}
+
+	raw := c.Query("date")
+	day, err := parseCalendarDate(&raw)
+	if err != nil || day == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "date must be a YYYY-MM-DD date"})
+	}
+
+	snapshot, err := h.service.GetVersionOn(c.Context(), actorID, role, programID, *day)
+	if err != nil {
+		return mapProgramVersionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"version": snapshot})
+}
+
+func (h *ProgramVersionHandler) DiffVersions(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	programID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || programID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid program id"})
+	}
+
+	fromVersion := parsePositiveInt(c.Query("from"), 0)
+	toVersion := parsePositiveInt(c.Query("to"), 0)
+	if fromVersion == 0 || toVersion == 0 || fromVersion == toVersion {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "from and to must be two different version numbers"})
+	}
+
+	diff, err := h.service.DiffVersions(c.Context(), actorID, role, programID, fromVersion, toVersion)
+	if err != nil {
+		return mapProgramVersionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"diff": diff})
+}
+
+func (h *ProgramVersionHandler) DownloadVersion(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	programID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || programID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid program id"})
+	}
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil || version <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid version"})
+	}
+
+	signedURL, err := h.service.GetVersionDownloadURL(c.Context(), actorID, role, programID, version)
+	if err != nil {
+		return mapProgramVersionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"download_url": signedURL, "expires_in_seconds": 3600})
+}
+
+func mapProgramVersionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrStorageUnavailable):
+		return c.Status(fiber.StatusServiceUnavailable).
+			JSON(fiber.Map{"error": "Storage service is not configured"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Program or version not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).
+			JSON(fiber.Map{"error": "Failed to process program version request"})
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+type stubProgramVersionService struct {
+	lastDay  time.Time
+	lastFrom int
+	lastTo   int
+}
+
+func (s *stubProgramVersionService) ListVersions(
+	_ context.Context,
+	_ int64,
+	_ string,
+	_ int64,
+) ([]models.ProgramVersion, error) {
+	return []models.ProgramVersion{}, nil
+}
+
+func (s *stubProgramVersionService) GetVersion(
+	_ context.Context,
+	_ int64,
+	_ string,
+	programID int64,
+	version int,
+) (*models.ProgramVersion, error) {
+	return &models.ProgramVersion{ProgramID: programID, Version: version}, nil
+}
+
+func (s *stubProgramVersionService) GetVersionOn(
+	_ context.Context,
+	_ int64,
+	_ string,
+	programID int64,
+	day time.Time,
+) (*models.ProgramVersion, error) {
+	s.lastDay = day
+	return &models.ProgramVersion{ProgramID: programID, Version: 1}, nil
+}
+
+func (s *stubProgramVersionService) DiffVersions(
+	_ context.Context,
+	_ int64,
+	_ string,
+	programID int64,
+	fromVersion int,
+	toVersion int,
+) (*models.ProgramVersionDiff, error) {
+	s.lastFrom = fromVersion
+	s.lastTo = toVersion
+	return &models.ProgramVersionDiff{ProgramID: programID, FromVersion: fromVersion, ToVersion: toVersion}, nil
+}
+
+func (s *stubProgramVersionService) GetVersionDownloadURL(
+	_ context.Context,
+	_ int64,
+	_ string,
+	_ int64,
+	_ int,
+) (string, error) {
+	return "https://storage/signed", nil
+}
+
+func newProgramVersionTestApp(service *stubProgramVersionService, role string) *fiber.App {
+	handler := NewProgramVersionHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Get("/api/v1/programs/:id/versions", handler.ListVersions)
+	app.Get("/api/v1/programs/:id/versions/diff", handler.DiffVersions)
+	app.Get("/api/v1/programs/:id/versions/on", handler.GetVersionOn)
+	app.Get("/api/v1/programs/:id/versions/:version", handler.GetVersion)
+	return app
+}
+
+func TestProgramVersionRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		target     string
+		wantStatus int
+	}{
+		{name: "client lists versions", role: "user", target: "/api/v1/programs/3/versions", wantStatus: http.StatusOK},
+		{name: "admin forbidden", role: "admin", target: "/api/v1/programs/3/versions", wantStatus: http.StatusForbidden},
+		{name: "get version", role: "coach", target: "/api/v1/programs/3/versions/2", wantStatus: http.StatusOK},
+		{name: "bad version", role: "coach", target: "/api/v1/programs/3/versions/zero", wantStatus: http.StatusBadRequest},
+		{name: "diff", role: "user", target: "/api/v1/programs/3/versions/diff?from=1&to=3", wantStatus: http.StatusOK},
+		{name: "diff same version", role: "user", target: "/api/v1/programs/3/versions/diff?from=2&to=2", wantStatus: http.StatusBadRequest},
+		{name: "diff missing to", role: "user", target: "/api/v1/programs/3/versions/diff?from=1", wantStatus: http.StatusBadRequest},
+		{name: "version on date", role: "user", target: "/api/v1/programs/3/versions/on?date=2030-04-02", wantStatus: http.StatusOK},
+		{name: "version on missing date", role: "user", target: "/api/v1/programs/3/versions/on", wantStatus: http.StatusBadRequest},
+		{name: "version on timestamp", role: "user", target: "/api/v1/programs/3/versions/on?date=2030-04-02T10:00:00Z", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubProgramVersionService{}
+			app := newProgramVersionTestApp(service, tt.role)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestProgramVersionOnPassesDay(t *testing.T) {
+	service := &stubProgramVersionService{}
+	app := newProgramVersionTestApp(service, "user")
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/programs/3/versions/on?date=2030-04-02", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+
+	if want := time.Date(2030, 4, 2, 0, 0, 0, 0, time.UTC); !service.lastDay.Equal(want) {
+		t.Fatalf("expected %v, got %v", want, service.lastDay)
+	}
+}
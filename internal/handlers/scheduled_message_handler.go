@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type scheduledMessageApplicationService interface {
+	ScheduleMessage(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		conversationID int64,
+		content string,
+		sendAt time.Time,
+	) (*models.ScheduledMessage, error)
+	ListScheduledMessages(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		status string,
+		limit int,
+		offset int,
+	) ([]models.ScheduledMessage, int, error)
+	CancelScheduledMessage(ctx context.Context, actorID int64, role string, scheduledID int64) (*models.ScheduledMessage, error)
+	GetAutoReply(ctx context.Context, actorID int64, role string) (*models.CoachAutoReply, error)
+	UpdateAutoReply(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		input repository.CoachAutoReplyInput,
+	) (*models.CoachAutoReply, error)
+}
+
+type ScheduledMessageHandler struct {
+	service scheduledMessageApplicationService
+}
+
+func NewScheduledMessageHandler(service scheduledMessageApplicationService) *ScheduledMessageHandler {
+	return &ScheduledMessageHandler{service: service}
+}
+
+type scheduleMessageRequest struct {
+	Content string  `json:"content"`
+	SendAt  *string `json:"send_at"`
+}
+
+type updateAutoReplyRequest struct {
+	Enabled     bool    `json:"enabled"`
+	Message     string  `json:"message"`
+	Timezone    string  `json:"timezone"`
+	WorkingDays []int   `json:"working_days"`
+	WorkStart   string  `json:"work_start"`
+	WorkEnd     string  `json:"work_end"`
+	AwayFrom    *string `json:"away_from"`
+	AwayUntil   *string `json:"away_until"`
+}
+
+// ScheduleMessage queues a message from the coach to be posted in the conversation at send_at.
+func (h *ScheduledMessageHandler) ScheduleMessage(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	conversationID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation id"})
+	}
+
+	var req scheduleMessageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	sendAt, err := parseOptionalTimestamp(req.SendAt)
+	if err != nil || sendAt == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "send_at must be a valid RFC3339 timestamp"})
+	}
+
+	scheduled, err := h.service.ScheduleMessage(c.Context(), coachID, role, conversationID, req.Content, *sendAt)
+	if err != nil {
+		return mapScheduledMessageError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"scheduled_message": scheduled})
+}
+
+func (h *ScheduledMessageHandler) ListScheduledMessages(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	page := parsePositiveInt(c.Query("page"), 1)
+	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	status := strings.ToLower(strings.TrimSpace(c.Query("status")))
+
+	messages, total, err := h.service.ListScheduledMessages(c.Context(), coachID, role, status, limit, (page-1)*limit)
+	if err != nil {
+		return mapScheduledMessageError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"scheduled_messages": messages,
+		"pagination":         buildPaginationMeta(page, limit, total),
+	})
+}
+
+// CancelScheduledMessage cancels a message that has not been sent yet.
+func (h *ScheduledMessageHandler) CancelScheduledMessage(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	scheduledID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || scheduledID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid scheduled message id"})
+	}
+
+	scheduled, err := h.service.CancelScheduledMessage(c.Context(), coachID, role, scheduledID)
+	if err != nil {
+		return mapScheduledMessageError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"scheduled_message": scheduled})
+}
+
+func (h *ScheduledMessageHandler) GetAutoReply(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	autoReply, err := h.service.GetAutoReply(c.Context(), coachID, role)
+	if err != nil {
+		return mapScheduledMessageError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"auto_reply": autoReply})
+}
+
+// UpdateAutoReply replaces the coach's auto-reply settings.
+func (h *ScheduledMessageHandler) UpdateAutoReply(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req updateAutoReplyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	awayFrom, err := parseOptionalTimestamp(req.AwayFrom)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "away_from must be a valid RFC3339 timestamp"})
+	}
+	awayUntil, err := parseOptionalTimestamp(req.AwayUntil)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "away_until must be a valid RFC3339 timestamp"})
+	}
+
+	autoReply, err := h.service.UpdateAutoReply(c.Context(), coachID, role, repository.CoachAutoReplyInput{
+		Enabled:     req.Enabled,
+		Message:     req.Message,
+		Timezone:    req.Timezone,
+		WorkingDays: req.WorkingDays,
+		WorkStart:   strings.TrimSpace(req.WorkStart),
+		WorkEnd:     strings.TrimSpace(req.WorkEnd),
+		AwayFrom:    awayFrom,
+		AwayUntil:   awayUntil,
+	})
+	if err != nil {
+		return mapScheduledMessageError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"auto_reply": autoReply})
+}
+
+func mapScheduledMessageError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrMessageBlocked):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "Message blocked by moderation"})
+	case errors.Is(err, services.ErrNotPending):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Scheduled message is no longer pending"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Scheduled message not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process scheduled message request"})
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubScheduledMessageService struct {
+	scheduleErr   error
+	cancelErr     error
+	lastSendAt    time.Time
+	lastAutoReply repository.CoachAutoReplyInput
+}
+
+func (s *stubScheduledMessageService) ScheduleMessage(
+	_ context.Context,
+	actorID int64,
+	_ string,
+	conversationID int64,
+	content string,
+	sendAt time.Time,
+) (*models.ScheduledMessage, error) {
+	s.lastSendAt = sendAt
+	if s.scheduleErr != nil {
+		return nil, s.scheduleErr
+	}
+	return &models.ScheduledMessage{
+		ID:             1,
+		ConversationID: conversationID,
+		SenderID:       actorID,
+		Content:        content,
+		SendAt:         sendAt,
+		Kind:           models.ScheduledMessageKindScheduled,
+		Status:         "pending",
+	}, nil
+}
+
+func (s *stubScheduledMessageService) ListScheduledMessages(
+	_ context.Context,
+	_ int64,
+	_ string,
+	_ string,
+	_ int,
+	_ int,
+) ([]models.ScheduledMessage, int, error) {
+	return []models.ScheduledMessage{}, 0, nil
+}
+
+func (s *stubScheduledMessageService) CancelScheduledMessage(
+	_ context.Context,
+	_ int64,
+	_ string,
+	scheduledID int64,
+) (*models.ScheduledMessage, error) {
+	if s.cancelErr != nil {
+		return nil, s.cancelErr
+	}
+	return &models.ScheduledMessage{ID: scheduledID, Status: "cancelled"}, nil
+}
+
+func (s *stubScheduledMessageService) GetAutoReply(_ context.Context, actorID int64, _ string) (*models.CoachAutoReply, error) {
+	return &models.CoachAutoReply{CoachID: actorID}, nil
+}
+
+func (s *stubScheduledMessageService) UpdateAutoReply(
+	_ context.Context,
+	actorID int64,
+	_ string,
+	input repository.CoachAutoReplyInput,
+) (*models.CoachAutoReply, error) {
+	s.lastAutoReply = input
+	return &models.CoachAutoReply{CoachID: actorID, Enabled: input.Enabled, Message: input.Message}, nil
+}
+
+func newScheduledMessageTestApp(service *stubScheduledMessageService, role string) *fiber.App {
+	handler := NewScheduledMessageHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "7")
+		return c.Next()
+	})
+	app.Post("/api/v1/conversations/:id/scheduled-messages", handler.ScheduleMessage)
+	app.Delete("/api/v1/scheduled-messages/:id", handler.CancelScheduledMessage)
+	app.Put("/api/v1/auto-reply", handler.UpdateAutoReply)
+	return app
+}
+
+func TestScheduleMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        string
+		body        string
+		scheduleErr error
+		wantStatus  int
+	}{
+		{name: "scheduled", role: "coach", body: `{"content":"Good luck today!","send_at":"2026-03-05T07:30:00+01:00"}`, wantStatus: http.StatusCreated},
+		{name: "client cannot schedule", role: "user", body: `{"content":"Hi","send_at":"2026-03-05T07:30:00Z"}`, wantStatus: http.StatusForbidden},
+		{name: "missing send_at", role: "coach", body: `{"content":"Hi"}`, wantStatus: http.StatusBadRequest},
+		{name: "bad send_at", role: "coach", body: `{"content":"Hi","send_at":"tomorrow"}`, wantStatus: http.StatusBadRequest},
+		{name: "blocked content", role: "coach", body: `{"content":"Hi","send_at":"2026-03-05T07:30:00Z"}`, scheduleErr: services.ErrMessageBlocked, wantStatus: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubScheduledMessageService{scheduleErr: tt.scheduleErr}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/conversations/3/scheduled-messages", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := newScheduledMessageTestApp(service, tt.role).Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	service := &stubScheduledMessageService{}
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/conversations/3/scheduled-messages",
+		strings.NewReader(`{"content":"Hi","send_at":"2026-03-05T07:30:00+01:00"}`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := newScheduledMessageTestApp(service, "coach").Test(req); err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if !service.lastSendAt.Equal(time.Date(2026, 3, 5, 6, 30, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected send_at %v", service.lastSendAt)
+	}
+}
+
+func TestCancelScheduledMessageAlreadySent(t *testing.T) {
+	service := &stubScheduledMessageService{cancelErr: services.ErrNotPending}
+	resp, err := newScheduledMessageTestApp(service, "coach").
+		Test(httptest.NewRequest(http.MethodDelete, "/api/v1/scheduled-messages/4", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", resp.StatusCode)
+	}
+}
+
+func TestUpdateAutoReply(t *testing.T) {
+	service := &stubScheduledMessageService{}
+	body := `{"enabled":true,"message":"Back soon","timezone":"Europe/Berlin","working_days":[1,2,3,4,5],` +
+		`"work_start":"09:00","work_end":"17:00","away_from":"2026-08-01T00:00:00Z","away_until":"2026-08-15T00:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/auto-reply", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := newScheduledMessageTestApp(service, "coach").Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	input := service.lastAutoReply
+	if !input.Enabled || input.Timezone != "Europe/Berlin" || len(input.WorkingDays) != 5 || input.AwayUntil == nil {
+		t.Fatalf("unexpected input %+v", input)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/auto-reply", strings.NewReader(`{"message":"Hi","away_from":"soon"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = newScheduledMessageTestApp(service, "coach").Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+}
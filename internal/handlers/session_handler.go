@@ -23,7 +23,7 @@ type sessionApplicationService interface {
 	ListSessions(ctx context.Context, actorID int64, role string, filter repository.SessionListFilter) ([]models.SessionDetail, error)
 	GetSession(ctx context.Context, actorID int64, role string, sessionID int64) (*models.SessionDetail, error)
 	UpdateStatus(ctx context.Context, actorID int64, role string, sessionID int64, requestedStatus string) (*models.SessionDetail, error)
-	PayForSession(ctx context.Context, actorID int64, role string, sessionID int64) (*models.SessionDetail, error)
+	PayForSession(ctx context.Context, actorID int64, role string, sessionID int64, couponCode *string) (*models.SessionDetail, error)
 }
 
 func NewSessionHandler(service *services.SessionService) *SessionHandler {
@@ -35,6 +35,11 @@ type bookSessionRequest struct {
 	ScheduledAt     string  `json:"scheduled_at"`
 	DurationMinutes int     `json:"duration_minutes"`
 	Notes           *string `json:"notes"`
+	CouponCode      *string `json:"coupon_code"`
+}
+
+type payForSessionRequest struct {
+	CouponCode *string `json:"coupon_code"`
 }
 
 type updateSessionStatusRequest struct {
@@ -67,12 +72,16 @@ func (h *SessionHandler) BookSession(c *fiber.Ctx) error {
 	if req.Notes != nil && strings.TrimSpace(*req.Notes) == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "notes must not be empty"})
 	}
+	if req.CouponCode != nil && strings.TrimSpace(*req.CouponCode) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "coupon_code must not be empty"})
+	}
 
 	detail, err := h.service.BookSession(c.Context(), userID, services.BookSessionInput{
 		CoachID:         req.CoachID,
 		ScheduledAt:     scheduledAt,
 		DurationMinutes: req.DurationMinutes,
 		Notes:           req.Notes,
+		CouponCode:      req.CouponCode,
 	})
 	if err != nil {
 		return mapSessionError(c, err)
@@ -177,7 +186,17 @@ func (h *SessionHandler) PayForSession(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid session id"})
 	}
 
-	session, err := h.service.PayForSession(c.Context(), userID, role, sessionID)
+	var req payForSessionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+	if req.CouponCode != nil && strings.TrimSpace(*req.CouponCode) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "coupon_code must not be empty"})
+	}
+
+	session, err := h.service.PayForSession(c.Context(), userID, role, sessionID, req.CouponCode)
 	if err != nil {
 		return mapSessionError(c, err)
 	}
@@ -195,6 +214,12 @@ func mapSessionError(c *fiber.Ctx, err error) error {
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Requested time conflicts with another session"})
 	case errors.Is(err, services.ErrInvalidStateTransition):
 		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidCoupon):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrCouponLimitReached):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrSubscriptionRequired), errors.Is(err, services.ErrSessionAllowanceExhausted):
+		return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{"error": err.Error()})
 	case errors.Is(err, services.ErrCoachNotFound):
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Coach not found"})
 	case errors.Is(err, pgx.ErrNoRows):
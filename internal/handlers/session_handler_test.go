@@ -34,6 +34,7 @@ type stubSessionService struct {
 	lastSessionID      int64
 	lastStatus         string
 	lastListFilter     repository.SessionListFilter
+	lastCouponCode     *string
 }
 
 func (s *stubSessionService) BookSession(_ context.Context, userID int64, input services.BookSessionInput) (*models.SessionDetail, error) {
@@ -64,10 +65,17 @@ func (s *stubSessionService) UpdateStatus(_ context.Context, actorID int64, role
 	return s.updateStatusResult, s.updateStatusErr
 }
 
-func (s *stubSessionService) PayForSession(_ context.Context, actorID int64, role string, sessionID int64) (*models.SessionDetail, error) {
+func (s *stubSessionService) PayForSession(
+	_ context.Context,
+	actorID int64,
+	role string,
+	sessionID int64,
+	couponCode *string,
+) (*models.SessionDetail, error) {
 	s.lastActorID = actorID
 	s.lastRole = role
 	s.lastSessionID = sessionID
+	s.lastCouponCode = couponCode
 	return s.payResult, s.payErr
 }
 
@@ -238,6 +246,7 @@ func TestUpdateStatusReturnsUnprocessableForInvalidTransition(t *testing.T) {
 
 func TestPayForSessionReturnsConfirmedSession(t *testing.T) {
 	now := time.Date(2026, 3, 20, 10, 0, 0, 0, time.UTC)
+	sessionID := int64(88)
 	service := &stubSessionService{
 		payResult: &models.SessionDetail{
 			Session: models.Session{
@@ -250,7 +259,7 @@ func TestPayForSessionReturnsConfirmedSession(t *testing.T) {
 			},
 			Payment: &models.Payment{
 				ID:        11,
-				SessionID: 88,
+				SessionID: &sessionID,
 				Status:    "paid",
 			},
 		},
@@ -290,6 +299,47 @@ func TestPayForSessionReturnsConfirmedSession(t *testing.T) {
 	}
 }
 
+func TestPayForSessionPassesCouponCodeAndMapsCouponErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "invalid coupon", err: services.ErrInvalidCoupon, expected: http.StatusUnprocessableEntity},
+		{name: "limit reached", err: services.ErrCouponLimitReached, expected: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubSessionService{payErr: tt.err}
+			handler := &SessionHandler{service: service}
+
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("role", "user")
+				c.Locals("user_id", "42")
+				return c.Next()
+			})
+			app.Post("/api/v1/sessions/:id/pay", handler.PayForSession)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/sessions/88/pay", strings.NewReader(`{"coupon_code":"first10"}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expected {
+				t.Fatalf("expected %d, got %d", tt.expected, resp.StatusCode)
+			}
+			if service.lastCouponCode == nil || *service.lastCouponCode != "first10" {
+				t.Fatalf("expected coupon code to be forwarded, got %v", service.lastCouponCode)
+			}
+		})
+	}
+}
+
 func TestMapSessionErrorDefaultsToInternalServerError(t *testing.T) {
 	app := fiber.New()
 	app.Get("/", func(c *fiber.Ctx) error {
@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type subscriptionApplicationService interface {
+	CreatePlan(ctx context.Context, coachID int64, input services.SubscriptionPlanInput) (*models.SubscriptionPlan, error)
+	UpdatePlan(ctx context.Context, coachID int64, planID int64, input repository.UpdateSubscriptionPlanInput) (*models.SubscriptionPlan, error)
+	ListPlans(ctx context.Context, actorID int64, role string, coachID int64) ([]models.SubscriptionPlan, error)
+	GetSettings(ctx context.Context, coachID int64) (*models.CoachSubscriptionSettings, error)
+	UpdateSettings(ctx context.Context, coachID int64, requiresSubscription bool) (*models.CoachSubscriptionSettings, error)
+	Subscribe(ctx context.Context, userID int64, planID int64) (*models.SubscriptionDetail, error)
+	ListSubscriptions(ctx context.Context, actorID int64, role string) ([]models.SubscriptionDetail, error)
+	GetSubscription(ctx context.Context, actorID int64, role string, subscriptionID int64) (*models.SubscriptionDetail, error)
+	ChangePlan(ctx context.Context, userID int64, subscriptionID int64, planID int64) (*models.SubscriptionDetail, *models.SubscriptionPlanChange, error)
+	Cancel(ctx context.Context, actorID int64, role string, subscriptionID int64) (*models.SubscriptionDetail, error)
+}
+
+type SubscriptionHandler struct {
+	service subscriptionApplicationService
+}
+
+type createSubscriptionPlanRequest struct {
+	Name                   string  `json:"name"`
+	Description            *string `json:"description"`
+	MonthlyPrice           float64 `json:"monthly_price"`
+	SessionsPerMonth       int     `json:"sessions_per_month"`
+	IncludesChat           *bool   `json:"includes_chat"`
+	IncludesProgramUpdates *bool   `json:"includes_program_updates"`
+	TrialDays              int     `json:"trial_days"`
+}
+
+type updateSubscriptionPlanRequest struct {
+	Name                   *string  `json:"name"`
+	Description            *string  `json:"description"`
+	MonthlyPrice           *float64 `json:"monthly_price"`
+	SessionsPerMonth       *int     `json:"sessions_per_month"`
+	IncludesChat           *bool    `json:"includes_chat"`
+	IncludesProgramUpdates *bool    `json:"includes_program_updates"`
+	TrialDays              *int     `json:"trial_days"`
+	IsActive               *bool    `json:"is_active"`
+}
+
+type subscriptionSettingsRequest struct {
+	RequiresSubscription *bool `json:"requires_subscription"`
+}
+
+type subscribeRequest struct {
+	PlanID int64 `json:"plan_id"`
+}
+
+func NewSubscriptionHandler(service subscriptionApplicationService) *SubscriptionHandler {
+	return &SubscriptionHandler{service: service}
+}
+
+func (h *SubscriptionHandler) CreatePlan(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req createSubscriptionPlanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name is required"})
+	}
+	if req.MonthlyPrice < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "monthly_price must not be negative"})
+	}
+	if req.SessionsPerMonth < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sessions_per_month must not be negative"})
+	}
+	if req.TrialDays < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "trial_days must not be negative"})
+	}
+
+	includesChat := true
+	if req.IncludesChat != nil {
+		includesChat = *req.IncludesChat
+	}
+	includesProgramUpdates := true
+	if req.IncludesProgramUpdates != nil {
+		includesProgramUpdates = *req.IncludesProgramUpdates
+	}
+
+	plan, err := h.service.CreatePlan(c.Context(), coachID, services.SubscriptionPlanInput{
+		Name:                   req.Name,
+		Description:            req.Description,
+		MonthlyPrice:           req.MonthlyPrice,
+		SessionsPerMonth:       req.SessionsPerMonth,
+		IncludesChat:           includesChat,
+		IncludesProgramUpdates: includesProgramUpdates,
+		TrialDays:              req.TrialDays,
+	})
+	if err != nil {
+		return mapSubscriptionError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"plan": plan})
+}
+
+func (h *SubscriptionHandler) UpdatePlan(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	planID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || planID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid plan id"})
+	}
+
+	var req updateSubscriptionPlanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	plan, err := h.service.UpdatePlan(c.Context(), coachID, planID, repository.UpdateSubscriptionPlanInput{
+		Name:                   req.Name,
+		Description:            req.Description,
+		MonthlyPrice:           req.MonthlyPrice,
+		SessionsPerMonth:       req.SessionsPerMonth,
+		IncludesChat:           req.IncludesChat,
+		IncludesProgramUpdates: req.IncludesProgramUpdates,
+		TrialDays:              req.TrialDays,
+		IsActive:               req.IsActive,
+	})
+	if err != nil {
+		return mapSubscriptionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"plan": plan})
+}
+
+func (h *SubscriptionHandler) ListPlans(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var coachID int64
+	if rawCoachID := strings.TrimSpace(c.Query("coach_id")); rawCoachID != "" {
+		coachID, err = strconv.ParseInt(rawCoachID, 10, 64)
+		if err != nil || coachID <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "coach_id must be a positive integer"})
+		}
+	} else if role == "user" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "coach_id is required"})
+	}
+
+	plans, err := h.service.ListPlans(c.Context(), actorID, role, coachID)
+	if err != nil {
+		return mapSubscriptionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"plans": plans})
+}
+
+func (h *SubscriptionHandler) GetSettings(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	settings, err := h.service.GetSettings(c.Context(), coachID)
+	if err != nil {
+		return mapSubscriptionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"settings": settings})
+}
+
+func (h *SubscriptionHandler) UpdateSettings(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "coach" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	coachID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req subscriptionSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.RequiresSubscription == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "requires_subscription is required"})
+	}
+
+	settings, err := h.service.UpdateSettings(c.Context(), coachID, *req.RequiresSubscription)
+	if err != nil {
+		return mapSubscriptionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"settings": settings})
+}
+
+func (h *SubscriptionHandler) Subscribe(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var req subscribeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.PlanID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "plan_id must be a positive integer"})
+	}
+
+	subscription, err := h.service.Subscribe(c.Context(), userID, req.PlanID)
+	if err != nil {
+		return mapSubscriptionError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"subscription": subscription})
+}
+
+func (h *SubscriptionHandler) ListSubscriptions(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	subscriptions, err := h.service.ListSubscriptions(c.Context(), actorID, role)
+	if err != nil {
+		return mapSubscriptionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"subscriptions": subscriptions})
+}
+
+func (h *SubscriptionHandler) GetSubscription(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	subscriptionID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || subscriptionID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid subscription id"})
+	}
+
+	subscription, err := h.service.GetSubscription(c.Context(), actorID, role, subscriptionID)
+	if err != nil {
+		return mapSubscriptionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"subscription": subscription})
+}
+
+func (h *SubscriptionHandler) ChangePlan(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	subscriptionID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || subscriptionID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid subscription id"})
+	}
+
+	var req subscribeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.PlanID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "plan_id must be a positive integer"})
+	}
+
+	subscription, change, err := h.service.ChangePlan(c.Context(), userID, subscriptionID, req.PlanID)
+	if err != nil {
+		return mapSubscriptionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"subscription": subscription, "plan_change": change})
+}
+
+func (h *SubscriptionHandler) Cancel(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	subscriptionID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || subscriptionID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid subscription id"})
+	}
+
+	subscription, err := h.service.Cancel(c.Context(), actorID, role, subscriptionID)
+	if err != nil {
+		return mapSubscriptionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"subscription": subscription})
+}
+
+func mapSubscriptionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, services.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "An open subscription with this coach already exists"})
+	case errors.Is(err, services.ErrInvalidStateTransition):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Subscription or plan not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process subscription request"})
+	}
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+var errTestGateway = errors.New("gateway unavailable")
+
+// stubSubscriptionService keeps one subscription whose status follows the gateway events it
+// receives, so webhook and API requests can be checked against each other.
+type stubSubscriptionService struct {
+	err          error
+	subscription models.SubscriptionDetail
+	lastPlanID   int64
+	lastCoachID  int64
+	lastRole     string
+	lastSettings *bool
+}
+
+func newStubSubscriptionService() *stubSubscriptionService {
+	gatewayID := "sub_1"
+	return &stubSubscriptionService{subscription: models.SubscriptionDetail{
+		Subscription: models.Subscription{
+			ID:                    5,
+			UserID:                42,
+			CoachID:               7,
+			PlanID:                3,
+			Status:                "trialing",
+			GatewaySubscriptionID: &gatewayID,
+		},
+	}}
+}
+
+func (s *stubSubscriptionService) CreatePlan(
+	_ context.Context,
+	coachID int64,
+	input services.SubscriptionPlanInput,
+) (*models.SubscriptionPlan, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.SubscriptionPlan{ID: 3, CoachID: coachID, Name: input.Name, MonthlyPrice: input.MonthlyPrice}, nil
+}
+
+func (s *stubSubscriptionService) UpdatePlan(
+	_ context.Context,
+	coachID int64,
+	planID int64,
+	_ repository.UpdateSubscriptionPlanInput,
+) (*models.SubscriptionPlan, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.SubscriptionPlan{ID: planID, CoachID: coachID}, nil
+}
+
+func (s *stubSubscriptionService) ListPlans(
+	_ context.Context,
+	_ int64,
+	role string,
+	coachID int64,
+) ([]models.SubscriptionPlan, error) {
+	s.lastRole = role
+	s.lastCoachID = coachID
+	return []models.SubscriptionPlan{}, s.err
+}
+
+func (s *stubSubscriptionService) GetSettings(_ context.Context, coachID int64) (*models.CoachSubscriptionSettings, error) {
+	return &models.CoachSubscriptionSettings{CoachID: coachID}, s.err
+}
+
+func (s *stubSubscriptionService) UpdateSettings(
+	_ context.Context,
+	coachID int64,
+	requiresSubscription bool,
+) (*models.CoachSubscriptionSettings, error) {
+	s.lastSettings = &requiresSubscription
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.CoachSubscriptionSettings{CoachID: coachID, RequiresSubscription: requiresSubscription}, nil
+}
+
+func (s *stubSubscriptionService) Subscribe(_ context.Context, _ int64, planID int64) (*models.SubscriptionDetail, error) {
+	s.lastPlanID = planID
+	if s.err != nil {
+		return nil, s.err
+	}
+	detail := s.subscription
+	return &detail, nil
+}
+
+func (s *stubSubscriptionService) ListSubscriptions(_ context.Context, _ int64, role string) ([]models.SubscriptionDetail, error) {
+	s.lastRole = role
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []models.SubscriptionDetail{s.subscription}, nil
+}
+
+func (s *stubSubscriptionService) GetSubscription(
+	_ context.Context,
+	_ int64,
+	_ string,
+	subscriptionID int64,
+) (*models.SubscriptionDetail, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if subscriptionID != s.subscription.ID {
+		return nil, pgx.ErrNoRows
+	}
+	detail := s.subscription
+	return &detail, nil
+}
+
+func (s *stubSubscriptionService) ChangePlan(
+	_ context.Context,
+	_ int64,
+	subscriptionID int64,
+	planID int64,
+) (*models.SubscriptionDetail, *models.SubscriptionPlanChange, error) {
+	s.lastPlanID = planID
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	change := &models.SubscriptionPlanChange{SubscriptionID: subscriptionID, FromPlanID: s.subscription.PlanID, ToPlanID: planID}
+	s.subscription.PlanID = planID
+	detail := s.subscription
+	return &detail, change, nil
+}
+
+func (s *stubSubscriptionService) Cancel(
+	_ context.Context,
+	_ int64,
+	role string,
+	_ int64,
+) (*models.SubscriptionDetail, error) {
+	s.lastRole = role
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.subscription.CancelAtPeriodEnd = true
+	detail := s.subscription
+	return &detail, nil
+}
+
+func (s *stubSubscriptionService) HandleGatewayEvent(_ context.Context, event services.GatewayEvent) error {
+	if event.Data.SubscriptionID != *s.subscription.GatewaySubscriptionID {
+		return pgx.ErrNoRows
+	}
+	switch event.Type {
+	case "subscription.activated", "subscription.renewed":
+		if s.subscription.Status == "cancelled" {
+			return services.ErrInvalidStateTransition
+		}
+		s.subscription.Status = "active"
+	case "subscription.payment_failed":
+		if s.subscription.Status == "cancelled" {
+			return services.ErrInvalidStateTransition
+		}
+		s.subscription.Status = "past_due"
+	case "subscription.cancelled":
+		s.subscription.Status = "cancelled"
+	default:
+		return services.ErrUnsupportedGatewayEvent
+	}
+	return nil
+}
+
+func newSubscriptionTestApp(service *stubSubscriptionService, role string) *fiber.App {
+	handler := NewSubscriptionHandler(service)
+	webhookHandler := NewPaymentWebhookHandler(service, "whsec")
+	app := fiber.New()
+	app.Post("/api/webhooks/payments", webhookHandler.HandleEvent)
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Post("/api/v1/subscription-plans", handler.CreatePlan)
+	app.Get("/api/v1/subscription-plans", handler.ListPlans)
+	app.Put("/api/v1/subscription-plans/:id", handler.UpdatePlan)
+	app.Get("/api/v1/coaches/subscription-settings", handler.GetSettings)
+	app.Put("/api/v1/coaches/subscription-settings", handler.UpdateSettings)
+	app.Post("/api/v1/subscriptions", handler.Subscribe)
+	app.Get("/api/v1/subscriptions", handler.ListSubscriptions)
+	app.Get("/api/v1/subscriptions/:id", handler.GetSubscription)
+	app.Put("/api/v1/subscriptions/:id/plan", handler.ChangePlan)
+	app.Post("/api/v1/subscriptions/:id/cancel", handler.Cancel)
+	return app
+}
+
+func TestSubscriptionRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		method     string
+		target     string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{name: "coach creates plan", role: "coach", method: http.MethodPost, target: "/api/v1/subscription-plans", body: `{"name":"Monthly","monthly_price":120,"sessions_per_month":4}`, wantStatus: http.StatusCreated},
+		{name: "client cannot create plan", role: "user", method: http.MethodPost, target: "/api/v1/subscription-plans", body: `{"name":"Monthly"}`, wantStatus: http.StatusForbidden},
+		{name: "plan needs a name", role: "coach", method: http.MethodPost, target: "/api/v1/subscription-plans", body: `{"name":" "}`, wantStatus: http.StatusBadRequest},
+		{name: "negative price", role: "coach", method: http.MethodPost, target: "/api/v1/subscription-plans", body: `{"name":"Monthly","monthly_price":-1}`, wantStatus: http.StatusBadRequest},
+		{name: "negative trial", role: "coach", method: http.MethodPost, target: "/api/v1/subscription-plans", body: `{"name":"Monthly","trial_days":-3}`, wantStatus: http.StatusBadRequest},
+		{name: "update someone else's plan", role: "coach", method: http.MethodPut, target: "/api/v1/subscription-plans/3", body: `{"is_active":false}`, err: services.ErrForbidden, wantStatus: http.StatusForbidden},
+		{name: "bad plan id", role: "coach", method: http.MethodPut, target: "/api/v1/subscription-plans/abc", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "client lists a coach's plans", role: "user", method: http.MethodGet, target: "/api/v1/subscription-plans?coach_id=7", wantStatus: http.StatusOK},
+		{name: "client must name the coach", role: "user", method: http.MethodGet, target: "/api/v1/subscription-plans", wantStatus: http.StatusBadRequest},
+		{name: "settings need requires_subscription", role: "coach", method: http.MethodPut, target: "/api/v1/coaches/subscription-settings", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "coach updates settings", role: "coach", method: http.MethodPut, target: "/api/v1/coaches/subscription-settings", body: `{"requires_subscription":true}`, wantStatus: http.StatusOK},
+		{name: "client subscribes", role: "user", method: http.MethodPost, target: "/api/v1/subscriptions", body: `{"plan_id":3}`, wantStatus: http.StatusCreated},
+		{name: "coach cannot subscribe", role: "coach", method: http.MethodPost, target: "/api/v1/subscriptions", body: `{"plan_id":3}`, wantStatus: http.StatusForbidden},
+		{name: "subscribe needs a plan", role: "user", method: http.MethodPost, target: "/api/v1/subscriptions", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "already subscribed", role: "user", method: http.MethodPost, target: "/api/v1/subscriptions", body: `{"plan_id":3}`, err: services.ErrConflict, wantStatus: http.StatusConflict},
+		{name: "unknown plan", role: "user", method: http.MethodPost, target: "/api/v1/subscriptions", body: `{"plan_id":3}`, err: pgx.ErrNoRows, wantStatus: http.StatusNotFound},
+		{name: "list subscriptions", role: "coach", method: http.MethodGet, target: "/api/v1/subscriptions", wantStatus: http.StatusOK},
+		{name: "unknown subscription", role: "user", method: http.MethodGet, target: "/api/v1/subscriptions/9", wantStatus: http.StatusNotFound},
+		{name: "client changes plan", role: "user", method: http.MethodPut, target: "/api/v1/subscriptions/5/plan", body: `{"plan_id":4}`, wantStatus: http.StatusOK},
+		{name: "coach cannot change plan", role: "coach", method: http.MethodPut, target: "/api/v1/subscriptions/5/plan", body: `{"plan_id":4}`, wantStatus: http.StatusForbidden},
+		{name: "plan change while past due", role: "user", method: http.MethodPut, target: "/api/v1/subscriptions/5/plan", body: `{"plan_id":4}`, err: services.ErrInvalidStateTransition, wantStatus: http.StatusUnprocessableEntity},
+		{name: "gateway refuses plan change", role: "user", method: http.MethodPut, target: "/api/v1/subscriptions/5/plan", body: `{"plan_id":4}`, err: errTestGateway, wantStatus: http.StatusInternalServerError},
+		{name: "coach cancels", role: "coach", method: http.MethodPost, target: "/api/v1/subscriptions/5/cancel", wantStatus: http.StatusOK},
+		{name: "cancel twice", role: "user", method: http.MethodPost, target: "/api/v1/subscriptions/5/cancel", err: services.ErrInvalidStateTransition, wantStatus: http.StatusUnprocessableEntity},
+		{name: "bad subscription id", role: "user", method: http.MethodPost, target: "/api/v1/subscriptions/0/cancel", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newStubSubscriptionService()
+			service.err = tt.err
+			app := newSubscriptionTestApp(service, tt.role)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.name == "client lists a coach's plans" && (service.lastCoachID != 7 || service.lastRole != "user") {
+				t.Fatalf("expected coach 7 listed for the client, got %d as %q", service.lastCoachID, service.lastRole)
+			}
+			if tt.name == "coach updates settings" && (service.lastSettings == nil || !*service.lastSettings) {
+				t.Fatalf("expected requires_subscription forwarded, got %v", service.lastSettings)
+			}
+			if tt.name == "client changes plan" && service.lastPlanID != 4 {
+				t.Fatalf("expected plan 4 forwarded, got %d", service.lastPlanID)
+			}
+		})
+	}
+}
+
+func TestSubscriptionLifecycleThroughWebhooks(t *testing.T) {
+	service := newStubSubscriptionService()
+	app := newSubscriptionTestApp(service, "user")
+
+	steps := []struct {
+		event       string
+		wantStatus  int
+		wantLocally string
+	}{
+		{event: "subscription.activated", wantStatus: http.StatusOK, wantLocally: "active"},
+		{event: "subscription.renewed", wantStatus: http.StatusOK, wantLocally: "active"},
+		{event: "subscription.payment_failed", wantStatus: http.StatusOK, wantLocally: "past_due"},
+		{event: "subscription.renewed", wantStatus: http.StatusOK, wantLocally: "active"},
+		{event: "subscription.cancelled", wantStatus: http.StatusOK, wantLocally: "cancelled"},
+		{event: "subscription.renewed", wantStatus: http.StatusUnprocessableEntity, wantLocally: "cancelled"},
+		{event: "subscription.paused", wantStatus: http.StatusBadRequest, wantLocally: "cancelled"},
+	}
+
+	for i, step := range steps {
+		body, err := json.Marshal(services.GatewayEvent{
+			ID:   "evt_" + step.event,
+			Type: step.event,
+			Data: services.GatewayEventData{SubscriptionID: "sub_1"},
+		})
+		if err != nil {
+			t.Fatalf("marshal event: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/webhooks/payments", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(paymentSignatureHeader, "sha256="+signPaymentPayload("whsec", body))
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("step %d: app.Test: %v", i, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != step.wantStatus {
+			t.Fatalf("step %d (%s): expected %d, got %d", i, step.event, step.wantStatus, resp.StatusCode)
+		}
+
+		resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/5", nil))
+		if err != nil {
+			t.Fatalf("step %d: app.Test: %v", i, err)
+		}
+		var payload struct {
+			Subscription models.SubscriptionDetail `json:"subscription"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			t.Fatalf("step %d: decode: %v", i, err)
+		}
+		resp.Body.Close()
+		if payload.Subscription.Status != step.wantLocally {
+			t.Fatalf("step %d (%s): expected %q, got %q", i, step.event, step.wantLocally, payload.Subscription.Status)
+		}
+	}
+}
+
+func TestSubscriptionWebhookForUnknownSubscription(t *testing.T) {
+	app := newSubscriptionTestApp(newStubSubscriptionService(), "user")
+
+	body := []byte(`{"id":"evt_x","type":"subscription.renewed","data":{"subscription_id":"sub_missing"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks/payments", bytes.NewReader(body))
+	req.Header.Set(paymentSignatureHeader, "sha256="+signPaymentPayload("whsec", body))
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+}
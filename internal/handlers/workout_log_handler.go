@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type workoutLogApplicationService interface {
+	CreateLog(ctx context.Context, userID int64, programID int64, input services.WorkoutLogInput) (*models.WorkoutLog, error)
+	ListLogs(ctx context.Context, actorID int64, role string, programID int64, limit int) ([]models.WorkoutLog, error)
+	DeleteLog(ctx context.Context, userID int64, logID int64) error
+	GetProgress(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		userID int64,
+		from *time.Time,
+		to *time.Time,
+	) (*models.ProgressReport, error)
+}
+
+type WorkoutLogHandler struct {
+	service workoutLogApplicationService
+}
+
+type workoutLogSetRequest struct {
+	ProgramExerciseID *int64   `json:"program_exercise_id"`
+	ExerciseID        *int64   `json:"exercise_id"`
+	ExerciseName      string   `json:"exercise_name"`
+	SetNumber         int      `json:"set_number"`
+	Reps              int      `json:"reps"`
+	WeightKg          *float64 `json:"weight_kg"`
+	RPE               *float64 `json:"rpe"`
+	Notes             *string  `json:"notes"`
+}
+
+type createWorkoutLogRequest struct {
+	ProgramDayID    int64                  `json:"program_day_id"`
+	PerformedAt     *string                `json:"performed_at"`
+	DurationMinutes *int                   `json:"duration_minutes"`
+	SessionRPE      *float64               `json:"session_rpe"`
+	Notes           *string                `json:"notes"`
+	Sets            []workoutLogSetRequest `json:"sets"`
+}
+
+func NewWorkoutLogHandler(service workoutLogApplicationService) *WorkoutLogHandler {
+	return &WorkoutLogHandler{service: service}
+}
+
+func (h *WorkoutLogHandler) CreateLog(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	programID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || programID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid program id"})
+	}
+
+	var req createWorkoutLogRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.ProgramDayID <= 0 {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "program_day_id must be a positive integer"})
+	}
+	performedAt, err := parseOptionalTimestamp(req.PerformedAt)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "performed_at must be a valid RFC3339 timestamp"})
+	}
+
+	input := services.WorkoutLogInput{
+		ProgramDayID:    req.ProgramDayID,
+		PerformedAt:     performedAt,
+		DurationMinutes: req.DurationMinutes,
+		SessionRPE:      req.SessionRPE,
+		Notes:           req.Notes,
+		Sets:            make([]services.WorkoutLogSetInput, 0, len(req.Sets)),
+	}
+	for _, set := range req.Sets {
+		if set.ProgramExerciseID == nil && strings.TrimSpace(set.ExerciseName) == "" {
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "each set needs program_exercise_id or exercise_name"})
+		}
+		input.Sets = append(input.Sets, services.WorkoutLogSetInput{
+			ProgramExerciseID: set.ProgramExerciseID,
+			ExerciseID:        set.ExerciseID,
+			ExerciseName:      set.ExerciseName,
+			SetNumber:         set.SetNumber,
+			Reps:              set.Reps,
+			WeightKg:          set.WeightKg,
+			RPE:               set.RPE,
+			Notes:             set.Notes,
+		})
+	}
+
+	log, err := h.service.CreateLog(c.Context(), userID, programID, input)
+	if err != nil {
+		return mapWorkoutLogError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"log": log})
+}
+
+func (h *WorkoutLogHandler) ListLogs(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	programID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || programID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid program id"})
+	}
+
+	limit := parsePositiveInt(c.Query("limit"), defaultPageLimit)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	logs, err := h.service.ListLogs(c.Context(), actorID, role, programID, limit)
+	if err != nil {
+		return mapWorkoutLogError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"logs": logs})
+}
+
+func (h *WorkoutLogHandler) DeleteLog(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || role != "user" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	userID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	logID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || logID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid workout log id"})
+	}
+
+	if err := h.service.DeleteLog(c.Context(), userID, logID); err != nil {
+		return mapWorkoutLogError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *WorkoutLogHandler) GetProgress(c *fiber.Ctx) error {
+	role, ok := c.Locals("role").(string)
+	if !ok || (role != "user" && role != "coach") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	actorID, err := parseProfileUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	var userID int64
+	if raw := strings.TrimSpace(c.Query("user_id")); raw != "" {
+		userID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || userID <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id must be a positive integer"})
+		}
+	}
+	if role == "coach" && userID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id is required"})
+	}
+
+	from, err := parseQueryTimestamp(c.Query("from"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from must be a valid RFC3339 timestamp"})
+	}
+	to, err := parseQueryTimestamp(c.Query("to"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "to must be a valid RFC3339 timestamp"})
+	}
+
+	report, err := h.service.GetProgress(c.Context(), actorID, role, userID, from, to)
+	if err != nil {
+		return mapWorkoutLogError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"progress": report})
+}
+
+func mapWorkoutLogError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	case errors.Is(err, pgx.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Workout log or program not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process workout log request"})
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+type stubWorkoutLogService struct {
+	lastInput  services.WorkoutLogInput
+	lastUserID int64
+	lastFrom   *time.Time
+}
+
+func (s *stubWorkoutLogService) CreateLog(
+	_ context.Context,
+	_ int64,
+	_ int64,
+	input services.WorkoutLogInput,
+) (*models.WorkoutLog, error) {
+	s.lastInput = input
+	return &models.WorkoutLog{ID: 1}, nil
+}
+
+func (s *stubWorkoutLogService) ListLogs(_ context.Context, _ int64, _ string, _ int64, _ int) ([]models.WorkoutLog, error) {
+	return []models.WorkoutLog{}, nil
+}
+
+func (s *stubWorkoutLogService) DeleteLog(_ context.Context, _ int64, _ int64) error {
+	return nil
+}
+
+func (s *stubWorkoutLogService) GetProgress(
+	_ context.Context,
+	_ int64,
+	_ string,
+	userID int64,
+	from *time.Time,
+	_ *time.Time,
+) (*models.ProgressReport, error) {
+	s.lastUserID = userID
+	s.lastFrom = from
+	return &models.ProgressReport{UserID: userID}, nil
+}
+
+func newWorkoutLogTestApp(service *stubWorkoutLogService, role string) *fiber.App {
+	handler := NewWorkoutLogHandler(service)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("user_id", "42")
+		return c.Next()
+	})
+	app.Post("/api/v1/programs/:id/logs", handler.CreateLog)
+	app.Get("/api/v1/progress", handler.GetProgress)
+	return app
+}
+
+func TestCreateWorkoutLogParsesSets(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "valid log",
+			role:       "user",
+			body:       `{"program_day_id":8,"performed_at":"2030-03-05T18:00:00Z","session_rpe":8,"sets":[{"program_exercise_id":21,"set_number":1,"reps":5,"weight_kg":100}]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "coach cannot log",
+			role:       "coach",
+			body:       `{"program_day_id":8}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "missing day",
+			role:       "user",
+			body:       `{"sets":[]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "set without exercise",
+			role:       "user",
+			body:       `{"program_day_id":8,"sets":[{"set_number":1,"reps":5}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "bad timestamp",
+			role:       "user",
+			body:       `{"program_day_id":8,"performed_at":"yesterday"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubWorkoutLogService{}
+			app := newWorkoutLogTestApp(service, tt.role)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/programs/10/logs", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			if service.lastInput.ProgramDayID != 8 || len(service.lastInput.Sets) != 1 {
+				t.Fatalf("unexpected input: %+v", service.lastInput)
+			}
+			if service.lastInput.PerformedAt == nil || service.lastInput.Sets[0].ProgramExerciseID == nil {
+				t.Fatalf("expected timestamp and program exercise id, got %+v", service.lastInput)
+			}
+		})
+	}
+}
+
+func TestGetProgressRequiresUserIDForCoach(t *testing.T) {
+	service := &stubWorkoutLogService{}
+	app := newWorkoutLogTestApp(service, "coach")
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/progress", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/progress?user_id=7&from=2030-01-01T00:00:00Z", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if service.lastUserID != 7 || service.lastFrom == nil {
+		t.Fatalf("expected user id and from to be forwarded, got %d %v", service.lastUserID, service.lastFrom)
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Activity is a workout imported from a watch or fitness app. HeartRateZones holds the seconds
+// spent in zones 1 to 5, when the file recorded heart rate.
+type Activity struct {
+	ID              int64     `json:"id"`
+	UserID          int64     `json:"user_id"`
+	ImportID        *int64    `json:"import_id,omitempty"`
+	Source          string    `json:"source"`
+	ActivityType    string    `json:"activity_type"`
+	StartedAt       time.Time `json:"started_at"`
+	DurationSeconds int       `json:"duration_seconds"`
+	DistanceM       *float64  `json:"distance_m,omitempty"`
+	AvgHeartRate    *int      `json:"avg_heart_rate,omitempty"`
+	MaxHeartRate    *int      `json:"max_heart_rate,omitempty"`
+	HeartRateZones  []int     `json:"heart_rate_zones,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ActivityImport records one uploaded file and what came of it. Activities lists the newly
+// imported activities in the upload response only.
+type ActivityImport struct {
+	ID                 int64      `json:"id"`
+	UserID             int64      `json:"user_id"`
+	Filename           string     `json:"filename"`
+	ActivitiesFound    int        `json:"activities_found"`
+	ActivitiesImported int        `json:"activities_imported"`
+	DuplicatesSkipped  int        `json:"duplicates_skipped"`
+	CreatedAt          time.Time  `json:"created_at"`
+	Activities         []Activity `json:"activities,omitempty"`
+}
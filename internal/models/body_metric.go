@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// BodyMeasurement is one dated entry in a client's measurement history. Any subset of the
+// metrics may be recorded.
+type BodyMeasurement struct {
+	ID               int64     `json:"id"`
+	UserID           int64     `json:"user_id"`
+	MeasuredAt       time.Time `json:"measured_at"`
+	WeightKg         *float64  `json:"weight_kg,omitempty"`
+	BodyFatPct       *float64  `json:"body_fat_pct,omitempty"`
+	ChestCm          *float64  `json:"chest_cm,omitempty"`
+	WaistCm          *float64  `json:"waist_cm,omitempty"`
+	HipsCm           *float64  `json:"hips_cm,omitempty"`
+	ArmCm            *float64  `json:"arm_cm,omitempty"`
+	ThighCm          *float64  `json:"thigh_cm,omitempty"`
+	RestingHeartRate *int      `json:"resting_heart_rate,omitempty"`
+	Notes            *string   `json:"notes,omitempty"`
+	Visibility       string    `json:"visibility"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ProgressPhoto never exposes its storage path; PhotoURL is a signed URL filled in per request.
+type ProgressPhoto struct {
+	ID            int64     `json:"id"`
+	UserID        int64     `json:"user_id"`
+	MeasurementID *int64    `json:"measurement_id,omitempty"`
+	FilePath      string    `json:"-"`
+	PhotoURL      string    `json:"photo_url,omitempty"`
+	Pose          string    `json:"pose"`
+	Visibility    string    `json:"visibility"`
+	TakenAt       time.Time `json:"taken_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type BodyMetricTrendPoint struct {
+	Date          time.Time `json:"date"`
+	Value         float64   `json:"value"`
+	MovingAverage float64   `json:"moving_average"`
+}
+
+type BodyMetricTrend struct {
+	UserID     int64                  `json:"user_id"`
+	Metric     string                 `json:"metric"`
+	WindowDays int                    `json:"window_days"`
+	From       time.Time              `json:"from"`
+	To         time.Time              `json:"to"`
+	Change     *float64               `json:"change,omitempty"`
+	Points     []BodyMetricTrendPoint `json:"points"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ChatBroadcast is a message a coach sends to a segment of their clients, one direct conversation
+// at a time. The counts report delivery progress.
+type ChatBroadcast struct {
+	ID                int64      `json:"id"`
+	CoachID           int64      `json:"coach_id"`
+	Content           string     `json:"content"`
+	Segment           string     `json:"segment"`
+	SessionsFrom      *time.Time `json:"sessions_from,omitempty"`
+	SessionsTo        *time.Time `json:"sessions_to,omitempty"`
+	ProgramTemplateID *int64     `json:"program_template_id,omitempty"`
+	Status            string     `json:"status"`
+	TotalCount        int        `json:"total_count"`
+	SentCount         int        `json:"sent_count"`
+	FailedCount       int        `json:"failed_count"`
+	PendingCount      int        `json:"pending_count"`
+	CreatedAt         time.Time  `json:"created_at"`
+	StartedAt         *time.Time `json:"started_at,omitempty"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+}
+
+// ChatBroadcastRecipient is one client's delivery of a broadcast. Error explains a failure.
+type ChatBroadcastRecipient struct {
+	BroadcastID int64      `json:"broadcast_id"`
+	UserID      int64      `json:"user_id"`
+	Status      string     `json:"status"`
+	MessageID   *int64     `json:"message_id,omitempty"`
+	Error       *string    `json:"error,omitempty"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
@@ -2,25 +2,110 @@ package models
 
 import "time"
 
+const (
+	ConversationKindDirect = "direct"
+	ConversationKindGroup  = "group"
+
+	ConversationRoleAdmin  = "admin"
+	ConversationRoleMember = "member"
+)
+
+// Conversation is either a direct chat between a client and a coach, linked to their coaching
+// relationship once they have one, or a group chat owned by CoachID. Groups have no UserID.
 type Conversation struct {
+	ID             int64     `json:"id"`
+	Kind           string    `json:"kind"`
+	Title          string    `json:"title,omitempty"`
+	UserID         int64     `json:"user_id,omitempty"`
+	CoachID        int64     `json:"coach_id"`
+	RelationshipID *int64    `json:"relationship_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ConversationMember is a current participant of a conversation.
+type ConversationMember struct {
+	ConversationID int64     `json:"conversation_id"`
+	UserID         int64     `json:"user_id"`
+	Role           string    `json:"role"`
+	JoinedAt       time.Time `json:"joined_at"`
+}
+
+// ChatMessage is a message in a conversation. Removed messages are tombstones: DeletedAt is set and
+// the content, attachments and reactions are left out.
+type ChatMessage struct {
+	ID             int64               `json:"id"`
+	ConversationID int64               `json:"conversation_id"`
+	SenderID       int64               `json:"sender_id"`
+	Content        string              `json:"content"`
+	IsRead         bool                `json:"is_read"`
+	CreatedAt      time.Time           `json:"created_at"`
+	EditedAt       *time.Time          `json:"edited_at,omitempty"`
+	DeletedAt      *time.Time          `json:"deleted_at,omitempty"`
+	Attachments    []MessageAttachment `json:"attachments,omitempty"`
+	Reactions      []MessageReaction   `json:"reactions,omitempty"`
+}
+
+// MessageEdit is an earlier version of an edited message; Content is the text the edit replaced.
+type MessageEdit struct {
 	ID        int64     `json:"id"`
+	MessageID int64     `json:"message_id"`
+	Content   string    `json:"content"`
+	EditedAt  time.Time `json:"edited_at"`
+}
+
+type MessageReaction struct {
+	MessageID int64     `json:"message_id"`
 	UserID    int64     `json:"user_id"`
-	CoachID   int64     `json:"coach_id"`
+	Emoji     string    `json:"emoji"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
 }
 
-type ChatMessage struct {
-	ID             int64     `json:"id"`
-	ConversationID int64     `json:"conversation_id"`
-	SenderID       int64     `json:"sender_id"`
-	Content        string    `json:"content"`
-	IsRead         bool      `json:"is_read"`
-	CreatedAt      time.Time `json:"created_at"`
+// MessageAttachment is a file sent in chat. Storage paths stay internal; participants receive
+// short-lived signed URLs.
+type MessageAttachment struct {
+	ID            int64     `json:"id"`
+	MessageID     int64     `json:"message_id"`
+	Kind          string    `json:"kind"`
+	FilePath      string    `json:"-"`
+	ThumbnailPath *string   `json:"-"`
+	Filename      string    `json:"filename"`
+	ContentType   string    `json:"content_type"`
+	SizeBytes     int64     `json:"size_bytes"`
+	Width         *int      `json:"width,omitempty"`
+	Height        *int      `json:"height,omitempty"`
+	URL           string    `json:"url,omitempty"`
+	ThumbnailURL  string    `json:"thumbnail_url,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// MessagePageInfo describes a page of history fetched by message id. Before is the oldest id on the
+// page and After the newest; HasMore reports whether there are more messages in the direction paged.
+type MessagePageInfo struct {
+	HasMore bool   `json:"has_more"`
+	Before  *int64 `json:"before,omitempty"`
+	After   *int64 `json:"after,omitempty"`
+}
+
+// ChatSearchResult is a message matching a chat search. Snippet is HTML-escaped, with the matching
+// words wrapped in <mark>.
+type ChatSearchResult struct {
+	Message ChatMessage `json:"message"`
+	Snippet string      `json:"snippet"`
 }
 
 type ConversationSummary struct {
 	Conversation
 	LastMessage *ChatMessage `json:"last_message,omitempty"`
 	UnreadCount int          `json:"unread_count"`
+	MemberCount int          `json:"member_count"`
+}
+
+// ChatEvent is a WebSocket frame kept for replay. Seq numbers a user's events without gaps.
+type ChatEvent struct {
+	UserID    int64     `json:"user_id"`
+	Seq       int64     `json:"seq"`
+	Type      string    `json:"type"`
+	Payload   []byte    `json:"payload"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// CheckInQuestion is one question on a check-in form. Min and Max bound scale and number
+// answers; Options lists the allowed answers for choice questions.
+type CheckInQuestion struct {
+	ID       string   `json:"id"`
+	Type     string   `json:"type"`
+	Prompt   string   `json:"prompt"`
+	Required bool     `json:"required"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+	Options  []string `json:"options,omitempty"`
+}
+
+type CheckInForm struct {
+	ID          int64             `json:"id"`
+	CoachID     int64             `json:"coach_id"`
+	Title       string            `json:"title"`
+	Description *string           `json:"description,omitempty"`
+	Questions   []CheckInQuestion `json:"questions"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// CheckInSchedule asks a client to answer a form every IntervalDays, starting on NextDueOn.
+type CheckInSchedule struct {
+	ID             int64     `json:"id"`
+	FormID         int64     `json:"form_id"`
+	FormTitle      string    `json:"form_title"`
+	CoachID        int64     `json:"coach_id"`
+	UserID         int64     `json:"user_id"`
+	RelationshipID *int64    `json:"relationship_id,omitempty"`
+	ClientName     *string   `json:"client_name,omitempty"`
+	IntervalDays   int       `json:"interval_days"`
+	NextDueOn      time.Time `json:"next_due_on"`
+	Active         bool      `json:"active"`
+	DaysOverdue    int       `json:"days_overdue"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// CheckInAnswer holds the answer to one question; only the field matching the question type is
+// set. Photo answers are stored privately and returned as signed URLs.
+type CheckInAnswer struct {
+	QuestionID string   `json:"question_id"`
+	Number     *float64 `json:"number,omitempty"`
+	Choice     *string  `json:"choice,omitempty"`
+	Text       *string  `json:"text,omitempty"`
+	PhotoPath  string   `json:"-"`
+	PhotoURL   string   `json:"photo_url,omitempty"`
+}
+
+type CheckInSubmission struct {
+	ID          int64             `json:"id"`
+	ScheduleID  *int64            `json:"schedule_id,omitempty"`
+	FormID      *int64            `json:"form_id,omitempty"`
+	CoachID     int64             `json:"coach_id"`
+	UserID      int64             `json:"user_id"`
+	ClientName  *string           `json:"client_name,omitempty"`
+	FormTitle   string            `json:"form_title"`
+	Questions   []CheckInQuestion `json:"questions"`
+	Answers     []CheckInAnswer   `json:"answers"`
+	DueOn       time.Time         `json:"due_on"`
+	SubmittedAt time.Time         `json:"submitted_at"`
+}
+
+// CheckInDashboard summarizes a coach's check-ins across all clients.
+type CheckInDashboard struct {
+	Overdue []CheckInSchedule   `json:"overdue"`
+	DueSoon []CheckInSchedule   `json:"due_soon"`
+	Recent  []CheckInSubmission `json:"recent"`
+}
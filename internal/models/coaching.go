@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// CoachingRelationship links a coach and a client. Only an active relationship gives the coach
+// access to the client's data and lets them assign new programs and check-ins.
+type CoachingRelationship struct {
+	ID         int64      `json:"id"`
+	CoachID    int64      `json:"coach_id"`
+	UserID     int64      `json:"user_id"`
+	CoachName  *string    `json:"coach_name,omitempty"`
+	ClientName *string    `json:"client_name,omitempty"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
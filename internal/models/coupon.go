@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type Coupon struct {
+	ID               int64      `json:"id"`
+	Code             string     `json:"code"`
+	CoachID          *int64     `json:"coach_id,omitempty"`
+	DiscountType     string     `json:"discount_type"`
+	DiscountValue    float64    `json:"discount_value"`
+	MaxRedemptions   *int       `json:"max_redemptions,omitempty"`
+	PerUserLimit     *int       `json:"per_user_limit,omitempty"`
+	RedemptionCount  int        `json:"redemption_count"`
+	FirstSessionOnly bool       `json:"first_session_only"`
+	ValidFrom        *time.Time `json:"valid_from,omitempty"`
+	ValidUntil       *time.Time `json:"valid_until,omitempty"`
+	IsActive         bool       `json:"is_active"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
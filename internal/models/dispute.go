@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+type Dispute struct {
+	ID                  int64            `json:"id"`
+	GatewayDisputeID    string           `json:"gateway_dispute_id"`
+	PaymentID           int64            `json:"payment_id"`
+	UserID              int64            `json:"user_id"`
+	CoachID             int64            `json:"coach_id"`
+	Amount              float64          `json:"amount"`
+	Reason              *string          `json:"reason,omitempty"`
+	Status              string           `json:"status"`
+	Evidence            *DisputeEvidence `json:"evidence,omitempty"`
+	EvidenceDueBy       *time.Time       `json:"evidence_due_by,omitempty"`
+	EvidenceSubmittedAt *time.Time       `json:"evidence_submitted_at,omitempty"`
+	ClosedAt            *time.Time       `json:"closed_at,omitempty"`
+	CreatedAt           time.Time        `json:"created_at"`
+	UpdatedAt           time.Time        `json:"updated_at"`
+}
+
+type DisputeEvidence struct {
+	Statement         *string                  `json:"statement,omitempty"`
+	Session           *DisputeSessionEvidence  `json:"session,omitempty"`
+	ChatExcerpts      []DisputeChatExcerpt     `json:"chat_excerpts"`
+	ProgramDeliveries []DisputeProgramDelivery `json:"program_deliveries"`
+	GatheredAt        time.Time                `json:"gathered_at"`
+}
+
+type DisputeSessionEvidence struct {
+	SessionID       int64     `json:"session_id"`
+	ScheduledAt     time.Time `json:"scheduled_at"`
+	DurationMinutes int       `json:"duration_minutes"`
+	Status          string    `json:"status"`
+	BookedAt        time.Time `json:"booked_at"`
+}
+
+type DisputeChatExcerpt struct {
+	SenderRole string    `json:"sender_role"`
+	Content    string    `json:"content"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+type DisputeProgramDelivery struct {
+	ProgramID   int64     `json:"program_id"`
+	SessionID   int64     `json:"session_id"`
+	Title       string    `json:"title"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+type LedgerAdjustment struct {
+	ID        int64     `json:"id"`
+	CoachID   int64     `json:"coach_id"`
+	PaymentID int64     `json:"payment_id"`
+	DisputeID *int64    `json:"dispute_id,omitempty"`
+	Kind      string    `json:"kind"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DisputeDetail struct {
+	Dispute
+	Adjustments []LedgerAdjustment `json:"adjustments"`
+}
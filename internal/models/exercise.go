@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type Exercise struct {
+	ID           int64     `json:"id"`
+	CoachID      *int64    `json:"coach_id,omitempty"`
+	Name         string    `json:"name"`
+	Aliases      []string  `json:"aliases"`
+	MuscleGroups []string  `json:"muscle_groups"`
+	Equipment    []string  `json:"equipment"`
+	Difficulty   string    `json:"difficulty"`
+	Instructions *string   `json:"instructions,omitempty"`
+	MediaURL     *string   `json:"media_url,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
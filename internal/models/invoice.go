@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type Invoice struct {
+	ID             int64     `json:"id"`
+	InvoiceNumber  string    `json:"invoice_number"`
+	Kind           string    `json:"kind"`
+	PaymentID      int64     `json:"payment_id"`
+	CoachID        int64     `json:"coach_id"`
+	UserID         int64     `json:"user_id"`
+	SequenceNumber int       `json:"sequence_number"`
+	Description    string    `json:"description"`
+	Subtotal       float64   `json:"subtotal"`
+	TaxRate        float64   `json:"tax_rate"`
+	TaxAmount      float64   `json:"tax_amount"`
+	Total          float64   `json:"total"`
+	SellerName     string    `json:"seller_name"`
+	SellerEmail    string    `json:"seller_email"`
+	SellerTaxID    *string   `json:"seller_tax_id,omitempty"`
+	SellerAddress  *string   `json:"seller_address,omitempty"`
+	BuyerName      string    `json:"buyer_name"`
+	BuyerEmail     string    `json:"buyer_email"`
+	PDFPath        *string   `json:"-"`
+	IssuedAt       time.Time `json:"issued_at"`
+}
+
+type CoachBillingDetails struct {
+	CoachID   int64     `json:"coach_id"`
+	LegalName *string   `json:"legal_name,omitempty"`
+	TaxID     *string   `json:"tax_id,omitempty"`
+	Address   *string   `json:"address,omitempty"`
+	TaxRate   float64   `json:"tax_rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
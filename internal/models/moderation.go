@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const (
+	ModerationSourceReport = "report"
+	ModerationSourceFilter = "filter"
+)
+
+// ModerationItem is an entry in the admin moderation queue: a report filed by a participant, or a
+// message the chat filters flagged, masked or blocked. Content is the text as it was sent; Rules
+// and Action are set for filter items, ReporterID, Reason and Details for reports.
+type ModerationItem struct {
+	ID             int64      `json:"id"`
+	Source         string     `json:"source"`
+	ConversationID int64      `json:"conversation_id"`
+	MessageID      *int64     `json:"message_id,omitempty"`
+	SenderID       *int64     `json:"sender_id,omitempty"`
+	ReporterID     *int64     `json:"reporter_id,omitempty"`
+	Reason         *string    `json:"reason,omitempty"`
+	Details        *string    `json:"details,omitempty"`
+	Content        string     `json:"content"`
+	Rules          []string   `json:"rules"`
+	Action         *string    `json:"action,omitempty"`
+	Status         string     `json:"status"`
+	ReviewedBy     *int64     `json:"reviewed_by,omitempty"`
+	ReviewNote     *string    `json:"review_note,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// UserBlock is a user the blocker no longer exchanges direct messages with.
+type UserBlock struct {
+	UserID    int64     `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// MacroTargets are daily intake targets. Grams are rounded to one decimal.
+type MacroTargets struct {
+	CaloriesKcal int     `json:"calories_kcal"`
+	ProteinG     float64 `json:"protein_g"`
+	CarbsG       float64 `json:"carbs_g"`
+	FatG         float64 `json:"fat_g"`
+}
+
+// NutritionPlan assigns daily targets and suggested meals to a client from StartDate until
+// EndDate; plans without an EndDate stay in effect.
+type NutritionPlan struct {
+	ID        int64          `json:"id"`
+	CoachID   int64          `json:"coach_id"`
+	UserID    int64          `json:"user_id"`
+	Title     string         `json:"title"`
+	Notes     *string        `json:"notes,omitempty"`
+	Targets   MacroTargets   `json:"targets"`
+	Meals     []MealTemplate `json:"meals"`
+	StartDate time.Time      `json:"start_date"`
+	EndDate   *time.Time     `json:"end_date,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// MealTemplate is a suggested meal within a plan, such as "Breakfast" at "07:30".
+type MealTemplate struct {
+	Name         string   `json:"name"`
+	TimeOfDay    *string  `json:"time_of_day,omitempty"`
+	CaloriesKcal *int     `json:"calories_kcal,omitempty"`
+	ProteinG     *float64 `json:"protein_g,omitempty"`
+	CarbsG       *float64 `json:"carbs_g,omitempty"`
+	FatG         *float64 `json:"fat_g,omitempty"`
+	Foods        []string `json:"foods,omitempty"`
+	Notes        *string  `json:"notes,omitempty"`
+}
+
+// MealLog is one food a client ate.
+type MealLog struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"user_id"`
+	Meal         string    `json:"meal"`
+	FoodName     string    `json:"food_name"`
+	Quantity     *string   `json:"quantity,omitempty"`
+	CaloriesKcal int       `json:"calories_kcal"`
+	ProteinG     float64   `json:"protein_g"`
+	CarbsG       float64   `json:"carbs_g"`
+	FatG         float64   `json:"fat_g"`
+	EatenAt      time.Time `json:"eaten_at"`
+	Notes        *string   `json:"notes,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// NutritionDay totals one UTC day of logged intake.
+type NutritionDay struct {
+	Date         time.Time `json:"date"`
+	CaloriesKcal int       `json:"calories_kcal"`
+	ProteinG     float64   `json:"protein_g"`
+	CarbsG       float64   `json:"carbs_g"`
+	FatG         float64   `json:"fat_g"`
+	Entries      int       `json:"entries"`
+	OnTarget     bool      `json:"on_target"`
+}
+
+// NutritionAdherence compares logged intake with a plan's targets for every day in the window.
+type NutritionAdherence struct {
+	PlanID        int64          `json:"plan_id"`
+	From          time.Time      `json:"from"`
+	To            time.Time      `json:"to"`
+	Targets       MacroTargets   `json:"targets"`
+	Average       MacroTargets   `json:"average"`
+	Days          []NutritionDay `json:"days"`
+	LoggedDays    int            `json:"logged_days"`
+	OnTargetDays  int            `json:"on_target_days"`
+	AdherenceRate float64        `json:"adherence_rate"`
+}
@@ -2,13 +2,112 @@ package models
 
 import "time"
 
+// WorkoutProgram is a client's program. SessionID is zero for programs not tied to a booking.
 type WorkoutProgram struct {
-	ID          int64     `json:"id"`
-	CoachID     int64     `json:"coach_id"`
-	UserID      int64     `json:"user_id"`
-	SessionID   int64     `json:"session_id"`
-	Title       string    `json:"title"`
-	Description *string   `json:"description,omitempty"`
-	FileURL     string    `json:"file_url"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID             int64          `json:"id"`
+	CoachID        int64          `json:"coach_id"`
+	UserID         int64          `json:"user_id"`
+	RelationshipID *int64         `json:"relationship_id,omitempty"`
+	SessionID      int64          `json:"session_id"`
+	TemplateID     *int64         `json:"template_id,omitempty"`
+	StartDate      *time.Time     `json:"start_date,omitempty"`
+	Title          string         `json:"title"`
+	Description    *string        `json:"description,omitempty"`
+	FileURL        string         `json:"file_url"`
+	Version        int            `json:"version"`
+	Phases         []ProgramPhase `json:"phases,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// ProgramVersion is an immutable snapshot of a program as it was published.
+type ProgramVersion struct {
+	ID            int64          `json:"id"`
+	ProgramID     int64          `json:"program_id"`
+	Version       int            `json:"version"`
+	Title         string         `json:"title"`
+	Description   *string        `json:"description,omitempty"`
+	FileURL       string         `json:"-"`
+	HasAttachment bool           `json:"has_attachment"`
+	Phases        []ProgramPhase `json:"phases,omitempty"`
+	ChangeNote    *string        `json:"change_note,omitempty"`
+	CreatedBy     *int64         `json:"created_by,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// ProgramVersionDiff lists what changed between two versions of a program. Exercises are matched
+// by phase name, week, day and exercise name.
+type ProgramVersionDiff struct {
+	ProgramID         int64                   `json:"program_id"`
+	FromVersion       int                     `json:"from_version"`
+	ToVersion         int                     `json:"to_version"`
+	Fields            []ProgramFieldChange    `json:"fields"`
+	AttachmentChanged bool                    `json:"attachment_changed"`
+	Exercises         []ProgramExerciseChange `json:"exercises"`
+}
+
+type ProgramFieldChange struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before,omitempty"`
+	After  *string `json:"after,omitempty"`
+}
+
+// ProgramExerciseChange is one added, removed or modified exercise.
+type ProgramExerciseChange struct {
+	Change     string           `json:"change"`
+	Phase      string           `json:"phase"`
+	WeekNumber int              `json:"week_number"`
+	DayNumber  int              `json:"day_number"`
+	Name       string           `json:"name"`
+	Before     *ProgramExercise `json:"before,omitempty"`
+	After      *ProgramExercise `json:"after,omitempty"`
+}
+
+// ProgramTemplate is a reusable program structure owned by a coach.
+type ProgramTemplate struct {
+	ID          int64          `json:"id"`
+	CoachID     int64          `json:"coach_id"`
+	Title       string         `json:"title"`
+	Description *string        `json:"description,omitempty"`
+	Phases      []ProgramPhase `json:"phases,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type ProgramPhase struct {
+	ID    int64         `json:"id"`
+	Name  string        `json:"name"`
+	Notes *string       `json:"notes,omitempty"`
+	Weeks []ProgramWeek `json:"weeks"`
+}
+
+type ProgramWeek struct {
+	ID         int64        `json:"id"`
+	WeekNumber int          `json:"week_number"`
+	Notes      *string      `json:"notes,omitempty"`
+	Days       []ProgramDay `json:"days"`
+}
+
+type ProgramDay struct {
+	ID        int64             `json:"id"`
+	DayNumber int               `json:"day_number"`
+	Name      *string           `json:"name,omitempty"`
+	Notes     *string           `json:"notes,omitempty"`
+	Exercises []ProgramExercise `json:"exercises"`
+}
+
+// ProgramExercise is one prescribed movement. Exercises sharing a SupersetGroup on the same day
+// are performed back to back.
+type ProgramExercise struct {
+	ID            int64    `json:"id"`
+	ExerciseID    *int64   `json:"exercise_id,omitempty"`
+	Name          string   `json:"name"`
+	SupersetGroup *string  `json:"superset_group,omitempty"`
+	Sets          *int     `json:"sets,omitempty"`
+	Reps          *string  `json:"reps,omitempty"`
+	WeightKg      *float64 `json:"weight_kg,omitempty"`
+	Tempo         *string  `json:"tempo,omitempty"`
+	RestSeconds   *int     `json:"rest_seconds,omitempty"`
+	RPE           *float64 `json:"rpe,omitempty"`
+	Notes         *string  `json:"notes,omitempty"`
 }
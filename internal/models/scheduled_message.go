@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+const (
+	ScheduledMessageKindScheduled = "scheduled"
+	ScheduledMessageKindAutoReply = "auto_reply"
+)
+
+// ScheduledMessage is a message queued to be posted at SendAt. MessageID is the message it became
+// once sent; Error explains a failure.
+type ScheduledMessage struct {
+	ID             int64      `json:"id"`
+	ConversationID int64      `json:"conversation_id"`
+	SenderID       int64      `json:"sender_id"`
+	Content        string     `json:"content"`
+	SendAt         time.Time  `json:"send_at"`
+	Kind           string     `json:"kind"`
+	Status         string     `json:"status"`
+	MessageID      *int64     `json:"message_id,omitempty"`
+	Error          *string    `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ProcessedAt    *time.Time `json:"processed_at,omitempty"`
+}
+
+// CoachAutoReply is the message a coach's clients get when they write while the coach is away:
+// outside WorkStart to WorkEnd on WorkingDays, in Timezone, or between AwayFrom and AwayUntil.
+// WorkingDays uses 0 for Sunday; an empty list means working hours do not apply.
+type CoachAutoReply struct {
+	CoachID     int64      `json:"coach_id"`
+	Enabled     bool       `json:"enabled"`
+	Message     string     `json:"message"`
+	Timezone    string     `json:"timezone"`
+	WorkingDays []int      `json:"working_days"`
+	WorkStart   string     `json:"work_start"`
+	WorkEnd     string     `json:"work_end"`
+	AwayFrom    *time.Time `json:"away_from,omitempty"`
+	AwayUntil   *time.Time `json:"away_until,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
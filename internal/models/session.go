@@ -15,13 +15,17 @@ type Session struct {
 }
 
 type Payment struct {
-	ID        int64     `json:"id"`
-	SessionID int64     `json:"session_id"`
-	UserID    int64     `json:"user_id"`
-	CoachID   int64     `json:"coach_id"`
-	Amount    float64   `json:"amount"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
+	ID             int64     `json:"id"`
+	SessionID      *int64    `json:"session_id"`
+	SubscriptionID *int64    `json:"subscription_id,omitempty"`
+	UserID         int64     `json:"user_id"`
+	CoachID        int64     `json:"coach_id"`
+	Amount         float64   `json:"amount"`
+	OriginalAmount *float64  `json:"original_amount,omitempty"`
+	DiscountAmount float64   `json:"discount_amount"`
+	CouponID       *int64    `json:"coupon_id,omitempty"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 type SessionDetail struct {
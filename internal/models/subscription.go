@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+type SubscriptionPlan struct {
+	ID                     int64     `json:"id"`
+	CoachID                int64     `json:"coach_id"`
+	Name                   string    `json:"name"`
+	Description            *string   `json:"description,omitempty"`
+	MonthlyPrice           float64   `json:"monthly_price"`
+	SessionsPerMonth       int       `json:"sessions_per_month"`
+	IncludesChat           bool      `json:"includes_chat"`
+	IncludesProgramUpdates bool      `json:"includes_program_updates"`
+	TrialDays              int       `json:"trial_days"`
+	IsActive               bool      `json:"is_active"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+type Subscription struct {
+	ID                    int64      `json:"id"`
+	UserID                int64      `json:"user_id"`
+	CoachID               int64      `json:"coach_id"`
+	PlanID                int64      `json:"plan_id"`
+	Status                string     `json:"status"`
+	GatewaySubscriptionID *string    `json:"gateway_subscription_id,omitempty"`
+	CurrentPeriodStart    time.Time  `json:"current_period_start"`
+	CurrentPeriodEnd      time.Time  `json:"current_period_end"`
+	CancelAtPeriodEnd     bool       `json:"cancel_at_period_end"`
+	CancelledAt           *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+type SubscriptionDetail struct {
+	Subscription
+	Plan              SubscriptionPlan `json:"plan"`
+	SessionsUsed      int              `json:"sessions_used"`
+	SessionsRemaining int              `json:"sessions_remaining"`
+}
+
+type SubscriptionPlanChange struct {
+	ID              int64     `json:"id"`
+	SubscriptionID  int64     `json:"subscription_id"`
+	FromPlanID      int64     `json:"from_plan_id"`
+	ToPlanID        int64     `json:"to_plan_id"`
+	ProrationAmount float64   `json:"proration_amount"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type CoachSubscriptionSettings struct {
+	CoachID              int64     `json:"coach_id"`
+	RequiresSubscription bool      `json:"requires_subscription"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
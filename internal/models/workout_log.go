@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// WorkoutLog records one performed program day. The day is identified by its phase position,
+// week and day number so logs survive structure edits.
+type WorkoutLog struct {
+	ID              int64           `json:"id"`
+	ProgramID       int64           `json:"program_id"`
+	UserID          int64           `json:"user_id"`
+	CoachID         int64           `json:"coach_id"`
+	PhasePosition   int             `json:"phase_position"`
+	WeekNumber      int             `json:"week_number"`
+	DayNumber       int             `json:"day_number"`
+	PerformedAt     time.Time       `json:"performed_at"`
+	DurationMinutes *int            `json:"duration_minutes,omitempty"`
+	SessionRPE      *float64        `json:"session_rpe,omitempty"`
+	Notes           *string         `json:"notes,omitempty"`
+	Sets            []WorkoutLogSet `json:"sets"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+type WorkoutLogSet struct {
+	ID                 int64    `json:"id"`
+	ExerciseID         *int64   `json:"exercise_id,omitempty"`
+	ExerciseName       string   `json:"exercise_name"`
+	SetNumber          int      `json:"set_number"`
+	Reps               int      `json:"reps"`
+	WeightKg           *float64 `json:"weight_kg,omitempty"`
+	RPE                *float64 `json:"rpe,omitempty"`
+	Notes              *string  `json:"notes,omitempty"`
+	EstimatedOneRepMax *float64 `json:"estimated_1rm,omitempty"`
+}
+
+type ProgramAdherence struct {
+	ProgramID     int64      `json:"program_id"`
+	Title         string     `json:"title"`
+	PlannedDays   int        `json:"planned_days"`
+	CompletedDays int        `json:"completed_days"`
+	AdherenceRate float64    `json:"adherence_rate"`
+	LastLoggedAt  *time.Time `json:"last_logged_at,omitempty"`
+}
+
+type MuscleGroupVolume struct {
+	WeekStart   time.Time `json:"week_start"`
+	MuscleGroup string    `json:"muscle_group"`
+	Sets        int       `json:"sets"`
+	VolumeKg    float64   `json:"volume_kg"`
+}
+
+type OneRepMaxPoint struct {
+	WeekStart          time.Time `json:"week_start"`
+	EstimatedOneRepMax float64   `json:"estimated_1rm"`
+}
+
+type ExerciseProgression struct {
+	ExerciseID   *int64           `json:"exercise_id,omitempty"`
+	ExerciseName string           `json:"exercise_name"`
+	Points       []OneRepMaxPoint `json:"points"`
+}
+
+type ProgressReport struct {
+	UserID       int64                 `json:"user_id"`
+	From         time.Time             `json:"from"`
+	To           time.Time             `json:"to"`
+	Adherence    []ProgramAdherence    `json:"adherence"`
+	WeeklyVolume []MuscleGroupVolume   `json:"weekly_volume"`
+	Progression  []ExerciseProgression `json:"progression"`
+}
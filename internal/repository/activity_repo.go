@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const activityColumns = `id, user_id, import_id, source, activity_type, started_at, duration_seconds,
+	distance_m::DOUBLE PRECISION, avg_heart_rate, max_heart_rate, heart_rate_zones, created_at`
+
+const activityImportColumns = `id, user_id, filename, activities_found, activities_imported,
+	duplicates_skipped, created_at`
+
+// activityDuplicateWindow is how far apart two starts of the same activity type may be and still
+// count as one workout recorded by different devices or apps.
+const activityDuplicateWindow = 2 * time.Minute
+
+type ActivityInput struct {
+	ImportID        *int64
+	Source          string
+	ActivityType    string
+	StartedAt       time.Time
+	DurationSeconds int
+	DistanceM       *float64
+	AvgHeartRate    *int
+	MaxHeartRate    *int
+	HeartRateZones  []int
+}
+
+type ActivityFilter struct {
+	UserID       int64
+	ActivityType string
+	From         *time.Time
+	To           *time.Time
+	Limit        int
+	Offset       int
+}
+
+type ActivityRepository struct {
+	db DBTX
+}
+
+func NewActivityRepository(db DBTX) *ActivityRepository {
+	return &ActivityRepository{db: db}
+}
+
+func (r *ActivityRepository) CreateImport(
+	ctx context.Context,
+	userID int64,
+	filename string,
+	fileSHA256 string,
+) (*models.ActivityImport, error) {
+	query := `
+		INSERT INTO activity_imports (user_id, filename, file_sha256)
+		VALUES ($1, $2, $3)
+		RETURNING ` + activityImportColumns
+	return scanActivityImport(r.db.QueryRow(ctx, query, userID, filename, fileSHA256))
+}
+
+func (r *ActivityRepository) UpdateImportCounts(
+	ctx context.Context,
+	importID int64,
+	found int,
+	imported int,
+	duplicates int,
+) (*models.ActivityImport, error) {
+	query := `
+		UPDATE activity_imports
+		SET activities_found = $2, activities_imported = $3, duplicates_skipped = $4
+		WHERE id = $1
+		RETURNING ` + activityImportColumns
+	return scanActivityImport(r.db.QueryRow(ctx, query, importID, found, imported, duplicates))
+}
+
+func (r *ActivityRepository) ListImports(
+	ctx context.Context,
+	userID int64,
+	limit int,
+	offset int,
+) ([]models.ActivityImport, int, error) {
+	var total int
+	if err := r.db.QueryRow(
+		ctx,
+		`SELECT COUNT(*) FROM activity_imports WHERE user_id = $1`,
+		userID,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT ` + activityImportColumns + `
+		FROM activity_imports
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	imports := make([]models.ActivityImport, 0, limit)
+	for rows.Next() {
+		item, err := scanActivityImport(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		imports = append(imports, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return imports, total, nil
+}
+
+// ExistsNear reports whether the user already has an activity of this type starting within
+// activityDuplicateWindow of startedAt.
+func (r *ActivityRepository) ExistsNear(
+	ctx context.Context,
+	userID int64,
+	activityType string,
+	startedAt time.Time,
+) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM activities
+			WHERE user_id = $1
+				AND activity_type = $2
+				AND started_at BETWEEN $3 AND $4
+		)
+	`, userID, activityType, startedAt.Add(-activityDuplicateWindow), startedAt.Add(activityDuplicateWindow)).Scan(&exists)
+	return exists, err
+}
+
+// Create inserts an activity. It returns pgx.ErrNoRows when the user already has an activity of
+// the same type starting at the same moment.
+func (r *ActivityRepository) Create(
+	ctx context.Context,
+	userID int64,
+	input ActivityInput,
+) (*models.Activity, error) {
+	var zones []byte
+	if len(input.HeartRateZones) > 0 {
+		encoded, err := json.Marshal(input.HeartRateZones)
+		if err != nil {
+			return nil, err
+		}
+		zones = encoded
+	}
+
+	query := `
+		INSERT INTO activities (
+			user_id, import_id, source, activity_type, started_at, duration_seconds,
+			distance_m, avg_heart_rate, max_heart_rate, heart_rate_zones
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (user_id, activity_type, started_at) DO NOTHING
+		RETURNING ` + activityColumns
+
+	return scanActivity(r.db.QueryRow(
+		ctx,
+		query,
+		userID,
+		input.ImportID,
+		input.Source,
+		input.ActivityType,
+		input.StartedAt,
+		input.DurationSeconds,
+		input.DistanceM,
+		input.AvgHeartRate,
+		input.MaxHeartRate,
+		zones,
+	))
+}
+
+func (r *ActivityRepository) GetByID(ctx context.Context, activityID int64) (*models.Activity, error) {
+	query := `
+		SELECT ` + activityColumns + `
+		FROM activities
+		WHERE id = $1
+	`
+	return scanActivity(r.db.QueryRow(ctx, query, activityID))
+}
+
+func (r *ActivityRepository) Delete(ctx context.Context, activityID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM activities WHERE id = $1`, activityID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *ActivityRepository) List(
+	ctx context.Context,
+	filter ActivityFilter,
+) ([]models.Activity, int, error) {
+	args := []any{filter.UserID}
+	whereParts := []string{"user_id = $1"}
+	if filter.ActivityType != "" {
+		args = append(args, filter.ActivityType)
+		whereParts = append(whereParts, fmt.Sprintf("activity_type = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		whereParts = append(whereParts, fmt.Sprintf("started_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		whereParts = append(whereParts, fmt.Sprintf("started_at < $%d", len(args)))
+	}
+	whereClause := strings.Join(whereParts, " AND ")
+
+	var total int
+	if err := r.db.QueryRow(
+		ctx,
+		"SELECT COUNT(*) FROM activities WHERE "+whereClause,
+		args...,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM activities
+		WHERE %s
+		ORDER BY started_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, activityColumns, whereClause, len(args)-1, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	activities := make([]models.Activity, 0, filter.Limit)
+	for rows.Next() {
+		activity, err := scanActivity(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		activities = append(activities, *activity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return activities, total, nil
+}
+
+func scanActivity(row pgx.Row) (*models.Activity, error) {
+	var activity models.Activity
+	var zones []byte
+	err := row.Scan(
+		&activity.ID,
+		&activity.UserID,
+		&activity.ImportID,
+		&activity.Source,
+		&activity.ActivityType,
+		&activity.StartedAt,
+		&activity.DurationSeconds,
+		&activity.DistanceM,
+		&activity.AvgHeartRate,
+		&activity.MaxHeartRate,
+		&zones,
+		&activity.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(zones) > 0 {
+		if err := json.Unmarshal(zones, &activity.HeartRateZones); err != nil {
+			return nil, err
+		}
+	}
+	return &activity, nil
+}
+
+func scanActivityImport(row pgx.Row) (*models.ActivityImport, error) {
+	var item models.ActivityImport
+	err := row.Scan(
+		&item.ID,
+		&item.UserID,
+		&item.Filename,
+		&item.ActivitiesFound,
+		&item.ActivitiesImported,
+		&item.DuplicatesSkipped,
+		&item.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
@@ -0,0 +1,436 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const bodyMeasurementColumns = `id, user_id, measured_at, weight_kg, body_fat_pct, chest_cm, waist_cm, hips_cm,
+	arm_cm, thigh_cm, resting_heart_rate, notes, visibility, created_at, updated_at`
+
+const progressPhotoColumns = `id, user_id, measurement_id, file_path, pose, visibility, taken_at, created_at`
+
+// bodyMetricColumns whitelists the metrics that can be charted; the key is also the column name.
+var bodyMetricColumns = map[string]bool{
+	"weight_kg":          true,
+	"body_fat_pct":       true,
+	"chest_cm":           true,
+	"waist_cm":           true,
+	"hips_cm":            true,
+	"arm_cm":             true,
+	"thigh_cm":           true,
+	"resting_heart_rate": true,
+}
+
+type BodyMeasurementInput struct {
+	MeasuredAt       time.Time
+	WeightKg         *float64
+	BodyFatPct       *float64
+	ChestCm          *float64
+	WaistCm          *float64
+	HipsCm           *float64
+	ArmCm            *float64
+	ThighCm          *float64
+	RestingHeartRate *int
+	Notes            *string
+	Visibility       string
+}
+
+type ProgressPhotoInput struct {
+	MeasurementID *int64
+	Pose          string
+	Visibility    string
+	TakenAt       time.Time
+}
+
+// BodyMetricFilter narrows a client's history. SharedOnly hides entries the client marked private.
+type BodyMetricFilter struct {
+	UserID     int64
+	SharedOnly bool
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+type BodyMetricValue struct {
+	MeasuredAt time.Time
+	Value      float64
+}
+
+type BodyMetricRepository struct {
+	db DBTX
+}
+
+func NewBodyMetricRepository(db DBTX) *BodyMetricRepository {
+	return &BodyMetricRepository{db: db}
+}
+
+func IsBodyMetric(metric string) bool {
+	return bodyMetricColumns[metric]
+}
+
+func (r *BodyMetricRepository) CreateMeasurement(
+	ctx context.Context,
+	userID int64,
+	input BodyMeasurementInput,
+) (*models.BodyMeasurement, error) {
+	query := `
+		INSERT INTO body_measurements (
+			user_id, measured_at, weight_kg, body_fat_pct, chest_cm, waist_cm, hips_cm,
+			arm_cm, thigh_cm, resting_heart_rate, notes, visibility
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING ` + bodyMeasurementColumns
+
+	return scanBodyMeasurement(r.db.QueryRow(
+		ctx,
+		query,
+		userID,
+		input.MeasuredAt,
+		input.WeightKg,
+		input.BodyFatPct,
+		input.ChestCm,
+		input.WaistCm,
+		input.HipsCm,
+		input.ArmCm,
+		input.ThighCm,
+		input.RestingHeartRate,
+		input.Notes,
+		input.Visibility,
+	))
+}
+
+func (r *BodyMetricRepository) GetMeasurementByID(ctx context.Context, measurementID int64) (*models.BodyMeasurement, error) {
+	query := `
+		SELECT ` + bodyMeasurementColumns + `
+		FROM body_measurements
+		WHERE id = $1
+	`
+	return scanBodyMeasurement(r.db.QueryRow(ctx, query, measurementID))
+}
+
+func (r *BodyMetricRepository) UpdateMeasurement(
+	ctx context.Context,
+	measurementID int64,
+	input BodyMeasurementInput,
+) (*models.BodyMeasurement, error) {
+	query := `
+		UPDATE body_measurements
+		SET measured_at = $2,
+			weight_kg = $3,
+			body_fat_pct = $4,
+			chest_cm = $5,
+			waist_cm = $6,
+			hips_cm = $7,
+			arm_cm = $8,
+			thigh_cm = $9,
+			resting_heart_rate = $10,
+			notes = $11,
+			visibility = $12,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + bodyMeasurementColumns
+
+	return scanBodyMeasurement(r.db.QueryRow(
+		ctx,
+		query,
+		measurementID,
+		input.MeasuredAt,
+		input.WeightKg,
+		input.BodyFatPct,
+		input.ChestCm,
+		input.WaistCm,
+		input.HipsCm,
+		input.ArmCm,
+		input.ThighCm,
+		input.RestingHeartRate,
+		input.Notes,
+		input.Visibility,
+	))
+}
+
+func (r *BodyMetricRepository) DeleteMeasurement(ctx context.Context, measurementID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM body_measurements WHERE id = $1`, measurementID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *BodyMetricRepository) ListMeasurements(
+	ctx context.Context,
+	filter BodyMetricFilter,
+) ([]models.BodyMeasurement, int, error) {
+	whereClause, args := bodyMetricWhere(filter, "measured_at")
+
+	var total int
+	if err := r.db.QueryRow(
+		ctx,
+		"SELECT COUNT(*) FROM body_measurements WHERE "+whereClause,
+		args...,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM body_measurements
+		WHERE %s
+		ORDER BY measured_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, bodyMeasurementColumns, whereClause, len(args)-1, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	measurements := make([]models.BodyMeasurement, 0, filter.Limit)
+	for rows.Next() {
+		measurement, err := scanBodyMeasurement(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		measurements = append(measurements, *measurement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return measurements, total, nil
+}
+
+// ListMetricValues returns the recorded values of one metric in chronological order. The metric
+// must pass IsBodyMetric.
+func (r *BodyMetricRepository) ListMetricValues(
+	ctx context.Context,
+	metric string,
+	filter BodyMetricFilter,
+) ([]BodyMetricValue, error) {
+	if !IsBodyMetric(metric) {
+		return nil, fmt.Errorf("unknown body metric %q", metric)
+	}
+
+	whereClause, args := bodyMetricWhere(filter, "measured_at")
+	query := fmt.Sprintf(`
+		SELECT measured_at, %[1]s::DOUBLE PRECISION
+		FROM body_measurements
+		WHERE %[2]s AND %[1]s IS NOT NULL
+		ORDER BY measured_at ASC, id ASC
+	`, metric, whereClause)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make([]BodyMetricValue, 0)
+	for rows.Next() {
+		var value BodyMetricValue
+		if err := rows.Scan(&value.MeasuredAt, &value.Value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// SyncProfileWeight copies the most recent recorded weight onto the user's profile. Profiles keep
+// their weight when no measurement has one.
+func (r *BodyMetricRepository) SyncProfileWeight(ctx context.Context, userID int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE user_profiles
+		SET weight_kg = latest.weight_kg,
+			updated_at = NOW()
+		FROM (
+			SELECT weight_kg
+			FROM body_measurements
+			WHERE user_id = $1 AND weight_kg IS NOT NULL
+			ORDER BY measured_at DESC, id DESC
+			LIMIT 1
+		) AS latest
+		WHERE user_profiles.user_id = $1
+			AND user_profiles.weight_kg IS DISTINCT FROM latest.weight_kg
+	`, userID)
+	return err
+}
+
+func (r *BodyMetricRepository) CreatePhoto(
+	ctx context.Context,
+	userID int64,
+	filePath string,
+	input ProgressPhotoInput,
+) (*models.ProgressPhoto, error) {
+	query := `
+		INSERT INTO progress_photos (user_id, measurement_id, file_path, pose, visibility, taken_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + progressPhotoColumns
+
+	return scanProgressPhoto(r.db.QueryRow(
+		ctx,
+		query,
+		userID,
+		input.MeasurementID,
+		filePath,
+		input.Pose,
+		input.Visibility,
+		input.TakenAt,
+	))
+}
+
+func (r *BodyMetricRepository) GetPhotoByID(ctx context.Context, photoID int64) (*models.ProgressPhoto, error) {
+	query := `
+		SELECT ` + progressPhotoColumns + `
+		FROM progress_photos
+		WHERE id = $1
+	`
+	return scanProgressPhoto(r.db.QueryRow(ctx, query, photoID))
+}
+
+func (r *BodyMetricRepository) UpdatePhoto(
+	ctx context.Context,
+	photoID int64,
+	pose string,
+	visibility string,
+) (*models.ProgressPhoto, error) {
+	query := `
+		UPDATE progress_photos
+		SET pose = $2, visibility = $3
+		WHERE id = $1
+		RETURNING ` + progressPhotoColumns
+	return scanProgressPhoto(r.db.QueryRow(ctx, query, photoID, pose, visibility))
+}
+
+func (r *BodyMetricRepository) DeletePhoto(ctx context.Context, photoID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM progress_photos WHERE id = $1`, photoID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *BodyMetricRepository) ListPhotos(
+	ctx context.Context,
+	filter BodyMetricFilter,
+) ([]models.ProgressPhoto, int, error) {
+	whereClause, args := bodyMetricWhere(filter, "taken_at")
+
+	var total int
+	if err := r.db.QueryRow(
+		ctx,
+		"SELECT COUNT(*) FROM progress_photos WHERE "+whereClause,
+		args...,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM progress_photos
+		WHERE %s
+		ORDER BY taken_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, progressPhotoColumns, whereClause, len(args)-1, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	photos := make([]models.ProgressPhoto, 0, filter.Limit)
+	for rows.Next() {
+		photo, err := scanProgressPhoto(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		photos = append(photos, *photo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return photos, total, nil
+}
+
+func bodyMetricWhere(filter BodyMetricFilter, timeColumn string) (string, []any) {
+	args := []any{filter.UserID}
+	whereParts := []string{"user_id = $1"}
+
+	if filter.SharedOnly {
+		whereParts = append(whereParts, "visibility = 'coaches'")
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		whereParts = append(whereParts, fmt.Sprintf("%s >= $%d", timeColumn, len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		whereParts = append(whereParts, fmt.Sprintf("%s < $%d", timeColumn, len(args)))
+	}
+
+	return strings.Join(whereParts, " AND "), args
+}
+
+func scanBodyMeasurement(row pgx.Row) (*models.BodyMeasurement, error) {
+	var measurement models.BodyMeasurement
+	err := row.Scan(
+		&measurement.ID,
+		&measurement.UserID,
+		&measurement.MeasuredAt,
+		&measurement.WeightKg,
+		&measurement.BodyFatPct,
+		&measurement.ChestCm,
+		&measurement.WaistCm,
+		&measurement.HipsCm,
+		&measurement.ArmCm,
+		&measurement.ThighCm,
+		&measurement.RestingHeartRate,
+		&measurement.Notes,
+		&measurement.Visibility,
+		&measurement.CreatedAt,
+		&measurement.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &measurement, nil
+}
+
+func scanProgressPhoto(row pgx.Row) (*models.ProgressPhoto, error) {
+	var photo models.ProgressPhoto
+	err := row.Scan(
+		&photo.ID,
+		&photo.UserID,
+		&photo.MeasurementID,
+		&photo.FilePath,
+		&photo.Pose,
+		&photo.Visibility,
+		&photo.TakenAt,
+		&photo.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
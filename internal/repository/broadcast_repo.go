@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const chatBroadcastColumns = `
+	b.id, b.coach_id, b.content, b.segment, b.sessions_from, b.sessions_to, b.program_template_id,
+	b.status, b.total_count, b.sent_count, b.failed_count,
+	b.total_count - b.sent_count - b.failed_count,
+	b.created_at, b.started_at, b.completed_at
+`
+
+// BroadcastInput describes a broadcast and the segment of the coach's clients it goes to.
+// SessionsFrom and SessionsTo apply to the session_range segment, ProgramTemplateID to program.
+type BroadcastInput struct {
+	CoachID           int64
+	Content           string
+	Segment           string
+	SessionsFrom      *time.Time
+	SessionsTo        *time.Time
+	ProgramTemplateID *int64
+}
+
+// BroadcastRepository stores coach broadcasts and their per-client delivery state.
+type BroadcastRepository struct {
+	db DBTX
+}
+
+func NewBroadcastRepository(db DBTX) *BroadcastRepository {
+	return &BroadcastRepository{db: db}
+}
+
+func scanChatBroadcast(row pgx.Row) (*models.ChatBroadcast, error) {
+	var broadcast models.ChatBroadcast
+	if err := row.Scan(
+		&broadcast.ID,
+		&broadcast.CoachID,
+		&broadcast.Content,
+		&broadcast.Segment,
+		&broadcast.SessionsFrom,
+		&broadcast.SessionsTo,
+		&broadcast.ProgramTemplateID,
+		&broadcast.Status,
+		&broadcast.TotalCount,
+		&broadcast.SentCount,
+		&broadcast.FailedCount,
+		&broadcast.PendingCount,
+		&broadcast.CreatedAt,
+		&broadcast.StartedAt,
+		&broadcast.CompletedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &broadcast, nil
+}
+
+// ListSegmentClients returns the clients in the segment. Only clients the coach actively coaches
+// are included, whatever the segment.
+func (r *BroadcastRepository) ListSegmentClients(ctx context.Context, input BroadcastInput) ([]int64, error) {
+	args := []any{input.CoachID}
+	condition := ""
+	switch input.Segment {
+	case "active_clients":
+	case "session_range":
+		if input.SessionsFrom == nil || input.SessionsTo == nil {
+			return nil, fmt.Errorf("session_range segment needs a time range")
+		}
+		args = append(args, *input.SessionsFrom, *input.SessionsTo)
+		condition = `AND EXISTS (
+			SELECT 1 FROM bookings s
+			WHERE s.coach_id = r.coach_id AND s.user_id = r.user_id
+			  AND s.status <> 'cancelled'
+			  AND s.scheduled_at >= $2 AND s.scheduled_at < $3
+		)`
+	case "program":
+		if input.ProgramTemplateID == nil {
+			return nil, fmt.Errorf("program segment needs a program template")
+		}
+		args = append(args, *input.ProgramTemplateID)
+		condition = `AND EXISTS (
+			SELECT 1 FROM workout_programs p
+			WHERE p.coach_id = r.coach_id AND p.user_id = r.user_id AND p.template_id = $2
+		)`
+	default:
+		return nil, fmt.Errorf("unknown broadcast segment %q", input.Segment)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT r.user_id
+		FROM coaching_relationships r
+		WHERE r.coach_id = $1 AND r.status = 'active' `+condition+`
+		ORDER BY r.user_id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// Create stores a pending broadcast with one pending delivery per recipient.
+func (r *BroadcastRepository) Create(
+	ctx context.Context,
+	input BroadcastInput,
+	recipientIDs []int64,
+) (*models.ChatBroadcast, error) {
+	query := `
+		WITH b AS (
+			INSERT INTO chat_broadcasts (
+				coach_id, content, segment, sessions_from, sessions_to, program_template_id, total_count
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, cardinality($7::BIGINT[]))
+			RETURNING *
+		), recipients AS (
+			INSERT INTO chat_broadcast_recipients (broadcast_id, user_id)
+			SELECT b.id, recipient FROM b, unnest($7::BIGINT[]) AS recipient
+		)
+		SELECT ` + chatBroadcastColumns + ` FROM b`
+
+	return scanChatBroadcast(r.db.QueryRow(
+		ctx,
+		query,
+		input.CoachID,
+		input.Content,
+		input.Segment,
+		input.SessionsFrom,
+		input.SessionsTo,
+		input.ProgramTemplateID,
+		recipientIDs,
+	))
+}
+
+func (r *BroadcastRepository) GetForCoach(ctx context.Context, broadcastID int64, coachID int64) (*models.ChatBroadcast, error) {
+	query := `SELECT ` + chatBroadcastColumns + ` FROM chat_broadcasts b WHERE b.id = $1 AND b.coach_id = $2`
+	return scanChatBroadcast(r.db.QueryRow(ctx, query, broadcastID, coachID))
+}
+
+// ListForCoach returns the coach's broadcasts, newest first.
+func (r *BroadcastRepository) ListForCoach(
+	ctx context.Context,
+	coachID int64,
+	limit int,
+	offset int,
+) ([]models.ChatBroadcast, int, error) {
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM chat_broadcasts WHERE coach_id = $1`, coachID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT `+chatBroadcastColumns+`
+		FROM chat_broadcasts b
+		WHERE b.coach_id = $1
+		ORDER BY b.created_at DESC, b.id DESC
+		LIMIT $2 OFFSET $3
+	`, coachID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	broadcasts := make([]models.ChatBroadcast, 0, limit)
+	for rows.Next() {
+		broadcast, err := scanChatBroadcast(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		broadcasts = append(broadcasts, *broadcast)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return broadcasts, total, nil
+}
+
+// ListRecipients returns a page of a broadcast's deliveries, optionally only those with a status.
+func (r *BroadcastRepository) ListRecipients(
+	ctx context.Context,
+	broadcastID int64,
+	status string,
+	limit int,
+	offset int,
+) ([]models.ChatBroadcastRecipient, int, error) {
+	var total int
+	if err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM chat_broadcast_recipients
+		WHERE broadcast_id = $1 AND ($2 = '' OR status = $2)
+	`, broadcastID, status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT broadcast_id, user_id, status, message_id, error, processed_at
+		FROM chat_broadcast_recipients
+		WHERE broadcast_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY user_id
+		LIMIT $3 OFFSET $4
+	`, broadcastID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	recipients := make([]models.ChatBroadcastRecipient, 0, limit)
+	for rows.Next() {
+		var recipient models.ChatBroadcastRecipient
+		if err := rows.Scan(
+			&recipient.BroadcastID,
+			&recipient.UserID,
+			&recipient.Status,
+			&recipient.MessageID,
+			&recipient.Error,
+			&recipient.ProcessedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return recipients, total, nil
+}
+
+// ClaimNext takes the oldest unfinished broadcast that no other instance holds, for the length of
+// the lease. It returns pgx.ErrNoRows when there is nothing to deliver.
+func (r *BroadcastRepository) ClaimNext(ctx context.Context, lease time.Duration) (*models.ChatBroadcast, error) {
+	query := `
+		UPDATE chat_broadcasts b
+		SET status = 'running',
+			started_at = COALESCE(b.started_at, NOW()),
+			lease_expires_at = NOW() + $1 * INTERVAL '1 millisecond'
+		WHERE b.id = (
+			SELECT id
+			FROM chat_broadcasts
+			WHERE status <> 'completed' AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + chatBroadcastColumns
+
+	return scanChatBroadcast(r.db.QueryRow(ctx, query, lease.Milliseconds()))
+}
+
+// ExtendLease keeps the broadcast claimed while its delivery goes on.
+func (r *BroadcastRepository) ExtendLease(ctx context.Context, broadcastID int64, lease time.Duration) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE chat_broadcasts
+		SET lease_expires_at = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id = $1
+	`, broadcastID, lease.Milliseconds())
+	return err
+}
+
+// ListPendingRecipients returns up to limit clients still waiting for the broadcast.
+func (r *BroadcastRepository) ListPendingRecipients(ctx context.Context, broadcastID int64, limit int) ([]int64, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT user_id
+		FROM chat_broadcast_recipients
+		WHERE broadcast_id = $1 AND status = 'pending'
+		ORDER BY user_id
+		LIMIT $2
+	`, broadcastID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// RecordDelivery marks one recipient sent, with the message it received, or failed with the
+// reason, and counts it on the broadcast. Recipients already recorded are left alone.
+func (r *BroadcastRepository) RecordDelivery(
+	ctx context.Context,
+	broadcastID int64,
+	userID int64,
+	messageID *int64,
+	failure *string,
+) error {
+	_, err := r.db.Exec(ctx, `
+		WITH recorded AS (
+			UPDATE chat_broadcast_recipients
+			SET status = CASE WHEN $3::BIGINT IS NOT NULL THEN 'sent' ELSE 'failed' END,
+				message_id = $3,
+				error = $4,
+				processed_at = NOW()
+			WHERE broadcast_id = $1 AND user_id = $2 AND status = 'pending'
+			RETURNING status
+		)
+		UPDATE chat_broadcasts
+		SET sent_count = sent_count + (SELECT COUNT(*) FROM recorded WHERE status = 'sent'),
+			failed_count = failed_count + (SELECT COUNT(*) FROM recorded WHERE status = 'failed')
+		WHERE id = $1
+	`, broadcastID, userID, messageID, failure)
+	return err
+}
+
+// Complete marks the broadcast completed once no recipient is pending. It reports whether it did.
+func (r *BroadcastRepository) Complete(ctx context.Context, broadcastID int64) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE chat_broadcasts b
+		SET status = 'completed', completed_at = NOW(), lease_expires_at = NULL
+		WHERE b.id = $1
+		  AND b.status <> 'completed'
+		  AND NOT EXISTS (
+			SELECT 1 FROM chat_broadcast_recipients
+			WHERE broadcast_id = b.id AND status = 'pending'
+		  )
+	`, broadcastID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
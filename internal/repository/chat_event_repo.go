@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+type ChatEventRepository struct {
+	db DBTX
+}
+
+func NewChatEventRepository(db DBTX) *ChatEventRepository {
+	return &ChatEventRepository{db: db}
+}
+
+// Append stores one event for each user under that user's next sequence number and returns the
+// numbers. It is a single statement, so the sequence rows stay locked until the events are in and
+// each user's events become visible in sequence order.
+func (r *ChatEventRepository) Append(
+	ctx context.Context,
+	userIDs []int64,
+	eventType string,
+	payload []byte,
+) (map[int64]int64, error) {
+	// Lock sequence rows in a fixed order so concurrent appends cannot deadlock.
+	ids := slices.Clone(userIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	rows, err := r.db.Query(ctx, `
+		WITH next AS (
+			INSERT INTO chat_event_sequences AS s (user_id, last_seq)
+			SELECT unnest($1::BIGINT[]), 1
+			ON CONFLICT (user_id) DO UPDATE SET last_seq = s.last_seq + 1
+			RETURNING user_id, last_seq
+		)
+		INSERT INTO chat_events (user_id, seq, event_type, payload)
+		SELECT user_id, last_seq, $2, $3
+		FROM next
+		RETURNING user_id, seq
+	`, ids, eventType, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sequences := make(map[int64]int64, len(ids))
+	for rows.Next() {
+		var userID, seq int64
+		if err := rows.Scan(&userID, &seq); err != nil {
+			return nil, err
+		}
+		sequences[userID] = seq
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sequences, nil
+}
+
+// ListSince returns up to limit of the user's events after afterSeq, in order.
+func (r *ChatEventRepository) ListSince(
+	ctx context.Context,
+	userID int64,
+	afterSeq int64,
+	limit int,
+) ([]models.ChatEvent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT user_id, seq, event_type, payload, created_at
+		FROM chat_events
+		WHERE user_id = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3
+	`, userID, afterSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]models.ChatEvent, 0)
+	for rows.Next() {
+		var event models.ChatEvent
+		if err := rows.Scan(
+			&event.UserID,
+			&event.Seq,
+			&event.Type,
+			&event.Payload,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// LatestSequence returns the number of the user's last event, or 0 before their first one.
+func (r *ChatEventRepository) LatestSequence(ctx context.Context, userID int64) (int64, error) {
+	var seq int64
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE(MAX(last_seq), 0)
+		FROM chat_event_sequences
+		WHERE user_id = $1
+	`, userID).Scan(&seq)
+	return seq, err
+}
+
+// DeleteBefore prunes events older than cutoff. Sequence numbers keep counting.
+func (r *ChatEventRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM chat_events WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
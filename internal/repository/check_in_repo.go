@@ -0,0 +1,498 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const checkInFormColumns = `id, coach_id, title, description, questions, created_at, updated_at`
+
+const checkInScheduleColumns = `s.id, s.form_id, f.title, s.coach_id, s.user_id, s.relationship_id, up.full_name,
+	s.interval_days, s.next_due_on, s.active, s.created_at, s.updated_at`
+
+const checkInScheduleFrom = `
+	FROM check_in_schedules s
+	JOIN check_in_forms f ON f.id = s.form_id
+	LEFT JOIN user_profiles up ON up.user_id = s.user_id`
+
+const checkInSubmissionColumns = `cs.id, cs.schedule_id, cs.form_id, cs.coach_id, cs.user_id, up.full_name, cs.form_title,
+	cs.questions, cs.answers, cs.due_on, cs.submitted_at`
+
+const checkInSubmissionFrom = `
+	FROM check_in_submissions cs
+	LEFT JOIN user_profiles up ON up.user_id = cs.user_id`
+
+type CheckInFormInput struct {
+	Title       string
+	Description *string
+	Questions   []models.CheckInQuestion
+}
+
+// CheckInScheduleFilter narrows schedules by coach or client. DueBefore keeps active schedules of
+// active coaching relationships due before the given day.
+type CheckInScheduleFilter struct {
+	CoachID   *int64
+	UserID    *int64
+	DueBefore *time.Time
+}
+
+type CheckInSubmissionFilter struct {
+	CoachID *int64
+	UserID  *int64
+	FormID  *int64
+	Since   *time.Time
+	Limit   int
+	Offset  int
+}
+
+// checkInAnswerRecord is the stored form of an answer; unlike the API model it keeps the photo's
+// storage path.
+type checkInAnswerRecord struct {
+	QuestionID string   `json:"question_id"`
+	Number     *float64 `json:"number,omitempty"`
+	Choice     *string  `json:"choice,omitempty"`
+	Text       *string  `json:"text,omitempty"`
+	PhotoPath  string   `json:"photo_path,omitempty"`
+}
+
+type CheckInRepository struct {
+	db DBTX
+}
+
+func NewCheckInRepository(db DBTX) *CheckInRepository {
+	return &CheckInRepository{db: db}
+}
+
+func (r *CheckInRepository) CreateForm(
+	ctx context.Context,
+	coachID int64,
+	input CheckInFormInput,
+) (*models.CheckInForm, error) {
+	questions, err := marshalCheckInQuestions(input.Questions)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO check_in_forms (coach_id, title, description, questions)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + checkInFormColumns
+
+	return scanCheckInForm(r.db.QueryRow(ctx, query, coachID, input.Title, input.Description, questions))
+}
+
+func (r *CheckInRepository) GetFormByID(ctx context.Context, formID int64) (*models.CheckInForm, error) {
+	query := `
+		SELECT ` + checkInFormColumns + `
+		FROM check_in_forms
+		WHERE id = $1
+	`
+	return scanCheckInForm(r.db.QueryRow(ctx, query, formID))
+}
+
+func (r *CheckInRepository) ListFormsByCoach(ctx context.Context, coachID int64) ([]models.CheckInForm, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+checkInFormColumns+`
+		FROM check_in_forms
+		WHERE coach_id = $1
+		ORDER BY updated_at DESC, id DESC
+	`, coachID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	forms := make([]models.CheckInForm, 0)
+	for rows.Next() {
+		form, err := scanCheckInForm(rows)
+		if err != nil {
+			return nil, err
+		}
+		forms = append(forms, *form)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return forms, nil
+}
+
+func (r *CheckInRepository) UpdateForm(
+	ctx context.Context,
+	formID int64,
+	input CheckInFormInput,
+) (*models.CheckInForm, error) {
+	questions, err := marshalCheckInQuestions(input.Questions)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE check_in_forms
+		SET title = $2,
+			description = $3,
+			questions = $4,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + checkInFormColumns
+
+	return scanCheckInForm(r.db.QueryRow(ctx, query, formID, input.Title, input.Description, questions))
+}
+
+// DeleteForm removes the form and its schedules. Submissions keep their copy of the questions.
+func (r *CheckInRepository) DeleteForm(ctx context.Context, formID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM check_in_forms WHERE id = $1`, formID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *CheckInRepository) CreateSchedule(
+	ctx context.Context,
+	formID int64,
+	relationship *models.CoachingRelationship,
+	intervalDays int,
+	nextDueOn time.Time,
+) (*models.CheckInSchedule, error) {
+	var scheduleID int64
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO check_in_schedules (form_id, coach_id, user_id, relationship_id, interval_days, next_due_on)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, formID, relationship.CoachID, relationship.UserID, relationship.ID, intervalDays, nextDueOn).Scan(&scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetScheduleByID(ctx, scheduleID)
+}
+
+func (r *CheckInRepository) GetScheduleByID(ctx context.Context, scheduleID int64) (*models.CheckInSchedule, error) {
+	query := `SELECT ` + checkInScheduleColumns + checkInScheduleFrom + `
+		WHERE s.id = $1`
+	return scanCheckInSchedule(r.db.QueryRow(ctx, query, scheduleID))
+}
+
+// GetScheduleByIDForUpdate locks the schedule row so concurrent submissions advance it once.
+func (r *CheckInRepository) GetScheduleByIDForUpdate(
+	ctx context.Context,
+	scheduleID int64,
+) (*models.CheckInSchedule, error) {
+	query := `SELECT ` + checkInScheduleColumns + checkInScheduleFrom + `
+		WHERE s.id = $1
+		FOR UPDATE OF s`
+	return scanCheckInSchedule(r.db.QueryRow(ctx, query, scheduleID))
+}
+
+// ListSchedules returns matching schedules, soonest due first.
+func (r *CheckInRepository) ListSchedules(
+	ctx context.Context,
+	filter CheckInScheduleFilter,
+) ([]models.CheckInSchedule, error) {
+	conditions := make([]string, 0, 3)
+	args := make([]any, 0, 3)
+	if filter.CoachID != nil {
+		args = append(args, *filter.CoachID)
+		conditions = append(conditions, fmt.Sprintf("s.coach_id = $%d", len(args)))
+	}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("s.user_id = $%d", len(args)))
+	}
+	if filter.DueBefore != nil {
+		args = append(args, *filter.DueBefore)
+		conditions = append(conditions, fmt.Sprintf(`s.active AND s.next_due_on < $%d AND NOT EXISTS (
+			SELECT 1 FROM coaching_relationships cr WHERE cr.id = s.relationship_id AND cr.status <> 'active'
+		)`, len(args)))
+	}
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("check-in schedule filter needs a coach or a user")
+	}
+
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`
+		SELECT %s %s
+		WHERE %s
+		ORDER BY s.next_due_on ASC, s.id ASC
+	`, checkInScheduleColumns, checkInScheduleFrom, strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]models.CheckInSchedule, 0)
+	for rows.Next() {
+		schedule, err := scanCheckInSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (r *CheckInRepository) UpdateSchedule(
+	ctx context.Context,
+	scheduleID int64,
+	intervalDays int,
+	nextDueOn time.Time,
+	active bool,
+) (*models.CheckInSchedule, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE check_in_schedules
+		SET interval_days = $2,
+			next_due_on = $3,
+			active = $4,
+			updated_at = NOW()
+		WHERE id = $1
+	`, scheduleID, intervalDays, nextDueOn, active)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return r.GetScheduleByID(ctx, scheduleID)
+}
+
+func (r *CheckInRepository) DeleteSchedule(ctx context.Context, scheduleID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM check_in_schedules WHERE id = $1`, scheduleID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *CheckInRepository) CreateSubmission(
+	ctx context.Context,
+	schedule *models.CheckInSchedule,
+	form *models.CheckInForm,
+	answers []models.CheckInAnswer,
+) (*models.CheckInSubmission, error) {
+	questions, err := marshalCheckInQuestions(form.Questions)
+	if err != nil {
+		return nil, err
+	}
+	answerJSON, err := marshalCheckInAnswers(answers)
+	if err != nil {
+		return nil, err
+	}
+
+	var submissionID int64
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO check_in_submissions (
+			schedule_id, form_id, coach_id, user_id, form_title, questions, answers, due_on
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`,
+		schedule.ID,
+		form.ID,
+		schedule.CoachID,
+		schedule.UserID,
+		form.Title,
+		questions,
+		answerJSON,
+		schedule.NextDueOn,
+	).Scan(&submissionID)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetSubmissionByID(ctx, submissionID)
+}
+
+func (r *CheckInRepository) GetSubmissionByID(
+	ctx context.Context,
+	submissionID int64,
+) (*models.CheckInSubmission, error) {
+	query := `SELECT ` + checkInSubmissionColumns + checkInSubmissionFrom + `
+		WHERE cs.id = $1`
+	return scanCheckInSubmission(r.db.QueryRow(ctx, query, submissionID))
+}
+
+// ListSubmissions returns matching submissions, newest first.
+func (r *CheckInRepository) ListSubmissions(
+	ctx context.Context,
+	filter CheckInSubmissionFilter,
+) ([]models.CheckInSubmission, int, error) {
+	conditions := make([]string, 0, 4)
+	args := make([]any, 0, 6)
+	if filter.CoachID != nil {
+		args = append(args, *filter.CoachID)
+		conditions = append(conditions, fmt.Sprintf("cs.coach_id = $%d", len(args)))
+	}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("cs.user_id = $%d", len(args)))
+	}
+	if filter.FormID != nil {
+		args = append(args, *filter.FormID)
+		conditions = append(conditions, fmt.Sprintf("cs.form_id = $%d", len(args)))
+	}
+	if filter.Since != nil {
+		args = append(args, *filter.Since)
+		conditions = append(conditions, fmt.Sprintf("cs.submitted_at >= $%d", len(args)))
+	}
+	if filter.CoachID == nil && filter.UserID == nil {
+		return nil, 0, fmt.Errorf("check-in submission filter needs a coach or a user")
+	}
+	whereClause := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := r.db.QueryRow(
+		ctx,
+		"SELECT COUNT(*) FROM check_in_submissions cs WHERE "+whereClause,
+		args...,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`
+		SELECT %s %s
+		WHERE %s
+		ORDER BY cs.submitted_at DESC, cs.id DESC
+		LIMIT $%d OFFSET $%d
+	`, checkInSubmissionColumns, checkInSubmissionFrom, whereClause, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	submissions := make([]models.CheckInSubmission, 0, filter.Limit)
+	for rows.Next() {
+		submission, err := scanCheckInSubmission(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		submissions = append(submissions, *submission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return submissions, total, nil
+}
+
+func marshalCheckInQuestions(questions []models.CheckInQuestion) ([]byte, error) {
+	if questions == nil {
+		questions = []models.CheckInQuestion{}
+	}
+	return json.Marshal(questions)
+}
+
+func marshalCheckInAnswers(answers []models.CheckInAnswer) ([]byte, error) {
+	records := make([]checkInAnswerRecord, 0, len(answers))
+	for _, answer := range answers {
+		records = append(records, checkInAnswerRecord{
+			QuestionID: answer.QuestionID,
+			Number:     answer.Number,
+			Choice:     answer.Choice,
+			Text:       answer.Text,
+			PhotoPath:  answer.PhotoPath,
+		})
+	}
+	return json.Marshal(records)
+}
+
+func scanCheckInForm(row pgx.Row) (*models.CheckInForm, error) {
+	var form models.CheckInForm
+	var questions []byte
+	err := row.Scan(
+		&form.ID,
+		&form.CoachID,
+		&form.Title,
+		&form.Description,
+		&questions,
+		&form.CreatedAt,
+		&form.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	form.Questions = []models.CheckInQuestion{}
+	if len(questions) > 0 {
+		if err := json.Unmarshal(questions, &form.Questions); err != nil {
+			return nil, err
+		}
+	}
+	return &form, nil
+}
+
+func scanCheckInSchedule(row pgx.Row) (*models.CheckInSchedule, error) {
+	var schedule models.CheckInSchedule
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.FormID,
+		&schedule.FormTitle,
+		&schedule.CoachID,
+		&schedule.UserID,
+		&schedule.RelationshipID,
+		&schedule.ClientName,
+		&schedule.IntervalDays,
+		&schedule.NextDueOn,
+		&schedule.Active,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func scanCheckInSubmission(row pgx.Row) (*models.CheckInSubmission, error) {
+	var submission models.CheckInSubmission
+	var questions, answers []byte
+	err := row.Scan(
+		&submission.ID,
+		&submission.ScheduleID,
+		&submission.FormID,
+		&submission.CoachID,
+		&submission.UserID,
+		&submission.ClientName,
+		&submission.FormTitle,
+		&questions,
+		&answers,
+		&submission.DueOn,
+		&submission.SubmittedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	submission.Questions = []models.CheckInQuestion{}
+	if err := json.Unmarshal(questions, &submission.Questions); err != nil {
+		return nil, err
+	}
+	var records []checkInAnswerRecord
+	if err := json.Unmarshal(answers, &records); err != nil {
+		return nil, err
+	}
+	submission.Answers = make([]models.CheckInAnswer, 0, len(records))
+	for _, record := range records {
+		submission.Answers = append(submission.Answers, models.CheckInAnswer{
+			QuestionID: record.QuestionID,
+			Number:     record.Number,
+			Choice:     record.Choice,
+			Text:       record.Text,
+			PhotoPath:  record.PhotoPath,
+		})
+	}
+	return &submission, nil
+}
@@ -363,6 +363,19 @@ func (r *CoachProfileRepository) GetAvailableSlotsPreview(ctx context.Context, c
 	return slots, nil
 }
 
+// HasCurrentSlot reports whether one of the coach's availability slots, booked or not, covers now.
+func (r *CoachProfileRepository) HasCurrentSlot(ctx context.Context, coachID int64) (bool, error) {
+	var current bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM coach_availability_slots
+			WHERE coach_id = $1 AND starts_at <= NOW() AND ends_at > NOW()
+		)
+	`, coachID).Scan(&current)
+	return current, err
+}
+
 type CoachOnboardingInput struct {
 	FullName        string
 	Bio             string
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const coachingRelationshipColumns = `r.id, r.coach_id, r.user_id, cp.full_name, up.full_name, r.status,
+	r.started_at, r.ended_at, r.created_at, r.updated_at`
+
+const coachingRelationshipFrom = `
+	FROM coaching_relationships r
+	LEFT JOIN coach_profiles cp ON cp.user_id = r.coach_id
+	LEFT JOIN user_profiles up ON up.user_id = r.user_id`
+
+// CoachingRelationshipFilter narrows relationships to one coach or one client.
+type CoachingRelationshipFilter struct {
+	CoachID *int64
+	UserID  *int64
+	Status  string
+	Limit   int
+	Offset  int
+}
+
+// CoachingRepository stores the working relationships between coaches and clients.
+type CoachingRepository struct {
+	db DBTX
+}
+
+func NewCoachingRepository(db DBTX) *CoachingRepository {
+	return &CoachingRepository{db: db}
+}
+
+// IsActiveCoach reports whether the coach has an active relationship with the client.
+func (r *CoachingRepository) IsActiveCoach(ctx context.Context, coachID int64, userID int64) (bool, error) {
+	var active bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM coaching_relationships
+			WHERE coach_id = $1 AND user_id = $2 AND status = 'active'
+		)
+	`, coachID, userID).Scan(&active)
+	return active, err
+}
+
+// Activate starts the relationship if the pair has none yet and returns it. An existing one keeps
+// its status: only the coach resumes a paused or ended relationship. Conversations between the pair
+// are linked to it.
+func (r *CoachingRepository) Activate(
+	ctx context.Context,
+	coachID int64,
+	userID int64,
+) (*models.CoachingRelationship, error) {
+	var relationshipID int64
+	err := r.db.QueryRow(ctx, `
+		WITH inserted AS (
+			INSERT INTO coaching_relationships (coach_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT (coach_id, user_id) DO NOTHING
+			RETURNING id
+		)
+		SELECT id FROM inserted
+		UNION ALL
+		SELECT id FROM coaching_relationships WHERE coach_id = $1 AND user_id = $2
+		LIMIT 1
+	`, coachID, userID).Scan(&relationshipID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.db.Exec(ctx, `
+		UPDATE conversations
+		SET relationship_id = $1
+		WHERE coach_id = $2 AND user_id = $3 AND relationship_id IS NULL
+	`, relationshipID, coachID, userID); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, relationshipID)
+}
+
+func (r *CoachingRepository) GetByID(ctx context.Context, relationshipID int64) (*models.CoachingRelationship, error) {
+	query := `SELECT ` + coachingRelationshipColumns + coachingRelationshipFrom + `
+		WHERE r.id = $1`
+	return scanCoachingRelationship(r.db.QueryRow(ctx, query, relationshipID))
+}
+
+func (r *CoachingRepository) GetByParticipants(
+	ctx context.Context,
+	coachID int64,
+	userID int64,
+) (*models.CoachingRelationship, error) {
+	query := `SELECT ` + coachingRelationshipColumns + coachingRelationshipFrom + `
+		WHERE r.coach_id = $1 AND r.user_id = $2`
+	return scanCoachingRelationship(r.db.QueryRow(ctx, query, coachID, userID))
+}
+
+// UpdateStatus moves the relationship to a new status, recording when it ended. Reopening an ended
+// relationship starts it afresh.
+func (r *CoachingRepository) UpdateStatus(
+	ctx context.Context,
+	relationshipID int64,
+	status string,
+) (*models.CoachingRelationship, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE coaching_relationships
+		SET status = $2,
+			started_at = CASE WHEN status = 'ended' AND $2 <> 'ended' THEN NOW() ELSE started_at END,
+			ended_at = CASE WHEN $2 = 'ended' THEN NOW() ELSE NULL END,
+			updated_at = NOW()
+		WHERE id = $1
+	`, relationshipID, status)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return r.GetByID(ctx, relationshipID)
+}
+
+func (r *CoachingRepository) List(
+	ctx context.Context,
+	filter CoachingRelationshipFilter,
+) ([]models.CoachingRelationship, int, error) {
+	conditions := make([]string, 0, 3)
+	args := make([]any, 0, 5)
+	if filter.CoachID != nil {
+		args = append(args, *filter.CoachID)
+		conditions = append(conditions, fmt.Sprintf("r.coach_id = $%d", len(args)))
+	}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("r.user_id = $%d", len(args)))
+	}
+	if len(conditions) == 0 {
+		return nil, 0, fmt.Errorf("coaching relationship filter needs a coach or a user")
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("r.status = $%d", len(args)))
+	}
+	whereClause := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := r.db.QueryRow(
+		ctx,
+		"SELECT COUNT(*) FROM coaching_relationships r WHERE "+whereClause,
+		args...,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT %s %s
+		WHERE %s
+		ORDER BY r.updated_at DESC, r.id DESC
+		LIMIT $%d OFFSET $%d
+	`, coachingRelationshipColumns, coachingRelationshipFrom, whereClause, len(args)-1, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	relationships := make([]models.CoachingRelationship, 0, filter.Limit)
+	for rows.Next() {
+		relationship, err := scanCoachingRelationship(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		relationships = append(relationships, *relationship)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return relationships, total, nil
+}
+
+func scanCoachingRelationship(row pgx.Row) (*models.CoachingRelationship, error) {
+	var relationship models.CoachingRelationship
+	err := row.Scan(
+		&relationship.ID,
+		&relationship.CoachID,
+		&relationship.UserID,
+		&relationship.CoachName,
+		&relationship.ClientName,
+		&relationship.Status,
+		&relationship.StartedAt,
+		&relationship.EndedAt,
+		&relationship.CreatedAt,
+		&relationship.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &relationship, nil
+}
@@ -3,7 +3,10 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/saeid-a/CoachAppBack/internal/models"
 )
 
@@ -15,103 +18,124 @@ func NewConversationRepository(db DBTX) *ConversationRepository {
 	return &ConversationRepository{db: db}
 }
 
-func (r *ConversationRepository) CreateOrGet(
-	ctx context.Context,
-	userID int64,
-	coachID int64,
-) (*models.Conversation, error) {
-	query := `
-		INSERT INTO conversations (user_id, coach_id)
-		VALUES ($1, $2)
-		ON CONFLICT (user_id, coach_id)
-		DO UPDATE SET updated_at = conversations.updated_at
-		RETURNING id, user_id, coach_id, created_at, updated_at
-	`
+const conversationColumns = `
+	c.id, c.kind, COALESCE(c.title, ''), COALESCE(c.user_id, 0), c.coach_id, c.relationship_id,
+	c.created_at, c.updated_at
+`
 
+// memberClause matches conversations, aliased c, in which the user bound to placeholder is a current
+// member. joined_at is deliberately not checked: current members read a group's whole history,
+// including what was sent before they joined or while they were away.
+func memberClause(placeholder string) string {
+	return fmt.Sprintf(`EXISTS (
+		SELECT 1 FROM conversation_members cm
+		WHERE cm.conversation_id = c.id AND cm.user_id = %s AND cm.left_at IS NULL
+	)`, placeholder)
+}
+
+func scanConversation(row pgx.Row) (*models.Conversation, error) {
 	var conversation models.Conversation
-	err := r.db.QueryRow(ctx, query, userID, coachID).Scan(
+	if err := row.Scan(
 		&conversation.ID,
+		&conversation.Kind,
+		&conversation.Title,
 		&conversation.UserID,
 		&conversation.CoachID,
+		&conversation.RelationshipID,
 		&conversation.CreatedAt,
 		&conversation.UpdatedAt,
-	)
-	if err != nil {
+	); err != nil {
 		return nil, err
 	}
-
 	return &conversation, nil
 }
 
-func (r *ConversationRepository) GetByID(ctx context.Context, conversationID int64) (*models.Conversation, error) {
+// CreateOrGet returns the direct conversation between a client and a coach, creating it and the
+// participants' memberships the first time.
+func (r *ConversationRepository) CreateOrGet(
+	ctx context.Context,
+	userID int64,
+	coachID int64,
+) (*models.Conversation, error) {
 	query := `
-		SELECT id, user_id, coach_id, created_at, updated_at
-		FROM conversations
-		WHERE id = $1
-	`
+		WITH c AS (
+			INSERT INTO conversations (kind, user_id, coach_id, relationship_id)
+			VALUES ('direct', $1, $2, (
+				SELECT id FROM coaching_relationships WHERE coach_id = $2 AND user_id = $1
+			))
+			ON CONFLICT (user_id, coach_id)
+			DO UPDATE SET relationship_id = COALESCE(conversations.relationship_id, EXCLUDED.relationship_id)
+			RETURNING *
+		), members AS (
+			INSERT INTO conversation_members (conversation_id, user_id)
+			SELECT c.id, participant FROM c, unnest(ARRAY[$1, $2]::BIGINT[]) AS participant
+			ON CONFLICT (conversation_id, user_id) DO NOTHING
+		)
+		SELECT ` + conversationColumns + ` FROM c`
 
-	var conversation models.Conversation
-	err := r.db.QueryRow(ctx, query, conversationID).Scan(
-		&conversation.ID,
-		&conversation.UserID,
-		&conversation.CoachID,
-		&conversation.CreatedAt,
-		&conversation.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
+	return scanConversation(r.db.QueryRow(ctx, query, userID, coachID))
+}
 
-	return &conversation, nil
+// CreateGroup creates an empty group conversation owned by the coach. Callers add the members.
+func (r *ConversationRepository) CreateGroup(
+	ctx context.Context,
+	coachID int64,
+	title string,
+) (*models.Conversation, error) {
+	query := `
+		INSERT INTO conversations AS c (kind, title, coach_id)
+		VALUES ('group', $1, $2)
+		RETURNING ` + conversationColumns
+
+	return scanConversation(r.db.QueryRow(ctx, query, title, coachID))
 }
 
+func (r *ConversationRepository) GetByID(ctx context.Context, conversationID int64) (*models.Conversation, error) {
+	query := `SELECT ` + conversationColumns + ` FROM conversations c WHERE c.id = $1`
+	return scanConversation(r.db.QueryRow(ctx, query, conversationID))
+}
+
+// GetByIDForParticipant returns the conversation if the participant is a current member of it.
 func (r *ConversationRepository) GetByIDForParticipant(
 	ctx context.Context,
 	conversationID int64,
 	participantID int64,
 ) (*models.Conversation, error) {
 	query := `
-		SELECT id, user_id, coach_id, created_at, updated_at
-		FROM conversations
-		WHERE id = $1 AND (user_id = $2 OR coach_id = $2)
-	`
-
-	var conversation models.Conversation
-	err := r.db.QueryRow(ctx, query, conversationID, participantID).Scan(
-		&conversation.ID,
-		&conversation.UserID,
-		&conversation.CoachID,
-		&conversation.CreatedAt,
-		&conversation.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
+		SELECT ` + conversationColumns + `
+		FROM conversations c
+		WHERE c.id = $1 AND ` + memberClause("$2")
 
-	return &conversation, nil
+	return scanConversation(r.db.QueryRow(ctx, query, conversationID, participantID))
 }
 
+// ListForParticipant returns the participant's current conversations, newest activity first. Unread
+// counts use messages.is_read in direct conversations and the participant's own read position in
+// groups.
 func (r *ConversationRepository) ListForParticipant(
 	ctx context.Context,
 	participantID int64,
 ) ([]models.ConversationSummary, error) {
 	query := `
 		SELECT
-			c.id,
-			c.user_id,
-			c.coach_id,
-			c.created_at,
-			c.updated_at,
+			` + conversationColumns + `,
 			lm.id,
 			lm.conversation_id,
 			lm.sender_id,
 			lm.content,
 			lm.is_read,
 			lm.created_at,
-			COALESCE(uc.unread_count, 0)
-		FROM conversations c
+			lm.edited_at,
+			lm.deleted_at,
+			COALESCE(uc.unread_count, 0),
+			mc.member_count
+		FROM conversation_members me
+		JOIN conversations c ON c.id = me.conversation_id
 		LEFT JOIN LATERAL (
-			SELECT id, conversation_id, sender_id, content, is_read, created_at
+			SELECT
+				id, conversation_id, sender_id,
+				CASE WHEN deleted_at IS NULL THEN content ELSE '' END AS content,
+				is_read, created_at, edited_at, deleted_at
 			FROM messages
 			WHERE conversation_id = c.id
 			ORDER BY created_at DESC, id DESC
@@ -122,9 +146,15 @@ func (r *ConversationRepository) ListForParticipant(
 			FROM messages
 			WHERE conversation_id = c.id
 			  AND sender_id <> $1
-			  AND is_read = FALSE
+			  AND deleted_at IS NULL
+			  AND CASE WHEN c.kind = 'group' THEN id > me.last_read_message_id ELSE is_read = FALSE END
 		) uc ON TRUE
-		WHERE c.user_id = $1 OR c.coach_id = $1
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS member_count
+			FROM conversation_members
+			WHERE conversation_id = c.id AND left_at IS NULL
+		) mc ON TRUE
+		WHERE me.user_id = $1 AND me.left_at IS NULL
 		ORDER BY COALESCE(lm.created_at, c.updated_at, c.created_at) DESC, c.id DESC
 	`
 
@@ -143,11 +173,16 @@ func (r *ConversationRepository) ListForParticipant(
 		var messageContent sql.NullString
 		var messageIsRead sql.NullBool
 		var messageCreatedAt sql.NullTime
+		var messageEditedAt *time.Time
+		var messageDeletedAt *time.Time
 
 		if err := rows.Scan(
 			&summary.ID,
+			&summary.Kind,
+			&summary.Title,
 			&summary.UserID,
 			&summary.CoachID,
+			&summary.RelationshipID,
 			&summary.CreatedAt,
 			&summary.UpdatedAt,
 			&messageID,
@@ -156,7 +191,10 @@ func (r *ConversationRepository) ListForParticipant(
 			&messageContent,
 			&messageIsRead,
 			&messageCreatedAt,
+			&messageEditedAt,
+			&messageDeletedAt,
 			&summary.UnreadCount,
+			&summary.MemberCount,
 		); err != nil {
 			return nil, err
 		}
@@ -169,6 +207,8 @@ func (r *ConversationRepository) ListForParticipant(
 				Content:        messageContent.String,
 				IsRead:         messageIsRead.Bool,
 				CreatedAt:      messageCreatedAt.Time,
+				EditedAt:       messageEditedAt,
+				DeletedAt:      messageDeletedAt,
 			}
 		}
 
@@ -190,3 +230,161 @@ func (r *ConversationRepository) Touch(ctx context.Context, conversationID int64
 	`, conversationID)
 	return err
 }
+
+// AddMember adds a user to a conversation, or brings back one who had left, with everything sent
+// so far counted as read. It reports false when the user was already a current member, leaving
+// their role unchanged.
+func (r *ConversationRepository) AddMember(
+	ctx context.Context,
+	conversationID int64,
+	userID int64,
+	role string,
+) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO conversation_members (conversation_id, user_id, role, last_read_message_id)
+		VALUES ($1, $2, $3, (SELECT COALESCE(MAX(id), 0) FROM messages WHERE conversation_id = $1))
+		ON CONFLICT (conversation_id, user_id) DO UPDATE
+		SET role = EXCLUDED.role,
+		    last_read_message_id = EXCLUDED.last_read_message_id,
+		    joined_at = NOW(),
+		    left_at = NULL
+		WHERE conversation_members.left_at IS NOT NULL
+	`, conversationID, userID, role)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetMember returns a current member of the conversation.
+func (r *ConversationRepository) GetMember(
+	ctx context.Context,
+	conversationID int64,
+	userID int64,
+) (*models.ConversationMember, error) {
+	var member models.ConversationMember
+	err := r.db.QueryRow(ctx, `
+		SELECT conversation_id, user_id, role, joined_at
+		FROM conversation_members
+		WHERE conversation_id = $1 AND user_id = $2 AND left_at IS NULL
+	`, conversationID, userID).Scan(
+		&member.ConversationID,
+		&member.UserID,
+		&member.Role,
+		&member.JoinedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListMembers returns the current members of a conversation, admins first.
+func (r *ConversationRepository) ListMembers(
+	ctx context.Context,
+	conversationID int64,
+) ([]models.ConversationMember, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT conversation_id, user_id, role, joined_at
+		FROM conversation_members
+		WHERE conversation_id = $1 AND left_at IS NULL
+		ORDER BY role = 'admin' DESC, joined_at, user_id
+	`, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]models.ConversationMember, 0)
+	for rows.Next() {
+		var member models.ConversationMember
+		if err := rows.Scan(
+			&member.ConversationID,
+			&member.UserID,
+			&member.Role,
+			&member.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ListMemberIDs returns the ids of a conversation's current members.
+func (r *ConversationRepository) ListMemberIDs(ctx context.Context, conversationID int64) ([]int64, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT user_id
+		FROM conversation_members
+		WHERE conversation_id = $1 AND left_at IS NULL
+		ORDER BY user_id
+	`, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// UpdateMemberRole changes a current member's role.
+func (r *ConversationRepository) UpdateMemberRole(
+	ctx context.Context,
+	conversationID int64,
+	userID int64,
+	role string,
+) (*models.ConversationMember, error) {
+	var member models.ConversationMember
+	err := r.db.QueryRow(ctx, `
+		UPDATE conversation_members
+		SET role = $3
+		WHERE conversation_id = $1 AND user_id = $2 AND left_at IS NULL
+		RETURNING conversation_id, user_id, role, joined_at
+	`, conversationID, userID, role).Scan(
+		&member.ConversationID,
+		&member.UserID,
+		&member.Role,
+		&member.JoinedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveMember marks a member as having left. It reports false when they were not a current member.
+func (r *ConversationRepository) RemoveMember(ctx context.Context, conversationID int64, userID int64) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE conversation_members
+		SET left_at = NOW()
+		WHERE conversation_id = $1 AND user_id = $2 AND left_at IS NULL
+	`, conversationID, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// AdvanceReadPosition moves a member's read position forward to messageID. It returns the position
+// before the move; positions never move back.
+func (r *ConversationRepository) AdvanceReadPosition(
+	ctx context.Context,
+	conversationID int64,
+	userID int64,
+	messageID int64,
+) (int64, error) {
+	var previous int64
+	err := r.db.QueryRow(ctx, `
+		UPDATE conversation_members cm
+		SET last_read_message_id = GREATEST(cm.last_read_message_id, $3)
+		FROM conversation_members old
+		WHERE cm.conversation_id = $1 AND cm.user_id = $2
+		  AND old.conversation_id = cm.conversation_id AND old.user_id = cm.user_id
+		RETURNING old.last_read_message_id
+	`, conversationID, userID, messageID).Scan(&previous)
+	if err != nil {
+		return 0, err
+	}
+	return previous, nil
+}
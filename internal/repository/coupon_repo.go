@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const couponColumns = `id, code, coach_id, discount_type, discount_value, max_redemptions, per_user_limit,
+	redemption_count, first_session_only, valid_from, valid_until, is_active, created_at, updated_at`
+
+type CreateCouponInput struct {
+	Code             string
+	CoachID          *int64
+	DiscountType     string
+	DiscountValue    float64
+	MaxRedemptions   *int
+	PerUserLimit     *int
+	FirstSessionOnly bool
+	ValidFrom        *time.Time
+	ValidUntil       *time.Time
+}
+
+type UpdateCouponInput struct {
+	MaxRedemptions *int
+	PerUserLimit   *int
+	ValidFrom      *time.Time
+	ValidUntil     *time.Time
+	IsActive       *bool
+}
+
+type CouponRepository struct {
+	db DBTX
+}
+
+func NewCouponRepository(db DBTX) *CouponRepository {
+	return &CouponRepository{db: db}
+}
+
+func (r *CouponRepository) Create(ctx context.Context, input CreateCouponInput) (*models.Coupon, error) {
+	query := `
+		INSERT INTO coupons (
+			code, coach_id, discount_type, discount_value, max_redemptions,
+			per_user_limit, first_session_only, valid_from, valid_until
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + couponColumns
+
+	return scanCoupon(r.db.QueryRow(
+		ctx,
+		query,
+		input.Code,
+		input.CoachID,
+		input.DiscountType,
+		input.DiscountValue,
+		input.MaxRedemptions,
+		input.PerUserLimit,
+		input.FirstSessionOnly,
+		input.ValidFrom,
+		input.ValidUntil,
+	))
+}
+
+func (r *CouponRepository) GetByID(ctx context.Context, couponID int64) (*models.Coupon, error) {
+	query := `
+		SELECT ` + couponColumns + `
+		FROM coupons
+		WHERE id = $1
+	`
+	return scanCoupon(r.db.QueryRow(ctx, query, couponID))
+}
+
+func (r *CouponRepository) GetByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	query := `
+		SELECT ` + couponColumns + `
+		FROM coupons
+		WHERE UPPER(code) = UPPER($1)
+	`
+	return scanCoupon(r.db.QueryRow(ctx, query, code))
+}
+
+func (r *CouponRepository) ListByCoachID(ctx context.Context, coachID int64) ([]models.Coupon, error) {
+	query := `
+		SELECT ` + couponColumns + `
+		FROM coupons
+		WHERE coach_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, coachID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := make([]models.Coupon, 0)
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, *coupon)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return coupons, nil
+}
+
+func (r *CouponRepository) Update(
+	ctx context.Context,
+	couponID int64,
+	coachID int64,
+	input UpdateCouponInput,
+) (*models.Coupon, error) {
+	query := `
+		UPDATE coupons
+		SET max_redemptions = COALESCE($3, max_redemptions),
+			per_user_limit = COALESCE($4, per_user_limit),
+			valid_from = COALESCE($5, valid_from),
+			valid_until = COALESCE($6, valid_until),
+			is_active = COALESCE($7, is_active),
+			updated_at = NOW()
+		WHERE id = $1 AND coach_id = $2
+		RETURNING ` + couponColumns
+
+	return scanCoupon(r.db.QueryRow(
+		ctx,
+		query,
+		couponID,
+		coachID,
+		input.MaxRedemptions,
+		input.PerUserLimit,
+		input.ValidFrom,
+		input.ValidUntil,
+		input.IsActive,
+	))
+}
+
+// IncrementRedemptions claims one use of the coupon. The row lock it takes is held until the
+// surrounding transaction ends, so concurrent redemptions of the same coupon are serialized.
+func (r *CouponRepository) IncrementRedemptions(ctx context.Context, couponID int64) (*models.Coupon, error) {
+	query := `
+		UPDATE coupons
+		SET redemption_count = redemption_count + 1,
+			updated_at = NOW()
+		WHERE id = $1
+			AND is_active
+			AND (max_redemptions IS NULL OR redemption_count < max_redemptions)
+		RETURNING ` + couponColumns
+
+	return scanCoupon(r.db.QueryRow(ctx, query, couponID))
+}
+
+func (r *CouponRepository) DecrementRedemptions(ctx context.Context, couponID int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE coupons
+		SET redemption_count = GREATEST(redemption_count - 1, 0),
+			updated_at = NOW()
+		WHERE id = $1
+	`, couponID)
+	return err
+}
+
+func (r *CouponRepository) CountUserRedemptions(ctx context.Context, couponID int64, userID int64) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM coupon_redemptions
+		WHERE coupon_id = $1 AND user_id = $2
+	`, couponID, userID).Scan(&count)
+	return count, err
+}
+
+// CountPriorSessions counts the user's non-cancelled bookings other than excludeSessionID,
+// optionally limited to one coach.
+func (r *CouponRepository) CountPriorSessions(
+	ctx context.Context,
+	userID int64,
+	coachID *int64,
+	excludeSessionID int64,
+) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM bookings
+		WHERE user_id = $1
+			AND ($2::BIGINT IS NULL OR coach_id = $2)
+			AND id <> $3
+			AND status <> 'cancelled'
+	`, userID, coachID, excludeSessionID).Scan(&count)
+	return count, err
+}
+
+func (r *CouponRepository) CreateRedemption(ctx context.Context, couponID int64, userID int64, paymentID int64) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO coupon_redemptions (coupon_id, user_id, payment_id)
+		VALUES ($1, $2, $3)
+	`, couponID, userID, paymentID)
+	return err
+}
+
+func (r *CouponRepository) DeleteRedemptionByPaymentID(ctx context.Context, paymentID int64) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM coupon_redemptions WHERE payment_id = $1`, paymentID)
+	return err
+}
+
+func scanCoupon(row pgx.Row) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := row.Scan(
+		&coupon.ID,
+		&coupon.Code,
+		&coupon.CoachID,
+		&coupon.DiscountType,
+		&coupon.DiscountValue,
+		&coupon.MaxRedemptions,
+		&coupon.PerUserLimit,
+		&coupon.RedemptionCount,
+		&coupon.FirstSessionOnly,
+		&coupon.ValidFrom,
+		&coupon.ValidUntil,
+		&coupon.IsActive,
+		&coupon.CreatedAt,
+		&coupon.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
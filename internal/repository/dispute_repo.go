@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const disputeColumns = `id, gateway_dispute_id, payment_id, user_id, coach_id, amount, reason, status,
+	evidence, evidence_due_by, evidence_submitted_at, closed_at, created_at, updated_at`
+
+type CreateDisputeInput struct {
+	GatewayDisputeID string
+	PaymentID        int64
+	UserID           int64
+	CoachID          int64
+	Amount           float64
+	Reason           *string
+	EvidenceDueBy    *time.Time
+}
+
+type CreateLedgerAdjustmentInput struct {
+	CoachID   int64
+	PaymentID int64
+	DisputeID *int64
+	Kind      string
+	Amount    float64
+}
+
+type DisputeRepository struct {
+	db DBTX
+}
+
+func NewDisputeRepository(db DBTX) *DisputeRepository {
+	return &DisputeRepository{db: db}
+}
+
+// Create inserts the dispute, returning the existing row if the gateway dispute was already recorded.
+func (r *DisputeRepository) Create(ctx context.Context, input CreateDisputeInput) (*models.Dispute, error) {
+	query := `
+		INSERT INTO disputes (
+			gateway_dispute_id, payment_id, user_id, coach_id, amount, reason, evidence_due_by
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (gateway_dispute_id)
+		DO UPDATE SET updated_at = disputes.updated_at
+		RETURNING ` + disputeColumns
+
+	return scanDispute(r.db.QueryRow(
+		ctx,
+		query,
+		input.GatewayDisputeID,
+		input.PaymentID,
+		input.UserID,
+		input.CoachID,
+		input.Amount,
+		input.Reason,
+		input.EvidenceDueBy,
+	))
+}
+
+func (r *DisputeRepository) GetByID(ctx context.Context, disputeID int64) (*models.Dispute, error) {
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes
+		WHERE id = $1
+	`
+	return scanDispute(r.db.QueryRow(ctx, query, disputeID))
+}
+
+func (r *DisputeRepository) GetByIDForUpdate(ctx context.Context, disputeID int64) (*models.Dispute, error) {
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes
+		WHERE id = $1
+		FOR UPDATE
+	`
+	return scanDispute(r.db.QueryRow(ctx, query, disputeID))
+}
+
+func (r *DisputeRepository) GetByGatewayIDForUpdate(ctx context.Context, gatewayDisputeID string) (*models.Dispute, error) {
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes
+		WHERE gateway_dispute_id = $1
+		FOR UPDATE
+	`
+	return scanDispute(r.db.QueryRow(ctx, query, gatewayDisputeID))
+}
+
+func (r *DisputeRepository) ListForParticipant(ctx context.Context, actorID int64, role string) ([]models.Dispute, error) {
+	actorColumn := "user_id"
+	if role == "coach" {
+		actorColumn = "coach_id"
+	}
+
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes
+		WHERE ` + actorColumn + ` = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, actorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	disputes := make([]models.Dispute, 0)
+	for rows.Next() {
+		dispute, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, *dispute)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return disputes, nil
+}
+
+func (r *DisputeRepository) SubmitEvidence(
+	ctx context.Context,
+	disputeID int64,
+	evidence *models.DisputeEvidence,
+) (*models.Dispute, error) {
+	payload, err := json.Marshal(evidence)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE disputes
+		SET evidence = $2,
+			evidence_submitted_at = NOW(),
+			status = 'under_review',
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'needs_response'
+		RETURNING ` + disputeColumns
+
+	return scanDispute(r.db.QueryRow(ctx, query, disputeID, payload))
+}
+
+func (r *DisputeRepository) Close(ctx context.Context, disputeID int64, status string) (*models.Dispute, error) {
+	query := `
+		UPDATE disputes
+		SET status = $2,
+			closed_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + disputeColumns
+
+	return scanDispute(r.db.QueryRow(ctx, query, disputeID, status))
+}
+
+func (r *DisputeRepository) CreateAdjustment(
+	ctx context.Context,
+	input CreateLedgerAdjustmentInput,
+) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO ledger_adjustments (coach_id, payment_id, dispute_id, kind, amount)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (dispute_id, kind) DO NOTHING
+	`, input.CoachID, input.PaymentID, input.DisputeID, input.Kind, input.Amount)
+	return err
+}
+
+func (r *DisputeRepository) ListAdjustmentsByDisputeID(
+	ctx context.Context,
+	disputeID int64,
+) ([]models.LedgerAdjustment, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, coach_id, payment_id, dispute_id, kind, amount, created_at
+		FROM ledger_adjustments
+		WHERE dispute_id = $1
+		ORDER BY id ASC
+	`, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments := make([]models.LedgerAdjustment, 0)
+	for rows.Next() {
+		var adjustment models.LedgerAdjustment
+		if err := rows.Scan(
+			&adjustment.ID,
+			&adjustment.CoachID,
+			&adjustment.PaymentID,
+			&adjustment.DisputeID,
+			&adjustment.Kind,
+			&adjustment.Amount,
+			&adjustment.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return adjustments, nil
+}
+
+func scanDispute(row pgx.Row) (*models.Dispute, error) {
+	var dispute models.Dispute
+	var evidence []byte
+	err := row.Scan(
+		&dispute.ID,
+		&dispute.GatewayDisputeID,
+		&dispute.PaymentID,
+		&dispute.UserID,
+		&dispute.CoachID,
+		&dispute.Amount,
+		&dispute.Reason,
+		&dispute.Status,
+		&evidence,
+		&dispute.EvidenceDueBy,
+		&dispute.EvidenceSubmittedAt,
+		&dispute.ClosedAt,
+		&dispute.CreatedAt,
+		&dispute.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(evidence) > 0 {
+		dispute.Evidence = &models.DisputeEvidence{}
+		if err := json.Unmarshal(evidence, dispute.Evidence); err != nil {
+			return nil, err
+		}
+	}
+	return &dispute, nil
+}
@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const exerciseColumns = `id, coach_id, name, aliases, muscle_groups, equipment, difficulty,
+	instructions, media_url, created_at, updated_at`
+
+type ExerciseInput struct {
+	Name         string
+	Aliases      []string
+	MuscleGroups []string
+	Equipment    []string
+	Difficulty   string
+	Instructions *string
+	MediaURL     *string
+}
+
+// ExerciseSearchFilter lists the shared catalog plus, when CoachID is set, that coach's
+// custom exercises.
+type ExerciseSearchFilter struct {
+	CoachID     int64
+	Query       string
+	MuscleGroup string
+	Equipment   string
+	Difficulty  string
+	CustomOnly  bool
+	Limit       int
+	Offset      int
+}
+
+type ExerciseRepository struct {
+	db DBTX
+}
+
+func NewExerciseRepository(db DBTX) *ExerciseRepository {
+	return &ExerciseRepository{db: db}
+}
+
+func (r *ExerciseRepository) Create(ctx context.Context, coachID int64, input ExerciseInput) (*models.Exercise, error) {
+	query := `
+		INSERT INTO exercises (
+			coach_id, name, aliases, muscle_groups, equipment, difficulty, instructions, media_url
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + exerciseColumns
+
+	return scanExercise(r.db.QueryRow(
+		ctx,
+		query,
+		coachID,
+		input.Name,
+		input.Aliases,
+		input.MuscleGroups,
+		input.Equipment,
+		input.Difficulty,
+		input.Instructions,
+		input.MediaURL,
+	))
+}
+
+// UpsertCatalog inserts or refreshes a shared catalog exercise matched by name and reports
+// whether a new row was created.
+func (r *ExerciseRepository) UpsertCatalog(ctx context.Context, input ExerciseInput) (bool, error) {
+	query := `
+		INSERT INTO exercises (
+			name, aliases, muscle_groups, equipment, difficulty, instructions, media_url
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT ((LOWER(name))) WHERE coach_id IS NULL
+		DO UPDATE SET name = EXCLUDED.name,
+			aliases = EXCLUDED.aliases,
+			muscle_groups = EXCLUDED.muscle_groups,
+			equipment = EXCLUDED.equipment,
+			difficulty = EXCLUDED.difficulty,
+			instructions = EXCLUDED.instructions,
+			media_url = COALESCE(EXCLUDED.media_url, exercises.media_url),
+			updated_at = NOW()
+		RETURNING xmax = 0
+	`
+
+	var inserted bool
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		input.Name,
+		input.Aliases,
+		input.MuscleGroups,
+		input.Equipment,
+		input.Difficulty,
+		input.Instructions,
+		input.MediaURL,
+	).Scan(&inserted)
+	return inserted, err
+}
+
+func (r *ExerciseRepository) GetByID(ctx context.Context, exerciseID int64) (*models.Exercise, error) {
+	query := `
+		SELECT ` + exerciseColumns + `
+		FROM exercises
+		WHERE id = $1
+	`
+	return scanExercise(r.db.QueryRow(ctx, query, exerciseID))
+}
+
+func (r *ExerciseRepository) Update(
+	ctx context.Context,
+	exerciseID int64,
+	coachID int64,
+	input ExerciseInput,
+) (*models.Exercise, error) {
+	query := `
+		UPDATE exercises
+		SET name = $3,
+			aliases = $4,
+			muscle_groups = $5,
+			equipment = $6,
+			difficulty = $7,
+			instructions = $8,
+			updated_at = NOW()
+		WHERE id = $1 AND coach_id = $2
+		RETURNING ` + exerciseColumns
+
+	return scanExercise(r.db.QueryRow(
+		ctx,
+		query,
+		exerciseID,
+		coachID,
+		input.Name,
+		input.Aliases,
+		input.MuscleGroups,
+		input.Equipment,
+		input.Difficulty,
+		input.Instructions,
+	))
+}
+
+func (r *ExerciseRepository) SetMediaURL(
+	ctx context.Context,
+	exerciseID int64,
+	coachID int64,
+	mediaURL string,
+) (*models.Exercise, error) {
+	query := `
+		UPDATE exercises
+		SET media_url = $3,
+			updated_at = NOW()
+		WHERE id = $1 AND coach_id = $2
+		RETURNING ` + exerciseColumns
+
+	return scanExercise(r.db.QueryRow(ctx, query, exerciseID, coachID, mediaURL))
+}
+
+func (r *ExerciseRepository) Delete(ctx context.Context, exerciseID int64, coachID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM exercises WHERE id = $1 AND coach_id = $2`, exerciseID, coachID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Search ranks by full-text relevance when a query is given and alphabetically otherwise.
+func (r *ExerciseRepository) Search(ctx context.Context, filter ExerciseSearchFilter) ([]models.Exercise, int, error) {
+	args := make([]any, 0, 7)
+	whereParts := make([]string, 0, 5)
+
+	switch {
+	case filter.CustomOnly:
+		args = append(args, filter.CoachID)
+		whereParts = append(whereParts, fmt.Sprintf("coach_id = $%d", len(args)))
+	case filter.CoachID > 0:
+		args = append(args, filter.CoachID)
+		whereParts = append(whereParts, fmt.Sprintf("(coach_id IS NULL OR coach_id = $%d)", len(args)))
+	default:
+		whereParts = append(whereParts, "coach_id IS NULL")
+	}
+
+	orderBy := "LOWER(name) ASC, id ASC"
+	if query := strings.TrimSpace(filter.Query); query != "" {
+		args = append(args, query)
+		whereParts = append(whereParts, fmt.Sprintf("search_vector @@ websearch_to_tsquery('english', $%d)", len(args)))
+		orderBy = fmt.Sprintf("ts_rank(search_vector, websearch_to_tsquery('english', $%d)) DESC, LOWER(name) ASC, id ASC", len(args))
+	}
+	if muscleGroup := strings.TrimSpace(filter.MuscleGroup); muscleGroup != "" {
+		args = append(args, strings.ToLower(muscleGroup))
+		whereParts = append(whereParts, fmt.Sprintf("$%d = ANY(muscle_groups)", len(args)))
+	}
+	if equipment := strings.TrimSpace(filter.Equipment); equipment != "" {
+		args = append(args, strings.ToLower(equipment))
+		whereParts = append(whereParts, fmt.Sprintf("$%d = ANY(equipment)", len(args)))
+	}
+	if difficulty := strings.TrimSpace(filter.Difficulty); difficulty != "" {
+		args = append(args, difficulty)
+		whereParts = append(whereParts, fmt.Sprintf("difficulty = $%d", len(args)))
+	}
+
+	whereClause := strings.Join(whereParts, " AND ")
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM exercises WHERE %s", whereClause)
+	if err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM exercises
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, exerciseColumns, whereClause, orderBy, len(args)-1, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	exercises := make([]models.Exercise, 0, filter.Limit)
+	for rows.Next() {
+		exercise, err := scanExercise(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		exercises = append(exercises, *exercise)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return exercises, total, nil
+}
+
+// CountAccessible counts how many of exerciseIDs are in the shared catalog or owned by coachID.
+func (r *ExerciseRepository) CountAccessible(ctx context.Context, coachID int64, exerciseIDs []int64) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM exercises
+		WHERE id = ANY($2) AND (coach_id IS NULL OR coach_id = $1)
+	`, coachID, exerciseIDs).Scan(&count)
+	return count, err
+}
+
+func scanExercise(row pgx.Row) (*models.Exercise, error) {
+	var exercise models.Exercise
+	err := row.Scan(
+		&exercise.ID,
+		&exercise.CoachID,
+		&exercise.Name,
+		&exercise.Aliases,
+		&exercise.MuscleGroups,
+		&exercise.Equipment,
+		&exercise.Difficulty,
+		&exercise.Instructions,
+		&exercise.MediaURL,
+		&exercise.CreatedAt,
+		&exercise.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &exercise, nil
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const invoiceColumns = `id, invoice_number, kind, payment_id, coach_id, user_id, sequence_number, description,
+	subtotal, tax_rate, tax_amount, total, seller_name, seller_email, seller_tax_id, seller_address,
+	buyer_name, buyer_email, pdf_path, issued_at`
+
+const billingDetailsColumns = `coach_id, legal_name, tax_id, address, tax_rate, updated_at`
+
+type CreateInvoiceInput struct {
+	InvoiceNumber  string
+	Kind           string
+	PaymentID      int64
+	CoachID        int64
+	UserID         int64
+	SequenceNumber int
+	Description    string
+	Subtotal       float64
+	TaxRate        float64
+	TaxAmount      float64
+	Total          float64
+	SellerName     string
+	SellerEmail    string
+	SellerTaxID    *string
+	SellerAddress  *string
+	BuyerName      string
+	BuyerEmail     string
+}
+
+type UpdateBillingDetailsInput struct {
+	LegalName *string
+	TaxID     *string
+	Address   *string
+	TaxRate   float64
+}
+
+type BillingParty struct {
+	Name  string
+	Email string
+}
+
+type InvoiceRepository struct {
+	db DBTX
+}
+
+func NewInvoiceRepository(db DBTX) *InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+func (r *InvoiceRepository) GetBillingDetails(ctx context.Context, coachID int64) (*models.CoachBillingDetails, error) {
+	query := `
+		SELECT ` + billingDetailsColumns + `
+		FROM coach_billing_details
+		WHERE coach_id = $1
+	`
+
+	details, err := scanBillingDetails(r.db.QueryRow(ctx, query, coachID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.CoachBillingDetails{CoachID: coachID}, nil
+		}
+		return nil, err
+	}
+	return details, nil
+}
+
+func (r *InvoiceRepository) UpsertBillingDetails(
+	ctx context.Context,
+	coachID int64,
+	input UpdateBillingDetailsInput,
+) (*models.CoachBillingDetails, error) {
+	query := `
+		INSERT INTO coach_billing_details (coach_id, legal_name, tax_id, address, tax_rate)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (coach_id)
+		DO UPDATE SET
+			legal_name = EXCLUDED.legal_name,
+			tax_id = EXCLUDED.tax_id,
+			address = EXCLUDED.address,
+			tax_rate = EXCLUDED.tax_rate,
+			updated_at = NOW()
+		RETURNING ` + billingDetailsColumns
+
+	return scanBillingDetails(r.db.QueryRow(
+		ctx,
+		query,
+		coachID,
+		input.LegalName,
+		input.TaxID,
+		input.Address,
+		input.TaxRate,
+	))
+}
+
+func (r *InvoiceRepository) GetBillingParty(ctx context.Context, userID int64) (*BillingParty, error) {
+	query := `
+		SELECT u.email, COALESCE(up.full_name, cp.full_name, '')
+		FROM users u
+		LEFT JOIN user_profiles up ON up.user_id = u.id
+		LEFT JOIN coach_profiles cp ON cp.user_id = u.id
+		WHERE u.id = $1
+	`
+
+	var party BillingParty
+	if err := r.db.QueryRow(ctx, query, userID).Scan(&party.Email, &party.Name); err != nil {
+		return nil, err
+	}
+	return &party, nil
+}
+
+// NextSequenceNumber locks the coach's counter row until the surrounding transaction ends,
+// which keeps invoice numbers gapless per coach.
+func (r *InvoiceRepository) NextSequenceNumber(ctx context.Context, coachID int64) (int, error) {
+	query := `
+		INSERT INTO invoice_sequences (coach_id, last_number)
+		VALUES ($1, 1)
+		ON CONFLICT (coach_id)
+		DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number
+	`
+
+	var next int
+	if err := r.db.QueryRow(ctx, query, coachID).Scan(&next); err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+func (r *InvoiceRepository) Create(ctx context.Context, input CreateInvoiceInput) (*models.Invoice, error) {
+	query := `
+		INSERT INTO invoices (
+			invoice_number, kind, payment_id, coach_id, user_id, sequence_number, description,
+			subtotal, tax_rate, tax_amount, total, seller_name, seller_email, seller_tax_id,
+			seller_address, buyer_name, buyer_email
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING ` + invoiceColumns
+
+	return scanInvoice(r.db.QueryRow(
+		ctx,
+		query,
+		input.InvoiceNumber,
+		input.Kind,
+		input.PaymentID,
+		input.CoachID,
+		input.UserID,
+		input.SequenceNumber,
+		input.Description,
+		input.Subtotal,
+		input.TaxRate,
+		input.TaxAmount,
+		input.Total,
+		input.SellerName,
+		input.SellerEmail,
+		input.SellerTaxID,
+		input.SellerAddress,
+		input.BuyerName,
+		input.BuyerEmail,
+	))
+}
+
+func (r *InvoiceRepository) GetByPaymentID(ctx context.Context, paymentID int64, kind string) (*models.Invoice, error) {
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE payment_id = $1 AND kind = $2
+	`
+	return scanInvoice(r.db.QueryRow(ctx, query, paymentID, kind))
+}
+
+func (r *InvoiceRepository) SetPDFPath(ctx context.Context, invoiceID int64, pdfPath string) (*models.Invoice, error) {
+	query := `
+		UPDATE invoices
+		SET pdf_path = $2
+		WHERE id = $1 AND pdf_path IS NULL
+		RETURNING ` + invoiceColumns
+
+	return scanInvoice(r.db.QueryRow(ctx, query, invoiceID, pdfPath))
+}
+
+func scanInvoice(row pgx.Row) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := row.Scan(
+		&invoice.ID,
+		&invoice.InvoiceNumber,
+		&invoice.Kind,
+		&invoice.PaymentID,
+		&invoice.CoachID,
+		&invoice.UserID,
+		&invoice.SequenceNumber,
+		&invoice.Description,
+		&invoice.Subtotal,
+		&invoice.TaxRate,
+		&invoice.TaxAmount,
+		&invoice.Total,
+		&invoice.SellerName,
+		&invoice.SellerEmail,
+		&invoice.SellerTaxID,
+		&invoice.SellerAddress,
+		&invoice.BuyerName,
+		&invoice.BuyerEmail,
+		&invoice.PDFPath,
+		&invoice.IssuedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func scanBillingDetails(row pgx.Row) (*models.CoachBillingDetails, error) {
+	var details models.CoachBillingDetails
+	err := row.Scan(
+		&details.CoachID,
+		&details.LegalName,
+		&details.TaxID,
+		&details.Address,
+		&details.TaxRate,
+		&details.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
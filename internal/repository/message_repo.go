@@ -2,7 +2,11 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/saeid-a/CoachAppBack/internal/models"
 )
 
@@ -14,34 +18,61 @@ func NewMessageRepository(db DBTX) *MessageRepository {
 	return &MessageRepository{db: db}
 }
 
-func (r *MessageRepository) Create(
-	ctx context.Context,
-	conversationID int64,
-	senderID int64,
-	content string,
-) (*models.ChatMessage, error) {
-	query := `
-		INSERT INTO messages (conversation_id, sender_id, content, is_read)
-		VALUES ($1, $2, $3, FALSE)
-		RETURNING id, conversation_id, sender_id, content, is_read, created_at
-	`
+// messageColumns reads a message as participants see it: removed messages come back without content.
+const messageColumns = `
+	m.id, m.conversation_id, m.sender_id,
+	CASE WHEN m.deleted_at IS NULL THEN m.content ELSE '' END,
+	m.is_read, m.created_at, m.edited_at, m.deleted_at
+`
 
+func scanMessage(row pgx.Row) (*models.ChatMessage, error) {
 	var message models.ChatMessage
-	err := r.db.QueryRow(ctx, query, conversationID, senderID, content).Scan(
+	if err := row.Scan(
 		&message.ID,
 		&message.ConversationID,
 		&message.SenderID,
 		&message.Content,
 		&message.IsRead,
 		&message.CreatedAt,
-	)
-	if err != nil {
+		&message.EditedAt,
+		&message.DeletedAt,
+	); err != nil {
 		return nil, err
 	}
-
 	return &message, nil
 }
 
+func scanMessages(rows pgx.Rows) ([]models.ChatMessage, error) {
+	defer rows.Close()
+
+	messages := make([]models.ChatMessage, 0)
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *MessageRepository) Create(
+	ctx context.Context,
+	conversationID int64,
+	senderID int64,
+	content string,
+) (*models.ChatMessage, error) {
+	query := `
+		INSERT INTO messages AS m (conversation_id, sender_id, content, is_read)
+		VALUES ($1, $2, $3, FALSE)
+		RETURNING ` + messageColumns
+
+	return scanMessage(r.db.QueryRow(ctx, query, conversationID, senderID, content))
+}
+
 func (r *MessageRepository) ListByConversation(
 	ctx context.Context,
 	conversationID int64,
@@ -60,10 +91,10 @@ func (r *MessageRepository) ListByConversation(
 	}
 
 	query := `
-		SELECT id, conversation_id, sender_id, content, is_read, created_at
-		FROM messages
-		WHERE conversation_id = $1
-		ORDER BY created_at DESC, id DESC
+		SELECT ` + messageColumns + `
+		FROM messages m
+		WHERE m.conversation_id = $1
+		ORDER BY m.created_at DESC, m.id DESC
 		LIMIT $2 OFFSET $3
 	`
 
@@ -71,11 +102,127 @@ func (r *MessageRepository) ListByConversation(
 	if err != nil {
 		return nil, 0, err
 	}
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
+
+// ListByCursor returns up to limit messages older than beforeID, newer than afterID, or the latest
+// ones when both are zero. Messages come newest first either way.
+func (r *MessageRepository) ListByCursor(
+	ctx context.Context,
+	conversationID int64,
+	beforeID int64,
+	afterID int64,
+	limit int,
+) ([]models.ChatMessage, error) {
+	var query string
+	args := []any{conversationID, limit}
+	switch {
+	case afterID > 0:
+		// Take the messages right after the cursor, then flip them to newest first.
+		args = append(args, afterID)
+		query = `
+			SELECT * FROM (
+				SELECT ` + messageColumns + `
+				FROM messages m
+				WHERE m.conversation_id = $1 AND m.id > $3
+				ORDER BY m.id ASC
+				LIMIT $2
+			) page
+			ORDER BY id DESC
+		`
+	case beforeID > 0:
+		args = append(args, beforeID)
+		query = `
+			SELECT ` + messageColumns + `
+			FROM messages m
+			WHERE m.conversation_id = $1 AND m.id < $3
+			ORDER BY m.id DESC
+			LIMIT $2
+		`
+	default:
+		query = `
+			SELECT ` + messageColumns + `
+			FROM messages m
+			WHERE m.conversation_id = $1
+			ORDER BY m.id DESC
+			LIMIT $2
+		`
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanMessages(rows)
+}
+
+// Search highlights wrap matches in these control characters, which cannot come from the
+// message text because Search strips them first.
+const (
+	SearchMatchStart = "\x02"
+	SearchMatchStop  = "\x03"
+)
+
+type MessageSearchFilter struct {
+	ParticipantID  int64
+	Query          string
+	ConversationID int64
+	BeforeID       int64
+	Limit          int
+}
+
+// Search returns matches from the participant's conversations, newest first and without removed
+// messages, each with a highlighted snippet.
+func (r *MessageRepository) Search(ctx context.Context, filter MessageSearchFilter) ([]models.ChatSearchResult, error) {
+	args := []any{filter.ParticipantID, filter.Query}
+	whereParts := []string{
+		memberClause("$1"),
+		"m.deleted_at IS NULL",
+		"m.search_vector @@ q.query",
+	}
+	if filter.ConversationID > 0 {
+		args = append(args, filter.ConversationID)
+		whereParts = append(whereParts, fmt.Sprintf("m.conversation_id = $%d", len(args)))
+	}
+	if filter.BeforeID > 0 {
+		args = append(args, filter.BeforeID)
+		whereParts = append(whereParts, fmt.Sprintf("m.id < $%d", len(args)))
+	}
+
+	args = append(args, fmt.Sprintf(
+		`StartSel="%s", StopSel="%s", MinWords=8, MaxWords=24, MaxFragments=2, FragmentDelimiter=…`,
+		SearchMatchStart,
+		SearchMatchStop,
+	))
+	optionsArg := len(args)
+	args = append(args, filter.Limit)
+
+	query := fmt.Sprintf(`
+		SELECT %s,
+			ts_headline('english', translate(m.content, E'\x02\x03', ''), q.query, $%d)
+		FROM messages m
+		JOIN conversations c ON c.id = m.conversation_id
+		CROSS JOIN websearch_to_tsquery('english', $2) AS q(query)
+		WHERE %s
+		ORDER BY m.id DESC
+		LIMIT $%d
+	`, messageColumns, optionsArg, strings.Join(whereParts, " AND "), len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
-	messages := make([]models.ChatMessage, 0)
+	results := make([]models.ChatSearchResult, 0, filter.Limit)
 	for rows.Next() {
-		var message models.ChatMessage
+		var result models.ChatSearchResult
+		message := &result.Message
 		if err := rows.Scan(
 			&message.ID,
 			&message.ConversationID,
@@ -83,18 +230,215 @@ func (r *MessageRepository) ListByConversation(
 			&message.Content,
 			&message.IsRead,
 			&message.CreatedAt,
+			&message.EditedAt,
+			&message.DeletedAt,
+			&result.Snippet,
 		); err != nil {
-			return nil, 0, err
+			return nil, err
 		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
 
-		messages = append(messages, message)
+// ListBetweenParticipants returns up to limit messages exchanged by the pair in [from, to), oldest first.
+// It serves as dispute evidence, so removed messages keep their original content.
+func (r *MessageRepository) ListBetweenParticipants(
+	ctx context.Context,
+	userID int64,
+	coachID int64,
+	from time.Time,
+	to time.Time,
+	limit int,
+) ([]models.ChatMessage, error) {
+	query := `
+		SELECT m.id, m.conversation_id, m.sender_id, m.content, m.is_read, m.created_at, m.edited_at, m.deleted_at
+		FROM (
+			SELECT m.*
+			FROM messages m
+			JOIN conversations c ON c.id = m.conversation_id
+			WHERE c.user_id = $1 AND c.coach_id = $2
+				AND m.created_at >= $3 AND m.created_at < $4
+			ORDER BY m.created_at DESC, m.id DESC
+			LIMIT $5
+		) m
+		ORDER BY m.created_at ASC, m.id ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, coachID, from, to, limit)
+	if err != nil {
+		return nil, err
 	}
+	return scanMessages(rows)
+}
+
+// GetForUpdate locks a message of the conversation for an edit or removal. Removed messages keep
+// their original content here.
+func (r *MessageRepository) GetForUpdate(
+	ctx context.Context,
+	conversationID int64,
+	messageID int64,
+) (*models.ChatMessage, error) {
+	return scanMessage(r.db.QueryRow(ctx, `
+		SELECT m.id, m.conversation_id, m.sender_id, m.content, m.is_read, m.created_at, m.edited_at, m.deleted_at
+		FROM messages m
+		WHERE m.id = $1 AND m.conversation_id = $2
+		FOR UPDATE
+	`, messageID, conversationID))
+}
+
+// Get returns a message of the conversation as participants see it.
+func (r *MessageRepository) Get(
+	ctx context.Context,
+	conversationID int64,
+	messageID int64,
+) (*models.ChatMessage, error) {
+	return scanMessage(r.db.QueryRow(ctx, `
+		SELECT `+messageColumns+`
+		FROM messages m
+		WHERE m.id = $1 AND m.conversation_id = $2
+	`, messageID, conversationID))
+}
+
+// UpdateContent replaces the content of a message and records the previous version.
+func (r *MessageRepository) UpdateContent(
+	ctx context.Context,
+	messageID int64,
+	previousContent string,
+	content string,
+) (*models.ChatMessage, error) {
+	if _, err := r.db.Exec(ctx, `
+		INSERT INTO message_edits (message_id, content)
+		VALUES ($1, $2)
+	`, messageID, previousContent); err != nil {
+		return nil, err
+	}
+
+	return scanMessage(r.db.QueryRow(ctx, `
+		UPDATE messages AS m
+		SET content = $2, edited_at = NOW()
+		WHERE m.id = $1
+		RETURNING `+messageColumns, messageID, content))
+}
+
+// SoftDelete turns a message into a tombstone and drops its reactions.
+func (r *MessageRepository) SoftDelete(ctx context.Context, messageID int64) (*models.ChatMessage, error) {
+	if _, err := r.db.Exec(ctx, `DELETE FROM message_reactions WHERE message_id = $1`, messageID); err != nil {
+		return nil, err
+	}
+
+	return scanMessage(r.db.QueryRow(ctx, `
+		UPDATE messages AS m
+		SET deleted_at = NOW()
+		WHERE m.id = $1
+		RETURNING `+messageColumns, messageID))
+}
 
+// ListEdits returns the earlier versions of a message, oldest first.
+func (r *MessageRepository) ListEdits(ctx context.Context, messageID int64) ([]models.MessageEdit, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, message_id, content, edited_at
+		FROM message_edits
+		WHERE message_id = $1
+		ORDER BY id
+	`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edits := make([]models.MessageEdit, 0)
+	for rows.Next() {
+		var edit models.MessageEdit
+		if err := rows.Scan(&edit.ID, &edit.MessageID, &edit.Content, &edit.EditedAt); err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+	}
 	if err := rows.Err(); err != nil {
-		return nil, 0, err
+		return nil, err
 	}
+	return edits, nil
+}
 
-	return messages, total, nil
+// SetReaction records the participant's reaction to a message, replacing any earlier one.
+func (r *MessageRepository) SetReaction(
+	ctx context.Context,
+	messageID int64,
+	userID int64,
+	emoji string,
+) (*models.MessageReaction, error) {
+	var reaction models.MessageReaction
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO message_reactions (message_id, user_id, emoji)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (message_id, user_id)
+		DO UPDATE SET emoji = EXCLUDED.emoji, created_at = NOW()
+		RETURNING message_id, user_id, emoji, created_at
+	`, messageID, userID, emoji).Scan(
+		&reaction.MessageID,
+		&reaction.UserID,
+		&reaction.Emoji,
+		&reaction.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &reaction, nil
+}
+
+// DeleteReaction removes the participant's reaction and reports whether there was one.
+func (r *MessageRepository) DeleteReaction(ctx context.Context, messageID int64, userID int64) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM message_reactions
+		WHERE message_id = $1 AND user_id = $2
+	`, messageID, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ListReactions returns the reactions to the given messages, oldest first.
+func (r *MessageRepository) ListReactions(
+	ctx context.Context,
+	messageIDs []int64,
+) ([]models.MessageReaction, error) {
+	reactions := make([]models.MessageReaction, 0)
+	if len(messageIDs) == 0 {
+		return reactions, nil
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT message_id, user_id, emoji, created_at
+		FROM message_reactions
+		WHERE message_id = ANY($1)
+		ORDER BY created_at, user_id
+	`, messageIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reaction models.MessageReaction
+		if err := rows.Scan(
+			&reaction.MessageID,
+			&reaction.UserID,
+			&reaction.Emoji,
+			&reaction.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, reaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return reactions, nil
 }
 
 func (r *MessageRepository) MarkConversationRead(
@@ -112,20 +456,180 @@ func (r *MessageRepository) MarkConversationRead(
 	return err
 }
 
+// ListReceived returns which of the given ids are messages in the conversation that someone other
+// than the reader sent.
+func (r *MessageRepository) ListReceived(
+	ctx context.Context,
+	conversationID int64,
+	messageIDs []int64,
+	readerID int64,
+) ([]int64, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id
+		FROM messages
+		WHERE id = ANY($1)
+		  AND conversation_id = $2
+		  AND sender_id <> $3
+		ORDER BY id
+	`, messageIDs, conversationID, readerID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// MarkMessagesRead marks the conversation's messages the reader received as read and returns the
+// ids that were unread until now.
 func (r *MessageRepository) MarkMessagesRead(
 	ctx context.Context,
+	conversationID int64,
 	messageIDs []int64,
 	readerID int64,
-) error {
+) ([]int64, error) {
+	marked := make([]int64, 0)
 	if len(messageIDs) == 0 {
-		return nil
+		return marked, nil
 	}
-	_, err := r.db.Exec(ctx, `
+	rows, err := r.db.Query(ctx, `
 		UPDATE messages
 		SET is_read = TRUE
 		WHERE id = ANY($1)
-		  AND sender_id <> $2
+		  AND conversation_id = $2
+		  AND sender_id <> $3
 		  AND is_read = FALSE
-	`, messageIDs, readerID)
-	return err
+		RETURNING id
+	`, messageIDs, conversationID, readerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		marked = append(marked, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return marked, nil
+}
+
+type MessageAttachmentInput struct {
+	Kind          string
+	FilePath      string
+	ThumbnailPath *string
+	Filename      string
+	ContentType   string
+	SizeBytes     int64
+	Width         *int
+	Height        *int
+}
+
+const messageAttachmentColumns = `
+	a.id, a.message_id, a.kind, a.file_path, a.thumbnail_path, a.filename, a.content_type,
+	a.size_bytes, a.width, a.height, a.created_at
+`
+
+func scanMessageAttachment(row pgx.Row) (*models.MessageAttachment, error) {
+	var attachment models.MessageAttachment
+	if err := row.Scan(
+		&attachment.ID,
+		&attachment.MessageID,
+		&attachment.Kind,
+		&attachment.FilePath,
+		&attachment.ThumbnailPath,
+		&attachment.Filename,
+		&attachment.ContentType,
+		&attachment.SizeBytes,
+		&attachment.Width,
+		&attachment.Height,
+		&attachment.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *MessageRepository) CreateAttachment(
+	ctx context.Context,
+	messageID int64,
+	input MessageAttachmentInput,
+) (*models.MessageAttachment, error) {
+	query := `
+		INSERT INTO message_attachments AS a (
+			message_id, kind, file_path, thumbnail_path, filename, content_type, size_bytes, width, height
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + messageAttachmentColumns
+
+	return scanMessageAttachment(r.db.QueryRow(
+		ctx,
+		query,
+		messageID,
+		input.Kind,
+		input.FilePath,
+		input.ThumbnailPath,
+		input.Filename,
+		input.ContentType,
+		input.SizeBytes,
+		input.Width,
+		input.Height,
+	))
+}
+
+// ListAttachments returns the attachments of the given messages, in upload order.
+func (r *MessageRepository) ListAttachments(
+	ctx context.Context,
+	messageIDs []int64,
+) ([]models.MessageAttachment, error) {
+	attachments := make([]models.MessageAttachment, 0)
+	if len(messageIDs) == 0 {
+		return attachments, nil
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT `+messageAttachmentColumns+`
+		FROM message_attachments a
+		WHERE a.message_id = ANY($1)
+		ORDER BY a.id
+	`, messageIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attachment, err := scanMessageAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// GetAttachmentForParticipant returns an attachment sent in the conversation, provided that
+// participantID takes part in it.
+func (r *MessageRepository) GetAttachmentForParticipant(
+	ctx context.Context,
+	conversationID int64,
+	attachmentID int64,
+	participantID int64,
+) (*models.MessageAttachment, error) {
+	return scanMessageAttachment(r.db.QueryRow(ctx, `
+		SELECT `+messageAttachmentColumns+`
+		FROM message_attachments a
+		JOIN messages m ON m.id = a.message_id
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE a.id = $1
+		  AND c.id = $2
+		  AND `+memberClause("$3")+`
+		  AND m.deleted_at IS NULL
+	`, attachmentID, conversationID, participantID))
 }
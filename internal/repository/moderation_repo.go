@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const moderationItemColumns = `
+	id, source, conversation_id, message_id, sender_id, reporter_id, reason, details, content, rules,
+	action, status, reviewed_by, review_note, reviewed_at, created_at
+`
+
+// ModerationItemInput is a new entry for the moderation queue.
+type ModerationItemInput struct {
+	Source         string
+	ConversationID int64
+	MessageID      *int64
+	SenderID       *int64
+	ReporterID     *int64
+	Reason         *string
+	Details        *string
+	Content        string
+	Rules          []string
+	Action         *string
+}
+
+type ModerationFilter struct {
+	Status string
+	Source string
+	Limit  int
+	Offset int
+}
+
+// ModerationRepository stores the moderation queue and the blocks between users.
+type ModerationRepository struct {
+	db DBTX
+}
+
+func NewModerationRepository(db DBTX) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+func scanModerationItem(row pgx.Row) (*models.ModerationItem, error) {
+	var item models.ModerationItem
+	if err := row.Scan(
+		&item.ID,
+		&item.Source,
+		&item.ConversationID,
+		&item.MessageID,
+		&item.SenderID,
+		&item.ReporterID,
+		&item.Reason,
+		&item.Details,
+		&item.Content,
+		&item.Rules,
+		&item.Action,
+		&item.Status,
+		&item.ReviewedBy,
+		&item.ReviewNote,
+		&item.ReviewedAt,
+		&item.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *ModerationRepository) CreateItem(ctx context.Context, input ModerationItemInput) (*models.ModerationItem, error) {
+	rules := input.Rules
+	if rules == nil {
+		rules = []string{}
+	}
+	query := `
+		INSERT INTO chat_moderation_items (
+			source, conversation_id, message_id, sender_id, reporter_id, reason, details, content, rules, action
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + moderationItemColumns
+
+	return scanModerationItem(r.db.QueryRow(
+		ctx,
+		query,
+		input.Source,
+		input.ConversationID,
+		input.MessageID,
+		input.SenderID,
+		input.ReporterID,
+		input.Reason,
+		input.Details,
+		input.Content,
+		rules,
+		input.Action,
+	))
+}
+
+func (r *ModerationRepository) GetItem(ctx context.Context, itemID int64) (*models.ModerationItem, error) {
+	query := `SELECT ` + moderationItemColumns + ` FROM chat_moderation_items WHERE id = $1`
+	return scanModerationItem(r.db.QueryRow(ctx, query, itemID))
+}
+
+// GetItemForUpdate locks a queue item while it is reviewed.
+func (r *ModerationRepository) GetItemForUpdate(ctx context.Context, itemID int64) (*models.ModerationItem, error) {
+	query := `SELECT ` + moderationItemColumns + ` FROM chat_moderation_items WHERE id = $1 FOR UPDATE`
+	return scanModerationItem(r.db.QueryRow(ctx, query, itemID))
+}
+
+// ListItems returns a page of the queue, oldest first so items are reviewed in the order they came in.
+func (r *ModerationRepository) ListItems(ctx context.Context, filter ModerationFilter) ([]models.ModerationItem, int, error) {
+	args := []any{}
+	whereParts := []string{"TRUE"}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		whereParts = append(whereParts, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Source != "" {
+		args = append(args, filter.Source)
+		whereParts = append(whereParts, fmt.Sprintf("source = $%d", len(args)))
+	}
+	whereClause := strings.Join(whereParts, " AND ")
+
+	var total int
+	if err := r.db.QueryRow(
+		ctx,
+		`SELECT COUNT(*) FROM chat_moderation_items WHERE `+whereClause,
+		args...,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM chat_moderation_items
+		WHERE %s
+		ORDER BY created_at, id
+		LIMIT $%d OFFSET $%d
+	`, moderationItemColumns, whereClause, len(args)-1, len(args))
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := make([]models.ModerationItem, 0, filter.Limit)
+	for rows.Next() {
+		item, err := scanModerationItem(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// ReviewItem closes a queue item as resolved or dismissed.
+func (r *ModerationRepository) ReviewItem(
+	ctx context.Context,
+	itemID int64,
+	reviewerID int64,
+	status string,
+	note *string,
+) (*models.ModerationItem, error) {
+	query := `
+		UPDATE chat_moderation_items
+		SET status = $3, reviewed_by = $2, review_note = $4, reviewed_at = NOW()
+		WHERE id = $1
+		RETURNING ` + moderationItemColumns
+	return scanModerationItem(r.db.QueryRow(ctx, query, itemID, reviewerID, status, note))
+}
+
+// Block stops blockedID from exchanging direct messages with blockerID. Blocking twice is a no-op.
+func (r *ModerationRepository) Block(ctx context.Context, blockerID int64, blockedID int64) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO user_blocks (blocker_id, blocked_id)
+		VALUES ($1, $2)
+		ON CONFLICT (blocker_id, blocked_id) DO NOTHING
+	`, blockerID, blockedID)
+	return err
+}
+
+// Unblock lifts a block and reports whether there was one.
+func (r *ModerationRepository) Unblock(ctx context.Context, blockerID int64, blockedID int64) (bool, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2`, blockerID, blockedID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ListBlocked returns the users blockerID has blocked, most recent first.
+func (r *ModerationRepository) ListBlocked(ctx context.Context, blockerID int64) ([]models.UserBlock, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT blocked_id, created_at
+		FROM user_blocks
+		WHERE blocker_id = $1
+		ORDER BY created_at DESC, blocked_id
+	`, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocks := make([]models.UserBlock, 0)
+	for rows.Next() {
+		var block models.UserBlock
+		if err := rows.Scan(&block.UserID, &block.CreatedAt); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// IsBlockedBetween reports whether either user has blocked the other.
+func (r *ModerationRepository) IsBlockedBetween(ctx context.Context, firstID int64, secondID int64) (bool, error) {
+	var blocked bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)
+	`, firstID, secondID).Scan(&blocked)
+	return blocked, err
+}
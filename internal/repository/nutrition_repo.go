@@ -0,0 +1,400 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const nutritionPlanColumns = `id, coach_id, user_id, title, notes, calories_kcal, protein_g, carbs_g, fat_g,
+	meals, start_date, end_date, created_at, updated_at`
+
+const mealLogColumns = `id, user_id, meal, food_name, quantity, calories_kcal, protein_g, carbs_g, fat_g,
+	eaten_at, notes, created_at`
+
+type NutritionPlanInput struct {
+	Title     string
+	Notes     *string
+	Targets   models.MacroTargets
+	Meals     []models.MealTemplate
+	StartDate time.Time
+	EndDate   *time.Time
+}
+
+// NutritionPlanFilter lists plans for a coach, a client, or one coach-client pair.
+type NutritionPlanFilter struct {
+	CoachID *int64
+	UserID  *int64
+}
+
+type MealLogInput struct {
+	Meal         string
+	FoodName     string
+	Quantity     *string
+	CaloriesKcal int
+	ProteinG     float64
+	CarbsG       float64
+	FatG         float64
+	EatenAt      time.Time
+	Notes        *string
+}
+
+type MealLogFilter struct {
+	UserID int64
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+type NutritionRepository struct {
+	db DBTX
+}
+
+func NewNutritionRepository(db DBTX) *NutritionRepository {
+	return &NutritionRepository{db: db}
+}
+
+func (r *NutritionRepository) CreatePlan(
+	ctx context.Context,
+	coachID int64,
+	userID int64,
+	input NutritionPlanInput,
+) (*models.NutritionPlan, error) {
+	meals, err := marshalMealTemplates(input.Meals)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO nutrition_plans (
+			coach_id, user_id, title, notes, calories_kcal, protein_g, carbs_g, fat_g, meals,
+			start_date, end_date
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + nutritionPlanColumns
+
+	return scanNutritionPlan(r.db.QueryRow(
+		ctx,
+		query,
+		coachID,
+		userID,
+		input.Title,
+		input.Notes,
+		input.Targets.CaloriesKcal,
+		input.Targets.ProteinG,
+		input.Targets.CarbsG,
+		input.Targets.FatG,
+		meals,
+		input.StartDate,
+		input.EndDate,
+	))
+}
+
+func (r *NutritionRepository) GetPlanByID(ctx context.Context, planID int64) (*models.NutritionPlan, error) {
+	query := `
+		SELECT ` + nutritionPlanColumns + `
+		FROM nutrition_plans
+		WHERE id = $1
+	`
+	return scanNutritionPlan(r.db.QueryRow(ctx, query, planID))
+}
+
+func (r *NutritionRepository) UpdatePlan(
+	ctx context.Context,
+	planID int64,
+	input NutritionPlanInput,
+) (*models.NutritionPlan, error) {
+	meals, err := marshalMealTemplates(input.Meals)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE nutrition_plans
+		SET title = $2,
+			notes = $3,
+			calories_kcal = $4,
+			protein_g = $5,
+			carbs_g = $6,
+			fat_g = $7,
+			meals = $8,
+			start_date = $9,
+			end_date = $10,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + nutritionPlanColumns
+
+	return scanNutritionPlan(r.db.QueryRow(
+		ctx,
+		query,
+		planID,
+		input.Title,
+		input.Notes,
+		input.Targets.CaloriesKcal,
+		input.Targets.ProteinG,
+		input.Targets.CarbsG,
+		input.Targets.FatG,
+		meals,
+		input.StartDate,
+		input.EndDate,
+	))
+}
+
+func (r *NutritionRepository) DeletePlan(ctx context.Context, planID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM nutrition_plans WHERE id = $1`, planID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ListPlans returns matching plans, most recent start date first.
+func (r *NutritionRepository) ListPlans(ctx context.Context, filter NutritionPlanFilter) ([]models.NutritionPlan, error) {
+	conditions := make([]string, 0, 2)
+	args := make([]any, 0, 2)
+	if filter.CoachID != nil {
+		args = append(args, *filter.CoachID)
+		conditions = append(conditions, fmt.Sprintf("coach_id = $%d", len(args)))
+	}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("nutrition plan filter needs a coach or a user")
+	}
+
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM nutrition_plans
+		WHERE %s
+		ORDER BY start_date DESC, id DESC
+	`, nutritionPlanColumns, strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := make([]models.NutritionPlan, 0)
+	for rows.Next() {
+		plan, err := scanNutritionPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, *plan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+func (r *NutritionRepository) CreateMealLog(
+	ctx context.Context,
+	userID int64,
+	input MealLogInput,
+) (*models.MealLog, error) {
+	query := `
+		INSERT INTO meal_logs (
+			user_id, meal, food_name, quantity, calories_kcal, protein_g, carbs_g, fat_g, eaten_at, notes
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + mealLogColumns
+
+	return scanMealLog(r.db.QueryRow(
+		ctx,
+		query,
+		userID,
+		input.Meal,
+		input.FoodName,
+		input.Quantity,
+		input.CaloriesKcal,
+		input.ProteinG,
+		input.CarbsG,
+		input.FatG,
+		input.EatenAt,
+		input.Notes,
+	))
+}
+
+func (r *NutritionRepository) GetMealLogByID(ctx context.Context, logID int64) (*models.MealLog, error) {
+	query := `
+		SELECT ` + mealLogColumns + `
+		FROM meal_logs
+		WHERE id = $1
+	`
+	return scanMealLog(r.db.QueryRow(ctx, query, logID))
+}
+
+func (r *NutritionRepository) DeleteMealLog(ctx context.Context, logID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM meal_logs WHERE id = $1`, logID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *NutritionRepository) ListMealLogs(ctx context.Context, filter MealLogFilter) ([]models.MealLog, int, error) {
+	conditions := []string{"user_id = $1"}
+	args := []any{filter.UserID}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("eaten_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("eaten_at < $%d", len(args)))
+	}
+	whereClause := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM meal_logs WHERE "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM meal_logs
+		WHERE %s
+		ORDER BY eaten_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, mealLogColumns, whereClause, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	logs := make([]models.MealLog, 0, filter.Limit)
+	for rows.Next() {
+		log, err := scanMealLog(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		logs = append(logs, *log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// ListDailyTotals sums a client's intake per UTC day in [from, to). Days without logs are omitted.
+func (r *NutritionRepository) ListDailyTotals(
+	ctx context.Context,
+	userID int64,
+	from time.Time,
+	to time.Time,
+) ([]models.NutritionDay, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT DATE_TRUNC('day', eaten_at) AS day,
+			SUM(calories_kcal)::INT,
+			SUM(protein_g)::DOUBLE PRECISION,
+			SUM(carbs_g)::DOUBLE PRECISION,
+			SUM(fat_g)::DOUBLE PRECISION,
+			COUNT(*)
+		FROM meal_logs
+		WHERE user_id = $1 AND eaten_at >= $2 AND eaten_at < $3
+		GROUP BY day
+		ORDER BY day ASC
+	`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make([]models.NutritionDay, 0)
+	for rows.Next() {
+		var day models.NutritionDay
+		if err := rows.Scan(
+			&day.Date,
+			&day.CaloriesKcal,
+			&day.ProteinG,
+			&day.CarbsG,
+			&day.FatG,
+			&day.Entries,
+		); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+func marshalMealTemplates(meals []models.MealTemplate) ([]byte, error) {
+	if meals == nil {
+		meals = []models.MealTemplate{}
+	}
+	return json.Marshal(meals)
+}
+
+func scanNutritionPlan(row pgx.Row) (*models.NutritionPlan, error) {
+	var plan models.NutritionPlan
+	var meals []byte
+	err := row.Scan(
+		&plan.ID,
+		&plan.CoachID,
+		&plan.UserID,
+		&plan.Title,
+		&plan.Notes,
+		&plan.Targets.CaloriesKcal,
+		&plan.Targets.ProteinG,
+		&plan.Targets.CarbsG,
+		&plan.Targets.FatG,
+		&meals,
+		&plan.StartDate,
+		&plan.EndDate,
+		&plan.CreatedAt,
+		&plan.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	plan.Meals = []models.MealTemplate{}
+	if len(meals) > 0 {
+		if err := json.Unmarshal(meals, &plan.Meals); err != nil {
+			return nil, err
+		}
+	}
+	return &plan, nil
+}
+
+func scanMealLog(row pgx.Row) (*models.MealLog, error) {
+	var log models.MealLog
+	err := row.Scan(
+		&log.ID,
+		&log.UserID,
+		&log.Meal,
+		&log.FoodName,
+		&log.Quantity,
+		&log.CaloriesKcal,
+		&log.ProteinG,
+		&log.CarbsG,
+		&log.FatG,
+		&log.EatenAt,
+		&log.Notes,
+		&log.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
@@ -2,16 +2,41 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/saeid-a/CoachAppBack/internal/models"
 )
 
+const paymentColumns = `id, booking_id, subscription_id, user_id, coach_id, amount, original_amount,
+	discount_amount, coupon_id, status, created_at`
+
 type CreatePaymentInput struct {
-	SessionID int64
-	UserID    int64
-	CoachID   int64
-	Amount    float64
-	Status    string
+	SessionID      *int64
+	SubscriptionID *int64
+	UserID         int64
+	CoachID        int64
+	Amount         float64
+	OriginalAmount *float64
+	DiscountAmount float64
+	CouponID       *int64
+	Status         string
+	// BillingPeriodStart marks a subscription's charge for the period starting then.
+	BillingPeriodStart *time.Time
+}
+
+type PaymentListFilter struct {
+	ActorID         int64
+	Role            string
+	Status          string
+	CounterpartID   *int64
+	From            *time.Time
+	To              *time.Time
+	CursorCreatedAt *time.Time
+	CursorID        int64
+	Limit           int
 }
 
 type PaymentRepository struct {
@@ -24,76 +49,69 @@ func NewPaymentRepository(db DBTX) *PaymentRepository {
 
 func (r *PaymentRepository) Create(ctx context.Context, input CreatePaymentInput) (*models.Payment, error) {
 	query := `
-		INSERT INTO payments (booking_id, user_id, coach_id, amount, status)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, booking_id, user_id, coach_id, amount, status, created_at
+		INSERT INTO payments (
+			booking_id, subscription_id, user_id, coach_id, amount,
+			original_amount, discount_amount, coupon_id, status, billing_period_start
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + paymentColumns
+
+	return scanPayment(r.db.QueryRow(
+		ctx,
+		query,
+		input.SessionID,
+		input.SubscriptionID,
+		input.UserID,
+		input.CoachID,
+		input.Amount,
+		input.OriginalAmount,
+		input.DiscountAmount,
+		input.CouponID,
+		input.Status,
+		input.BillingPeriodStart,
+	))
+}
+
+func (r *PaymentRepository) GetByID(ctx context.Context, paymentID int64) (*models.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE id = $1
 	`
+	return scanPayment(r.db.QueryRow(ctx, query, paymentID))
+}
 
-	var payment models.Payment
-	err := r.db.QueryRow(ctx, query, input.SessionID, input.UserID, input.CoachID, input.Amount, input.Status).Scan(
-		&payment.ID,
-		&payment.SessionID,
-		&payment.UserID,
-		&payment.CoachID,
-		&payment.Amount,
-		&payment.Status,
-		&payment.CreatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &payment, nil
+func (r *PaymentRepository) GetByIDForUpdate(ctx context.Context, paymentID int64) (*models.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE id = $1
+		FOR UPDATE
+	`
+	return scanPayment(r.db.QueryRow(ctx, query, paymentID))
 }
 
 func (r *PaymentRepository) GetBySessionID(ctx context.Context, sessionID int64) (*models.Payment, error) {
 	query := `
-		SELECT id, booking_id, user_id, coach_id, amount, status, created_at
+		SELECT ` + paymentColumns + `
 		FROM payments
 		WHERE booking_id = $1
 		ORDER BY id DESC
 		LIMIT 1
 	`
-
-	var payment models.Payment
-	err := r.db.QueryRow(ctx, query, sessionID).Scan(
-		&payment.ID,
-		&payment.SessionID,
-		&payment.UserID,
-		&payment.CoachID,
-		&payment.Amount,
-		&payment.Status,
-		&payment.CreatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &payment, nil
+	return scanPayment(r.db.QueryRow(ctx, query, sessionID))
 }
 
 func (r *PaymentRepository) GetBySessionIDForUpdate(ctx context.Context, sessionID int64) (*models.Payment, error) {
 	query := `
-		SELECT id, booking_id, user_id, coach_id, amount, status, created_at
+		SELECT ` + paymentColumns + `
 		FROM payments
 		WHERE booking_id = $1
 		ORDER BY id DESC
 		LIMIT 1
 		FOR UPDATE
 	`
-
-	var payment models.Payment
-	err := r.db.QueryRow(ctx, query, sessionID).Scan(
-		&payment.ID,
-		&payment.SessionID,
-		&payment.UserID,
-		&payment.CoachID,
-		&payment.Amount,
-		&payment.Status,
-		&payment.CreatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &payment, nil
+	return scanPayment(r.db.QueryRow(ctx, query, sessionID))
 }
 
 func (r *PaymentRepository) ListBySessionIDs(ctx context.Context, sessionIDs []int64) (map[int64]models.Payment, error) {
@@ -103,7 +121,7 @@ func (r *PaymentRepository) ListBySessionIDs(ctx context.Context, sessionIDs []i
 	}
 
 	query := `
-		SELECT DISTINCT ON (booking_id) id, booking_id, user_id, coach_id, amount, status, created_at
+		SELECT DISTINCT ON (booking_id) ` + paymentColumns + `
 		FROM payments
 		WHERE booking_id = ANY($1)
 		ORDER BY booking_id, id DESC
@@ -116,19 +134,13 @@ func (r *PaymentRepository) ListBySessionIDs(ctx context.Context, sessionIDs []i
 	defer rows.Close()
 
 	for rows.Next() {
-		var payment models.Payment
-		if err := rows.Scan(
-			&payment.ID,
-			&payment.SessionID,
-			&payment.UserID,
-			&payment.CoachID,
-			&payment.Amount,
-			&payment.Status,
-			&payment.CreatedAt,
-		); err != nil {
+		payment, err := scanPayment(rows)
+		if err != nil {
 			return nil, err
 		}
-		payments[payment.SessionID] = payment
+		if payment.SessionID != nil {
+			payments[*payment.SessionID] = *payment
+		}
 	}
 
 	if err := rows.Err(); err != nil {
@@ -138,28 +150,160 @@ func (r *PaymentRepository) ListBySessionIDs(ctx context.Context, sessionIDs []i
 	return payments, nil
 }
 
-func (r *PaymentRepository) UpdateStatus(ctx context.Context, paymentID int64, status string) (*models.Payment, error) {
+func (r *PaymentRepository) ListBySubscriptionID(ctx context.Context, subscriptionID int64) ([]models.Payment, error) {
 	query := `
-		UPDATE payments
-		SET status = $2
-		WHERE id = $1
-		RETURNING id, booking_id, user_id, coach_id, amount, status, created_at
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC, id DESC
 	`
 
-	var payment models.Payment
-	err := r.db.QueryRow(ctx, query, paymentID, status).Scan(
-		&payment.ID,
-		&payment.SessionID,
-		&payment.UserID,
-		&payment.CoachID,
-		&payment.Amount,
-		&payment.Status,
-		&payment.CreatedAt,
-	)
+	rows, err := r.db.Query(ctx, query, subscriptionID)
 	if err != nil {
 		return nil, err
 	}
-	return &payment, nil
+	defer rows.Close()
+
+	payments := make([]models.Payment, 0)
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// HasPeriodCharge reports whether the subscription was charged for the period starting at
+// periodStart, whatever happened to the charge afterwards. Covered bookings and proration charges
+// do not count.
+func (r *PaymentRepository) HasPeriodCharge(ctx context.Context, subscriptionID int64, periodStart time.Time) (bool, error) {
+	var charged bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM payments
+			WHERE subscription_id = $1 AND billing_period_start = $2
+		)
+	`, subscriptionID, periodStart).Scan(&charged)
+	return charged, err
+}
+
+// List returns the actor's payments newest first, continuing after the cursor when one is set.
+func (r *PaymentRepository) List(ctx context.Context, filter PaymentListFilter) ([]models.Payment, error) {
+	actorColumn, counterpartColumn := "user_id", "coach_id"
+	if filter.Role == "coach" {
+		actorColumn, counterpartColumn = "coach_id", "user_id"
+	}
+
+	args := []any{filter.ActorID}
+	whereParts := []string{fmt.Sprintf("%s = $1", actorColumn)}
+
+	if status := strings.TrimSpace(filter.Status); status != "" {
+		args = append(args, status)
+		whereParts = append(whereParts, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.CounterpartID != nil {
+		args = append(args, *filter.CounterpartID)
+		whereParts = append(whereParts, fmt.Sprintf("%s = $%d", counterpartColumn, len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		whereParts = append(whereParts, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		whereParts = append(whereParts, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	if filter.CursorCreatedAt != nil {
+		args = append(args, *filter.CursorCreatedAt, filter.CursorID)
+		whereParts = append(whereParts, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	limitClause := ""
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		limitClause = fmt.Sprintf("LIMIT $%d", len(args))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM payments
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		%s
+	`, paymentColumns, strings.Join(whereParts, " AND "), limitClause)
+
+	return r.queryPayments(ctx, query, args...)
+}
+
+func (r *PaymentRepository) ListCreatedBetween(ctx context.Context, from time.Time, to time.Time) ([]models.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY id ASC
+	`
+	return r.queryPayments(ctx, query, from, to)
+}
+
+func (r *PaymentRepository) ListByIDs(ctx context.Context, paymentIDs []int64) (map[int64]models.Payment, error) {
+	payments := make(map[int64]models.Payment, len(paymentIDs))
+	if len(paymentIDs) == 0 {
+		return payments, nil
+	}
+
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE id = ANY($1)
+	`
+	list, err := r.queryPayments(ctx, query, paymentIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, payment := range list {
+		payments[payment.ID] = payment
+	}
+	return payments, nil
+}
+
+func (r *PaymentRepository) queryPayments(ctx context.Context, query string, args ...any) ([]models.Payment, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := make([]models.Payment, 0)
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (r *PaymentRepository) UpdateStatus(ctx context.Context, paymentID int64, status string) (*models.Payment, error) {
+	query := `
+		UPDATE payments
+		SET status = $2
+		WHERE id = $1
+		RETURNING ` + paymentColumns
+
+	return scanPayment(r.db.QueryRow(ctx, query, paymentID, status))
 }
 
 func (r *PaymentRepository) UpdateStatusIfCurrent(ctx context.Context, paymentID int64, currentStatus string, nextStatus string) (*models.Payment, error) {
@@ -167,16 +311,71 @@ func (r *PaymentRepository) UpdateStatusIfCurrent(ctx context.Context, paymentID
 		UPDATE payments
 		SET status = $3
 		WHERE id = $1 AND status = $2
-		RETURNING id, booking_id, user_id, coach_id, amount, status, created_at
-	`
+		RETURNING ` + paymentColumns
+
+	return scanPayment(r.db.QueryRow(ctx, query, paymentID, currentStatus, nextStatus))
+}
+
+func (r *PaymentRepository) ApplyDiscount(
+	ctx context.Context,
+	paymentID int64,
+	couponID int64,
+	discountAmount float64,
+) (*models.Payment, error) {
+	query := `
+		UPDATE payments
+		SET original_amount = amount,
+			amount = amount - $3,
+			discount_amount = $3,
+			coupon_id = $2
+		WHERE id = $1 AND coupon_id IS NULL
+		RETURNING ` + paymentColumns
+
+	return scanPayment(r.db.QueryRow(ctx, query, paymentID, couponID, discountAmount))
+}
+
+func (r *PaymentRepository) RemoveDiscount(ctx context.Context, paymentID int64) (*models.Payment, error) {
+	query := `
+		UPDATE payments
+		SET amount = COALESCE(original_amount, amount),
+			original_amount = NULL,
+			discount_amount = 0,
+			coupon_id = NULL
+		WHERE id = $1
+		RETURNING ` + paymentColumns
+
+	return scanPayment(r.db.QueryRow(ctx, query, paymentID))
+}
+
+func (r *PaymentRepository) RecordGatewayEvent(
+	ctx context.Context,
+	eventID string,
+	eventType string,
+	payload []byte,
+) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO payment_gateway_events (event_id, event_type, payload)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (event_id) DO NOTHING
+	`, eventID, eventType, payload)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
 
+func scanPayment(row pgx.Row) (*models.Payment, error) {
 	var payment models.Payment
-	err := r.db.QueryRow(ctx, query, paymentID, currentStatus, nextStatus).Scan(
+	err := row.Scan(
 		&payment.ID,
 		&payment.SessionID,
+		&payment.SubscriptionID,
 		&payment.UserID,
 		&payment.CoachID,
 		&payment.Amount,
+		&payment.OriginalAmount,
+		&payment.DiscountAmount,
+		&payment.CouponID,
 		&payment.Status,
 		&payment.CreatedAt,
 	)
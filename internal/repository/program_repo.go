@@ -2,17 +2,27 @@ package repository
 
 import (
 	"context"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/saeid-a/CoachAppBack/internal/models"
 )
 
+// Programs without an attachment have a NULL file_url, surfaced as an empty FileURL; programs
+// without a booking surface a zero SessionID.
+const workoutProgramColumns = `id, coach_id, user_id, relationship_id, COALESCE(booking_id, 0), template_id,
+	start_date, title, description, COALESCE(file_url, ''), current_version, created_at, updated_at`
+
 type CreateWorkoutProgramInput struct {
-	CoachID     int64
-	UserID      int64
-	SessionID   int64
-	Title       string
-	Description *string
-	FileURL     string
+	CoachID        int64
+	UserID         int64
+	RelationshipID *int64
+	SessionID      int64
+	TemplateID     *int64
+	StartDate      *time.Time
+	Title          string
+	Description    *string
+	FileURL        string
 }
 
 type WorkoutProgramRepository struct {
@@ -27,42 +37,42 @@ func (r *WorkoutProgramRepository) Create(
 	ctx context.Context,
 	input CreateWorkoutProgramInput,
 ) (*models.WorkoutProgram, error) {
+	// Version 1 is recorded with the program itself; structured programs fill in its phases once
+	// their structure is stored.
 	query := `
-		INSERT INTO workout_programs (coach_id, user_id, booking_id, title, description, file_url)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, coach_id, user_id, booking_id, title, description, file_url, created_at
-	`
+		WITH program AS (
+			INSERT INTO workout_programs (
+				coach_id, user_id, relationship_id, booking_id, template_id, start_date, title,
+				description, file_url, current_version
+			)
+			VALUES ($1, $2, $3, NULLIF($4::BIGINT, 0), $5, $6, $7, $8, NULLIF($9, ''), 1)
+			RETURNING *
+		), version AS (
+			INSERT INTO program_versions (program_id, version_number, title, description, file_url, created_by)
+			SELECT id, 1, title, description, file_url, coach_id
+			FROM program
+		)
+		SELECT ` + workoutProgramColumns + `
+		FROM program`
 
-	var program models.WorkoutProgram
-	err := r.db.QueryRow(
+	return scanWorkoutProgram(r.db.QueryRow(
 		ctx,
 		query,
 		input.CoachID,
 		input.UserID,
+		input.RelationshipID,
 		input.SessionID,
+		input.TemplateID,
+		input.StartDate,
 		input.Title,
 		input.Description,
 		input.FileURL,
-	).Scan(
-		&program.ID,
-		&program.CoachID,
-		&program.UserID,
-		&program.SessionID,
-		&program.Title,
-		&program.Description,
-		&program.FileURL,
-		&program.CreatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return &program, nil
+	))
 }
 
 func (r *WorkoutProgramRepository) ListByCoachID(ctx context.Context, coachID int64) ([]models.WorkoutProgram, error) {
 	query := `
-		SELECT id, coach_id, user_id, booking_id, title, description, file_url, created_at
+		SELECT ` + workoutProgramColumns + `
 		FROM workout_programs
 		WHERE coach_id = $1
 		ORDER BY created_at DESC, id DESC
@@ -72,7 +82,7 @@ func (r *WorkoutProgramRepository) ListByCoachID(ctx context.Context, coachID in
 
 func (r *WorkoutProgramRepository) ListByUserID(ctx context.Context, userID int64) ([]models.WorkoutProgram, error) {
 	query := `
-		SELECT id, coach_id, user_id, booking_id, title, description, file_url, created_at
+		SELECT ` + workoutProgramColumns + `
 		FROM workout_programs
 		WHERE user_id = $1
 		ORDER BY created_at DESC, id DESC
@@ -80,37 +90,290 @@ func (r *WorkoutProgramRepository) ListByUserID(ctx context.Context, userID int6
 	return r.list(ctx, query, userID)
 }
 
+func (r *WorkoutProgramRepository) ListByCoachAndUser(
+	ctx context.Context,
+	coachID int64,
+	userID int64,
+) ([]models.WorkoutProgram, error) {
+	query := `
+		SELECT ` + workoutProgramColumns + `
+		FROM workout_programs
+		WHERE coach_id = $1 AND user_id = $2
+		ORDER BY created_at ASC, id ASC
+	`
+	return r.list(ctx, query, coachID, userID)
+}
+
 func (r *WorkoutProgramRepository) GetByID(ctx context.Context, programID int64) (*models.WorkoutProgram, error) {
 	query := `
-		SELECT id, coach_id, user_id, booking_id, title, description, file_url, created_at
+		SELECT ` + workoutProgramColumns + `
 		FROM workout_programs
 		WHERE id = $1
 	`
+	return scanWorkoutProgram(r.db.QueryRow(ctx, query, programID))
+}
 
-	var program models.WorkoutProgram
-	err := r.db.QueryRow(ctx, query, programID).Scan(
-		&program.ID,
-		&program.CoachID,
-		&program.UserID,
-		&program.SessionID,
-		&program.Title,
-		&program.Description,
-		&program.FileURL,
-		&program.CreatedAt,
-	)
+func (r *WorkoutProgramRepository) GetByIDForUpdate(ctx context.Context, programID int64) (*models.WorkoutProgram, error) {
+	query := `
+		SELECT ` + workoutProgramColumns + `
+		FROM workout_programs
+		WHERE id = $1
+		FOR UPDATE
+	`
+	return scanWorkoutProgram(r.db.QueryRow(ctx, query, programID))
+}
+
+func (r *WorkoutProgramRepository) UpdateDetails(
+	ctx context.Context,
+	programID int64,
+	title string,
+	description *string,
+) (*models.WorkoutProgram, error) {
+	query := `
+		UPDATE workout_programs
+		SET title = $2,
+			description = $3,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + workoutProgramColumns
+
+	return scanWorkoutProgram(r.db.QueryRow(ctx, query, programID, title, description))
+}
+
+func (r *WorkoutProgramRepository) SetFileURL(
+	ctx context.Context,
+	programID int64,
+	fileURL string,
+) (*models.WorkoutProgram, error) {
+	query := `
+		UPDATE workout_programs
+		SET file_url = NULLIF($2, ''),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + workoutProgramColumns
+
+	return scanWorkoutProgram(r.db.QueryRow(ctx, query, programID, fileURL))
+}
+
+func (r *WorkoutProgramRepository) Delete(ctx context.Context, programID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM workout_programs WHERE id = $1`, programID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetStructure loads the program's phases, weeks, days and exercises in prescribed order.
+func (r *WorkoutProgramRepository) GetStructure(ctx context.Context, programID int64) ([]models.ProgramPhase, error) {
+	phases := make([]models.ProgramPhase, 0)
+	phaseIndex := make(map[int64]int)
+	err := r.each(ctx, `
+		SELECT id, name, notes
+		FROM program_phases
+		WHERE program_id = $1
+		ORDER BY position ASC
+	`, programID, func(rows pgx.Rows) error {
+		phase := models.ProgramPhase{Weeks: []models.ProgramWeek{}}
+		if err := rows.Scan(&phase.ID, &phase.Name, &phase.Notes); err != nil {
+			return err
+		}
+		phaseIndex[phase.ID] = len(phases)
+		phases = append(phases, phase)
+		return nil
+	})
+	if err != nil || len(phases) == 0 {
+		return phases, err
+	}
+
+	type weekRef struct{ phase, week int }
+	weekIndex := make(map[int64]weekRef)
+	err = r.each(ctx, `
+		SELECT w.id, w.phase_id, w.week_number, w.notes
+		FROM program_weeks w
+		JOIN program_phases ph ON ph.id = w.phase_id
+		WHERE ph.program_id = $1
+		ORDER BY w.week_number ASC
+	`, programID, func(rows pgx.Rows) error {
+		var phaseID int64
+		week := models.ProgramWeek{Days: []models.ProgramDay{}}
+		if err := rows.Scan(&week.ID, &phaseID, &week.WeekNumber, &week.Notes); err != nil {
+			return err
+		}
+		p := phaseIndex[phaseID]
+		weekIndex[week.ID] = weekRef{phase: p, week: len(phases[p].Weeks)}
+		phases[p].Weeks = append(phases[p].Weeks, week)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &program, nil
+	type dayRef struct{ phase, week, day int }
+	dayIndex := make(map[int64]dayRef)
+	err = r.each(ctx, `
+		SELECT d.id, d.week_id, d.day_number, d.name, d.notes
+		FROM program_days d
+		JOIN program_weeks w ON w.id = d.week_id
+		JOIN program_phases ph ON ph.id = w.phase_id
+		WHERE ph.program_id = $1
+		ORDER BY d.day_number ASC
+	`, programID, func(rows pgx.Rows) error {
+		var weekID int64
+		day := models.ProgramDay{Exercises: []models.ProgramExercise{}}
+		if err := rows.Scan(&day.ID, &weekID, &day.DayNumber, &day.Name, &day.Notes); err != nil {
+			return err
+		}
+		ref := weekIndex[weekID]
+		week := &phases[ref.phase].Weeks[ref.week]
+		dayIndex[day.ID] = dayRef{phase: ref.phase, week: ref.week, day: len(week.Days)}
+		week.Days = append(week.Days, day)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.each(ctx, `
+		SELECT e.id, e.day_id, e.exercise_id, e.name, e.superset_group, e.sets, e.reps, e.weight_kg,
+			e.tempo, e.rest_seconds, e.rpe, e.notes
+		FROM program_exercises e
+		JOIN program_days d ON d.id = e.day_id
+		JOIN program_weeks w ON w.id = d.week_id
+		JOIN program_phases ph ON ph.id = w.phase_id
+		WHERE ph.program_id = $1
+		ORDER BY e.position ASC
+	`, programID, func(rows pgx.Rows) error {
+		var dayID int64
+		var exercise models.ProgramExercise
+		if err := rows.Scan(
+			&exercise.ID,
+			&dayID,
+			&exercise.ExerciseID,
+			&exercise.Name,
+			&exercise.SupersetGroup,
+			&exercise.Sets,
+			&exercise.Reps,
+			&exercise.WeightKg,
+			&exercise.Tempo,
+			&exercise.RestSeconds,
+			&exercise.RPE,
+			&exercise.Notes,
+		); err != nil {
+			return err
+		}
+		ref := dayIndex[dayID]
+		day := &phases[ref.phase].Weeks[ref.week].Days[ref.day]
+		day.Exercises = append(day.Exercises, exercise)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return phases, nil
+}
+
+// ReplaceStructure swaps the whole program tree; callers must run it inside a transaction.
+func (r *WorkoutProgramRepository) ReplaceStructure(
+	ctx context.Context,
+	programID int64,
+	phases []models.ProgramPhase,
+) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM program_phases WHERE program_id = $1`, programID); err != nil {
+		return err
+	}
+
+	for phasePosition, phase := range phases {
+		var phaseID int64
+		if err := r.db.QueryRow(ctx, `
+			INSERT INTO program_phases (program_id, position, name, notes)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id
+		`, programID, phasePosition+1, phase.Name, phase.Notes).Scan(&phaseID); err != nil {
+			return err
+		}
+
+		for _, week := range phase.Weeks {
+			var weekID int64
+			if err := r.db.QueryRow(ctx, `
+				INSERT INTO program_weeks (phase_id, week_number, notes)
+				VALUES ($1, $2, $3)
+				RETURNING id
+			`, phaseID, week.WeekNumber, week.Notes).Scan(&weekID); err != nil {
+				return err
+			}
+
+			for _, day := range week.Days {
+				var dayID int64
+				if err := r.db.QueryRow(ctx, `
+					INSERT INTO program_days (week_id, day_number, name, notes)
+					VALUES ($1, $2, $3, $4)
+					RETURNING id
+				`, weekID, day.DayNumber, day.Name, day.Notes).Scan(&dayID); err != nil {
+					return err
+				}
+
+				for exercisePosition, exercise := range day.Exercises {
+					if _, err := r.db.Exec(ctx, `
+						INSERT INTO program_exercises (
+							day_id, position, exercise_id, name, superset_group, sets, reps,
+							weight_kg, tempo, rest_seconds, rpe, notes
+						)
+						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+					`,
+						dayID,
+						exercisePosition+1,
+						exercise.ExerciseID,
+						exercise.Name,
+						exercise.SupersetGroup,
+						exercise.Sets,
+						exercise.Reps,
+						exercise.WeightKg,
+						exercise.Tempo,
+						exercise.RestSeconds,
+						exercise.RPE,
+						exercise.Notes,
+					); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	_, err := r.db.Exec(ctx, `UPDATE workout_programs SET updated_at = NOW() WHERE id = $1`, programID)
+	return err
+}
+
+func (r *WorkoutProgramRepository) each(
+	ctx context.Context,
+	query string,
+	programID int64,
+	scan func(rows pgx.Rows) error,
+) error {
+	rows, err := r.db.Query(ctx, query, programID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 func (r *WorkoutProgramRepository) list(
 	ctx context.Context,
 	query string,
-	actorID int64,
+	args ...any,
 ) ([]models.WorkoutProgram, error) {
-	rows, err := r.db.Query(ctx, query, actorID)
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -118,20 +381,11 @@ func (r *WorkoutProgramRepository) list(
 
 	programs := make([]models.WorkoutProgram, 0)
 	for rows.Next() {
-		var program models.WorkoutProgram
-		if err := rows.Scan(
-			&program.ID,
-			&program.CoachID,
-			&program.UserID,
-			&program.SessionID,
-			&program.Title,
-			&program.Description,
-			&program.FileURL,
-			&program.CreatedAt,
-		); err != nil {
+		program, err := scanWorkoutProgram(rows)
+		if err != nil {
 			return nil, err
 		}
-		programs = append(programs, program)
+		programs = append(programs, *program)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -140,3 +394,26 @@ func (r *WorkoutProgramRepository) list(
 
 	return programs, nil
 }
+
+func scanWorkoutProgram(row pgx.Row) (*models.WorkoutProgram, error) {
+	var program models.WorkoutProgram
+	err := row.Scan(
+		&program.ID,
+		&program.CoachID,
+		&program.UserID,
+		&program.RelationshipID,
+		&program.SessionID,
+		&program.TemplateID,
+		&program.StartDate,
+		&program.Title,
+		&program.Description,
+		&program.FileURL,
+		&program.Version,
+		&program.CreatedAt,
+		&program.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &program, nil
+}
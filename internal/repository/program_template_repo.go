@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const programTemplateColumns = `id, coach_id, title, description, phases, created_at, updated_at`
+
+type ProgramTemplateInput struct {
+	Title       string
+	Description *string
+	Phases      []models.ProgramPhase
+}
+
+type ProgramTemplateRepository struct {
+	db DBTX
+}
+
+func NewProgramTemplateRepository(db DBTX) *ProgramTemplateRepository {
+	return &ProgramTemplateRepository{db: db}
+}
+
+func (r *ProgramTemplateRepository) Create(
+	ctx context.Context,
+	coachID int64,
+	input ProgramTemplateInput,
+) (*models.ProgramTemplate, error) {
+	phases, err := marshalProgramPhases(input.Phases)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO program_templates (coach_id, title, description, phases)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + programTemplateColumns
+
+	return scanProgramTemplate(r.db.QueryRow(ctx, query, coachID, input.Title, input.Description, phases))
+}
+
+func (r *ProgramTemplateRepository) GetByID(ctx context.Context, templateID int64) (*models.ProgramTemplate, error) {
+	query := `
+		SELECT ` + programTemplateColumns + `
+		FROM program_templates
+		WHERE id = $1
+	`
+	return scanProgramTemplate(r.db.QueryRow(ctx, query, templateID))
+}
+
+// ListByCoachID returns the coach's templates without their structure.
+func (r *ProgramTemplateRepository) ListByCoachID(ctx context.Context, coachID int64) ([]models.ProgramTemplate, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, coach_id, title, description, created_at, updated_at
+		FROM program_templates
+		WHERE coach_id = $1
+		ORDER BY updated_at DESC, id DESC
+	`, coachID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := make([]models.ProgramTemplate, 0)
+	for rows.Next() {
+		var template models.ProgramTemplate
+		if err := rows.Scan(
+			&template.ID,
+			&template.CoachID,
+			&template.Title,
+			&template.Description,
+			&template.CreatedAt,
+			&template.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (r *ProgramTemplateRepository) Update(
+	ctx context.Context,
+	templateID int64,
+	input ProgramTemplateInput,
+) (*models.ProgramTemplate, error) {
+	phases, err := marshalProgramPhases(input.Phases)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE program_templates
+		SET title = $2,
+			description = $3,
+			phases = $4,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + programTemplateColumns
+
+	return scanProgramTemplate(r.db.QueryRow(ctx, query, templateID, input.Title, input.Description, phases))
+}
+
+func (r *ProgramTemplateRepository) Delete(ctx context.Context, templateID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM program_templates WHERE id = $1`, templateID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func marshalProgramPhases(phases []models.ProgramPhase) ([]byte, error) {
+	if phases == nil {
+		phases = []models.ProgramPhase{}
+	}
+	return json.Marshal(phases)
+}
+
+func scanProgramTemplate(row pgx.Row) (*models.ProgramTemplate, error) {
+	var template models.ProgramTemplate
+	var phases []byte
+	err := row.Scan(
+		&template.ID,
+		&template.CoachID,
+		&template.Title,
+		&template.Description,
+		&phases,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	template.Phases = []models.ProgramPhase{}
+	if len(phases) > 0 {
+		if err := json.Unmarshal(phases, &template.Phases); err != nil {
+			return nil, err
+		}
+	}
+	return &template, nil
+}
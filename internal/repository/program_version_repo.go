@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const programVersionColumns = `id, program_id, version_number, title, description, COALESCE(file_url, ''), phases,
+	change_note, created_by, created_at`
+
+type ProgramVersionInput struct {
+	Title       string
+	Description *string
+	FileURL     string
+	Phases      []models.ProgramPhase
+	ChangeNote  *string
+	CreatedBy   int64
+}
+
+type ProgramVersionRepository struct {
+	db DBTX
+}
+
+func NewProgramVersionRepository(db DBTX) *ProgramVersionRepository {
+	return &ProgramVersionRepository{db: db}
+}
+
+// Create stores the next version of the program and advances its current_version.
+func (r *ProgramVersionRepository) Create(
+	ctx context.Context,
+	programID int64,
+	input ProgramVersionInput,
+) (*models.ProgramVersion, error) {
+	phases, err := marshalProgramPhases(input.Phases)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH next AS (
+			UPDATE workout_programs
+			SET current_version = current_version + 1
+			WHERE id = $1
+			RETURNING current_version
+		)
+		INSERT INTO program_versions (
+			program_id, version_number, title, description, file_url, phases, change_note, created_by
+		)
+		SELECT $1, next.current_version, $2, $3, NULLIF($4, ''), $5, $6, $7
+		FROM next
+		RETURNING ` + programVersionColumns
+
+	return scanProgramVersion(r.db.QueryRow(
+		ctx,
+		query,
+		programID,
+		input.Title,
+		input.Description,
+		input.FileURL,
+		phases,
+		input.ChangeNote,
+		input.CreatedBy,
+	))
+}
+
+// SetInitialPhases stores the structure of version 1, which is created together with the program
+// before its structure exists. Callers must run it in the transaction that created the program.
+func (r *ProgramVersionRepository) SetInitialPhases(
+	ctx context.Context,
+	programID int64,
+	phases []models.ProgramPhase,
+) error {
+	encoded, err := marshalProgramPhases(phases)
+	if err != nil {
+		return err
+	}
+
+	tag, err := r.db.Exec(ctx, `
+		UPDATE program_versions
+		SET phases = $2
+		WHERE program_id = $1 AND version_number = 1
+	`, programID, encoded)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *ProgramVersionRepository) GetByNumber(
+	ctx context.Context,
+	programID int64,
+	version int,
+) (*models.ProgramVersion, error) {
+	query := `
+		SELECT ` + programVersionColumns + `
+		FROM program_versions
+		WHERE program_id = $1 AND version_number = $2
+	`
+	return scanProgramVersion(r.db.QueryRow(ctx, query, programID, version))
+}
+
+func (r *ProgramVersionRepository) GetLatest(ctx context.Context, programID int64) (*models.ProgramVersion, error) {
+	query := `
+		SELECT ` + programVersionColumns + `
+		FROM program_versions
+		WHERE program_id = $1
+		ORDER BY version_number DESC
+		LIMIT 1
+	`
+	return scanProgramVersion(r.db.QueryRow(ctx, query, programID))
+}
+
+// GetEffectiveBefore returns the latest version published before the given moment.
+func (r *ProgramVersionRepository) GetEffectiveBefore(
+	ctx context.Context,
+	programID int64,
+	before time.Time,
+) (*models.ProgramVersion, error) {
+	query := `
+		SELECT ` + programVersionColumns + `
+		FROM program_versions
+		WHERE program_id = $1 AND created_at < $2
+		ORDER BY version_number DESC
+		LIMIT 1
+	`
+	return scanProgramVersion(r.db.QueryRow(ctx, query, programID, before))
+}
+
+// ListByProgramID returns the program's versions newest first, without their structure.
+func (r *ProgramVersionRepository) ListByProgramID(ctx context.Context, programID int64) ([]models.ProgramVersion, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, program_id, version_number, title, description, COALESCE(file_url, ''), change_note,
+			created_by, created_at
+		FROM program_versions
+		WHERE program_id = $1
+		ORDER BY version_number DESC
+	`, programID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make([]models.ProgramVersion, 0)
+	for rows.Next() {
+		var version models.ProgramVersion
+		if err := rows.Scan(
+			&version.ID,
+			&version.ProgramID,
+			&version.Version,
+			&version.Title,
+			&version.Description,
+			&version.FileURL,
+			&version.ChangeNote,
+			&version.CreatedBy,
+			&version.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		version.HasAttachment = version.FileURL != ""
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// ListFileURLs returns every distinct attachment referenced by the program or its versions.
+func (r *ProgramVersionRepository) ListFileURLs(ctx context.Context, programID int64) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT file_url FROM program_versions WHERE program_id = $1 AND file_url IS NOT NULL
+		UNION
+		SELECT file_url FROM workout_programs WHERE id = $1 AND file_url IS NOT NULL
+	`, programID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fileURLs := make([]string, 0)
+	for rows.Next() {
+		var fileURL string
+		if err := rows.Scan(&fileURL); err != nil {
+			return nil, err
+		}
+		fileURLs = append(fileURLs, fileURL)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return fileURLs, nil
+}
+
+func scanProgramVersion(row pgx.Row) (*models.ProgramVersion, error) {
+	var version models.ProgramVersion
+	var phases []byte
+	err := row.Scan(
+		&version.ID,
+		&version.ProgramID,
+		&version.Version,
+		&version.Title,
+		&version.Description,
+		&version.FileURL,
+		&phases,
+		&version.ChangeNote,
+		&version.CreatedBy,
+		&version.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	version.HasAttachment = version.FileURL != ""
+	version.Phases = []models.ProgramPhase{}
+	if len(phases) > 0 {
+		if err := json.Unmarshal(phases, &version.Phases); err != nil {
+			return nil, err
+		}
+	}
+	return &version, nil
+}
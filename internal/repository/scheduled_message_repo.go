@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const scheduledMessageColumns = `
+	id, conversation_id, sender_id, content, send_at, kind, status, message_id, error, created_at,
+	processed_at
+`
+
+const coachAutoReplyColumns = `
+	coach_id, enabled, message, timezone, working_days, work_start, work_end, away_from, away_until,
+	updated_at
+`
+
+// CoachAutoReplyInput replaces a coach's auto-reply settings.
+type CoachAutoReplyInput struct {
+	CoachID     int64
+	Enabled     bool
+	Message     string
+	Timezone    string
+	WorkingDays []int
+	WorkStart   string
+	WorkEnd     string
+	AwayFrom    *time.Time
+	AwayUntil   *time.Time
+}
+
+// ScheduledMessageRepository stores messages queued for later, auto-replies among them, and the
+// coaches' auto-reply settings.
+type ScheduledMessageRepository struct {
+	db DBTX
+}
+
+func NewScheduledMessageRepository(db DBTX) *ScheduledMessageRepository {
+	return &ScheduledMessageRepository{db: db}
+}
+
+func scanScheduledMessage(row pgx.Row) (*models.ScheduledMessage, error) {
+	var message models.ScheduledMessage
+	if err := row.Scan(
+		&message.ID,
+		&message.ConversationID,
+		&message.SenderID,
+		&message.Content,
+		&message.SendAt,
+		&message.Kind,
+		&message.Status,
+		&message.MessageID,
+		&message.Error,
+		&message.CreatedAt,
+		&message.ProcessedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func scanCoachAutoReply(row pgx.Row) (*models.CoachAutoReply, error) {
+	var autoReply models.CoachAutoReply
+	if err := row.Scan(
+		&autoReply.CoachID,
+		&autoReply.Enabled,
+		&autoReply.Message,
+		&autoReply.Timezone,
+		&autoReply.WorkingDays,
+		&autoReply.WorkStart,
+		&autoReply.WorkEnd,
+		&autoReply.AwayFrom,
+		&autoReply.AwayUntil,
+		&autoReply.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &autoReply, nil
+}
+
+func (r *ScheduledMessageRepository) Create(
+	ctx context.Context,
+	conversationID int64,
+	senderID int64,
+	content string,
+	sendAt time.Time,
+) (*models.ScheduledMessage, error) {
+	query := `
+		INSERT INTO chat_scheduled_messages (conversation_id, sender_id, content, send_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + scheduledMessageColumns
+	return scanScheduledMessage(r.db.QueryRow(ctx, query, conversationID, senderID, content, sendAt.UTC()))
+}
+
+// QueueAutoReply queues the coach's auto-reply to go out now, unless the conversation already got
+// one in the same away window. It reports whether it queued one.
+func (r *ScheduledMessageRepository) QueueAutoReply(
+	ctx context.Context,
+	conversationID int64,
+	coachID int64,
+	content string,
+	window time.Time,
+) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO chat_scheduled_messages (conversation_id, sender_id, content, send_at, kind, auto_reply_window)
+		VALUES ($1, $2, $3, NOW(), 'auto_reply', $4)
+		ON CONFLICT (conversation_id, auto_reply_window) WHERE kind = 'auto_reply' DO NOTHING
+	`, conversationID, coachID, content, window.UTC())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *ScheduledMessageRepository) GetForSender(
+	ctx context.Context,
+	scheduledID int64,
+	senderID int64,
+) (*models.ScheduledMessage, error) {
+	query := `
+		SELECT ` + scheduledMessageColumns + `
+		FROM chat_scheduled_messages
+		WHERE id = $1 AND sender_id = $2 AND kind = 'scheduled'
+	`
+	return scanScheduledMessage(r.db.QueryRow(ctx, query, scheduledID, senderID))
+}
+
+// ListForSender returns a page of the messages the sender scheduled, soonest first, optionally only
+// those with a status. Auto-replies are not included.
+func (r *ScheduledMessageRepository) ListForSender(
+	ctx context.Context,
+	senderID int64,
+	status string,
+	limit int,
+	offset int,
+) ([]models.ScheduledMessage, int, error) {
+	args := []any{senderID}
+	where := "sender_id = $1 AND kind = 'scheduled'"
+	if status != "" {
+		args = append(args, status)
+		where += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM chat_scheduled_messages WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, limit, offset)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM chat_scheduled_messages
+		WHERE %s
+		ORDER BY send_at, id
+		LIMIT $%d OFFSET $%d
+	`, scheduledMessageColumns, where, len(args)-1, len(args))
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	messages := make([]models.ScheduledMessage, 0, limit)
+	for rows.Next() {
+		message, err := scanScheduledMessage(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		messages = append(messages, *message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
+}
+
+// Cancel cancels a pending scheduled message that is not being sent right now.
+func (r *ScheduledMessageRepository) Cancel(
+	ctx context.Context,
+	scheduledID int64,
+	senderID int64,
+) (*models.ScheduledMessage, error) {
+	query := `
+		UPDATE chat_scheduled_messages
+		SET status = 'cancelled', processed_at = NOW()
+		WHERE id = $1 AND sender_id = $2 AND kind = 'scheduled' AND status = 'pending'
+		  AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+		RETURNING ` + scheduledMessageColumns
+	return scanScheduledMessage(r.db.QueryRow(ctx, query, scheduledID, senderID))
+}
+
+// ClaimDue leases up to limit pending messages whose time has come, oldest first. Messages leased
+// by an instance that stopped are claimed again once the lease runs out.
+func (r *ScheduledMessageRepository) ClaimDue(
+	ctx context.Context,
+	limit int,
+	lease time.Duration,
+) ([]models.ScheduledMessage, error) {
+	query := `
+		UPDATE chat_scheduled_messages
+		SET lease_expires_at = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id
+			FROM chat_scheduled_messages
+			WHERE status = 'pending' AND send_at <= NOW()
+			  AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+			ORDER BY send_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + scheduledMessageColumns
+	rows, err := r.db.Query(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]models.ScheduledMessage, 0, limit)
+	for rows.Next() {
+		message, err := scanScheduledMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// RecordDelivery marks a scheduled message sent, as messageID, or failed with the reason.
+func (r *ScheduledMessageRepository) RecordDelivery(
+	ctx context.Context,
+	scheduledID int64,
+	messageID *int64,
+	failure *string,
+) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE chat_scheduled_messages
+		SET status = CASE WHEN $2::BIGINT IS NOT NULL THEN 'sent' ELSE 'failed' END,
+			message_id = $2,
+			error = $3,
+			lease_expires_at = NULL,
+			processed_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, scheduledID, messageID, failure)
+	return err
+}
+
+func (r *ScheduledMessageRepository) GetAutoReply(ctx context.Context, coachID int64) (*models.CoachAutoReply, error) {
+	query := `SELECT ` + coachAutoReplyColumns + ` FROM coach_auto_replies WHERE coach_id = $1`
+	return scanCoachAutoReply(r.db.QueryRow(ctx, query, coachID))
+}
+
+func (r *ScheduledMessageRepository) UpsertAutoReply(
+	ctx context.Context,
+	input CoachAutoReplyInput,
+) (*models.CoachAutoReply, error) {
+	workingDays := input.WorkingDays
+	if workingDays == nil {
+		workingDays = []int{}
+	}
+	var awayFrom, awayUntil *time.Time
+	if input.AwayFrom != nil && input.AwayUntil != nil {
+		from, until := input.AwayFrom.UTC(), input.AwayUntil.UTC()
+		awayFrom, awayUntil = &from, &until
+	}
+	query := `
+		INSERT INTO coach_auto_replies (
+			coach_id, enabled, message, timezone, working_days, work_start, work_end, away_from, away_until
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (coach_id) DO UPDATE
+		SET enabled = EXCLUDED.enabled,
+			message = EXCLUDED.message,
+			timezone = EXCLUDED.timezone,
+			working_days = EXCLUDED.working_days,
+			work_start = EXCLUDED.work_start,
+			work_end = EXCLUDED.work_end,
+			away_from = EXCLUDED.away_from,
+			away_until = EXCLUDED.away_until,
+			updated_at = NOW()
+		RETURNING ` + coachAutoReplyColumns
+	return scanCoachAutoReply(r.db.QueryRow(
+		ctx,
+		query,
+		input.CoachID,
+		input.Enabled,
+		input.Message,
+		input.Timezone,
+		workingDays,
+		input.WorkStart,
+		input.WorkEnd,
+		awayFrom,
+		awayUntil,
+	))
+}
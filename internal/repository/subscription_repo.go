@@ -0,0 +1,485 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const subscriptionPlanColumns = `
+	id, coach_id, name, description, monthly_price, sessions_per_month, includes_chat,
+	includes_program_updates, trial_days, is_active, created_at, updated_at
+`
+
+const subscriptionColumns = `
+	id, user_id, coach_id, plan_id, status, gateway_subscription_id, current_period_start,
+	current_period_end, cancel_at_period_end, cancelled_at, created_at, updated_at
+`
+
+type CreateSubscriptionPlanInput struct {
+	CoachID                int64
+	Name                   string
+	Description            *string
+	MonthlyPrice           float64
+	SessionsPerMonth       int
+	IncludesChat           bool
+	IncludesProgramUpdates bool
+	TrialDays              int
+}
+
+type UpdateSubscriptionPlanInput struct {
+	Name                   *string
+	Description            *string
+	MonthlyPrice           *float64
+	SessionsPerMonth       *int
+	IncludesChat           *bool
+	IncludesProgramUpdates *bool
+	TrialDays              *int
+	IsActive               *bool
+}
+
+type CreateSubscriptionInput struct {
+	UserID                int64
+	CoachID               int64
+	PlanID                int64
+	Status                string
+	GatewaySubscriptionID *string
+	CurrentPeriodStart    time.Time
+	CurrentPeriodEnd      time.Time
+}
+
+type SubscriptionRepository struct {
+	db DBTX
+}
+
+func NewSubscriptionRepository(db DBTX) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db}
+}
+
+func (r *SubscriptionRepository) CreatePlan(
+	ctx context.Context,
+	input CreateSubscriptionPlanInput,
+) (*models.SubscriptionPlan, error) {
+	query := `
+		INSERT INTO subscription_plans (
+			coach_id, name, description, monthly_price, sessions_per_month,
+			includes_chat, includes_program_updates, trial_days
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + subscriptionPlanColumns
+
+	return scanSubscriptionPlan(r.db.QueryRow(
+		ctx,
+		query,
+		input.CoachID,
+		input.Name,
+		input.Description,
+		input.MonthlyPrice,
+		input.SessionsPerMonth,
+		input.IncludesChat,
+		input.IncludesProgramUpdates,
+		input.TrialDays,
+	))
+}
+
+func (r *SubscriptionRepository) UpdatePlan(
+	ctx context.Context,
+	planID int64,
+	coachID int64,
+	input UpdateSubscriptionPlanInput,
+) (*models.SubscriptionPlan, error) {
+	query := `
+		UPDATE subscription_plans
+		SET name = COALESCE($3, name),
+		    description = COALESCE($4, description),
+		    monthly_price = COALESCE($5, monthly_price),
+		    sessions_per_month = COALESCE($6, sessions_per_month),
+		    includes_chat = COALESCE($7, includes_chat),
+		    includes_program_updates = COALESCE($8, includes_program_updates),
+		    trial_days = COALESCE($9, trial_days),
+		    is_active = COALESCE($10, is_active),
+		    updated_at = NOW()
+		WHERE id = $1 AND coach_id = $2
+		RETURNING ` + subscriptionPlanColumns
+
+	return scanSubscriptionPlan(r.db.QueryRow(
+		ctx,
+		query,
+		planID,
+		coachID,
+		input.Name,
+		input.Description,
+		input.MonthlyPrice,
+		input.SessionsPerMonth,
+		input.IncludesChat,
+		input.IncludesProgramUpdates,
+		input.TrialDays,
+		input.IsActive,
+	))
+}
+
+func (r *SubscriptionRepository) GetPlanByID(ctx context.Context, planID int64) (*models.SubscriptionPlan, error) {
+	query := `
+		SELECT ` + subscriptionPlanColumns + `
+		FROM subscription_plans
+		WHERE id = $1
+	`
+	return scanSubscriptionPlan(r.db.QueryRow(ctx, query, planID))
+}
+
+func (r *SubscriptionRepository) ListPlansByCoachID(
+	ctx context.Context,
+	coachID int64,
+	activeOnly bool,
+) ([]models.SubscriptionPlan, error) {
+	query := `
+		SELECT ` + subscriptionPlanColumns + `
+		FROM subscription_plans
+		WHERE coach_id = $1 AND (is_active OR NOT $2)
+		ORDER BY monthly_price ASC, id ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, coachID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := make([]models.SubscriptionPlan, 0)
+	for rows.Next() {
+		plan, err := scanSubscriptionPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, *plan)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+func (r *SubscriptionRepository) GetSettings(
+	ctx context.Context,
+	coachID int64,
+) (*models.CoachSubscriptionSettings, error) {
+	query := `
+		SELECT coach_id, requires_subscription, updated_at
+		FROM coach_subscription_settings
+		WHERE coach_id = $1
+	`
+
+	var settings models.CoachSubscriptionSettings
+	err := r.db.QueryRow(ctx, query, coachID).Scan(
+		&settings.CoachID,
+		&settings.RequiresSubscription,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.CoachSubscriptionSettings{CoachID: coachID}, nil
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *SubscriptionRepository) UpsertSettings(
+	ctx context.Context,
+	coachID int64,
+	requiresSubscription bool,
+) (*models.CoachSubscriptionSettings, error) {
+	query := `
+		INSERT INTO coach_subscription_settings (coach_id, requires_subscription)
+		VALUES ($1, $2)
+		ON CONFLICT (coach_id)
+		DO UPDATE SET requires_subscription = EXCLUDED.requires_subscription, updated_at = NOW()
+		RETURNING coach_id, requires_subscription, updated_at
+	`
+
+	var settings models.CoachSubscriptionSettings
+	err := r.db.QueryRow(ctx, query, coachID, requiresSubscription).Scan(
+		&settings.CoachID,
+		&settings.RequiresSubscription,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *SubscriptionRepository) Create(
+	ctx context.Context,
+	input CreateSubscriptionInput,
+) (*models.Subscription, error) {
+	query := `
+		INSERT INTO subscriptions (
+			user_id, coach_id, plan_id, status, gateway_subscription_id,
+			current_period_start, current_period_end
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + subscriptionColumns
+
+	return scanSubscription(r.db.QueryRow(
+		ctx,
+		query,
+		input.UserID,
+		input.CoachID,
+		input.PlanID,
+		input.Status,
+		input.GatewaySubscriptionID,
+		input.CurrentPeriodStart,
+		input.CurrentPeriodEnd,
+	))
+}
+
+func (r *SubscriptionRepository) GetByID(ctx context.Context, subscriptionID int64) (*models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE id = $1
+	`
+	return scanSubscription(r.db.QueryRow(ctx, query, subscriptionID))
+}
+
+func (r *SubscriptionRepository) GetByIDForUpdate(ctx context.Context, subscriptionID int64) (*models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE id = $1
+		FOR UPDATE
+	`
+	return scanSubscription(r.db.QueryRow(ctx, query, subscriptionID))
+}
+
+func (r *SubscriptionRepository) GetByGatewayIDForUpdate(
+	ctx context.Context,
+	gatewaySubscriptionID string,
+) (*models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE gateway_subscription_id = $1
+		FOR UPDATE
+	`
+	return scanSubscription(r.db.QueryRow(ctx, query, gatewaySubscriptionID))
+}
+
+func (r *SubscriptionRepository) GetOpenForParticipants(
+	ctx context.Context,
+	userID int64,
+	coachID int64,
+) (*models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE user_id = $1 AND coach_id = $2 AND status <> 'cancelled'
+	`
+	return scanSubscription(r.db.QueryRow(ctx, query, userID, coachID))
+}
+
+func (r *SubscriptionRepository) ListForParticipant(
+	ctx context.Context,
+	actorID int64,
+	role string,
+) ([]models.Subscription, error) {
+	actorColumn := "user_id"
+	if role == "coach" {
+		actorColumn = "coach_id"
+	}
+
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE ` + actorColumn + ` = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, actorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := make([]models.Subscription, 0)
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+func (r *SubscriptionRepository) UpdateStatus(
+	ctx context.Context,
+	subscriptionID int64,
+	status string,
+) (*models.Subscription, error) {
+	query := `
+		UPDATE subscriptions
+		SET status = $2,
+		    cancelled_at = CASE WHEN $2 = 'cancelled' THEN NOW() ELSE NULL END,
+		    updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + subscriptionColumns
+
+	return scanSubscription(r.db.QueryRow(ctx, query, subscriptionID, status))
+}
+
+func (r *SubscriptionRepository) StartPeriod(
+	ctx context.Context,
+	subscriptionID int64,
+	status string,
+	periodStart time.Time,
+	periodEnd time.Time,
+) (*models.Subscription, error) {
+	query := `
+		UPDATE subscriptions
+		SET status = $2, current_period_start = $3, current_period_end = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + subscriptionColumns
+
+	return scanSubscription(r.db.QueryRow(ctx, query, subscriptionID, status, periodStart, periodEnd))
+}
+
+func (r *SubscriptionRepository) UpdatePlanID(
+	ctx context.Context,
+	subscriptionID int64,
+	planID int64,
+) (*models.Subscription, error) {
+	query := `
+		UPDATE subscriptions
+		SET plan_id = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + subscriptionColumns
+
+	return scanSubscription(r.db.QueryRow(ctx, query, subscriptionID, planID))
+}
+
+func (r *SubscriptionRepository) SetCancelAtPeriodEnd(
+	ctx context.Context,
+	subscriptionID int64,
+	cancelAtPeriodEnd bool,
+) (*models.Subscription, error) {
+	query := `
+		UPDATE subscriptions
+		SET cancel_at_period_end = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + subscriptionColumns
+
+	return scanSubscription(r.db.QueryRow(ctx, query, subscriptionID, cancelAtPeriodEnd))
+}
+
+func (r *SubscriptionRepository) CreatePlanChange(
+	ctx context.Context,
+	subscriptionID int64,
+	fromPlanID int64,
+	toPlanID int64,
+	prorationAmount float64,
+) (*models.SubscriptionPlanChange, error) {
+	query := `
+		INSERT INTO subscription_plan_changes (subscription_id, from_plan_id, to_plan_id, proration_amount)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, subscription_id, from_plan_id, to_plan_id, proration_amount, created_at
+	`
+
+	var change models.SubscriptionPlanChange
+	err := r.db.QueryRow(ctx, query, subscriptionID, fromPlanID, toPlanID, prorationAmount).Scan(
+		&change.ID,
+		&change.SubscriptionID,
+		&change.FromPlanID,
+		&change.ToPlanID,
+		&change.ProrationAmount,
+		&change.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// DeletePlanChange removes a plan change the gateway refused.
+func (r *SubscriptionRepository) DeletePlanChange(ctx context.Context, changeID int64) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM subscription_plan_changes WHERE id = $1`, changeID)
+	return err
+}
+
+func (r *SubscriptionRepository) CountCoveredSessions(
+	ctx context.Context,
+	subscriptionID int64,
+	periodStart time.Time,
+	periodEnd time.Time,
+) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM payments p
+		JOIN bookings b ON b.id = p.booking_id
+		WHERE p.subscription_id = $1
+		  AND b.status <> 'cancelled'
+		  AND p.created_at >= $2
+		  AND p.created_at < $3
+	`
+
+	var count int
+	if err := r.db.QueryRow(ctx, query, subscriptionID, periodStart, periodEnd).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func scanSubscriptionPlan(row pgx.Row) (*models.SubscriptionPlan, error) {
+	var plan models.SubscriptionPlan
+	err := row.Scan(
+		&plan.ID,
+		&plan.CoachID,
+		&plan.Name,
+		&plan.Description,
+		&plan.MonthlyPrice,
+		&plan.SessionsPerMonth,
+		&plan.IncludesChat,
+		&plan.IncludesProgramUpdates,
+		&plan.TrialDays,
+		&plan.IsActive,
+		&plan.CreatedAt,
+		&plan.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func scanSubscription(row pgx.Row) (*models.Subscription, error) {
+	var subscription models.Subscription
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.UserID,
+		&subscription.CoachID,
+		&subscription.PlanID,
+		&subscription.Status,
+		&subscription.GatewaySubscriptionID,
+		&subscription.CurrentPeriodStart,
+		&subscription.CurrentPeriodEnd,
+		&subscription.CancelAtPeriodEnd,
+		&subscription.CancelledAt,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
@@ -104,8 +104,18 @@ func (r *UserProfileRepository) UpdateOnboarding(ctx context.Context, userID int
 	return &profile, nil
 }
 
+// UpdatePartial also appends a body measurement when the weight changes so the history is kept.
 func (r *UserProfileRepository) UpdatePartial(ctx context.Context, userID int64, req UpdateUserProfileInput) (*models.UserProfile, error) {
 	query := `
+		WITH previous AS (
+			SELECT weight_kg FROM user_profiles WHERE user_id = $11
+		),
+		recorded AS (
+			INSERT INTO body_measurements (user_id, measured_at, weight_kg)
+			SELECT $11, NOW(), $6::DECIMAL
+			FROM previous
+			WHERE $6::DECIMAL IS NOT NULL AND previous.weight_kg IS DISTINCT FROM $6::DECIMAL
+		)
 		UPDATE user_profiles
 		SET full_name = COALESCE($1, full_name),
 			avatar_url = COALESCE($2, avatar_url),
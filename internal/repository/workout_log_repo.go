@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+const workoutLogColumns = `id, program_id, user_id, coach_id, phase_position, week_number, day_number,
+	performed_at, duration_minutes, session_rpe, notes, created_at`
+
+// ProgressSet is one logged set joined with the muscle groups of its library exercise.
+type ProgressSet struct {
+	PerformedAt  time.Time
+	ExerciseID   *int64
+	ExerciseName string
+	MuscleGroups []string
+	Reps         int
+	WeightKg     *float64
+}
+
+type ProgressFilter struct {
+	UserID  int64
+	CoachID *int64
+	From    time.Time
+	To      time.Time
+}
+
+type WorkoutLogRepository struct {
+	db DBTX
+}
+
+func NewWorkoutLogRepository(db DBTX) *WorkoutLogRepository {
+	return &WorkoutLogRepository{db: db}
+}
+
+// Create inserts the log and its sets; callers must run it inside a transaction.
+func (r *WorkoutLogRepository) Create(ctx context.Context, log models.WorkoutLog) (*models.WorkoutLog, error) {
+	query := `
+		INSERT INTO workout_logs (
+			program_id, user_id, coach_id, phase_position, week_number, day_number,
+			performed_at, duration_minutes, session_rpe, notes
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + workoutLogColumns
+
+	created, err := scanWorkoutLog(r.db.QueryRow(
+		ctx,
+		query,
+		log.ProgramID,
+		log.UserID,
+		log.CoachID,
+		log.PhasePosition,
+		log.WeekNumber,
+		log.DayNumber,
+		log.PerformedAt,
+		log.DurationMinutes,
+		log.SessionRPE,
+		log.Notes,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	created.Sets = make([]models.WorkoutLogSet, 0, len(log.Sets))
+	for position, set := range log.Sets {
+		if err := r.db.QueryRow(ctx, `
+			INSERT INTO workout_log_sets (
+				log_id, position, exercise_id, exercise_name, set_number, reps, weight_kg, rpe, notes
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id
+		`,
+			created.ID,
+			position+1,
+			set.ExerciseID,
+			set.ExerciseName,
+			set.SetNumber,
+			set.Reps,
+			set.WeightKg,
+			set.RPE,
+			set.Notes,
+		).Scan(&set.ID); err != nil {
+			return nil, err
+		}
+		created.Sets = append(created.Sets, set)
+	}
+
+	return created, nil
+}
+
+func (r *WorkoutLogRepository) GetByID(ctx context.Context, logID int64) (*models.WorkoutLog, error) {
+	query := `
+		SELECT ` + workoutLogColumns + `
+		FROM workout_logs
+		WHERE id = $1
+	`
+	return scanWorkoutLog(r.db.QueryRow(ctx, query, logID))
+}
+
+func (r *WorkoutLogRepository) ListByProgramID(ctx context.Context, programID int64, limit int) ([]models.WorkoutLog, error) {
+	query := `
+		SELECT ` + workoutLogColumns + `
+		FROM workout_logs
+		WHERE program_id = $1
+		ORDER BY performed_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, programID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := make([]models.WorkoutLog, 0)
+	logIndex := make(map[int64]int)
+	logIDs := make([]int64, 0)
+	for rows.Next() {
+		log, err := scanWorkoutLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		log.Sets = []models.WorkoutLogSet{}
+		logIndex[log.ID] = len(logs)
+		logIDs = append(logIDs, log.ID)
+		logs = append(logs, *log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
+		return logs, nil
+	}
+
+	setRows, err := r.db.Query(ctx, `
+		SELECT log_id, id, exercise_id, exercise_name, set_number, reps, weight_kg, rpe, notes
+		FROM workout_log_sets
+		WHERE log_id = ANY($1)
+		ORDER BY log_id, position
+	`, logIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer setRows.Close()
+
+	for setRows.Next() {
+		var logID int64
+		var set models.WorkoutLogSet
+		if err := setRows.Scan(
+			&logID,
+			&set.ID,
+			&set.ExerciseID,
+			&set.ExerciseName,
+			&set.SetNumber,
+			&set.Reps,
+			&set.WeightKg,
+			&set.RPE,
+			&set.Notes,
+		); err != nil {
+			return nil, err
+		}
+		log := &logs[logIndex[logID]]
+		log.Sets = append(log.Sets, set)
+	}
+	if err := setRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+func (r *WorkoutLogRepository) Delete(ctx context.Context, logID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM workout_logs WHERE id = $1`, logID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *WorkoutLogRepository) ListProgressSets(ctx context.Context, filter ProgressFilter) ([]ProgressSet, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT l.performed_at, s.exercise_id, s.exercise_name, COALESCE(e.muscle_groups, '{}'),
+			s.reps, s.weight_kg
+		FROM workout_log_sets s
+		JOIN workout_logs l ON l.id = s.log_id
+		LEFT JOIN exercises e ON e.id = s.exercise_id
+		WHERE l.user_id = $1
+			AND ($2::BIGINT IS NULL OR l.coach_id = $2)
+			AND l.performed_at >= $3
+			AND l.performed_at < $4
+		ORDER BY l.performed_at ASC, s.log_id ASC, s.position ASC
+	`, filter.UserID, filter.CoachID, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sets := make([]ProgressSet, 0)
+	for rows.Next() {
+		var set ProgressSet
+		if err := rows.Scan(
+			&set.PerformedAt,
+			&set.ExerciseID,
+			&set.ExerciseName,
+			&set.MuscleGroups,
+			&set.Reps,
+			&set.WeightKg,
+		); err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sets, nil
+}
+
+// ListAdherence counts, per program, the planned days in its current structure and how many of
+// them have at least one log.
+func (r *WorkoutLogRepository) ListAdherence(ctx context.Context, userID int64, coachID *int64) ([]models.ProgramAdherence, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH planned AS (
+			SELECT ph.program_id, ph.position, w.week_number, d.day_number
+			FROM program_days d
+			JOIN program_weeks w ON w.id = d.week_id
+			JOIN program_phases ph ON ph.id = w.phase_id
+		)
+		SELECT p.id, p.title,
+			(SELECT COUNT(*) FROM planned WHERE planned.program_id = p.id)::INT,
+			(
+				SELECT COUNT(*)
+				FROM planned
+				WHERE planned.program_id = p.id
+					AND EXISTS (
+						SELECT 1
+						FROM workout_logs l
+						WHERE l.program_id = p.id
+							AND l.phase_position = planned.position
+							AND l.week_number = planned.week_number
+							AND l.day_number = planned.day_number
+					)
+			)::INT,
+			(SELECT MAX(l.performed_at) FROM workout_logs l WHERE l.program_id = p.id)
+		FROM workout_programs p
+		WHERE p.user_id = $1
+			AND ($2::BIGINT IS NULL OR p.coach_id = $2)
+		ORDER BY p.created_at DESC, p.id DESC
+	`, userID, coachID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adherence := make([]models.ProgramAdherence, 0)
+	for rows.Next() {
+		var item models.ProgramAdherence
+		if err := rows.Scan(
+			&item.ProgramID,
+			&item.Title,
+			&item.PlannedDays,
+			&item.CompletedDays,
+			&item.LastLoggedAt,
+		); err != nil {
+			return nil, err
+		}
+		adherence = append(adherence, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return adherence, nil
+}
+
+func scanWorkoutLog(row pgx.Row) (*models.WorkoutLog, error) {
+	var log models.WorkoutLog
+	err := row.Scan(
+		&log.ID,
+		&log.ProgramID,
+		&log.UserID,
+		&log.CoachID,
+		&log.PhasePosition,
+		&log.WeekNumber,
+		&log.DayNumber,
+		&log.PerformedAt,
+		&log.DurationMinutes,
+		&log.SessionRPE,
+		&log.Notes,
+		&log.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
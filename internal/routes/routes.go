@@ -35,6 +35,20 @@ func RegisterRoutes(app *fiber.App, cfg *config.Config, db *pgxpool.Pool) error
 	programRepo := repository.NewWorkoutProgramRepository(db)
 	conversationRepo := repository.NewConversationRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
+	subscriptionRepo := repository.NewSubscriptionRepository(db)
+	invoiceRepo := repository.NewInvoiceRepository(db)
+	couponRepo := repository.NewCouponRepository(db)
+	disputeRepo := repository.NewDisputeRepository(db)
+	exerciseRepo := repository.NewExerciseRepository(db)
+	workoutLogRepo := repository.NewWorkoutLogRepository(db)
+	bodyMetricRepo := repository.NewBodyMetricRepository(db)
+	coachingRepo := repository.NewCoachingRepository(db)
+	programTemplateRepo := repository.NewProgramTemplateRepository(db)
+	programVersionRepo := repository.NewProgramVersionRepository(db)
+	nutritionRepo := repository.NewNutritionRepository(db)
+	checkInRepo := repository.NewCheckInRepository(db)
+	activityRepo := repository.NewActivityRepository(db)
+	moderationRepo := repository.NewModerationRepository(db)
 	var storageService services.StorageService
 	if cfg.SupabaseURL != "" && cfg.SupabaseBucket != "" && cfg.SupabaseServiceKey != "" {
 		storageService = services.NewSupabaseStorageService(
@@ -68,26 +82,132 @@ func RegisterRoutes(app *fiber.App, cfg *config.Config, db *pgxpool.Pool) error
 		userProfileRepo,
 		matchmakingService,
 	)
+	invoiceService := services.NewInvoiceService(db, invoiceRepo, paymentRepo, storageService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
 	sessionService := services.NewSessionService(
 		db,
 		sessionRepo,
 		paymentRepo,
 		userRepo,
 		coachProfileRepo,
+		invoiceService,
 	)
 	sessionHandler := handlers.NewSessionHandler(sessionService)
+	couponService := services.NewCouponService(couponRepo)
+	couponHandler := handlers.NewCouponHandler(couponService)
+	exerciseService := services.NewExerciseService(db, exerciseRepo, storageService)
+	exerciseHandler := handlers.NewExerciseHandler(exerciseService)
+	workoutLogService := services.NewWorkoutLogService(db, workoutLogRepo, programRepo, coachingRepo)
+	workoutLogHandler := handlers.NewWorkoutLogHandler(workoutLogService)
+	bodyMetricService := services.NewBodyMetricService(db, bodyMetricRepo, coachingRepo, storageService)
+	bodyMetricHandler := handlers.NewBodyMetricHandler(bodyMetricService)
+	nutritionService := services.NewNutritionService(nutritionRepo, userRepo, coachingRepo)
+	nutritionHandler := handlers.NewNutritionHandler(nutritionService)
+	checkInService := services.NewCheckInService(db, checkInRepo, userRepo, coachingRepo, storageService)
+	checkInHandler := handlers.NewCheckInHandler(checkInService)
+	activityService := services.NewActivityService(db, activityRepo, userProfileRepo, coachingRepo)
+	activityHandler := handlers.NewActivityHandler(activityService)
+	coachingService := services.NewCoachingService(coachingRepo, userRepo)
+	coachingHandler := handlers.NewCoachingHandler(coachingService)
+	programVersionService := services.NewProgramVersionService(programRepo, programVersionRepo, coachingRepo, storageService)
+	programVersionHandler := handlers.NewProgramVersionHandler(programVersionService)
+	programTemplateService := services.NewProgramTemplateService(
+		db,
+		programTemplateRepo,
+		programRepo,
+		sessionRepo,
+		userRepo,
+		coachingRepo,
+	)
+	programTemplateHandler := handlers.NewProgramTemplateHandler(programTemplateService)
+	var chatBroker chatws.Broker = chatws.NewMemoryBroker()
+	if cfg.ChatBroker == "postgres" {
+		postgresBroker := chatws.NewPostgresBroker(db)
+		go postgresBroker.Listen(context.Background())
+		chatBroker = postgresBroker
+	}
+	chatHub := chatws.NewHub(chatBroker, repository.NewChatEventRepository(db))
+	go chatHub.Run()
+	chatModerator, err := newChatModerator(cfg)
+	if err != nil {
+		return err
+	}
+	chatService := services.NewChatService(
+		db,
+		conversationRepo,
+		messageRepo,
+		subscriptionRepo,
+		coachingRepo,
+		userRepo,
+		storageService,
+		moderationRepo,
+		chatModerator,
+	)
+	chatHandler := handlers.NewChatHandler(chatService, chatHub, cfg.JWTSecret)
 	programService := services.NewProgramService(
 		db,
 		programRepo,
 		sessionRepo,
+		coachingRepo,
 		userRepo,
 		storageService,
+		conversationRepo,
+		chatService,
+		chatHub,
 	)
 	programHandler := handlers.NewProgramHandler(programService)
-	chatHub := chatws.NewHub()
-	go chatHub.Run()
-	chatService := services.NewChatService(db, conversationRepo, messageRepo, userRepo)
-	chatHandler := handlers.NewChatHandler(chatService, chatHub, cfg.JWTSecret)
+	broadcastService := services.NewBroadcastService(
+		repository.NewBroadcastRepository(db),
+		conversationRepo,
+		coachingRepo,
+		chatService,
+		chatHub,
+		chatModerator,
+	)
+	go broadcastService.Run(context.Background())
+	broadcastHandler := handlers.NewBroadcastHandler(broadcastService)
+	scheduledMessageService := services.NewScheduledMessageService(
+		repository.NewScheduledMessageRepository(db),
+		conversationRepo,
+		chatService,
+		chatHub,
+		chatModerator,
+	)
+	go scheduledMessageService.Run(context.Background())
+	scheduledMessageHandler := handlers.NewScheduledMessageHandler(scheduledMessageService)
+	moderationService := services.NewModerationService(db, moderationRepo)
+	moderationHandler := handlers.NewModerationHandler(moderationService, chatHub)
+	paymentGateway := services.NewPlaceholderPaymentGateway()
+	subscriptionService := services.NewSubscriptionService(
+		db,
+		subscriptionRepo,
+		paymentGateway,
+		invoiceService,
+	)
+	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionService)
+	disputeService := services.NewDisputeService(
+		db,
+		disputeRepo,
+		paymentRepo,
+		sessionRepo,
+		messageRepo,
+		programRepo,
+		paymentGateway,
+		invoiceService,
+	)
+	disputeHandler := handlers.NewDisputeHandler(disputeService)
+	paymentService := services.NewPaymentService(
+		db,
+		paymentRepo,
+		subscriptionService,
+		disputeService,
+		invoiceService,
+	)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	paymentWebhookHandler := handlers.NewPaymentWebhookHandler(
+		paymentService,
+		cfg.PaymentWebhookSecret,
+	)
 
 	api := app.Group("/api")
 
@@ -96,6 +216,8 @@ func RegisterRoutes(app *fiber.App, cfg *config.Config, db *pgxpool.Pool) error
 	auth.Post("/login", authHandler.Login)
 	auth.Get("/me", middleware.AuthRequired(cfg.JWTSecret), authHandler.Me)
 
+	api.Post("/webhooks/payments", paymentWebhookHandler.HandleEvent)
+
 	authProtected := api.Group("/v1", middleware.AuthRequired(cfg.JWTSecret))
 
 	users := authProtected.Group("/users")
@@ -111,6 +233,10 @@ func RegisterRoutes(app *fiber.App, cfg *config.Config, db *pgxpool.Pool) error
 	coaches.Put("/profile", profileHandler.UpdateCoachProfile)
 	coaches.Post("/profile/avatar", profileHandler.UploadCoachAvatar)
 	coaches.Get("/recommended", coachDiscoveryHandler.GetRecommendedCoaches)
+	coaches.Get("/subscription-settings", subscriptionHandler.GetSettings)
+	coaches.Put("/subscription-settings", subscriptionHandler.UpdateSettings)
+	coaches.Get("/billing-details", invoiceHandler.GetBillingDetails)
+	coaches.Put("/billing-details", invoiceHandler.UpdateBillingDetails)
 	coaches.Get("/:id", coachDiscoveryHandler.GetCoachDetail)
 
 	sessions := authProtected.Group("/sessions")
@@ -120,16 +246,164 @@ func RegisterRoutes(app *fiber.App, cfg *config.Config, db *pgxpool.Pool) error
 	sessions.Put("/:id/status", sessionHandler.UpdateStatus)
 	sessions.Post("/:id/pay", sessionHandler.PayForSession)
 
+	coupons := authProtected.Group("/coupons")
+	coupons.Post("", couponHandler.CreateCoupon)
+	coupons.Get("", couponHandler.ListCoupons)
+	coupons.Put("/:id", couponHandler.UpdateCoupon)
+
+	payments := authProtected.Group("/payments")
+	payments.Get("", paymentHandler.ListPayments)
+	payments.Get("/export", paymentHandler.ExportPayments)
+	payments.Get("/:id/invoice", invoiceHandler.GetInvoice)
+
+	disputes := authProtected.Group("/disputes")
+	disputes.Get("", disputeHandler.ListDisputes)
+	disputes.Get("/:id", disputeHandler.GetDispute)
+	disputes.Post("/:id/evidence", disputeHandler.SubmitEvidence)
+
+	subscriptionPlans := authProtected.Group("/subscription-plans")
+	subscriptionPlans.Post("", subscriptionHandler.CreatePlan)
+	subscriptionPlans.Get("", subscriptionHandler.ListPlans)
+	subscriptionPlans.Put("/:id", subscriptionHandler.UpdatePlan)
+
+	subscriptions := authProtected.Group("/subscriptions")
+	subscriptions.Post("", subscriptionHandler.Subscribe)
+	subscriptions.Get("", subscriptionHandler.ListSubscriptions)
+	subscriptions.Get("/:id", subscriptionHandler.GetSubscription)
+	subscriptions.Put("/:id/plan", subscriptionHandler.ChangePlan)
+	subscriptions.Post("/:id/cancel", subscriptionHandler.Cancel)
+
 	programs := authProtected.Group("/programs")
 	programs.Post("", programHandler.CreateProgram)
 	programs.Get("", programHandler.ListPrograms)
 	programs.Get("/:id", programHandler.GetProgram)
+	programs.Put("/:id", programHandler.UpdateProgram)
+	programs.Delete("/:id", programHandler.DeleteProgram)
+	programs.Post("/:id/attachment", programHandler.UploadAttachment)
 	programs.Get("/:id/download", programHandler.DownloadProgram)
+	programs.Get("/:id/versions", programVersionHandler.ListVersions)
+	programs.Get("/:id/versions/diff", programVersionHandler.DiffVersions)
+	programs.Get("/:id/versions/on", programVersionHandler.GetVersionOn)
+	programs.Get("/:id/versions/:version", programVersionHandler.GetVersion)
+	programs.Get("/:id/versions/:version/download", programVersionHandler.DownloadVersion)
+	programs.Post("/:id/logs", workoutLogHandler.CreateLog)
+	programs.Get("/:id/logs", workoutLogHandler.ListLogs)
+
+	programTemplates := authProtected.Group("/program-templates")
+	programTemplates.Post("", programTemplateHandler.CreateTemplate)
+	programTemplates.Get("", programTemplateHandler.ListTemplates)
+	programTemplates.Get("/:id", programTemplateHandler.GetTemplate)
+	programTemplates.Put("/:id", programTemplateHandler.UpdateTemplate)
+	programTemplates.Delete("/:id", programTemplateHandler.DeleteTemplate)
+	programTemplates.Post("/:id/assign", programTemplateHandler.AssignTemplate)
+
+	workoutLogs := authProtected.Group("/workout-logs")
+	workoutLogs.Delete("/:id", workoutLogHandler.DeleteLog)
+
+	progress := authProtected.Group("/progress")
+	progress.Get("", workoutLogHandler.GetProgress)
+
+	bodyMetrics := authProtected.Group("/body-metrics")
+	bodyMetrics.Post("", bodyMetricHandler.CreateMeasurement)
+	bodyMetrics.Get("", bodyMetricHandler.ListMeasurements)
+	bodyMetrics.Get("/trends", bodyMetricHandler.GetTrend)
+	bodyMetrics.Post("/photos", bodyMetricHandler.UploadPhoto)
+	bodyMetrics.Get("/photos", bodyMetricHandler.ListPhotos)
+	bodyMetrics.Put("/photos/:id", bodyMetricHandler.UpdatePhoto)
+	bodyMetrics.Delete("/photos/:id", bodyMetricHandler.DeletePhoto)
+	bodyMetrics.Put("/:id", bodyMetricHandler.UpdateMeasurement)
+	bodyMetrics.Delete("/:id", bodyMetricHandler.DeleteMeasurement)
+
+	nutrition := authProtected.Group("/nutrition")
+	nutrition.Post("/plans", nutritionHandler.CreatePlan)
+	nutrition.Get("/plans", nutritionHandler.ListPlans)
+	nutrition.Get("/plans/:id", nutritionHandler.GetPlan)
+	nutrition.Put("/plans/:id", nutritionHandler.UpdatePlan)
+	nutrition.Delete("/plans/:id", nutritionHandler.DeletePlan)
+	nutrition.Get("/plans/:id/adherence", nutritionHandler.GetAdherence)
+	nutrition.Post("/logs", nutritionHandler.LogMeal)
+	nutrition.Get("/logs", nutritionHandler.ListMealLogs)
+	nutrition.Delete("/logs/:id", nutritionHandler.DeleteMealLog)
+
+	checkIns := authProtected.Group("/check-ins")
+	checkIns.Get("/dashboard", checkInHandler.GetDashboard)
+	checkIns.Post("/forms", checkInHandler.CreateForm)
+	checkIns.Get("/forms", checkInHandler.ListForms)
+	checkIns.Get("/forms/:id", checkInHandler.GetForm)
+	checkIns.Put("/forms/:id", checkInHandler.UpdateForm)
+	checkIns.Delete("/forms/:id", checkInHandler.DeleteForm)
+	checkIns.Post("/forms/:id/assign", checkInHandler.AssignForm)
+	checkIns.Get("/schedules", checkInHandler.ListSchedules)
+	checkIns.Put("/schedules/:id", checkInHandler.UpdateSchedule)
+	checkIns.Delete("/schedules/:id", checkInHandler.DeleteSchedule)
+	checkIns.Post("/schedules/:id/submissions", checkInHandler.Submit)
+	checkIns.Get("/submissions", checkInHandler.ListSubmissions)
+	checkIns.Get("/submissions/:id", checkInHandler.GetSubmission)
+
+	activities := authProtected.Group("/activities")
+	activities.Post("/imports", activityHandler.ImportFile)
+	activities.Get("/imports", activityHandler.ListImports)
+	activities.Get("", activityHandler.ListActivities)
+	activities.Get("/:id", activityHandler.GetActivity)
+	activities.Delete("/:id", activityHandler.DeleteActivity)
+
+	relationships := authProtected.Group("/relationships")
+	relationships.Post("", coachingHandler.StartRelationship)
+	relationships.Get("", coachingHandler.ListRelationships)
+	relationships.Get("/:id", coachingHandler.GetRelationship)
+	relationships.Put("/:id", coachingHandler.UpdateRelationship)
+
+	exercises := authProtected.Group("/exercises")
+	exercises.Get("", exerciseHandler.ListExercises)
+	exercises.Post("", exerciseHandler.CreateExercise)
+	exercises.Get("/:id", exerciseHandler.GetExercise)
+	exercises.Put("/:id", exerciseHandler.UpdateExercise)
+	exercises.Delete("/:id", exerciseHandler.DeleteExercise)
+	exercises.Post("/:id/media", exerciseHandler.UploadMedia)
 
 	conversations := authProtected.Group("/conversations")
 	conversations.Get("", chatHandler.ListConversations)
 	conversations.Post("", chatHandler.CreateConversation)
+	conversations.Post("/groups", chatHandler.CreateGroup)
+	conversations.Get("/search", chatHandler.SearchMessages)
 	conversations.Get("/:id/messages", chatHandler.GetMessages)
+	conversations.Patch("/:id/messages/:messageId", chatHandler.EditMessage)
+	conversations.Delete("/:id/messages/:messageId", chatHandler.DeleteMessage)
+	conversations.Get("/:id/messages/:messageId/edits", chatHandler.ListMessageEdits)
+	conversations.Put("/:id/messages/:messageId/reaction", chatHandler.SetReaction)
+	conversations.Delete("/:id/messages/:messageId/reaction", chatHandler.RemoveReaction)
+	conversations.Post("/:id/attachments", chatHandler.SendAttachment)
+	conversations.Get("/:id/attachments/:attachmentId", chatHandler.GetAttachment)
+	conversations.Get("/:id/members", chatHandler.ListGroupMembers)
+	conversations.Post("/:id/members", chatHandler.AddGroupMembers)
+	conversations.Patch("/:id/members/:userId", chatHandler.UpdateGroupMember)
+	conversations.Delete("/:id/members/:userId", chatHandler.RemoveGroupMember)
+	conversations.Post("/:id/reports", chatHandler.ReportConversation)
+	conversations.Post("/:id/scheduled-messages", scheduledMessageHandler.ScheduleMessage)
+
+	scheduledMessages := authProtected.Group("/scheduled-messages")
+	scheduledMessages.Get("", scheduledMessageHandler.ListScheduledMessages)
+	scheduledMessages.Delete("/:id", scheduledMessageHandler.CancelScheduledMessage)
+
+	autoReply := authProtected.Group("/auto-reply")
+	autoReply.Get("", scheduledMessageHandler.GetAutoReply)
+	autoReply.Put("", scheduledMessageHandler.UpdateAutoReply)
+
+	blocks := authProtected.Group("/blocks")
+	blocks.Get("", chatHandler.ListBlockedUsers)
+	blocks.Put("/:userId", chatHandler.BlockUser)
+	blocks.Delete("/:userId", chatHandler.UnblockUser)
+
+	broadcasts := authProtected.Group("/broadcasts")
+	broadcasts.Post("", broadcastHandler.CreateBroadcast)
+	broadcasts.Get("", broadcastHandler.ListBroadcasts)
+	broadcasts.Get("/:id", broadcastHandler.GetBroadcast)
+	broadcasts.Get("/:id/recipients", broadcastHandler.ListRecipients)
+
+	moderation := authProtected.Group("/moderation")
+	moderation.Get("/items", moderationHandler.ListQueue)
+	moderation.Get("/items/:id", moderationHandler.GetItem)
+	moderation.Put("/items/:id", moderationHandler.ReviewItem)
 
 	api.Use("/v1/ws", chatHandler.WebSocketAuth)
 	api.Get("/v1/ws", websocket.New(chatHandler.HandleWebSocket))
@@ -137,6 +411,23 @@ func RegisterRoutes(app *fiber.App, cfg *config.Config, db *pgxpool.Pool) error
 	return nil
 }
 
+// newChatModerator builds the chat moderation pipeline: contact details detection, unless turned
+// off, and the rules in the configured file.
+func newChatModerator(cfg *config.Config) (*services.ModerationPipeline, error) {
+	var rules []services.ModerationRule
+	if cfg.ModerationContactAction != "off" {
+		rules = append(rules, services.ContactInfoRules(cfg.ModerationContactAction)...)
+	}
+	if cfg.ModerationRulesFile != "" {
+		fileRules, err := services.LoadModerationRules(cfg.ModerationRulesFile)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, fileRules...)
+	}
+	return services.NewModerationPipeline(rules...), nil
+}
+
 func ensureDefaultUsers(
 	cfg *config.Config,
 	db *pgxpool.Pool,
@@ -178,6 +469,17 @@ func ensureDefaultUsers(
 	); err != nil {
 		return err
 	}
+	if err := ensureDefaultAccount(
+		db,
+		userRepo,
+		userProfileRepo,
+		coachProfileRepo,
+		strings.TrimSpace(cfg.DefaultAdminEmail),
+		cfg.DefaultAdminPassword,
+		"admin",
+	); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -199,8 +501,8 @@ func ensureDefaultAccount(
 		return err
 	}
 	email = strings.ToLower(parsedEmail.Address)
-	if role != "user" && role != "coach" {
-		return fmt.Errorf("role must be user or coach")
+	if role != "user" && role != "coach" && role != "admin" {
+		return fmt.Errorf("role must be user, coach, or admin")
 	}
 
 	ctx := context.Background()
@@ -242,11 +544,13 @@ func ensureDefaultAccount(
 		return err
 	}
 
-	if role == "user" {
+	// Admins have no profile.
+	switch role {
+	case "user":
 		if err := txUserProfileRepo.CreateEmpty(ctx, user.ID); err != nil {
 			return err
 		}
-	} else {
+	case "coach":
 		if err := txCoachProfileRepo.CreateEmpty(ctx, user.ID); err != nil {
 			return err
 		}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/pkg/activity"
+)
+
+// defaultMaxHeartRate is used for heart-rate zones when the profile has no age.
+const defaultMaxHeartRate = 190
+
+type ActivityUpload struct {
+	File     multipart.File
+	Filename string
+	Size     int64
+}
+
+type ActivityService struct {
+	db           *pgxpool.Pool
+	activityRepo *repository.ActivityRepository
+	profileRepo  *repository.UserProfileRepository
+	coachingRepo *repository.CoachingRepository
+}
+
+func NewActivityService(
+	db *pgxpool.Pool,
+	activityRepo *repository.ActivityRepository,
+	profileRepo *repository.UserProfileRepository,
+	coachingRepo *repository.CoachingRepository,
+) *ActivityService {
+	return &ActivityService{
+		db:           db,
+		activityRepo: activityRepo,
+		profileRepo:  profileRepo,
+		coachingRepo: coachingRepo,
+	}
+}
+
+// ImportFile parses an uploaded activity file or export archive and stores its activities.
+// Uploading the same file twice is a conflict; activities already recorded from another file or
+// device are counted as duplicates and skipped.
+func (s *ActivityService) ImportFile(
+	ctx context.Context,
+	userID int64,
+	upload ActivityUpload,
+) (*models.ActivityImport, error) {
+	if upload.File == nil || upload.Size <= 0 {
+		return nil, ErrInvalidInput
+	}
+	filename := filepath.Base(strings.TrimSpace(upload.Filename))
+	if filename == "." || len(filename) > 255 {
+		return nil, ErrInvalidInput
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(upload.File, 0, upload.Size)); err != nil {
+		return nil, err
+	}
+	parsed, err := activity.Parse(filename, upload.File, upload.Size)
+	if errors.Is(err, activity.ErrUnsupportedFormat) || errors.Is(err, activity.ErrInvalidFile) {
+		return nil, ErrInvalidInput
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	maxHR, err := s.estimateMaxHeartRate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txActivityRepo := repository.NewActivityRepository(tx)
+	record, err := txActivityRepo.CreateImport(ctx, userID, filename, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrConflict
+		}
+		return nil, err
+	}
+
+	imported := make([]models.Activity, 0, len(parsed))
+	duplicates := 0
+	for _, item := range parsed {
+		input := activityInput(item, maxHR)
+		input.ImportID = &record.ID
+
+		exists, err := txActivityRepo.ExistsNear(ctx, userID, input.ActivityType, input.StartedAt)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			duplicates++
+			continue
+		}
+		created, err := txActivityRepo.Create(ctx, userID, input)
+		if errors.Is(err, pgx.ErrNoRows) {
+			duplicates++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		imported = append(imported, *created)
+	}
+
+	record, err = txActivityRepo.UpdateImportCounts(ctx, record.ID, len(parsed), len(imported), duplicates)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	record.Activities = imported
+	return record, nil
+}
+
+func (s *ActivityService) ListImports(
+	ctx context.Context,
+	userID int64,
+	limit int,
+	offset int,
+) ([]models.ActivityImport, int, error) {
+	return s.activityRepo.ListImports(ctx, userID, limit, offset)
+}
+
+// ListActivities returns the actor's activities, or those of one of a coach's active clients.
+func (s *ActivityService) ListActivities(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	filter repository.ActivityFilter,
+) ([]models.Activity, int, error) {
+	if filter.ActivityType != "" && activity.NormalizeType(filter.ActivityType) != filter.ActivityType {
+		return nil, 0, ErrInvalidInput
+	}
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		return nil, 0, ErrInvalidInput
+	}
+
+	switch role {
+	case "user":
+		if filter.UserID != 0 && filter.UserID != actorID {
+			return nil, 0, ErrForbidden
+		}
+		filter.UserID = actorID
+	case "coach":
+		if filter.UserID <= 0 {
+			return nil, 0, ErrInvalidInput
+		}
+		if err := s.requireActiveCoach(ctx, actorID, filter.UserID); err != nil {
+			return nil, 0, err
+		}
+	default:
+		return nil, 0, ErrForbidden
+	}
+
+	return s.activityRepo.List(ctx, filter)
+}
+
+func (s *ActivityService) GetActivity(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	activityID int64,
+) (*models.Activity, error) {
+	item, err := s.activityRepo.GetByID(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch role {
+	case "user":
+		if item.UserID != actorID {
+			return nil, ErrForbidden
+		}
+	case "coach":
+		if err := s.requireActiveCoach(ctx, actorID, item.UserID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrForbidden
+	}
+	return item, nil
+}
+
+func (s *ActivityService) DeleteActivity(ctx context.Context, userID int64, activityID int64) error {
+	item, err := s.activityRepo.GetByID(ctx, activityID)
+	if err != nil {
+		return err
+	}
+	if item.UserID != userID {
+		return ErrForbidden
+	}
+	return s.activityRepo.Delete(ctx, activityID)
+}
+
+func (s *ActivityService) requireActiveCoach(ctx context.Context, coachID int64, userID int64) error {
+	active, err := s.coachingRepo.IsActiveCoach(ctx, coachID, userID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// estimateMaxHeartRate uses the 220 minus age rule, falling back to defaultMaxHeartRate.
+func (s *ActivityService) estimateMaxHeartRate(ctx context.Context, userID int64) (int, error) {
+	profile, err := s.profileRepo.GetByUserID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultMaxHeartRate, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return maxHeartRateForAge(profile.Age), nil
+}
+
+func maxHeartRateForAge(age *int) int {
+	if age == nil || *age < 10 || *age > 100 {
+		return defaultMaxHeartRate
+	}
+	return 220 - *age
+}
+
+// activityInput converts a parsed activity into a row, leaving out figures the file lacked.
+func activityInput(item activity.Activity, maxHR int) repository.ActivityInput {
+	input := repository.ActivityInput{
+		Source:          item.Source,
+		ActivityType:    item.Type,
+		StartedAt:       item.StartedAt.UTC(),
+		DurationSeconds: int(math.Round(item.Duration.Seconds())),
+		HeartRateZones:  item.HeartRateZones(maxHR),
+	}
+	if item.DistanceM > 0 {
+		distance := math.Round(item.DistanceM*10) / 10
+		input.DistanceM = &distance
+	}
+	if item.AvgHeartRate > 0 {
+		avg := item.AvgHeartRate
+		input.AvgHeartRate = &avg
+	}
+	if item.MaxHeartRate > 0 {
+		peak := item.MaxHeartRate
+		input.MaxHeartRate = &peak
+	}
+	return input
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/pkg/activity"
+)
+
+func TestMaxHeartRateForAge(t *testing.T) {
+	age := func(v int) *int { return &v }
+	cases := []struct {
+		age  *int
+		want int
+	}{
+		{age: nil, want: defaultMaxHeartRate},
+		{age: age(30), want: 190},
+		{age: age(45), want: 175},
+		{age: age(4), want: defaultMaxHeartRate},
+	}
+	for _, tc := range cases {
+		if got := maxHeartRateForAge(tc.age); got != tc.want {
+			t.Fatalf("maxHeartRateForAge(%v) = %d, want %d", tc.age, got, tc.want)
+		}
+	}
+}
+
+func TestActivityInput(t *testing.T) {
+	start := time.Date(2030, 3, 1, 8, 0, 0, 0, time.FixedZone("CET", 3600))
+	input := activityInput(activity.Activity{
+		Source:    activity.SourceGPX,
+		Type:      activity.TypeRun,
+		StartedAt: start,
+		Duration:  1800*time.Second + 400*time.Millisecond,
+		DistanceM: 5012.345,
+		HeartRate: []activity.HeartRateSample{
+			{Time: start, BPM: 150},
+			{Time: start.Add(10 * time.Second), BPM: 150},
+		},
+		AvgHeartRate: 150,
+		MaxHeartRate: 150,
+	}, 200)
+
+	if input.StartedAt.Location() != time.UTC || input.StartedAt.Hour() != 7 {
+		t.Fatalf("expected start in UTC, got %v", input.StartedAt)
+	}
+	if input.DurationSeconds != 1800 || input.DistanceM == nil || *input.DistanceM != 5012.3 {
+		t.Fatalf("unexpected duration or distance: %+v", input)
+	}
+	if len(input.HeartRateZones) != 5 || input.HeartRateZones[2] != 11 {
+		t.Fatalf("expected time in zone 3, got %v", input.HeartRateZones)
+	}
+
+	bare := activityInput(activity.Activity{Type: activity.TypeStrength, StartedAt: start}, 200)
+	if bare.DistanceM != nil || bare.AvgHeartRate != nil || bare.MaxHeartRate != nil || bare.HeartRateZones != nil {
+		t.Fatalf("expected missing figures to stay empty, got %+v", bare)
+	}
+}
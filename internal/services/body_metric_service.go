@@ -0,0 +1,551 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	defaultTrendWindowDays   = 7
+	maxTrendWindowDays       = 90
+	defaultTrendRangeDays    = 90
+	maxTrendRange            = 366 * 24 * time.Hour
+	bodyMetricFutureLeeway   = 5 * time.Minute
+	maxBodyMetricNotesLength = 2000
+)
+
+type BodyMetricService struct {
+	db             *pgxpool.Pool
+	metricRepo     *repository.BodyMetricRepository
+	coachingRepo   *repository.CoachingRepository
+	storageService StorageService
+}
+
+func NewBodyMetricService(
+	db *pgxpool.Pool,
+	metricRepo *repository.BodyMetricRepository,
+	coachingRepo *repository.CoachingRepository,
+	storageService StorageService,
+) *BodyMetricService {
+	return &BodyMetricService{
+		db:             db,
+		metricRepo:     metricRepo,
+		coachingRepo:   coachingRepo,
+		storageService: storageService,
+	}
+}
+
+// CreateMeasurement records a new entry and keeps the profile weight pointing at the latest one.
+func (s *BodyMetricService) CreateMeasurement(
+	ctx context.Context,
+	userID int64,
+	input repository.BodyMeasurementInput,
+) (*models.BodyMeasurement, error) {
+	if err := normalizeBodyMeasurement(&input, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txMetricRepo := repository.NewBodyMetricRepository(tx)
+	measurement, err := txMetricRepo.CreateMeasurement(ctx, userID, input)
+	if err != nil {
+		return nil, err
+	}
+	if input.WeightKg != nil {
+		if err := txMetricRepo.SyncProfileWeight(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return measurement, nil
+}
+
+func (s *BodyMetricService) UpdateMeasurement(
+	ctx context.Context,
+	userID int64,
+	measurementID int64,
+	input repository.BodyMeasurementInput,
+) (*models.BodyMeasurement, error) {
+	if err := normalizeBodyMeasurement(&input, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txMetricRepo := repository.NewBodyMetricRepository(tx)
+	existing, err := txMetricRepo.GetMeasurementByID(ctx, measurementID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.UserID != userID {
+		return nil, ErrForbidden
+	}
+
+	measurement, err := txMetricRepo.UpdateMeasurement(ctx, measurementID, input)
+	if err != nil {
+		return nil, err
+	}
+	if existing.WeightKg != nil || input.WeightKg != nil {
+		if err := txMetricRepo.SyncProfileWeight(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return measurement, nil
+}
+
+func (s *BodyMetricService) DeleteMeasurement(ctx context.Context, userID int64, measurementID int64) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txMetricRepo := repository.NewBodyMetricRepository(tx)
+	existing, err := txMetricRepo.GetMeasurementByID(ctx, measurementID)
+	if err != nil {
+		return err
+	}
+	if existing.UserID != userID {
+		return ErrForbidden
+	}
+
+	if err := txMetricRepo.DeleteMeasurement(ctx, measurementID); err != nil {
+		return err
+	}
+	if existing.WeightKg != nil {
+		if err := txMetricRepo.SyncProfileWeight(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListMeasurements returns the history for the actor, or for one of a coach's active clients.
+func (s *BodyMetricService) ListMeasurements(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	filter repository.BodyMetricFilter,
+) ([]models.BodyMeasurement, int, error) {
+	if err := s.scopeBodyMetricFilter(ctx, actorID, role, &filter); err != nil {
+		return nil, 0, err
+	}
+	return s.metricRepo.ListMeasurements(ctx, filter)
+}
+
+// GetTrend charts one metric as daily averages with a trailing moving average over windowDays.
+func (s *BodyMetricService) GetTrend(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	userID int64,
+	metric string,
+	windowDays int,
+	from *time.Time,
+	to *time.Time,
+) (*models.BodyMetricTrend, error) {
+	if !repository.IsBodyMetric(metric) {
+		return nil, ErrInvalidInput
+	}
+	if windowDays == 0 {
+		windowDays = defaultTrendWindowDays
+	}
+	if windowDays < 1 || windowDays > maxTrendWindowDays {
+		return nil, ErrInvalidInput
+	}
+
+	end := time.Now().UTC()
+	if to != nil {
+		end = to.UTC()
+	}
+	start := end.AddDate(0, 0, -defaultTrendRangeDays)
+	if from != nil {
+		start = from.UTC()
+	}
+	if !end.After(start) || end.Sub(start) > maxTrendRange {
+		return nil, ErrInvalidInput
+	}
+
+	filter := repository.BodyMetricFilter{UserID: userID}
+	if err := s.scopeBodyMetricFilter(ctx, actorID, role, &filter); err != nil {
+		return nil, err
+	}
+
+	// Load the window's lead-in too so the first moving averages are not cut short.
+	leadIn := start.AddDate(0, 0, -(windowDays - 1))
+	filter.From = &leadIn
+	filter.To = &end
+	values, err := s.metricRepo.ListMetricValues(ctx, metric, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	points := bodyMetricTrend(values, windowDays)
+	visible := make([]models.BodyMetricTrendPoint, 0, len(points))
+	for _, point := range points {
+		if !point.Date.Before(startOfUTCDay(start)) {
+			visible = append(visible, point)
+		}
+	}
+
+	trend := &models.BodyMetricTrend{
+		UserID:     filter.UserID,
+		Metric:     metric,
+		WindowDays: windowDays,
+		From:       start,
+		To:         end,
+		Points:     visible,
+	}
+	if len(visible) > 1 {
+		change := math.Round((visible[len(visible)-1].Value-visible[0].Value)*100) / 100
+		trend.Change = &change
+	}
+	return trend, nil
+}
+
+type ProgressPhotoUpload struct {
+	File     multipart.File
+	Filename string
+	Input    repository.ProgressPhotoInput
+}
+
+func (s *BodyMetricService) UploadPhoto(
+	ctx context.Context,
+	userID int64,
+	upload ProgressPhotoUpload,
+) (*models.ProgressPhoto, error) {
+	if s.storageService == nil {
+		return nil, ErrStorageUnavailable
+	}
+	if upload.File == nil {
+		return nil, ErrInvalidInput
+	}
+
+	input := upload.Input
+	if input.TakenAt.IsZero() {
+		input.TakenAt = time.Now().UTC()
+	}
+	if input.TakenAt.After(time.Now().UTC().Add(bodyMetricFutureLeeway)) {
+		return nil, ErrInvalidInput
+	}
+	if input.Pose == "" {
+		input.Pose = "other"
+	}
+	if !isProgressPhotoPose(input.Pose) {
+		return nil, ErrInvalidInput
+	}
+	visibility, err := normalizeBodyMetricVisibility(input.Visibility)
+	if err != nil {
+		return nil, err
+	}
+	input.Visibility = visibility
+
+	if input.MeasurementID != nil {
+		measurement, err := s.metricRepo.GetMeasurementByID(ctx, *input.MeasurementID)
+		if err != nil {
+			return nil, err
+		}
+		if measurement.UserID != userID {
+			return nil, ErrForbidden
+		}
+	}
+
+	ext := strings.ToLower(filepath.Ext(strings.TrimSpace(upload.Filename)))
+	filename := fmt.Sprintf("%d-%d%s", userID, time.Now().UnixNano(), ext)
+	filePath, err := s.storageService.UploadFile(ctx, upload.File, filename, "progress-photos")
+	if err != nil {
+		return nil, err
+	}
+
+	photo, err := s.metricRepo.CreatePhoto(ctx, userID, filePath, input)
+	if err != nil {
+		cleanupErr := s.storageService.DeleteFile(ctx, filePath)
+		if cleanupErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("cleanup failed: %w", cleanupErr))
+		}
+		return nil, err
+	}
+
+	if err := s.signPhoto(ctx, photo); err != nil {
+		return nil, err
+	}
+	return photo, nil
+}
+
+// ListPhotos returns photos with freshly signed URLs; storage paths are never exposed.
+func (s *BodyMetricService) ListPhotos(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	filter repository.BodyMetricFilter,
+) ([]models.ProgressPhoto, int, error) {
+	if s.storageService == nil {
+		return nil, 0, ErrStorageUnavailable
+	}
+	if err := s.scopeBodyMetricFilter(ctx, actorID, role, &filter); err != nil {
+		return nil, 0, err
+	}
+
+	photos, total, err := s.metricRepo.ListPhotos(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range photos {
+		if err := s.signPhoto(ctx, &photos[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return photos, total, nil
+}
+
+func (s *BodyMetricService) UpdatePhoto(
+	ctx context.Context,
+	userID int64,
+	photoID int64,
+	pose string,
+	visibility string,
+) (*models.ProgressPhoto, error) {
+	if s.storageService == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	photo, err := s.metricRepo.GetPhotoByID(ctx, photoID)
+	if err != nil {
+		return nil, err
+	}
+	if photo.UserID != userID {
+		return nil, ErrForbidden
+	}
+
+	if pose == "" {
+		pose = photo.Pose
+	}
+	if !isProgressPhotoPose(pose) {
+		return nil, ErrInvalidInput
+	}
+	if visibility == "" {
+		visibility = photo.Visibility
+	}
+	visibility, err = normalizeBodyMetricVisibility(visibility)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.metricRepo.UpdatePhoto(ctx, photoID, pose, visibility)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.signPhoto(ctx, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *BodyMetricService) DeletePhoto(ctx context.Context, userID int64, photoID int64) error {
+	photo, err := s.metricRepo.GetPhotoByID(ctx, photoID)
+	if err != nil {
+		return err
+	}
+	if photo.UserID != userID {
+		return ErrForbidden
+	}
+
+	if err := s.metricRepo.DeletePhoto(ctx, photoID); err != nil {
+		return err
+	}
+	if s.storageService != nil {
+		_ = s.storageService.DeleteFile(ctx, photo.FilePath)
+	}
+	return nil
+}
+
+// scopeBodyMetricFilter limits users to their own data and coaches to the shared entries of
+// clients they actively work with.
+func (s *BodyMetricService) scopeBodyMetricFilter(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	filter *repository.BodyMetricFilter,
+) error {
+	switch role {
+	case "user":
+		if filter.UserID != 0 && filter.UserID != actorID {
+			return ErrForbidden
+		}
+		filter.UserID = actorID
+		filter.SharedOnly = false
+	case "coach":
+		if filter.UserID <= 0 {
+			return ErrInvalidInput
+		}
+		active, err := s.coachingRepo.IsActiveCoach(ctx, actorID, filter.UserID)
+		if err != nil {
+			return err
+		}
+		if !active {
+			return ErrForbidden
+		}
+		filter.SharedOnly = true
+	default:
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (s *BodyMetricService) signPhoto(ctx context.Context, photo *models.ProgressPhoto) error {
+	signedURL, err := s.storageService.GetSignedURL(ctx, photo.FilePath)
+	if err != nil {
+		return err
+	}
+	photo.PhotoURL = signedURL
+	return nil
+}
+
+func normalizeBodyMeasurement(input *repository.BodyMeasurementInput, now time.Time) error {
+	if input.MeasuredAt.IsZero() {
+		input.MeasuredAt = now
+	}
+	if input.MeasuredAt.After(now.Add(bodyMetricFutureLeeway)) {
+		return ErrInvalidInput
+	}
+	input.MeasuredAt = input.MeasuredAt.UTC()
+
+	ranges := []struct {
+		value    *float64
+		min, max float64
+	}{
+		{input.WeightKg, 20, 400},
+		{input.BodyFatPct, 2, 75},
+		{input.ChestCm, 30, 250},
+		{input.WaistCm, 30, 250},
+		{input.HipsCm, 30, 250},
+		{input.ArmCm, 10, 100},
+		{input.ThighCm, 20, 150},
+	}
+	recorded := false
+	for _, r := range ranges {
+		if r.value == nil {
+			continue
+		}
+		if *r.value < r.min || *r.value > r.max {
+			return ErrInvalidInput
+		}
+		recorded = true
+	}
+	if input.RestingHeartRate != nil {
+		if *input.RestingHeartRate < 20 || *input.RestingHeartRate > 250 {
+			return ErrInvalidInput
+		}
+		recorded = true
+	}
+	if !recorded {
+		return ErrInvalidInput
+	}
+
+	input.Notes = blankToNil(input.Notes)
+	if input.Notes != nil && len(*input.Notes) > maxBodyMetricNotesLength {
+		return ErrInvalidInput
+	}
+
+	visibility, err := normalizeBodyMetricVisibility(input.Visibility)
+	if err != nil {
+		return err
+	}
+	input.Visibility = visibility
+	return nil
+}
+
+func normalizeBodyMetricVisibility(visibility string) (string, error) {
+	switch visibility = strings.ToLower(strings.TrimSpace(visibility)); visibility {
+	case "":
+		return "coaches", nil
+	case "coaches", "private":
+		return visibility, nil
+	default:
+		return "", ErrInvalidInput
+	}
+}
+
+func isProgressPhotoPose(pose string) bool {
+	switch pose {
+	case "front", "side", "back", "other":
+		return true
+	default:
+		return false
+	}
+}
+
+func startOfUTCDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// bodyMetricTrend averages same-day readings, then smooths them with a trailing average over the
+// readings that fall within windowDays calendar days. Values must be in chronological order.
+func bodyMetricTrend(values []repository.BodyMetricValue, windowDays int) []models.BodyMetricTrendPoint {
+	points := make([]models.BodyMetricTrendPoint, 0)
+	counts := make([]int, 0)
+	for _, value := range values {
+		day := startOfUTCDay(value.MeasuredAt)
+		last := len(points) - 1
+		if last >= 0 && points[last].Date.Equal(day) {
+			points[last].Value += value.Value
+			counts[last]++
+			continue
+		}
+		points = append(points, models.BodyMetricTrendPoint{Date: day, Value: value.Value})
+		counts = append(counts, 1)
+	}
+	for i := range points {
+		points[i].Value /= float64(counts[i])
+	}
+
+	start := 0
+	sum := 0.0
+	for i := range points {
+		sum += points[i].Value
+		windowStart := points[i].Date.AddDate(0, 0, -(windowDays - 1))
+		for points[start].Date.Before(windowStart) {
+			sum -= points[start].Value
+			start++
+		}
+		points[i].MovingAverage = math.Round(sum/float64(i-start+1)*100) / 100
+	}
+	for i := range points {
+		points[i].Value = math.Round(points[i].Value*100) / 100
+	}
+	return points
+}
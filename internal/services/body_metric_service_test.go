@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+func TestNormalizeBodyMeasurement(t *testing.T) {
+	now := time.Date(2030, 3, 10, 12, 0, 0, 0, time.UTC)
+	value := func(v float64) *float64 { return &v }
+	heartRate := func(v int) *int { return &v }
+
+	tests := []struct {
+		name           string
+		input          repository.BodyMeasurementInput
+		wantErr        error
+		wantVisibility string
+	}{
+		{
+			name:           "weight only defaults",
+			input:          repository.BodyMeasurementInput{WeightKg: value(82.4)},
+			wantVisibility: "coaches",
+		},
+		{
+			name:           "private circumferences",
+			input:          repository.BodyMeasurementInput{WaistCm: value(84), HipsCm: value(98), Visibility: " Private "},
+			wantVisibility: "private",
+		},
+		{
+			name:           "resting heart rate only",
+			input:          repository.BodyMeasurementInput{RestingHeartRate: heartRate(58)},
+			wantVisibility: "coaches",
+		},
+		{
+			name:    "nothing recorded",
+			input:   repository.BodyMeasurementInput{},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "body fat out of range",
+			input:   repository.BodyMeasurementInput{BodyFatPct: value(90)},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "future measurement",
+			input:   repository.BodyMeasurementInput{WeightKg: value(80), MeasuredAt: now.Add(time.Hour)},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "unknown visibility",
+			input:   repository.BodyMeasurementInput{WeightKg: value(80), Visibility: "public"},
+			wantErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.input
+			err := normalizeBodyMeasurement(&input, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if input.Visibility != tt.wantVisibility {
+				t.Fatalf("expected visibility %q, got %q", tt.wantVisibility, input.Visibility)
+			}
+			if input.MeasuredAt.IsZero() {
+				t.Fatal("expected measured_at to default to now")
+			}
+		})
+	}
+}
+
+func TestBodyMetricTrend(t *testing.T) {
+	day := func(d int, hour int) time.Time {
+		return time.Date(2030, 3, d, hour, 0, 0, 0, time.UTC)
+	}
+	values := []repository.BodyMetricValue{
+		{MeasuredAt: day(1, 7), Value: 80},
+		{MeasuredAt: day(1, 20), Value: 81},
+		{MeasuredAt: day(2, 7), Value: 80},
+		{MeasuredAt: day(4, 7), Value: 79},
+		{MeasuredAt: day(9, 7), Value: 78},
+	}
+
+	points := bodyMetricTrend(values, 3)
+	if len(points) != 4 {
+		t.Fatalf("expected 4 daily points, got %d", len(points))
+	}
+
+	want := []struct {
+		value         float64
+		movingAverage float64
+	}{
+		{value: 80.5, movingAverage: 80.5},
+		{value: 80, movingAverage: 80.25},
+		{value: 79, movingAverage: 79.5},
+		{value: 78, movingAverage: 78},
+	}
+	for i, w := range want {
+		if points[i].Value != w.value || points[i].MovingAverage != w.movingAverage {
+			t.Fatalf("point %d: expected %v/%v, got %v/%v",
+				i, w.value, w.movingAverage, points[i].Value, points[i].MovingAverage)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	BroadcastSegmentActiveClients = "active_clients"
+	BroadcastSegmentSessionRange  = "session_range"
+	BroadcastSegmentProgram       = "program"
+
+	maxBroadcastContentLength = 4000
+	// maxBroadcastSessionRange bounds the session_range segment to a quarter.
+	maxBroadcastSessionRange = 92 * 24 * time.Hour
+
+	broadcastBatchSize    = 50
+	broadcastLease        = 2 * time.Minute
+	broadcastPollInterval = 30 * time.Second
+)
+
+// ErrEmptySegment means no active client of the coach falls in the broadcast's segment.
+var ErrEmptySegment = errors.New("broadcast segment has no clients")
+
+var errNoLongerCoached = errors.New("client is no longer coached")
+
+type chatMessageSender interface {
+	SendMessage(ctx context.Context, actorID int64, role string, conversationID int64, content string) (*ChatDelivery, error)
+}
+
+// ChatDeliverer pushes a stored message to the participants who are online.
+type ChatDeliverer interface {
+	DeliverMessage(delivery *ChatDelivery) error
+}
+
+// deliverStored pushes a message that is already stored. Failures are only logged, since anyone
+// who missed the push still sees the message in the conversation history. deliverer may be nil.
+func deliverStored(deliverer ChatDeliverer, delivery *ChatDelivery, what string) {
+	if deliverer == nil {
+		return
+	}
+	if err := deliverer.DeliverMessage(delivery); err != nil {
+		log.Printf("%s real-time delivery: %v", what, err)
+	}
+}
+
+// BroadcastService lets coaches message a segment of their clients. Each client receives the
+// broadcast as an ordinary message in their direct conversation with the coach, sent by a
+// background worker so a large segment does not hold up the request.
+type BroadcastService struct {
+	broadcastRepo    *repository.BroadcastRepository
+	conversationRepo *repository.ConversationRepository
+	coachingRepo     *repository.CoachingRepository
+	sender           chatMessageSender
+	deliverer        ChatDeliverer
+	moderator        *ModerationPipeline
+	wake             chan struct{}
+}
+
+func NewBroadcastService(
+	broadcastRepo *repository.BroadcastRepository,
+	conversationRepo *repository.ConversationRepository,
+	coachingRepo *repository.CoachingRepository,
+	sender chatMessageSender,
+	deliverer ChatDeliverer,
+	moderator *ModerationPipeline,
+) *BroadcastService {
+	return &BroadcastService{
+		broadcastRepo:    broadcastRepo,
+		conversationRepo: conversationRepo,
+		coachingRepo:     coachingRepo,
+		sender:           sender,
+		deliverer:        deliverer,
+		moderator:        moderator,
+		wake:             make(chan struct{}, 1),
+	}
+}
+
+// CreateBroadcast queues a broadcast to the coach's active clients in the segment. Recipients are
+// fixed when it is created; delivery happens in the background.
+func (s *BroadcastService) CreateBroadcast(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	input repository.BroadcastInput,
+) (*models.ChatBroadcast, error) {
+	if role != "coach" {
+		return nil, ErrForbidden
+	}
+	input.CoachID = actorID
+	if err := normalizeBroadcastInput(&input); err != nil {
+		return nil, err
+	}
+	// Each message is moderated when it is sent, but content that would be blocked is refused here
+	// rather than failing for every recipient.
+	if verdict := s.moderator.Moderate(input.Content); verdict != nil && verdict.Action == ModerationActionBlock {
+		return nil, ErrMessageBlocked
+	}
+
+	recipientIDs, err := s.broadcastRepo.ListSegmentClients(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipientIDs) == 0 {
+		return nil, ErrEmptySegment
+	}
+
+	broadcast, err := s.broadcastRepo.Create(ctx, input, recipientIDs)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return broadcast, nil
+}
+
+// normalizeBroadcastInput trims the content and checks it, and that the segment has exactly the
+// parameters it needs.
+func normalizeBroadcastInput(input *repository.BroadcastInput) error {
+	input.Content = strings.TrimSpace(input.Content)
+	if input.Content == "" || utf8.RuneCountInString(input.Content) > maxBroadcastContentLength {
+		return ErrInvalidInput
+	}
+
+	switch input.Segment {
+	case BroadcastSegmentActiveClients:
+		if input.SessionsFrom != nil || input.SessionsTo != nil || input.ProgramTemplateID != nil {
+			return ErrInvalidInput
+		}
+	case BroadcastSegmentSessionRange:
+		if input.SessionsFrom == nil || input.SessionsTo == nil || input.ProgramTemplateID != nil {
+			return ErrInvalidInput
+		}
+		if !input.SessionsTo.After(*input.SessionsFrom) ||
+			input.SessionsTo.Sub(*input.SessionsFrom) > maxBroadcastSessionRange {
+			return ErrInvalidInput
+		}
+	case BroadcastSegmentProgram:
+		if input.ProgramTemplateID == nil || *input.ProgramTemplateID <= 0 ||
+			input.SessionsFrom != nil || input.SessionsTo != nil {
+			return ErrInvalidInput
+		}
+	default:
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+func (s *BroadcastService) GetBroadcast(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	broadcastID int64,
+) (*models.ChatBroadcast, error) {
+	if role != "coach" {
+		return nil, ErrForbidden
+	}
+	if broadcastID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	return s.broadcastRepo.GetForCoach(ctx, broadcastID, actorID)
+}
+
+func (s *BroadcastService) ListBroadcasts(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	limit int,
+	offset int,
+) ([]models.ChatBroadcast, int, error) {
+	if role != "coach" {
+		return nil, 0, ErrForbidden
+	}
+	return s.broadcastRepo.ListForCoach(ctx, actorID, limit, offset)
+}
+
+// ListRecipients returns a page of a broadcast's per-client deliveries, optionally only those
+// with the given status.
+func (s *BroadcastService) ListRecipients(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	broadcastID int64,
+	status string,
+	limit int,
+	offset int,
+) ([]models.ChatBroadcastRecipient, int, error) {
+	switch status {
+	case "", "pending", "sent", "failed":
+	default:
+		return nil, 0, ErrInvalidInput
+	}
+	if _, err := s.GetBroadcast(ctx, actorID, role, broadcastID); err != nil {
+		return nil, 0, err
+	}
+	return s.broadcastRepo.ListRecipients(ctx, broadcastID, status, limit, offset)
+}
+
+// Run delivers queued broadcasts until ctx is done. It wakes when a broadcast is created here and
+// polls for the rest, including broadcasts left behind by an instance that stopped mid-delivery.
+func (s *BroadcastService) Run(ctx context.Context) {
+	ticker := time.NewTicker(broadcastPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			delivered, err := s.deliverNext(ctx)
+			if err != nil {
+				log.Printf("broadcast delivery: %v", err)
+			}
+			if !delivered || err != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// deliverNext claims one unfinished broadcast and sends it to its pending recipients. It reports
+// whether there was one.
+func (s *BroadcastService) deliverNext(ctx context.Context) (bool, error) {
+	broadcast, err := s.broadcastRepo.ClaimNext(ctx, broadcastLease)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for {
+		recipientIDs, err := s.broadcastRepo.ListPendingRecipients(ctx, broadcast.ID, broadcastBatchSize)
+		if err != nil {
+			return true, err
+		}
+		if len(recipientIDs) == 0 {
+			break
+		}
+		for _, userID := range recipientIDs {
+			if err := s.deliverTo(ctx, broadcast, userID); err != nil {
+				return true, err
+			}
+		}
+		if err := s.broadcastRepo.ExtendLease(ctx, broadcast.ID, broadcastLease); err != nil {
+			return true, err
+		}
+	}
+
+	_, err = s.broadcastRepo.Complete(ctx, broadcast.ID)
+	return true, err
+}
+
+// deliverTo sends the broadcast to one client and records the outcome. Clients the coach stopped
+// coaching or who blocked the coach since the broadcast was queued are recorded as failed. Only
+// errors recording the outcome are returned.
+func (s *BroadcastService) deliverTo(ctx context.Context, broadcast *models.ChatBroadcast, userID int64) error {
+	delivery, err := s.sendTo(ctx, broadcast, userID)
+	if err != nil {
+		failure := err.Error()
+		if !errors.Is(err, errNoLongerCoached) && !errors.Is(err, ErrUserBlocked) {
+			log.Printf("broadcast %d send to %d: %v", broadcast.ID, userID, err)
+			failure = "message could not be sent"
+		}
+		return s.broadcastRepo.RecordDelivery(ctx, broadcast.ID, userID, nil, &failure)
+	}
+	if err := s.broadcastRepo.RecordDelivery(ctx, broadcast.ID, userID, &delivery.Message.ID, nil); err != nil {
+		return err
+	}
+	deliverStored(s.deliverer, delivery, fmt.Sprintf("broadcast %d to %d", broadcast.ID, userID))
+	return nil
+}
+
+func (s *BroadcastService) sendTo(ctx context.Context, broadcast *models.ChatBroadcast, userID int64) (*ChatDelivery, error) {
+	active, err := s.coachingRepo.IsActiveCoach(ctx, broadcast.CoachID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, errNoLongerCoached
+	}
+	conversation, err := s.conversationRepo.CreateOrGet(ctx, userID, broadcast.CoachID)
+	if err != nil {
+		return nil, err
+	}
+	return s.sender.SendMessage(ctx, broadcast.CoachID, "coach", conversation.ID, broadcast.Content)
+}
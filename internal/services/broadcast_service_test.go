@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+func TestCreateBroadcastValidatesBeforeLookups(t *testing.T) {
+	service := &BroadcastService{}
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(7 * 24 * time.Hour)
+	templateID := int64(5)
+	cases := []struct {
+		name  string
+		role  string
+		input repository.BroadcastInput
+		want  error
+	}{
+		{name: "client", role: "user", input: repository.BroadcastInput{Content: "Hi", Segment: BroadcastSegmentActiveClients}, want: ErrForbidden},
+		{name: "blank content", role: "coach", input: repository.BroadcastInput{Content: " ", Segment: BroadcastSegmentActiveClients}, want: ErrInvalidInput},
+		{name: "long content", role: "coach", input: repository.BroadcastInput{Content: strings.Repeat("a", maxBroadcastContentLength+1), Segment: BroadcastSegmentActiveClients}, want: ErrInvalidInput},
+		{name: "unknown segment", role: "coach", input: repository.BroadcastInput{Content: "Hi", Segment: "everyone"}, want: ErrInvalidInput},
+		{name: "range without end", role: "coach", input: repository.BroadcastInput{Content: "Hi", Segment: BroadcastSegmentSessionRange, SessionsFrom: &from}, want: ErrInvalidInput},
+		{name: "reversed range", role: "coach", input: repository.BroadcastInput{Content: "Hi", Segment: BroadcastSegmentSessionRange, SessionsFrom: &to, SessionsTo: &from}, want: ErrInvalidInput},
+		{name: "program without template", role: "coach", input: repository.BroadcastInput{Content: "Hi", Segment: BroadcastSegmentProgram}, want: ErrInvalidInput},
+		{name: "template on active clients", role: "coach", input: repository.BroadcastInput{Content: "Hi", Segment: BroadcastSegmentActiveClients, ProgramTemplateID: &templateID}, want: ErrInvalidInput},
+	}
+	for _, tc := range cases {
+		if _, err := service.CreateBroadcast(context.Background(), 7, tc.role, tc.input); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestNormalizeBroadcastInputTrimsContent(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(maxBroadcastSessionRange)
+	input := repository.BroadcastInput{
+		Content:      "  Gym is closed Friday  ",
+		Segment:      BroadcastSegmentSessionRange,
+		SessionsFrom: &from,
+		SessionsTo:   &to,
+	}
+	if err := normalizeBroadcastInput(&input); err != nil {
+		t.Fatalf("normalizeBroadcastInput: %v", err)
+	}
+	if input.Content != "Gym is closed Friday" {
+		t.Fatalf("unexpected content %q", input.Content)
+	}
+}
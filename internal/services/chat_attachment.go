@@ -0,0 +1,312 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+	"github.com/saeid-a/CoachAppBack/pkg/thumbnail"
+)
+
+const (
+	AttachmentImage = "image"
+	AttachmentVideo = "video"
+	AttachmentAudio = "audio"
+	AttachmentFile  = "file"
+
+	chatThumbnailMaxSide = 320
+	maxAttachmentNameLen = 255
+)
+
+var ErrAttachmentTooLarge = errors.New("attachment exceeds the size limit for its type")
+
+// ChatAttachmentUpload is a file sent in a conversation. Caption becomes the message content.
+type ChatAttachmentUpload struct {
+	File     multipart.File
+	Filename string
+	Size     int64
+	Caption  string
+}
+
+// maxAttachmentBytes is the size limit per kind. Form-check videos get the most room.
+func maxAttachmentBytes(kind string) int64 {
+	switch kind {
+	case AttachmentImage:
+		return 10 * 1024 * 1024
+	case AttachmentVideo:
+		return 100 * 1024 * 1024
+	case AttachmentAudio:
+		return 20 * 1024 * 1024
+	default:
+		return 25 * 1024 * 1024
+	}
+}
+
+// sniffChatAttachment identifies an upload from its first bytes rather than trusting the client's
+// filename or Content-Type. The extension only separates formats that share a container.
+func sniffChatAttachment(head []byte, filename string) (string, string, bool) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return AttachmentImage, contentType, true
+	case "video/mp4", "application/octet-stream":
+		if brand, ok := isoMediaBrand(head); ok {
+			switch brand {
+			case "M4A ", "M4B ":
+				return AttachmentAudio, "audio/mp4", true
+			case "qt  ":
+				return AttachmentVideo, "video/quicktime", true
+			case "heic", "heix", "mif1":
+				return AttachmentImage, "image/heic", true
+			}
+			return AttachmentVideo, "video/mp4", true
+		}
+		return "", "", false
+	case "video/webm":
+		if ext == ".weba" {
+			return AttachmentAudio, "audio/webm", true
+		}
+		return AttachmentVideo, contentType, true
+	case "video/avi":
+		return AttachmentVideo, contentType, true
+	case "audio/mpeg", "audio/wave", "audio/aiff":
+		return AttachmentAudio, contentType, true
+	case "application/ogg":
+		return AttachmentAudio, "audio/ogg", true
+	case "application/pdf", "application/zip", "text/plain":
+		return AttachmentFile, contentType, true
+	default:
+		return "", "", false
+	}
+}
+
+// isoMediaBrand returns the major brand of an ISO base media file (MP4, MOV, M4A, HEIC).
+func isoMediaBrand(head []byte) (string, bool) {
+	if len(head) < 12 || !bytes.Equal(head[4:8], []byte("ftyp")) {
+		return "", false
+	}
+	return string(head[8:12]), true
+}
+
+// attachmentDisplayName keeps the base name of the client's file for display, without any path.
+func attachmentDisplayName(filename string, kind string) string {
+	name := strings.TrimSpace(filepath.Base(strings.ReplaceAll(filename, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		name = kind
+	}
+	if len(name) > maxAttachmentNameLen {
+		ext := filepath.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		name = strings.ToValidUTF8(name[:maxAttachmentNameLen-len(ext)], "") + ext
+	}
+	return name
+}
+
+// SendAttachment stores a file sent in the conversation together with a message carrying the
+// optional caption. The type is sniffed from the content, and images get a JPEG thumbnail.
+func (s *ChatService) SendAttachment(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	upload ChatAttachmentUpload,
+) (*ChatDelivery, error) {
+	if s.storageService == nil {
+		return nil, ErrStorageUnavailable
+	}
+	if role != "user" && role != "coach" {
+		return nil, ErrForbidden
+	}
+	if conversationID <= 0 || upload.File == nil || upload.Size <= 0 {
+		return nil, ErrInvalidInput
+	}
+
+	conversation, recipientIDs, err := s.authorizeSend(ctx, actorID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	caption, err := s.moderate(ctx, conversationID, actorID, strings.TrimSpace(upload.Caption))
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(upload.File, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, ErrInvalidInput
+	}
+	kind, contentType, ok := sniffChatAttachment(head[:n], upload.Filename)
+	if !ok {
+		return nil, ErrInvalidInput
+	}
+	if upload.Size > maxAttachmentBytes(kind) {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	input := repository.MessageAttachmentInput{
+		Kind:        kind,
+		Filename:    attachmentDisplayName(upload.Filename, kind),
+		ContentType: contentType,
+		SizeBytes:   upload.Size,
+	}
+	baseName := fmt.Sprintf("%d-%d", actorID, time.Now().UnixNano())
+	folder := fmt.Sprintf("chat/%d", conversationID)
+
+	var thumb *thumbnail.Result
+	if kind == AttachmentImage {
+		if _, err := upload.File.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		// Formats the standard library cannot decode (WebP, HEIC) are sent without a thumbnail.
+		if result, err := thumbnail.Generate(upload.File, chatThumbnailMaxSide); err == nil {
+			thumb = result
+			input.Width = &result.SourceWidth
+			input.Height = &result.SourceHeight
+		}
+	}
+
+	if _, err := upload.File.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(filepath.Ext(input.Filename))
+	input.FilePath, err = s.storageService.UploadFile(ctx, upload.File, baseName+ext, folder)
+	if err != nil {
+		return nil, err
+	}
+	uploaded := []string{input.FilePath}
+	cleanup := func(err error) error {
+		for _, path := range uploaded {
+			if cleanupErr := s.storageService.DeleteFile(ctx, path); cleanupErr != nil {
+				err = errors.Join(err, fmt.Errorf("cleanup failed: %w", cleanupErr))
+			}
+		}
+		return err
+	}
+
+	if thumb != nil {
+		thumbnailPath, err := s.storageService.UploadFile(ctx, newMemoryFile(thumb.JPEG), baseName+"-thumb.jpg", folder)
+		if err != nil {
+			return nil, cleanup(err)
+		}
+		input.ThumbnailPath = &thumbnailPath
+		uploaded = append(uploaded, thumbnailPath)
+	}
+
+	message, err := s.createAttachmentMessage(ctx, actorID, conversation, caption, input)
+	if err != nil {
+		return nil, cleanup(err)
+	}
+	if err := s.signAttachments(ctx, message.Attachments); err != nil {
+		return nil, err
+	}
+
+	return &ChatDelivery{
+		Conversation: conversation,
+		Message:      message,
+		RecipientIDs: recipientIDs,
+	}, nil
+}
+
+func (s *ChatService) createAttachmentMessage(
+	ctx context.Context,
+	actorID int64,
+	conversation *models.Conversation,
+	caption moderatedContent,
+	input repository.MessageAttachmentInput,
+) (*models.ChatMessage, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txMessageRepo := repository.NewMessageRepository(tx)
+	message, err := txMessageRepo.Create(ctx, conversation.ID, actorID, caption.Text)
+	if err != nil {
+		return nil, err
+	}
+	if err := queueModeration(ctx, repository.NewModerationRepository(tx), message, caption); err != nil {
+		return nil, err
+	}
+	attachment, err := txMessageRepo.CreateAttachment(ctx, message.ID, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := queueAutoReply(
+		ctx,
+		repository.NewScheduledMessageRepository(tx),
+		repository.NewCoachProfileRepository(tx),
+		conversation,
+		actorID,
+	); err != nil {
+		return nil, err
+	}
+	if err := repository.NewConversationRepository(tx).Touch(ctx, conversation.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	message.Attachments = []models.MessageAttachment{*attachment}
+	return message, nil
+}
+
+// GetAttachment returns an attachment with freshly signed URLs, for when earlier links expired.
+func (s *ChatService) GetAttachment(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	attachmentID int64,
+) (*models.MessageAttachment, error) {
+	if s.storageService == nil {
+		return nil, ErrStorageUnavailable
+	}
+	if role != "user" && role != "coach" {
+		return nil, ErrForbidden
+	}
+
+	attachment, err := s.messageRepo.GetAttachmentForParticipant(ctx, conversationID, attachmentID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	signed := []models.MessageAttachment{*attachment}
+	if err := s.signAttachments(ctx, signed); err != nil {
+		return nil, err
+	}
+	return &signed[0], nil
+}
+
+func (s *ChatService) signAttachments(ctx context.Context, attachments []models.MessageAttachment) error {
+	for i := range attachments {
+		url, err := s.storageService.GetSignedURL(ctx, attachments[i].FilePath)
+		if err != nil {
+			return err
+		}
+		attachments[i].URL = url
+		if attachments[i].ThumbnailPath != nil {
+			thumbnailURL, err := s.storageService.GetSignedURL(ctx, *attachments[i].ThumbnailPath)
+			if err != nil {
+				return err
+			}
+			attachments[i].ThumbnailURL = thumbnailURL
+		}
+	}
+	return nil
+}
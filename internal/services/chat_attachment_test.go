@@ -0,0 +1,47 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSniffChatAttachment(t *testing.T) {
+	ftyp := func(brand string) []byte {
+		return append([]byte{0, 0, 0, 0x18}, []byte("ftyp"+brand+"\x00\x00\x00\x00isom")...)
+	}
+	cases := []struct {
+		name     string
+		head     []byte
+		filename string
+		kind     string
+		mime     string
+		ok       bool
+	}{
+		{name: "jpeg", head: []byte("\xff\xd8\xff\xe0\x00\x10JFIF"), filename: "a.png", kind: AttachmentImage, mime: "image/jpeg", ok: true},
+		{name: "mp4 video", head: ftyp("isom"), filename: "squat.mp4", kind: AttachmentVideo, mime: "video/mp4", ok: true},
+		{name: "quicktime", head: ftyp("qt  "), filename: "squat.mov", kind: AttachmentVideo, mime: "video/quicktime", ok: true},
+		{name: "voice note", head: ftyp("M4A "), filename: "note.m4a", kind: AttachmentAudio, mime: "audio/mp4", ok: true},
+		{name: "ogg voice note", head: []byte("OggS\x00\x02"), filename: "note.ogg", kind: AttachmentAudio, mime: "audio/ogg", ok: true},
+		{name: "pdf", head: []byte("%PDF-1.7\n"), filename: "plan.pdf", kind: AttachmentFile, mime: "application/pdf", ok: true},
+		{name: "executable renamed", head: []byte("MZ\x90\x00\x03\x00\x00\x00"), filename: "photo.jpg", ok: false},
+		{name: "html", head: []byte("<html><script>"), filename: "notes.txt", ok: false},
+	}
+	for _, tc := range cases {
+		kind, mime, ok := sniffChatAttachment(tc.head, tc.filename)
+		if ok != tc.ok || kind != tc.kind || mime != tc.mime {
+			t.Fatalf("%s: got (%q, %q, %v), want (%q, %q, %v)", tc.name, kind, mime, ok, tc.kind, tc.mime, tc.ok)
+		}
+	}
+}
+
+func TestAttachmentDisplayName(t *testing.T) {
+	if got := attachmentDisplayName(`C:\Users\me\squat.mp4`, AttachmentVideo); got != "squat.mp4" {
+		t.Fatalf("expected path to be stripped, got %q", got)
+	}
+	if got := attachmentDisplayName("  ", AttachmentImage); got != "image" {
+		t.Fatalf("expected kind as fallback, got %q", got)
+	}
+	if got := attachmentDisplayName(strings.Repeat("a", 300)+".pdf", AttachmentFile); len(got) != maxAttachmentNameLen || !strings.HasSuffix(got, ".pdf") {
+		t.Fatalf("expected truncated name keeping the extension, got %d bytes", len(got))
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+// queueAutoReply queues the coach's auto-reply when a client writes in a direct conversation while
+// the coach is away. The worker that sends scheduled messages posts it.
+//
+// Working hours are a recurring weekly schedule kept with the auto-reply rather than derived from
+// coach_availability_slots: those are one-off bookable session times, and most coaches answer chat
+// well outside them. The two are kept consistent by never treating the coach as away during one of
+// their availability slots.
+func queueAutoReply(
+	ctx context.Context,
+	scheduledRepo *repository.ScheduledMessageRepository,
+	profileRepo *repository.CoachProfileRepository,
+	conversation *models.Conversation,
+	senderID int64,
+) error {
+	if conversation.Kind == models.ConversationKindGroup || senderID != conversation.UserID {
+		return nil
+	}
+	autoReply, err := scheduledRepo.GetAutoReply(ctx, conversation.CoachID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	window, away := autoReplyWindow(autoReply, time.Now())
+	if !away {
+		return nil
+	}
+	inSlot, err := profileRepo.HasCurrentSlot(ctx, conversation.CoachID)
+	if err != nil {
+		return err
+	}
+	if inSlot {
+		return nil
+	}
+	_, err = scheduledRepo.QueueAutoReply(ctx, conversation.ID, conversation.CoachID, autoReply.Message, window)
+	return err
+}
+
+// autoReplyWindow reports whether the coach is away at now and, if so, when the away window began:
+// the start of the away period, or the end of the last working day. Each window gets at most one
+// auto-reply per conversation.
+func autoReplyWindow(autoReply *models.CoachAutoReply, now time.Time) (time.Time, bool) {
+	if !autoReply.Enabled {
+		return time.Time{}, false
+	}
+	if autoReply.AwayFrom != nil && autoReply.AwayUntil != nil &&
+		!now.Before(*autoReply.AwayFrom) && now.Before(*autoReply.AwayUntil) {
+		return autoReply.AwayFrom.UTC(), true
+	}
+	if len(autoReply.WorkingDays) == 0 {
+		return time.Time{}, false
+	}
+
+	location, err := time.LoadLocation(autoReply.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	startMinute, startOK := parseClockMinutes(autoReply.WorkStart)
+	endMinute, endOK := parseClockMinutes(autoReply.WorkEnd)
+	if !startOK || !endOK || startMinute >= endMinute {
+		return time.Time{}, false
+	}
+
+	local := now.In(location)
+	for daysBack := 0; daysBack <= 7; daysBack++ {
+		day := local.AddDate(0, 0, -daysBack)
+		if !slices.Contains(autoReply.WorkingDays, int(day.Weekday())) {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), startMinute/60, startMinute%60, 0, 0, location)
+		end := time.Date(day.Year(), day.Month(), day.Day(), endMinute/60, endMinute%60, 0, 0, location)
+		if !local.Before(start) && local.Before(end) {
+			return time.Time{}, false
+		}
+		if !end.After(local) {
+			return end.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseClockMinutes parses an HH:MM time of day into minutes after midnight.
+func parseClockMinutes(value string) (int, bool) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return parsed.Hour()*60 + parsed.Minute(), true
+}
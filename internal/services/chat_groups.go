@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	maxGroupTitleLength = 100
+	// maxGroupMembers counts the coach who owns the group.
+	maxGroupMembers = 50
+)
+
+var ErrMemberNotFound = errors.New("conversation member not found")
+
+// MembershipChange is a set of users who joined or left a group. ActorID made the change, which
+// is the user themselves when they leave. RecipientIDs are everyone else to tell: the remaining
+// members plus those who left.
+type MembershipChange struct {
+	ConversationID int64
+	ActorID        int64
+	UserIDs        []int64
+	Joined         bool
+	RecipientIDs   []int64
+	At             time.Time
+}
+
+// CreateGroup opens a group conversation owned by the coach, who becomes its first admin. Members
+// must be clients the coach actively coaches.
+func (s *ChatService) CreateGroup(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	title string,
+	memberIDs []int64,
+) (*models.Conversation, *MembershipChange, error) {
+	if role != "coach" {
+		return nil, nil, ErrForbidden
+	}
+	title = strings.TrimSpace(title)
+	if title == "" || utf8.RuneCountInString(title) > maxGroupTitleLength {
+		return nil, nil, ErrInvalidInput
+	}
+	memberIDs = uniqueMemberIDs(memberIDs, actorID)
+	if len(memberIDs) == 0 || len(memberIDs)+1 > maxGroupMembers {
+		return nil, nil, ErrInvalidInput
+	}
+	if err := s.checkGroupCandidates(ctx, actorID, memberIDs); err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txConversationRepo := repository.NewConversationRepository(tx)
+	conversation, err := txConversationRepo.CreateGroup(ctx, actorID, title)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := txConversationRepo.AddMember(ctx, conversation.ID, actorID, models.ConversationRoleAdmin); err != nil {
+		return nil, nil, err
+	}
+	for _, memberID := range memberIDs {
+		if _, err := txConversationRepo.AddMember(ctx, conversation.ID, memberID, models.ConversationRoleMember); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	return conversation, &MembershipChange{
+		ConversationID: conversation.ID,
+		ActorID:        actorID,
+		UserIDs:        memberIDs,
+		Joined:         true,
+		RecipientIDs:   memberIDs,
+		At:             conversation.CreatedAt,
+	}, nil
+}
+
+// ListGroupMembers returns the current members of a group the actor belongs to.
+func (s *ChatService) ListGroupMembers(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+) ([]models.ConversationMember, error) {
+	if _, _, err := s.groupMembership(ctx, actorID, role, conversationID); err != nil {
+		return nil, err
+	}
+	return s.conversationRepo.ListMembers(ctx, conversationID)
+}
+
+// AddGroupMembers adds clients of the group's coach to a group; only admins can. Users who are
+// already members are skipped. It returns nil when nobody new joined.
+func (s *ChatService) AddGroupMembers(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	userIDs []int64,
+) (*MembershipChange, error) {
+	conversation, member, err := s.groupMembership(ctx, actorID, role, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role != models.ConversationRoleAdmin {
+		return nil, ErrForbidden
+	}
+	userIDs = uniqueMemberIDs(userIDs, conversation.CoachID)
+	if len(userIDs) == 0 {
+		return nil, ErrInvalidInput
+	}
+	if err := s.checkGroupCandidates(ctx, conversation.CoachID, userIDs); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txConversationRepo := repository.NewConversationRepository(tx)
+	joined := make([]int64, 0, len(userIDs))
+	for _, userID := range userIDs {
+		added, err := txConversationRepo.AddMember(ctx, conversationID, userID, models.ConversationRoleMember)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			joined = append(joined, userID)
+		}
+	}
+	memberIDs, err := txConversationRepo.ListMemberIDs(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberIDs) > maxGroupMembers {
+		return nil, ErrInvalidInput
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	if len(joined) == 0 {
+		return nil, nil
+	}
+
+	return &MembershipChange{
+		ConversationID: conversationID,
+		ActorID:        actorID,
+		UserIDs:        joined,
+		Joined:         true,
+		RecipientIDs:   withoutMember(memberIDs, actorID),
+		At:             time.Now().UTC(),
+	}, nil
+}
+
+// RemoveGroupMember takes a member out of a group. Admins can remove anyone but the coach who owns
+// the group, and every member except that coach can leave by removing themselves.
+func (s *ChatService) RemoveGroupMember(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	userID int64,
+) (*MembershipChange, error) {
+	conversation, member, err := s.groupMembership(ctx, actorID, role, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if userID == conversation.CoachID {
+		return nil, ErrForbidden
+	}
+	if userID != actorID && member.Role != models.ConversationRoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	removed, err := s.conversationRepo.RemoveMember(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, ErrMemberNotFound
+	}
+	memberIDs, err := s.conversationRepo.ListMemberIDs(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if userID != actorID {
+		memberIDs = append(memberIDs, userID)
+	}
+
+	return &MembershipChange{
+		ConversationID: conversationID,
+		ActorID:        actorID,
+		UserIDs:        []int64{userID},
+		RecipientIDs:   withoutMember(memberIDs, actorID),
+		At:             time.Now().UTC(),
+	}, nil
+}
+
+// SetGroupMemberRole makes a member an admin or a plain member; only admins can. The role of the
+// coach who owns the group cannot change.
+func (s *ChatService) SetGroupMemberRole(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	userID int64,
+	memberRole string,
+) (*models.ConversationMember, error) {
+	if memberRole != models.ConversationRoleAdmin && memberRole != models.ConversationRoleMember {
+		return nil, ErrInvalidInput
+	}
+	conversation, member, err := s.groupMembership(ctx, actorID, role, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role != models.ConversationRoleAdmin || userID == conversation.CoachID {
+		return nil, ErrForbidden
+	}
+	updated, err := s.conversationRepo.UpdateMemberRole(ctx, conversationID, userID, memberRole)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	return updated, err
+}
+
+// groupMembership returns a group the actor belongs to and their membership. Direct conversations
+// have no members to manage.
+func (s *ChatService) groupMembership(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+) (*models.Conversation, *models.ConversationMember, error) {
+	if role != "user" && role != "coach" {
+		return nil, nil, ErrForbidden
+	}
+	if conversationID <= 0 {
+		return nil, nil, ErrInvalidInput
+	}
+
+	conversation, err := s.conversationRepo.GetByIDForParticipant(ctx, conversationID, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if conversation.Kind != models.ConversationKindGroup {
+		return nil, nil, ErrInvalidInput
+	}
+	member, err := s.conversationRepo.GetMember(ctx, conversationID, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conversation, member, nil
+}
+
+// checkGroupCandidates checks that every user is a client the coach actively coaches.
+func (s *ChatService) checkGroupCandidates(ctx context.Context, coachID int64, userIDs []int64) error {
+	for _, userID := range userIDs {
+		if _, err := activeRelationship(ctx, s.userRepo, s.coachingRepo, coachID, userID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrInvalidInput
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// uniqueMemberIDs drops duplicates, non-positive ids and the excluded user, keeping the order.
+func uniqueMemberIDs(userIDs []int64, excludeID int64) []int64 {
+	unique := make([]int64, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userID > 0 && userID != excludeID && !slices.Contains(unique, userID) {
+			unique = append(unique, userID)
+		}
+	}
+	return unique
+}
+
+func withoutMember(memberIDs []int64, userID int64) []int64 {
+	return slices.DeleteFunc(memberIDs, func(memberID int64) bool {
+		return memberID == userID
+	})
+}
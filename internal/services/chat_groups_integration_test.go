@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+func TestGroupMembersReadFullHistoryAfterJoiningAndRejoining(t *testing.T) {
+	ctx := context.Background()
+	pool := integrationTestPool(t)
+	service := newIntegrationChatService(pool)
+
+	coachID := createTestAccount(t, ctx, pool, "coach", 100)
+	firstID := createTestAccount(t, ctx, pool, "user", 0)
+	laterID := createTestAccount(t, ctx, pool, "user", 0)
+	t.Cleanup(func() {
+		if _, err := pool.Exec(ctx, "DELETE FROM conversations WHERE coach_id = $1", coachID); err != nil {
+			t.Fatalf("cleanup conversations: %v", err)
+		}
+		cleanupTestUsers(t, ctx, pool, coachID, firstID, laterID)
+	})
+	coachingRepo := repository.NewCoachingRepository(pool)
+	for _, userID := range []int64{firstID, laterID} {
+		if _, err := coachingRepo.Activate(ctx, coachID, userID); err != nil {
+			t.Fatalf("Activate: %v", err)
+		}
+	}
+
+	group, _, err := service.CreateGroup(ctx, coachID, "coach", "Cohort", []int64{firstID})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	send := func(content string) int64 {
+		t.Helper()
+		delivery, err := service.SendMessage(ctx, coachID, "coach", group.ID, content)
+		if err != nil {
+			t.Fatalf("SendMessage %q: %v", content, err)
+		}
+		return delivery.Message.ID
+	}
+	history := func() []int64 {
+		t.Helper()
+		messages, _, err := service.ListMessagesByCursor(ctx, laterID, "user", group.ID, MessageCursor{}, 50)
+		if err != nil {
+			t.Fatalf("ListMessagesByCursor: %v", err)
+		}
+		ids := make([]int64, 0, len(messages))
+		for _, message := range messages {
+			ids = append(ids, message.ID)
+		}
+		return ids
+	}
+
+	beforeJoin := send("welcome")
+	if _, err := service.AddGroupMembers(ctx, coachID, "coach", group.ID, []int64{laterID}); err != nil {
+		t.Fatalf("AddGroupMembers: %v", err)
+	}
+	if ids := history(); !slices.Equal(ids, []int64{beforeJoin}) {
+		t.Fatalf("expected a new member to see earlier messages, got %v", ids)
+	}
+
+	if _, err := service.RemoveGroupMember(ctx, laterID, "user", group.ID, laterID); err != nil {
+		t.Fatalf("RemoveGroupMember: %v", err)
+	}
+	whileAway := send("week two")
+	if _, err := service.AddGroupMembers(ctx, coachID, "coach", group.ID, []int64{laterID}); err != nil {
+		t.Fatalf("AddGroupMembers again: %v", err)
+	}
+	if ids := history(); !slices.Equal(ids, []int64{whileAway, beforeJoin}) {
+		t.Fatalf("expected a returning member to see the full history, got %v", ids)
+	}
+}
+
+func newIntegrationChatService(pool *pgxpool.Pool) *ChatService {
+	return NewChatService(
+		pool,
+		repository.NewConversationRepository(pool),
+		repository.NewMessageRepository(pool),
+		repository.NewSubscriptionRepository(pool),
+		repository.NewCoachingRepository(pool),
+		repository.NewUserRepository(pool),
+		nil,
+		repository.NewModerationRepository(pool),
+		nil,
+	)
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+func TestUniqueMemberIDs(t *testing.T) {
+	got := uniqueMemberIDs([]int64{42, 7, 43, 42, 0, -1, 44}, 7)
+	if want := []int64{42, 43, 44}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCreateGroupValidatesBeforeLookups(t *testing.T) {
+	service := &ChatService{}
+	cases := []struct {
+		name      string
+		role      string
+		title     string
+		memberIDs []int64
+		want      error
+	}{
+		{name: "client", role: "user", title: "Cohort", memberIDs: []int64{42}, want: ErrForbidden},
+		{name: "blank title", role: "coach", title: "  ", memberIDs: []int64{42}, want: ErrInvalidInput},
+		{name: "long title", role: "coach", title: strings.Repeat("a", maxGroupTitleLength+1), memberIDs: []int64{42}, want: ErrInvalidInput},
+		{name: "only the coach", role: "coach", title: "Cohort", memberIDs: []int64{7}, want: ErrInvalidInput},
+		{name: "too many", role: "coach", title: "Cohort", memberIDs: sequentialIDs(100, maxGroupMembers), want: ErrInvalidInput},
+	}
+	for _, tc := range cases {
+		if _, _, err := service.CreateGroup(context.Background(), 7, tc.role, tc.title, tc.memberIDs); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestPeersOfDirectConversation(t *testing.T) {
+	service := &ChatService{}
+	conversation := &models.Conversation{ID: 9, Kind: models.ConversationKindDirect, UserID: 42, CoachID: 7}
+
+	for actorID, want := range map[int64]int64{42: 7, 7: 42} {
+		peers, err := service.peersOf(context.Background(), conversation, actorID)
+		if err != nil || !slices.Equal(peers, []int64{want}) {
+			t.Fatalf("actor %d: got %v %v, want [%d]", actorID, peers, err, want)
+		}
+	}
+}
+
+func sequentialIDs(first int64, count int) []int64 {
+	ids := make([]int64, count)
+	for i := range ids {
+		ids[i] = first + int64(i)
+	}
+	return ids
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	// messageEditWindow is how long after sending a message its sender may still edit it.
+	messageEditWindow = 15 * time.Minute
+	maxReactionBytes  = 32
+)
+
+var (
+	ErrMessageNotFound   = errors.New("message not found")
+	ErrMessageRemoved    = errors.New("message has been removed")
+	ErrEditWindowExpired = errors.New("message can no longer be edited")
+)
+
+// ReactionChange is a reaction added, replaced or removed by UserID. Emoji is empty on removal;
+// RecipientIDs are the other participants.
+type ReactionChange struct {
+	ConversationID int64
+	MessageID      int64
+	UserID         int64
+	RecipientIDs   []int64
+	Emoji          string
+	At             time.Time
+}
+
+// checkMessageEdit reports why the actor may not edit the message, if they may not.
+func checkMessageEdit(message *models.ChatMessage, actorID int64, now time.Time) error {
+	if message.SenderID != actorID {
+		return ErrForbidden
+	}
+	if message.DeletedAt != nil {
+		return ErrMessageRemoved
+	}
+	if now.Sub(message.CreatedAt) > messageEditWindow {
+		return ErrEditWindowExpired
+	}
+	return nil
+}
+
+// validReactionEmoji accepts a single short emoji sequence. It does not check the sequence against
+// the Unicode emoji list; it only keeps out words, whitespace and control characters.
+func validReactionEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > maxReactionBytes || !utf8.ValidString(emoji) {
+		return false
+	}
+	hasSymbol := false
+	for _, r := range emoji {
+		switch {
+		case unicode.IsSpace(r), unicode.IsControl(r), unicode.IsLetter(r) && r < utf8.RuneSelf:
+			return false
+		case r >= utf8.RuneSelf:
+			hasSymbol = true
+		}
+	}
+	return hasSymbol
+}
+
+// EditMessage replaces the content of one of the actor's own messages within the edit window. The
+// previous content is kept in the edit history.
+func (s *ChatService) EditMessage(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	messageID int64,
+	content string,
+) (*ChatDelivery, error) {
+	if role != "user" && role != "coach" {
+		return nil, ErrForbidden
+	}
+	trimmed := strings.TrimSpace(content)
+	if conversationID <= 0 || messageID <= 0 || trimmed == "" {
+		return nil, ErrInvalidInput
+	}
+
+	conversation, recipientIDs, err := s.authorizeSend(ctx, actorID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txMessageRepo := repository.NewMessageRepository(tx)
+	message, err := txMessageRepo.GetForUpdate(ctx, conversationID, messageID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if err := checkMessageEdit(message, actorID, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	moderated, err := s.moderate(ctx, conversationID, actorID, trimmed)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.Content != moderated.Text {
+		message, err = txMessageRepo.UpdateContent(ctx, messageID, message.Content, moderated.Text)
+		if err != nil {
+			return nil, err
+		}
+		if err := queueModeration(ctx, repository.NewModerationRepository(tx), message, moderated); err != nil {
+			return nil, err
+		}
+	}
+
+	messages := []models.ChatMessage{*message}
+	if err := s.decorateMessages(ctx, txMessageRepo, messages); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &ChatDelivery{
+		Conversation: conversation,
+		Message:      &messages[0],
+		RecipientIDs: recipientIDs,
+	}, nil
+}
+
+// DeleteMessage turns one of the actor's own messages into a "message removed" tombstone.
+func (s *ChatService) DeleteMessage(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	messageID int64,
+) (*ChatDelivery, error) {
+	if messageID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	recipientIDs, err := s.ConversationPeers(ctx, actorID, role, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txMessageRepo := repository.NewMessageRepository(tx)
+	message, err := txMessageRepo.GetForUpdate(ctx, conversationID, messageID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if message.SenderID != actorID {
+		return nil, ErrForbidden
+	}
+	if message.DeletedAt != nil {
+		return nil, ErrMessageRemoved
+	}
+
+	message, err = txMessageRepo.SoftDelete(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &ChatDelivery{Message: message, RecipientIDs: recipientIDs}, nil
+}
+
+// ListMessageEdits returns the earlier versions of a message to either participant.
+func (s *ChatService) ListMessageEdits(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	messageID int64,
+) ([]models.MessageEdit, error) {
+	if _, err := s.visibleMessage(ctx, actorID, role, conversationID, messageID); err != nil {
+		return nil, err
+	}
+	return s.messageRepo.ListEdits(ctx, messageID)
+}
+
+// SetReaction sets the actor's reaction to a message, replacing the one they had.
+func (s *ChatService) SetReaction(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	messageID int64,
+	emoji string,
+) (*ReactionChange, error) {
+	emoji = strings.TrimSpace(emoji)
+	if !validReactionEmoji(emoji) {
+		return nil, ErrInvalidInput
+	}
+	recipientIDs, err := s.visibleMessage(ctx, actorID, role, conversationID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	reaction, err := s.messageRepo.SetReaction(ctx, messageID, actorID, emoji)
+	if err != nil {
+		return nil, err
+	}
+	return &ReactionChange{
+		ConversationID: conversationID,
+		MessageID:      messageID,
+		UserID:         actorID,
+		RecipientIDs:   recipientIDs,
+		Emoji:          reaction.Emoji,
+		At:             reaction.CreatedAt,
+	}, nil
+}
+
+// RemoveReaction removes the actor's reaction to a message. It returns nil when there was none.
+func (s *ChatService) RemoveReaction(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	messageID int64,
+) (*ReactionChange, error) {
+	recipientIDs, err := s.visibleMessage(ctx, actorID, role, conversationID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := s.messageRepo.DeleteReaction(ctx, messageID, actorID)
+	if err != nil || !removed {
+		return nil, err
+	}
+	return &ReactionChange{
+		ConversationID: conversationID,
+		MessageID:      messageID,
+		UserID:         actorID,
+		RecipientIDs:   recipientIDs,
+		At:             time.Now().UTC(),
+	}, nil
+}
+
+// visibleMessage checks that the actor takes part in the conversation and that the message is in it
+// and not removed. It returns the other participants.
+func (s *ChatService) visibleMessage(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	messageID int64,
+) ([]int64, error) {
+	if messageID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	recipientIDs, err := s.ConversationPeers(ctx, actorID, role, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := s.messageRepo.Get(ctx, conversationID, messageID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if message.DeletedAt != nil {
+		return nil, ErrMessageRemoved
+	}
+	return recipientIDs, nil
+}
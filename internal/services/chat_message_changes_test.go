@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+func TestCheckMessageEdit(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	removedAt := now.Add(-time.Minute)
+	cases := []struct {
+		name    string
+		message models.ChatMessage
+		actorID int64
+		want    error
+	}{
+		{name: "own recent message", message: models.ChatMessage{SenderID: 7, CreatedAt: now.Add(-time.Minute)}, actorID: 7},
+		{name: "other participant", message: models.ChatMessage{SenderID: 8, CreatedAt: now.Add(-time.Minute)}, actorID: 7, want: ErrForbidden},
+		{name: "removed", message: models.ChatMessage{SenderID: 7, CreatedAt: now.Add(-2 * time.Minute), DeletedAt: &removedAt}, actorID: 7, want: ErrMessageRemoved},
+		{name: "window expired", message: models.ChatMessage{SenderID: 7, CreatedAt: now.Add(-messageEditWindow - time.Second)}, actorID: 7, want: ErrEditWindowExpired},
+	}
+	for _, tc := range cases {
+		if err := checkMessageEdit(&tc.message, tc.actorID, now); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestValidReactionEmoji(t *testing.T) {
+	valid := []string{"👍", "💪🏽", "❤️", "👨‍👩‍👧", "1️⃣"}
+	for _, emoji := range valid {
+		if !validReactionEmoji(emoji) {
+			t.Fatalf("expected %q to be accepted", emoji)
+		}
+	}
+	invalid := []string{"", "ok", "👍 👍", "1", "\u0007", strings.Repeat("👍", 9)}
+	for _, emoji := range invalid {
+		if validReactionEmoji(emoji) {
+			t.Fatalf("expected %q to be rejected", emoji)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const maxReportDetailsLength = 1000
+
+var (
+	ErrMessageBlocked = errors.New("message was blocked by moderation")
+	ErrUserBlocked    = errors.New("messaging is blocked between these users")
+	ErrUserNotFound   = errors.New("user not found")
+	ErrNotBlocked     = errors.New("user is not blocked")
+)
+
+var reportReasons = []string{"spam", "harassment", "contact_info", "off_platform_payment", "inappropriate", "other"}
+
+// moderatedContent is content that went through the moderation pipeline: Text is what to store,
+// Original is what the sender wrote, and Verdict is nil when no rule matched.
+type moderatedContent struct {
+	Text     string
+	Original string
+	Verdict  *ModerationVerdict
+}
+
+// moderate runs the pipeline over content the actor is about to post. Blocked content is queued for
+// review and never stored as a message; anything else comes back ready to store.
+func (s *ChatService) moderate(
+	ctx context.Context,
+	conversationID int64,
+	actorID int64,
+	content string,
+) (moderatedContent, error) {
+	moderated := moderatedContent{Text: content, Original: content, Verdict: s.moderator.Moderate(content)}
+	if moderated.Verdict == nil {
+		return moderated, nil
+	}
+	if moderated.Verdict.Action == ModerationActionBlock {
+		if _, err := s.moderationRepo.CreateItem(ctx, filterItem(conversationID, actorID, nil, moderated)); err != nil {
+			return moderatedContent{}, err
+		}
+		return moderatedContent{}, ErrMessageBlocked
+	}
+	moderated.Text = moderated.Verdict.Content
+	return moderated, nil
+}
+
+// queueModeration puts a stored message that a rule matched in the moderation queue.
+func queueModeration(
+	ctx context.Context,
+	moderationRepo *repository.ModerationRepository,
+	message *models.ChatMessage,
+	moderated moderatedContent,
+) error {
+	if moderated.Verdict == nil {
+		return nil
+	}
+	_, err := moderationRepo.CreateItem(ctx, filterItem(message.ConversationID, message.SenderID, &message.ID, moderated))
+	return err
+}
+
+func filterItem(
+	conversationID int64,
+	senderID int64,
+	messageID *int64,
+	moderated moderatedContent,
+) repository.ModerationItemInput {
+	action := moderated.Verdict.Action
+	return repository.ModerationItemInput{
+		Source:         models.ModerationSourceFilter,
+		ConversationID: conversationID,
+		MessageID:      messageID,
+		SenderID:       &senderID,
+		Content:        moderated.Original,
+		Rules:          moderated.Verdict.Rules,
+		Action:         &action,
+	}
+}
+
+// checkBlocked refuses a direct conversation in which either participant blocked the other. Blocks
+// do not apply to groups, which the coach moderates.
+func (s *ChatService) checkBlocked(ctx context.Context, conversation *models.Conversation) error {
+	if conversation.Kind == models.ConversationKindGroup {
+		return nil
+	}
+	blocked, err := s.moderationRepo.IsBlockedBetween(ctx, conversation.UserID, conversation.CoachID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrUserBlocked
+	}
+	return nil
+}
+
+// ReportConversation files a report about a conversation the actor takes part in, or about one
+// message in it when messageID is set, for admins to review.
+func (s *ChatService) ReportConversation(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	messageID *int64,
+	reason string,
+	details string,
+) (*models.ModerationItem, error) {
+	details = strings.TrimSpace(details)
+	if !slices.Contains(reportReasons, reason) || utf8.RuneCountInString(details) > maxReportDetailsLength {
+		return nil, ErrInvalidInput
+	}
+	if messageID != nil && *messageID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	conversation, err := s.participantConversation(ctx, actorID, role, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	input := repository.ModerationItemInput{
+		Source:         models.ModerationSourceReport,
+		ConversationID: conversationID,
+		MessageID:      messageID,
+		ReporterID:     &actorID,
+		Reason:         &reason,
+	}
+	if details != "" {
+		input.Details = &details
+	}
+	if messageID != nil {
+		message, err := s.messageRepo.Get(ctx, conversationID, *messageID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, ErrMessageNotFound
+			}
+			return nil, err
+		}
+		if message.DeletedAt != nil {
+			return nil, ErrMessageRemoved
+		}
+		if message.SenderID == actorID {
+			return nil, ErrInvalidInput
+		}
+		input.SenderID = &message.SenderID
+		input.Content = message.Content
+	} else if conversation.Kind != models.ConversationKindGroup {
+		peerID := conversation.CoachID
+		if actorID == conversation.CoachID {
+			peerID = conversation.UserID
+		}
+		input.SenderID = &peerID
+	}
+
+	item, err := s.moderationRepo.CreateItem(ctx, input)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrConflict
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+// BlockUser stops another user from exchanging direct messages with the actor, in both directions,
+// until the actor unblocks them.
+func (s *ChatService) BlockUser(ctx context.Context, actorID int64, role string, userID int64) error {
+	if role != "user" && role != "coach" {
+		return ErrForbidden
+	}
+	if userID <= 0 || userID == actorID {
+		return ErrInvalidInput
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return s.moderationRepo.Block(ctx, actorID, userID)
+}
+
+func (s *ChatService) UnblockUser(ctx context.Context, actorID int64, role string, userID int64) error {
+	if role != "user" && role != "coach" {
+		return ErrForbidden
+	}
+	if userID <= 0 {
+		return ErrInvalidInput
+	}
+	unblocked, err := s.moderationRepo.Unblock(ctx, actorID, userID)
+	if err != nil {
+		return err
+	}
+	if !unblocked {
+		return ErrNotBlocked
+	}
+	return nil
+}
+
+func (s *ChatService) ListBlockedUsers(ctx context.Context, actorID int64, role string) ([]models.UserBlock, error) {
+	if role != "user" && role != "coach" {
+		return nil, ErrForbidden
+	}
+	return s.moderationRepo.ListBlocked(ctx, actorID)
+}
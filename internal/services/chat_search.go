@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const maxChatSearchQueryLen = 200
+
+// SearchMessages runs a full-text search over the messages in the actor's conversations, newest
+// first, optionally within one conversation. Pass the id of the last result as beforeID for the
+// next page; the flag reports whether there is one.
+func (s *ChatService) SearchMessages(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	query string,
+	conversationID int64,
+	beforeID int64,
+	limit int,
+) ([]models.ChatSearchResult, bool, error) {
+	if role != "user" && role != "coach" {
+		return nil, false, ErrForbidden
+	}
+	query = strings.TrimSpace(query)
+	if query == "" || utf8.RuneCountInString(query) > maxChatSearchQueryLen ||
+		conversationID < 0 || beforeID < 0 || limit <= 0 {
+		return nil, false, ErrInvalidInput
+	}
+
+	results, err := s.messageRepo.Search(ctx, repository.MessageSearchFilter{
+		ParticipantID:  actorID,
+		Query:          query,
+		ConversationID: conversationID,
+		BeforeID:       beforeID,
+		Limit:          limit + 1,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(results) > limit
+	if hasMore {
+		results = results[:limit]
+	}
+	for i := range results {
+		results[i].Snippet = highlightSnippet(results[i].Snippet)
+	}
+	return results, hasMore, nil
+}
+
+// highlightSnippet escapes a search headline for HTML and turns its match markers into <mark> tags,
+// so message text can never inject markup.
+func highlightSnippet(raw string) string {
+	escaped := html.EscapeString(raw)
+	escaped = strings.ReplaceAll(escaped, repository.SearchMatchStart, "<mark>")
+	return strings.ReplaceAll(escaped, repository.SearchMatchStop, "</mark>")
+}
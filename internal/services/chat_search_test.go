@@ -0,0 +1,11 @@
+package services
+
+import "testing"
+
+func TestHighlightSnippet(t *testing.T) {
+	raw := "Try the <b>new</b> \x02squat\x03 cue & keep \x02squatting\x03"
+	want := "Try the &lt;b&gt;new&lt;/b&gt; <mark>squat</mark> cue &amp; keep <mark>squatting</mark>"
+	if got := highlightSnippet(raw); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
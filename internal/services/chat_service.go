@@ -16,26 +16,112 @@ type ChatService struct {
 	db               *pgxpool.Pool
 	conversationRepo *repository.ConversationRepository
 	messageRepo      *repository.MessageRepository
+	subscriptionRepo *repository.SubscriptionRepository
+	coachingRepo     *repository.CoachingRepository
 	userRepo         userReader
+	storageService   StorageService
+	moderationRepo   *repository.ModerationRepository
+	moderator        *ModerationPipeline
 }
 
+// ChatDelivery is a stored message and the participants, other than its sender, to deliver it to.
 type ChatDelivery struct {
 	Conversation *models.Conversation
 	Message      *models.ChatMessage
-	RecipientID  int64
+	RecipientIDs []int64
 }
 
+// authorizeSend checks that the actor may post in the conversation and returns the recipients.
+// Clients need a chat entitlement with the coach, in groups too; coaches can always post, except to
+// a client who blocked them or whom they blocked.
+func (s *ChatService) authorizeSend(
+	ctx context.Context,
+	actorID int64,
+	conversationID int64,
+) (*models.Conversation, []int64, error) {
+	conversation, err := s.conversationRepo.GetByIDForParticipant(ctx, conversationID, actorID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrForbidden
+		}
+		return nil, nil, err
+	}
+
+	if err := s.checkBlocked(ctx, conversation); err != nil {
+		return nil, nil, err
+	}
+	if actorID != conversation.CoachID {
+		if err := checkChatEntitlement(
+			ctx,
+			s.subscriptionRepo,
+			actorID,
+			conversation.CoachID,
+			time.Now().UTC(),
+		); err != nil {
+			return nil, nil, err
+		}
+	}
+	recipientIDs, err := s.peersOf(ctx, conversation, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conversation, recipientIDs, nil
+}
+
+// peersOf returns the participants of the conversation other than the actor.
+func (s *ChatService) peersOf(ctx context.Context, conversation *models.Conversation, actorID int64) ([]int64, error) {
+	if conversation.Kind != models.ConversationKindGroup {
+		if actorID == conversation.UserID {
+			return []int64{conversation.CoachID}, nil
+		}
+		return []int64{conversation.UserID}, nil
+	}
+
+	memberIDs, err := s.conversationRepo.ListMemberIDs(ctx, conversation.ID)
+	if err != nil {
+		return nil, err
+	}
+	peers := make([]int64, 0, len(memberIDs))
+	for _, memberID := range memberIDs {
+		if memberID != actorID {
+			peers = append(peers, memberID)
+		}
+	}
+	return peers, nil
+}
+
+// ReadReceipt lists the messages a reader has just read; RecipientIDs are the other participants.
+type ReadReceipt struct {
+	ConversationID int64
+	ReaderID       int64
+	RecipientIDs   []int64
+	MessageIDs     []int64
+	ReadAt         time.Time
+}
+
+const maxReadReceiptMessages = 100
+
 func NewChatService(
 	db *pgxpool.Pool,
 	conversationRepo *repository.ConversationRepository,
 	messageRepo *repository.MessageRepository,
+	subscriptionRepo *repository.SubscriptionRepository,
+	coachingRepo *repository.CoachingRepository,
 	userRepo userReader,
+	storageService StorageService,
+	moderationRepo *repository.ModerationRepository,
+	moderator *ModerationPipeline,
 ) *ChatService {
 	return &ChatService{
 		db:               db,
 		conversationRepo: conversationRepo,
 		messageRepo:      messageRepo,
+		subscriptionRepo: subscriptionRepo,
+		coachingRepo:     coachingRepo,
 		userRepo:         userRepo,
+		storageService:   storageService,
+		moderationRepo:   moderationRepo,
+		moderator:        moderator,
 	}
 }
 
@@ -51,20 +137,28 @@ func (s *ChatService) ListConversations(
 	return s.conversationRepo.ListForParticipant(ctx, actorID)
 }
 
+// CreateConversation opens (or returns) the chat with participantID. Clients can reach any coach;
+// coaches can only start chats with clients they actively coach.
 func (s *ChatService) CreateConversation(
 	ctx context.Context,
 	actorID int64,
 	role string,
-	coachID int64,
+	participantID int64,
 ) (*models.Conversation, error) {
-	if role != "user" {
+	if role != "user" && role != "coach" {
 		return nil, ErrForbidden
 	}
-	if coachID <= 0 || coachID == actorID {
+	if participantID <= 0 || participantID == actorID {
 		return nil, ErrInvalidInput
 	}
+	if role == "coach" {
+		if _, err := activeRelationship(ctx, s.userRepo, s.coachingRepo, actorID, participantID); err != nil {
+			return nil, err
+		}
+		return s.conversationRepo.CreateOrGet(ctx, participantID, actorID)
+	}
 
-	coach, err := s.userRepo.GetByID(ctx, coachID)
+	coach, err := s.userRepo.GetByID(ctx, participantID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrCoachNotFound
@@ -75,7 +169,7 @@ func (s *ChatService) CreateConversation(
 		return nil, ErrInvalidInput
 	}
 
-	return s.conversationRepo.CreateOrGet(ctx, actorID, coachID)
+	return s.conversationRepo.CreateOrGet(ctx, actorID, participantID)
 }
 
 func (s *ChatService) ListMessages(
@@ -93,17 +187,80 @@ func (s *ChatService) ListMessages(
 		return nil, 0, ErrInvalidInput
 	}
 
-	_, err := s.conversationRepo.GetByIDForParticipant(ctx, conversationID, actorID)
+	var total int
+	messages, err := s.readHistory(ctx, actorID, conversationID, func(messageRepo *repository.MessageRepository) ([]models.ChatMessage, error) {
+		messages, count, err := messageRepo.ListByConversation(ctx, conversationID, limit, (page-1)*limit)
+		total = count
+		return messages, err
+	})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, 0, pgx.ErrNoRows
-		}
 		return nil, 0, err
 	}
+	return messages, total, nil
+}
+
+// MessageCursor selects history relative to a message id: Before pages back to older messages and
+// After catches up on newer ones. With neither set, the latest messages are returned.
+type MessageCursor struct {
+	Before int64
+	After  int64
+}
+
+// ListMessagesByCursor pages through history by message id, which stays stable while new messages
+// arrive. Messages come newest first; the flag reports more in the direction paged.
+func (s *ChatService) ListMessagesByCursor(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	cursor MessageCursor,
+	limit int,
+) ([]models.ChatMessage, bool, error) {
+	if role != "user" && role != "coach" {
+		return nil, false, ErrForbidden
+	}
+	if conversationID <= 0 || limit <= 0 || cursor.Before < 0 || cursor.After < 0 ||
+		(cursor.Before > 0 && cursor.After > 0) {
+		return nil, false, ErrInvalidInput
+	}
+
+	hasMore := false
+	messages, err := s.readHistory(ctx, actorID, conversationID, func(messageRepo *repository.MessageRepository) ([]models.ChatMessage, error) {
+		messages, err := messageRepo.ListByCursor(ctx, conversationID, cursor.Before, cursor.After, limit+1)
+		if err != nil || len(messages) <= limit {
+			return messages, err
+		}
+		hasMore = true
+		// The extra message is the one furthest from the cursor.
+		if cursor.After > 0 {
+			return messages[1:], nil
+		}
+		return messages[:limit], nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return messages, hasMore, nil
+}
+
+// readHistory loads a page of a conversation the actor takes part in, marks the received messages
+// on it as read, and adds their attachments and reactions. In groups, reading moves the actor's
+// read position up to the newest message on the page. Group members see the full history, not only
+// what was sent since they joined.
+func (s *ChatService) readHistory(
+	ctx context.Context,
+	actorID int64,
+	conversationID int64,
+	fetch func(messageRepo *repository.MessageRepository) ([]models.ChatMessage, error),
+) ([]models.ChatMessage, error) {
+	conversation, err := s.conversationRepo.GetByIDForParticipant(ctx, conversationID, actorID)
+	if err != nil {
+		return nil, err
+	}
 
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer func() {
 		_ = tx.Rollback(ctx)
@@ -111,23 +268,30 @@ func (s *ChatService) ListMessages(
 
 	txMessageRepo := repository.NewMessageRepository(tx)
 
-	messages, total, err := txMessageRepo.ListByConversation(
-		ctx,
-		conversationID,
-		limit,
-		(page-1)*limit,
-	)
+	messages, err := fetch(txMessageRepo)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	messageIDs := make([]int64, 0, len(messages))
+	newestID := int64(0)
 	for _, message := range messages {
 		messageIDs = append(messageIDs, message.ID)
+		newestID = max(newestID, message.ID)
 	}
 
-	if err := txMessageRepo.MarkMessagesRead(ctx, messageIDs, actorID); err != nil {
-		return nil, 0, err
+	if conversation.Kind == models.ConversationKindGroup {
+		if newestID > 0 {
+			if _, err := repository.NewConversationRepository(tx).AdvanceReadPosition(ctx, conversationID, actorID, newestID); err != nil {
+				return nil, err
+			}
+		}
+	} else if _, err := txMessageRepo.MarkMessagesRead(ctx, conversationID, messageIDs, actorID); err != nil {
+		return nil, err
+	}
+
+	if err := s.decorateMessages(ctx, txMessageRepo, messages); err != nil {
+		return nil, err
 	}
 
 	for i := range messages {
@@ -137,10 +301,56 @@ func (s *ChatService) ListMessages(
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	return messages, total, nil
+	return messages, nil
+}
+
+// decorateMessages loads the attachments and reactions of a page of messages, skipping tombstones.
+// Attachment URLs are only signed when storage is configured; the metadata is returned either way.
+func (s *ChatService) decorateMessages(
+	ctx context.Context,
+	messageRepo *repository.MessageRepository,
+	messages []models.ChatMessage,
+) error {
+	messageIDs := make([]int64, 0, len(messages))
+	for _, message := range messages {
+		if message.DeletedAt == nil {
+			messageIDs = append(messageIDs, message.ID)
+		}
+	}
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	attachments, err := messageRepo.ListAttachments(ctx, messageIDs)
+	if err != nil {
+		return err
+	}
+	if s.storageService != nil {
+		if err := s.signAttachments(ctx, attachments); err != nil {
+			return err
+		}
+	}
+	reactions, err := messageRepo.ListReactions(ctx, messageIDs)
+	if err != nil {
+		return err
+	}
+
+	attachmentsByMessage := make(map[int64][]models.MessageAttachment, len(attachments))
+	for _, attachment := range attachments {
+		attachmentsByMessage[attachment.MessageID] = append(attachmentsByMessage[attachment.MessageID], attachment)
+	}
+	reactionsByMessage := make(map[int64][]models.MessageReaction, len(reactions))
+	for _, reaction := range reactions {
+		reactionsByMessage[reaction.MessageID] = append(reactionsByMessage[reaction.MessageID], reaction)
+	}
+	for i := range messages {
+		messages[i].Attachments = attachmentsByMessage[messages[i].ID]
+		messages[i].Reactions = reactionsByMessage[messages[i].ID]
+	}
+	return nil
 }
 
 func (s *ChatService) SendMessage(
@@ -162,17 +372,13 @@ func (s *ChatService) SendMessage(
 		return nil, ErrInvalidInput
 	}
 
-	conversation, err := s.conversationRepo.GetByIDForParticipant(ctx, conversationID, actorID)
+	conversation, recipientIDs, err := s.authorizeSend(ctx, actorID, conversationID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrForbidden
-		}
 		return nil, err
 	}
-
-	recipientID := conversation.UserID
-	if actorID == conversation.UserID {
-		recipientID = conversation.CoachID
+	moderated, err := s.moderate(ctx, conversationID, actorID, trimmed)
+	if err != nil {
+		return nil, err
 	}
 
 	tx, err := s.db.Begin(ctx)
@@ -186,10 +392,22 @@ func (s *ChatService) SendMessage(
 	txMessageRepo := repository.NewMessageRepository(tx)
 	txConversationRepo := repository.NewConversationRepository(tx)
 
-	message, err := txMessageRepo.Create(ctx, conversationID, actorID, trimmed)
+	message, err := txMessageRepo.Create(ctx, conversationID, actorID, moderated.Text)
 	if err != nil {
 		return nil, err
 	}
+	if err := queueModeration(ctx, repository.NewModerationRepository(tx), message, moderated); err != nil {
+		return nil, err
+	}
+	if err := queueAutoReply(
+		ctx,
+		repository.NewScheduledMessageRepository(tx),
+		repository.NewCoachProfileRepository(tx),
+		conversation,
+		actorID,
+	); err != nil {
+		return nil, err
+	}
 
 	if err := txConversationRepo.Touch(ctx, conversationID); err != nil {
 		return nil, err
@@ -202,10 +420,124 @@ func (s *ChatService) SendMessage(
 	return &ChatDelivery{
 		Conversation: conversation,
 		Message:      message,
-		RecipientID:  recipientID,
+		RecipientIDs: recipientIDs,
+	}, nil
+}
+
+// MarkMessagesRead records an explicit read acknowledgement. Ids that are already read, belong to
+// another conversation, or were sent by the reader are ignored.
+func (s *ChatService) MarkMessagesRead(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	messageIDs []int64,
+) (*ReadReceipt, error) {
+	if role != "user" && role != "coach" {
+		return nil, ErrForbidden
+	}
+	if conversationID <= 0 || len(messageIDs) == 0 || len(messageIDs) > maxReadReceiptMessages {
+		return nil, ErrInvalidInput
+	}
+
+	conversation, err := s.participantConversation(ctx, actorID, role, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	recipientIDs, err := s.peersOf(ctx, conversation, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var marked []int64
+	if conversation.Kind == models.ConversationKindGroup {
+		marked, err = s.markGroupMessagesRead(ctx, conversationID, messageIDs, actorID)
+	} else {
+		marked, err = s.messageRepo.MarkMessagesRead(ctx, conversationID, messageIDs, actorID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReadReceipt{
+		ConversationID: conversationID,
+		ReaderID:       actorID,
+		RecipientIDs:   recipientIDs,
+		MessageIDs:     marked,
+		ReadAt:         time.Now().UTC(),
 	}, nil
 }
 
+// markGroupMessagesRead moves the reader's read position in a group up to the newest of the given
+// messages they received, and returns the ones that were past the old position.
+func (s *ChatService) markGroupMessagesRead(
+	ctx context.Context,
+	conversationID int64,
+	messageIDs []int64,
+	readerID int64,
+) ([]int64, error) {
+	received, err := s.messageRepo.ListReceived(ctx, conversationID, messageIDs, readerID)
+	if err != nil || len(received) == 0 {
+		return received, err
+	}
+
+	newestID := int64(0)
+	for _, id := range received {
+		newestID = max(newestID, id)
+	}
+	previous, err := s.conversationRepo.AdvanceReadPosition(ctx, conversationID, readerID, newestID)
+	if err != nil {
+		return nil, err
+	}
+
+	marked := make([]int64, 0, len(received))
+	for _, id := range received {
+		if id > previous {
+			marked = append(marked, id)
+		}
+	}
+	return marked, nil
+}
+
+// ConversationPeers returns the other participants of a conversation the actor takes part in.
+func (s *ChatService) ConversationPeers(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+) ([]int64, error) {
+	conversation, err := s.participantConversation(ctx, actorID, role, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return s.peersOf(ctx, conversation, actorID)
+}
+
+// participantConversation returns a conversation the actor is a current member of. Others get
+// ErrForbidden.
+func (s *ChatService) participantConversation(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+) (*models.Conversation, error) {
+	if role != "user" && role != "coach" {
+		return nil, ErrForbidden
+	}
+	if conversationID <= 0 {
+		return nil, ErrInvalidInput
+	}
+
+	conversation, err := s.conversationRepo.GetByIDForParticipant(ctx, conversationID, actorID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrForbidden
+		}
+		return nil, err
+	}
+	return conversation, nil
+}
+
 func FormatChatTimestamp(ts time.Time) string {
 	return ts.UTC().Format(time.RFC3339)
 }
@@ -0,0 +1,735 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	maxCheckInQuestions       = 30
+	maxCheckInChoiceOptions   = 20
+	maxCheckInTextAnswer      = 5000
+	maxCheckInIntervalDays    = 90
+	defaultCheckInInterval    = 7
+	maxCheckInEarlyDays       = 2
+	checkInDueSoonDays        = 3
+	checkInRecentWindow       = 7 * 24 * time.Hour
+	maxCheckInDashboardRecent = 20
+	defaultScaleMin           = 1
+	defaultScaleMax           = 10
+)
+
+var checkInQuestionTypes = map[string]bool{
+	"scale":  true,
+	"choice": true,
+	"number": true,
+	"text":   true,
+	"photo":  true,
+}
+
+// CheckInAssignment schedules a form for a client. FirstDueOn defaults to today.
+type CheckInAssignment struct {
+	UserID       int64
+	IntervalDays int
+	FirstDueOn   *time.Time
+}
+
+// CheckInScheduleUpdate changes a schedule; nil fields keep their current value.
+type CheckInScheduleUpdate struct {
+	IntervalDays *int
+	NextDueOn    *time.Time
+	Active       *bool
+}
+
+// CheckInPhotoUpload is the file answering a photo question.
+type CheckInPhotoUpload struct {
+	File     multipart.File
+	Filename string
+}
+
+// CheckInSubmissionInput carries the answers to a form. Photos are keyed by question id.
+type CheckInSubmissionInput struct {
+	Answers []models.CheckInAnswer
+	Photos  map[string]CheckInPhotoUpload
+}
+
+type CheckInService struct {
+	db             *pgxpool.Pool
+	checkInRepo    *repository.CheckInRepository
+	userRepo       userReader
+	coachingRepo   *repository.CoachingRepository
+	storageService StorageService
+}
+
+func NewCheckInService(
+	db *pgxpool.Pool,
+	checkInRepo *repository.CheckInRepository,
+	userRepo userReader,
+	coachingRepo *repository.CoachingRepository,
+	storageService StorageService,
+) *CheckInService {
+	return &CheckInService{
+		db:             db,
+		checkInRepo:    checkInRepo,
+		userRepo:       userRepo,
+		coachingRepo:   coachingRepo,
+		storageService: storageService,
+	}
+}
+
+func (s *CheckInService) CreateForm(
+	ctx context.Context,
+	coachID int64,
+	input repository.CheckInFormInput,
+) (*models.CheckInForm, error) {
+	if err := normalizeCheckInForm(&input); err != nil {
+		return nil, err
+	}
+	return s.checkInRepo.CreateForm(ctx, coachID, input)
+}
+
+func (s *CheckInService) ListForms(ctx context.Context, coachID int64) ([]models.CheckInForm, error) {
+	return s.checkInRepo.ListFormsByCoach(ctx, coachID)
+}
+
+// GetForm returns a form to its coach, or to a client it is scheduled for.
+func (s *CheckInService) GetForm(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	formID int64,
+) (*models.CheckInForm, error) {
+	form, err := s.checkInRepo.GetFormByID(ctx, formID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch role {
+	case "coach":
+		if form.CoachID != actorID {
+			return nil, ErrForbidden
+		}
+	case "user":
+		schedules, err := s.checkInRepo.ListSchedules(ctx, repository.CheckInScheduleFilter{UserID: &actorID})
+		if err != nil {
+			return nil, err
+		}
+		assigned := false
+		for _, schedule := range schedules {
+			if schedule.FormID == formID {
+				assigned = true
+				break
+			}
+		}
+		if !assigned {
+			return nil, ErrForbidden
+		}
+	default:
+		return nil, ErrForbidden
+	}
+	return form, nil
+}
+
+// UpdateForm replaces the form's questions. Future submissions use the new questions; earlier
+// submissions keep the ones they answered.
+func (s *CheckInService) UpdateForm(
+	ctx context.Context,
+	coachID int64,
+	formID int64,
+	input repository.CheckInFormInput,
+) (*models.CheckInForm, error) {
+	if err := normalizeCheckInForm(&input); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetForm(ctx, coachID, "coach", formID); err != nil {
+		return nil, err
+	}
+	return s.checkInRepo.UpdateForm(ctx, formID, input)
+}
+
+func (s *CheckInService) DeleteForm(ctx context.Context, coachID int64, formID int64) error {
+	if _, err := s.GetForm(ctx, coachID, "coach", formID); err != nil {
+		return err
+	}
+	return s.checkInRepo.DeleteForm(ctx, formID)
+}
+
+// AssignForm schedules a recurring check-in for a client the coach is actively coaching.
+func (s *CheckInService) AssignForm(
+	ctx context.Context,
+	coachID int64,
+	formID int64,
+	assignment CheckInAssignment,
+) (*models.CheckInSchedule, error) {
+	if assignment.UserID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	if assignment.IntervalDays == 0 {
+		assignment.IntervalDays = defaultCheckInInterval
+	}
+	if assignment.IntervalDays < 1 || assignment.IntervalDays > maxCheckInIntervalDays {
+		return nil, ErrInvalidInput
+	}
+	today := startOfUTCDay(time.Now().UTC())
+	firstDueOn := today
+	if assignment.FirstDueOn != nil {
+		firstDueOn = startOfUTCDay(*assignment.FirstDueOn)
+		if firstDueOn.Before(today) {
+			return nil, ErrInvalidInput
+		}
+	}
+
+	if _, err := s.GetForm(ctx, coachID, "coach", formID); err != nil {
+		return nil, err
+	}
+	relationship, err := activeRelationship(ctx, s.userRepo, s.coachingRepo, coachID, assignment.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.checkInRepo.CreateSchedule(
+		ctx,
+		formID,
+		relationship,
+		assignment.IntervalDays,
+		firstDueOn,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrConflict
+		}
+		return nil, err
+	}
+	setCheckInOverdue(schedule, today)
+	return schedule, nil
+}
+
+// ListSchedules returns a client's own check-ins, or a coach's schedules optionally narrowed to
+// one client.
+func (s *CheckInService) ListSchedules(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	userID int64,
+) ([]models.CheckInSchedule, error) {
+	filter := repository.CheckInScheduleFilter{}
+	switch role {
+	case "user":
+		if userID != 0 && userID != actorID {
+			return nil, ErrForbidden
+		}
+		filter.UserID = &actorID
+	case "coach":
+		filter.CoachID = &actorID
+		if userID > 0 {
+			filter.UserID = &userID
+		}
+	default:
+		return nil, ErrForbidden
+	}
+
+	schedules, err := s.checkInRepo.ListSchedules(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	today := startOfUTCDay(time.Now().UTC())
+	for i := range schedules {
+		setCheckInOverdue(&schedules[i], today)
+	}
+	return schedules, nil
+}
+
+func (s *CheckInService) UpdateSchedule(
+	ctx context.Context,
+	coachID int64,
+	scheduleID int64,
+	update CheckInScheduleUpdate,
+) (*models.CheckInSchedule, error) {
+	schedule, err := s.checkInRepo.GetScheduleByID(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.CoachID != coachID {
+		return nil, ErrForbidden
+	}
+
+	intervalDays := schedule.IntervalDays
+	if update.IntervalDays != nil {
+		intervalDays = *update.IntervalDays
+		if intervalDays < 1 || intervalDays > maxCheckInIntervalDays {
+			return nil, ErrInvalidInput
+		}
+	}
+	today := startOfUTCDay(time.Now().UTC())
+	nextDueOn := schedule.NextDueOn
+	if update.NextDueOn != nil {
+		nextDueOn = startOfUTCDay(*update.NextDueOn)
+		if nextDueOn.Before(today) {
+			return nil, ErrInvalidInput
+		}
+	}
+	active := schedule.Active
+	if update.Active != nil {
+		active = *update.Active
+	}
+
+	updated, err := s.checkInRepo.UpdateSchedule(ctx, scheduleID, intervalDays, nextDueOn, active)
+	if err != nil {
+		return nil, err
+	}
+	setCheckInOverdue(updated, today)
+	return updated, nil
+}
+
+func (s *CheckInService) DeleteSchedule(ctx context.Context, coachID int64, scheduleID int64) error {
+	schedule, err := s.checkInRepo.GetScheduleByID(ctx, scheduleID)
+	if err != nil {
+		return err
+	}
+	if schedule.CoachID != coachID {
+		return ErrForbidden
+	}
+	return s.checkInRepo.DeleteSchedule(ctx, scheduleID)
+}
+
+// Submit answers the schedule's current check-in and moves it to the next due date. A check-in
+// opens up to two days before it is due; late submissions skip the periods that were missed.
+func (s *CheckInService) Submit(
+	ctx context.Context,
+	userID int64,
+	scheduleID int64,
+	input CheckInSubmissionInput,
+) (*models.CheckInSubmission, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txCheckInRepo := repository.NewCheckInRepository(tx)
+	schedule, err := txCheckInRepo.GetScheduleByIDForUpdate(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.UserID != userID {
+		return nil, ErrForbidden
+	}
+	active, err := s.coachingRepo.IsActiveCoach(ctx, schedule.CoachID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrForbidden
+	}
+	today := startOfUTCDay(time.Now().UTC())
+	if !schedule.Active || today.Before(checkInOpensOn(schedule)) {
+		return nil, ErrConflict
+	}
+	form, err := txCheckInRepo.GetFormByID(ctx, schedule.FormID)
+	if err != nil {
+		return nil, err
+	}
+
+	answers, err := normalizeCheckInAnswers(form.Questions, input.Answers, input.Photos)
+	if err != nil {
+		return nil, err
+	}
+	uploaded, err := s.uploadCheckInPhotos(ctx, userID, answers, input.Photos)
+	if err != nil {
+		return nil, err
+	}
+
+	submission, err := txCheckInRepo.CreateSubmission(ctx, schedule, form, answers)
+	if err == nil {
+		_, err = txCheckInRepo.UpdateSchedule(
+			ctx,
+			schedule.ID,
+			schedule.IntervalDays,
+			nextCheckInDueOn(schedule.NextDueOn, schedule.IntervalDays, today),
+			schedule.Active,
+		)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		return nil, errors.Join(err, s.deleteCheckInPhotos(ctx, uploaded))
+	}
+
+	if err := s.signCheckInSubmission(ctx, submission); err != nil {
+		return nil, err
+	}
+	return submission, nil
+}
+
+// ListSubmissions returns a client's own submissions, or those sent to a coach.
+func (s *CheckInService) ListSubmissions(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	filter repository.CheckInSubmissionFilter,
+) ([]models.CheckInSubmission, int, error) {
+	switch role {
+	case "user":
+		if filter.UserID != nil && *filter.UserID != actorID {
+			return nil, 0, ErrForbidden
+		}
+		filter.UserID = &actorID
+	case "coach":
+		filter.CoachID = &actorID
+	default:
+		return nil, 0, ErrForbidden
+	}
+
+	submissions, total, err := s.checkInRepo.ListSubmissions(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range submissions {
+		if err := s.signCheckInSubmission(ctx, &submissions[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return submissions, total, nil
+}
+
+func (s *CheckInService) GetSubmission(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	submissionID int64,
+) (*models.CheckInSubmission, error) {
+	submission, err := s.checkInRepo.GetSubmissionByID(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkClientRecordAccess(ctx, s.coachingRepo, role, actorID, submission.CoachID, submission.UserID); err != nil {
+		return nil, err
+	}
+	if err := s.signCheckInSubmission(ctx, submission); err != nil {
+		return nil, err
+	}
+	return submission, nil
+}
+
+// GetDashboard lists overdue check-ins, those due in the next three days, and submissions from
+// the last week across all of the coach's clients.
+func (s *CheckInService) GetDashboard(ctx context.Context, coachID int64) (*models.CheckInDashboard, error) {
+	now := time.Now().UTC()
+	today := startOfUTCDay(now)
+	dueBefore := today.AddDate(0, 0, checkInDueSoonDays+1)
+	schedules, err := s.checkInRepo.ListSchedules(ctx, repository.CheckInScheduleFilter{
+		CoachID:   &coachID,
+		DueBefore: &dueBefore,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &models.CheckInDashboard{
+		Overdue: []models.CheckInSchedule{},
+		DueSoon: []models.CheckInSchedule{},
+	}
+	for _, schedule := range schedules {
+		setCheckInOverdue(&schedule, today)
+		if schedule.DaysOverdue > 0 {
+			dashboard.Overdue = append(dashboard.Overdue, schedule)
+		} else {
+			dashboard.DueSoon = append(dashboard.DueSoon, schedule)
+		}
+	}
+
+	since := now.Add(-checkInRecentWindow)
+	dashboard.Recent, _, err = s.ListSubmissions(ctx, coachID, "coach", repository.CheckInSubmissionFilter{
+		Since: &since,
+		Limit: maxCheckInDashboardRecent,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dashboard, nil
+}
+
+func (s *CheckInService) uploadCheckInPhotos(
+	ctx context.Context,
+	userID int64,
+	answers []models.CheckInAnswer,
+	photos map[string]CheckInPhotoUpload,
+) ([]string, error) {
+	uploaded := make([]string, 0, len(photos))
+	for i := range answers {
+		photo, ok := photos[answers[i].QuestionID]
+		if !ok {
+			continue
+		}
+		if s.storageService == nil {
+			return nil, ErrStorageUnavailable
+		}
+
+		ext := strings.ToLower(filepath.Ext(strings.TrimSpace(photo.Filename)))
+		filename := fmt.Sprintf("%d-%d%s", userID, time.Now().UnixNano(), ext)
+		filePath, err := s.storageService.UploadFile(ctx, photo.File, filename, "check-in-photos")
+		if err != nil {
+			return nil, errors.Join(err, s.deleteCheckInPhotos(ctx, uploaded))
+		}
+		uploaded = append(uploaded, filePath)
+		answers[i].PhotoPath = filePath
+	}
+	return uploaded, nil
+}
+
+func (s *CheckInService) deleteCheckInPhotos(ctx context.Context, filePaths []string) error {
+	var errs []error
+	for _, filePath := range filePaths {
+		if err := s.storageService.DeleteFile(ctx, filePath); err != nil {
+			errs = append(errs, fmt.Errorf("cleanup failed: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// signCheckInSubmission replaces photo paths with signed URLs. Without storage the URLs are left
+// empty so the rest of the answers stay readable.
+func (s *CheckInService) signCheckInSubmission(ctx context.Context, submission *models.CheckInSubmission) error {
+	if s.storageService == nil {
+		return nil
+	}
+	for i := range submission.Answers {
+		if submission.Answers[i].PhotoPath == "" {
+			continue
+		}
+		signedURL, err := s.storageService.GetSignedURL(ctx, submission.Answers[i].PhotoPath)
+		if err != nil {
+			return err
+		}
+		submission.Answers[i].PhotoURL = signedURL
+	}
+	return nil
+}
+
+// checkInOpensOn is the first day a schedule's current check-in can be submitted.
+func checkInOpensOn(schedule *models.CheckInSchedule) time.Time {
+	earlyDays := min(maxCheckInEarlyDays, schedule.IntervalDays/2)
+	return startOfUTCDay(schedule.NextDueOn).AddDate(0, 0, -earlyDays)
+}
+
+// nextCheckInDueOn moves a due date forward by whole intervals until it is after today.
+func nextCheckInDueOn(dueOn time.Time, intervalDays int, today time.Time) time.Time {
+	next := startOfUTCDay(dueOn).AddDate(0, 0, intervalDays)
+	for !next.After(today) {
+		next = next.AddDate(0, 0, intervalDays)
+	}
+	return next
+}
+
+func setCheckInOverdue(schedule *models.CheckInSchedule, today time.Time) {
+	schedule.DaysOverdue = 0
+	if schedule.Active && schedule.NextDueOn.Before(today) {
+		schedule.DaysOverdue = int(today.Sub(startOfUTCDay(schedule.NextDueOn)).Hours() / 24)
+	}
+}
+
+func normalizeCheckInForm(input *repository.CheckInFormInput) error {
+	input.Title = strings.TrimSpace(input.Title)
+	input.Description = blankToNil(input.Description)
+	if input.Title == "" || len(input.Title) > 255 {
+		return ErrInvalidInput
+	}
+	if input.Description != nil && len(*input.Description) > 2000 {
+		return ErrInvalidInput
+	}
+	if len(input.Questions) == 0 || len(input.Questions) > maxCheckInQuestions {
+		return ErrInvalidInput
+	}
+
+	seen := make(map[string]bool, len(input.Questions))
+	for i := range input.Questions {
+		question := &input.Questions[i]
+		question.ID = strings.TrimSpace(question.ID)
+		if question.ID == "" {
+			question.ID = fmt.Sprintf("q%d", i+1)
+		}
+		if len(question.ID) > 50 || seen[question.ID] {
+			return ErrInvalidInput
+		}
+		seen[question.ID] = true
+		if err := normalizeCheckInQuestion(question); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func normalizeCheckInQuestion(question *models.CheckInQuestion) error {
+	question.Type = strings.ToLower(strings.TrimSpace(question.Type))
+	question.Prompt = strings.TrimSpace(question.Prompt)
+	if !checkInQuestionTypes[question.Type] || question.Prompt == "" || len(question.Prompt) > 500 {
+		return ErrInvalidInput
+	}
+	if question.Type != "choice" && len(question.Options) > 0 {
+		return ErrInvalidInput
+	}
+
+	switch question.Type {
+	case "scale":
+		if question.Min == nil {
+			value := float64(defaultScaleMin)
+			question.Min = &value
+		}
+		if question.Max == nil {
+			value := float64(defaultScaleMax)
+			question.Max = &value
+		}
+		if *question.Min != math.Trunc(*question.Min) || *question.Max != math.Trunc(*question.Max) {
+			return ErrInvalidInput
+		}
+		if *question.Min >= *question.Max || *question.Max-*question.Min > 100 {
+			return ErrInvalidInput
+		}
+	case "number":
+		if question.Min != nil && question.Max != nil && *question.Min > *question.Max {
+			return ErrInvalidInput
+		}
+	case "choice":
+		if question.Min != nil || question.Max != nil {
+			return ErrInvalidInput
+		}
+		if len(question.Options) < 2 || len(question.Options) > maxCheckInChoiceOptions {
+			return ErrInvalidInput
+		}
+		seen := make(map[string]bool, len(question.Options))
+		for i, option := range question.Options {
+			option = strings.TrimSpace(option)
+			key := strings.ToLower(option)
+			if option == "" || len(option) > 100 || seen[key] {
+				return ErrInvalidInput
+			}
+			seen[key] = true
+			question.Options[i] = option
+		}
+	default:
+		if question.Min != nil || question.Max != nil {
+			return ErrInvalidInput
+		}
+	}
+	return nil
+}
+
+// normalizeCheckInAnswers checks the answers against the form and returns them in question
+// order. Photo questions are answered by an upload keyed by the question id.
+func normalizeCheckInAnswers(
+	questions []models.CheckInQuestion,
+	answers []models.CheckInAnswer,
+	photos map[string]CheckInPhotoUpload,
+) ([]models.CheckInAnswer, error) {
+	byQuestion := make(map[string]models.CheckInAnswer, len(answers))
+	for _, answer := range answers {
+		answer.QuestionID = strings.TrimSpace(answer.QuestionID)
+		if _, exists := byQuestion[answer.QuestionID]; exists {
+			return nil, ErrInvalidInput
+		}
+		byQuestion[answer.QuestionID] = answer
+	}
+	known := make(map[string]bool, len(questions))
+	for _, question := range questions {
+		known[question.ID] = true
+	}
+	for questionID := range byQuestion {
+		if !known[questionID] {
+			return nil, ErrInvalidInput
+		}
+	}
+	for questionID := range photos {
+		if !known[questionID] {
+			return nil, ErrInvalidInput
+		}
+	}
+
+	normalized := make([]models.CheckInAnswer, 0, len(questions))
+	for _, question := range questions {
+		answer, answered := byQuestion[question.ID]
+		if question.Type == "photo" {
+			if answered {
+				return nil, ErrInvalidInput
+			}
+			_, answered = photos[question.ID]
+			answer = models.CheckInAnswer{QuestionID: question.ID}
+		} else if answered {
+			if _, hasPhoto := photos[question.ID]; hasPhoto {
+				return nil, ErrInvalidInput
+			}
+			if err := normalizeCheckInAnswer(question, &answer); err != nil {
+				return nil, err
+			}
+		}
+
+		if !answered {
+			if question.Required {
+				return nil, ErrInvalidInput
+			}
+			continue
+		}
+		normalized = append(normalized, answer)
+	}
+	return normalized, nil
+}
+
+func normalizeCheckInAnswer(question models.CheckInQuestion, answer *models.CheckInAnswer) error {
+	answer.PhotoPath = ""
+	answer.PhotoURL = ""
+
+	switch question.Type {
+	case "scale", "number":
+		if answer.Number == nil || answer.Choice != nil || answer.Text != nil {
+			return ErrInvalidInput
+		}
+		value := *answer.Number
+		if question.Type == "scale" && value != math.Trunc(value) {
+			return ErrInvalidInput
+		}
+		if (question.Min != nil && value < *question.Min) || (question.Max != nil && value > *question.Max) {
+			return ErrInvalidInput
+		}
+	case "choice":
+		if answer.Choice == nil || answer.Number != nil || answer.Text != nil {
+			return ErrInvalidInput
+		}
+		choice := strings.TrimSpace(*answer.Choice)
+		matched := false
+		for _, option := range question.Options {
+			if strings.EqualFold(option, choice) {
+				answer.Choice = &option
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return ErrInvalidInput
+		}
+	case "text":
+		if answer.Number != nil || answer.Choice != nil {
+			return ErrInvalidInput
+		}
+		answer.Text = blankToNil(answer.Text)
+		if answer.Text == nil || len(*answer.Text) > maxCheckInTextAnswer {
+			return ErrInvalidInput
+		}
+	default:
+		return ErrInvalidInput
+	}
+	return nil
+}
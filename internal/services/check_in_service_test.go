@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+func checkInTestQuestions(t *testing.T) []models.CheckInQuestion {
+	t.Helper()
+	input := repository.CheckInFormInput{
+		Title: "Weekly check-in",
+		Questions: []models.CheckInQuestion{
+			{Type: "scale", Prompt: "Sleep quality", Required: true},
+			{ID: "mood", Type: "choice", Prompt: "Mood", Options: []string{"Good", " Okay ", "Bad"}},
+			{ID: "steps", Type: "number", Prompt: "Average steps"},
+			{ID: "notes", Type: "text", Prompt: "Anything else?"},
+			{ID: "front", Type: "photo", Prompt: "Front photo"},
+		},
+	}
+	if err := normalizeCheckInForm(&input); err != nil {
+		t.Fatalf("normalizeCheckInForm: %v", err)
+	}
+	return input.Questions
+}
+
+func TestNormalizeCheckInForm(t *testing.T) {
+	questions := checkInTestQuestions(t)
+
+	if questions[0].ID != "q1" || *questions[0].Min != 1 || *questions[0].Max != 10 {
+		t.Fatalf("expected default scale question, got %+v", questions[0])
+	}
+	if questions[1].Options[1] != "Okay" {
+		t.Fatalf("expected trimmed options, got %v", questions[1].Options)
+	}
+
+	invalid := []models.CheckInQuestion{
+		{Type: "choice", Prompt: "Mood", Options: []string{"Good"}},
+		{Type: "choice", Prompt: "Mood", Options: []string{"Good", "good"}},
+		{Type: "slider", Prompt: "Energy"},
+		{Type: "text", Prompt: "Notes", Options: []string{"a", "b"}},
+	}
+	for _, question := range invalid {
+		input := repository.CheckInFormInput{Title: "Form", Questions: []models.CheckInQuestion{question}}
+		if err := normalizeCheckInForm(&input); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("expected %+v to be rejected, got %v", question, err)
+		}
+	}
+
+	duplicate := repository.CheckInFormInput{
+		Title: "Form",
+		Questions: []models.CheckInQuestion{
+			{ID: "sleep", Type: "text", Prompt: "Sleep"},
+			{ID: "sleep", Type: "text", Prompt: "Sleep again"},
+		},
+	}
+	if err := normalizeCheckInForm(&duplicate); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected duplicate ids to be rejected, got %v", err)
+	}
+}
+
+func TestNormalizeCheckInAnswers(t *testing.T) {
+	questions := checkInTestQuestions(t)
+	sleep := 8.0
+	mood := "okay"
+	photos := map[string]CheckInPhotoUpload{"front": {Filename: "front.jpg"}}
+
+	answers, err := normalizeCheckInAnswers(questions, []models.CheckInAnswer{
+		{QuestionID: "mood", Choice: &mood},
+		{QuestionID: "q1", Number: &sleep},
+	}, photos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(answers) != 3 || answers[0].QuestionID != "q1" || answers[2].QuestionID != "front" {
+		t.Fatalf("expected answers in question order, got %+v", answers)
+	}
+	if *answers[1].Choice != "Okay" {
+		t.Fatalf("expected canonical choice, got %q", *answers[1].Choice)
+	}
+
+	outOfRange := 11.0
+	halfPoint := 7.5
+	unknown := "great"
+	cases := map[string][]models.CheckInAnswer{
+		"missing required": {{QuestionID: "mood", Choice: &mood}},
+		"out of range":     {{QuestionID: "q1", Number: &outOfRange}},
+		"fractional scale": {{QuestionID: "q1", Number: &halfPoint}},
+		"unknown option":   {{QuestionID: "q1", Number: &sleep}, {QuestionID: "mood", Choice: &unknown}},
+		"unknown question": {{QuestionID: "q1", Number: &sleep}, {QuestionID: "energy", Number: &sleep}},
+		"photo as json":    {{QuestionID: "q1", Number: &sleep}, {QuestionID: "front", Text: &mood}},
+	}
+	for name, answers := range cases {
+		if _, err := normalizeCheckInAnswers(questions, answers, nil); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("%s: expected invalid input, got %v", name, err)
+		}
+	}
+}
+
+func TestCheckInDueDates(t *testing.T) {
+	due := time.Date(2030, 3, 10, 0, 0, 0, 0, time.UTC)
+	weekly := &models.CheckInSchedule{IntervalDays: 7, NextDueOn: due}
+	daily := &models.CheckInSchedule{IntervalDays: 1, NextDueOn: due}
+
+	if opens := checkInOpensOn(weekly); !opens.Equal(due.AddDate(0, 0, -2)) {
+		t.Fatalf("expected weekly check-in to open two days early, got %v", opens)
+	}
+	if opens := checkInOpensOn(daily); !opens.Equal(due) {
+		t.Fatalf("expected daily check-in to open on the day, got %v", opens)
+	}
+
+	if next := nextCheckInDueOn(due, 7, due.AddDate(0, 0, -1)); !next.Equal(due.AddDate(0, 0, 7)) {
+		t.Fatalf("expected next week, got %v", next)
+	}
+	if next := nextCheckInDueOn(due, 7, due.AddDate(0, 0, 10)); !next.Equal(due.AddDate(0, 0, 14)) {
+		t.Fatalf("expected missed weeks to be skipped, got %v", next)
+	}
+
+	setCheckInOverdue(weekly, due.AddDate(0, 0, 3))
+	if weekly.DaysOverdue != 0 {
+		t.Fatalf("expected paused schedule not to be overdue, got %d", weekly.DaysOverdue)
+	}
+	weekly.Active = true
+	setCheckInOverdue(weekly, due.AddDate(0, 0, 3))
+	if weekly.DaysOverdue != 3 {
+		t.Fatalf("expected 3 days overdue, got %d", weekly.DaysOverdue)
+	}
+}
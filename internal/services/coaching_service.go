@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	RelationshipActive = "active"
+	RelationshipPaused = "paused"
+	RelationshipEnded  = "ended"
+)
+
+type CoachingService struct {
+	coachingRepo *repository.CoachingRepository
+	userRepo     userReader
+}
+
+func NewCoachingService(coachingRepo *repository.CoachingRepository, userRepo userReader) *CoachingService {
+	return &CoachingService{coachingRepo: coachingRepo, userRepo: userRepo}
+}
+
+// StartRelationship lets a client start working with a coach, for example after buying a package
+// outside the app. A paused relationship stays paused, and an ended one can only be reopened by
+// the coach.
+func (s *CoachingService) StartRelationship(
+	ctx context.Context,
+	userID int64,
+	coachID int64,
+) (*models.CoachingRelationship, error) {
+	if coachID <= 0 || coachID == userID {
+		return nil, ErrInvalidInput
+	}
+	coach, err := s.userRepo.GetByID(ctx, coachID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCoachNotFound
+		}
+		return nil, err
+	}
+	if coach.Role != "coach" {
+		return nil, ErrInvalidInput
+	}
+	relationship, err := s.coachingRepo.Activate(ctx, coachID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if relationship.Status == RelationshipEnded {
+		return nil, ErrConflict
+	}
+	return relationship, nil
+}
+
+// ListRelationships returns a coach's clients or a client's coaches, optionally by status.
+func (s *CoachingService) ListRelationships(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	filter repository.CoachingRelationshipFilter,
+) ([]models.CoachingRelationship, int, error) {
+	if filter.Status != "" && !isRelationshipStatus(filter.Status) {
+		return nil, 0, ErrInvalidInput
+	}
+	switch role {
+	case "coach":
+		filter.CoachID = &actorID
+		filter.UserID = nil
+	case "user":
+		filter.UserID = &actorID
+		filter.CoachID = nil
+	default:
+		return nil, 0, ErrForbidden
+	}
+	return s.coachingRepo.List(ctx, filter)
+}
+
+func (s *CoachingService) GetRelationship(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	relationshipID int64,
+) (*models.CoachingRelationship, error) {
+	relationship, err := s.coachingRepo.GetByID(ctx, relationshipID)
+	if err != nil {
+		return nil, err
+	}
+	if !isRelationshipParticipant(relationship, actorID, role) {
+		return nil, ErrForbidden
+	}
+	return relationship, nil
+}
+
+// UpdateStatus applies a status change. Coaches pause, resume and end relationships; clients can
+// only end them. Only the coach reopens an ended relationship; bookings and subscriptions never do.
+func (s *CoachingService) UpdateStatus(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	relationshipID int64,
+	status string,
+) (*models.CoachingRelationship, error) {
+	if !isRelationshipStatus(status) {
+		return nil, ErrInvalidInput
+	}
+	relationship, err := s.GetRelationship(ctx, actorID, role, relationshipID)
+	if err != nil {
+		return nil, err
+	}
+	if !canChangeRelationshipStatus(role, relationship.Status, status) {
+		return nil, ErrForbidden
+	}
+	if relationship.Status == status {
+		return relationship, nil
+	}
+	return s.coachingRepo.UpdateStatus(ctx, relationshipID, status)
+}
+
+func isRelationshipStatus(status string) bool {
+	switch status {
+	case RelationshipActive, RelationshipPaused, RelationshipEnded:
+		return true
+	default:
+		return false
+	}
+}
+
+func isRelationshipParticipant(relationship *models.CoachingRelationship, actorID int64, role string) bool {
+	switch role {
+	case "coach":
+		return relationship.CoachID == actorID
+	case "user":
+		return relationship.UserID == actorID
+	default:
+		return false
+	}
+}
+
+func canChangeRelationshipStatus(role string, from string, to string) bool {
+	if from == to {
+		return true
+	}
+	if to == RelationshipEnded {
+		return true
+	}
+	// Only coaches move between active and paused, and an ended relationship only goes back to active.
+	return role == "coach" && (from != RelationshipEnded || to == RelationshipActive)
+}
+
+// activeRelationship returns the coach's active relationship with the client, who must be a user
+// account. Without one the coach may not act on the client's behalf.
+func activeRelationship(
+	ctx context.Context,
+	userRepo userReader,
+	coachingRepo *repository.CoachingRepository,
+	coachID int64,
+	userID int64,
+) (*models.CoachingRelationship, error) {
+	user, err := userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != "user" {
+		return nil, ErrInvalidInput
+	}
+
+	relationship, err := coachingRepo.GetByParticipants(ctx, coachID, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrForbidden
+	}
+	if err != nil {
+		return nil, err
+	}
+	if relationship.Status != RelationshipActive {
+		return nil, ErrForbidden
+	}
+	return relationship, nil
+}
@@ -0,0 +1,28 @@
+package services
+
+import "testing"
+
+func TestCanChangeRelationshipStatus(t *testing.T) {
+	cases := []struct {
+		role string
+		from string
+		to   string
+		want bool
+	}{
+		{role: "coach", from: RelationshipActive, to: RelationshipPaused, want: true},
+		{role: "coach", from: RelationshipPaused, to: RelationshipActive, want: true},
+		{role: "user", from: RelationshipActive, to: RelationshipPaused, want: false},
+		{role: "user", from: RelationshipPaused, to: RelationshipActive, want: false},
+		{role: "user", from: RelationshipActive, to: RelationshipEnded, want: true},
+		{role: "coach", from: RelationshipPaused, to: RelationshipEnded, want: true},
+		{role: "coach", from: RelationshipEnded, to: RelationshipActive, want: true},
+		{role: "coach", from: RelationshipEnded, to: RelationshipPaused, want: false},
+		{role: "user", from: RelationshipEnded, to: RelationshipActive, want: false},
+		{role: "user", from: RelationshipEnded, to: RelationshipEnded, want: true},
+	}
+	for _, tc := range cases {
+		if got := canChangeRelationshipStatus(tc.role, tc.from, tc.to); got != tc.want {
+			t.Fatalf("canChangeRelationshipStatus(%q, %q, %q) = %v, want %v", tc.role, tc.from, tc.to, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+var (
+	ErrInvalidCoupon      = errors.New("coupon is not valid for this payment")
+	ErrCouponLimitReached = errors.New("coupon usage limit reached")
+)
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,50}$`)
+
+type CouponInput struct {
+	Code             string
+	DiscountType     string
+	DiscountValue    float64
+	MaxRedemptions   *int
+	PerUserLimit     *int
+	FirstSessionOnly bool
+	ValidFrom        *time.Time
+	ValidUntil       *time.Time
+}
+
+type CouponService struct {
+	couponRepo *repository.CouponRepository
+}
+
+func NewCouponService(couponRepo *repository.CouponRepository) *CouponService {
+	return &CouponService{couponRepo: couponRepo}
+}
+
+func (s *CouponService) CreateCoupon(
+	ctx context.Context,
+	coachID int64,
+	input CouponInput,
+) (*models.Coupon, error) {
+	code := strings.ToUpper(strings.TrimSpace(input.Code))
+	if coachID <= 0 || !couponCodePattern.MatchString(code) {
+		return nil, ErrInvalidInput
+	}
+	switch input.DiscountType {
+	case "percent":
+		if input.DiscountValue <= 0 || input.DiscountValue > 100 {
+			return nil, ErrInvalidInput
+		}
+	case "fixed":
+		if input.DiscountValue <= 0 {
+			return nil, ErrInvalidInput
+		}
+	default:
+		return nil, ErrInvalidInput
+	}
+	if err := validateCouponLimits(input.MaxRedemptions, input.PerUserLimit, input.ValidFrom, input.ValidUntil); err != nil {
+		return nil, err
+	}
+
+	coupon, err := s.couponRepo.Create(ctx, repository.CreateCouponInput{
+		Code:             code,
+		CoachID:          &coachID,
+		DiscountType:     input.DiscountType,
+		DiscountValue:    input.DiscountValue,
+		MaxRedemptions:   input.MaxRedemptions,
+		PerUserLimit:     input.PerUserLimit,
+		FirstSessionOnly: input.FirstSessionOnly,
+		ValidFrom:        input.ValidFrom,
+		ValidUntil:       input.ValidUntil,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrConflict
+		}
+		return nil, err
+	}
+	return coupon, nil
+}
+
+func (s *CouponService) ListCoupons(ctx context.Context, coachID int64) ([]models.Coupon, error) {
+	return s.couponRepo.ListByCoachID(ctx, coachID)
+}
+
+func (s *CouponService) UpdateCoupon(
+	ctx context.Context,
+	coachID int64,
+	couponID int64,
+	input repository.UpdateCouponInput,
+) (*models.Coupon, error) {
+	if couponID <= 0 {
+		return nil, ErrInvalidInput
+	}
+
+	coupon, err := s.couponRepo.GetByID(ctx, couponID)
+	if err != nil {
+		return nil, err
+	}
+	if coupon.CoachID == nil || *coupon.CoachID != coachID {
+		return nil, ErrForbidden
+	}
+
+	validFrom := coupon.ValidFrom
+	if input.ValidFrom != nil {
+		validFrom = input.ValidFrom
+	}
+	validUntil := coupon.ValidUntil
+	if input.ValidUntil != nil {
+		validUntil = input.ValidUntil
+	}
+	if err := validateCouponLimits(input.MaxRedemptions, input.PerUserLimit, validFrom, validUntil); err != nil {
+		return nil, err
+	}
+	if input.MaxRedemptions != nil && *input.MaxRedemptions < coupon.RedemptionCount {
+		return nil, ErrInvalidInput
+	}
+
+	return s.couponRepo.Update(ctx, couponID, coachID, input)
+}
+
+// reserveCoupon validates code for a payment of amount to coachID and claims one redemption
+// inside the caller's transaction. The caller must record the redemption against the payment.
+func reserveCoupon(
+	ctx context.Context,
+	couponRepo *repository.CouponRepository,
+	code string,
+	userID int64,
+	coachID int64,
+	excludeSessionID int64,
+	amount float64,
+	now time.Time,
+) (*models.Coupon, float64, error) {
+	code = strings.TrimSpace(code)
+	if code == "" || amount <= 0 {
+		return nil, 0, ErrInvalidCoupon
+	}
+
+	coupon, err := couponRepo.GetByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, 0, ErrInvalidCoupon
+		}
+		return nil, 0, err
+	}
+	if !isCouponApplicable(coupon, coachID, now) {
+		return nil, 0, ErrInvalidCoupon
+	}
+	if coupon.FirstSessionOnly {
+		prior, err := couponRepo.CountPriorSessions(ctx, userID, coupon.CoachID, excludeSessionID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if prior > 0 {
+			return nil, 0, ErrInvalidCoupon
+		}
+	}
+
+	claimed, err := couponRepo.IncrementRedemptions(ctx, coupon.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, 0, ErrCouponLimitReached
+		}
+		return nil, 0, err
+	}
+	if claimed.PerUserLimit != nil {
+		used, err := couponRepo.CountUserRedemptions(ctx, claimed.ID, userID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if used >= *claimed.PerUserLimit {
+			return nil, 0, ErrCouponLimitReached
+		}
+	}
+
+	return claimed, calculateDiscount(claimed, amount), nil
+}
+
+// releaseCouponForPayment undoes a redemption on an unpaid payment, e.g. when its booking is cancelled.
+func releaseCouponForPayment(
+	ctx context.Context,
+	couponRepo *repository.CouponRepository,
+	paymentRepo *repository.PaymentRepository,
+	payment *models.Payment,
+) error {
+	if payment.CouponID == nil || payment.Status != "placeholder" {
+		return nil
+	}
+	if err := couponRepo.DeleteRedemptionByPaymentID(ctx, payment.ID); err != nil {
+		return err
+	}
+	if err := couponRepo.DecrementRedemptions(ctx, *payment.CouponID); err != nil {
+		return err
+	}
+	_, err := paymentRepo.RemoveDiscount(ctx, payment.ID)
+	return err
+}
+
+func isCouponApplicable(coupon *models.Coupon, coachID int64, now time.Time) bool {
+	if !coupon.IsActive {
+		return false
+	}
+	if coupon.CoachID != nil && *coupon.CoachID != coachID {
+		return false
+	}
+	if coupon.ValidFrom != nil && now.Before(*coupon.ValidFrom) {
+		return false
+	}
+	if coupon.ValidUntil != nil && !now.Before(*coupon.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+func calculateDiscount(coupon *models.Coupon, amount float64) float64 {
+	var discount float64
+	switch coupon.DiscountType {
+	case "percent":
+		discount = math.Round(amount*coupon.DiscountValue) / 100
+	case "fixed":
+		discount = coupon.DiscountValue
+	}
+	return math.Min(discount, amount)
+}
+
+func validateCouponLimits(maxRedemptions *int, perUserLimit *int, validFrom *time.Time, validUntil *time.Time) error {
+	if (maxRedemptions != nil && *maxRedemptions <= 0) || (perUserLimit != nil && *perUserLimit <= 0) {
+		return ErrInvalidInput
+	}
+	if validFrom != nil && validUntil != nil && !validUntil.After(*validFrom) {
+		return ErrInvalidInput
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+func TestCouponParallelRedemptionsRespectLimit(t *testing.T) {
+	ctx := context.Background()
+	pool := integrationTestPool(t)
+	service := newIntegrationSessionService(pool)
+
+	const (
+		maxRedemptions = 3
+		clients        = 8
+	)
+	coachID := createTestAccount(t, ctx, pool, "coach", 100)
+	accountIDs := []int64{coachID}
+	userIDs := make([]int64, clients)
+	for i := range userIDs {
+		userIDs[i] = createTestAccount(t, ctx, pool, "user", 0)
+		accountIDs = append(accountIDs, userIDs[i])
+	}
+	t.Cleanup(func() { cleanupTestUsers(t, ctx, pool, accountIDs...) })
+
+	limit := maxRedemptions
+	coupon, err := NewCouponService(repository.NewCouponRepository(pool)).CreateCoupon(ctx, coachID, CouponInput{
+		Code:           fmt.Sprintf("RACE%d", time.Now().UnixNano()),
+		DiscountType:   "percent",
+		DiscountValue:  20,
+		MaxRedemptions: &limit,
+	})
+	if err != nil {
+		t.Fatalf("CreateCoupon: %v", err)
+	}
+
+	// Every client books a different hour so only the coupon limit can refuse them.
+	errs := make([]error, clients)
+	var wg sync.WaitGroup
+	for i, userID := range userIDs {
+		wg.Add(1)
+		go func(i int, userID int64) {
+			defer wg.Done()
+			_, errs[i] = service.BookSession(ctx, userID, BookSessionInput{
+				CoachID:         coachID,
+				ScheduledAt:     time.Date(2030, 7, 1, 8+i, 0, 0, 0, time.UTC),
+				DurationMinutes: 60,
+				CouponCode:      &coupon.Code,
+			})
+		}(i, userID)
+	}
+	wg.Wait()
+
+	redeemed := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			redeemed++
+		case errors.Is(err, ErrCouponLimitReached):
+		default:
+			t.Fatalf("BookSession for client %d: %v", i, err)
+		}
+	}
+	if redeemed != maxRedemptions {
+		t.Fatalf("expected %d redemptions, got %d", maxRedemptions, redeemed)
+	}
+
+	stored, err := repository.NewCouponRepository(pool).GetByID(ctx, coupon.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if stored.RedemptionCount != maxRedemptions {
+		t.Fatalf("expected redemption count %d, got %d", maxRedemptions, stored.RedemptionCount)
+	}
+	var rows int
+	if err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = $1", coupon.ID).Scan(&rows); err != nil {
+		t.Fatalf("count redemptions: %v", err)
+	}
+	if rows != maxRedemptions {
+		t.Fatalf("expected %d redemption rows, got %d", maxRedemptions, rows)
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+func TestCalculateDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		coupon   models.Coupon
+		amount   float64
+		expected float64
+	}{
+		{name: "percent", coupon: models.Coupon{DiscountType: "percent", DiscountValue: 20}, amount: 50, expected: 10},
+		{name: "percent rounds to cents", coupon: models.Coupon{DiscountType: "percent", DiscountValue: 15}, amount: 33.33, expected: 5},
+		{name: "full percent", coupon: models.Coupon{DiscountType: "percent", DiscountValue: 100}, amount: 42.5, expected: 42.5},
+		{name: "fixed", coupon: models.Coupon{DiscountType: "fixed", DiscountValue: 12.5}, amount: 50, expected: 12.5},
+		{name: "fixed capped at amount", coupon: models.Coupon{DiscountType: "fixed", DiscountValue: 80}, amount: 50, expected: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateDiscount(&tt.coupon, tt.amount); got != tt.expected {
+				t.Fatalf("expected %.2f, got %.2f", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestIsCouponApplicable(t *testing.T) {
+	now := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-24 * time.Hour)
+	future := now.Add(24 * time.Hour)
+	coachID := int64(7)
+	otherCoachID := int64(8)
+
+	tests := []struct {
+		name     string
+		coupon   models.Coupon
+		expected bool
+	}{
+		{name: "platform wide", coupon: models.Coupon{IsActive: true}, expected: true},
+		{name: "same coach", coupon: models.Coupon{IsActive: true, CoachID: &coachID}, expected: true},
+		{name: "other coach", coupon: models.Coupon{IsActive: true, CoachID: &otherCoachID}, expected: false},
+		{name: "inactive", coupon: models.Coupon{IsActive: false}, expected: false},
+		{name: "not yet valid", coupon: models.Coupon{IsActive: true, ValidFrom: &future}, expected: false},
+		{name: "expired", coupon: models.Coupon{IsActive: true, ValidUntil: &past}, expected: false},
+		{name: "expires exactly now", coupon: models.Coupon{IsActive: true, ValidUntil: &now}, expected: false},
+		{name: "inside window", coupon: models.Coupon{IsActive: true, ValidFrom: &past, ValidUntil: &future}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCouponApplicable(&tt.coupon, coachID, now); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateCouponLimits(t *testing.T) {
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := from.Add(24 * time.Hour)
+	zero := 0
+	one := 1
+
+	tests := []struct {
+		name       string
+		max        *int
+		perUser    *int
+		validFrom  *time.Time
+		validUntil *time.Time
+		wantErr    bool
+	}{
+		{name: "no limits"},
+		{name: "positive limits", max: &one, perUser: &one, validFrom: &from, validUntil: &until},
+		{name: "zero max", max: &zero, wantErr: true},
+		{name: "zero per user", perUser: &zero, wantErr: true},
+		{name: "window reversed", validFrom: &until, validUntil: &from, wantErr: true},
+		{name: "empty window", validFrom: &from, validUntil: &from, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCouponLimits(tt.max, tt.perUser, tt.validFrom, tt.validUntil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
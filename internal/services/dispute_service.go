@@ -0,0 +1,431 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	// disputeChatLookback is how far before the payment chat history is considered relevant evidence.
+	disputeChatLookback    = 14 * 24 * time.Hour
+	disputeChatExcerptSize = 50
+)
+
+type DisputeService struct {
+	db             *pgxpool.Pool
+	disputeRepo    *repository.DisputeRepository
+	paymentRepo    *repository.PaymentRepository
+	sessionRepo    *repository.SessionRepository
+	messageRepo    *repository.MessageRepository
+	programRepo    *repository.WorkoutProgramRepository
+	gateway        PaymentGateway
+	invoiceService *InvoiceService
+}
+
+func NewDisputeService(
+	db *pgxpool.Pool,
+	disputeRepo *repository.DisputeRepository,
+	paymentRepo *repository.PaymentRepository,
+	sessionRepo *repository.SessionRepository,
+	messageRepo *repository.MessageRepository,
+	programRepo *repository.WorkoutProgramRepository,
+	gateway PaymentGateway,
+	invoiceService *InvoiceService,
+) *DisputeService {
+	return &DisputeService{
+		db:             db,
+		disputeRepo:    disputeRepo,
+		paymentRepo:    paymentRepo,
+		sessionRepo:    sessionRepo,
+		messageRepo:    messageRepo,
+		programRepo:    programRepo,
+		gateway:        gateway,
+		invoiceService: invoiceService,
+	}
+}
+
+func (s *DisputeService) ListDisputes(ctx context.Context, actorID int64, role string) ([]models.Dispute, error) {
+	if role != "user" && role != "coach" {
+		return nil, ErrForbidden
+	}
+	return s.disputeRepo.ListForParticipant(ctx, actorID, role)
+}
+
+func (s *DisputeService) GetDispute(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	disputeID int64,
+) (*models.DisputeDetail, error) {
+	dispute, err := s.disputeRepo.GetByID(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccessDispute(role, actorID, dispute) {
+		return nil, ErrForbidden
+	}
+
+	adjustments, err := s.disputeRepo.ListAdjustmentsByDisputeID(ctx, dispute.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DisputeDetail{Dispute: *dispute, Adjustments: adjustments}, nil
+}
+
+// SubmitEvidence gathers the session, chat and program-delivery record for the disputed
+// payment, attaches the coach's statement and forwards it to the gateway.
+func (s *DisputeService) SubmitEvidence(
+	ctx context.Context,
+	coachID int64,
+	disputeID int64,
+	statement *string,
+) (*models.Dispute, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txDisputeRepo := repository.NewDisputeRepository(tx)
+
+	dispute, err := txDisputeRepo.GetByIDForUpdate(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.CoachID != coachID {
+		return nil, ErrForbidden
+	}
+	if dispute.Status != "needs_response" {
+		return nil, ErrInvalidStateTransition
+	}
+	now := time.Now().UTC()
+	if dispute.EvidenceDueBy != nil && !now.Before(*dispute.EvidenceDueBy) {
+		return nil, ErrInvalidStateTransition
+	}
+
+	payment, err := repository.NewPaymentRepository(tx).GetByID(ctx, dispute.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	evidence, err := s.gatherEvidence(ctx, tx, payment, dispute.CreatedAt, now)
+	if err != nil {
+		return nil, err
+	}
+	evidence.Statement = blankToNil(statement)
+
+	updated, err := txDisputeRepo.SubmitEvidence(ctx, dispute.ID, evidence)
+	if err != nil {
+		return nil, err
+	}
+	// Submit before committing so a gateway rejection leaves the dispute open for another attempt.
+	if err := s.gateway.SubmitDisputeEvidence(ctx, dispute.GatewayDisputeID, evidence); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *DisputeService) gatherEvidence(
+	ctx context.Context,
+	db repository.DBTX,
+	payment *models.Payment,
+	disputedAt time.Time,
+	now time.Time,
+) (*models.DisputeEvidence, error) {
+	evidence := &models.DisputeEvidence{GatheredAt: now}
+
+	if payment.SessionID != nil {
+		session, err := repository.NewSessionRepository(db).GetByID(ctx, *payment.SessionID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		if session != nil {
+			evidence.Session = &models.DisputeSessionEvidence{
+				SessionID:       session.ID,
+				ScheduledAt:     session.ScheduledAt,
+				DurationMinutes: session.DurationMinutes,
+				Status:          session.Status,
+				BookedAt:        session.CreatedAt,
+			}
+		}
+	}
+
+	messages, err := repository.NewMessageRepository(db).ListBetweenParticipants(
+		ctx,
+		payment.UserID,
+		payment.CoachID,
+		payment.CreatedAt.Add(-disputeChatLookback),
+		disputedAt,
+		disputeChatExcerptSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	evidence.ChatExcerpts = buildChatExcerpts(messages, payment.CoachID)
+
+	programs, err := repository.NewWorkoutProgramRepository(db).ListByCoachAndUser(ctx, payment.CoachID, payment.UserID)
+	if err != nil {
+		return nil, err
+	}
+	evidence.ProgramDeliveries = buildProgramDeliveries(programs, payment)
+
+	return evidence, nil
+}
+
+func (s *DisputeService) HandleGatewayEvent(ctx context.Context, event GatewayEvent) error {
+	if strings.TrimSpace(event.Data.DisputeID) == "" {
+		return ErrInvalidInput
+	}
+
+	switch event.Type {
+	case "dispute.created":
+		return s.handleDisputeCreated(ctx, event)
+	case "dispute.closed":
+		return s.handleDisputeClosed(ctx, event)
+	default:
+		return ErrUnsupportedGatewayEvent
+	}
+}
+
+func (s *DisputeService) handleDisputeCreated(ctx context.Context, event GatewayEvent) error {
+	if event.Data.PaymentID == nil || *event.Data.PaymentID <= 0 {
+		return ErrInvalidInput
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txPaymentRepo := repository.NewPaymentRepository(tx)
+
+	recorded, err := txPaymentRepo.RecordGatewayEvent(ctx, event.ID, event.Type, payload)
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return nil
+	}
+
+	payment, err := txPaymentRepo.GetByIDForUpdate(ctx, *event.Data.PaymentID)
+	if err != nil {
+		return err
+	}
+	if payment.Status == "placeholder" {
+		return ErrInvalidStateTransition
+	}
+
+	amount := payment.Amount
+	if event.Data.Amount != nil && *event.Data.Amount > 0 {
+		amount = *event.Data.Amount
+	}
+	reason := strings.TrimSpace(event.Data.Reason)
+	if _, err := repository.NewDisputeRepository(tx).Create(ctx, repository.CreateDisputeInput{
+		GatewayDisputeID: strings.TrimSpace(event.Data.DisputeID),
+		PaymentID:        payment.ID,
+		UserID:           payment.UserID,
+		CoachID:          payment.CoachID,
+		Amount:           amount,
+		Reason:           blankToNil(&reason),
+		EvidenceDueBy:    event.Data.EvidenceDueBy,
+	}); err != nil {
+		return err
+	}
+
+	// Refunded payments can still be disputed; their status already reflects the reversal.
+	if payment.Status == "paid" {
+		if _, err := txPaymentRepo.UpdateStatus(ctx, payment.ID, "disputed"); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *DisputeService) handleDisputeClosed(ctx context.Context, event GatewayEvent) error {
+	outcome := event.Data.Status
+	if outcome != "won" && outcome != "lost" {
+		return ErrInvalidInput
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txPaymentRepo := repository.NewPaymentRepository(tx)
+	txDisputeRepo := repository.NewDisputeRepository(tx)
+
+	recorded, err := txPaymentRepo.RecordGatewayEvent(ctx, event.ID, event.Type, payload)
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return nil
+	}
+
+	dispute, err := txDisputeRepo.GetByGatewayIDForUpdate(ctx, strings.TrimSpace(event.Data.DisputeID))
+	if err != nil {
+		return err
+	}
+	if dispute.Status == "won" || dispute.Status == "lost" {
+		return tx.Commit(ctx)
+	}
+	if _, err := txDisputeRepo.Close(ctx, dispute.ID, outcome); err != nil {
+		return err
+	}
+
+	payment, err := txPaymentRepo.GetByIDForUpdate(ctx, dispute.PaymentID)
+	if err != nil {
+		return err
+	}
+
+	var creditNote *models.Invoice
+	if outcome == "won" {
+		if payment.Status == "disputed" {
+			if _, err := txPaymentRepo.UpdateStatus(ctx, payment.ID, "paid"); err != nil {
+				return err
+			}
+		}
+	} else {
+		if payment.Status == "disputed" {
+			chargedBack, err := txPaymentRepo.UpdateStatus(ctx, payment.ID, "charged_back")
+			if err != nil {
+				return err
+			}
+			creditNote, err = s.invoiceService.IssueForPayment(ctx, tx, chargedBack, invoiceKindCreditNote)
+			if err != nil {
+				return err
+			}
+		}
+		for _, adjustment := range chargebackAdjustments(dispute, payment, event.Data.Fee) {
+			if err := txDisputeRepo.CreateAdjustment(ctx, adjustment); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	if creditNote != nil {
+		s.invoiceService.storePDFs(ctx, creditNote)
+	}
+	return nil
+}
+
+// chargebackAdjustments debits the coach for the disputed amount and any gateway fee. Money
+// already refunded to the client was taken back from the coach then, so only the part of the
+// payment that was not refunded is charged back.
+func chargebackAdjustments(
+	dispute *models.Dispute,
+	payment *models.Payment,
+	fee *float64,
+) []repository.CreateLedgerAdjustmentInput {
+	disputeID := dispute.ID
+	var adjustments []repository.CreateLedgerAdjustmentInput
+
+	// Refunds always cover the whole payment.
+	remaining := payment.Amount
+	if payment.Status == "refunded" {
+		remaining = 0
+	}
+	amount := math.Round(math.Min(dispute.Amount, remaining)*100) / 100
+	if amount > 0 {
+		adjustments = append(adjustments, repository.CreateLedgerAdjustmentInput{
+			CoachID:   dispute.CoachID,
+			PaymentID: dispute.PaymentID,
+			DisputeID: &disputeID,
+			Kind:      "chargeback",
+			Amount:    -amount,
+		})
+	}
+	if fee != nil && *fee > 0 {
+		adjustments = append(adjustments, repository.CreateLedgerAdjustmentInput{
+			CoachID:   dispute.CoachID,
+			PaymentID: dispute.PaymentID,
+			DisputeID: &disputeID,
+			Kind:      "dispute_fee",
+			Amount:    -*fee,
+		})
+	}
+	return adjustments
+}
+
+func buildChatExcerpts(messages []models.ChatMessage, coachID int64) []models.DisputeChatExcerpt {
+	excerpts := make([]models.DisputeChatExcerpt, 0, len(messages))
+	for _, message := range messages {
+		senderRole := "client"
+		if message.SenderID == coachID {
+			senderRole = "coach"
+		}
+		excerpts = append(excerpts, models.DisputeChatExcerpt{
+			SenderRole: senderRole,
+			Content:    message.Content,
+			SentAt:     message.CreatedAt,
+		})
+	}
+	return excerpts
+}
+
+// buildProgramDeliveries keeps programs tied to the disputed session, plus the relationship's own
+// programs delivered in the month after the payment, or every program delivered to the client when
+// the payment was not for a single session.
+func buildProgramDeliveries(programs []models.WorkoutProgram, payment *models.Payment) []models.DisputeProgramDelivery {
+	periodEnd := payment.CreatedAt.AddDate(0, 1, 0)
+	deliveries := make([]models.DisputeProgramDelivery, 0, len(programs))
+	for _, program := range programs {
+		if payment.SessionID != nil && program.SessionID != *payment.SessionID {
+			if program.SessionID != 0 || program.CreatedAt.Before(payment.CreatedAt) || !program.CreatedAt.Before(periodEnd) {
+				continue
+			}
+		}
+		deliveries = append(deliveries, models.DisputeProgramDelivery{
+			ProgramID:   program.ID,
+			SessionID:   program.SessionID,
+			Title:       program.Title,
+			DeliveredAt: program.CreatedAt,
+		})
+	}
+	return deliveries
+}
+
+func canAccessDispute(role string, actorID int64, dispute *models.Dispute) bool {
+	if role == "user" {
+		return dispute.UserID == actorID
+	}
+	if role == "coach" {
+		return dispute.CoachID == actorID
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+func TestChargebackAdjustments(t *testing.T) {
+	dispute := &models.Dispute{ID: 4, PaymentID: 9, CoachID: 2, Amount: 80}
+	payment := &models.Payment{ID: 9, Amount: 80, Status: "disputed"}
+
+	adjustments := chargebackAdjustments(dispute, payment, nil)
+	if len(adjustments) != 1 {
+		t.Fatalf("expected one adjustment, got %+v", adjustments)
+	}
+	if adjustments[0].Kind != "chargeback" || adjustments[0].Amount != -80 || *adjustments[0].DisputeID != 4 {
+		t.Fatalf("unexpected chargeback adjustment: %+v", adjustments[0])
+	}
+
+	fee := 15.0
+	adjustments = chargebackAdjustments(dispute, payment, &fee)
+	if len(adjustments) != 2 || adjustments[1].Kind != "dispute_fee" || adjustments[1].Amount != -15 {
+		t.Fatalf("unexpected adjustments with fee: %+v", adjustments)
+	}
+
+	zero := 0.0
+	if adjustments = chargebackAdjustments(dispute, payment, &zero); len(adjustments) != 1 {
+		t.Fatalf("expected zero fee to be skipped, got %+v", adjustments)
+	}
+
+	overclaimed := &models.Dispute{ID: 4, PaymentID: 9, CoachID: 2, Amount: 120}
+	if adjustments = chargebackAdjustments(overclaimed, payment, nil); len(adjustments) != 1 || adjustments[0].Amount != -80 {
+		t.Fatalf("expected the chargeback capped at the payment, got %+v", adjustments)
+	}
+}
+
+func TestChargebackAdjustmentsOnRefundedPayment(t *testing.T) {
+	dispute := &models.Dispute{ID: 4, PaymentID: 9, CoachID: 2, Amount: 80}
+	payment := &models.Payment{ID: 9, Amount: 80, Status: "refunded"}
+
+	if adjustments := chargebackAdjustments(dispute, payment, nil); len(adjustments) != 0 {
+		t.Fatalf("expected no chargeback for a refunded payment, got %+v", adjustments)
+	}
+
+	fee := 15.0
+	adjustments := chargebackAdjustments(dispute, payment, &fee)
+	if len(adjustments) != 1 || adjustments[0].Kind != "dispute_fee" || adjustments[0].Amount != -15 {
+		t.Fatalf("expected only the dispute fee, got %+v", adjustments)
+	}
+}
+
+func TestBuildChatExcerptsLabelsSenders(t *testing.T) {
+	sentAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	excerpts := buildChatExcerpts([]models.ChatMessage{
+		{SenderID: 5, Content: "See you Tuesday", CreatedAt: sentAt},
+		{SenderID: 2, Content: "Program attached", CreatedAt: sentAt.Add(time.Minute)},
+	}, 2)
+
+	if len(excerpts) != 2 {
+		t.Fatalf("expected 2 excerpts, got %d", len(excerpts))
+	}
+	if excerpts[0].SenderRole != "client" || excerpts[1].SenderRole != "coach" {
+		t.Fatalf("unexpected sender roles: %+v", excerpts)
+	}
+	if !excerpts[0].SentAt.Equal(sentAt) || excerpts[0].Content != "See you Tuesday" {
+		t.Fatalf("unexpected excerpt: %+v", excerpts[0])
+	}
+}
+
+func TestBuildProgramDeliveries(t *testing.T) {
+	paidAt := time.Date(2030, 3, 1, 9, 0, 0, 0, time.UTC)
+	programs := []models.WorkoutProgram{
+		{ID: 1, SessionID: 10, Title: "Week 1", CreatedAt: paidAt.Add(time.Hour)},
+		{ID: 2, SessionID: 11, Title: "Week 2", CreatedAt: paidAt.Add(time.Hour)},
+		{ID: 3, Title: "Mobility", CreatedAt: paidAt.AddDate(0, 0, 10)},
+		{ID: 4, Title: "Old plan", CreatedAt: paidAt.AddDate(0, 0, -1)},
+		{ID: 5, Title: "Next month", CreatedAt: paidAt.AddDate(0, 1, 1)},
+	}
+
+	sessionID := int64(11)
+	deliveries := buildProgramDeliveries(programs, &models.Payment{SessionID: &sessionID, CreatedAt: paidAt})
+	if len(deliveries) != 2 || deliveries[0].ProgramID != 2 || deliveries[1].ProgramID != 3 {
+		t.Fatalf("expected the session's program and the relationship program from that month, got %+v", deliveries)
+	}
+
+	subscriptionID := int64(3)
+	deliveries = buildProgramDeliveries(programs, &models.Payment{SubscriptionID: &subscriptionID, CreatedAt: paidAt})
+	if len(deliveries) != len(programs) {
+		t.Fatalf("expected every program for subscription payments, got %+v", deliveries)
+	}
+}
+
+func TestCanAccessDispute(t *testing.T) {
+	dispute := &models.Dispute{UserID: 5, CoachID: 2}
+
+	tests := []struct {
+		role     string
+		actorID  int64
+		expected bool
+	}{
+		{role: "user", actorID: 5, expected: true},
+		{role: "user", actorID: 2, expected: false},
+		{role: "coach", actorID: 2, expected: true},
+		{role: "coach", actorID: 5, expected: false},
+		{role: "admin", actorID: 2, expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := canAccessDispute(tt.role, tt.actorID, dispute); got != tt.expected {
+			t.Fatalf("%s %d: expected %v, got %v", tt.role, tt.actorID, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+// ExerciseCatalogEntry is one exercise in an import file. MediaURL is stored as-is; MediaFile is
+// a local path that gets uploaded to storage.
+type ExerciseCatalogEntry struct {
+	Name         string   `json:"name"`
+	Aliases      []string `json:"aliases"`
+	MuscleGroups []string `json:"muscle_groups"`
+	Equipment    []string `json:"equipment"`
+	Difficulty   string   `json:"difficulty"`
+	Instructions string   `json:"instructions"`
+	MediaURL     string   `json:"media_url"`
+	MediaFile    string   `json:"media_file"`
+}
+
+// catalogListSeparator splits multi-value CSV cells such as "chest|triceps".
+const catalogListSeparator = "|"
+
+// ParseExerciseCatalog reads a JSON array or a CSV file with a header row. Relative media_file
+// paths are resolved against baseDir.
+func ParseExerciseCatalog(r io.Reader, format string, baseDir string) ([]ExerciseCatalogEntry, error) {
+	var (
+		entries []ExerciseCatalogEntry
+		err     error
+	)
+	switch strings.ToLower(format) {
+	case "json":
+		err = json.NewDecoder(r).Decode(&entries)
+	case "csv":
+		entries, err = parseExerciseCatalogCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported catalog format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		if path := strings.TrimSpace(entries[i].MediaFile); path != "" && !filepath.IsAbs(path) {
+			entries[i].MediaFile = filepath.Join(baseDir, path)
+		}
+	}
+	return entries, nil
+}
+
+func parseExerciseCatalogCSV(r io.Reader) ([]ExerciseCatalogEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("catalog is empty")
+		}
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("catalog header must include a name column")
+	}
+
+	entries := make([]ExerciseCatalogEntry, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cell := func(column string) string {
+			index, ok := columns[column]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+		list := func(column string) []string {
+			value := cell(column)
+			if value == "" {
+				return nil
+			}
+			return strings.Split(value, catalogListSeparator)
+		}
+
+		entries = append(entries, ExerciseCatalogEntry{
+			Name:         cell("name"),
+			Aliases:      list("aliases"),
+			MuscleGroups: list("muscle_groups"),
+			Equipment:    list("equipment"),
+			Difficulty:   cell("difficulty"),
+			Instructions: cell("instructions"),
+			MediaURL:     cell("media_url"),
+			MediaFile:    cell("media_file"),
+		})
+	}
+	return entries, nil
+}
+
+func (e ExerciseCatalogEntry) exerciseInput() repository.ExerciseInput {
+	input := repository.ExerciseInput{
+		Name:         e.Name,
+		Aliases:      e.Aliases,
+		MuscleGroups: e.MuscleGroups,
+		Equipment:    e.Equipment,
+		Difficulty:   e.Difficulty,
+	}
+	if e.Instructions != "" {
+		instructions := e.Instructions
+		input.Instructions = &instructions
+	}
+	if e.MediaURL != "" {
+		mediaURL := e.MediaURL
+		input.MediaURL = &mediaURL
+	}
+	return input
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseExerciseCatalogJSON(t *testing.T) {
+	input := `[
+		{"name": "Back Squat", "aliases": ["High bar squat"], "muscle_groups": ["quads", "glutes"],
+		 "equipment": ["barbell"], "difficulty": "intermediate", "media_file": "media/squat.mp4"},
+		{"name": "Push-up", "media_url": "https://cdn.example.com/pushup.gif"}
+	]`
+
+	entries, err := ParseExerciseCatalog(strings.NewReader(input), "json", "/catalog")
+	if err != nil {
+		t.Fatalf("ParseExerciseCatalog: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].MediaFile != filepath.Join("/catalog", "media/squat.mp4") {
+		t.Fatalf("expected media file resolved against base dir, got %q", entries[0].MediaFile)
+	}
+	if len(entries[0].MuscleGroups) != 2 || entries[0].Difficulty != "intermediate" {
+		t.Fatalf("unexpected entry: %+v", entries[0])
+	}
+	if entries[1].MediaURL != "https://cdn.example.com/pushup.gif" || entries[1].MediaFile != "" {
+		t.Fatalf("unexpected media fields: %+v", entries[1])
+	}
+}
+
+func TestParseExerciseCatalogCSV(t *testing.T) {
+	input := "name,aliases,muscle_groups,equipment,difficulty,instructions\n" +
+		"Bench Press,Flat bench|BP,chest|triceps,barbell|bench,intermediate,\"Lower to chest, press up\"\n" +
+		"Plank,,core,,beginner,\n"
+
+	entries, err := ParseExerciseCatalog(strings.NewReader(input), "CSV", ".")
+	if err != nil {
+		t.Fatalf("ParseExerciseCatalog: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	bench := entries[0]
+	if bench.Name != "Bench Press" || len(bench.Aliases) != 2 || bench.Aliases[1] != "BP" {
+		t.Fatalf("unexpected bench entry: %+v", bench)
+	}
+	if bench.Instructions != "Lower to chest, press up" {
+		t.Fatalf("unexpected instructions: %q", bench.Instructions)
+	}
+	if entries[1].Aliases != nil || len(entries[1].MuscleGroups) != 1 {
+		t.Fatalf("unexpected plank entry: %+v", entries[1])
+	}
+}
+
+func TestParseExerciseCatalogRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format string
+	}{
+		{name: "unknown format", input: "[]", format: "xml"},
+		{name: "csv without name column", input: "title,difficulty\nSquat,beginner\n", format: "csv"},
+		{name: "empty csv", input: "", format: "csv"},
+		{name: "malformed json", input: "{", format: "json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseExerciseCatalog(strings.NewReader(tt.input), tt.format, "."); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const maxExerciseTags = 20
+
+type ExerciseImportResult struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+}
+
+type ExerciseService struct {
+	db             *pgxpool.Pool
+	exerciseRepo   *repository.ExerciseRepository
+	storageService StorageService
+}
+
+func NewExerciseService(
+	db *pgxpool.Pool,
+	exerciseRepo *repository.ExerciseRepository,
+	storageService StorageService,
+) *ExerciseService {
+	return &ExerciseService{
+		db:             db,
+		exerciseRepo:   exerciseRepo,
+		storageService: storageService,
+	}
+}
+
+// SearchExercises shows users the shared catalog and coaches the catalog plus their own exercises.
+func (s *ExerciseService) SearchExercises(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	filter repository.ExerciseSearchFilter,
+) ([]models.Exercise, int, error) {
+	if filter.Difficulty != "" && !isExerciseDifficulty(filter.Difficulty) {
+		return nil, 0, ErrInvalidInput
+	}
+
+	switch role {
+	case "coach":
+		filter.CoachID = actorID
+	case "user":
+		if filter.CustomOnly {
+			return nil, 0, ErrForbidden
+		}
+		filter.CoachID = 0
+	default:
+		return nil, 0, ErrForbidden
+	}
+
+	return s.exerciseRepo.Search(ctx, filter)
+}
+
+func (s *ExerciseService) GetExercise(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	exerciseID int64,
+) (*models.Exercise, error) {
+	exercise, err := s.exerciseRepo.GetByID(ctx, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	if exercise.CoachID != nil && (role != "coach" || *exercise.CoachID != actorID) {
+		return nil, ErrForbidden
+	}
+	return exercise, nil
+}
+
+func (s *ExerciseService) CreateExercise(
+	ctx context.Context,
+	coachID int64,
+	input repository.ExerciseInput,
+) (*models.Exercise, error) {
+	if coachID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	if err := normalizeExerciseInput(&input); err != nil {
+		return nil, err
+	}
+	// Media is attached through UploadMedia so only storage-backed URLs are accepted.
+	input.MediaURL = nil
+
+	exercise, err := s.exerciseRepo.Create(ctx, coachID, input)
+	if err != nil {
+		return nil, mapExerciseWriteError(err)
+	}
+	return exercise, nil
+}
+
+func (s *ExerciseService) UpdateExercise(
+	ctx context.Context,
+	coachID int64,
+	exerciseID int64,
+	input repository.ExerciseInput,
+) (*models.Exercise, error) {
+	if _, err := s.getCustomExercise(ctx, coachID, exerciseID); err != nil {
+		return nil, err
+	}
+	if err := normalizeExerciseInput(&input); err != nil {
+		return nil, err
+	}
+
+	exercise, err := s.exerciseRepo.Update(ctx, exerciseID, coachID, input)
+	if err != nil {
+		return nil, mapExerciseWriteError(err)
+	}
+	return exercise, nil
+}
+
+func (s *ExerciseService) DeleteExercise(ctx context.Context, coachID int64, exerciseID int64) error {
+	exercise, err := s.getCustomExercise(ctx, coachID, exerciseID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.exerciseRepo.Delete(ctx, exerciseID, coachID); err != nil {
+		return err
+	}
+	if exercise.MediaURL != nil && s.storageService != nil {
+		_ = s.storageService.DeleteFile(ctx, *exercise.MediaURL)
+	}
+	return nil
+}
+
+func (s *ExerciseService) UploadMedia(
+	ctx context.Context,
+	coachID int64,
+	exerciseID int64,
+	file multipart.File,
+	originalFilename string,
+) (*models.Exercise, error) {
+	if s.storageService == nil {
+		return nil, ErrStorageUnavailable
+	}
+	if file == nil {
+		return nil, ErrInvalidInput
+	}
+
+	exercise, err := s.getCustomExercise(ctx, coachID, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(strings.TrimSpace(originalFilename)))
+	filename := fmt.Sprintf("%d-%d-%d%s", coachID, exerciseID, time.Now().UnixNano(), ext)
+	mediaURL, err := s.storageService.UploadFile(ctx, file, filename, "exercises/media")
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.exerciseRepo.SetMediaURL(ctx, exerciseID, coachID, mediaURL)
+	if err != nil {
+		cleanupErr := s.storageService.DeleteFile(ctx, mediaURL)
+		if cleanupErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("cleanup failed: %w", cleanupErr))
+		}
+		return nil, err
+	}
+	if exercise.MediaURL != nil && *exercise.MediaURL != mediaURL {
+		_ = s.storageService.DeleteFile(ctx, *exercise.MediaURL)
+	}
+	return updated, nil
+}
+
+// ImportCatalog upserts entries into the shared catalog in one transaction. Local media files
+// are uploaded under a name derived from the exercise, so re-running an import overwrites them.
+func (s *ExerciseService) ImportCatalog(ctx context.Context, entries []ExerciseCatalogEntry) (*ExerciseImportResult, error) {
+	inputs := make([]repository.ExerciseInput, len(entries))
+	for i, entry := range entries {
+		inputs[i] = entry.exerciseInput()
+		if err := normalizeExerciseInput(&inputs[i]); err != nil {
+			return nil, fmt.Errorf("entry %d (%q): %w", i+1, entry.Name, err)
+		}
+		if entry.MediaFile != "" && s.storageService == nil {
+			return nil, fmt.Errorf("entry %d (%q): %w", i+1, entry.Name, ErrStorageUnavailable)
+		}
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txExerciseRepo := repository.NewExerciseRepository(tx)
+
+	result := &ExerciseImportResult{}
+	for i, entry := range entries {
+		input := inputs[i]
+		if entry.MediaFile != "" {
+			mediaURL, err := s.uploadCatalogMedia(ctx, input.Name, entry.MediaFile)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d (%q): upload media: %w", i+1, entry.Name, err)
+			}
+			input.MediaURL = &mediaURL
+		}
+
+		inserted, err := txExerciseRepo.UpsertCatalog(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d (%q): %w", i+1, entry.Name, err)
+		}
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *ExerciseService) uploadCatalogMedia(ctx context.Context, name string, path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	filename := exerciseSlug(name) + strings.ToLower(filepath.Ext(path))
+	return s.storageService.UploadFile(ctx, file, filename, "exercises/catalog")
+}
+
+// getCustomExercise loads an exercise the coach owns; catalog exercises are read-only.
+func (s *ExerciseService) getCustomExercise(ctx context.Context, coachID int64, exerciseID int64) (*models.Exercise, error) {
+	exercise, err := s.exerciseRepo.GetByID(ctx, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	if exercise.CoachID == nil || *exercise.CoachID != coachID {
+		return nil, ErrForbidden
+	}
+	return exercise, nil
+}
+
+func mapExerciseWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrConflict
+	}
+	return err
+}
+
+func normalizeExerciseInput(input *repository.ExerciseInput) error {
+	input.Name = strings.Join(strings.Fields(input.Name), " ")
+	if input.Name == "" || len(input.Name) > 150 {
+		return ErrInvalidInput
+	}
+
+	var err error
+	if input.Aliases, err = normalizeExerciseTags(input.Aliases, false); err != nil {
+		return err
+	}
+	if input.MuscleGroups, err = normalizeExerciseTags(input.MuscleGroups, true); err != nil {
+		return err
+	}
+	if input.Equipment, err = normalizeExerciseTags(input.Equipment, true); err != nil {
+		return err
+	}
+
+	input.Difficulty = strings.ToLower(strings.TrimSpace(input.Difficulty))
+	if input.Difficulty == "" {
+		input.Difficulty = "beginner"
+	}
+	if !isExerciseDifficulty(input.Difficulty) {
+		return ErrInvalidInput
+	}
+
+	input.Instructions = blankToNil(input.Instructions)
+	if input.Instructions != nil && len(*input.Instructions) > 10000 {
+		return ErrInvalidInput
+	}
+	input.MediaURL = blankToNil(input.MediaURL)
+	return nil
+}
+
+// normalizeExerciseTags trims and de-duplicates values case-insensitively, lower-casing them
+// when they are used as filters.
+func normalizeExerciseTags(values []string, lower bool) ([]string, error) {
+	normalized := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		value = strings.Join(strings.Fields(value), " ")
+		if lower {
+			value = strings.ToLower(value)
+		}
+		key := strings.ToLower(value)
+		if value == "" || seen[key] {
+			continue
+		}
+		if len(value) > 100 {
+			return nil, ErrInvalidInput
+		}
+		seen[key] = true
+		normalized = append(normalized, value)
+	}
+	if len(normalized) > maxExerciseTags {
+		return nil, ErrInvalidInput
+	}
+	return normalized, nil
+}
+
+func isExerciseDifficulty(difficulty string) bool {
+	switch difficulty {
+	case "beginner", "intermediate", "advanced":
+		return true
+	default:
+		return false
+	}
+}
+
+func exerciseSlug(name string) string {
+	var builder strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			builder.WriteRune(r)
+			dash = false
+		case !dash && builder.Len() > 0:
+			builder.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "-")
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+func TestNormalizeExerciseInput(t *testing.T) {
+	instructions := "  Keep your back flat.  "
+	input := repository.ExerciseInput{
+		Name:         "  Romanian   Deadlift ",
+		Aliases:      []string{"RDL", " rdl ", ""},
+		MuscleGroups: []string{"Hamstrings", "GLUTES", "hamstrings"},
+		Equipment:    []string{" Barbell "},
+		Instructions: &instructions,
+	}
+
+	if err := normalizeExerciseInput(&input); err != nil {
+		t.Fatalf("normalizeExerciseInput: %v", err)
+	}
+	if input.Name != "Romanian Deadlift" {
+		t.Fatalf("expected collapsed name, got %q", input.Name)
+	}
+	if len(input.Aliases) != 1 || input.Aliases[0] != "RDL" {
+		t.Fatalf("expected de-duplicated aliases, got %v", input.Aliases)
+	}
+	if strings.Join(input.MuscleGroups, ",") != "hamstrings,glutes" {
+		t.Fatalf("expected lower-cased muscle groups, got %v", input.MuscleGroups)
+	}
+	if input.Equipment[0] != "barbell" {
+		t.Fatalf("expected lower-cased equipment, got %v", input.Equipment)
+	}
+	if input.Difficulty != "beginner" {
+		t.Fatalf("expected default difficulty, got %q", input.Difficulty)
+	}
+	if *input.Instructions != "Keep your back flat." {
+		t.Fatalf("expected trimmed instructions, got %q", *input.Instructions)
+	}
+}
+
+func TestNormalizeExerciseInputRejectsInvalidValues(t *testing.T) {
+	tooManyTags := make([]string, maxExerciseTags+1)
+	for i := range tooManyTags {
+		tooManyTags[i] = strings.Repeat("x", i+1)
+	}
+
+	tests := []struct {
+		name  string
+		input repository.ExerciseInput
+	}{
+		{name: "blank name", input: repository.ExerciseInput{Name: "  "}},
+		{name: "long name", input: repository.ExerciseInput{Name: strings.Repeat("a", 151)}},
+		{name: "unknown difficulty", input: repository.ExerciseInput{Name: "Squat", Difficulty: "expert"}},
+		{name: "too many muscle groups", input: repository.ExerciseInput{Name: "Squat", MuscleGroups: tooManyTags}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := normalizeExerciseInput(&tt.input); !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("expected ErrInvalidInput, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExerciseSlug(t *testing.T) {
+	tests := map[string]string{
+		"Back Squat":           "back-squat",
+		"  Push-up (Incline) ": "push-up-incline",
+		"90/90 Hip Switch":     "90-90-hip-switch",
+	}
+	for name, want := range tests {
+		if got := exerciseSlug(name); got != want {
+			t.Fatalf("exerciseSlug(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
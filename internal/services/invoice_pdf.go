@@ -0,0 +1,90 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/pkg/pdf"
+)
+
+const (
+	invoiceMarginLeft  = 50.0
+	invoiceMarginRight = pdf.PageWidth - 50.0
+	invoicePartyColumn = 320.0
+)
+
+func renderInvoicePDF(invoice *models.Invoice) []byte {
+	doc := pdf.New()
+	page := doc.AddPage()
+
+	title := "INVOICE"
+	if invoice.Kind == invoiceKindCreditNote {
+		title = "CREDIT NOTE"
+	}
+	page.Text(invoiceMarginLeft, 70, pdf.Bold, 22, title)
+	page.TextRight(invoiceMarginRight, 62, pdf.Regular, 10, "Number: "+invoice.InvoiceNumber)
+	page.TextRight(invoiceMarginRight, 76, pdf.Regular, 10, "Issued: "+invoice.IssuedAt.UTC().Format("2006-01-02"))
+	page.Line(invoiceMarginLeft, 92, invoiceMarginRight, 92, 0.75)
+
+	sellerLines := []string{invoice.SellerName, invoice.SellerEmail}
+	sellerLines = append(sellerLines, splitAddress(invoice.SellerAddress)...)
+	if invoice.SellerTaxID != nil {
+		sellerLines = append(sellerLines, "Tax ID: "+*invoice.SellerTaxID)
+	}
+	drawPartyBlock(page, invoiceMarginLeft, 125, "From", sellerLines)
+	drawPartyBlock(page, invoicePartyColumn, 125, "Bill to", []string{invoice.BuyerName, invoice.BuyerEmail})
+
+	tableTop := 125.0 + 16*float64(len(sellerLines)+2)
+	page.Text(invoiceMarginLeft, tableTop, pdf.Bold, 11, "Description")
+	page.TextRight(invoiceMarginRight, tableTop, pdf.Bold, 11, "Amount")
+	page.Line(invoiceMarginLeft, tableTop+8, invoiceMarginRight, tableTop+8, 0.5)
+
+	rowY := tableTop + 26
+	page.Text(invoiceMarginLeft, rowY, pdf.Regular, 11, invoice.Description)
+	page.TextRight(invoiceMarginRight, rowY, pdf.Regular, 11, formatInvoiceAmount(invoice.Subtotal))
+	page.Line(invoiceMarginLeft, rowY+10, invoiceMarginRight, rowY+10, 0.5)
+
+	totalsLabelX := invoicePartyColumn
+	totalsY := rowY + 32
+	page.Text(totalsLabelX, totalsY, pdf.Regular, 11, "Subtotal")
+	page.TextRight(invoiceMarginRight, totalsY, pdf.Regular, 11, formatInvoiceAmount(invoice.Subtotal))
+	page.Text(totalsLabelX, totalsY+18, pdf.Regular, 11, fmt.Sprintf("Tax (%s%%)", formatTaxRate(invoice.TaxRate)))
+	page.TextRight(invoiceMarginRight, totalsY+18, pdf.Regular, 11, formatInvoiceAmount(invoice.TaxAmount))
+	page.Line(totalsLabelX, totalsY+28, invoiceMarginRight, totalsY+28, 0.5)
+	page.Text(totalsLabelX, totalsY+46, pdf.Bold, 12, "Total")
+	page.TextRight(invoiceMarginRight, totalsY+46, pdf.Bold, 12, formatInvoiceAmount(invoice.Total))
+
+	footer := fmt.Sprintf("Payment reference #%d. This document was issued electronically and is valid without a signature.", invoice.PaymentID)
+	page.Text(invoiceMarginLeft, pdf.PageHeight-50, pdf.Regular, 8, footer)
+
+	return doc.Bytes()
+}
+
+func drawPartyBlock(page *pdf.Page, x float64, y float64, heading string, lines []string) {
+	page.Text(x, y, pdf.Bold, 11, heading)
+	for i, line := range lines {
+		page.Text(x, y+16*float64(i+1), pdf.Regular, 10, line)
+	}
+}
+
+func splitAddress(address *string) []string {
+	if address == nil {
+		return nil
+	}
+	lines := make([]string, 0)
+	for _, line := range strings.Split(*address, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			lines = append(lines, trimmed)
+		}
+	}
+	return lines
+}
+
+func formatInvoiceAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+func formatTaxRate(rate float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", rate), "0"), ".")
+}
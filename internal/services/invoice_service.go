@@ -0,0 +1,333 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	invoiceKindInvoice    = "invoice"
+	invoiceKindCreditNote = "credit_note"
+)
+
+type InvoiceDownload struct {
+	Invoice     *models.Invoice
+	DownloadURL string
+}
+
+type InvoiceService struct {
+	db             *pgxpool.Pool
+	invoiceRepo    *repository.InvoiceRepository
+	paymentRepo    *repository.PaymentRepository
+	storageService StorageService
+}
+
+func NewInvoiceService(
+	db *pgxpool.Pool,
+	invoiceRepo *repository.InvoiceRepository,
+	paymentRepo *repository.PaymentRepository,
+	storageService StorageService,
+) *InvoiceService {
+	return &InvoiceService{
+		db:             db,
+		invoiceRepo:    invoiceRepo,
+		paymentRepo:    paymentRepo,
+		storageService: storageService,
+	}
+}
+
+func (s *InvoiceService) GetBillingDetails(ctx context.Context, coachID int64) (*models.CoachBillingDetails, error) {
+	return s.invoiceRepo.GetBillingDetails(ctx, coachID)
+}
+
+func (s *InvoiceService) UpdateBillingDetails(
+	ctx context.Context,
+	coachID int64,
+	input repository.UpdateBillingDetailsInput,
+) (*models.CoachBillingDetails, error) {
+	if coachID <= 0 || input.TaxRate < 0 || input.TaxRate >= 100 {
+		return nil, ErrInvalidInput
+	}
+
+	input.LegalName = blankToNil(input.LegalName)
+	input.TaxID = blankToNil(input.TaxID)
+	input.Address = blankToNil(input.Address)
+
+	return s.invoiceRepo.UpsertBillingDetails(ctx, coachID, input)
+}
+
+// IssueForPayment records an invoice (or a credit note for refunds) inside the caller's
+// transaction so the document number is only consumed if the payment change commits.
+// Zero-amount payments, such as sessions covered by a subscription, are not invoiced.
+func (s *InvoiceService) IssueForPayment(
+	ctx context.Context,
+	db repository.DBTX,
+	payment *models.Payment,
+	kind string,
+) (*models.Invoice, error) {
+	if kind != invoiceKindInvoice && kind != invoiceKindCreditNote {
+		return nil, ErrInvalidInput
+	}
+	if payment.Amount <= 0 {
+		return nil, nil
+	}
+
+	txInvoiceRepo := repository.NewInvoiceRepository(db)
+
+	existing, err := txInvoiceRepo.GetByPaymentID(ctx, payment.ID, kind)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	billing, err := txInvoiceRepo.GetBillingDetails(ctx, payment.CoachID)
+	if err != nil {
+		return nil, err
+	}
+	seller, err := txInvoiceRepo.GetBillingParty(ctx, payment.CoachID)
+	if err != nil {
+		return nil, err
+	}
+	buyer, err := txInvoiceRepo.GetBillingParty(ctx, payment.UserID)
+	if err != nil {
+		return nil, err
+	}
+	sequence, err := txInvoiceRepo.NextSequenceNumber(ctx, payment.CoachID)
+	if err != nil {
+		return nil, err
+	}
+
+	subtotal, taxAmount := splitInclusiveTax(payment.Amount, billing.TaxRate)
+	total := payment.Amount
+	if kind == invoiceKindCreditNote {
+		subtotal, taxAmount, total = -subtotal, -taxAmount, -total
+	}
+
+	sellerName := seller.Name
+	if billing.LegalName != nil {
+		sellerName = *billing.LegalName
+	}
+
+	return txInvoiceRepo.Create(ctx, repository.CreateInvoiceInput{
+		InvoiceNumber:  formatInvoiceNumber(kind, payment.CoachID, sequence),
+		Kind:           kind,
+		PaymentID:      payment.ID,
+		CoachID:        payment.CoachID,
+		UserID:         payment.UserID,
+		SequenceNumber: sequence,
+		Description:    describePayment(payment, kind),
+		Subtotal:       subtotal,
+		TaxRate:        billing.TaxRate,
+		TaxAmount:      taxAmount,
+		Total:          total,
+		SellerName:     fallbackPartyName(sellerName, seller.Email),
+		SellerEmail:    seller.Email,
+		SellerTaxID:    billing.TaxID,
+		SellerAddress:  billing.Address,
+		BuyerName:      fallbackPartyName(buyer.Name, buyer.Email),
+		BuyerEmail:     buyer.Email,
+	})
+}
+
+// StorePDF renders the invoice and uploads it once; stored documents are never re-rendered.
+func (s *InvoiceService) StorePDF(ctx context.Context, invoice *models.Invoice) (*models.Invoice, error) {
+	if invoice.PDFPath != nil {
+		return invoice, nil
+	}
+	if s.storageService == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	content := renderInvoicePDF(invoice)
+	fileURL, err := s.storageService.UploadFile(
+		ctx,
+		newMemoryFile(content),
+		invoice.InvoiceNumber+".pdf",
+		fmt.Sprintf("invoices/%d", invoice.CoachID),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.invoiceRepo.SetPDFPath(ctx, invoice.ID, fileURL)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s.invoiceRepo.GetByPaymentID(ctx, invoice.PaymentID, invoice.Kind)
+	}
+	return updated, err
+}
+
+// storePDFs is called after a payment transaction commits. Failures are tolerated because
+// GetInvoiceDownload renders any missing PDF on first access.
+func (s *InvoiceService) storePDFs(ctx context.Context, invoices ...*models.Invoice) {
+	for _, invoice := range invoices {
+		if invoice == nil {
+			continue
+		}
+		_, _ = s.StorePDF(ctx, invoice)
+	}
+}
+
+func (s *InvoiceService) GetInvoiceDownload(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	paymentID int64,
+	kind string,
+) (*InvoiceDownload, error) {
+	if kind != invoiceKindInvoice && kind != invoiceKindCreditNote {
+		return nil, ErrInvalidInput
+	}
+	if s.storageService == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccessPayment(role, actorID, payment) {
+		return nil, ErrForbidden
+	}
+
+	invoice, err := s.invoiceRepo.GetByPaymentID(ctx, paymentID, kind)
+	if errors.Is(err, pgx.ErrNoRows) {
+		invoice, err = s.issueMissing(ctx, paymentID, kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err = s.StorePDF(ctx, invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	signedURL, err := s.storageService.GetSignedURL(ctx, *invoice.PDFPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InvoiceDownload{Invoice: invoice, DownloadURL: signedURL}, nil
+}
+
+// issueMissing backfills documents for payments captured before invoicing existed.
+func (s *InvoiceService) issueMissing(ctx context.Context, paymentID int64, kind string) (*models.Invoice, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	payment, err := repository.NewPaymentRepository(tx).GetByIDForUpdate(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	reversed := payment.Status == "refunded" || payment.Status == "charged_back"
+	eligible := reversed ||
+		(kind == invoiceKindInvoice && (payment.Status == "paid" || payment.Status == "disputed"))
+	if !eligible {
+		return nil, pgx.ErrNoRows
+	}
+
+	invoice, err := s.IssueForPayment(ctx, tx, payment, kind)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil {
+		return nil, pgx.ErrNoRows
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+func canAccessPayment(role string, actorID int64, payment *models.Payment) bool {
+	if role == "user" {
+		return payment.UserID == actorID
+	}
+	if role == "coach" {
+		return payment.CoachID == actorID
+	}
+	return false
+}
+
+// splitInclusiveTax treats amount as the gross price and returns the net subtotal and tax.
+func splitInclusiveTax(amount float64, taxRate float64) (float64, float64) {
+	subtotal := math.Round(amount/(1+taxRate/100)*100) / 100
+	tax := math.Round((amount-subtotal)*100) / 100
+	return subtotal, tax
+}
+
+func formatInvoiceNumber(kind string, coachID int64, sequence int) string {
+	prefix := "INV"
+	if kind == invoiceKindCreditNote {
+		prefix = "CN"
+	}
+	return fmt.Sprintf("%s-%d-%06d", prefix, coachID, sequence)
+}
+
+func describePayment(payment *models.Payment, kind string) string {
+	var description string
+	switch {
+	case payment.SessionID != nil:
+		description = fmt.Sprintf("Coaching session #%d", *payment.SessionID)
+	case payment.SubscriptionID != nil:
+		description = fmt.Sprintf("Coaching subscription #%d", *payment.SubscriptionID)
+	default:
+		description = fmt.Sprintf("Coaching payment #%d", payment.ID)
+	}
+	if kind == invoiceKindCreditNote {
+		if payment.Status == "charged_back" {
+			description = "Chargeback: " + description
+		} else {
+			description = "Refund: " + description
+		}
+	}
+	return description
+}
+
+func fallbackPartyName(name string, email string) string {
+	if trimmed := strings.TrimSpace(name); trimmed != "" {
+		return trimmed
+	}
+	return email
+}
+
+func blankToNil(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func newMemoryFile(content []byte) memoryFile {
+	return memoryFile{Reader: bytes.NewReader(content)}
+}
+
+func (memoryFile) Close() error {
+	return nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+func TestSplitInclusiveTax(t *testing.T) {
+	tests := []struct {
+		name             string
+		amount           float64
+		taxRate          float64
+		expectedSubtotal float64
+		expectedTax      float64
+	}{
+		{name: "no tax", amount: 80, taxRate: 0, expectedSubtotal: 80, expectedTax: 0},
+		{name: "nineteen percent", amount: 119, taxRate: 19, expectedSubtotal: 100, expectedTax: 19},
+		{name: "rounds to cents", amount: 50, taxRate: 7.5, expectedSubtotal: 46.51, expectedTax: 3.49},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subtotal, tax := splitInclusiveTax(tt.amount, tt.taxRate)
+			if subtotal != tt.expectedSubtotal || tax != tt.expectedTax {
+				t.Fatalf("expected %.2f + %.2f, got %.2f + %.2f", tt.expectedSubtotal, tt.expectedTax, subtotal, tax)
+			}
+		})
+	}
+}
+
+func TestFormatInvoiceNumber(t *testing.T) {
+	if got := formatInvoiceNumber(invoiceKindInvoice, 42, 7); got != "INV-42-000007" {
+		t.Fatalf("unexpected invoice number %q", got)
+	}
+	if got := formatInvoiceNumber(invoiceKindCreditNote, 42, 8); got != "CN-42-000008" {
+		t.Fatalf("unexpected credit note number %q", got)
+	}
+}
+
+func TestRenderInvoicePDF(t *testing.T) {
+	sessionID := int64(9)
+	taxID := "DE123456789"
+	address := "Main Street 1\n10115 Berlin"
+	content := renderInvoicePDF(&models.Invoice{
+		InvoiceNumber: "CN-42-000008",
+		Kind:          invoiceKindCreditNote,
+		PaymentID:     3,
+		Description:   describePayment(&models.Payment{ID: 3, SessionID: &sessionID}, invoiceKindCreditNote),
+		Subtotal:      -100,
+		TaxRate:       19,
+		TaxAmount:     -19,
+		Total:         -119,
+		SellerName:    "Coach (Pro) GmbH",
+		SellerEmail:   "coach@example.com",
+		SellerTaxID:   &taxID,
+		SellerAddress: &address,
+		BuyerName:     "Sam User",
+		BuyerEmail:    "sam@example.com",
+		IssuedAt:      time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC),
+	})
+
+	for _, expected := range []string{
+		"%PDF-1.4",
+		"(CREDIT NOTE)",
+		"(Number: CN-42-000008)",
+		"(Issued: 2030-01-02)",
+		`(Coach \(Pro\) GmbH)`,
+		"(10115 Berlin)",
+		"(Tax ID: DE123456789)",
+		"(Refund: Coaching session #9)",
+		"(Tax \\(19%\\))",
+		"(-119.00)",
+	} {
+		if !bytes.Contains(content, []byte(expected)) {
+			t.Fatalf("expected rendered PDF to contain %q", expected)
+		}
+	}
+}
+
+func TestStorePDFRequiresStorage(t *testing.T) {
+	service := NewInvoiceService(nil, nil, nil, nil)
+	if _, err := service.StorePDF(t.Context(), &models.Invoice{ID: 1}); err != ErrStorageUnavailable {
+		t.Fatalf("expected ErrStorageUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	ModerationActionFlag  = "flag"
+	ModerationActionMask  = "mask"
+	ModerationActionBlock = "block"
+
+	moderationMask = "***"
+)
+
+// ModerationRule finds content that needs moderating. Match returns the byte ranges it matched, as
+// regexp.FindAllStringIndex does, or nil.
+type ModerationRule interface {
+	Name() string
+	Action() string
+	Match(content string) [][]int
+}
+
+// ModerationVerdict is what the pipeline decided about some content: the strongest action of the
+// rules that matched, their names, and the content to store, masked when that action is mask.
+type ModerationVerdict struct {
+	Action  string
+	Rules   []string
+	Content string
+}
+
+// ModerationPipeline runs every rule over a piece of content. A nil pipeline lets everything through.
+type ModerationPipeline struct {
+	rules []ModerationRule
+}
+
+func NewModerationPipeline(rules ...ModerationRule) *ModerationPipeline {
+	return &ModerationPipeline{rules: rules}
+}
+
+// Moderate returns nil when no rule matches. Only matches of mask rules are masked; text matched by
+// flag rules is kept as written.
+func (p *ModerationPipeline) Moderate(content string) *ModerationVerdict {
+	if p == nil || content == "" {
+		return nil
+	}
+
+	var verdict *ModerationVerdict
+	var masked [][]int
+	for _, rule := range p.rules {
+		matches := rule.Match(content)
+		if len(matches) == 0 {
+			continue
+		}
+		if verdict == nil {
+			verdict = &ModerationVerdict{Content: content}
+		}
+		if !slices.Contains(verdict.Rules, rule.Name()) {
+			verdict.Rules = append(verdict.Rules, rule.Name())
+		}
+		if moderationSeverity(rule.Action()) > moderationSeverity(verdict.Action) {
+			verdict.Action = rule.Action()
+		}
+		if rule.Action() == ModerationActionMask {
+			masked = append(masked, matches...)
+		}
+	}
+	if verdict != nil && verdict.Action == ModerationActionMask {
+		verdict.Content = maskRanges(content, masked)
+	}
+	return verdict
+}
+
+func moderationSeverity(action string) int {
+	switch action {
+	case ModerationActionFlag:
+		return 1
+	case ModerationActionMask:
+		return 2
+	case ModerationActionBlock:
+		return 3
+	default:
+		return 0
+	}
+}
+
+func validModerationAction(action string) bool {
+	return moderationSeverity(action) > 0
+}
+
+// maskRanges replaces each range of content, merging ranges that overlap.
+func maskRanges(content string, ranges [][]int) string {
+	slices.SortFunc(ranges, func(a, b []int) int { return a[0] - b[0] })
+
+	var builder strings.Builder
+	position := 0
+	for i := 0; i < len(ranges); {
+		start, end := ranges[i][0], ranges[i][1]
+		for i++; i < len(ranges) && ranges[i][0] <= end; i++ {
+			end = max(end, ranges[i][1])
+		}
+		builder.WriteString(content[position:start])
+		builder.WriteString(moderationMask)
+		position = end
+	}
+	builder.WriteString(content[position:])
+	return builder.String()
+}
+
+// PatternRule matches a regular expression. accept, when set, drops matches that only look right.
+type PatternRule struct {
+	name    string
+	action  string
+	pattern *regexp.Regexp
+	accept  func(match string) bool
+}
+
+func NewPatternRule(name string, action string, pattern string) (*PatternRule, error) {
+	if strings.TrimSpace(name) == "" || !validModerationAction(action) {
+		return nil, fmt.Errorf("moderation rule %q: needs a name and an action of flag, mask or block", name)
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("moderation rule %q: %w", name, err)
+	}
+	return &PatternRule{name: name, action: action, pattern: compiled}, nil
+}
+
+// NewKeywordRule matches any of the keywords as whole words, ignoring case. Spaces inside a keyword
+// match any run of whitespace.
+func NewKeywordRule(name string, action string, keywords []string) (*PatternRule, error) {
+	alternatives := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		words := strings.Fields(keyword)
+		if len(words) == 0 {
+			continue
+		}
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		alternatives = append(alternatives, strings.Join(words, `\s+`))
+	}
+	if len(alternatives) == 0 {
+		return nil, fmt.Errorf("moderation rule %q: has no keywords", name)
+	}
+	return NewPatternRule(name, action, `(?i)\b(?:`+strings.Join(alternatives, "|")+`)\b`)
+}
+
+func (r *PatternRule) Name() string {
+	return r.name
+}
+
+func (r *PatternRule) Action() string {
+	return r.action
+}
+
+func (r *PatternRule) Match(content string) [][]int {
+	matches := r.pattern.FindAllStringIndex(content, -1)
+	if r.accept == nil {
+		return matches
+	}
+	return slices.DeleteFunc(matches, func(match []int) bool {
+		return !r.accept(content[match[0]:match[1]])
+	})
+}
+
+var (
+	phoneNumberPattern = regexp.MustCompile(`\+?\(?\d(?:[\s.()-]{0,2}\d){7,}`)
+	// emailPattern also catches addresses written as "name (at) host (dot) com" or "name at host dot
+	// com". The spelled-out form needs "dot" so ordinary sentences with "at" do not match.
+	emailPattern = regexp.MustCompile(`(?i)[a-z0-9._%+-]+\s*(?:@|[(\[]\s*at\s*[)\]])\s*[a-z0-9-]+(?:\s*(?:\.|[(\[]\s*dot\s*[)\]])\s*[a-z0-9-]+)+` +
+		`|[a-z0-9._%+-]+\s+at\s+[a-z0-9-]+(?:\s+dot\s+[a-z0-9-]+)+`)
+)
+
+// ContactInfoRules detect phone numbers and email addresses, which clients and coaches could use to
+// move their arrangement off the platform.
+func ContactInfoRules(action string) []ModerationRule {
+	return []ModerationRule{
+		&PatternRule{name: "phone_number", action: action, pattern: phoneNumberPattern, accept: plausiblePhoneNumber},
+		&PatternRule{name: "email_address", action: action, pattern: emailPattern},
+	}
+}
+
+// plausiblePhoneNumber keeps runs of 10 to 15 digits, the length of a number with its area code.
+// Shorter runs are usually weights, reps or dates.
+func plausiblePhoneNumber(match string) bool {
+	digits := 0
+	for _, r := range match {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	return digits >= 10 && digits <= 15
+}
+
+type moderationRuleConfig struct {
+	Name     string   `json:"name"`
+	Action   string   `json:"action"`
+	Keywords []string `json:"keywords"`
+	Pattern  string   `json:"pattern"`
+}
+
+// LoadModerationRules reads keyword and pattern rules from a JSON file holding a list of objects
+// with a name, an action, and either keywords or a pattern.
+func LoadModerationRules(path string) ([]ModerationRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read moderation rules: %w", err)
+	}
+	var configs []moderationRuleConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("parse moderation rules: %w", err)
+	}
+
+	rules := make([]ModerationRule, 0, len(configs))
+	for _, config := range configs {
+		var rule *PatternRule
+		switch {
+		case len(config.Keywords) > 0 && config.Pattern == "":
+			rule, err = NewKeywordRule(config.Name, config.Action, config.Keywords)
+		case config.Pattern != "" && len(config.Keywords) == 0:
+			rule, err = NewPatternRule(config.Name, config.Action, config.Pattern)
+		default:
+			err = fmt.Errorf("moderation rule %q: needs either keywords or a pattern", config.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	ModerationStatusOpen      = "open"
+	ModerationStatusResolved  = "resolved"
+	ModerationStatusDismissed = "dismissed"
+
+	maxReviewNoteLength = 1000
+)
+
+// ErrAlreadyReviewed means the queue item was resolved or dismissed before.
+var ErrAlreadyReviewed = errors.New("moderation item was already reviewed")
+
+// ModerationReview closes a queue item. RemoveMessage also removes the message it is about, which
+// only a resolved item can do.
+type ModerationReview struct {
+	Status        string
+	Note          string
+	RemoveMessage bool
+}
+
+// ModerationService is the admin side of chat moderation: the queue of reports and filtered
+// messages, and their review.
+type ModerationService struct {
+	db             *pgxpool.Pool
+	moderationRepo *repository.ModerationRepository
+}
+
+func NewModerationService(db *pgxpool.Pool, moderationRepo *repository.ModerationRepository) *ModerationService {
+	return &ModerationService{db: db, moderationRepo: moderationRepo}
+}
+
+func (s *ModerationService) ListQueue(
+	ctx context.Context,
+	role string,
+	filter repository.ModerationFilter,
+) ([]models.ModerationItem, int, error) {
+	if role != "admin" {
+		return nil, 0, ErrForbidden
+	}
+	switch filter.Status {
+	case "", ModerationStatusOpen, ModerationStatusResolved, ModerationStatusDismissed:
+	default:
+		return nil, 0, ErrInvalidInput
+	}
+	switch filter.Source {
+	case "", models.ModerationSourceReport, models.ModerationSourceFilter:
+	default:
+		return nil, 0, ErrInvalidInput
+	}
+	return s.moderationRepo.ListItems(ctx, filter)
+}
+
+func (s *ModerationService) GetItem(ctx context.Context, role string, itemID int64) (*models.ModerationItem, error) {
+	if role != "admin" {
+		return nil, ErrForbidden
+	}
+	if itemID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	return s.moderationRepo.GetItem(ctx, itemID)
+}
+
+// ReviewItem resolves or dismisses an open queue item. When the review removes the message, the
+// returned delivery tells the conversation's participants; it is nil otherwise.
+func (s *ModerationService) ReviewItem(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	itemID int64,
+	review ModerationReview,
+) (*models.ModerationItem, *ChatDelivery, error) {
+	if role != "admin" {
+		return nil, nil, ErrForbidden
+	}
+	review.Note = strings.TrimSpace(review.Note)
+	if itemID <= 0 || utf8.RuneCountInString(review.Note) > maxReviewNoteLength {
+		return nil, nil, ErrInvalidInput
+	}
+	if review.Status != ModerationStatusResolved && review.Status != ModerationStatusDismissed {
+		return nil, nil, ErrInvalidInput
+	}
+	if review.RemoveMessage && review.Status != ModerationStatusResolved {
+		return nil, nil, ErrInvalidInput
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txModerationRepo := repository.NewModerationRepository(tx)
+	item, err := txModerationRepo.GetItemForUpdate(ctx, itemID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if item.Status != ModerationStatusOpen {
+		return nil, nil, ErrAlreadyReviewed
+	}
+
+	var delivery *ChatDelivery
+	if review.RemoveMessage {
+		if item.MessageID == nil {
+			return nil, nil, ErrInvalidInput
+		}
+		delivery, err = removeModeratedMessage(ctx, tx, item.ConversationID, *item.MessageID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var note *string
+	if review.Note != "" {
+		note = &review.Note
+	}
+	item, err = txModerationRepo.ReviewItem(ctx, itemID, actorID, review.Status, note)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return item, delivery, nil
+}
+
+// removeModeratedMessage turns a message into a tombstone, as if its sender had removed it. It
+// returns nil if the message was already removed.
+func removeModeratedMessage(
+	ctx context.Context,
+	tx pgx.Tx,
+	conversationID int64,
+	messageID int64,
+) (*ChatDelivery, error) {
+	txMessageRepo := repository.NewMessageRepository(tx)
+	message, err := txMessageRepo.GetForUpdate(ctx, conversationID, messageID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if message.DeletedAt != nil {
+		return nil, nil
+	}
+	message, err = txMessageRepo.SoftDelete(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberIDs, err := repository.NewConversationRepository(tx).ListMemberIDs(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return &ChatDelivery{Message: message, RecipientIDs: withoutMember(memberIDs, message.SenderID)}, nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModerationPipelineContactInfo(t *testing.T) {
+	pipeline := NewModerationPipeline(ContactInfoRules(ModerationActionMask)...)
+	cases := []struct {
+		name    string
+		content string
+		want    string
+		rules   []string
+	}{
+		{name: "clean", content: "Squat 3x10 at 100 kg on 12.03.2026"},
+		{name: "phone", content: "Call me on +1 (555) 123-4567 tonight", want: "Call me on *** tonight", rules: []string{"phone_number"}},
+		{name: "email", content: "write to coach.sam@example.com", want: "write to ***", rules: []string{"email_address"}},
+		{name: "spelled email", content: "sam (at) example (dot) com works", want: "*** works", rules: []string{"email_address"}},
+		{name: "plain at", content: "see you at noon"},
+	}
+	for _, tc := range cases {
+		verdict := pipeline.Moderate(tc.content)
+		if tc.rules == nil {
+			if verdict != nil {
+				t.Fatalf("%s: expected no verdict, got %+v", tc.name, verdict)
+			}
+			continue
+		}
+		if verdict == nil {
+			t.Fatalf("%s: expected a verdict", tc.name)
+		}
+		if verdict.Action != ModerationActionMask || verdict.Content != tc.want {
+			t.Fatalf("%s: got %q %q", tc.name, verdict.Action, verdict.Content)
+		}
+		if len(verdict.Rules) != len(tc.rules) || verdict.Rules[0] != tc.rules[0] {
+			t.Fatalf("%s: unexpected rules %v", tc.name, verdict.Rules)
+		}
+	}
+}
+
+func TestModerationPipelineStrongestActionWins(t *testing.T) {
+	flagRule, err := NewKeywordRule("payment", ModerationActionFlag, []string{"bank transfer", "venmo"})
+	if err != nil {
+		t.Fatalf("NewKeywordRule: %v", err)
+	}
+	blockRule, err := NewPatternRule("slur", ModerationActionBlock, `(?i)\bidiot\b`)
+	if err != nil {
+		t.Fatalf("NewPatternRule: %v", err)
+	}
+	pipeline := NewModerationPipeline(append(ContactInfoRules(ModerationActionMask), flagRule, blockRule)...)
+
+	verdict := pipeline.Moderate("Pay by Bank  Transfer or call 555 123 4567")
+	if verdict == nil || verdict.Action != ModerationActionMask {
+		t.Fatalf("expected mask, got %+v", verdict)
+	}
+	if verdict.Content != "Pay by Bank  Transfer or call ***" {
+		t.Fatalf("flagged text should be kept, got %q", verdict.Content)
+	}
+
+	verdict = pipeline.Moderate("you idiot, venmo me")
+	if verdict == nil || verdict.Action != ModerationActionBlock {
+		t.Fatalf("expected block, got %+v", verdict)
+	}
+
+	var nilPipeline *ModerationPipeline
+	if nilPipeline.Moderate("call 555 123 4567") != nil {
+		t.Fatal("nil pipeline should let everything through")
+	}
+}
+
+func TestLoadModerationRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	content := `[
+		{"name": "payment", "action": "flag", "keywords": ["paypal"]},
+		{"name": "handles", "action": "mask", "pattern": "@[a-z0-9_]{3,}"}
+	]`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	rules, err := LoadModerationRules(path)
+	if err != nil {
+		t.Fatalf("LoadModerationRules: %v", err)
+	}
+	verdict := NewModerationPipeline(rules...).Moderate("PayPal me, I'm @sam_fit")
+	if verdict == nil || verdict.Content != "PayPal me, I'm ***" || len(verdict.Rules) != 2 {
+		t.Fatalf("unexpected verdict %+v", verdict)
+	}
+
+	if err := os.WriteFile(path, []byte(`[{"name": "bad", "action": "delete", "pattern": "x"}]`), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := LoadModerationRules(path); err == nil {
+		t.Fatal("expected an unknown action to be rejected")
+	}
+}
+
+func TestReportConversationValidatesBeforeLookups(t *testing.T) {
+	service := &ChatService{}
+	messageID := int64(0)
+	cases := []struct {
+		name      string
+		messageID *int64
+		reason    string
+	}{
+		{name: "unknown reason", reason: "rude"},
+		{name: "bad message id", messageID: &messageID, reason: "spam"},
+	}
+	for _, tc := range cases {
+		_, err := service.ReportConversation(context.Background(), 1, "user", 2, tc.messageID, tc.reason, "")
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("%s: got %v", tc.name, err)
+		}
+	}
+	if err := service.BlockUser(context.Background(), 1, "user", 1); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("blocking yourself: got %v", err)
+	}
+}
+
+func TestReviewItemValidatesBeforeLookups(t *testing.T) {
+	service := &ModerationService{}
+	cases := []struct {
+		name   string
+		role   string
+		review ModerationReview
+		want   error
+	}{
+		{name: "coach", role: "coach", review: ModerationReview{Status: ModerationStatusResolved}, want: ErrForbidden},
+		{name: "reopen", role: "admin", review: ModerationReview{Status: ModerationStatusOpen}, want: ErrInvalidInput},
+		{name: "dismiss and remove", role: "admin", review: ModerationReview{Status: ModerationStatusDismissed, RemoveMessage: true}, want: ErrInvalidInput},
+	}
+	for _, tc := range cases {
+		if _, _, err := service.ReviewItem(context.Background(), 1, tc.role, 3, tc.review); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
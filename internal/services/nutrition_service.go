@@ -0,0 +1,435 @@
+package services
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	minPlanCalories           = 500
+	maxPlanCalories           = 10000
+	maxMacroGrams             = 1000
+	maxPlanMeals              = 10
+	maxMealFoods              = 30
+	maxMealLogCalories        = 10000
+	maxNutritionNotesLength   = 2000
+	defaultAdherenceDays      = 14
+	maxAdherenceDays          = 92
+	mealLogFutureLeeway       = 5 * time.Minute
+	onTargetCalorieTolerance  = 0.10
+	onTargetMinProteinPortion = 0.90
+)
+
+var mealTimePattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+var mealTypes = map[string]bool{
+	"breakfast": true,
+	"lunch":     true,
+	"dinner":    true,
+	"snack":     true,
+}
+
+type NutritionService struct {
+	nutritionRepo *repository.NutritionRepository
+	userRepo      userReader
+	coachingRepo  *repository.CoachingRepository
+}
+
+func NewNutritionService(
+	nutritionRepo *repository.NutritionRepository,
+	userRepo userReader,
+	coachingRepo *repository.CoachingRepository,
+) *NutritionService {
+	return &NutritionService{
+		nutritionRepo: nutritionRepo,
+		userRepo:      userRepo,
+		coachingRepo:  coachingRepo,
+	}
+}
+
+// CreatePlan assigns a plan to a client the coach is actively coaching.
+func (s *NutritionService) CreatePlan(
+	ctx context.Context,
+	coachID int64,
+	userID int64,
+	input repository.NutritionPlanInput,
+) (*models.NutritionPlan, error) {
+	if userID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	if err := normalizeNutritionPlan(&input); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != "user" {
+		return nil, ErrInvalidInput
+	}
+	active, err := s.coachingRepo.IsActiveCoach(ctx, coachID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrForbidden
+	}
+
+	return s.nutritionRepo.CreatePlan(ctx, coachID, userID, input)
+}
+
+// ListPlans returns a client's own plans, or a coach's plans optionally narrowed to one client.
+func (s *NutritionService) ListPlans(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	userID int64,
+) ([]models.NutritionPlan, error) {
+	filter := repository.NutritionPlanFilter{}
+	switch role {
+	case "user":
+		if userID != 0 && userID != actorID {
+			return nil, ErrForbidden
+		}
+		filter.UserID = &actorID
+	case "coach":
+		filter.CoachID = &actorID
+		if userID > 0 {
+			filter.UserID = &userID
+		}
+	default:
+		return nil, ErrForbidden
+	}
+	return s.nutritionRepo.ListPlans(ctx, filter)
+}
+
+func (s *NutritionService) GetPlan(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	planID int64,
+) (*models.NutritionPlan, error) {
+	plan, err := s.nutritionRepo.GetPlanByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkClientRecordAccess(ctx, s.coachingRepo, role, actorID, plan.CoachID, plan.UserID); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// UpdatePlan replaces the plan's targets, meals and dates.
+func (s *NutritionService) UpdatePlan(
+	ctx context.Context,
+	coachID int64,
+	planID int64,
+	input repository.NutritionPlanInput,
+) (*models.NutritionPlan, error) {
+	if err := normalizeNutritionPlan(&input); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetPlan(ctx, coachID, "coach", planID); err != nil {
+		return nil, err
+	}
+	return s.nutritionRepo.UpdatePlan(ctx, planID, input)
+}
+
+func (s *NutritionService) DeletePlan(ctx context.Context, coachID int64, planID int64) error {
+	if _, err := s.GetPlan(ctx, coachID, "coach", planID); err != nil {
+		return err
+	}
+	return s.nutritionRepo.DeletePlan(ctx, planID)
+}
+
+func (s *NutritionService) LogMeal(
+	ctx context.Context,
+	userID int64,
+	input repository.MealLogInput,
+) (*models.MealLog, error) {
+	if err := normalizeMealLog(&input, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return s.nutritionRepo.CreateMealLog(ctx, userID, input)
+}
+
+// ListMealLogs returns a client's meal history. Coaches need a nutrition plan with the client and
+// an active relationship.
+func (s *NutritionService) ListMealLogs(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	filter repository.MealLogFilter,
+) ([]models.MealLog, int, error) {
+	switch role {
+	case "user":
+		if filter.UserID != 0 && filter.UserID != actorID {
+			return nil, 0, ErrForbidden
+		}
+		filter.UserID = actorID
+	case "coach":
+		if filter.UserID <= 0 {
+			return nil, 0, ErrInvalidInput
+		}
+		active, err := s.coachingRepo.IsActiveCoach(ctx, actorID, filter.UserID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !active {
+			return nil, 0, ErrForbidden
+		}
+		plans, err := s.nutritionRepo.ListPlans(ctx, repository.NutritionPlanFilter{
+			CoachID: &actorID,
+			UserID:  &filter.UserID,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(plans) == 0 {
+			return nil, 0, ErrForbidden
+		}
+	default:
+		return nil, 0, ErrForbidden
+	}
+	return s.nutritionRepo.ListMealLogs(ctx, filter)
+}
+
+func (s *NutritionService) DeleteMealLog(ctx context.Context, userID int64, logID int64) error {
+	log, err := s.nutritionRepo.GetMealLogByID(ctx, logID)
+	if err != nil {
+		return err
+	}
+	if log.UserID != userID {
+		return ErrForbidden
+	}
+	return s.nutritionRepo.DeleteMealLog(ctx, logID)
+}
+
+// GetAdherence compares the client's daily intake with the plan's targets. from and to are
+// inclusive calendar days; by default the last 14 days, clipped to the plan's dates.
+func (s *NutritionService) GetAdherence(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	planID int64,
+	from *time.Time,
+	to *time.Time,
+) (*models.NutritionAdherence, error) {
+	plan, err := s.GetPlan(ctx, actorID, role, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	lastDay := startOfUTCDay(time.Now().UTC())
+	if to != nil {
+		lastDay = startOfUTCDay(*to)
+	}
+	if plan.EndDate != nil && plan.EndDate.Before(lastDay) {
+		lastDay = startOfUTCDay(*plan.EndDate)
+	}
+	firstDay := lastDay.AddDate(0, 0, 1-defaultAdherenceDays)
+	if from != nil {
+		firstDay = startOfUTCDay(*from)
+	}
+	if planStart := startOfUTCDay(plan.StartDate); firstDay.Before(planStart) {
+		firstDay = planStart
+	}
+	if from != nil && to != nil && from.After(*to) {
+		return nil, ErrInvalidInput
+	}
+	if lastDay.Sub(firstDay) >= maxAdherenceDays*24*time.Hour {
+		return nil, ErrInvalidInput
+	}
+
+	totals := []models.NutritionDay{}
+	if !lastDay.Before(firstDay) {
+		totals, err = s.nutritionRepo.ListDailyTotals(ctx, plan.UserID, firstDay, lastDay.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nutritionAdherence(plan, firstDay, lastDay, totals), nil
+}
+
+// nutritionAdherence lays the logged totals over every day from firstDay to lastDay. A day is on
+// target when calories are within 10% of the target and protein reaches 90% of it.
+func nutritionAdherence(
+	plan *models.NutritionPlan,
+	firstDay time.Time,
+	lastDay time.Time,
+	totals []models.NutritionDay,
+) *models.NutritionAdherence {
+	report := &models.NutritionAdherence{
+		PlanID:  plan.ID,
+		From:    firstDay,
+		To:      lastDay,
+		Targets: plan.Targets,
+		Days:    []models.NutritionDay{},
+	}
+
+	logged := make(map[time.Time]models.NutritionDay, len(totals))
+	for _, day := range totals {
+		logged[startOfUTCDay(day.Date)] = day
+	}
+
+	var calories int
+	var protein, carbs, fat float64
+	for day := firstDay; !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		entry, ok := logged[day]
+		entry.Date = day
+		if ok && entry.Entries > 0 {
+			report.LoggedDays++
+			calories += entry.CaloriesKcal
+			protein += entry.ProteinG
+			carbs += entry.CarbsG
+			fat += entry.FatG
+			entry.OnTarget = isOnNutritionTarget(plan.Targets, entry)
+			if entry.OnTarget {
+				report.OnTargetDays++
+			}
+		}
+		entry.ProteinG = math.Round(entry.ProteinG*10) / 10
+		entry.CarbsG = math.Round(entry.CarbsG*10) / 10
+		entry.FatG = math.Round(entry.FatG*10) / 10
+		report.Days = append(report.Days, entry)
+	}
+
+	if len(report.Days) > 0 {
+		rate := float64(report.OnTargetDays) / float64(len(report.Days))
+		report.AdherenceRate = math.Round(rate*100) / 100
+	}
+	if report.LoggedDays > 0 {
+		days := float64(report.LoggedDays)
+		report.Average = models.MacroTargets{
+			CaloriesKcal: int(math.Round(float64(calories) / days)),
+			ProteinG:     math.Round(protein/days*10) / 10,
+			CarbsG:       math.Round(carbs/days*10) / 10,
+			FatG:         math.Round(fat/days*10) / 10,
+		}
+	}
+	return report
+}
+
+func isOnNutritionTarget(targets models.MacroTargets, day models.NutritionDay) bool {
+	target := float64(targets.CaloriesKcal)
+	if math.Abs(float64(day.CaloriesKcal)-target) > target*onTargetCalorieTolerance {
+		return false
+	}
+	return day.ProteinG >= targets.ProteinG*onTargetMinProteinPortion
+}
+
+func normalizeNutritionPlan(input *repository.NutritionPlanInput) error {
+	input.Title = strings.TrimSpace(input.Title)
+	input.Notes = blankToNil(input.Notes)
+	if input.Title == "" || len(input.Title) > 255 {
+		return ErrInvalidInput
+	}
+	if input.Notes != nil && len(*input.Notes) > maxNutritionNotesLength {
+		return ErrInvalidInput
+	}
+	if input.Targets.CaloriesKcal < minPlanCalories || input.Targets.CaloriesKcal > maxPlanCalories {
+		return ErrInvalidInput
+	}
+	for _, grams := range []*float64{&input.Targets.ProteinG, &input.Targets.CarbsG, &input.Targets.FatG} {
+		if *grams < 0 || *grams > maxMacroGrams {
+			return ErrInvalidInput
+		}
+		*grams = math.Round(*grams*10) / 10
+	}
+
+	if input.StartDate.IsZero() {
+		return ErrInvalidInput
+	}
+	input.StartDate = startOfUTCDay(input.StartDate)
+	if input.EndDate != nil {
+		end := startOfUTCDay(*input.EndDate)
+		if end.Before(input.StartDate) {
+			return ErrInvalidInput
+		}
+		input.EndDate = &end
+	}
+
+	if len(input.Meals) > maxPlanMeals {
+		return ErrInvalidInput
+	}
+	for i := range input.Meals {
+		if err := normalizeMealTemplate(&input.Meals[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func normalizeMealTemplate(meal *models.MealTemplate) error {
+	meal.Name = strings.TrimSpace(meal.Name)
+	meal.TimeOfDay = blankToNil(meal.TimeOfDay)
+	meal.Notes = blankToNil(meal.Notes)
+	if meal.Name == "" || len(meal.Name) > 100 {
+		return ErrInvalidInput
+	}
+	if meal.TimeOfDay != nil && !mealTimePattern.MatchString(*meal.TimeOfDay) {
+		return ErrInvalidInput
+	}
+	if meal.CaloriesKcal != nil && (*meal.CaloriesKcal < 0 || *meal.CaloriesKcal > maxPlanCalories) {
+		return ErrInvalidInput
+	}
+	for _, grams := range []*float64{meal.ProteinG, meal.CarbsG, meal.FatG} {
+		if grams != nil && (*grams < 0 || *grams > maxMacroGrams) {
+			return ErrInvalidInput
+		}
+	}
+	if len(meal.Foods) > maxMealFoods {
+		return ErrInvalidInput
+	}
+	foods := make([]string, 0, len(meal.Foods))
+	for _, food := range meal.Foods {
+		food = strings.TrimSpace(food)
+		if food == "" || len(food) > 150 {
+			return ErrInvalidInput
+		}
+		foods = append(foods, food)
+	}
+	meal.Foods = foods
+	return nil
+}
+
+func normalizeMealLog(input *repository.MealLogInput, now time.Time) error {
+	input.Meal = strings.ToLower(strings.TrimSpace(input.Meal))
+	input.FoodName = strings.TrimSpace(input.FoodName)
+	input.Quantity = blankToNil(input.Quantity)
+	input.Notes = blankToNil(input.Notes)
+	if !mealTypes[input.Meal] || input.FoodName == "" || len(input.FoodName) > 150 {
+		return ErrInvalidInput
+	}
+	if input.Quantity != nil && len(*input.Quantity) > 50 {
+		return ErrInvalidInput
+	}
+	if input.Notes != nil && len(*input.Notes) > maxNutritionNotesLength {
+		return ErrInvalidInput
+	}
+	if input.CaloriesKcal < 0 || input.CaloriesKcal > maxMealLogCalories {
+		return ErrInvalidInput
+	}
+	for _, grams := range []*float64{&input.ProteinG, &input.CarbsG, &input.FatG} {
+		if *grams < 0 || *grams > maxMacroGrams {
+			return ErrInvalidInput
+		}
+		*grams = math.Round(*grams*10) / 10
+	}
+
+	if input.EatenAt.IsZero() {
+		input.EatenAt = now
+	}
+	input.EatenAt = input.EatenAt.UTC()
+	if input.EatenAt.After(now.Add(mealLogFutureLeeway)) {
+		return ErrInvalidInput
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+func TestNormalizeNutritionPlan(t *testing.T) {
+	breakfast := "7:30"
+	input := repository.NutritionPlanInput{
+		Title:     " Cut ",
+		Targets:   models.MacroTargets{CaloriesKcal: 2200, ProteinG: 160.04},
+		Meals:     []models.MealTemplate{{Name: "Breakfast", Foods: []string{" Oats "}}},
+		StartDate: time.Date(2030, 3, 1, 15, 0, 0, 0, time.UTC),
+	}
+	if err := normalizeNutritionPlan(&input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if input.Title != "Cut" || input.Targets.ProteinG != 160 || input.Meals[0].Foods[0] != "Oats" {
+		t.Fatalf("unexpected normalized plan: %+v", input)
+	}
+	if !input.StartDate.Equal(time.Date(2030, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected start date truncated to the day, got %v", input.StartDate)
+	}
+
+	input.Meals[0].TimeOfDay = &breakfast
+	if err := normalizeNutritionPlan(&input); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected invalid meal time, got %v", err)
+	}
+
+	end := time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)
+	input.Meals = nil
+	input.EndDate = &end
+	if err := normalizeNutritionPlan(&input); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected end before start to fail, got %v", err)
+	}
+}
+
+func TestNormalizeMealLog(t *testing.T) {
+	now := time.Date(2030, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	input := repository.MealLogInput{Meal: " Lunch ", FoodName: "Rice", CaloriesKcal: 300, CarbsG: 65.55}
+	if err := normalizeMealLog(&input, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if input.Meal != "lunch" || !input.EatenAt.Equal(now) || input.CarbsG != 65.6 {
+		t.Fatalf("unexpected normalized log: %+v", input)
+	}
+
+	future := repository.MealLogInput{Meal: "dinner", FoodName: "Rice", EatenAt: now.Add(time.Hour)}
+	if err := normalizeMealLog(&future, now); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected future meal to fail, got %v", err)
+	}
+
+	unknown := repository.MealLogInput{Meal: "brunch", FoodName: "Eggs"}
+	if err := normalizeMealLog(&unknown, now); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected unknown meal to fail, got %v", err)
+	}
+}
+
+func TestNutritionAdherence(t *testing.T) {
+	plan := &models.NutritionPlan{
+		ID:      5,
+		Targets: models.MacroTargets{CaloriesKcal: 2000, ProteinG: 150},
+	}
+	first := time.Date(2030, 3, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 0, 3)
+	totals := []models.NutritionDay{
+		{Date: first, CaloriesKcal: 2100, ProteinG: 140, Entries: 3},
+		{Date: first.AddDate(0, 0, 1), CaloriesKcal: 2500, ProteinG: 160, Entries: 4},
+		{Date: first.AddDate(0, 0, 3), CaloriesKcal: 1900, ProteinG: 120, Entries: 2},
+	}
+
+	report := nutritionAdherence(plan, first, last, totals)
+
+	if len(report.Days) != 4 || report.LoggedDays != 3 || report.OnTargetDays != 1 {
+		t.Fatalf("unexpected day counts: %+v", report)
+	}
+	if !report.Days[0].OnTarget || report.Days[1].OnTarget || report.Days[2].Entries != 0 || report.Days[3].OnTarget {
+		t.Fatalf("unexpected days: %+v", report.Days)
+	}
+	if report.AdherenceRate != 0.25 {
+		t.Fatalf("expected adherence 0.25, got %v", report.AdherenceRate)
+	}
+	if report.Average.CaloriesKcal != 2167 || report.Average.ProteinG != 140 {
+		t.Fatalf("unexpected average: %+v", report.Average)
+	}
+}
+
+func TestNutritionServiceListMealLogsRequiresActiveCoach(t *testing.T) {
+	for _, status := range []string{"paused", "ended"} {
+		t.Run(status, func(t *testing.T) {
+			service := &NutritionService{
+				coachingRepo: repository.NewCoachingRepository(newRelationshipStatusDB(status)),
+			}
+			_, _, err := service.ListMealLogs(context.Background(), 7, "coach", repository.MealLogFilter{UserID: 42})
+			if !errors.Is(err, ErrForbidden) {
+				t.Fatalf("expected ErrForbidden, got %v", err)
+			}
+		})
+	}
+}
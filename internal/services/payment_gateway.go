@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+var ErrUnsupportedGatewayEvent = errors.New("unsupported gateway event")
+
+type GatewayEvent struct {
+	ID   string           `json:"id"`
+	Type string           `json:"type"`
+	Data GatewayEventData `json:"data"`
+}
+
+type GatewayEventData struct {
+	SubscriptionID     string     `json:"subscription_id,omitempty"`
+	PaymentID          *int64     `json:"payment_id,omitempty"`
+	Amount             *float64   `json:"amount,omitempty"`
+	CurrentPeriodStart *time.Time `json:"current_period_start,omitempty"`
+	CurrentPeriodEnd   *time.Time `json:"current_period_end,omitempty"`
+	DisputeID          string     `json:"dispute_id,omitempty"`
+	Reason             string     `json:"reason,omitempty"`
+	Status             string     `json:"status,omitempty"`
+	EvidenceDueBy      *time.Time `json:"evidence_due_by,omitempty"`
+	Fee                *float64   `json:"fee,omitempty"`
+}
+
+type GatewaySubscriptionInput struct {
+	UserID       int64
+	CoachID      int64
+	PlanID       int64
+	MonthlyPrice float64
+	TrialDays    int
+}
+
+type PaymentGateway interface {
+	CreateSubscription(ctx context.Context, input GatewaySubscriptionInput) (string, error)
+	ChangeSubscriptionPlan(ctx context.Context, gatewaySubscriptionID string, monthlyPrice float64, prorationAmount float64) error
+	CancelSubscription(ctx context.Context, gatewaySubscriptionID string, atPeriodEnd bool) error
+	SubmitDisputeEvidence(ctx context.Context, gatewayDisputeID string, evidence *models.DisputeEvidence) error
+}
+
+type PlaceholderPaymentGateway struct {
+	sequence atomic.Int64
+}
+
+func NewPlaceholderPaymentGateway() *PlaceholderPaymentGateway {
+	return &PlaceholderPaymentGateway{}
+}
+
+func (g *PlaceholderPaymentGateway) CreateSubscription(
+	_ context.Context,
+	input GatewaySubscriptionInput,
+) (string, error) {
+	return fmt.Sprintf(
+		"sub_placeholder_%d_%d_%d",
+		input.UserID,
+		time.Now().UnixNano(),
+		g.sequence.Add(1),
+	), nil
+}
+
+func (g *PlaceholderPaymentGateway) ChangeSubscriptionPlan(_ context.Context, _ string, _ float64, _ float64) error {
+	return nil
+}
+
+func (g *PlaceholderPaymentGateway) CancelSubscription(_ context.Context, _ string, _ bool) error {
+	return nil
+}
+
+func (g *PlaceholderPaymentGateway) SubmitDisputeEvidence(_ context.Context, _ string, _ *models.DisputeEvidence) error {
+	return nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	MismatchMissingAtGateway = "missing_at_gateway"
+	MismatchMissingLocally   = "missing_locally"
+	MismatchAmount           = "amount_mismatch"
+	MismatchStatus           = "status_mismatch"
+)
+
+// GatewayPaymentRecord is a payment as reported by the gateway's settlement export.
+type GatewayPaymentRecord struct {
+	PaymentID int64   `json:"payment_id"`
+	Reference string  `json:"reference"`
+	Amount    float64 `json:"amount"`
+	Status    string  `json:"status"`
+}
+
+type ReconciliationMismatch struct {
+	Kind          string   `json:"kind"`
+	PaymentID     int64    `json:"payment_id"`
+	Reference     string   `json:"reference,omitempty"`
+	LocalAmount   *float64 `json:"local_amount,omitempty"`
+	GatewayAmount *float64 `json:"gateway_amount,omitempty"`
+	LocalStatus   string   `json:"local_status,omitempty"`
+	GatewayStatus string   `json:"gateway_status,omitempty"`
+}
+
+type ReconciliationReport struct {
+	From       time.Time                `json:"from"`
+	To         time.Time                `json:"to"`
+	Checked    int                      `json:"checked"`
+	Mismatches []ReconciliationMismatch `json:"mismatches"`
+}
+
+type ReconciliationService struct {
+	paymentRepo *repository.PaymentRepository
+}
+
+func NewReconciliationService(paymentRepo *repository.PaymentRepository) *ReconciliationService {
+	return &ReconciliationService{paymentRepo: paymentRepo}
+}
+
+// Reconcile compares local payments created in [from, to) with the gateway's records for the same window.
+func (s *ReconciliationService) Reconcile(
+	ctx context.Context,
+	from time.Time,
+	to time.Time,
+	records []GatewayPaymentRecord,
+) (*ReconciliationReport, error) {
+	if !to.After(from) {
+		return nil, ErrInvalidInput
+	}
+
+	local, err := s.paymentRepo.ListCreatedBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Gateway settlement can lag behind local creation, so records that fall outside the
+	// local window are looked up individually before being reported as missing.
+	inWindow := make(map[int64]bool, len(local))
+	for _, payment := range local {
+		inWindow[payment.ID] = true
+	}
+	outside := make([]int64, 0)
+	for _, record := range records {
+		if !inWindow[record.PaymentID] {
+			outside = append(outside, record.PaymentID)
+		}
+	}
+	extra, err := s.paymentRepo.ListByIDs(ctx, outside)
+	if err != nil {
+		return nil, err
+	}
+	for _, payment := range extra {
+		local = append(local, payment)
+	}
+
+	return &ReconciliationReport{
+		From:       from,
+		To:         to,
+		Checked:    len(local),
+		Mismatches: reconcilePayments(local, records),
+	}, nil
+}
+
+func reconcilePayments(local []models.Payment, records []GatewayPaymentRecord) []ReconciliationMismatch {
+	remoteByID := make(map[int64]GatewayPaymentRecord, len(records))
+	for _, record := range records {
+		remoteByID[record.PaymentID] = record
+	}
+	localByID := make(map[int64]models.Payment, len(local))
+	for _, payment := range local {
+		localByID[payment.ID] = payment
+	}
+
+	mismatches := make([]ReconciliationMismatch, 0)
+	for _, payment := range local {
+		localAmount := payment.Amount
+		record, ok := remoteByID[payment.ID]
+		if !ok {
+			// Placeholders have not been charged and zero-amount payments never reach the gateway.
+			if payment.Status != "placeholder" && payment.Amount > 0 {
+				mismatches = append(mismatches, ReconciliationMismatch{
+					Kind:        MismatchMissingAtGateway,
+					PaymentID:   payment.ID,
+					LocalAmount: &localAmount,
+					LocalStatus: payment.Status,
+				})
+			}
+			continue
+		}
+
+		gatewayAmount := record.Amount
+		if math.Abs(record.Amount-payment.Amount) >= 0.005 {
+			mismatches = append(mismatches, ReconciliationMismatch{
+				Kind:          MismatchAmount,
+				PaymentID:     payment.ID,
+				Reference:     record.Reference,
+				LocalAmount:   &localAmount,
+				GatewayAmount: &gatewayAmount,
+			})
+		}
+		if record.Status != payment.Status {
+			mismatches = append(mismatches, ReconciliationMismatch{
+				Kind:          MismatchStatus,
+				PaymentID:     payment.ID,
+				Reference:     record.Reference,
+				LocalStatus:   payment.Status,
+				GatewayStatus: record.Status,
+			})
+		}
+	}
+
+	for _, record := range records {
+		if _, ok := localByID[record.PaymentID]; ok {
+			continue
+		}
+		gatewayAmount := record.Amount
+		mismatches = append(mismatches, ReconciliationMismatch{
+			Kind:          MismatchMissingLocally,
+			PaymentID:     record.PaymentID,
+			Reference:     record.Reference,
+			GatewayAmount: &gatewayAmount,
+			GatewayStatus: record.Status,
+		})
+	}
+
+	sort.SliceStable(mismatches, func(i, j int) bool {
+		return mismatches[i].PaymentID < mismatches[j].PaymentID
+	})
+	return mismatches
+}
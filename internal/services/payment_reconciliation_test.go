@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+func TestReconcilePayments(t *testing.T) {
+	local := []models.Payment{
+		{ID: 1, Amount: 50, Status: "paid"},
+		{ID: 2, Amount: 40, Status: "paid"},
+		{ID: 3, Amount: 30, Status: "refunded"},
+		{ID: 4, Amount: 25, Status: "paid"},
+		{ID: 5, Amount: 60, Status: "placeholder"},
+		{ID: 6, Amount: 0, Status: "paid"},
+	}
+	records := []GatewayPaymentRecord{
+		{PaymentID: 1, Reference: "ch_1", Amount: 50, Status: "paid"},
+		{PaymentID: 2, Reference: "ch_2", Amount: 45, Status: "paid"},
+		{PaymentID: 3, Reference: "ch_3", Amount: 30, Status: "paid"},
+		{PaymentID: 9, Reference: "ch_9", Amount: 10, Status: "paid"},
+	}
+
+	mismatches := reconcilePayments(local, records)
+
+	expected := []struct {
+		kind      string
+		paymentID int64
+	}{
+		{kind: MismatchAmount, paymentID: 2},
+		{kind: MismatchStatus, paymentID: 3},
+		{kind: MismatchMissingAtGateway, paymentID: 4},
+		{kind: MismatchMissingLocally, paymentID: 9},
+	}
+	if len(mismatches) != len(expected) {
+		t.Fatalf("expected %d mismatches, got %+v", len(expected), mismatches)
+	}
+	for i, want := range expected {
+		if mismatches[i].Kind != want.kind || mismatches[i].PaymentID != want.paymentID {
+			t.Fatalf("mismatch %d: expected %s for %d, got %+v", i, want.kind, want.paymentID, mismatches[i])
+		}
+	}
+	if *mismatches[0].LocalAmount != 40 || *mismatches[0].GatewayAmount != 45 {
+		t.Fatalf("unexpected amounts: %+v", mismatches[0])
+	}
+}
+
+func TestReconcilePaymentsToleratesRounding(t *testing.T) {
+	local := []models.Payment{{ID: 1, Amount: 33.33, Status: "paid"}}
+	records := []GatewayPaymentRecord{{PaymentID: 1, Amount: 33.3300001, Status: "paid"}}
+
+	if mismatches := reconcilePayments(local, records); len(mismatches) != 0 {
+		t.Fatalf("expected no mismatches, got %+v", mismatches)
+	}
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+// maxPaymentExportRows bounds a single CSV export so it cannot hold a connection indefinitely.
+const maxPaymentExportRows = 10000
+
+// ErrExportTooLarge means the filters match more payments than one export may hold.
+var ErrExportTooLarge = fmt.Errorf("export is limited to %d payments", maxPaymentExportRows)
+
+type PaymentListQuery struct {
+	Status        string
+	CounterpartID *int64
+	From          *time.Time
+	To            *time.Time
+	Cursor        string
+	Limit         int
+}
+
+type PaymentPage struct {
+	Payments   []models.Payment `json:"payments"`
+	NextCursor *string          `json:"next_cursor"`
+}
+
+type PaymentService struct {
+	db                  *pgxpool.Pool
+	paymentRepo         *repository.PaymentRepository
+	subscriptionService *SubscriptionService
+	disputeService      *DisputeService
+	invoiceService      *InvoiceService
+}
+
+func NewPaymentService(
+	db *pgxpool.Pool,
+	paymentRepo *repository.PaymentRepository,
+	subscriptionService *SubscriptionService,
+	disputeService *DisputeService,
+	invoiceService *InvoiceService,
+) *PaymentService {
+	return &PaymentService{
+		db:                  db,
+		paymentRepo:         paymentRepo,
+		subscriptionService: subscriptionService,
+		disputeService:      disputeService,
+		invoiceService:      invoiceService,
+	}
+}
+
+func (s *PaymentService) ListPayments(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	query PaymentListQuery,
+) (*PaymentPage, error) {
+	filter, err := buildPaymentListFilter(actorID, role, query)
+	if err != nil {
+		return nil, err
+	}
+	if query.Limit <= 0 {
+		return nil, ErrInvalidInput
+	}
+	if strings.TrimSpace(query.Cursor) != "" {
+		createdAt, id, err := decodePaymentCursor(query.Cursor)
+		if err != nil {
+			return nil, ErrInvalidInput
+		}
+		filter.CursorCreatedAt = &createdAt
+		filter.CursorID = id
+	}
+	// Fetch one extra row to learn whether another page exists.
+	filter.Limit = query.Limit + 1
+
+	payments, err := s.paymentRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PaymentPage{Payments: payments}
+	if len(payments) > query.Limit {
+		page.Payments = payments[:query.Limit]
+		last := page.Payments[len(page.Payments)-1]
+		cursor := encodePaymentCursor(last.CreatedAt, last.ID)
+		page.NextCursor = &cursor
+	}
+	return page, nil
+}
+
+func (s *PaymentService) ExportPayments(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	query PaymentListQuery,
+) ([]models.Payment, error) {
+	filter, err := buildPaymentListFilter(actorID, role, query)
+	if err != nil {
+		return nil, err
+	}
+	// Fetch one extra row to tell a complete export from a truncated one.
+	filter.Limit = maxPaymentExportRows + 1
+	payments, err := s.paymentRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(payments) > maxPaymentExportRows {
+		return nil, ErrExportTooLarge
+	}
+	return payments, nil
+}
+
+func (s *PaymentService) HandleGatewayEvent(ctx context.Context, event GatewayEvent) error {
+	if strings.TrimSpace(event.ID) == "" || strings.TrimSpace(event.Type) == "" {
+		return ErrInvalidInput
+	}
+
+	switch {
+	case strings.HasPrefix(event.Type, "subscription."):
+		return s.subscriptionService.HandleGatewayEvent(ctx, event)
+	case strings.HasPrefix(event.Type, "dispute."):
+		return s.disputeService.HandleGatewayEvent(ctx, event)
+	case event.Type == "payment.refunded":
+		return s.handleRefund(ctx, event)
+	default:
+		return ErrUnsupportedGatewayEvent
+	}
+}
+
+func (s *PaymentService) handleRefund(ctx context.Context, event GatewayEvent) error {
+	if event.Data.PaymentID == nil || *event.Data.PaymentID <= 0 {
+		return ErrInvalidInput
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txPaymentRepo := repository.NewPaymentRepository(tx)
+
+	recorded, err := txPaymentRepo.RecordGatewayEvent(ctx, event.ID, event.Type, payload)
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return nil
+	}
+
+	payment, err := txPaymentRepo.GetByIDForUpdate(ctx, *event.Data.PaymentID)
+	if err != nil {
+		return err
+	}
+	if payment.Status == "refunded" {
+		return tx.Commit(ctx)
+	}
+	if payment.Status != "paid" {
+		return ErrInvalidStateTransition
+	}
+	// Partial refunds are not supported; the credit note always mirrors the full payment.
+	if event.Data.Amount != nil && math.Abs(*event.Data.Amount-payment.Amount) >= 0.005 {
+		return ErrInvalidInput
+	}
+
+	refunded, err := txPaymentRepo.UpdateStatus(ctx, payment.ID, "refunded")
+	if err != nil {
+		return err
+	}
+	creditNote, err := s.invoiceService.IssueForPayment(ctx, tx, refunded, invoiceKindCreditNote)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	s.invoiceService.storePDFs(ctx, creditNote)
+	return nil
+}
+
+func buildPaymentListFilter(actorID int64, role string, query PaymentListQuery) (repository.PaymentListFilter, error) {
+	if actorID <= 0 || (role != "user" && role != "coach") {
+		return repository.PaymentListFilter{}, ErrForbidden
+	}
+	status := strings.TrimSpace(query.Status)
+	if status != "" && !isPaymentStatus(status) {
+		return repository.PaymentListFilter{}, ErrInvalidInput
+	}
+	if query.From != nil && query.To != nil && !query.To.After(*query.From) {
+		return repository.PaymentListFilter{}, ErrInvalidInput
+	}
+
+	return repository.PaymentListFilter{
+		ActorID:       actorID,
+		Role:          role,
+		Status:        status,
+		CounterpartID: query.CounterpartID,
+		From:          query.From,
+		To:            query.To,
+	}, nil
+}
+
+func isPaymentStatus(status string) bool {
+	switch status {
+	case "placeholder", "paid", "refunded", "disputed", "charged_back":
+		return true
+	default:
+		return false
+	}
+}
+
+// Cursors are opaque to clients; they encode the (created_at, id) sort key of the last row served.
+func encodePaymentCursor(createdAt time.Time, id int64) string {
+	raw := fmt.Sprintf("%d:%d", createdAt.UnixMicro(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePaymentCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(cursor))
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	micros, idPart, found := strings.Cut(string(raw), ":")
+	if !found {
+		return time.Time{}, 0, fmt.Errorf("malformed payment cursor")
+	}
+	unixMicro, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || id <= 0 {
+		return time.Time{}, 0, fmt.Errorf("malformed payment cursor")
+	}
+	return time.UnixMicro(unixMicro).UTC(), id, nil
+}
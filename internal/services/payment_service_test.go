@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPaymentCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2030, 3, 4, 5, 6, 7, 123456000, time.UTC)
+
+	cursor := encodePaymentCursor(createdAt, 42)
+	gotTime, gotID, err := decodePaymentCursor(cursor)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !gotTime.Equal(createdAt) || gotID != 42 {
+		t.Fatalf("expected (%s, 42), got (%s, %d)", createdAt, gotTime, gotID)
+	}
+}
+
+func TestDecodePaymentCursorRejectsMalformedInput(t *testing.T) {
+	for _, cursor := range []string{"not base64!", "MTIz", "YWJjOjE", "MTIzOjA"} {
+		if _, _, err := decodePaymentCursor(cursor); err == nil {
+			t.Fatalf("expected error for cursor %q", cursor)
+		}
+	}
+}
+
+func TestBuildPaymentListFilter(t *testing.T) {
+	from := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+
+	if _, err := buildPaymentListFilter(1, "admin", PaymentListQuery{}); err != ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := buildPaymentListFilter(1, "user", PaymentListQuery{Status: "pending"}); err != ErrInvalidInput {
+		t.Fatalf("expected ErrInvalidInput for status, got %v", err)
+	}
+	if _, err := buildPaymentListFilter(1, "user", PaymentListQuery{From: &from, To: &to}); err != ErrInvalidInput {
+		t.Fatalf("expected ErrInvalidInput for range, got %v", err)
+	}
+
+	filter, err := buildPaymentListFilter(7, "coach", PaymentListQuery{Status: " paid "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.ActorID != 7 || filter.Role != "coach" || filter.Status != "paid" {
+		t.Fatalf("unexpected filter: %+v", filter)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"mime/multipart"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -17,6 +19,17 @@ import (
 
 var ErrStorageUnavailable = errors.New("storage service is not configured")
 
+const (
+	maxProgramPhases       = 20
+	maxProgramWeeks        = 52
+	maxProgramDayExercises = 50
+)
+
+var (
+	programTempoPattern    = regexp.MustCompile(`^[0-9X]{1,2}(-[0-9X]{1,2}){2,3}$`)
+	programSupersetPattern = regexp.MustCompile(`^[A-Z][0-9]?$`)
+)
+
 type workoutProgramStore interface {
 	Create(
 		ctx context.Context,
@@ -25,15 +38,24 @@ type workoutProgramStore interface {
 	ListByCoachID(ctx context.Context, coachID int64) ([]models.WorkoutProgram, error)
 	ListByUserID(ctx context.Context, userID int64) ([]models.WorkoutProgram, error)
 	GetByID(ctx context.Context, programID int64) (*models.WorkoutProgram, error)
+	GetStructure(ctx context.Context, programID int64) ([]models.ProgramPhase, error)
 }
 
 type ProgramService struct {
+	db             *pgxpool.Pool
 	programRepo    workoutProgramStore
 	sessionRepo    *repository.SessionRepository
+	coachingRepo   *repository.CoachingRepository
 	userRepo       userReader
 	storageService StorageService
+	// Program revisions are announced in the client's chat with the coach.
+	conversationRepo *repository.ConversationRepository
+	chatSender       chatMessageSender
+	chatDeliverer    ChatDeliverer
 }
 
+// CreateProgramInput targets a client the coach actively coaches. SessionID optionally links the
+// program to one of their sessions.
 type CreateProgramInput struct {
 	UserID      int64
 	SessionID   int64
@@ -43,18 +65,44 @@ type CreateProgramInput struct {
 	Filename    string
 }
 
+type StructuredProgramInput struct {
+	UserID      int64
+	SessionID   int64
+	Title       string
+	Description *string
+	Phases      []models.ProgramPhase
+}
+
+// UpdateProgramInput changes only the provided fields; a non-nil Phases replaces the whole structure.
+// ChangeNote is shown to the client with the new version.
+type UpdateProgramInput struct {
+	Title       *string
+	Description *string
+	Phases      *[]models.ProgramPhase
+	ChangeNote  *string
+}
+
 func NewProgramService(
 	db *pgxpool.Pool,
 	programRepo *repository.WorkoutProgramRepository,
 	sessionRepo *repository.SessionRepository,
+	coachingRepo *repository.CoachingRepository,
 	userRepo userReader,
 	storageService StorageService,
+	conversationRepo *repository.ConversationRepository,
+	chatSender chatMessageSender,
+	chatDeliverer ChatDeliverer,
 ) *ProgramService {
 	return &ProgramService{
-		programRepo:    programRepo,
-		sessionRepo:    sessionRepo,
-		userRepo:       userRepo,
-		storageService: storageService,
+		db:               db,
+		programRepo:      programRepo,
+		sessionRepo:      sessionRepo,
+		coachingRepo:     coachingRepo,
+		userRepo:         userRepo,
+		storageService:   storageService,
+		conversationRepo: conversationRepo,
+		chatSender:       chatSender,
+		chatDeliverer:    chatDeliverer,
 	}
 }
 
@@ -66,57 +114,282 @@ func (s *ProgramService) CreateProgram(
 	if s.storageService == nil {
 		return nil, ErrStorageUnavailable
 	}
-	if coachID <= 0 || input.UserID <= 0 || input.SessionID <= 0 || input.File == nil {
+	if coachID <= 0 || input.UserID <= 0 || input.SessionID < 0 || input.File == nil {
 		return nil, ErrInvalidInput
 	}
 
-	title := strings.TrimSpace(input.Title)
-	if title == "" {
+	title, description, err := normalizeProgramDetails(input.Title, input.Description)
+	if err != nil {
+		return nil, err
+	}
+	relationship, err := s.authorizeProgramTarget(ctx, coachID, input.UserID, input.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	filename := buildProgramFilename(coachID, input.UserID, input.Filename)
+	fileURL, err := s.storageService.UploadFile(ctx, input.File, filename, "programs")
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := s.programRepo.Create(ctx, repository.CreateWorkoutProgramInput{
+		CoachID:        coachID,
+		UserID:         input.UserID,
+		RelationshipID: &relationship.ID,
+		SessionID:      input.SessionID,
+		Title:          title,
+		Description:    description,
+		FileURL:        fileURL,
+	})
+	if err != nil {
+		cleanupErr := s.storageService.DeleteFile(ctx, fileURL)
+		if cleanupErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("cleanup failed: %w", cleanupErr))
+		}
+		return nil, err
+	}
+
+	return program, nil
+}
+
+func (s *ProgramService) CreateStructuredProgram(
+	ctx context.Context,
+	coachID int64,
+	input StructuredProgramInput,
+) (*models.WorkoutProgram, error) {
+	if coachID <= 0 || input.UserID <= 0 || input.SessionID < 0 {
 		return nil, ErrInvalidInput
 	}
+	title, description, err := normalizeProgramDetails(input.Title, input.Description)
+	if err != nil {
+		return nil, err
+	}
+	if err := normalizeProgramPhases(input.Phases); err != nil {
+		return nil, err
+	}
+	relationship, err := s.authorizeProgramTarget(ctx, coachID, input.UserID, input.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := checkProgramExerciseAccess(ctx, tx, coachID, input.Phases); err != nil {
+		return nil, err
+	}
 
-	var description *string
-	if input.Description != nil {
-		trimmed := strings.TrimSpace(*input.Description)
-		if trimmed == "" {
-			return nil, ErrInvalidInput
+	txProgramRepo := repository.NewWorkoutProgramRepository(tx)
+
+	program, err := txProgramRepo.Create(ctx, repository.CreateWorkoutProgramInput{
+		CoachID:        coachID,
+		UserID:         input.UserID,
+		RelationshipID: &relationship.ID,
+		SessionID:      input.SessionID,
+		Title:          title,
+		Description:    description,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := txProgramRepo.ReplaceStructure(ctx, program.ID, input.Phases); err != nil {
+		return nil, err
+	}
+	if program.Phases, err = txProgramRepo.GetStructure(ctx, program.ID); err != nil {
+		return nil, err
+	}
+	if err := repository.NewProgramVersionRepository(tx).SetInitialPhases(
+		ctx,
+		program.ID,
+		cloneProgramPhases(program.Phases),
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+// UpdateProgram applies the changes and publishes them as a new version, which the client is told
+// about in chat. Saving identical content does not create a version.
+func (s *ProgramService) UpdateProgram(
+	ctx context.Context,
+	coachID int64,
+	programID int64,
+	input UpdateProgramInput,
+) (*models.WorkoutProgram, error) {
+	if input.Phases != nil {
+		if err := normalizeProgramPhases(*input.Phases); err != nil {
+			return nil, err
 		}
-		description = &trimmed
 	}
+	changeNote, err := normalizeChangeNote(input.ChangeNote)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txProgramRepo := repository.NewWorkoutProgramRepository(tx)
 
-	user, err := s.userRepo.GetByID(ctx, input.UserID)
+	program, err := txProgramRepo.GetByIDForUpdate(ctx, programID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, pgx.ErrNoRows
+		return nil, err
+	}
+	if program.CoachID != coachID {
+		return nil, ErrForbidden
+	}
+
+	if input.Title != nil || input.Description != nil {
+		title := program.Title
+		if input.Title != nil {
+			title = *input.Title
+		}
+		description := program.Description
+		if input.Description != nil {
+			description = input.Description
+		}
+		title, description, err = normalizeProgramDetails(title, description)
+		if err != nil {
+			return nil, err
+		}
+		if program, err = txProgramRepo.UpdateDetails(ctx, programID, title, description); err != nil {
+			return nil, err
 		}
+	}
+	if input.Phases != nil {
+		if err := checkProgramExerciseAccess(ctx, tx, coachID, *input.Phases); err != nil {
+			return nil, err
+		}
+		if err := txProgramRepo.ReplaceStructure(ctx, programID, *input.Phases); err != nil {
+			return nil, err
+		}
+		if program, err = txProgramRepo.GetByID(ctx, programID); err != nil {
+			return nil, err
+		}
+	}
+	if program.Phases, err = txProgramRepo.GetStructure(ctx, programID); err != nil {
 		return nil, err
 	}
-	if user.Role != "user" {
+	version, revised, err := publishProgramVersion(ctx, tx, program, changeNote)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	if revised {
+		s.announceProgramVersion(ctx, program, version, changeNote)
+	}
+	return program, nil
+}
+
+// announceProgramVersion tells the client about a new program version in their chat with the
+// coach. The version is already stored, so failures are only logged.
+func (s *ProgramService) announceProgramVersion(
+	ctx context.Context,
+	program *models.WorkoutProgram,
+	version *models.ProgramVersion,
+	changeNote *string,
+) {
+	if s.chatSender == nil {
+		return
+	}
+	conversation, err := s.conversationRepo.CreateOrGet(ctx, program.UserID, program.CoachID)
+	if err != nil {
+		log.Printf("program %d version %d notice: %v", program.ID, version.Version, err)
+		return
+	}
+	delivery, err := s.chatSender.SendMessage(
+		ctx,
+		program.CoachID,
+		"coach",
+		conversation.ID,
+		programVersionMessage(program.Title, version.Version, changeNote),
+	)
+	if err != nil {
+		log.Printf("program %d version %d notice: %v", program.ID, version.Version, err)
+		return
+	}
+	deliverStored(s.chatDeliverer, delivery, fmt.Sprintf("program %d version %d notice", program.ID, version.Version))
+}
+
+func (s *ProgramService) DeleteProgram(ctx context.Context, coachID int64, programID int64) error {
+	program, err := s.programRepo.GetByID(ctx, programID)
+	if err != nil {
+		return err
+	}
+	if program.CoachID != coachID {
+		return ErrForbidden
+	}
+
+	fileURLs, err := repository.NewProgramVersionRepository(s.db).ListFileURLs(ctx, programID)
+	if err != nil {
+		return err
+	}
+	if err := repository.NewWorkoutProgramRepository(s.db).Delete(ctx, programID); err != nil {
+		return err
+	}
+	// The row is gone either way; leftover blobs are preferable to failing the delete.
+	if s.storageService != nil {
+		for _, fileURL := range fileURLs {
+			_ = s.storageService.DeleteFile(ctx, fileURL)
+		}
+	}
+	return nil
+}
+
+// AttachFile uploads file as the program's downloadable attachment and publishes a new version.
+// Earlier files are kept for the versions that reference them.
+func (s *ProgramService) AttachFile(
+	ctx context.Context,
+	coachID int64,
+	programID int64,
+	file multipart.File,
+	originalFilename string,
+	changeNote *string,
+) (*models.WorkoutProgram, error) {
+	if s.storageService == nil {
+		return nil, ErrStorageUnavailable
+	}
+	if file == nil {
 		return nil, ErrInvalidInput
 	}
+	changeNote, err := normalizeChangeNote(changeNote)
+	if err != nil {
+		return nil, err
+	}
 
-	session, err := s.sessionRepo.GetByID(ctx, input.SessionID)
+	program, err := s.programRepo.GetByID(ctx, programID)
 	if err != nil {
 		return nil, err
 	}
-	if session.CoachID != coachID || session.UserID != input.UserID {
+	if program.CoachID != coachID {
 		return nil, ErrForbidden
 	}
 
-	filename := buildProgramFilename(coachID, input.UserID, input.Filename)
-	fileURL, err := s.storageService.UploadFile(ctx, input.File, filename, "programs")
+	filename := buildProgramFilename(coachID, program.UserID, originalFilename)
+	fileURL, err := s.storageService.UploadFile(ctx, file, filename, "programs")
 	if err != nil {
 		return nil, err
 	}
 
-	program, err := s.programRepo.Create(ctx, repository.CreateWorkoutProgramInput{
-		CoachID:     coachID,
-		UserID:      input.UserID,
-		SessionID:   input.SessionID,
-		Title:       title,
-		Description: description,
-		FileURL:     fileURL,
-	})
+	updated, err := s.storeAttachment(ctx, programID, fileURL, changeNote)
 	if err != nil {
 		cleanupErr := s.storageService.DeleteFile(ctx, fileURL)
 		if cleanupErr != nil {
@@ -124,7 +397,42 @@ func (s *ProgramService) CreateProgram(
 		}
 		return nil, err
 	}
+	return updated, nil
+}
+
+func (s *ProgramService) storeAttachment(
+	ctx context.Context,
+	programID int64,
+	fileURL string,
+	changeNote *string,
+) (*models.WorkoutProgram, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
+	txProgramRepo := repository.NewWorkoutProgramRepository(tx)
+	program, err := txProgramRepo.SetFileURL(ctx, programID, fileURL)
+	if err != nil {
+		return nil, err
+	}
+	if program.Phases, err = txProgramRepo.GetStructure(ctx, programID); err != nil {
+		return nil, err
+	}
+	version, revised, err := publishProgramVersion(ctx, tx, program, changeNote)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	if revised {
+		s.announceProgramVersion(ctx, program, version, changeNote)
+	}
 	return program, nil
 }
 
@@ -153,8 +461,11 @@ func (s *ProgramService) GetProgram(
 	if err != nil {
 		return nil, err
 	}
-	if !canAccessProgram(role, actorID, program) {
-		return nil, ErrForbidden
+	if err := checkProgramAccess(ctx, s.coachingRepo, role, actorID, program); err != nil {
+		return nil, err
+	}
+	if program.Phases, err = s.programRepo.GetStructure(ctx, programID); err != nil {
+		return nil, err
 	}
 	return program, nil
 }
@@ -174,21 +485,193 @@ func (s *ProgramService) GetDownloadURL(
 		return "", err
 	}
 
+	if program.FileURL == "" {
+		return "", pgx.ErrNoRows
+	}
+
 	return s.storageService.GetSignedURL(ctx, program.FileURL)
 }
 
-func canAccessProgram(role string, actorID int64, program *models.WorkoutProgram) bool {
+func (s *ProgramService) authorizeProgramTarget(
+	ctx context.Context,
+	coachID int64,
+	userID int64,
+	sessionID int64,
+) (*models.CoachingRelationship, error) {
+	return authorizeProgramClient(ctx, s.userRepo, s.sessionRepo, s.coachingRepo, coachID, userID, sessionID)
+}
+
+// authorizeProgramClient checks that the coach actively coaches the client and, when a session is
+// given, that it is between them. It returns the relationship the program belongs to.
+func authorizeProgramClient(
+	ctx context.Context,
+	userRepo userReader,
+	sessionRepo *repository.SessionRepository,
+	coachingRepo *repository.CoachingRepository,
+	coachID int64,
+	userID int64,
+	sessionID int64,
+) (*models.CoachingRelationship, error) {
+	relationship, err := activeRelationship(ctx, userRepo, coachingRepo, coachID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if sessionID == 0 {
+		return relationship, nil
+	}
+
+	session, err := sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.CoachID != coachID || session.UserID != userID {
+		return nil, ErrForbidden
+	}
+	return relationship, nil
+}
+
+func normalizeProgramDetails(title string, description *string) (string, *string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", nil, ErrInvalidInput
+	}
+	if description == nil {
+		return title, nil, nil
+	}
+	trimmed := strings.TrimSpace(*description)
+	if trimmed == "" {
+		return "", nil, ErrInvalidInput
+	}
+	return title, &trimmed, nil
+}
+
+// normalizeProgramPhases trims free text in place and rejects structures the schema cannot hold.
+func normalizeProgramPhases(phases []models.ProgramPhase) error {
+	if len(phases) > maxProgramPhases {
+		return ErrInvalidInput
+	}
+	for i := range phases {
+		phase := &phases[i]
+		phase.Name = strings.TrimSpace(phase.Name)
+		phase.Notes = blankToNil(phase.Notes)
+		if phase.Name == "" || len(phase.Name) > 100 || len(phase.Weeks) > maxProgramWeeks {
+			return ErrInvalidInput
+		}
+
+		weekNumbers := make(map[int]bool, len(phase.Weeks))
+		for j := range phase.Weeks {
+			week := &phase.Weeks[j]
+			week.Notes = blankToNil(week.Notes)
+			if week.WeekNumber <= 0 || weekNumbers[week.WeekNumber] {
+				return ErrInvalidInput
+			}
+			weekNumbers[week.WeekNumber] = true
+
+			dayNumbers := make(map[int]bool, len(week.Days))
+			for k := range week.Days {
+				day := &week.Days[k]
+				day.Name = blankToNil(day.Name)
+				day.Notes = blankToNil(day.Notes)
+				if day.DayNumber < 1 || day.DayNumber > 7 || dayNumbers[day.DayNumber] {
+					return ErrInvalidInput
+				}
+				if day.Name != nil && len(*day.Name) > 100 {
+					return ErrInvalidInput
+				}
+				dayNumbers[day.DayNumber] = true
+
+				if len(day.Exercises) > maxProgramDayExercises {
+					return ErrInvalidInput
+				}
+				for l := range day.Exercises {
+					if err := normalizeProgramExercise(&day.Exercises[l]); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func normalizeProgramExercise(exercise *models.ProgramExercise) error {
+	exercise.Name = strings.TrimSpace(exercise.Name)
+	exercise.Reps = blankToNil(exercise.Reps)
+	exercise.Tempo = blankToNil(exercise.Tempo)
+	exercise.Notes = blankToNil(exercise.Notes)
+	if exercise.SupersetGroup = blankToNil(exercise.SupersetGroup); exercise.SupersetGroup != nil {
+		group := strings.ToUpper(*exercise.SupersetGroup)
+		exercise.SupersetGroup = &group
+	}
+
+	switch {
+	case exercise.Name == "" || len(exercise.Name) > 150:
+		return ErrInvalidInput
+	case exercise.ExerciseID != nil && *exercise.ExerciseID <= 0:
+		return ErrInvalidInput
+	case exercise.SupersetGroup != nil && !programSupersetPattern.MatchString(*exercise.SupersetGroup):
+		return ErrInvalidInput
+	case exercise.Sets != nil && (*exercise.Sets <= 0 || *exercise.Sets > 100):
+		return ErrInvalidInput
+	case exercise.Reps != nil && len(*exercise.Reps) > 20:
+		return ErrInvalidInput
+	case exercise.WeightKg != nil && (*exercise.WeightKg < 0 || *exercise.WeightKg >= 10000):
+		return ErrInvalidInput
+	case exercise.Tempo != nil && !programTempoPattern.MatchString(strings.ToUpper(*exercise.Tempo)):
+		return ErrInvalidInput
+	case exercise.RestSeconds != nil && (*exercise.RestSeconds < 0 || *exercise.RestSeconds > 3600):
+		return ErrInvalidInput
+	case exercise.RPE != nil && (*exercise.RPE < 1 || *exercise.RPE > 10):
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// checkProgramAccess lets the client read their program, and the assigning coach only while they
+// still actively coach the client.
+func checkProgramAccess(
+	ctx context.Context,
+	coachingRepo *repository.CoachingRepository,
+	role string,
+	actorID int64,
+	program *models.WorkoutProgram,
+) error {
 	if program == nil {
-		return false
+		return ErrForbidden
 	}
+	return checkClientRecordAccess(ctx, coachingRepo, role, actorID, program.CoachID, program.UserID)
+}
 
+// checkClientRecordAccess lets the client read a record they share with a coach, and the coach
+// only while their relationship with the client is active.
+func checkClientRecordAccess(
+	ctx context.Context,
+	coachingRepo *repository.CoachingRepository,
+	role string,
+	actorID int64,
+	coachID int64,
+	userID int64,
+) error {
 	switch role {
 	case "coach":
-		return actorID == program.CoachID
+		if actorID != coachID {
+			return ErrForbidden
+		}
+		active, err := coachingRepo.IsActiveCoach(ctx, coachID, userID)
+		if err != nil {
+			return err
+		}
+		if !active {
+			return ErrForbidden
+		}
+		return nil
 	case "user":
-		return actorID == program.UserID
+		if actorID != userID {
+			return ErrForbidden
+		}
+		return nil
 	default:
-		return false
+		return ErrForbidden
 	}
 }
 
@@ -199,3 +682,34 @@ func buildProgramFilename(coachID int64, userID int64, original string) string {
 	}
 	return fmt.Sprintf("%d-%d-%d%s", coachID, userID, time.Now().UnixNano(), ext)
 }
+
+// checkProgramExerciseAccess rejects library references to exercises that do not exist or are
+// another coach's custom exercises.
+func checkProgramExerciseAccess(ctx context.Context, db repository.DBTX, coachID int64, phases []models.ProgramPhase) error {
+	seen := make(map[int64]bool)
+	exerciseIDs := make([]int64, 0)
+	for _, phase := range phases {
+		for _, week := range phase.Weeks {
+			for _, day := range week.Days {
+				for _, exercise := range day.Exercises {
+					if exercise.ExerciseID != nil && !seen[*exercise.ExerciseID] {
+						seen[*exercise.ExerciseID] = true
+						exerciseIDs = append(exerciseIDs, *exercise.ExerciseID)
+					}
+				}
+			}
+		}
+	}
+	if len(exerciseIDs) == 0 {
+		return nil
+	}
+
+	count, err := repository.NewExerciseRepository(db).CountAccessible(ctx, coachID, exerciseIDs)
+	if err != nil {
+		return err
+	}
+	if count != len(exerciseIDs) {
+		return ErrInvalidInput
+	}
+	return nil
+}
@@ -42,6 +42,10 @@ func (r *stubProgramRepo) GetByID(_ context.Context, _ int64) (*models.WorkoutPr
 	return r.getResult, r.getErr
 }
 
+func (r *stubProgramRepo) GetStructure(_ context.Context, _ int64) ([]models.ProgramPhase, error) {
+	return []models.ProgramPhase{}, nil
+}
+
 type stubProgramStorage struct {
 	uploadURL      string
 	uploadErr      error
@@ -99,12 +103,18 @@ func (r stubRow) Scan(dest ...any) error {
 			*target = r.values[i].(int64)
 		case *int:
 			*target = r.values[i].(int)
+		case *bool:
+			*target = r.values[i].(bool)
 		case *string:
 			*target = r.values[i].(string)
+		case *[]int:
+			*target = r.values[i].([]int)
 		case **string:
 			*target = r.values[i].(*string)
 		case *time.Time:
 			*target = r.values[i].(time.Time)
+		case **time.Time:
+			*target = r.values[i].(*time.Time)
 		default:
 			return errors.New("unsupported scan target")
 		}
@@ -114,9 +124,13 @@ func (r stubRow) Scan(dest ...any) error {
 
 type stubDBTX struct {
 	queryRowFn func(ctx context.Context, query string, args ...any) stubRow
+	execFn     func(ctx context.Context, query string, args ...any) error
 }
 
-func (db *stubDBTX) Exec(_ context.Context, _ string, _ ...any) (pgconn.CommandTag, error) {
+func (db *stubDBTX) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	if db.execFn != nil {
+		return pgconn.CommandTag{}, db.execFn(ctx, query, args...)
+	}
 	return pgconn.CommandTag{}, nil
 }
 
@@ -142,24 +156,50 @@ func newTestMultipartFile(content string) multipart.File {
 
 var testTime = time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
 
-func TestProgramServiceCreateProgramUploadsAndStoresProgram(t *testing.T) {
-	programRepo := &stubProgramRepo{
-		createResult: &models.WorkoutProgram{ID: 1, CoachID: 7, UserID: 42, SessionID: 99, FileURL: "https://storage/program.pdf"},
-	}
-	sessionRepo := repository.NewSessionRepository(&stubDBTX{
+// newProgramClientDB answers session 99 between coach 7 and client 42, and their coaching
+// relationship with the given status.
+func newProgramClientDB(status string) *stubDBTX {
+	return &stubDBTX{
 		queryRowFn: func(_ context.Context, query string, args ...any) stubRow {
-			if strings.Contains(query, "FROM bookings") {
+			switch {
+			case strings.Contains(query, "FROM bookings"):
 				return stubRow{values: []any{int64(99), int64(42), int64(7), testTime, 60, "completed", (*string)(nil), testTime, testTime}}
+			case strings.Contains(query, "FROM coaching_relationships"):
+				return stubRow{values: []any{
+					int64(5), int64(7), int64(42), (*string)(nil), (*string)(nil), status,
+					testTime, (*time.Time)(nil), testTime, testTime,
+				}}
 			}
 			return stubRow{err: pgx.ErrNoRows}
 		},
-	})
+	}
+}
+
+// newRelationshipStatusDB reports whether coach 7 actively coaches client 42 given the status of
+// their relationship.
+func newRelationshipStatusDB(status string) *stubDBTX {
+	return &stubDBTX{
+		queryRowFn: func(_ context.Context, query string, args ...any) stubRow {
+			if strings.Contains(query, "FROM coaching_relationships") {
+				return stubRow{values: []any{args[0] == int64(7) && args[1] == int64(42) && status == "active"}}
+			}
+			return stubRow{err: pgx.ErrNoRows}
+		},
+	}
+}
+
+func TestProgramServiceCreateProgramUploadsAndStoresProgram(t *testing.T) {
+	programRepo := &stubProgramRepo{
+		createResult: &models.WorkoutProgram{ID: 1, CoachID: 7, UserID: 42, SessionID: 99, FileURL: "https://storage/program.pdf"},
+	}
+	db := newProgramClientDB("active")
 	userRepo := &stubProgramUserRepo{user: &models.User{ID: 42, Role: "user"}}
 	storage := &stubProgramStorage{uploadURL: "https://storage/program.pdf"}
 
 	service := &ProgramService{
 		programRepo:    programRepo,
-		sessionRepo:    sessionRepo,
+		sessionRepo:    repository.NewSessionRepository(db),
+		coachingRepo:   repository.NewCoachingRepository(db),
 		userRepo:       userRepo,
 		storageService: storage,
 	}
@@ -195,22 +235,61 @@ func TestProgramServiceCreateProgramUploadsAndStoresProgram(t *testing.T) {
 	}
 }
 
+func TestProgramServiceCreateProgramFollowsRelationship(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    string
+		sessionID int64
+		wantErr   error
+	}{
+		{name: "active client without a session", status: "active"},
+		{name: "paused client", status: "paused", wantErr: ErrForbidden},
+		{name: "ended client with an old session", status: "ended", sessionID: 99, wantErr: ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newProgramClientDB(tt.status)
+			programRepo := &stubProgramRepo{createResult: &models.WorkoutProgram{ID: 1, CoachID: 7, UserID: 42}}
+			service := &ProgramService{
+				programRepo:    programRepo,
+				sessionRepo:    repository.NewSessionRepository(db),
+				coachingRepo:   repository.NewCoachingRepository(db),
+				userRepo:       &stubProgramUserRepo{user: &models.User{ID: 42, Role: "user"}},
+				storageService: &stubProgramStorage{uploadURL: "https://storage/program.pdf"},
+			}
+
+			_, err := service.CreateProgram(context.Background(), 7, CreateProgramInput{
+				UserID:    42,
+				SessionID: tt.sessionID,
+				Title:     "Week 1",
+				File:      newTestMultipartFile("program-bytes"),
+				Filename:  "program.pdf",
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if programRepo.lastCreate.RelationshipID == nil || *programRepo.lastCreate.RelationshipID != 5 ||
+				programRepo.lastCreate.SessionID != 0 {
+				t.Fatalf("expected program linked to the relationship only, got %+v", programRepo.lastCreate)
+			}
+		})
+	}
+}
+
 func TestProgramServiceCreateProgramDeletesUploadWhenInsertFails(t *testing.T) {
 	programRepo := &stubProgramRepo{createErr: errors.New("insert failed")}
-	sessionRepo := repository.NewSessionRepository(&stubDBTX{
-		queryRowFn: func(_ context.Context, query string, args ...any) stubRow {
-			if strings.Contains(query, "FROM bookings") {
-				return stubRow{values: []any{int64(99), int64(42), int64(7), testTime, 60, "completed", (*string)(nil), testTime, testTime}}
-			}
-			return stubRow{err: pgx.ErrNoRows}
-		},
-	})
+	db := newProgramClientDB("active")
 	userRepo := &stubProgramUserRepo{user: &models.User{ID: 42, Role: "user"}}
 	storage := &stubProgramStorage{uploadURL: "https://storage/program.pdf"}
 
 	service := &ProgramService{
 		programRepo:    programRepo,
-		sessionRepo:    sessionRepo,
+		sessionRepo:    repository.NewSessionRepository(db),
+		coachingRepo:   repository.NewCoachingRepository(db),
 		userRepo:       userRepo,
 		storageService: storage,
 	}
@@ -235,14 +314,7 @@ func TestProgramServiceCreateProgramSurfacesCleanupFailure(t *testing.T) {
 	createErr := errors.New("insert failed")
 	deleteErr := errors.New("delete failed")
 	programRepo := &stubProgramRepo{createErr: createErr}
-	sessionRepo := repository.NewSessionRepository(&stubDBTX{
-		queryRowFn: func(_ context.Context, query string, args ...any) stubRow {
-			if strings.Contains(query, "FROM bookings") {
-				return stubRow{values: []any{int64(99), int64(42), int64(7), testTime, 60, "completed", (*string)(nil), testTime, testTime}}
-			}
-			return stubRow{err: pgx.ErrNoRows}
-		},
-	})
+	db := newProgramClientDB("active")
 	userRepo := &stubProgramUserRepo{user: &models.User{ID: 42, Role: "user"}}
 	storage := &stubProgramStorage{
 		uploadURL: "https://storage/program.pdf",
@@ -251,7 +323,8 @@ func TestProgramServiceCreateProgramSurfacesCleanupFailure(t *testing.T) {
 
 	service := &ProgramService{
 		programRepo:    programRepo,
-		sessionRepo:    sessionRepo,
+		sessionRepo:    repository.NewSessionRepository(db),
+		coachingRepo:   repository.NewCoachingRepository(db),
 		userRepo:       userRepo,
 		storageService: storage,
 	}
@@ -305,3 +378,110 @@ func TestProgramServiceGetDownloadURLChecksAccess(t *testing.T) {
 		t.Fatalf("expected ErrForbidden, got %v", err)
 	}
 }
+
+func TestProgramServiceGetProgramFollowsRelationship(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    string
+		actorID int64
+		status  string
+		wantErr error
+	}{
+		{name: "active coach", role: "coach", actorID: 7, status: "active"},
+		{name: "paused coach", role: "coach", actorID: 7, status: "paused", wantErr: ErrForbidden},
+		{name: "ended coach", role: "coach", actorID: 7, status: "ended", wantErr: ErrForbidden},
+		{name: "other coach", role: "coach", actorID: 8, status: "active", wantErr: ErrForbidden},
+		{name: "client after relationship ended", role: "user", actorID: 42, status: "ended"},
+		{name: "other client", role: "user", actorID: 43, status: "active", wantErr: ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &ProgramService{
+				programRepo:  &stubProgramRepo{getResult: &models.WorkoutProgram{ID: 4, CoachID: 7, UserID: 42}},
+				coachingRepo: repository.NewCoachingRepository(newRelationshipStatusDB(tt.status)),
+			}
+			_, err := service.GetProgram(context.Background(), tt.actorID, tt.role, 4)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeProgramPhases(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	floatPtr := func(v float64) *float64 { return &v }
+	strPtr := func(v string) *string { return &v }
+	int64Ptr := func(v int64) *int64 { return &v }
+	withExercise := func(exercise models.ProgramExercise) []models.ProgramPhase {
+		return []models.ProgramPhase{{
+			Name: "Base",
+			Weeks: []models.ProgramWeek{{
+				WeekNumber: 1,
+				Days:       []models.ProgramDay{{DayNumber: 1, Exercises: []models.ProgramExercise{exercise}}},
+			}},
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		phases  []models.ProgramPhase
+		wantErr bool
+	}{
+		{name: "empty structure", phases: nil},
+		{
+			name: "valid exercise",
+			phases: withExercise(models.ProgramExercise{
+				Name: " Bench press ", SupersetGroup: strPtr("a1"), Sets: intPtr(3), Reps: strPtr("6-8"),
+				WeightKg: floatPtr(80), Tempo: strPtr("3-1-X-0"), RestSeconds: intPtr(120), RPE: floatPtr(8),
+			}),
+		},
+		{name: "blank phase name", phases: []models.ProgramPhase{{Name: "  "}}, wantErr: true},
+		{
+			name: "duplicate week",
+			phases: []models.ProgramPhase{{
+				Name:  "Base",
+				Weeks: []models.ProgramWeek{{WeekNumber: 1}, {WeekNumber: 1}},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "day out of range",
+			phases: []models.ProgramPhase{{
+				Name:  "Base",
+				Weeks: []models.ProgramWeek{{WeekNumber: 1, Days: []models.ProgramDay{{DayNumber: 8}}}},
+			}},
+			wantErr: true,
+		},
+		{name: "zero sets", phases: withExercise(models.ProgramExercise{Name: "Row", Sets: intPtr(0)}), wantErr: true},
+		{name: "rpe above ten", phases: withExercise(models.ProgramExercise{Name: "Row", RPE: floatPtr(11)}), wantErr: true},
+		{name: "bad tempo", phases: withExercise(models.ProgramExercise{Name: "Row", Tempo: strPtr("slow")}), wantErr: true},
+		{name: "negative rest", phases: withExercise(models.ProgramExercise{Name: "Row", RestSeconds: intPtr(-1)}), wantErr: true},
+		{name: "invalid library id", phases: withExercise(models.ProgramExercise{Name: "Row", ExerciseID: int64Ptr(0)}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := normalizeProgramPhases(tt.phases)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Fatalf("expected ErrInvalidInput, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	phases := withExercise(models.ProgramExercise{Name: " Bench press ", SupersetGroup: strPtr("a1")})
+	if err := normalizeProgramPhases(phases); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exercise := phases[0].Weeks[0].Days[0].Exercises[0]
+	if exercise.Name != "Bench press" || *exercise.SupersetGroup != "A1" {
+		t.Fatalf("expected trimmed name and upper-cased superset, got %+v", exercise)
+	}
+}
@@ -0,0 +1,380 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	maxTemplateAssignments = 100
+	minWeightMultiplier    = 0.1
+	maxWeightMultiplier    = 3
+)
+
+// ProgramTemplateInput creates a template from Phases, or from a copy of one of the coach's
+// programs when SourceProgramID is set.
+type ProgramTemplateInput struct {
+	Title           string
+	Description     *string
+	Phases          []models.ProgramPhase
+	SourceProgramID *int64
+}
+
+// ExerciseSubstitution swaps every template exercise named Name for Replacement. ExerciseID
+// links the replacement to the exercise library.
+type ExerciseSubstitution struct {
+	Name        string
+	Replacement string
+	ExerciseID  *int64
+}
+
+// ProgramAssignment describes one client receiving a template. The coach must be actively coaching
+// the client; SessionID optionally links the program to one of their sessions.
+type ProgramAssignment struct {
+	UserID           int64
+	SessionID        int64
+	StartDate        *time.Time
+	Title            *string
+	WeightMultiplier *float64
+	Substitutions    []ExerciseSubstitution
+}
+
+type ProgramTemplateService struct {
+	db           *pgxpool.Pool
+	templateRepo *repository.ProgramTemplateRepository
+	programRepo  *repository.WorkoutProgramRepository
+	sessionRepo  *repository.SessionRepository
+	userRepo     userReader
+	coachingRepo *repository.CoachingRepository
+}
+
+func NewProgramTemplateService(
+	db *pgxpool.Pool,
+	templateRepo *repository.ProgramTemplateRepository,
+	programRepo *repository.WorkoutProgramRepository,
+	sessionRepo *repository.SessionRepository,
+	userRepo userReader,
+	coachingRepo *repository.CoachingRepository,
+) *ProgramTemplateService {
+	return &ProgramTemplateService{
+		db:           db,
+		templateRepo: templateRepo,
+		programRepo:  programRepo,
+		sessionRepo:  sessionRepo,
+		userRepo:     userRepo,
+		coachingRepo: coachingRepo,
+	}
+}
+
+func (s *ProgramTemplateService) CreateTemplate(
+	ctx context.Context,
+	coachID int64,
+	input ProgramTemplateInput,
+) (*models.ProgramTemplate, error) {
+	title, description, err := normalizeProgramDetails(input.Title, input.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	phases := input.Phases
+	if input.SourceProgramID != nil {
+		if len(phases) > 0 {
+			return nil, ErrInvalidInput
+		}
+		program, err := s.programRepo.GetByID(ctx, *input.SourceProgramID)
+		if err != nil {
+			return nil, err
+		}
+		if program.CoachID != coachID {
+			return nil, ErrForbidden
+		}
+		if phases, err = s.programRepo.GetStructure(ctx, program.ID); err != nil {
+			return nil, err
+		}
+	}
+	phases = cloneProgramPhases(phases)
+	if err := normalizeProgramPhases(phases); err != nil {
+		return nil, err
+	}
+	if err := checkProgramExerciseAccess(ctx, s.db, coachID, phases); err != nil {
+		return nil, err
+	}
+
+	return s.templateRepo.Create(ctx, coachID, repository.ProgramTemplateInput{
+		Title:       title,
+		Description: description,
+		Phases:      phases,
+	})
+}
+
+func (s *ProgramTemplateService) ListTemplates(ctx context.Context, coachID int64) ([]models.ProgramTemplate, error) {
+	return s.templateRepo.ListByCoachID(ctx, coachID)
+}
+
+func (s *ProgramTemplateService) GetTemplate(
+	ctx context.Context,
+	coachID int64,
+	templateID int64,
+) (*models.ProgramTemplate, error) {
+	template, err := s.templateRepo.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template.CoachID != coachID {
+		return nil, ErrForbidden
+	}
+	return template, nil
+}
+
+// UpdateTemplate changes only the provided fields. Programs already assigned from the template
+// are not affected.
+func (s *ProgramTemplateService) UpdateTemplate(
+	ctx context.Context,
+	coachID int64,
+	templateID int64,
+	input UpdateProgramInput,
+) (*models.ProgramTemplate, error) {
+	template, err := s.GetTemplate(ctx, coachID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	title := template.Title
+	if input.Title != nil {
+		title = *input.Title
+	}
+	description := template.Description
+	if input.Description != nil {
+		description = input.Description
+	}
+	title, description, err = normalizeProgramDetails(title, description)
+	if err != nil {
+		return nil, err
+	}
+
+	phases := template.Phases
+	if input.Phases != nil {
+		phases = cloneProgramPhases(*input.Phases)
+		if err := normalizeProgramPhases(phases); err != nil {
+			return nil, err
+		}
+		if err := checkProgramExerciseAccess(ctx, s.db, coachID, phases); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.templateRepo.Update(ctx, templateID, repository.ProgramTemplateInput{
+		Title:       title,
+		Description: description,
+		Phases:      phases,
+	})
+}
+
+func (s *ProgramTemplateService) DeleteTemplate(ctx context.Context, coachID int64, templateID int64) error {
+	if _, err := s.GetTemplate(ctx, coachID, templateID); err != nil {
+		return err
+	}
+	return s.templateRepo.Delete(ctx, templateID)
+}
+
+// AssignTemplate clones the template into one program per client in a single transaction; if any
+// assignment is rejected, no program is created. startDate applies to assignments without their own.
+func (s *ProgramTemplateService) AssignTemplate(
+	ctx context.Context,
+	coachID int64,
+	templateID int64,
+	startDate *time.Time,
+	assignments []ProgramAssignment,
+) ([]models.WorkoutProgram, error) {
+	if len(assignments) == 0 || len(assignments) > maxTemplateAssignments {
+		return nil, ErrInvalidInput
+	}
+
+	template, err := s.GetTemplate(ctx, coachID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	type plannedProgram struct {
+		input  repository.CreateWorkoutProgramInput
+		phases []models.ProgramPhase
+	}
+	planned := make([]plannedProgram, 0, len(assignments))
+	seen := make(map[int64]bool, len(assignments))
+	for i, assignment := range assignments {
+		if assignment.UserID <= 0 || seen[assignment.UserID] || assignment.SessionID < 0 {
+			return nil, fmt.Errorf("assignment %d: %w", i+1, ErrInvalidInput)
+		}
+		seen[assignment.UserID] = true
+
+		start := assignment.StartDate
+		if start == nil {
+			start = startDate
+		}
+		if start == nil {
+			return nil, fmt.Errorf("assignment %d: %w", i+1, ErrInvalidInput)
+		}
+		day := startOfUTCDay(*start)
+
+		title := template.Title
+		if assignment.Title != nil {
+			title = *assignment.Title
+		}
+		title, description, err := normalizeProgramDetails(title, template.Description)
+		if err != nil {
+			return nil, fmt.Errorf("assignment %d: %w", i+1, err)
+		}
+
+		phases, err := adjustTemplatePhases(template.Phases, assignment)
+		if err != nil {
+			return nil, fmt.Errorf("assignment %d: %w", i+1, err)
+		}
+
+		relationship, err := authorizeProgramClient(
+			ctx,
+			s.userRepo,
+			s.sessionRepo,
+			s.coachingRepo,
+			coachID,
+			assignment.UserID,
+			assignment.SessionID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("assignment %d: %w", i+1, err)
+		}
+
+		planned = append(planned, plannedProgram{
+			input: repository.CreateWorkoutProgramInput{
+				CoachID:        coachID,
+				UserID:         assignment.UserID,
+				RelationshipID: &relationship.ID,
+				SessionID:      assignment.SessionID,
+				TemplateID:     &template.ID,
+				StartDate:      &day,
+				Title:          title,
+				Description:    description,
+			},
+			phases: phases,
+		})
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txProgramRepo := repository.NewWorkoutProgramRepository(tx)
+	txVersionRepo := repository.NewProgramVersionRepository(tx)
+	programs := make([]models.WorkoutProgram, 0, len(planned))
+	for _, plan := range planned {
+		if err := checkProgramExerciseAccess(ctx, tx, coachID, plan.phases); err != nil {
+			return nil, err
+		}
+		program, err := txProgramRepo.Create(ctx, plan.input)
+		if err != nil {
+			return nil, err
+		}
+		if err := txProgramRepo.ReplaceStructure(ctx, program.ID, plan.phases); err != nil {
+			return nil, err
+		}
+		if program.Phases, err = txProgramRepo.GetStructure(ctx, program.ID); err != nil {
+			return nil, err
+		}
+		if err := txVersionRepo.SetInitialPhases(ctx, program.ID, cloneProgramPhases(program.Phases)); err != nil {
+			return nil, err
+		}
+		programs = append(programs, *program)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return programs, nil
+}
+
+// adjustTemplatePhases returns a validated copy of the template structure with the client's
+// substitutions and load scaling applied.
+func adjustTemplatePhases(phases []models.ProgramPhase, assignment ProgramAssignment) ([]models.ProgramPhase, error) {
+	if assignment.WeightMultiplier != nil &&
+		(*assignment.WeightMultiplier < minWeightMultiplier || *assignment.WeightMultiplier > maxWeightMultiplier) {
+		return nil, ErrInvalidInput
+	}
+
+	substitutions := make(map[string]ExerciseSubstitution, len(assignment.Substitutions))
+	for _, substitution := range assignment.Substitutions {
+		key := strings.ToLower(strings.TrimSpace(substitution.Name))
+		substitution.Replacement = strings.TrimSpace(substitution.Replacement)
+		if key == "" || substitution.Replacement == "" {
+			return nil, ErrInvalidInput
+		}
+		if _, duplicate := substitutions[key]; duplicate {
+			return nil, ErrInvalidInput
+		}
+		substitutions[key] = substitution
+	}
+
+	adjusted := cloneProgramPhases(phases)
+	for i := range adjusted {
+		for j := range adjusted[i].Weeks {
+			for k := range adjusted[i].Weeks[j].Days {
+				exercises := adjusted[i].Weeks[j].Days[k].Exercises
+				for l := range exercises {
+					exercise := &exercises[l]
+					if substitution, ok := substitutions[strings.ToLower(exercise.Name)]; ok {
+						exercise.Name = substitution.Replacement
+						exercise.ExerciseID = substitution.ExerciseID
+					}
+					if assignment.WeightMultiplier != nil && exercise.WeightKg != nil {
+						// Round to the nearest 0.5kg so scaled loads stay loadable on a barbell.
+						weight := math.Round(*exercise.WeightKg**assignment.WeightMultiplier*2) / 2
+						exercise.WeightKg = &weight
+					}
+				}
+			}
+		}
+	}
+
+	if err := normalizeProgramPhases(adjusted); err != nil {
+		return nil, err
+	}
+	return adjusted, nil
+}
+
+// cloneProgramPhases deep-copies the slices of a structure and clears row IDs, which belong to the
+// program the structure came from.
+func cloneProgramPhases(phases []models.ProgramPhase) []models.ProgramPhase {
+	cloned := make([]models.ProgramPhase, len(phases))
+	for i, phase := range phases {
+		phase.ID = 0
+		weeks := make([]models.ProgramWeek, len(phase.Weeks))
+		for j, week := range phase.Weeks {
+			week.ID = 0
+			days := make([]models.ProgramDay, len(week.Days))
+			for k, day := range week.Days {
+				day.ID = 0
+				exercises := make([]models.ProgramExercise, len(day.Exercises))
+				for l, exercise := range day.Exercises {
+					exercise.ID = 0
+					exercises[l] = exercise
+				}
+				day.Exercises = exercises
+				days[k] = day
+			}
+			week.Days = days
+			weeks[j] = week
+		}
+		phase.Weeks = weeks
+		cloned[i] = phase
+	}
+	return cloned
+}
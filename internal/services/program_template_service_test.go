@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+func templateTestPhases() []models.ProgramPhase {
+	weight := 60.0
+	libraryID := int64(3)
+	return []models.ProgramPhase{
+		{
+			ID:   10,
+			Name: "Foundation",
+			Weeks: []models.ProgramWeek{
+				{
+					ID:         11,
+					WeekNumber: 1,
+					Days: []models.ProgramDay{
+						{
+							ID:        12,
+							DayNumber: 1,
+							Exercises: []models.ProgramExercise{
+								{ID: 13, Name: "Back Squat", ExerciseID: &libraryID, WeightKg: &weight},
+								{ID: 14, Name: "Plank"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestAdjustTemplatePhases(t *testing.T) {
+	multiplier := func(v float64) *float64 { return &v }
+	gobletID := int64(8)
+
+	tests := []struct {
+		name       string
+		assignment ProgramAssignment
+		wantErr    error
+		wantName   string
+		wantID     *int64
+		wantWeight float64
+	}{
+		{
+			name:       "unchanged",
+			assignment: ProgramAssignment{UserID: 1},
+			wantName:   "Back Squat",
+			wantWeight: 60,
+		},
+		{
+			name:       "scaled to nearest half kilo",
+			assignment: ProgramAssignment{UserID: 1, WeightMultiplier: multiplier(0.79)},
+			wantName:   "Back Squat",
+			wantWeight: 47.5,
+		},
+		{
+			name: "substitution ignores case",
+			assignment: ProgramAssignment{
+				UserID:        1,
+				Substitutions: []ExerciseSubstitution{{Name: " back squat ", Replacement: "Goblet Squat", ExerciseID: &gobletID}},
+			},
+			wantName:   "Goblet Squat",
+			wantID:     &gobletID,
+			wantWeight: 60,
+		},
+		{
+			name:       "multiplier out of range",
+			assignment: ProgramAssignment{UserID: 1, WeightMultiplier: multiplier(5)},
+			wantErr:    ErrInvalidInput,
+		},
+		{
+			name: "duplicate substitution",
+			assignment: ProgramAssignment{
+				UserID: 1,
+				Substitutions: []ExerciseSubstitution{
+					{Name: "Back Squat", Replacement: "Goblet Squat"},
+					{Name: "BACK SQUAT", Replacement: "Leg Press"},
+				},
+			},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name: "blank replacement",
+			assignment: ProgramAssignment{
+				UserID:        1,
+				Substitutions: []ExerciseSubstitution{{Name: "Plank", Replacement: "  "}},
+			},
+			wantErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := templateTestPhases()
+			phases, err := adjustTemplatePhases(source, tt.assignment)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			exercise := phases[0].Weeks[0].Days[0].Exercises[0]
+			if exercise.Name != tt.wantName || exercise.WeightKg == nil || *exercise.WeightKg != tt.wantWeight {
+				t.Fatalf("unexpected exercise: %+v", exercise)
+			}
+			if tt.wantID != nil && (exercise.ExerciseID == nil || *exercise.ExerciseID != *tt.wantID) {
+				t.Fatalf("expected exercise id %d, got %v", *tt.wantID, exercise.ExerciseID)
+			}
+			original := source[0].Weeks[0].Days[0].Exercises[0]
+			if original.Name != "Back Squat" || *original.WeightKg != 60 {
+				t.Fatalf("template structure was modified: %+v", original)
+			}
+		})
+	}
+}
+
+func TestCloneProgramPhasesClearsIDs(t *testing.T) {
+	source := templateTestPhases()
+	cloned := cloneProgramPhases(source)
+
+	day := cloned[0].Weeks[0].Days[0]
+	if cloned[0].ID != 0 || cloned[0].Weeks[0].ID != 0 || day.ID != 0 || day.Exercises[0].ID != 0 {
+		t.Fatalf("expected row ids to be cleared: %+v", cloned)
+	}
+
+	day.Exercises[1].Name = "Side Plank"
+	if source[0].Weeks[0].Days[0].Exercises[1].Name != "Plank" {
+		t.Fatal("clone shares exercises with its source")
+	}
+	if source[0].ID != 10 {
+		t.Fatal("clone cleared ids on its source")
+	}
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const maxProgramChangeNoteLength = 500
+
+type ProgramVersionService struct {
+	programRepo    *repository.WorkoutProgramRepository
+	versionRepo    *repository.ProgramVersionRepository
+	coachingRepo   *repository.CoachingRepository
+	storageService StorageService
+}
+
+func NewProgramVersionService(
+	programRepo *repository.WorkoutProgramRepository,
+	versionRepo *repository.ProgramVersionRepository,
+	coachingRepo *repository.CoachingRepository,
+	storageService StorageService,
+) *ProgramVersionService {
+	return &ProgramVersionService{
+		programRepo:    programRepo,
+		versionRepo:    versionRepo,
+		coachingRepo:   coachingRepo,
+		storageService: storageService,
+	}
+}
+
+func (s *ProgramVersionService) ListVersions(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	programID int64,
+) ([]models.ProgramVersion, error) {
+	if err := s.checkAccess(ctx, actorID, role, programID); err != nil {
+		return nil, err
+	}
+	return s.versionRepo.ListByProgramID(ctx, programID)
+}
+
+func (s *ProgramVersionService) GetVersion(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	programID int64,
+	version int,
+) (*models.ProgramVersion, error) {
+	if version <= 0 {
+		return nil, ErrInvalidInput
+	}
+	if err := s.checkAccess(ctx, actorID, role, programID); err != nil {
+		return nil, err
+	}
+	return s.versionRepo.GetByNumber(ctx, programID, version)
+}
+
+// GetVersionOn returns the version the client was following on the given calendar day, i.e. the
+// latest version published before the day ended.
+func (s *ProgramVersionService) GetVersionOn(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	programID int64,
+	day time.Time,
+) (*models.ProgramVersion, error) {
+	if err := s.checkAccess(ctx, actorID, role, programID); err != nil {
+		return nil, err
+	}
+	return s.versionRepo.GetEffectiveBefore(ctx, programID, startOfUTCDay(day).AddDate(0, 0, 1))
+}
+
+func (s *ProgramVersionService) DiffVersions(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	programID int64,
+	fromVersion int,
+	toVersion int,
+) (*models.ProgramVersionDiff, error) {
+	if fromVersion <= 0 || toVersion <= 0 || fromVersion == toVersion {
+		return nil, ErrInvalidInput
+	}
+	if err := s.checkAccess(ctx, actorID, role, programID); err != nil {
+		return nil, err
+	}
+
+	from, err := s.versionRepo.GetByNumber(ctx, programID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.versionRepo.GetByNumber(ctx, programID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+	return diffProgramVersions(from, to), nil
+}
+
+func (s *ProgramVersionService) GetVersionDownloadURL(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	programID int64,
+	version int,
+) (string, error) {
+	if s.storageService == nil {
+		return "", ErrStorageUnavailable
+	}
+
+	snapshot, err := s.GetVersion(ctx, actorID, role, programID, version)
+	if err != nil {
+		return "", err
+	}
+	if snapshot.FileURL == "" {
+		return "", pgx.ErrNoRows
+	}
+	return s.storageService.GetSignedURL(ctx, snapshot.FileURL)
+}
+
+func (s *ProgramVersionService) checkAccess(ctx context.Context, actorID int64, role string, programID int64) error {
+	program, err := s.programRepo.GetByID(ctx, programID)
+	if err != nil {
+		return err
+	}
+	return checkProgramAccess(ctx, s.coachingRepo, role, actorID, program)
+}
+
+// publishProgramVersion records program, with its structure loaded, as a new version unless it
+// matches the latest one. It reports whether the version is a revision the client should be told
+// about once the transaction commits. Callers must run it in the transaction that changed the
+// program.
+func publishProgramVersion(
+	ctx context.Context,
+	db repository.DBTX,
+	program *models.WorkoutProgram,
+	changeNote *string,
+) (*models.ProgramVersion, bool, error) {
+	versionRepo := repository.NewProgramVersionRepository(db)
+	phases := cloneProgramPhases(program.Phases)
+
+	latest, err := versionRepo.GetLatest(ctx, program.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, err
+	}
+	if latest != nil {
+		same, err := sameProgramContent(latest, program.Title, program.Description, program.FileURL, phases)
+		if err != nil {
+			return nil, false, err
+		}
+		if same {
+			return latest, false, nil
+		}
+	}
+
+	version, err := versionRepo.Create(ctx, program.ID, repository.ProgramVersionInput{
+		Title:       program.Title,
+		Description: program.Description,
+		FileURL:     program.FileURL,
+		Phases:      phases,
+		ChangeNote:  changeNote,
+		CreatedBy:   program.CoachID,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	program.Version = version.Version
+	return version, version.Version > 1, nil
+}
+
+func normalizeChangeNote(note *string) (*string, error) {
+	note = blankToNil(note)
+	if note != nil && len(*note) > maxProgramChangeNoteLength {
+		return nil, ErrInvalidInput
+	}
+	return note, nil
+}
+
+func programVersionMessage(title string, version int, changeNote *string) string {
+	message := fmt.Sprintf("Your program %q was updated to version %d.", title, version)
+	if changeNote != nil {
+		message += " What changed: " + *changeNote
+	}
+	return message
+}
+
+func sameProgramContent(
+	latest *models.ProgramVersion,
+	title string,
+	description *string,
+	fileURL string,
+	phases []models.ProgramPhase,
+) (bool, error) {
+	if latest.Title != title || latest.FileURL != fileURL || !equalOptionalString(latest.Description, description) {
+		return false, nil
+	}
+	// Compare the encoded form so nil and empty slices, as produced by JSONB round trips, match.
+	before, err := json.Marshal(latest.Phases)
+	if err != nil {
+		return false, err
+	}
+	after, err := json.Marshal(phases)
+	if err != nil {
+		return false, err
+	}
+	return string(before) == string(after), nil
+}
+
+func equalOptionalString(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+type programExerciseKey struct {
+	phase      string
+	weekNumber int
+	dayNumber  int
+	name       string
+	occurrence int
+}
+
+type programExerciseSlot struct {
+	key      programExerciseKey
+	exercise models.ProgramExercise
+}
+
+// diffProgramVersions compares two snapshots field by field and exercise by exercise. Exercises
+// are matched on phase name, week, day and name; repeats of a name on a day pair up in order.
+func diffProgramVersions(from *models.ProgramVersion, to *models.ProgramVersion) *models.ProgramVersionDiff {
+	diff := &models.ProgramVersionDiff{
+		ProgramID:         to.ProgramID,
+		FromVersion:       from.Version,
+		ToVersion:         to.Version,
+		Fields:            []models.ProgramFieldChange{},
+		AttachmentChanged: from.FileURL != to.FileURL,
+		Exercises:         []models.ProgramExerciseChange{},
+	}
+
+	if from.Title != to.Title {
+		before, after := from.Title, to.Title
+		diff.Fields = append(diff.Fields, models.ProgramFieldChange{Field: "title", Before: &before, After: &after})
+	}
+	if !equalOptionalString(from.Description, to.Description) {
+		diff.Fields = append(diff.Fields, models.ProgramFieldChange{
+			Field:  "description",
+			Before: from.Description,
+			After:  to.Description,
+		})
+	}
+
+	before := flattenProgramExercises(from.Phases)
+	after := flattenProgramExercises(to.Phases)
+	previous := make(map[programExerciseKey]models.ProgramExercise, len(before))
+	for _, slot := range before {
+		previous[slot.key] = slot.exercise
+	}
+	current := make(map[programExerciseKey]bool, len(after))
+
+	for _, slot := range after {
+		current[slot.key] = true
+		exercise := slot.exercise
+		old, existed := previous[slot.key]
+		switch {
+		case !existed:
+			diff.Exercises = append(diff.Exercises, newProgramExerciseChange("added", slot.key, nil, &exercise))
+		case !reflect.DeepEqual(old, exercise):
+			diff.Exercises = append(diff.Exercises, newProgramExerciseChange("modified", slot.key, &old, &exercise))
+		}
+	}
+	for _, slot := range before {
+		if current[slot.key] {
+			continue
+		}
+		exercise := slot.exercise
+		diff.Exercises = append(diff.Exercises, newProgramExerciseChange("removed", slot.key, &exercise, nil))
+	}
+	return diff
+}
+
+func flattenProgramExercises(phases []models.ProgramPhase) []programExerciseSlot {
+	slots := make([]programExerciseSlot, 0)
+	for _, phase := range phases {
+		for _, week := range phase.Weeks {
+			for _, day := range week.Days {
+				seen := make(map[string]int)
+				for _, exercise := range day.Exercises {
+					name := strings.ToLower(exercise.Name)
+					seen[name]++
+					exercise.ID = 0
+					slots = append(slots, programExerciseSlot{
+						key: programExerciseKey{
+							phase:      phase.Name,
+							weekNumber: week.WeekNumber,
+							dayNumber:  day.DayNumber,
+							name:       name,
+							occurrence: seen[name],
+						},
+						exercise: exercise,
+					})
+				}
+			}
+		}
+	}
+	return slots
+}
+
+func newProgramExerciseChange(
+	change string,
+	key programExerciseKey,
+	before *models.ProgramExercise,
+	after *models.ProgramExercise,
+) models.ProgramExerciseChange {
+	name := ""
+	if after != nil {
+		name = after.Name
+	} else if before != nil {
+		name = before.Name
+	}
+	return models.ProgramExerciseChange{
+		Change:     change,
+		Phase:      key.phase,
+		WeekNumber: key.weekNumber,
+		DayNumber:  key.dayNumber,
+		Name:       name,
+		Before:     before,
+		After:      after,
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+func versionTestPhases(squatWeight float64, accessory string) []models.ProgramPhase {
+	sets := 3
+	return []models.ProgramPhase{
+		{
+			Name: "Base",
+			Weeks: []models.ProgramWeek{
+				{
+					WeekNumber: 1,
+					Days: []models.ProgramDay{
+						{
+							DayNumber: 1,
+							Exercises: []models.ProgramExercise{
+								{Name: "Back Squat", Sets: &sets, WeightKg: &squatWeight},
+								{Name: accessory},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDiffProgramVersions(t *testing.T) {
+	description := "Eight week block"
+	from := &models.ProgramVersion{
+		ProgramID: 4,
+		Version:   1,
+		Title:     "Strength",
+		FileURL:   "programs/a.pdf",
+		Phases:    versionTestPhases(60, "Plank"),
+	}
+	to := &models.ProgramVersion{
+		ProgramID:   4,
+		Version:     2,
+		Title:       "Strength",
+		Description: &description,
+		FileURL:     "programs/b.pdf",
+		Phases:      versionTestPhases(65, "Side Plank"),
+	}
+
+	diff := diffProgramVersions(from, to)
+
+	if diff.FromVersion != 1 || diff.ToVersion != 2 || !diff.AttachmentChanged {
+		t.Fatalf("unexpected diff header: %+v", diff)
+	}
+	if len(diff.Fields) != 1 || diff.Fields[0].Field != "description" || diff.Fields[0].Before != nil {
+		t.Fatalf("unexpected field changes: %+v", diff.Fields)
+	}
+
+	changes := make(map[string]models.ProgramExerciseChange, len(diff.Exercises))
+	for _, change := range diff.Exercises {
+		changes[change.Change+":"+change.Name] = change
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 exercise changes, got %+v", diff.Exercises)
+	}
+	modified, ok := changes["modified:Back Squat"]
+	if !ok || *modified.Before.WeightKg != 60 || *modified.After.WeightKg != 65 {
+		t.Fatalf("expected squat load change, got %+v", diff.Exercises)
+	}
+	if modified.Phase != "Base" || modified.WeekNumber != 1 || modified.DayNumber != 1 {
+		t.Fatalf("unexpected exercise location: %+v", modified)
+	}
+	if _, ok := changes["removed:Plank"]; !ok {
+		t.Fatalf("expected plank removal, got %+v", diff.Exercises)
+	}
+	if _, ok := changes["added:Side Plank"]; !ok {
+		t.Fatalf("expected side plank addition, got %+v", diff.Exercises)
+	}
+}
+
+func TestDiffProgramVersionsIgnoresRowIDs(t *testing.T) {
+	from := &models.ProgramVersion{Version: 1, Title: "Strength", Phases: versionTestPhases(60, "Plank")}
+	to := &models.ProgramVersion{Version: 2, Title: "Strength", Phases: versionTestPhases(60, "Plank")}
+	to.Phases[0].Weeks[0].Days[0].Exercises[0].ID = 99
+
+	diff := diffProgramVersions(from, to)
+	if len(diff.Fields) != 0 || diff.AttachmentChanged || len(diff.Exercises) != 0 {
+		t.Fatalf("expected no changes, got %+v", diff)
+	}
+}
+
+func TestSameProgramContent(t *testing.T) {
+	latest := &models.ProgramVersion{Title: "Strength", Phases: versionTestPhases(60, "Plank")}
+
+	same, err := sameProgramContent(latest, "Strength", nil, "", cloneProgramPhases(versionTestPhases(60, "Plank")))
+	if err != nil || !same {
+		t.Fatalf("expected identical content, got %v (%v)", same, err)
+	}
+
+	same, err = sameProgramContent(latest, "Strength", nil, "programs/a.pdf", versionTestPhases(60, "Plank"))
+	if err != nil || same {
+		t.Fatalf("expected new attachment to count as a change, got %v (%v)", same, err)
+	}
+
+	same, err = sameProgramContent(latest, "Strength", nil, "", versionTestPhases(62.5, "Plank"))
+	if err != nil || same {
+		t.Fatalf("expected structure change to count, got %v (%v)", same, err)
+	}
+}
+
+func TestNormalizeChangeNote(t *testing.T) {
+	blank := "   "
+	note, err := normalizeChangeNote(&blank)
+	if err != nil || note != nil {
+		t.Fatalf("expected blank note to be dropped, got %v (%v)", note, err)
+	}
+
+	long := strings.Repeat("x", maxProgramChangeNoteLength+1)
+	if _, err := normalizeChangeNote(&long); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected invalid input, got %v", err)
+	}
+
+	text := " Swapped squats for lunges "
+	note, err = normalizeChangeNote(&text)
+	if err != nil || note == nil || *note != "Swapped squats for lunges" {
+		t.Fatalf("unexpected note: %v (%v)", note, err)
+	}
+	message := programVersionMessage("Strength", 3, note)
+	if !strings.Contains(message, "version 3") || !strings.HasSuffix(message, "Swapped squats for lunges") {
+		t.Fatalf("unexpected message: %q", message)
+	}
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	maxScheduledContentLength = 4000
+	maxAutoReplyLength        = 1000
+	// maxScheduleAhead bounds how far ahead a message can be scheduled.
+	maxScheduleAhead = 90 * 24 * time.Hour
+
+	defaultWorkStart = "09:00"
+	defaultWorkEnd   = "17:00"
+
+	scheduledMessageBatchSize    = 50
+	scheduledMessageLease        = 2 * time.Minute
+	scheduledMessagePollInterval = 15 * time.Second
+)
+
+// ErrNotPending means the scheduled message was already sent, failed, or was cancelled, or is
+// being sent right now.
+var ErrNotPending = errors.New("scheduled message is no longer pending")
+
+// ScheduledMessageService lets coaches queue messages for later and set an auto-reply for when
+// they are away. A background worker posts both through ChatService.SendMessage, so they are
+// ordinary messages from the coach by the time clients see them.
+type ScheduledMessageService struct {
+	scheduledRepo    *repository.ScheduledMessageRepository
+	conversationRepo *repository.ConversationRepository
+	sender           chatMessageSender
+	deliverer        ChatDeliverer
+	moderator        *ModerationPipeline
+}
+
+func NewScheduledMessageService(
+	scheduledRepo *repository.ScheduledMessageRepository,
+	conversationRepo *repository.ConversationRepository,
+	sender chatMessageSender,
+	deliverer ChatDeliverer,
+	moderator *ModerationPipeline,
+) *ScheduledMessageService {
+	return &ScheduledMessageService{
+		scheduledRepo:    scheduledRepo,
+		conversationRepo: conversationRepo,
+		sender:           sender,
+		deliverer:        deliverer,
+		moderator:        moderator,
+	}
+}
+
+// ScheduleMessage queues a message from the coach to be posted in the conversation at sendAt.
+func (s *ScheduledMessageService) ScheduleMessage(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	conversationID int64,
+	content string,
+	sendAt time.Time,
+) (*models.ScheduledMessage, error) {
+	if role != "coach" {
+		return nil, ErrForbidden
+	}
+	content = strings.TrimSpace(content)
+	if conversationID <= 0 || content == "" || utf8.RuneCountInString(content) > maxScheduledContentLength {
+		return nil, ErrInvalidInput
+	}
+	now := time.Now()
+	if !sendAt.After(now) || sendAt.Sub(now) > maxScheduleAhead {
+		return nil, ErrInvalidInput
+	}
+	if err := s.refuseBlocked(content); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.conversationRepo.GetByIDForParticipant(ctx, conversationID, actorID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrForbidden
+		}
+		return nil, err
+	}
+	return s.scheduledRepo.Create(ctx, conversationID, actorID, content, sendAt)
+}
+
+// refuseBlocked refuses content the moderation pipeline would block. Messages are moderated again
+// when they are sent, but a coach learns about it now rather than from a failed delivery.
+func (s *ScheduledMessageService) refuseBlocked(content string) error {
+	if verdict := s.moderator.Moderate(content); verdict != nil && verdict.Action == ModerationActionBlock {
+		return ErrMessageBlocked
+	}
+	return nil
+}
+
+// ListScheduledMessages returns a page of the coach's scheduled messages, soonest first, optionally
+// only those with the given status.
+func (s *ScheduledMessageService) ListScheduledMessages(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	status string,
+	limit int,
+	offset int,
+) ([]models.ScheduledMessage, int, error) {
+	if role != "coach" {
+		return nil, 0, ErrForbidden
+	}
+	switch status {
+	case "", "pending", "sent", "failed", "cancelled":
+	default:
+		return nil, 0, ErrInvalidInput
+	}
+	return s.scheduledRepo.ListForSender(ctx, actorID, status, limit, offset)
+}
+
+func (s *ScheduledMessageService) CancelScheduledMessage(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	scheduledID int64,
+) (*models.ScheduledMessage, error) {
+	if role != "coach" {
+		return nil, ErrForbidden
+	}
+	if scheduledID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	message, err := s.scheduledRepo.Cancel(ctx, scheduledID, actorID)
+	if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+		return message, err
+	}
+	if _, err := s.scheduledRepo.GetForSender(ctx, scheduledID, actorID); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotPending
+}
+
+// GetAutoReply returns the coach's auto-reply settings, or disabled defaults if they never set one.
+func (s *ScheduledMessageService) GetAutoReply(ctx context.Context, actorID int64, role string) (*models.CoachAutoReply, error) {
+	if role != "coach" {
+		return nil, ErrForbidden
+	}
+	autoReply, err := s.scheduledRepo.GetAutoReply(ctx, actorID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &models.CoachAutoReply{
+			CoachID:     actorID,
+			Timezone:    "UTC",
+			WorkingDays: []int{},
+			WorkStart:   defaultWorkStart,
+			WorkEnd:     defaultWorkEnd,
+		}, nil
+	}
+	return autoReply, err
+}
+
+// UpdateAutoReply replaces the coach's auto-reply settings.
+func (s *ScheduledMessageService) UpdateAutoReply(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	input repository.CoachAutoReplyInput,
+) (*models.CoachAutoReply, error) {
+	if role != "coach" {
+		return nil, ErrForbidden
+	}
+	input.CoachID = actorID
+	if err := normalizeAutoReplyInput(&input); err != nil {
+		return nil, err
+	}
+	if err := s.refuseBlocked(input.Message); err != nil {
+		return nil, err
+	}
+	return s.scheduledRepo.UpsertAutoReply(ctx, input)
+}
+
+// normalizeAutoReplyInput fills in defaults and checks the settings. An enabled auto-reply needs
+// working days or an away period, or it would never fire.
+func normalizeAutoReplyInput(input *repository.CoachAutoReplyInput) error {
+	input.Message = strings.TrimSpace(input.Message)
+	if input.Message == "" || utf8.RuneCountInString(input.Message) > maxAutoReplyLength {
+		return ErrInvalidInput
+	}
+
+	input.Timezone = strings.TrimSpace(input.Timezone)
+	if input.Timezone == "" {
+		input.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(input.Timezone); err != nil {
+		return ErrInvalidInput
+	}
+
+	for _, day := range input.WorkingDays {
+		if day < int(time.Sunday) || day > int(time.Saturday) {
+			return ErrInvalidInput
+		}
+	}
+	input.WorkingDays = slices.Compact(slices.Sorted(slices.Values(input.WorkingDays)))
+
+	if input.WorkStart == "" {
+		input.WorkStart = defaultWorkStart
+	}
+	if input.WorkEnd == "" {
+		input.WorkEnd = defaultWorkEnd
+	}
+	startMinute, startOK := parseClockMinutes(input.WorkStart)
+	endMinute, endOK := parseClockMinutes(input.WorkEnd)
+	if !startOK || !endOK || startMinute >= endMinute {
+		return ErrInvalidInput
+	}
+
+	if (input.AwayFrom == nil) != (input.AwayUntil == nil) {
+		return ErrInvalidInput
+	}
+	if input.AwayFrom != nil && !input.AwayUntil.After(*input.AwayFrom) {
+		return ErrInvalidInput
+	}
+	if input.Enabled && len(input.WorkingDays) == 0 && input.AwayFrom == nil {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// Run sends scheduled messages and auto-replies as they come due, until ctx is done. Messages
+// claimed by an instance that stopped are sent by another once their lease expires.
+func (s *ScheduledMessageService) Run(ctx context.Context) {
+	ticker := time.NewTicker(scheduledMessagePollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			sent, err := s.deliverDue(ctx)
+			if err != nil {
+				log.Printf("scheduled message delivery: %v", err)
+			}
+			if sent < scheduledMessageBatchSize || err != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue claims a batch of due messages and sends them. It returns how many it claimed.
+func (s *ScheduledMessageService) deliverDue(ctx context.Context) (int, error) {
+	messages, err := s.scheduledRepo.ClaimDue(ctx, scheduledMessageBatchSize, scheduledMessageLease)
+	if err != nil {
+		return 0, err
+	}
+	for i := range messages {
+		if err := s.deliver(ctx, &messages[i]); err != nil {
+			return len(messages), err
+		}
+	}
+	return len(messages), nil
+}
+
+// deliver sends one scheduled message and records the outcome. Messages the coach may no longer
+// send are marked failed; other send errors are returned and leave the message pending, so it is
+// retried once its lease expires.
+func (s *ScheduledMessageService) deliver(ctx context.Context, scheduled *models.ScheduledMessage) error {
+	delivery, err := s.sender.SendMessage(ctx, scheduled.SenderID, "coach", scheduled.ConversationID, scheduled.Content)
+	if err != nil {
+		var failure string
+		switch {
+		case errors.Is(err, ErrForbidden):
+			failure = "sender is no longer in the conversation"
+		case errors.Is(err, ErrUserBlocked), errors.Is(err, ErrMessageBlocked),
+			errors.Is(err, ErrSubscriptionRequired), errors.Is(err, ErrInvalidInput):
+			failure = err.Error()
+		default:
+			return fmt.Errorf("send scheduled message %d: %w", scheduled.ID, err)
+		}
+		return s.scheduledRepo.RecordDelivery(ctx, scheduled.ID, nil, &failure)
+	}
+	if err := s.scheduledRepo.RecordDelivery(ctx, scheduled.ID, &delivery.Message.ID, nil); err != nil {
+		return err
+	}
+	deliverStored(s.deliverer, delivery, fmt.Sprintf("scheduled message %d", scheduled.ID))
+	return nil
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+func TestAutoReplyWindow(t *testing.T) {
+	weekdays := &models.CoachAutoReply{
+		Enabled:     true,
+		Timezone:    "Europe/Berlin",
+		WorkingDays: []int{1, 2, 3, 4, 5},
+		WorkStart:   "09:00",
+		WorkEnd:     "17:00",
+	}
+	wednesdayClose := time.Date(2026, 3, 4, 16, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name       string
+		now        time.Time
+		wantAway   bool
+		wantWindow time.Time
+	}{
+		{name: "working hours", now: time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)},
+		{name: "evening", now: time.Date(2026, 3, 4, 19, 0, 0, 0, time.UTC), wantAway: true, wantWindow: wednesdayClose},
+		{name: "next morning", now: time.Date(2026, 3, 5, 6, 0, 0, 0, time.UTC), wantAway: true, wantWindow: wednesdayClose},
+		{name: "weekend", now: time.Date(2026, 3, 7, 11, 0, 0, 0, time.UTC), wantAway: true, wantWindow: time.Date(2026, 3, 6, 16, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		window, away := autoReplyWindow(weekdays, tc.now)
+		if away != tc.wantAway || !window.Equal(tc.wantWindow) {
+			t.Fatalf("%s: got %v %v, want %v %v", tc.name, window, away, tc.wantWindow, tc.wantAway)
+		}
+	}
+
+	awayFrom := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	awayUntil := awayFrom.Add(7 * 24 * time.Hour)
+	vacation := *weekdays
+	vacation.AwayFrom, vacation.AwayUntil = &awayFrom, &awayUntil
+	if window, away := autoReplyWindow(&vacation, time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)); !away || !window.Equal(awayFrom) {
+		t.Fatalf("away period: got %v %v", window, away)
+	}
+
+	disabled := *weekdays
+	disabled.Enabled = false
+	if _, away := autoReplyWindow(&disabled, time.Date(2026, 3, 4, 19, 0, 0, 0, time.UTC)); away {
+		t.Fatal("a disabled auto-reply should never fire")
+	}
+}
+
+func TestNormalizeAutoReplyInput(t *testing.T) {
+	input := repository.CoachAutoReplyInput{
+		Enabled:     true,
+		Message:     "  Back on Monday!  ",
+		WorkingDays: []int{5, 1, 3, 1},
+	}
+	if err := normalizeAutoReplyInput(&input); err != nil {
+		t.Fatalf("normalizeAutoReplyInput: %v", err)
+	}
+	if input.Message != "Back on Monday!" || input.Timezone != "UTC" || input.WorkStart != "09:00" || input.WorkEnd != "17:00" {
+		t.Fatalf("unexpected defaults %+v", input)
+	}
+	if len(input.WorkingDays) != 3 || input.WorkingDays[0] != 1 || input.WorkingDays[2] != 5 {
+		t.Fatalf("expected sorted unique days, got %v", input.WorkingDays)
+	}
+
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		input repository.CoachAutoReplyInput
+	}{
+		{name: "no message", input: repository.CoachAutoReplyInput{WorkingDays: []int{1}}},
+		{name: "unknown timezone", input: repository.CoachAutoReplyInput{Message: "Away", Timezone: "Mars/Olympus"}},
+		{name: "bad weekday", input: repository.CoachAutoReplyInput{Message: "Away", WorkingDays: []int{7}}},
+		{name: "hours reversed", input: repository.CoachAutoReplyInput{Message: "Away", WorkStart: "18:00", WorkEnd: "08:00"}},
+		{name: "hour format", input: repository.CoachAutoReplyInput{Message: "Away", WorkStart: "9am"}},
+		{name: "open-ended away", input: repository.CoachAutoReplyInput{Message: "Away", AwayFrom: &from}},
+		{name: "never fires", input: repository.CoachAutoReplyInput{Enabled: true, Message: "Away"}},
+	}
+	for _, tc := range cases {
+		if err := normalizeAutoReplyInput(&tc.input); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("%s: got %v", tc.name, err)
+		}
+	}
+}
+
+func TestScheduleMessageValidatesBeforeLookups(t *testing.T) {
+	service := &ScheduledMessageService{}
+	later := time.Now().Add(time.Hour)
+	cases := []struct {
+		name    string
+		role    string
+		content string
+		sendAt  time.Time
+		want    error
+	}{
+		{name: "client", role: "user", content: "Hi", sendAt: later, want: ErrForbidden},
+		{name: "blank content", role: "coach", content: "  ", sendAt: later, want: ErrInvalidInput},
+		{name: "long content", role: "coach", content: strings.Repeat("a", maxScheduledContentLength+1), sendAt: later, want: ErrInvalidInput},
+		{name: "in the past", role: "coach", content: "Hi", sendAt: time.Now().Add(-time.Minute), want: ErrInvalidInput},
+		{name: "too far ahead", role: "coach", content: "Hi", sendAt: time.Now().Add(maxScheduleAhead + time.Hour), want: ErrInvalidInput},
+	}
+	for _, tc := range cases {
+		if _, err := service.ScheduleMessage(context.Background(), 7, tc.role, 3, tc.content, tc.sendAt); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+type stubChatSender struct {
+	err error
+}
+
+func (s stubChatSender) SendMessage(context.Context, int64, string, int64, string) (*ChatDelivery, error) {
+	return nil, s.err
+}
+
+func TestDeliverScheduledMessageRetriesTransientErrors(t *testing.T) {
+	cases := []struct {
+		name        string
+		sendErr     error
+		wantRecord  bool
+		wantFailure string
+	}{
+		{name: "left conversation", sendErr: ErrForbidden, wantRecord: true, wantFailure: "sender is no longer in the conversation"},
+		{name: "blocked", sendErr: ErrUserBlocked, wantRecord: true, wantFailure: ErrUserBlocked.Error()},
+		{name: "moderated", sendErr: ErrMessageBlocked, wantRecord: true, wantFailure: ErrMessageBlocked.Error()},
+		{name: "database unavailable", sendErr: errors.New("connection refused")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var recorded []any
+			db := &stubDBTX{execFn: func(_ context.Context, query string, args ...any) error {
+				if strings.Contains(query, "UPDATE chat_scheduled_messages") {
+					recorded = args
+				}
+				return nil
+			}}
+			service := &ScheduledMessageService{
+				scheduledRepo: repository.NewScheduledMessageRepository(db),
+				sender:        stubChatSender{err: tc.sendErr},
+			}
+
+			err := service.deliver(context.Background(), &models.ScheduledMessage{ID: 3, SenderID: 7, ConversationID: 11, Content: "hi"})
+			if !tc.wantRecord {
+				if !errors.Is(err, tc.sendErr) {
+					t.Fatalf("expected the send error to be returned, got %v", err)
+				}
+				if recorded != nil {
+					t.Fatalf("expected the message to stay pending, got %v", recorded)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("deliver: %v", err)
+			}
+			if recorded == nil {
+				t.Fatal("expected the failure to be recorded")
+			}
+			if failure, ok := recorded[2].(*string); !ok || failure == nil || *failure != tc.wantFailure {
+				t.Fatalf("expected failure %q, got %v", tc.wantFailure, recorded[2])
+			}
+		})
+	}
+}
+
+func TestQueueAutoReplySkipsAvailabilitySlots(t *testing.T) {
+	awayFrom := time.Now().Add(-time.Hour)
+	awayUntil := time.Now().Add(time.Hour)
+	conversation := &models.Conversation{ID: 11, CoachID: 7, UserID: 42}
+
+	for _, inSlot := range []bool{false, true} {
+		queued := false
+		db := &stubDBTX{
+			queryRowFn: func(_ context.Context, query string, _ ...any) stubRow {
+				switch {
+				case strings.Contains(query, "FROM coach_auto_replies"):
+					return stubRow{values: []any{
+						int64(7), true, "Back soon", "UTC", []int{}, "09:00", "17:00", &awayFrom, &awayUntil, testTime,
+					}}
+				case strings.Contains(query, "FROM coach_availability_slots"):
+					return stubRow{values: []any{inSlot}}
+				}
+				return stubRow{err: errors.New("unexpected query")}
+			},
+			execFn: func(_ context.Context, query string, _ ...any) error {
+				queued = queued || strings.Contains(query, "INSERT INTO chat_scheduled_messages")
+				return nil
+			},
+		}
+
+		err := queueAutoReply(
+			context.Background(),
+			repository.NewScheduledMessageRepository(db),
+			repository.NewCoachProfileRepository(db),
+			conversation,
+			42,
+		)
+		if err != nil {
+			t.Fatalf("queueAutoReply in slot %v: %v", inSlot, err)
+		}
+		if queued == inSlot {
+			t.Fatalf("in slot %v: expected queued %v", inSlot, !inSlot)
+		}
+	}
+}
@@ -35,6 +35,7 @@ type SessionService struct {
 	paymentRepo      *repository.PaymentRepository
 	userRepo         userReader
 	coachProfileRepo coachProfileReader
+	invoiceService   *InvoiceService
 }
 
 func NewSessionService(
@@ -43,6 +44,7 @@ func NewSessionService(
 	paymentRepo *repository.PaymentRepository,
 	userRepo userReader,
 	coachProfileRepo coachProfileReader,
+	invoiceService *InvoiceService,
 ) *SessionService {
 	return &SessionService{
 		db:               db,
@@ -50,6 +52,7 @@ func NewSessionService(
 		paymentRepo:      paymentRepo,
 		userRepo:         userRepo,
 		coachProfileRepo: coachProfileRepo,
+		invoiceService:   invoiceService,
 	}
 }
 
@@ -58,6 +61,7 @@ type BookSessionInput struct {
 	ScheduledAt     time.Time
 	DurationMinutes int
 	Notes           *string
+	CouponCode      *string
 }
 
 func (s *SessionService) BookSession(
@@ -113,11 +117,24 @@ func (s *SessionService) BookSession(
 
 	txSessionRepo := repository.NewSessionRepository(tx)
 	txPaymentRepo := repository.NewPaymentRepository(tx)
+	txSubscriptionRepo := repository.NewSubscriptionRepository(tx)
+	txCouponRepo := repository.NewCouponRepository(tx)
 
 	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", input.CoachID); err != nil {
 		return nil, err
 	}
 
+	subscription, err := resolveSessionEntitlement(
+		ctx,
+		txSubscriptionRepo,
+		userID,
+		input.CoachID,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	hasConflict, err := txSessionRepo.HasConflict(
 		ctx,
 		input.CoachID,
@@ -131,6 +148,28 @@ func (s *SessionService) BookSession(
 		return nil, ErrConflict
 	}
 
+	var coupon *models.Coupon
+	discount := 0.0
+	if input.CouponCode != nil {
+		// Subscription-covered sessions are free, so there is nothing to discount.
+		if subscription != nil {
+			return nil, ErrInvalidCoupon
+		}
+		coupon, discount, err = reserveCoupon(
+			ctx,
+			txCouponRepo,
+			*input.CouponCode,
+			userID,
+			input.CoachID,
+			0,
+			amount,
+			time.Now().UTC(),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	session, err := txSessionRepo.Create(ctx, repository.CreateSessionInput{
 		UserID:          userID,
 		CoachID:         input.CoachID,
@@ -142,16 +181,38 @@ func (s *SessionService) BookSession(
 		return nil, err
 	}
 
-	payment, err := txPaymentRepo.Create(ctx, repository.CreatePaymentInput{
-		SessionID: session.ID,
+	paymentInput := repository.CreatePaymentInput{
+		SessionID: &session.ID,
 		UserID:    userID,
 		CoachID:   input.CoachID,
 		Amount:    amount,
 		Status:    "placeholder",
-	})
+	}
+	if subscription != nil {
+		paymentInput.SubscriptionID = &subscription.ID
+		paymentInput.Amount = 0
+		paymentInput.Status = "paid"
+	}
+	if coupon != nil {
+		paymentInput.OriginalAmount = &amount
+		paymentInput.DiscountAmount = discount
+		paymentInput.CouponID = &coupon.ID
+		paymentInput.Amount = amount - discount
+	}
+
+	payment, err := txPaymentRepo.Create(ctx, paymentInput)
 	if err != nil {
 		return nil, err
 	}
+	if coupon != nil {
+		if err := txCouponRepo.CreateRedemption(ctx, coupon.ID, userID, payment.ID); err != nil {
+			return nil, err
+		}
+	}
+	// Booking a coach starts working with them. An ended relationship stays ended.
+	if _, err := repository.NewCoachingRepository(tx).Activate(ctx, input.CoachID, userID); err != nil {
+		return nil, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
@@ -275,13 +336,38 @@ func (s *SessionService) UpdateStatus(
 		}
 	}
 
-	updated, err := s.sessionRepo.UpdateStatusIfCurrent(ctx, sessionID, session.Status, nextStatus)
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txPaymentRepo := repository.NewPaymentRepository(tx)
+
+	updated, err := repository.NewSessionRepository(tx).UpdateStatusIfCurrent(ctx, sessionID, session.Status, nextStatus)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrInvalidStateTransition
 		}
 		return nil, err
 	}
+	if nextStatus == "cancelled" {
+		payment, err := txPaymentRepo.GetBySessionIDForUpdate(ctx, sessionID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		if payment != nil {
+			if err := releaseCouponForPayment(ctx, repository.NewCouponRepository(tx), txPaymentRepo, payment); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 	return s.GetSession(ctx, actorID, role, updated.ID)
 }
 
@@ -290,6 +376,7 @@ func (s *SessionService) PayForSession(
 	actorID int64,
 	role string,
 	sessionID int64,
+	couponCode *string,
 ) (*models.SessionDetail, error) {
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
@@ -336,7 +423,34 @@ func (s *SessionService) PayForSession(
 		return nil, ErrConflict
 	}
 
-	if _, err := txPaymentRepo.UpdateStatusIfCurrent(ctx, payment.ID, "placeholder", "paid"); err != nil {
+	if couponCode != nil {
+		if payment.CouponID != nil {
+			return nil, ErrInvalidCoupon
+		}
+		txCouponRepo := repository.NewCouponRepository(tx)
+		coupon, discount, err := reserveCoupon(
+			ctx,
+			txCouponRepo,
+			*couponCode,
+			session.UserID,
+			session.CoachID,
+			session.ID,
+			payment.Amount,
+			time.Now().UTC(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := txPaymentRepo.ApplyDiscount(ctx, payment.ID, coupon.ID, discount); err != nil {
+			return nil, err
+		}
+		if err := txCouponRepo.CreateRedemption(ctx, coupon.ID, session.UserID, payment.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	paidPayment, err := txPaymentRepo.UpdateStatusIfCurrent(ctx, payment.ID, "placeholder", "paid")
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrInvalidStateTransition
 		}
@@ -348,10 +462,15 @@ func (s *SessionService) PayForSession(
 		}
 		return nil, err
 	}
+	invoice, err := s.invoiceService.IssueForPayment(ctx, tx, paidPayment, invoiceKindInvoice)
+	if err != nil {
+		return nil, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
+	s.invoiceService.storePDFs(ctx, invoice)
 
 	return s.GetSession(ctx, actorID, role, sessionID)
 }
@@ -39,6 +39,10 @@ func TestSessionServiceBookAndPayFlow(t *testing.T) {
 	if err != nil {
 		t.Fatalf("BookSession: %v", err)
 	}
+	active, err := repository.NewCoachingRepository(pool).IsActiveCoach(ctx, coachID, userID)
+	if err != nil || !active {
+		t.Fatalf("expected booking to start a coaching relationship, got %v, %v", active, err)
+	}
 
 	if detail.Status != "pending" {
 		t.Fatalf("expected pending session, got %q", detail.Status)
@@ -50,7 +54,7 @@ func TestSessionServiceBookAndPayFlow(t *testing.T) {
 		t.Fatalf("expected amount 180, got %.2f", detail.Payment.Amount)
 	}
 
-	paidDetail, err := service.PayForSession(ctx, userID, "user", detail.ID)
+	paidDetail, err := service.PayForSession(ctx, userID, "user", detail.ID, nil)
 	if err != nil {
 		t.Fatalf("PayForSession: %v", err)
 	}
@@ -176,6 +180,7 @@ func newIntegrationSessionService(pool *pgxpool.Pool) *SessionService {
 		repository.NewPaymentRepository(pool),
 		repository.NewUserRepository(pool),
 		repository.NewCoachProfileRepository(pool),
+		NewInvoiceService(pool, repository.NewInvoiceRepository(pool), repository.NewPaymentRepository(pool), nil),
 	)
 }
 
@@ -231,6 +236,9 @@ func cleanupTestUsers(t *testing.T, ctx context.Context, pool *pgxpool.Pool, use
 		return
 	}
 
+	if _, err := pool.Exec(ctx, "DELETE FROM invoices WHERE user_id = ANY($1) OR coach_id = ANY($1)", userIDs); err != nil {
+		t.Fatalf("cleanup invoices: %v", err)
+	}
 	if _, err := pool.Exec(ctx, "DELETE FROM payments WHERE user_id = ANY($1) OR coach_id = ANY($1)", userIDs); err != nil {
 		t.Fatalf("cleanup payments: %v", err)
 	}
@@ -0,0 +1,800 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+var (
+	ErrSubscriptionRequired      = errors.New("active subscription required")
+	ErrSessionAllowanceExhausted = errors.New("subscription session allowance exhausted")
+)
+
+type SubscriptionPlanInput struct {
+	Name                   string
+	Description            *string
+	MonthlyPrice           float64
+	SessionsPerMonth       int
+	IncludesChat           bool
+	IncludesProgramUpdates bool
+	TrialDays              int
+}
+
+type SubscriptionService struct {
+	db               *pgxpool.Pool
+	subscriptionRepo *repository.SubscriptionRepository
+	gateway          PaymentGateway
+	invoiceService   *InvoiceService
+	now              func() time.Time
+}
+
+func NewSubscriptionService(
+	db *pgxpool.Pool,
+	subscriptionRepo *repository.SubscriptionRepository,
+	gateway PaymentGateway,
+	invoiceService *InvoiceService,
+) *SubscriptionService {
+	return &SubscriptionService{
+		db:               db,
+		subscriptionRepo: subscriptionRepo,
+		gateway:          gateway,
+		invoiceService:   invoiceService,
+		now:              func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (s *SubscriptionService) CreatePlan(
+	ctx context.Context,
+	coachID int64,
+	input SubscriptionPlanInput,
+) (*models.SubscriptionPlan, error) {
+	if coachID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" || input.MonthlyPrice < 0 || input.SessionsPerMonth < 0 || input.TrialDays < 0 {
+		return nil, ErrInvalidInput
+	}
+	description, err := normalizeOptionalText(input.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.subscriptionRepo.CreatePlan(ctx, repository.CreateSubscriptionPlanInput{
+		CoachID:                coachID,
+		Name:                   name,
+		Description:            description,
+		MonthlyPrice:           input.MonthlyPrice,
+		SessionsPerMonth:       input.SessionsPerMonth,
+		IncludesChat:           input.IncludesChat,
+		IncludesProgramUpdates: input.IncludesProgramUpdates,
+		TrialDays:              input.TrialDays,
+	})
+}
+
+func (s *SubscriptionService) UpdatePlan(
+	ctx context.Context,
+	coachID int64,
+	planID int64,
+	input repository.UpdateSubscriptionPlanInput,
+) (*models.SubscriptionPlan, error) {
+	if coachID <= 0 || planID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	if input.Name != nil {
+		trimmed := strings.TrimSpace(*input.Name)
+		if trimmed == "" {
+			return nil, ErrInvalidInput
+		}
+		input.Name = &trimmed
+	}
+	description, err := normalizeOptionalText(input.Description)
+	if err != nil {
+		return nil, err
+	}
+	input.Description = description
+	if (input.MonthlyPrice != nil && *input.MonthlyPrice < 0) ||
+		(input.SessionsPerMonth != nil && *input.SessionsPerMonth < 0) ||
+		(input.TrialDays != nil && *input.TrialDays < 0) {
+		return nil, ErrInvalidInput
+	}
+
+	plan, err := s.subscriptionRepo.GetPlanByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.CoachID != coachID {
+		return nil, ErrForbidden
+	}
+
+	return s.subscriptionRepo.UpdatePlan(ctx, planID, coachID, input)
+}
+
+func (s *SubscriptionService) ListPlans(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	coachID int64,
+) ([]models.SubscriptionPlan, error) {
+	switch role {
+	case "coach":
+		if coachID != 0 && coachID != actorID {
+			return s.subscriptionRepo.ListPlansByCoachID(ctx, coachID, true)
+		}
+		return s.subscriptionRepo.ListPlansByCoachID(ctx, actorID, false)
+	case "user":
+		if coachID <= 0 {
+			return nil, ErrInvalidInput
+		}
+		return s.subscriptionRepo.ListPlansByCoachID(ctx, coachID, true)
+	default:
+		return nil, ErrForbidden
+	}
+}
+
+func (s *SubscriptionService) GetSettings(
+	ctx context.Context,
+	coachID int64,
+) (*models.CoachSubscriptionSettings, error) {
+	return s.subscriptionRepo.GetSettings(ctx, coachID)
+}
+
+func (s *SubscriptionService) UpdateSettings(
+	ctx context.Context,
+	coachID int64,
+	requiresSubscription bool,
+) (*models.CoachSubscriptionSettings, error) {
+	if coachID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	return s.subscriptionRepo.UpsertSettings(ctx, coachID, requiresSubscription)
+}
+
+func (s *SubscriptionService) Subscribe(
+	ctx context.Context,
+	userID int64,
+	planID int64,
+) (*models.SubscriptionDetail, error) {
+	if userID <= 0 || planID <= 0 {
+		return nil, ErrInvalidInput
+	}
+
+	plan, err := s.subscriptionRepo.GetPlanByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.IsActive || plan.CoachID == userID {
+		return nil, ErrInvalidInput
+	}
+
+	_, err = s.subscriptionRepo.GetOpenForParticipants(ctx, userID, plan.CoachID)
+	if err == nil {
+		return nil, ErrConflict
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	gatewayID, err := s.gateway.CreateSubscription(ctx, GatewaySubscriptionInput{
+		UserID:       userID,
+		CoachID:      plan.CoachID,
+		PlanID:       plan.ID,
+		MonthlyPrice: plan.MonthlyPrice,
+		TrialDays:    plan.TrialDays,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.createSubscription(ctx, userID, plan, gatewayID)
+	if err != nil {
+		// Without a local row nothing would ever cancel the gateway subscription, and the client
+		// would keep being charged.
+		if cancelErr := s.gateway.CancelSubscription(context.WithoutCancel(ctx), gatewayID, false); cancelErr != nil {
+			err = errors.Join(err, fmt.Errorf("cancel gateway subscription %s: %w", gatewayID, cancelErr))
+		}
+		return nil, err
+	}
+
+	return s.buildDetail(ctx, subscription)
+}
+
+// createSubscription stores a new subscription on its first period. No payment is recorded here:
+// the gateway's subscription.activated event records the first charge once it has gone through.
+func (s *SubscriptionService) createSubscription(
+	ctx context.Context,
+	userID int64,
+	plan *models.SubscriptionPlan,
+	gatewayID string,
+) (*models.Subscription, error) {
+	now := s.now()
+	status := "active"
+	periodEnd := now.AddDate(0, 1, 0)
+	if plan.TrialDays > 0 {
+		status = "trialing"
+		periodEnd = now.AddDate(0, 0, plan.TrialDays)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	subscription, err := repository.NewSubscriptionRepository(tx).Create(ctx, repository.CreateSubscriptionInput{
+		UserID:                userID,
+		CoachID:               plan.CoachID,
+		PlanID:                plan.ID,
+		Status:                status,
+		GatewaySubscriptionID: &gatewayID,
+		CurrentPeriodStart:    now,
+		CurrentPeriodEnd:      periodEnd,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrConflict
+		}
+		return nil, err
+	}
+	if _, err := repository.NewCoachingRepository(tx).Activate(ctx, plan.CoachID, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *SubscriptionService) ListSubscriptions(
+	ctx context.Context,
+	actorID int64,
+	role string,
+) ([]models.SubscriptionDetail, error) {
+	if role != "user" && role != "coach" {
+		return nil, ErrForbidden
+	}
+
+	subscriptions, err := s.subscriptionRepo.ListForParticipant(ctx, actorID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]models.SubscriptionDetail, 0, len(subscriptions))
+	for i := range subscriptions {
+		detail, err := s.buildDetail(ctx, &subscriptions[i])
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, *detail)
+	}
+	return details, nil
+}
+
+func (s *SubscriptionService) GetSubscription(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	subscriptionID int64,
+) (*models.SubscriptionDetail, error) {
+	subscription, err := s.subscriptionRepo.GetByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccessSubscription(role, actorID, subscription) {
+		return nil, ErrForbidden
+	}
+	return s.buildDetail(ctx, subscription)
+}
+
+// ChangePlan moves the subscription to another plan of the same coach. The gateway is told after the
+// change is stored and charges any proration itself; the charge is recorded when its
+// subscription.proration_charged event arrives. If the gateway refuses, the change is undone.
+func (s *SubscriptionService) ChangePlan(
+	ctx context.Context,
+	userID int64,
+	subscriptionID int64,
+	planID int64,
+) (*models.SubscriptionDetail, *models.SubscriptionPlanChange, error) {
+	if subscriptionID <= 0 || planID <= 0 {
+		return nil, nil, ErrInvalidInput
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txSubscriptionRepo := repository.NewSubscriptionRepository(tx)
+
+	subscription, err := txSubscriptionRepo.GetByIDForUpdate(ctx, subscriptionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if subscription.UserID != userID {
+		return nil, nil, ErrForbidden
+	}
+	if (subscription.Status != "active" && subscription.Status != "trialing") || subscription.CancelAtPeriodEnd {
+		return nil, nil, ErrInvalidStateTransition
+	}
+	if subscription.PlanID == planID {
+		return nil, nil, ErrInvalidInput
+	}
+
+	currentPlan, err := txSubscriptionRepo.GetPlanByID(ctx, subscription.PlanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	nextPlan, err := txSubscriptionRepo.GetPlanByID(ctx, planID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if nextPlan.CoachID != subscription.CoachID || !nextPlan.IsActive {
+		return nil, nil, ErrInvalidInput
+	}
+
+	proration := 0.0
+	if subscription.Status == "active" {
+		proration = calculateProration(
+			currentPlan.MonthlyPrice,
+			nextPlan.MonthlyPrice,
+			subscription.CurrentPeriodStart,
+			subscription.CurrentPeriodEnd,
+			s.now(),
+		)
+	}
+
+	updated, err := txSubscriptionRepo.UpdatePlanID(ctx, subscription.ID, nextPlan.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	change, err := txSubscriptionRepo.CreatePlanChange(ctx, subscription.ID, currentPlan.ID, nextPlan.ID, proration)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	if subscription.GatewaySubscriptionID != nil {
+		if err := s.gateway.ChangeSubscriptionPlan(
+			ctx,
+			*subscription.GatewaySubscriptionID,
+			nextPlan.MonthlyPrice,
+			proration,
+		); err != nil {
+			if revertErr := s.revertPlanChange(context.WithoutCancel(ctx), change); revertErr != nil {
+				err = errors.Join(err, fmt.Errorf("revert plan change %d: %w", change.ID, revertErr))
+			}
+			return nil, nil, err
+		}
+	}
+
+	detail, err := s.buildDetail(ctx, updated)
+	if err != nil {
+		return nil, nil, err
+	}
+	return detail, change, nil
+}
+
+// revertPlanChange puts the subscription back on its previous plan unless it moved on since.
+func (s *SubscriptionService) revertPlanChange(ctx context.Context, change *models.SubscriptionPlanChange) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txSubscriptionRepo := repository.NewSubscriptionRepository(tx)
+	subscription, err := txSubscriptionRepo.GetByIDForUpdate(ctx, change.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if subscription.PlanID == change.ToPlanID {
+		if _, err := txSubscriptionRepo.UpdatePlanID(ctx, subscription.ID, change.FromPlanID); err != nil {
+			return err
+		}
+	}
+	if err := txSubscriptionRepo.DeletePlanChange(ctx, change.ID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Cancel cancels the subscription at period end, or right away when it is past due. The gateway is
+// told after the cancellation is stored; if it refuses, the cancellation is undone.
+func (s *SubscriptionService) Cancel(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	subscriptionID int64,
+) (*models.SubscriptionDetail, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txSubscriptionRepo := repository.NewSubscriptionRepository(tx)
+
+	subscription, err := txSubscriptionRepo.GetByIDForUpdate(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccessSubscription(role, actorID, subscription) {
+		return nil, ErrForbidden
+	}
+	if subscription.Status == "cancelled" || subscription.CancelAtPeriodEnd {
+		return nil, ErrInvalidStateTransition
+	}
+
+	atPeriodEnd := subscription.Status != "past_due"
+	var updated *models.Subscription
+	if atPeriodEnd {
+		updated, err = txSubscriptionRepo.SetCancelAtPeriodEnd(ctx, subscription.ID, true)
+	} else {
+		updated, err = txSubscriptionRepo.UpdateStatus(ctx, subscription.ID, "cancelled")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	if subscription.GatewaySubscriptionID != nil {
+		if err := s.gateway.CancelSubscription(ctx, *subscription.GatewaySubscriptionID, atPeriodEnd); err != nil {
+			if revertErr := s.revertCancel(context.WithoutCancel(ctx), subscription); revertErr != nil {
+				err = errors.Join(err, fmt.Errorf("revert cancellation of subscription %d: %w", subscription.ID, revertErr))
+			}
+			return nil, err
+		}
+	}
+
+	return s.buildDetail(ctx, updated)
+}
+
+// revertCancel restores the state the subscription had before Cancel.
+func (s *SubscriptionService) revertCancel(ctx context.Context, previous *models.Subscription) error {
+	var err error
+	if previous.Status == "past_due" {
+		_, err = s.subscriptionRepo.UpdateStatus(ctx, previous.ID, previous.Status)
+	} else {
+		_, err = s.subscriptionRepo.SetCancelAtPeriodEnd(ctx, previous.ID, false)
+	}
+	return err
+}
+
+func (s *SubscriptionService) HandleGatewayEvent(ctx context.Context, event GatewayEvent) error {
+	if strings.TrimSpace(event.ID) == "" || strings.TrimSpace(event.Type) == "" {
+		return ErrInvalidInput
+	}
+	if strings.TrimSpace(event.Data.SubscriptionID) == "" {
+		return ErrInvalidInput
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txSubscriptionRepo := repository.NewSubscriptionRepository(tx)
+	txPaymentRepo := repository.NewPaymentRepository(tx)
+
+	recorded, err := txPaymentRepo.RecordGatewayEvent(ctx, event.ID, event.Type, payload)
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return nil
+	}
+
+	subscription, err := txSubscriptionRepo.GetByGatewayIDForUpdate(ctx, event.Data.SubscriptionID)
+	if err != nil {
+		return err
+	}
+
+	var invoice *models.Invoice
+	switch event.Type {
+	case "subscription.activated", "subscription.renewed":
+		if subscription.Status == "cancelled" {
+			return ErrInvalidStateTransition
+		}
+		plan, err := txSubscriptionRepo.GetPlanByID(ctx, subscription.PlanID)
+		if err != nil {
+			return err
+		}
+		// A subscription without a trial starts on its first period, so activation pays for that
+		// period instead of starting the next one.
+		periodStart, periodEnd := subscription.CurrentPeriodStart, subscription.CurrentPeriodEnd
+		if event.Type != "subscription.activated" || subscription.Status != "active" {
+			periodStart, periodEnd = nextBillingPeriod(subscription, event.Data, s.now())
+		}
+		// Repeated events for a period that was already charged change nothing.
+		charged, err := txPaymentRepo.HasPeriodCharge(ctx, subscription.ID, periodStart)
+		if err != nil {
+			return err
+		}
+		if charged {
+			break
+		}
+		if _, err := txSubscriptionRepo.StartPeriod(ctx, subscription.ID, "active", periodStart, periodEnd); err != nil {
+			return err
+		}
+		amount := plan.MonthlyPrice
+		if event.Data.Amount != nil {
+			amount = *event.Data.Amount
+		}
+		if amount > 0 {
+			payment, err := txPaymentRepo.Create(ctx, repository.CreatePaymentInput{
+				SubscriptionID:     &subscription.ID,
+				UserID:             subscription.UserID,
+				CoachID:            subscription.CoachID,
+				Amount:             amount,
+				Status:             "paid",
+				BillingPeriodStart: &periodStart,
+			})
+			if err != nil {
+				return err
+			}
+			invoice, err = s.invoiceService.IssueForPayment(ctx, tx, payment, invoiceKindInvoice)
+			if err != nil {
+				return err
+			}
+		}
+	case "subscription.proration_charged":
+		// The gateway collected the proration of a plan change made through ChangePlan.
+		if event.Data.Amount == nil || *event.Data.Amount <= 0 {
+			return ErrInvalidInput
+		}
+		payment, err := txPaymentRepo.Create(ctx, repository.CreatePaymentInput{
+			SubscriptionID: &subscription.ID,
+			UserID:         subscription.UserID,
+			CoachID:        subscription.CoachID,
+			Amount:         *event.Data.Amount,
+			Status:         "paid",
+		})
+		if err != nil {
+			return err
+		}
+		invoice, err = s.invoiceService.IssueForPayment(ctx, tx, payment, invoiceKindInvoice)
+		if err != nil {
+			return err
+		}
+	case "subscription.payment_failed":
+		if subscription.Status == "cancelled" {
+			return ErrInvalidStateTransition
+		}
+		if _, err := txSubscriptionRepo.UpdateStatus(ctx, subscription.ID, "past_due"); err != nil {
+			return err
+		}
+	case "subscription.cancelled":
+		if subscription.Status != "cancelled" {
+			if _, err := txSubscriptionRepo.UpdateStatus(ctx, subscription.ID, "cancelled"); err != nil {
+				return err
+			}
+		}
+	default:
+		return ErrUnsupportedGatewayEvent
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	s.invoiceService.storePDFs(ctx, invoice)
+	return nil
+}
+
+func (s *SubscriptionService) buildDetail(
+	ctx context.Context,
+	subscription *models.Subscription,
+) (*models.SubscriptionDetail, error) {
+	plan, err := s.subscriptionRepo.GetPlanByID(ctx, subscription.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	used, err := s.subscriptionRepo.CountCoveredSessions(
+		ctx,
+		subscription.ID,
+		subscription.CurrentPeriodStart,
+		subscription.CurrentPeriodEnd,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := plan.SessionsPerMonth - used
+	if remaining < 0 || !isSubscriptionEntitled(subscription, s.now()) {
+		remaining = 0
+	}
+
+	return &models.SubscriptionDetail{
+		Subscription:      *subscription,
+		Plan:              *plan,
+		SessionsUsed:      used,
+		SessionsRemaining: remaining,
+	}, nil
+}
+
+func resolveSessionEntitlement(
+	ctx context.Context,
+	repo *repository.SubscriptionRepository,
+	userID int64,
+	coachID int64,
+	now time.Time,
+) (*models.Subscription, error) {
+	settings, err := repo.GetSettings(ctx, coachID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := repo.GetOpenForParticipants(ctx, userID, coachID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if subscription == nil || !isSubscriptionEntitled(subscription, now) {
+		if settings.RequiresSubscription {
+			return nil, ErrSubscriptionRequired
+		}
+		return nil, nil
+	}
+
+	plan, err := repo.GetPlanByID(ctx, subscription.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	used, err := repo.CountCoveredSessions(
+		ctx,
+		subscription.ID,
+		subscription.CurrentPeriodStart,
+		subscription.CurrentPeriodEnd,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if used >= plan.SessionsPerMonth {
+		if settings.RequiresSubscription {
+			return nil, ErrSessionAllowanceExhausted
+		}
+		return nil, nil
+	}
+
+	return subscription, nil
+}
+
+func checkChatEntitlement(
+	ctx context.Context,
+	repo *repository.SubscriptionRepository,
+	userID int64,
+	coachID int64,
+	now time.Time,
+) error {
+	settings, err := repo.GetSettings(ctx, coachID)
+	if err != nil {
+		return err
+	}
+	if !settings.RequiresSubscription {
+		return nil
+	}
+
+	subscription, err := repo.GetOpenForParticipants(ctx, userID, coachID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSubscriptionRequired
+		}
+		return err
+	}
+	if !isSubscriptionEntitled(subscription, now) {
+		return ErrSubscriptionRequired
+	}
+
+	plan, err := repo.GetPlanByID(ctx, subscription.PlanID)
+	if err != nil {
+		return err
+	}
+	if !plan.IncludesChat {
+		return ErrSubscriptionRequired
+	}
+	return nil
+}
+
+func isSubscriptionEntitled(subscription *models.Subscription, now time.Time) bool {
+	if subscription == nil {
+		return false
+	}
+	if subscription.Status != "active" && subscription.Status != "trialing" {
+		return false
+	}
+	return now.Before(subscription.CurrentPeriodEnd)
+}
+
+func canAccessSubscription(role string, actorID int64, subscription *models.Subscription) bool {
+	switch role {
+	case "user":
+		return subscription.UserID == actorID
+	case "coach":
+		return subscription.CoachID == actorID
+	default:
+		return false
+	}
+}
+
+func calculateProration(
+	currentPrice float64,
+	nextPrice float64,
+	periodStart time.Time,
+	periodEnd time.Time,
+	now time.Time,
+) float64 {
+	total := periodEnd.Sub(periodStart)
+	if total <= 0 || !now.Before(periodEnd) {
+		return 0
+	}
+
+	remaining := periodEnd.Sub(now)
+	if remaining > total {
+		remaining = total
+	}
+
+	amount := (nextPrice - currentPrice) * remaining.Seconds() / total.Seconds()
+	return math.Round(amount*100) / 100
+}
+
+func nextBillingPeriod(
+	subscription *models.Subscription,
+	data GatewayEventData,
+	now time.Time,
+) (time.Time, time.Time) {
+	if data.CurrentPeriodStart != nil && data.CurrentPeriodEnd != nil &&
+		data.CurrentPeriodEnd.After(*data.CurrentPeriodStart) {
+		return data.CurrentPeriodStart.UTC(), data.CurrentPeriodEnd.UTC()
+	}
+
+	start := subscription.CurrentPeriodEnd
+	if subscription.Status == "trialing" || start.Before(now) {
+		start = now
+	}
+	return start, start.AddDate(0, 1, 0)
+}
+
+func normalizeOptionalText(value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil, ErrInvalidInput
+	}
+	return &trimmed, nil
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+func TestSubscriptionActivationChargesFirstPeriodAfterCoveredBooking(t *testing.T) {
+	ctx := context.Background()
+	pool := integrationTestPool(t)
+	service := newIntegrationSubscriptionService(pool)
+
+	userID := createTestAccount(t, ctx, pool, "user", 0)
+	coachID := createTestAccount(t, ctx, pool, "coach", 100)
+	t.Cleanup(func() { cleanupTestUsers(t, ctx, pool, userID, coachID) })
+
+	plan, err := service.CreatePlan(ctx, coachID, SubscriptionPlanInput{
+		Name:             "Monthly",
+		MonthlyPrice:     120,
+		SessionsPerMonth: 4,
+		IncludesChat:     true,
+	})
+	if err != nil {
+		t.Fatalf("CreatePlan: %v", err)
+	}
+	detail, err := service.Subscribe(ctx, userID, plan.ID)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if detail.Status != "active" || detail.GatewaySubscriptionID == nil {
+		t.Fatalf("expected an active gateway subscription, got %+v", detail.Subscription)
+	}
+
+	// The booking is covered by the subscription and recorded as a paid 0 payment linked to it.
+	booked, err := newIntegrationSessionService(pool).BookSession(ctx, userID, BookSessionInput{
+		CoachID:         coachID,
+		ScheduledAt:     time.Date(2030, 6, 3, 10, 0, 0, 0, time.UTC),
+		DurationMinutes: 60,
+	})
+	if err != nil {
+		t.Fatalf("BookSession: %v", err)
+	}
+	if booked.Payment == nil || booked.Payment.SubscriptionID == nil || booked.Payment.Amount != 0 {
+		t.Fatalf("expected a covered booking, got %+v", booked.Payment)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := service.HandleGatewayEvent(ctx, GatewayEvent{
+			ID:   fmt.Sprintf("evt-activated-%d-%d-%d", detail.ID, time.Now().UnixNano(), i),
+			Type: "subscription.activated",
+			Data: GatewayEventData{SubscriptionID: *detail.GatewaySubscriptionID},
+		}); err != nil {
+			t.Fatalf("HandleGatewayEvent activated #%d: %v", i+1, err)
+		}
+	}
+
+	payments, err := repository.NewPaymentRepository(pool).ListBySubscriptionID(ctx, detail.ID)
+	if err != nil {
+		t.Fatalf("ListBySubscriptionID: %v", err)
+	}
+	charges := 0
+	for _, payment := range payments {
+		if payment.Amount == 120 && payment.Status == "paid" {
+			charges++
+		}
+	}
+	if charges != 1 {
+		t.Fatalf("expected exactly one first-period charge, got %+v", payments)
+	}
+
+	current, err := service.GetSubscription(ctx, userID, "user", detail.ID)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if !current.CurrentPeriodStart.Equal(detail.CurrentPeriodStart) {
+		t.Fatalf("expected activation to keep the first period, got %v -> %v", detail.CurrentPeriodStart, current.CurrentPeriodStart)
+	}
+}
+
+type refusingPaymentGateway struct {
+	*PlaceholderPaymentGateway
+}
+
+var errGatewayRefused = errors.New("gateway refused")
+
+func (g refusingPaymentGateway) ChangeSubscriptionPlan(context.Context, string, float64, float64) error {
+	return errGatewayRefused
+}
+
+func TestSubscriptionChangePlanRevertsWhenGatewayRefuses(t *testing.T) {
+	ctx := context.Background()
+	pool := integrationTestPool(t)
+	service := newIntegrationSubscriptionService(pool)
+
+	userID := createTestAccount(t, ctx, pool, "user", 0)
+	coachID := createTestAccount(t, ctx, pool, "coach", 100)
+	t.Cleanup(func() { cleanupTestUsers(t, ctx, pool, userID, coachID) })
+
+	basic, err := service.CreatePlan(ctx, coachID, SubscriptionPlanInput{Name: "Basic", MonthlyPrice: 50, SessionsPerMonth: 2})
+	if err != nil {
+		t.Fatalf("CreatePlan basic: %v", err)
+	}
+	premium, err := service.CreatePlan(ctx, coachID, SubscriptionPlanInput{Name: "Premium", MonthlyPrice: 90, SessionsPerMonth: 4})
+	if err != nil {
+		t.Fatalf("CreatePlan premium: %v", err)
+	}
+	detail, err := service.Subscribe(ctx, userID, basic.ID)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	service.gateway = refusingPaymentGateway{NewPlaceholderPaymentGateway()}
+	if _, _, err := service.ChangePlan(ctx, userID, detail.ID, premium.ID); !errors.Is(err, errGatewayRefused) {
+		t.Fatalf("expected the gateway error, got %v", err)
+	}
+
+	current, err := service.GetSubscription(ctx, userID, "user", detail.ID)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if current.PlanID != basic.ID {
+		t.Fatalf("expected the subscription back on plan %d, got %d", basic.ID, current.PlanID)
+	}
+	payments, err := repository.NewPaymentRepository(pool).ListBySubscriptionID(ctx, detail.ID)
+	if err != nil {
+		t.Fatalf("ListBySubscriptionID: %v", err)
+	}
+	if len(payments) != 0 {
+		t.Fatalf("expected no payment before the gateway confirms a charge, got %+v", payments)
+	}
+}
+
+func newIntegrationSubscriptionService(pool *pgxpool.Pool) *SubscriptionService {
+	return NewSubscriptionService(
+		pool,
+		repository.NewSubscriptionRepository(pool),
+		NewPlaceholderPaymentGateway(),
+		NewInvoiceService(pool, repository.NewInvoiceRepository(pool), repository.NewPaymentRepository(pool), nil),
+	)
+}
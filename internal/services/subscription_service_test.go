@@ -0,0 +1,90 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+func TestCalculateProration(t *testing.T) {
+	periodStart := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		current  float64
+		next     float64
+		now      time.Time
+		expected float64
+	}{
+		{name: "upgrade halfway", current: 100, next: 160, now: periodStart.Add(15 * 24 * time.Hour), expected: 30},
+		{name: "downgrade halfway", current: 160, next: 100, now: periodStart.Add(15 * 24 * time.Hour), expected: -30},
+		{name: "upgrade at period start", current: 50, next: 80, now: periodStart, expected: 30},
+		{name: "before period start is capped", current: 50, next: 80, now: periodStart.Add(-time.Hour), expected: 30},
+		{name: "after period end", current: 50, next: 80, now: periodEnd, expected: 0},
+		{name: "rounds to cents", current: 10, next: 20, now: periodStart.Add(10 * 24 * time.Hour), expected: 6.67},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateProration(tt.current, tt.next, periodStart, periodEnd, tt.now)
+			if got != tt.expected {
+				t.Fatalf("expected %.2f, got %.2f", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestIsSubscriptionEntitled(t *testing.T) {
+	now := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	future := now.Add(24 * time.Hour)
+	past := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name         string
+		subscription *models.Subscription
+		expected     bool
+	}{
+		{name: "nil", subscription: nil, expected: false},
+		{name: "active in period", subscription: &models.Subscription{Status: "active", CurrentPeriodEnd: future}, expected: true},
+		{name: "trialing in period", subscription: &models.Subscription{Status: "trialing", CurrentPeriodEnd: future}, expected: true},
+		{name: "active after period end", subscription: &models.Subscription{Status: "active", CurrentPeriodEnd: past}, expected: false},
+		{name: "past due", subscription: &models.Subscription{Status: "past_due", CurrentPeriodEnd: future}, expected: false},
+		{name: "cancelled", subscription: &models.Subscription{Status: "cancelled", CurrentPeriodEnd: future}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSubscriptionEntitled(tt.subscription, now); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNextBillingPeriod(t *testing.T) {
+	now := time.Date(2030, 2, 1, 12, 0, 0, 0, time.UTC)
+	periodEnd := time.Date(2030, 2, 3, 0, 0, 0, 0, time.UTC)
+
+	start, end := nextBillingPeriod(&models.Subscription{Status: "active", CurrentPeriodEnd: periodEnd}, GatewayEventData{}, now)
+	if !start.Equal(periodEnd) || !end.Equal(periodEnd.AddDate(0, 1, 0)) {
+		t.Fatalf("expected renewal to continue from period end, got %s - %s", start, end)
+	}
+
+	start, end = nextBillingPeriod(&models.Subscription{Status: "trialing", CurrentPeriodEnd: periodEnd}, GatewayEventData{}, now)
+	if !start.Equal(now) || !end.Equal(now.AddDate(0, 1, 0)) {
+		t.Fatalf("expected activation to start now, got %s - %s", start, end)
+	}
+
+	gatewayStart := time.Date(2030, 2, 5, 0, 0, 0, 0, time.UTC)
+	gatewayEnd := time.Date(2030, 3, 5, 0, 0, 0, 0, time.UTC)
+	start, end = nextBillingPeriod(
+		&models.Subscription{Status: "active", CurrentPeriodEnd: periodEnd},
+		GatewayEventData{CurrentPeriodStart: &gatewayStart, CurrentPeriodEnd: &gatewayEnd},
+		now,
+	)
+	if !start.Equal(gatewayStart) || !end.Equal(gatewayEnd) {
+		t.Fatalf("expected gateway period to win, got %s - %s", start, end)
+	}
+}
@@ -0,0 +1,487 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+const (
+	maxWorkoutLogSets       = 200
+	defaultProgressWeeks    = 12
+	maxProgressWindow       = 53 * 7 * 24 * time.Hour
+	maxOneRepMaxReps        = 12
+	workoutLogFutureLeeway  = 5 * time.Minute
+	maxWorkoutLogNoteLength = 2000
+)
+
+type WorkoutLogSetInput struct {
+	ProgramExerciseID *int64
+	ExerciseID        *int64
+	ExerciseName      string
+	SetNumber         int
+	Reps              int
+	WeightKg          *float64
+	RPE               *float64
+	Notes             *string
+}
+
+type WorkoutLogInput struct {
+	ProgramDayID    int64
+	PerformedAt     *time.Time
+	DurationMinutes *int
+	SessionRPE      *float64
+	Notes           *string
+	Sets            []WorkoutLogSetInput
+}
+
+type WorkoutLogService struct {
+	db          *pgxpool.Pool
+	logRepo     *repository.WorkoutLogRepository
+	programRepo *repository.WorkoutProgramRepository
+	// Coaches read a client's logs only while they actively coach them.
+	coachingRepo *repository.CoachingRepository
+}
+
+func NewWorkoutLogService(
+	db *pgxpool.Pool,
+	logRepo *repository.WorkoutLogRepository,
+	programRepo *repository.WorkoutProgramRepository,
+	coachingRepo *repository.CoachingRepository,
+) *WorkoutLogService {
+	return &WorkoutLogService{
+		db:           db,
+		logRepo:      logRepo,
+		programRepo:  programRepo,
+		coachingRepo: coachingRepo,
+	}
+}
+
+// CreateLog records a client's performance of one day of their program.
+func (s *WorkoutLogService) CreateLog(
+	ctx context.Context,
+	userID int64,
+	programID int64,
+	input WorkoutLogInput,
+) (*models.WorkoutLog, error) {
+	program, err := s.programRepo.GetByID(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	if program.UserID != userID {
+		return nil, ErrForbidden
+	}
+
+	phases, err := s.programRepo.GetStructure(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	log, dayExercises, ok := locateProgramDay(phases, input.ProgramDayID)
+	if !ok {
+		return nil, ErrInvalidInput
+	}
+	log.ProgramID = program.ID
+	log.UserID = program.UserID
+	log.CoachID = program.CoachID
+
+	now := time.Now().UTC()
+	log.PerformedAt = now
+	if input.PerformedAt != nil {
+		log.PerformedAt = input.PerformedAt.UTC()
+	}
+	if log.PerformedAt.After(now.Add(workoutLogFutureLeeway)) {
+		return nil, ErrInvalidInput
+	}
+	if input.DurationMinutes != nil && (*input.DurationMinutes <= 0 || *input.DurationMinutes > 24*60) {
+		return nil, ErrInvalidInput
+	}
+	if input.SessionRPE != nil && (*input.SessionRPE < 1 || *input.SessionRPE > 10) {
+		return nil, ErrInvalidInput
+	}
+	log.DurationMinutes = input.DurationMinutes
+	log.SessionRPE = input.SessionRPE
+	if log.Notes = blankToNil(input.Notes); log.Notes != nil && len(*log.Notes) > maxWorkoutLogNoteLength {
+		return nil, ErrInvalidInput
+	}
+
+	if len(input.Sets) > maxWorkoutLogSets {
+		return nil, ErrInvalidInput
+	}
+	log.Sets = make([]models.WorkoutLogSet, 0, len(input.Sets))
+	for _, setInput := range input.Sets {
+		set, err := buildWorkoutLogSet(setInput, dayExercises)
+		if err != nil {
+			return nil, err
+		}
+		log.Sets = append(log.Sets, set)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := checkLogExerciseAccess(ctx, tx, program.CoachID, log.Sets); err != nil {
+		return nil, err
+	}
+
+	created, err := repository.NewWorkoutLogRepository(tx).Create(ctx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	annotateOneRepMax(created)
+	return created, nil
+}
+
+func (s *WorkoutLogService) ListLogs(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	programID int64,
+	limit int,
+) ([]models.WorkoutLog, error) {
+	program, err := s.programRepo.GetByID(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProgramAccess(ctx, s.coachingRepo, role, actorID, program); err != nil {
+		return nil, err
+	}
+
+	logs, err := s.logRepo.ListByProgramID(ctx, programID, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range logs {
+		annotateOneRepMax(&logs[i])
+	}
+	return logs, nil
+}
+
+func (s *WorkoutLogService) DeleteLog(ctx context.Context, userID int64, logID int64) error {
+	log, err := s.logRepo.GetByID(ctx, logID)
+	if err != nil {
+		return err
+	}
+	if log.UserID != userID {
+		return ErrForbidden
+	}
+	return s.logRepo.Delete(ctx, logID)
+}
+
+// GetProgress reports adherence and training metrics for a client. Coaches only see data from
+// programs they assigned, and only while they actively coach the client; clients see their own
+// data across all coaches.
+func (s *WorkoutLogService) GetProgress(
+	ctx context.Context,
+	actorID int64,
+	role string,
+	userID int64,
+	from *time.Time,
+	to *time.Time,
+) (*models.ProgressReport, error) {
+	filter := repository.ProgressFilter{UserID: userID}
+	switch role {
+	case "user":
+		if userID != 0 && userID != actorID {
+			return nil, ErrForbidden
+		}
+		filter.UserID = actorID
+	case "coach":
+		if userID <= 0 {
+			return nil, ErrInvalidInput
+		}
+		active, err := s.coachingRepo.IsActiveCoach(ctx, actorID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, ErrForbidden
+		}
+		programs, err := s.programRepo.ListByCoachAndUser(ctx, actorID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(programs) == 0 {
+			return nil, ErrForbidden
+		}
+		filter.CoachID = &actorID
+	default:
+		return nil, ErrForbidden
+	}
+
+	filter.To = time.Now().UTC()
+	if to != nil {
+		filter.To = to.UTC()
+	}
+	filter.From = filter.To.AddDate(0, 0, -7*defaultProgressWeeks)
+	if from != nil {
+		filter.From = from.UTC()
+	}
+	if !filter.To.After(filter.From) || filter.To.Sub(filter.From) > maxProgressWindow {
+		return nil, ErrInvalidInput
+	}
+
+	adherence, err := s.logRepo.ListAdherence(ctx, filter.UserID, filter.CoachID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range adherence {
+		if adherence[i].PlannedDays > 0 {
+			rate := float64(adherence[i].CompletedDays) / float64(adherence[i].PlannedDays)
+			adherence[i].AdherenceRate = math.Round(rate*100) / 100
+		}
+	}
+
+	sets, err := s.logRepo.ListProgressSets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ProgressReport{
+		UserID:       filter.UserID,
+		From:         filter.From,
+		To:           filter.To,
+		Adherence:    adherence,
+		WeeklyVolume: weeklyMuscleVolume(sets),
+		Progression:  exerciseProgression(sets),
+	}, nil
+}
+
+// locateProgramDay resolves dayID to its natural key and the exercises prescribed on it.
+func locateProgramDay(
+	phases []models.ProgramPhase,
+	dayID int64,
+) (models.WorkoutLog, map[int64]models.ProgramExercise, bool) {
+	for phaseIndex, phase := range phases {
+		for _, week := range phase.Weeks {
+			for _, day := range week.Days {
+				if day.ID != dayID {
+					continue
+				}
+				exercises := make(map[int64]models.ProgramExercise, len(day.Exercises))
+				for _, exercise := range day.Exercises {
+					exercises[exercise.ID] = exercise
+				}
+				return models.WorkoutLog{
+					PhasePosition: phaseIndex + 1,
+					WeekNumber:    week.WeekNumber,
+					DayNumber:     day.DayNumber,
+				}, exercises, true
+			}
+		}
+	}
+	return models.WorkoutLog{}, nil, false
+}
+
+func buildWorkoutLogSet(
+	input WorkoutLogSetInput,
+	dayExercises map[int64]models.ProgramExercise,
+) (models.WorkoutLogSet, error) {
+	set := models.WorkoutLogSet{
+		ExerciseID:   input.ExerciseID,
+		ExerciseName: strings.TrimSpace(input.ExerciseName),
+		SetNumber:    input.SetNumber,
+		Reps:         input.Reps,
+		WeightKg:     input.WeightKg,
+		RPE:          input.RPE,
+		Notes:        blankToNil(input.Notes),
+	}
+
+	if input.ProgramExerciseID != nil {
+		prescribed, ok := dayExercises[*input.ProgramExerciseID]
+		if !ok {
+			return set, ErrInvalidInput
+		}
+		if set.ExerciseName == "" {
+			set.ExerciseName = prescribed.Name
+		}
+		if set.ExerciseID == nil {
+			set.ExerciseID = prescribed.ExerciseID
+		}
+	}
+
+	switch {
+	case set.ExerciseName == "" || len(set.ExerciseName) > 150:
+		return set, ErrInvalidInput
+	case set.ExerciseID != nil && *set.ExerciseID <= 0:
+		return set, ErrInvalidInput
+	case set.SetNumber <= 0 || set.SetNumber > 100:
+		return set, ErrInvalidInput
+	case set.Reps < 0 || set.Reps > 1000:
+		return set, ErrInvalidInput
+	case set.WeightKg != nil && (*set.WeightKg < 0 || *set.WeightKg >= 10000):
+		return set, ErrInvalidInput
+	case set.RPE != nil && (*set.RPE < 1 || *set.RPE > 10):
+		return set, ErrInvalidInput
+	case set.Notes != nil && len(*set.Notes) > maxWorkoutLogNoteLength:
+		return set, ErrInvalidInput
+	}
+	return set, nil
+}
+
+func checkLogExerciseAccess(ctx context.Context, db repository.DBTX, coachID int64, sets []models.WorkoutLogSet) error {
+	seen := make(map[int64]bool)
+	exerciseIDs := make([]int64, 0)
+	for _, set := range sets {
+		if set.ExerciseID != nil && !seen[*set.ExerciseID] {
+			seen[*set.ExerciseID] = true
+			exerciseIDs = append(exerciseIDs, *set.ExerciseID)
+		}
+	}
+	if len(exerciseIDs) == 0 {
+		return nil
+	}
+
+	count, err := repository.NewExerciseRepository(db).CountAccessible(ctx, coachID, exerciseIDs)
+	if err != nil {
+		return err
+	}
+	if count != len(exerciseIDs) {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+func annotateOneRepMax(log *models.WorkoutLog) {
+	for i := range log.Sets {
+		log.Sets[i].EstimatedOneRepMax = estimateOneRepMax(log.Sets[i].Reps, log.Sets[i].WeightKg)
+	}
+}
+
+// estimateOneRepMax uses the Epley formula. Sets above 12 reps are too far from a single to give
+// a meaningful estimate.
+func estimateOneRepMax(reps int, weightKg *float64) *float64 {
+	if weightKg == nil || *weightKg <= 0 || reps <= 0 || reps > maxOneRepMaxReps {
+		return nil
+	}
+	estimate := *weightKg
+	if reps > 1 {
+		estimate = *weightKg * (1 + float64(reps)/30)
+	}
+	estimate = math.Round(estimate*10) / 10
+	return &estimate
+}
+
+// progressWeekStart returns the Monday (UTC) of the week containing t.
+func progressWeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// weeklyMuscleVolume sums working sets and load (reps x kg) per muscle group and week. Sets
+// without a library exercise have no muscle groups and are left out.
+func weeklyMuscleVolume(sets []repository.ProgressSet) []models.MuscleGroupVolume {
+	type volumeKey struct {
+		week  time.Time
+		group string
+	}
+	totals := make(map[volumeKey]*models.MuscleGroupVolume)
+	for _, set := range sets {
+		if set.Reps <= 0 {
+			continue
+		}
+		week := progressWeekStart(set.PerformedAt)
+		load := 0.0
+		if set.WeightKg != nil {
+			load = float64(set.Reps) * *set.WeightKg
+		}
+		for _, group := range set.MuscleGroups {
+			key := volumeKey{week: week, group: group}
+			total, ok := totals[key]
+			if !ok {
+				total = &models.MuscleGroupVolume{WeekStart: week, MuscleGroup: group}
+				totals[key] = total
+			}
+			total.Sets++
+			total.VolumeKg += load
+		}
+	}
+
+	volumes := make([]models.MuscleGroupVolume, 0, len(totals))
+	for _, total := range totals {
+		total.VolumeKg = math.Round(total.VolumeKg*10) / 10
+		volumes = append(volumes, *total)
+	}
+	sort.Slice(volumes, func(i, j int) bool {
+		if !volumes[i].WeekStart.Equal(volumes[j].WeekStart) {
+			return volumes[i].WeekStart.Before(volumes[j].WeekStart)
+		}
+		return volumes[i].MuscleGroup < volumes[j].MuscleGroup
+	})
+	return volumes
+}
+
+// exerciseProgression keeps the best estimated 1RM per exercise and week. Exercises are grouped
+// by library id when present and by name otherwise.
+func exerciseProgression(sets []repository.ProgressSet) []models.ExerciseProgression {
+	type bestKey struct {
+		exercise string
+		week     time.Time
+	}
+	progressions := make(map[string]*models.ExerciseProgression)
+	best := make(map[bestKey]int)
+	for _, set := range sets {
+		estimate := estimateOneRepMax(set.Reps, set.WeightKg)
+		if estimate == nil {
+			continue
+		}
+
+		key := "name:" + strings.ToLower(set.ExerciseName)
+		if set.ExerciseID != nil {
+			key = "id:" + strconv.FormatInt(*set.ExerciseID, 10)
+		}
+		progression, ok := progressions[key]
+		if !ok {
+			progression = &models.ExerciseProgression{
+				ExerciseID:   set.ExerciseID,
+				ExerciseName: set.ExerciseName,
+				Points:       []models.OneRepMaxPoint{},
+			}
+			progressions[key] = progression
+		}
+
+		week := progressWeekStart(set.PerformedAt)
+		index, ok := best[bestKey{exercise: key, week: week}]
+		if !ok {
+			best[bestKey{exercise: key, week: week}] = len(progression.Points)
+			progression.Points = append(progression.Points, models.OneRepMaxPoint{
+				WeekStart:          week,
+				EstimatedOneRepMax: *estimate,
+			})
+			continue
+		}
+		if *estimate > progression.Points[index].EstimatedOneRepMax {
+			progression.Points[index].EstimatedOneRepMax = *estimate
+		}
+	}
+
+	result := make([]models.ExerciseProgression, 0, len(progressions))
+	for _, progression := range progressions {
+		result = append(result, *progression)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		left, right := strings.ToLower(result[i].ExerciseName), strings.ToLower(result[j].ExerciseName)
+		if left != right {
+			return left < right
+		}
+		return result[i].ExerciseID != nil && result[j].ExerciseID == nil
+	})
+	return result
+}
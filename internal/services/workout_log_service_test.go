@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/repository"
+)
+
+func TestEstimateOneRepMax(t *testing.T) {
+	weight := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name   string
+		reps   int
+		weight *float64
+		want   *float64
+	}{
+		{name: "single", reps: 1, weight: weight(140), want: weight(140)},
+		{name: "epley five reps", reps: 5, weight: weight(100), want: weight(116.7)},
+		{name: "twelve reps", reps: 12, weight: weight(60), want: weight(84)},
+		{name: "too many reps", reps: 13, weight: weight(60)},
+		{name: "bodyweight", reps: 10},
+		{name: "zero reps", reps: 0, weight: weight(100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := estimateOneRepMax(tt.reps, tt.weight)
+			switch {
+			case tt.want == nil && got != nil:
+				t.Fatalf("expected nil, got %v", *got)
+			case tt.want != nil && (got == nil || *got != *tt.want):
+				t.Fatalf("expected %v, got %v", *tt.want, got)
+			}
+		})
+	}
+}
+
+func TestProgressWeekStart(t *testing.T) {
+	sunday := time.Date(2030, 3, 10, 22, 0, 0, 0, time.UTC)
+	monday := time.Date(2030, 3, 4, 0, 0, 0, 0, time.UTC)
+	if got := progressWeekStart(sunday); !got.Equal(monday) {
+		t.Fatalf("expected %v, got %v", monday, got)
+	}
+	if got := progressWeekStart(monday.Add(time.Hour)); !got.Equal(monday) {
+		t.Fatalf("expected %v, got %v", monday, got)
+	}
+}
+
+func TestWeeklyMuscleVolumeAndProgression(t *testing.T) {
+	weight := func(v float64) *float64 { return &v }
+	squatID := int64(3)
+	week1 := time.Date(2030, 3, 5, 18, 0, 0, 0, time.UTC)
+	week2 := week1.AddDate(0, 0, 7)
+
+	sets := []repository.ProgressSet{
+		{PerformedAt: week1, ExerciseID: &squatID, ExerciseName: "Back Squat", MuscleGroups: []string{"quads", "glutes"}, Reps: 5, WeightKg: weight(100)},
+		{PerformedAt: week1, ExerciseID: &squatID, ExerciseName: "Back Squat", MuscleGroups: []string{"quads", "glutes"}, Reps: 3, WeightKg: weight(110)},
+		{PerformedAt: week1, ExerciseName: "Farmer carry", Reps: 1, WeightKg: weight(40)},
+		{PerformedAt: week1, ExerciseName: "Plank", MuscleGroups: []string{"core"}, Reps: 0},
+		{PerformedAt: week2, ExerciseID: &squatID, ExerciseName: "Back Squat", MuscleGroups: []string{"quads", "glutes"}, Reps: 5, WeightKg: weight(105)},
+	}
+
+	volume := weeklyMuscleVolume(sets)
+	if len(volume) != 4 {
+		t.Fatalf("expected 4 volume rows, got %+v", volume)
+	}
+	first := volume[1]
+	if first.MuscleGroup != "quads" || first.Sets != 2 || first.VolumeKg != 830 {
+		t.Fatalf("unexpected week 1 quads volume: %+v", first)
+	}
+	if !volume[2].WeekStart.After(volume[1].WeekStart) {
+		t.Fatalf("expected rows ordered by week, got %+v", volume)
+	}
+
+	progression := exerciseProgression(sets)
+	if len(progression) != 2 {
+		t.Fatalf("expected 2 exercises, got %+v", progression)
+	}
+	squat := progression[0]
+	if squat.ExerciseName != "Back Squat" || len(squat.Points) != 2 {
+		t.Fatalf("unexpected squat progression: %+v", squat)
+	}
+	if squat.Points[0].EstimatedOneRepMax != 121 || squat.Points[1].EstimatedOneRepMax != 122.5 {
+		t.Fatalf("expected best estimate per week, got %+v", squat.Points)
+	}
+}
+
+func TestBuildWorkoutLogSet(t *testing.T) {
+	libraryID := int64(9)
+	prescribed := map[int64]models.ProgramExercise{
+		11: {ID: 11, Name: "Bench press", ExerciseID: &libraryID},
+	}
+	programExerciseID := int64(11)
+	unknownID := int64(12)
+	rpe := 11.0
+
+	set, err := buildWorkoutLogSet(WorkoutLogSetInput{ProgramExerciseID: &programExerciseID, SetNumber: 1, Reps: 8}, prescribed)
+	if err != nil {
+		t.Fatalf("buildWorkoutLogSet: %v", err)
+	}
+	if set.ExerciseName != "Bench press" || set.ExerciseID == nil || *set.ExerciseID != libraryID {
+		t.Fatalf("expected prescription details to be copied, got %+v", set)
+	}
+
+	invalid := []WorkoutLogSetInput{
+		{ProgramExerciseID: &unknownID, SetNumber: 1, Reps: 5},
+		{ExerciseName: "Row", SetNumber: 0, Reps: 5},
+		{ExerciseName: "Row", SetNumber: 1, Reps: -1},
+		{ExerciseName: "Row", SetNumber: 1, Reps: 5, RPE: &rpe},
+		{ExerciseName: "  ", SetNumber: 1, Reps: 5},
+	}
+	for i, input := range invalid {
+		if _, err := buildWorkoutLogSet(input, prescribed); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("case %d: expected ErrInvalidInput, got %v", i, err)
+		}
+	}
+}
+
+func TestLocateProgramDay(t *testing.T) {
+	phases := []models.ProgramPhase{
+		{Name: "Base", Weeks: []models.ProgramWeek{{WeekNumber: 1, Days: []models.ProgramDay{{ID: 5, DayNumber: 1}}}}},
+		{Name: "Peak", Weeks: []models.ProgramWeek{{WeekNumber: 4, Days: []models.ProgramDay{
+			{ID: 8, DayNumber: 3, Exercises: []models.ProgramExercise{{ID: 21, Name: "Deadlift"}}},
+		}}}},
+	}
+
+	log, exercises, ok := locateProgramDay(phases, 8)
+	if !ok {
+		t.Fatal("expected day to be found")
+	}
+	if log.PhasePosition != 2 || log.WeekNumber != 4 || log.DayNumber != 3 {
+		t.Fatalf("unexpected day key: %+v", log)
+	}
+	if _, ok := exercises[21]; !ok {
+		t.Fatalf("expected day exercises, got %+v", exercises)
+	}
+	if _, _, ok := locateProgramDay(phases, 99); ok {
+		t.Fatal("expected unknown day to be rejected")
+	}
+}
+
+func TestWorkoutLogServiceGetProgressRequiresActiveCoach(t *testing.T) {
+	for _, status := range []string{"paused", "ended"} {
+		t.Run(status, func(t *testing.T) {
+			service := &WorkoutLogService{
+				coachingRepo: repository.NewCoachingRepository(newRelationshipStatusDB(status)),
+			}
+			_, err := service.GetProgress(context.Background(), 7, "coach", 42, nil, nil)
+			if !errors.Is(err, ErrForbidden) {
+				t.Fatalf("expected ErrForbidden, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package chatws
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Broker fans encoded hub messages out to every API instance, including the one that published them.
+type Broker interface {
+	Publish(ctx context.Context, payload []byte) error
+	Messages() <-chan []byte
+}
+
+type MemoryBroker struct {
+	messages chan []byte
+}
+
+// NewMemoryBroker returns a broker for a single instance; messages never leave the process.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{messages: make(chan []byte, 64)}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, payload []byte) error {
+	select {
+	case b.messages <- payload:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *MemoryBroker) Messages() <-chan []byte {
+	return b.messages
+}
+
+const (
+	chatNotifyChannel = "chat_events"
+	// Postgres rejects NOTIFY payloads of 8000 bytes or more; larger messages are sent in parts.
+	maxNotifyPayloadBytes = 7900
+	listenRetryDelay      = 2 * time.Second
+)
+
+type PostgresBroker struct {
+	pool     *pgxpool.Pool
+	channel  string
+	messages chan []byte
+}
+
+func NewPostgresBroker(pool *pgxpool.Pool) *PostgresBroker {
+	return &PostgresBroker{
+		pool:     pool,
+		channel:  chatNotifyChannel,
+		messages: make(chan []byte, 64),
+	}
+}
+
+// Publish sends the payload with pg_notify. Parts of a split payload are sent in one transaction,
+// so listeners receive them back to back and in order.
+func (b *PostgresBroker) Publish(ctx context.Context, payload []byte) error {
+	parts := splitNotifyPayload(string(payload), maxNotifyPayloadBytes)
+	if len(parts) == 1 {
+		_, err := b.pool.Exec(ctx, "SELECT pg_notify($1, $2)", b.channel, parts[0])
+		return err
+	}
+
+	tx, err := b.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for i, part := range parts {
+		chunk := fmt.Sprintf("%d/%d:%s", i+1, len(parts), part)
+		if _, err := tx.Exec(ctx, "SELECT pg_notify($1, $2)", b.channel, chunk); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (b *PostgresBroker) Messages() <-chan []byte {
+	return b.messages
+}
+
+// Listen holds a dedicated connection listening for notifications until ctx is cancelled and
+// reconnects after failures. Messages published while it is disconnected are not received.
+func (b *PostgresBroker) Listen(ctx context.Context) {
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("chat broker listen: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+func (b *PostgresBroker) listen(ctx context.Context) error {
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The listening connection must not go back to the pool.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	var assembler notifyAssembler
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		payload, ok := assembler.add(notification.Payload)
+		if !ok {
+			continue
+		}
+		select {
+		case b.messages <- payload:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// splitNotifyPayload cuts payload into parts of at most limit bytes without splitting a UTF-8
+// sequence, since a notification payload must be valid text.
+func splitNotifyPayload(payload string, limit int) []string {
+	if len(payload) <= limit {
+		return []string{payload}
+	}
+
+	var parts []string
+	for len(payload) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(payload[cut]) {
+			cut--
+		}
+		parts = append(parts, payload[:cut])
+		payload = payload[cut:]
+	}
+	return append(parts, payload)
+}
+
+// notifyAssembler joins the parts of split payloads. Whole payloads are JSON objects and pass
+// straight through; parts are prefixed with "<index>/<total>:".
+type notifyAssembler struct {
+	parts []string
+	total int
+}
+
+func (a *notifyAssembler) add(raw string) ([]byte, bool) {
+	if strings.HasPrefix(raw, "{") {
+		a.reset()
+		return []byte(raw), true
+	}
+
+	header, part, found := strings.Cut(raw, ":")
+	indexText, totalText, hasTotal := strings.Cut(header, "/")
+	index, indexErr := strconv.Atoi(indexText)
+	total, totalErr := strconv.Atoi(totalText)
+	if !found || !hasTotal || indexErr != nil || totalErr != nil || index < 1 || index > total {
+		log.Printf("chat broker: dropping malformed notification")
+		a.reset()
+		return nil, false
+	}
+
+	if index == 1 {
+		a.parts = a.parts[:0]
+		a.total = total
+	}
+	if total != a.total || index != len(a.parts)+1 {
+		// A part went missing, for example across a reconnect.
+		a.reset()
+		return nil, false
+	}
+
+	a.parts = append(a.parts, part)
+	if index < total {
+		return nil, false
+	}
+	payload := []byte(strings.Join(a.parts, ""))
+	a.reset()
+	return payload, true
+}
+
+func (a *notifyAssembler) reset() {
+	a.parts = a.parts[:0]
+	a.total = 0
+}
@@ -0,0 +1,50 @@
+package chatws
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitNotifyPayloadRoundTrip(t *testing.T) {
+	payload := `{"content":"` + strings.Repeat("héllo wörld ", 2000) + `"}`
+	parts := splitNotifyPayload(payload, 100)
+	if len(parts) < 2 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+
+	var assembler notifyAssembler
+	var joined []byte
+	for i, part := range parts {
+		if len(part) > 100 || !utf8.ValidString(part) {
+			t.Fatalf("part %d is not a valid notification payload", i)
+		}
+		result, ok := assembler.add(fmt.Sprintf("%d/%d:%s", i+1, len(parts), part))
+		if ok != (i == len(parts)-1) {
+			t.Fatalf("part %d: unexpected completion %v", i, ok)
+		}
+		joined = result
+	}
+	if string(joined) != payload {
+		t.Fatal("reassembled payload does not match")
+	}
+
+	if single := splitNotifyPayload(`{"a":1}`, 100); len(single) != 1 {
+		t.Fatalf("expected short payload to stay whole, got %d parts", len(single))
+	}
+}
+
+func TestNotifyAssemblerDropsIncompletePayloads(t *testing.T) {
+	var assembler notifyAssembler
+	if _, ok := assembler.add("1/3:{\"a\""); ok {
+		t.Fatal("expected first part to be held")
+	}
+	// Part 2 was lost; part 3 must not complete a corrupt payload.
+	if _, ok := assembler.add("3/3:}"); ok {
+		t.Fatal("expected out-of-order part to be dropped")
+	}
+	if payload, ok := assembler.add(`{"b":2}`); !ok || string(payload) != `{"b":2}` {
+		t.Fatalf("expected whole payload to pass through, got %q %v", payload, ok)
+	}
+}
@@ -0,0 +1,183 @@
+package chatws
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+const (
+	// maxReplayEvents caps a resume; clients further behind reload from the messages API.
+	maxReplayEvents = 500
+	eventRetention  = 7 * 24 * time.Hour
+	pruneInterval   = time.Hour
+)
+
+// EventStore numbers and keeps the frames each user receives so reconnecting clients can resume.
+type EventStore interface {
+	Append(ctx context.Context, userIDs []int64, eventType string, payload []byte) (map[int64]int64, error)
+	ListSince(ctx context.Context, userID int64, afterSeq int64, limit int) ([]models.ChatEvent, error)
+	LatestSequence(ctx context.Context, userID int64) (int64, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// durable reports whether a frame type is numbered and replayed. Typing and presence are only
+// meaningful live, and errors concern a single connection.
+func durable(messageType string) bool {
+	switch messageType {
+	case TypeMessage, TypeAttachment, TypeMessageEdited, TypeMessageDeleted, TypeReaction, TypeReceipt,
+		TypeMemberJoined, TypeMemberLeft:
+		return true
+	default:
+		return false
+	}
+}
+
+// recipients lists the users a frame goes to, starting with its sender.
+func recipients(message *Message) []string {
+	users := []string{message.SenderID}
+	if len(message.Members) > 0 {
+		for _, userID := range message.Members {
+			if !slices.Contains(users, userID) {
+				users = append(users, userID)
+			}
+		}
+		return users
+	}
+	if message.RecipientID == "" || message.RecipientID == message.SenderID {
+		return users
+	}
+	return append(users, message.RecipientID)
+}
+
+// record stores a durable frame for each of its recipients and notes their sequence numbers on the
+// message. If that fails the frame still goes out live, without a number.
+func (h *Hub) record(ctx context.Context, message *Message) {
+	userIDs := make([]int64, 0, len(message.Members)+2)
+	for _, raw := range recipients(message) {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return
+		}
+		userIDs = append(userIDs, id)
+	}
+
+	stored := *message
+	stored.Members = nil
+	frame, err := encodeMessage(&stored)
+	if err != nil {
+		log.Printf("chat hub encode %s for replay: %v", message.Type, err)
+		return
+	}
+	sequences, err := h.events.Append(ctx, userIDs, message.Type, frame)
+	if err != nil {
+		log.Printf("chat hub record %s: %v", message.Type, err)
+		return
+	}
+
+	message.Sequences = make(map[string]int64, len(sequences))
+	for userID, seq := range sequences {
+		message.Sequences[strconv.FormatInt(userID, 10)] = seq
+	}
+}
+
+// frameFor returns the payload for one recipient, carrying that recipient's sequence number and
+// without the hub-only member list.
+func frameFor(message *Message, payload []byte, userID string) []byte {
+	if len(message.Sequences) == 0 && len(message.Members) == 0 {
+		return payload
+	}
+	frame := *message
+	frame.Seq = message.Sequences[userID]
+	frame.Sequences = nil
+	frame.Members = nil
+	encoded, err := encodeMessage(&frame)
+	if err != nil {
+		log.Printf("chat hub encode %s: %v", message.Type, err)
+		return payload
+	}
+	return encoded
+}
+
+// resumeFrames returns what a client that last saw lastSeq has missed, followed by a resumed
+// frame. When the events are no longer all kept, or there are too many, it returns a single
+// resync frame instead and the client reloads its conversations over HTTP.
+func (c *Client) resumeFrames(ctx context.Context, lastSeq int64) ([][]byte, error) {
+	if c.hub.events == nil {
+		frame, err := sequenceFrame(TypeResync, 0)
+		return [][]byte{frame}, err
+	}
+	userID, err := strconv.ParseInt(c.userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := c.hub.events.LatestSequence(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []models.ChatEvent
+	complete := lastSeq == latest
+	if lastSeq >= 0 && lastSeq < latest {
+		events, err = c.hub.events.ListSince(ctx, userID, lastSeq, maxReplayEvents+1)
+		if err != nil {
+			return nil, err
+		}
+		complete = len(events) > 0 && len(events) <= maxReplayEvents && events[0].Seq == lastSeq+1
+	}
+	if !complete {
+		frame, err := sequenceFrame(TypeResync, latest)
+		return [][]byte{frame}, err
+	}
+
+	frames := make([][]byte, 0, len(events)+1)
+	for _, event := range events {
+		var message Message
+		if err := json.Unmarshal(event.Payload, &message); err != nil {
+			return nil, err
+		}
+		message.Seq = event.Seq
+		frame, err := encodeMessage(&message)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+		// Events recorded after LatestSequence was read can show up in the list too.
+		latest = max(latest, event.Seq)
+	}
+	frame, err := sequenceFrame(TypeResumed, latest)
+	if err != nil {
+		return nil, err
+	}
+	return append(frames, frame), nil
+}
+
+// sequenceFrame builds a resumed or resync frame pointing at the user's latest sequence number.
+func sequenceFrame(messageType string, seq int64) ([]byte, error) {
+	return encodeMessage(&Message{
+		Version:   ProtocolVersion,
+		Type:      messageType,
+		Seq:       seq,
+		Timestamp: services.FormatChatTimestamp(time.Now().UTC()),
+	})
+}
+
+func (h *Hub) pruneEvents() {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if _, err := h.events.DeleteBefore(ctx, time.Now().UTC().Add(-eventRetention)); err != nil {
+			log.Printf("chat hub prune events: %v", err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,150 @@
+package chatws
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+)
+
+type memoryEventStore struct {
+	mu     sync.Mutex
+	latest map[int64]int64
+	events []models.ChatEvent
+}
+
+func newMemoryEventStore() *memoryEventStore {
+	return &memoryEventStore{latest: make(map[int64]int64)}
+}
+
+func (s *memoryEventStore) Append(_ context.Context, userIDs []int64, eventType string, payload []byte) (map[int64]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sequences := make(map[int64]int64, len(userIDs))
+	for _, userID := range userIDs {
+		s.latest[userID]++
+		sequences[userID] = s.latest[userID]
+		s.events = append(s.events, models.ChatEvent{UserID: userID, Seq: s.latest[userID], Type: eventType, Payload: payload})
+	}
+	return sequences, nil
+}
+
+func (s *memoryEventStore) ListSince(_ context.Context, userID int64, afterSeq int64, limit int) ([]models.ChatEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := make([]models.ChatEvent, 0)
+	for _, event := range s.events {
+		if event.UserID == userID && event.Seq > afterSeq && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (s *memoryEventStore) LatestSequence(_ context.Context, userID int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latest[userID], nil
+}
+
+func (s *memoryEventStore) DeleteBefore(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
+
+func decodeFrames(t *testing.T, frames [][]byte) []Message {
+	t.Helper()
+	messages := make([]Message, 0, len(frames))
+	for _, frame := range frames {
+		var message Message
+		if err := json.Unmarshal(frame, &message); err != nil {
+			t.Fatalf("decode %s: %v", frame, err)
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func TestDeliverNumbersDurableFramesPerUser(t *testing.T) {
+	store := newMemoryEventStore()
+	hub := NewHub(NewMemoryBroker(), store)
+	go hub.Run()
+
+	coach := newTestClient(hub, "7")
+	client := newTestClient(hub, "8")
+
+	// The coach has an earlier event with someone else, so the two sequences differ.
+	store.latest[7] = 4
+
+	if err := hub.deliver(&Message{Type: TypeMessage, SenderID: "7", RecipientID: "8", Content: "hi"}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if frame := receive(t, coach, TypeMessage); frame.Seq != 5 || frame.Sequences != nil {
+		t.Fatalf("expected coach frame with seq 5 only, got %+v", frame)
+	}
+	if frame := receive(t, client, TypeMessage); frame.Seq != 1 {
+		t.Fatalf("expected client frame with seq 1, got %+v", frame)
+	}
+
+	if err := hub.deliver(&Message{Type: TypeTyping, SenderID: "8", RecipientID: "7", State: "start"}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if frame := receive(t, coach, TypeTyping); frame.Seq != 0 {
+		t.Fatalf("expected typing to stay unnumbered, got %+v", frame)
+	}
+	if latest, _ := store.LatestSequence(context.Background(), 8); latest != 1 {
+		t.Fatalf("expected typing not to be stored, latest is %d", latest)
+	}
+}
+
+func TestResumeFrames(t *testing.T) {
+	store := newMemoryEventStore()
+	hub := NewHub(NewMemoryBroker(), store)
+	client := NewClient(hub, nil, "8")
+	for _, content := range []string{"one", "two", "three"} {
+		frame, _ := encodeMessage(&Message{Version: ProtocolVersion, Type: TypeMessage, SenderID: "7", RecipientID: "8", Content: content})
+		if _, err := store.Append(context.Background(), []int64{8}, TypeMessage, frame); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	frames, err := client.resumeFrames(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("resumeFrames: %v", err)
+	}
+	got := decodeFrames(t, frames)
+	if len(got) != 3 || got[0].Content != "two" || got[0].Seq != 2 || got[1].Seq != 3 || got[2].Type != TypeResumed || got[2].Seq != 3 {
+		t.Fatalf("unexpected replay: %+v", got)
+	}
+
+	frames, _ = client.resumeFrames(context.Background(), 3)
+	if got := decodeFrames(t, frames); len(got) != 1 || got[0].Type != TypeResumed {
+		t.Fatalf("expected an up-to-date client to just resume, got %+v", got)
+	}
+
+	frames, _ = client.resumeFrames(context.Background(), 9)
+	if got := decodeFrames(t, frames); len(got) != 1 || got[0].Type != TypeResync || got[0].Seq != 3 {
+		t.Fatalf("expected an unknown sequence to resync, got %+v", got)
+	}
+
+	// Pruned events cannot be replayed.
+	store.events = store.events[1:]
+	frames, _ = client.resumeFrames(context.Background(), 0)
+	if got := decodeFrames(t, frames); len(got) != 1 || got[0].Type != TypeResync {
+		t.Fatalf("expected a gap to resync, got %+v", got)
+	}
+}
+
+func TestClosedClientRejectsFrames(t *testing.T) {
+	client := NewClient(NewHub(NewMemoryBroker(), nil), nil, "8")
+	if !client.queue([]byte(`{}`)) {
+		t.Fatal("expected an open client to accept a frame")
+	}
+	client.close()
+	client.close()
+	if client.queue([]byte(`{}`)) {
+		t.Fatal("expected a closed client to reject frames")
+	}
+}
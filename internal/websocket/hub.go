@@ -2,30 +2,93 @@ package chatws
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"log"
 	"strconv"
+	"sync"
 	"time"
 
 	websocket "github.com/gofiber/contrib/websocket"
 	"github.com/saeid-a/CoachAppBack/internal/services"
 )
 
+// ProtocolVersion is the envelope version sent in "v". Clients may omit it on frames they send.
+const ProtocolVersion = 1
+
+// Envelope types exchanged with clients.
+const (
+	TypeMessage        = "message"
+	TypeAttachment     = "attachment"
+	TypeMessageEdited  = "message_edited"
+	TypeMessageDeleted = "message_deleted"
+	TypeReaction       = "reaction"
+	TypeMemberJoined   = "member_joined"
+	TypeMemberLeft     = "member_left"
+	TypeTyping         = "typing"
+	TypeRead           = "read"
+	TypeReceipt        = "receipt"
+	TypePresence       = "presence"
+	TypeResume         = "resume"
+	TypeResumed        = "resumed"
+	TypeResync         = "resync"
+	TypeError          = "error"
+)
+
+// Reaction frame states.
+const (
+	ReactionAdded   = "added"
+	ReactionRemoved = "removed"
+)
+
+const (
+	publishTimeout = 5 * time.Second
+	writeWait      = 10 * time.Second
+	// pongWait is how long a connection may stay silent; pings keep healthy ones from hitting it.
+	pongWait     = 60 * time.Second
+	pingInterval = pongWait * 9 / 10
+	// peerCacheTTL bounds how long the read pump relies on a cached member list, since group
+	// members come and go.
+	peerCacheTTL = time.Minute
+)
+
+// Hub tracks the clients connected to this instance. Messages go through the broker so that
+// every instance delivers them to its own clients.
 type Hub struct {
+	instanceID string
 	clients    map[string]map[*Client]struct{}
+	presence   map[string]*userPresence
 	register   chan *Client
 	unregister chan *Client
-	broadcast  chan *Message
+	outbound   chan *Message
+	broker     Broker
+	events     EventStore
 }
 
 type Client struct {
 	hub    *Hub
 	conn   *websocket.Conn
 	userID string
+	// send is closed by the hub; mu and closed let the read pump queue error frames safely.
 	send   chan []byte
+	mu     sync.Mutex
+	closed bool
+	resume chan int64
+	// watching holds the users whose presence the client receives. Once the client is
+	// registered it is only touched by the hub goroutine.
+	watching map[string]struct{}
+	// peers caches the other participants of each conversation for the read pump.
+	peers map[int64]cachedPeers
+}
+
+type cachedPeers struct {
+	ids       []int64
+	fetchedAt time.Time
 }
 
-type sender interface {
+type chatService interface {
 	SendMessage(
 		ctx context.Context,
 		actorID int64,
@@ -33,59 +96,112 @@ type sender interface {
 		conversationID int64,
 		content string,
 	) (*services.ChatDelivery, error)
+	MarkMessagesRead(
+		ctx context.Context,
+		actorID int64,
+		role string,
+		conversationID int64,
+		messageIDs []int64,
+	) (*services.ReadReceipt, error)
+	ConversationPeers(ctx context.Context, actorID int64, role string, conversationID int64) ([]int64, error)
 }
 
+// Message is the versioned envelope. Which fields are set depends on Type.
 type Message struct {
-	Type           string `json:"type"`
-	ConversationID string `json:"conversation_id"`
-	SenderID       string `json:"sender_id"`
-	RecipientID    string `json:"recipient_id,omitempty"`
-	Content        string `json:"content"`
-	Timestamp      string `json:"timestamp"`
+	Version        int                `json:"v"`
+	Type           string             `json:"type"`
+	ConversationID string             `json:"conversation_id,omitempty"`
+	MessageID      string             `json:"message_id,omitempty"`
+	SenderID       string             `json:"sender_id,omitempty"`
+	RecipientID    string             `json:"recipient_id,omitempty"`
+	Content        string             `json:"content,omitempty"`
+	Attachment     *AttachmentPayload `json:"attachment,omitempty"`
+	Emoji          string             `json:"emoji,omitempty"`
+	State          string             `json:"state,omitempty"`
+	MessageIDs     []string           `json:"message_ids,omitempty"`
+	UserID         string             `json:"user_id,omitempty"`
+	Online         *bool              `json:"online,omitempty"`
+	LastSeenAt     string             `json:"last_seen_at,omitempty"`
+	// Seq numbers the durable frames a user receives, per user. On resume it is the last one seen.
+	Seq       int64  `json:"seq,omitempty"`
+	Timestamp string `json:"timestamp"`
+	// Instance, UserIDs, Members and Sequences only travel between hubs. Members lists the
+	// recipients of a group frame, which has no single RecipientID.
+	Instance  string           `json:"instance,omitempty"`
+	UserIDs   []string         `json:"user_ids,omitempty"`
+	Members   []string         `json:"members,omitempty"`
+	Sequences map[string]int64 `json:"sequences,omitempty"`
+}
+
+// AttachmentPayload describes a file on an attachment frame. The URLs are signed and short-lived.
+type AttachmentPayload struct {
+	ID           string `json:"id"`
+	Kind         string `json:"kind"`
+	Filename     string `json:"filename"`
+	ContentType  string `json:"content_type"`
+	SizeBytes    int64  `json:"size_bytes"`
+	Width        *int   `json:"width,omitempty"`
+	Height       *int   `json:"height,omitempty"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
 }
 
-func NewHub() *Hub {
+// NewHub creates a hub. Without an event store, frames are not numbered and clients cannot resume.
+func NewHub(broker Broker, events EventStore) *Hub {
 	return &Hub{
+		instanceID: newInstanceID(),
 		clients:    make(map[string]map[*Client]struct{}),
+		presence:   make(map[string]*userPresence),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
-		broadcast:  make(chan *Message, 64),
+		outbound:   make(chan *Message, 256),
+		broker:     broker,
+		events:     events,
 	}
 }
 
 func NewClient(hub *Hub, conn *websocket.Conn, userID string) *Client {
 	return &Client{
-		hub:    hub,
-		conn:   conn,
-		userID: userID,
-		send:   make(chan []byte, 32),
+		hub:      hub,
+		conn:     conn,
+		userID:   userID,
+		send:     make(chan []byte, 64),
+		resume:   make(chan int64, 1),
+		watching: make(map[string]struct{}),
+		peers:    make(map[int64]cachedPeers),
+	}
+}
+
+// Watch subscribes the client to the presence of the given users. Call it before Register.
+func (c *Client) Watch(userIDs ...string) {
+	for _, userID := range userIDs {
+		if userID != "" && userID != c.userID {
+			c.watching[userID] = struct{}{}
+		}
 	}
 }
 
 func (h *Hub) Run() {
+	go h.publishLoop()
+	if h.events != nil {
+		go h.pruneEvents()
+	}
+	h.enqueue(&Message{Type: typePresenceSync, Instance: h.instanceID})
+
+	ticker := time.NewTicker(presenceHeartbeatInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case client := <-h.register:
-			set, ok := h.clients[client.userID]
-			if !ok {
-				set = make(map[*Client]struct{})
-				h.clients[client.userID] = set
-			}
-			set[client] = struct{}{}
+			h.addClient(client)
 		case client := <-h.unregister:
-			set, ok := h.clients[client.userID]
-			if !ok {
-				continue
-			}
-			if _, exists := set[client]; exists {
-				delete(set, client)
-				close(client.send)
-			}
-			if len(set) == 0 {
-				delete(h.clients, client.userID)
-			}
-		case message := <-h.broadcast:
-			h.deliver(message)
+			h.removeClient(client)
+		case payload := <-h.broker.Messages():
+			h.dispatch(payload)
+		case now := <-ticker.C:
+			h.announcePresence()
+			h.expirePresence(now)
 		}
 	}
 }
@@ -98,35 +214,277 @@ func (h *Hub) Unregister(client *Client) {
 	h.unregister <- client
 }
 
-func (h *Hub) deliver(message *Message) {
+func (h *Hub) addClient(client *Client) {
+	set, ok := h.clients[client.userID]
+	if !ok {
+		set = make(map[*Client]struct{})
+		h.clients[client.userID] = set
+		h.enqueue(&Message{Type: typePresenceOnline, Instance: h.instanceID, UserIDs: []string{client.userID}})
+	}
+	set[client] = struct{}{}
+
+	for userID := range client.watching {
+		h.sendPresence(client, userID)
+	}
+}
+
+func (h *Hub) removeClient(client *Client) {
+	set, ok := h.clients[client.userID]
+	if !ok {
+		return
+	}
+	if _, exists := set[client]; !exists {
+		return
+	}
+	delete(set, client)
+	client.close()
+
+	if len(set) == 0 {
+		delete(h.clients, client.userID)
+		h.enqueue(&Message{
+			Type:       typePresenceOffline,
+			Instance:   h.instanceID,
+			UserID:     client.userID,
+			LastSeenAt: services.FormatChatTimestamp(time.Now().UTC()),
+		})
+	}
+}
+
+// deliver publishes the message to all instances. Each instance hands it to the clients
+// connected to it. Durable frames are numbered and stored first so they can be replayed.
+func (h *Hub) deliver(message *Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	message.Version = ProtocolVersion
+	if h.events != nil && durable(message.Type) {
+		h.record(ctx, message)
+	}
 	encoded, err := encodeMessage(message)
 	if err != nil {
-		log.Printf("chat hub encode message: %v", err)
-		return
+		return err
 	}
+	return h.broker.Publish(ctx, encoded)
+}
 
-	h.sendToUser(message.SenderID, encoded)
-	if message.RecipientID != "" && message.RecipientID != message.SenderID {
-		h.sendToUser(message.RecipientID, encoded)
+// addressTo sets who a frame goes to besides its sender: the RecipientID in a direct conversation,
+// or the members of a group.
+func addressTo(message *Message, recipientIDs []int64) *Message {
+	if len(recipientIDs) == 1 {
+		message.RecipientID = strconv.FormatInt(recipientIDs[0], 10)
+		return message
+	}
+	message.Members = make([]string, 0, len(recipientIDs))
+	for _, id := range recipientIDs {
+		message.Members = append(message.Members, strconv.FormatInt(id, 10))
 	}
+	return message
 }
 
-func (h *Hub) sendToUser(userID string, payload []byte) {
-	set, ok := h.clients[userID]
-	if !ok {
+// DeliverMessage sends a new text message to every participant. Messages sent over the socket go
+// through it as well as those sent on a coach's behalf, such as broadcasts.
+func (h *Hub) DeliverMessage(delivery *services.ChatDelivery) error {
+	return h.deliver(addressTo(&Message{
+		Type:           TypeMessage,
+		ConversationID: strconv.FormatInt(delivery.Message.ConversationID, 10),
+		MessageID:      strconv.FormatInt(delivery.Message.ID, 10),
+		SenderID:       strconv.FormatInt(delivery.Message.SenderID, 10),
+		Content:        delivery.Message.Content,
+		Timestamp:      services.FormatChatTimestamp(delivery.Message.CreatedAt),
+	}, delivery.RecipientIDs))
+}
+
+// DeliverAttachment sends an attachment frame to every participant. Uploads arrive over HTTP, so
+// the handler calls this once the attachment is stored.
+func (h *Hub) DeliverAttachment(delivery *services.ChatDelivery) error {
+	if len(delivery.Message.Attachments) == 0 {
+		return nil
+	}
+	attachment := delivery.Message.Attachments[0]
+	return h.deliver(addressTo(&Message{
+		Type:           TypeAttachment,
+		ConversationID: strconv.FormatInt(delivery.Message.ConversationID, 10),
+		MessageID:      strconv.FormatInt(delivery.Message.ID, 10),
+		SenderID:       strconv.FormatInt(delivery.Message.SenderID, 10),
+		Content:        delivery.Message.Content,
+		Attachment: &AttachmentPayload{
+			ID:           strconv.FormatInt(attachment.ID, 10),
+			Kind:         attachment.Kind,
+			Filename:     attachment.Filename,
+			ContentType:  attachment.ContentType,
+			SizeBytes:    attachment.SizeBytes,
+			Width:        attachment.Width,
+			Height:       attachment.Height,
+			URL:          attachment.URL,
+			ThumbnailURL: attachment.ThumbnailURL,
+		},
+		Timestamp: services.FormatChatTimestamp(delivery.Message.CreatedAt),
+	}, delivery.RecipientIDs))
+}
+
+// DeliverEdit tells every participant that a message has new content. Timestamp is the edit time.
+func (h *Hub) DeliverEdit(delivery *services.ChatDelivery) error {
+	editedAt := delivery.Message.CreatedAt
+	if delivery.Message.EditedAt != nil {
+		editedAt = *delivery.Message.EditedAt
+	}
+	return h.deliver(addressTo(&Message{
+		Type:           TypeMessageEdited,
+		ConversationID: strconv.FormatInt(delivery.Message.ConversationID, 10),
+		MessageID:      strconv.FormatInt(delivery.Message.ID, 10),
+		SenderID:       strconv.FormatInt(delivery.Message.SenderID, 10),
+		Content:        delivery.Message.Content,
+		Timestamp:      services.FormatChatTimestamp(editedAt),
+	}, delivery.RecipientIDs))
+}
+
+// DeliverDeletion tells every participant to replace a message with a tombstone.
+func (h *Hub) DeliverDeletion(delivery *services.ChatDelivery) error {
+	deletedAt := time.Now().UTC()
+	if delivery.Message.DeletedAt != nil {
+		deletedAt = *delivery.Message.DeletedAt
+	}
+	return h.deliver(addressTo(&Message{
+		Type:           TypeMessageDeleted,
+		ConversationID: strconv.FormatInt(delivery.Message.ConversationID, 10),
+		MessageID:      strconv.FormatInt(delivery.Message.ID, 10),
+		SenderID:       strconv.FormatInt(delivery.Message.SenderID, 10),
+		Timestamp:      services.FormatChatTimestamp(deletedAt),
+	}, delivery.RecipientIDs))
+}
+
+// DeliverReaction tells every participant that SenderID reacted to a message or took the reaction back.
+func (h *Hub) DeliverReaction(change *services.ReactionChange) error {
+	state := ReactionAdded
+	if change.Emoji == "" {
+		state = ReactionRemoved
+	}
+	return h.deliver(addressTo(&Message{
+		Type:           TypeReaction,
+		ConversationID: strconv.FormatInt(change.ConversationID, 10),
+		MessageID:      strconv.FormatInt(change.MessageID, 10),
+		SenderID:       strconv.FormatInt(change.UserID, 10),
+		Emoji:          change.Emoji,
+		State:          state,
+		Timestamp:      services.FormatChatTimestamp(change.At),
+	}, change.RecipientIDs))
+}
+
+// DeliverMembership sends a member_joined or member_left frame per affected user to the group.
+// SenderID is the member who made the change and UserID the one who joined or left.
+func (h *Hub) DeliverMembership(change *services.MembershipChange) error {
+	messageType := TypeMemberLeft
+	if change.Joined {
+		messageType = TypeMemberJoined
+	}
+	var errs []error
+	for _, userID := range change.UserIDs {
+		if err := h.deliver(addressTo(&Message{
+			Type:           messageType,
+			ConversationID: strconv.FormatInt(change.ConversationID, 10),
+			SenderID:       strconv.FormatInt(change.ActorID, 10),
+			UserID:         strconv.FormatInt(userID, 10),
+			Timestamp:      services.FormatChatTimestamp(change.At),
+		}, change.RecipientIDs)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// enqueue publishes from the hub goroutine without blocking it, since that goroutine also drains
+// the broker. Messages are published in order.
+func (h *Hub) enqueue(message *Message) {
+	select {
+	case h.outbound <- message:
+	default:
+		log.Printf("chat hub: outbound queue full, dropping %s", message.Type)
+	}
+}
+
+func (h *Hub) publishLoop() {
+	for message := range h.outbound {
+		if err := h.deliver(message); err != nil {
+			log.Printf("chat hub publish %s: %v", message.Type, err)
+		}
+	}
+}
+
+func (h *Hub) dispatch(payload []byte) {
+	var message Message
+	if err := json.Unmarshal(payload, &message); err != nil {
+		log.Printf("chat hub decode message: %v", err)
 		return
 	}
 
-	for client := range set {
-		select {
-		case client.send <- payload:
-		default:
-			delete(set, client)
-			close(client.send)
+	switch message.Type {
+	case typePresenceOnline, typePresenceOffline, typePresenceSync:
+		h.applyPresence(&message, time.Now().UTC())
+	case TypeTyping:
+		h.watchEachOther(message.SenderID, message.RecipientID)
+		for _, userID := range recipients(&message) {
+			if userID != message.SenderID {
+				h.sendToUser(userID, frameFor(&message, payload, userID))
+			}
+		}
+	default:
+		h.watchEachOther(message.SenderID, message.RecipientID)
+		for _, userID := range recipients(&message) {
+			h.sendToUser(userID, frameFor(&message, payload, userID))
 		}
 	}
-	if len(set) == 0 {
-		delete(h.clients, userID)
+}
+
+// watchEachOther makes two chat participants see each other's presence, covering conversations
+// started after they connected.
+func (h *Hub) watchEachOther(firstID string, secondID string) {
+	if firstID == "" || secondID == "" || firstID == secondID {
+		return
+	}
+	for client := range h.clients[firstID] {
+		client.watching[secondID] = struct{}{}
+	}
+	for client := range h.clients[secondID] {
+		client.watching[firstID] = struct{}{}
+	}
+}
+
+func (h *Hub) sendToUser(userID string, payload []byte) {
+	for client := range h.clients[userID] {
+		h.sendToClient(client, payload)
+	}
+}
+
+func (h *Hub) sendToClient(client *Client, payload []byte) {
+	if !client.queue(payload) {
+		// The client is not keeping up. Dropping it closes the connection, and the client
+		// reconnects and resumes from its last sequence number.
+		h.removeClient(client)
+	}
+}
+
+// queue hands a frame to the write pump without blocking. It fails once the buffer is full or the
+// hub has dropped the client.
+func (c *Client) queue(payload []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	select {
+	case c.send <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.send)
 	}
 }
 
@@ -134,7 +492,15 @@ func encodeMessage(message *Message) ([]byte, error) {
 	return json.Marshal(message)
 }
 
-func (c *Client) ReadPump(service sender, role string) {
+func newInstanceID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(buf)
+}
+
+func (c *Client) ReadPump(service chatService, role string) {
 	defer func() {
 		c.hub.Unregister(c)
 		_ = c.conn.Close()
@@ -146,23 +512,37 @@ func (c *Client) ReadPump(service sender, role string) {
 		return
 	}
 
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
 	for {
 		_, payload, err := c.conn.ReadMessage()
 		if err != nil {
 			return
 		}
+		_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
 
 		var incoming struct {
-			Type           string `json:"type"`
-			ConversationID string `json:"conversation_id"`
-			Content        string `json:"content"`
+			Version        int      `json:"v"`
+			Type           string   `json:"type"`
+			ConversationID string   `json:"conversation_id"`
+			Content        string   `json:"content"`
+			State          string   `json:"state"`
+			MessageIDs     []string `json:"message_ids"`
+			Seq            int64    `json:"seq"`
 		}
 		if err := json.Unmarshal(payload, &incoming); err != nil {
 			writeError(c, "invalid message payload")
 			continue
 		}
-		if incoming.Type != "message" {
-			writeError(c, "unsupported message type")
+		if incoming.Version != 0 && incoming.Version != ProtocolVersion {
+			writeError(c, "unsupported protocol version")
+			continue
+		}
+		if incoming.Type == TypeResume {
+			c.requestResume(incoming.Seq)
 			continue
 		}
 
@@ -172,53 +552,200 @@ func (c *Client) ReadPump(service sender, role string) {
 			continue
 		}
 
-		delivery, err := service.SendMessage(
-			context.Background(),
-			actorID,
-			role,
-			conversationID,
-			incoming.Content,
-		)
-		if err != nil {
+		switch incoming.Type {
+		case TypeMessage:
+			c.sendMessage(service, actorID, role, conversationID, incoming.Content)
+		case TypeTyping:
+			c.sendTyping(service, actorID, role, conversationID, incoming.State)
+		case TypeRead:
+			c.sendReadReceipt(service, actorID, role, conversationID, incoming.MessageIDs)
+		default:
+			writeError(c, "unsupported message type")
+		}
+	}
+}
+
+func (c *Client) sendMessage(service chatService, actorID int64, role string, conversationID int64, content string) {
+	delivery, err := service.SendMessage(context.Background(), actorID, role, conversationID, content)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSubscriptionRequired):
+			writeError(c, "active subscription required")
+		case errors.Is(err, services.ErrMessageBlocked):
+			writeError(c, "message blocked by moderation")
+		case errors.Is(err, services.ErrUserBlocked):
+			writeError(c, "messaging is blocked between you and this user")
+		default:
 			writeError(c, "failed to send message")
-			continue
 		}
+		return
+	}
+	c.cachePeers(conversationID, delivery.RecipientIDs)
+
+	if err := c.hub.DeliverMessage(delivery); err != nil {
+		// The message is stored, so it still shows up in the conversation history.
+		log.Printf("chat hub publish message: %v", err)
+		writeError(c, "message saved but not delivered in real time")
+	}
+}
 
-		c.hub.broadcast <- &Message{
-			Type:           "message",
-			ConversationID: strconv.FormatInt(delivery.Message.ConversationID, 10),
-			SenderID:       strconv.FormatInt(delivery.Message.SenderID, 10),
-			RecipientID:    strconv.FormatInt(delivery.RecipientID, 10),
-			Content:        delivery.Message.Content,
-			Timestamp:      services.FormatChatTimestamp(delivery.Message.CreatedAt),
+// sendTyping relays a typing start or stop to the other participants. Typing state is not stored.
+func (c *Client) sendTyping(service chatService, actorID int64, role string, conversationID int64, state string) {
+	if state != "start" && state != "stop" {
+		writeError(c, "typing state must be start or stop")
+		return
+	}
+
+	cached, ok := c.peers[conversationID]
+	if !ok || time.Since(cached.fetchedAt) > peerCacheTTL {
+		peerIDs, err := service.ConversationPeers(context.Background(), actorID, role, conversationID)
+		if err != nil {
+			writeError(c, "conversation not found")
+			return
 		}
+		cached = c.cachePeers(conversationID, peerIDs)
 	}
+	if len(cached.ids) == 0 {
+		return
+	}
+
+	if err := c.hub.deliver(addressTo(&Message{
+		Type:           TypeTyping,
+		ConversationID: strconv.FormatInt(conversationID, 10),
+		SenderID:       c.userID,
+		State:          state,
+		Timestamp:      services.FormatChatTimestamp(time.Now().UTC()),
+	}, cached.ids)); err != nil {
+		log.Printf("chat hub publish typing: %v", err)
+	}
+}
+
+// sendReadReceipt marks messages read and tells every participant which ones were newly read.
+func (c *Client) sendReadReceipt(
+	service chatService,
+	actorID int64,
+	role string,
+	conversationID int64,
+	rawIDs []string,
+) {
+	messageIDs := make([]int64, 0, len(rawIDs))
+	for _, raw := range rawIDs {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			writeError(c, "invalid message id")
+			return
+		}
+		messageIDs = append(messageIDs, id)
+	}
+
+	receipt, err := service.MarkMessagesRead(context.Background(), actorID, role, conversationID, messageIDs)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			writeError(c, "message_ids must list 1 to 100 messages")
+			return
+		}
+		writeError(c, "failed to mark messages read")
+		return
+	}
+	c.cachePeers(conversationID, receipt.RecipientIDs)
+	if len(receipt.MessageIDs) == 0 {
+		return
+	}
+
+	readIDs := make([]string, 0, len(receipt.MessageIDs))
+	for _, id := range receipt.MessageIDs {
+		readIDs = append(readIDs, strconv.FormatInt(id, 10))
+	}
+	if err := c.hub.deliver(addressTo(&Message{
+		Type:           TypeReceipt,
+		ConversationID: strconv.FormatInt(receipt.ConversationID, 10),
+		SenderID:       strconv.FormatInt(receipt.ReaderID, 10),
+		MessageIDs:     readIDs,
+		Timestamp:      services.FormatChatTimestamp(receipt.ReadAt),
+	}, receipt.RecipientIDs)); err != nil {
+		log.Printf("chat hub publish receipt: %v", err)
+	}
+}
+
+// cachePeers remembers the other participants of a conversation for typing frames.
+func (c *Client) cachePeers(conversationID int64, peerIDs []int64) cachedPeers {
+	cached := cachedPeers{ids: peerIDs, fetchedAt: time.Now()}
+	c.peers[conversationID] = cached
+	return cached
 }
 
+// requestResume asks the write pump to replay the events after lastSeq. A request made while
+// another one is pending is dropped, since the pending replay covers it.
+func (c *Client) requestResume(lastSeq int64) {
+	if lastSeq < 0 {
+		writeError(c, "seq must not be negative")
+		return
+	}
+	select {
+	case c.resume <- lastSeq:
+	default:
+	}
+}
+
+// WritePump owns writes to the connection: queued frames, resume replays and pings.
 func (c *Client) WritePump() {
+	ticker := time.NewTicker(pingInterval)
 	defer func() {
+		ticker.Stop()
 		_ = c.conn.Close()
 	}()
 
-	for payload := range c.send {
-		if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
-			return
+	for {
+		select {
+		case payload, ok := <-c.send:
+			if !ok {
+				// The hub dropped the client; tell it to reconnect and resume.
+				_ = c.conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "reconnect and resume"),
+					time.Now().Add(writeWait),
+				)
+				return
+			}
+			if err := c.write(payload); err != nil {
+				return
+			}
+		case lastSeq := <-c.resume:
+			ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+			frames, err := c.resumeFrames(ctx, lastSeq)
+			cancel()
+			if err != nil {
+				log.Printf("chat resume for user %s: %v", c.userID, err)
+				writeError(c, "failed to resume")
+				continue
+			}
+			for _, frame := range frames {
+				if err := c.write(frame); err != nil {
+					return
+				}
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
 		}
 	}
 }
 
+func (c *Client) write(payload []byte) error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(websocket.TextMessage, payload)
+}
+
 func writeError(client *Client, message string) {
 	payload, err := json.Marshal(Message{
-		Type:      "error",
+		Version:   ProtocolVersion,
+		Type:      TypeError,
 		Content:   message,
 		Timestamp: services.FormatChatTimestamp(time.Now().UTC()),
 	})
 	if err != nil {
 		return
 	}
-	select {
-	case client.send <- payload:
-	default:
-		client.hub.Unregister(client)
-	}
+	client.queue(payload)
 }
@@ -0,0 +1,281 @@
+package chatws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/models"
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+func newTestClient(hub *Hub, userID string, watching ...string) *Client {
+	client := NewClient(hub, nil, userID)
+	client.send = make(chan []byte, 16)
+	client.Watch(watching...)
+	hub.Register(client)
+	return client
+}
+
+// receive waits for the next envelope of the given type, skipping others such as presence updates.
+func receive(t *testing.T, client *Client, messageType string) Message {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case payload := <-client.send:
+			var message Message
+			if err := json.Unmarshal(payload, &message); err != nil {
+				t.Fatalf("decode %s: %v", payload, err)
+			}
+			if message.Type == messageType {
+				return message
+			}
+		case <-timeout:
+			t.Fatalf("client %s received no %s", client.userID, messageType)
+		}
+	}
+}
+
+func expectNone(t *testing.T, client *Client, messageType string) {
+	t.Helper()
+	timeout := time.After(100 * time.Millisecond)
+	for {
+		select {
+		case payload := <-client.send:
+			var message Message
+			_ = json.Unmarshal(payload, &message)
+			if message.Type == messageType {
+				t.Fatalf("client %s unexpectedly received %s", client.userID, payload)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func TestHubDeliversMessagesToSenderAndRecipient(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), nil)
+	go hub.Run()
+
+	sender := newTestClient(hub, "7")
+	recipient := newTestClient(hub, "8")
+	bystander := newTestClient(hub, "9")
+
+	if err := hub.deliver(&Message{Type: TypeMessage, SenderID: "7", RecipientID: "8", Content: "hi"}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+
+	for _, client := range []*Client{sender, recipient} {
+		message := receive(t, client, TypeMessage)
+		if message.Content != "hi" || message.Version != ProtocolVersion {
+			t.Fatalf("unexpected message for %s: %+v", client.userID, message)
+		}
+	}
+	expectNone(t, bystander, TypeMessage)
+}
+
+func TestHubSendsTypingOnlyToRecipient(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), nil)
+	go hub.Run()
+
+	sender := newTestClient(hub, "7")
+	recipient := newTestClient(hub, "8")
+
+	if err := hub.deliver(&Message{Type: TypeTyping, SenderID: "7", RecipientID: "8", State: "start"}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+
+	if message := receive(t, recipient, TypeTyping); message.State != "start" || message.SenderID != "7" {
+		t.Fatalf("unexpected typing event: %+v", message)
+	}
+	expectNone(t, sender, TypeTyping)
+}
+
+func TestHubTracksPresenceOfWatchedUsers(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), nil)
+	go hub.Run()
+
+	coach := newTestClient(hub, "7", "8")
+	if snapshot := receive(t, coach, TypePresence); snapshot.UserID != "8" || *snapshot.Online {
+		t.Fatalf("expected client 8 to start offline, got %+v", snapshot)
+	}
+
+	client := newTestClient(hub, "8")
+	if update := receive(t, coach, TypePresence); update.UserID != "8" || !*update.Online {
+		t.Fatalf("expected client 8 online, got %+v", update)
+	}
+
+	hub.Unregister(client)
+	update := receive(t, coach, TypePresence)
+	if *update.Online || update.LastSeenAt == "" {
+		t.Fatalf("expected client 8 offline with last seen, got %+v", update)
+	}
+}
+
+func TestExpirePresenceDropsSilentInstances(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), nil)
+	now := time.Now().UTC()
+	hub.applyPresence(&Message{Type: typePresenceOnline, Instance: "other", UserIDs: []string{"8"}}, now.Add(-presenceTTL-time.Second))
+
+	hub.expirePresence(now)
+
+	p := hub.presence["8"]
+	if p.online() || p.lastSeen.IsZero() {
+		t.Fatalf("expected user 8 offline after the instance went silent, got %+v", p)
+	}
+}
+
+func TestDeliverAttachmentCarriesMetadata(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), nil)
+	go hub.Run()
+
+	recipient := newTestClient(hub, "8")
+	bystander := newTestClient(hub, "9")
+
+	err := hub.DeliverAttachment(&services.ChatDelivery{
+		Message: &models.ChatMessage{
+			ID:             30,
+			ConversationID: 11,
+			SenderID:       7,
+			Content:        "Depth check",
+			CreatedAt:      time.Now().UTC(),
+			Attachments: []models.MessageAttachment{{
+				ID:          4,
+				Kind:        "video",
+				Filename:    "squat.mp4",
+				ContentType: "video/mp4",
+				SizeBytes:   2048,
+				URL:         "https://storage.example/signed",
+			}},
+		},
+		RecipientIDs: []int64{8},
+	})
+	if err != nil {
+		t.Fatalf("DeliverAttachment: %v", err)
+	}
+
+	frame := receive(t, recipient, TypeAttachment)
+	if frame.MessageID != "30" || frame.Attachment == nil || frame.Attachment.Kind != "video" || frame.Attachment.URL == "" {
+		t.Fatalf("unexpected attachment frame: %+v", frame)
+	}
+	expectNone(t, bystander, TypeAttachment)
+}
+
+func TestDeliverReactionAndDeletion(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), nil)
+	go hub.Run()
+
+	sender := newTestClient(hub, "7")
+	recipient := newTestClient(hub, "8")
+
+	if err := hub.DeliverReaction(&services.ReactionChange{
+		ConversationID: 11,
+		MessageID:      30,
+		UserID:         8,
+		RecipientIDs:   []int64{7},
+		Emoji:          "🔥",
+		At:             time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("DeliverReaction: %v", err)
+	}
+	for _, client := range []*Client{sender, recipient} {
+		frame := receive(t, client, TypeReaction)
+		if frame.MessageID != "30" || frame.SenderID != "8" || frame.Emoji != "🔥" || frame.State != ReactionAdded {
+			t.Fatalf("unexpected reaction frame for %s: %+v", client.userID, frame)
+		}
+	}
+
+	if err := hub.DeliverReaction(&services.ReactionChange{ConversationID: 11, MessageID: 30, UserID: 8, RecipientIDs: []int64{7}}); err != nil {
+		t.Fatalf("DeliverReaction: %v", err)
+	}
+	if frame := receive(t, sender, TypeReaction); frame.State != ReactionRemoved || frame.Emoji != "" {
+		t.Fatalf("unexpected reaction removal: %+v", frame)
+	}
+
+	deletedAt := time.Now().UTC()
+	if err := hub.DeliverDeletion(&services.ChatDelivery{
+		Message:      &models.ChatMessage{ID: 30, ConversationID: 11, SenderID: 7, Content: "", DeletedAt: &deletedAt},
+		RecipientIDs: []int64{8},
+	}); err != nil {
+		t.Fatalf("DeliverDeletion: %v", err)
+	}
+	if frame := receive(t, recipient, TypeMessageDeleted); frame.MessageID != "30" || frame.Content != "" {
+		t.Fatalf("unexpected deletion frame: %+v", frame)
+	}
+}
+
+func TestHubFansGroupFramesOutToMembers(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), nil)
+	go hub.Run()
+
+	coach := newTestClient(hub, "7")
+	members := []*Client{newTestClient(hub, "8"), newTestClient(hub, "9")}
+	outsider := newTestClient(hub, "10")
+
+	if err := hub.DeliverEdit(&services.ChatDelivery{
+		Message:      &models.ChatMessage{ID: 30, ConversationID: 12, SenderID: 7, Content: "Week 2 starts Monday", CreatedAt: time.Now().UTC()},
+		RecipientIDs: []int64{8, 9},
+	}); err != nil {
+		t.Fatalf("DeliverEdit: %v", err)
+	}
+	for _, client := range append(members, coach) {
+		frame := receive(t, client, TypeMessageEdited)
+		if frame.RecipientID != "" || len(frame.Members) != 0 {
+			t.Fatalf("group frame for %s leaked addressing: %+v", client.userID, frame)
+		}
+	}
+	expectNone(t, outsider, TypeMessageEdited)
+
+	if err := hub.deliver(addressTo(&Message{Type: TypeTyping, ConversationID: "12", SenderID: "8", State: "start"}, []int64{7, 9})); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	receive(t, coach, TypeTyping)
+	receive(t, members[1], TypeTyping)
+	expectNone(t, members[0], TypeTyping)
+}
+
+func TestDeliverMembershipTellsRemovedMember(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), nil)
+	go hub.Run()
+
+	coach := newTestClient(hub, "7")
+	removed := newTestClient(hub, "8")
+	remaining := newTestClient(hub, "9")
+
+	if err := hub.DeliverMembership(&services.MembershipChange{
+		ConversationID: 12,
+		ActorID:        7,
+		UserIDs:        []int64{8},
+		RecipientIDs:   []int64{9, 8},
+		At:             time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("DeliverMembership: %v", err)
+	}
+	for _, client := range []*Client{coach, removed, remaining} {
+		frame := receive(t, client, TypeMemberLeft)
+		if frame.ConversationID != "12" || frame.UserID != "8" || frame.SenderID != "7" {
+			t.Fatalf("unexpected member_left frame for %s: %+v", client.userID, frame)
+		}
+	}
+}
+
+func TestDeliverMessageReachesRecipient(t *testing.T) {
+	hub := NewHub(NewMemoryBroker(), nil)
+	go hub.Run()
+
+	coach := newTestClient(hub, "7")
+	client := newTestClient(hub, "8")
+
+	if err := hub.DeliverMessage(&services.ChatDelivery{
+		Message:      &models.ChatMessage{ID: 31, ConversationID: 11, SenderID: 7, Content: "Gym closed Friday", CreatedAt: time.Now().UTC()},
+		RecipientIDs: []int64{8},
+	}); err != nil {
+		t.Fatalf("DeliverMessage: %v", err)
+	}
+	for _, c := range []*Client{coach, client} {
+		if frame := receive(t, c, TypeMessage); frame.MessageID != "31" || frame.Content != "Gym closed Friday" {
+			t.Fatalf("unexpected message frame for %s: %+v", c.userID, frame)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package chatws
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/saeid-a/CoachAppBack/internal/services"
+)
+
+// Hub-to-hub presence types; they are never sent to clients.
+const (
+	typePresenceOnline  = "presence_online"
+	typePresenceOffline = "presence_offline"
+	typePresenceSync    = "presence_sync"
+)
+
+const (
+	presenceHeartbeatInterval = 30 * time.Second
+	// An instance that misses three heartbeats is presumed gone, along with its clients.
+	presenceTTL = 3 * presenceHeartbeatInterval
+)
+
+// userPresence records which instances a user is connected to, as last reported by each of them.
+type userPresence struct {
+	instances map[string]time.Time
+	lastSeen  time.Time
+}
+
+func (p *userPresence) online() bool {
+	return len(p.instances) > 0
+}
+
+func (h *Hub) presenceFor(userID string) *userPresence {
+	p, ok := h.presence[userID]
+	if !ok {
+		p = &userPresence{instances: make(map[string]time.Time)}
+		h.presence[userID] = p
+	}
+	return p
+}
+
+func (h *Hub) applyPresence(message *Message, now time.Time) {
+	switch message.Type {
+	case typePresenceOnline:
+		for _, userID := range message.UserIDs {
+			p := h.presenceFor(userID)
+			wasOnline := p.online()
+			p.instances[message.Instance] = now
+			if !wasOnline {
+				h.notifyWatchers(userID)
+			}
+		}
+	case typePresenceOffline:
+		p := h.presenceFor(message.UserID)
+		wasOnline := p.online()
+		delete(p.instances, message.Instance)
+		if lastSeen, err := time.Parse(time.RFC3339, message.LastSeenAt); err == nil && lastSeen.After(p.lastSeen) {
+			p.lastSeen = lastSeen
+		}
+		if wasOnline && !p.online() {
+			h.notifyWatchers(message.UserID)
+		}
+	case typePresenceSync:
+		// A new instance asks everyone for their connected users.
+		if message.Instance != h.instanceID {
+			h.announcePresence()
+		}
+	}
+}
+
+// announcePresence reports every locally connected user; it doubles as the heartbeat.
+func (h *Hub) announcePresence() {
+	if len(h.clients) == 0 {
+		return
+	}
+	userIDs := make([]string, 0, len(h.clients))
+	for userID := range h.clients {
+		userIDs = append(userIDs, userID)
+	}
+	h.enqueue(&Message{Type: typePresenceOnline, Instance: h.instanceID, UserIDs: userIDs})
+}
+
+func (h *Hub) expirePresence(now time.Time) {
+	for userID, p := range h.presence {
+		wasOnline := p.online()
+		for instance, reportedAt := range p.instances {
+			if now.Sub(reportedAt) > presenceTTL {
+				delete(p.instances, instance)
+				if reportedAt.After(p.lastSeen) {
+					p.lastSeen = reportedAt
+				}
+			}
+		}
+		if wasOnline && !p.online() {
+			h.notifyWatchers(userID)
+		}
+	}
+}
+
+func (h *Hub) notifyWatchers(userID string) {
+	for _, set := range h.clients {
+		for client := range set {
+			if _, ok := client.watching[userID]; ok {
+				h.sendPresence(client, userID)
+			}
+		}
+	}
+}
+
+func (h *Hub) sendPresence(client *Client, userID string) {
+	online := false
+	message := Message{
+		Version:   ProtocolVersion,
+		Type:      TypePresence,
+		UserID:    userID,
+		Online:    &online,
+		Timestamp: services.FormatChatTimestamp(time.Now().UTC()),
+	}
+	if p, ok := h.presence[userID]; ok {
+		online = p.online()
+		if !online && !p.lastSeen.IsZero() {
+			message.LastSeenAt = services.FormatChatTimestamp(p.lastSeen)
+		}
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("chat hub encode presence: %v", err)
+		return
+	}
+	h.sendToClient(client, payload)
+}
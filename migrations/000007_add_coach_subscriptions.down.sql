@@ -0,0 +1,18 @@
+DROP INDEX IF EXISTS idx_payments_subscription_id;
+
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS subscription_id;
+
+DROP TABLE IF EXISTS payment_gateway_events;
+
+DROP INDEX IF EXISTS idx_subscription_plan_changes_subscription_id;
+DROP TABLE IF EXISTS subscription_plan_changes;
+
+DROP INDEX IF EXISTS idx_subscriptions_coach_id;
+DROP INDEX IF EXISTS idx_subscriptions_user_coach_open;
+DROP TABLE IF EXISTS subscriptions;
+
+DROP TABLE IF EXISTS coach_subscription_settings;
+
+DROP INDEX IF EXISTS idx_subscription_plans_coach_id;
+DROP TABLE IF EXISTS subscription_plans;
@@ -0,0 +1,69 @@
+CREATE TABLE subscription_plans (
+    id                       BIGSERIAL PRIMARY KEY,
+    coach_id                 BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name                     VARCHAR(100) NOT NULL,
+    description              TEXT,
+    monthly_price            DECIMAL(10,2) NOT NULL CHECK (monthly_price >= 0),
+    sessions_per_month       INT NOT NULL DEFAULT 0 CHECK (sessions_per_month >= 0),
+    includes_chat            BOOLEAN NOT NULL DEFAULT TRUE,
+    includes_program_updates BOOLEAN NOT NULL DEFAULT TRUE,
+    trial_days               INT NOT NULL DEFAULT 0 CHECK (trial_days >= 0),
+    is_active                BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at               TIMESTAMP DEFAULT NOW(),
+    updated_at               TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_subscription_plans_coach_id ON subscription_plans(coach_id);
+
+CREATE TABLE coach_subscription_settings (
+    coach_id              BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    requires_subscription BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at            TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE subscriptions (
+    id                      BIGSERIAL PRIMARY KEY,
+    user_id                 BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    coach_id                BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan_id                 BIGINT NOT NULL REFERENCES subscription_plans(id),
+    status                  VARCHAR(20) NOT NULL
+                            CHECK (status IN ('trialing', 'active', 'past_due', 'cancelled')),
+    gateway_subscription_id VARCHAR(255) UNIQUE,
+    current_period_start    TIMESTAMP NOT NULL,
+    current_period_end      TIMESTAMP NOT NULL,
+    cancel_at_period_end    BOOLEAN NOT NULL DEFAULT FALSE,
+    cancelled_at            TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT NOW(),
+    updated_at              TIMESTAMP DEFAULT NOW(),
+    CHECK (current_period_end > current_period_start)
+);
+
+CREATE UNIQUE INDEX idx_subscriptions_user_coach_open
+    ON subscriptions(user_id, coach_id)
+    WHERE status <> 'cancelled';
+CREATE INDEX idx_subscriptions_coach_id ON subscriptions(coach_id);
+
+CREATE TABLE subscription_plan_changes (
+    id               BIGSERIAL PRIMARY KEY,
+    subscription_id  BIGINT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    from_plan_id     BIGINT NOT NULL REFERENCES subscription_plans(id),
+    to_plan_id       BIGINT NOT NULL REFERENCES subscription_plans(id),
+    proration_amount DECIMAL(10,2) NOT NULL,
+    created_at       TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_subscription_plan_changes_subscription_id
+    ON subscription_plan_changes(subscription_id);
+
+CREATE TABLE payment_gateway_events (
+    id           BIGSERIAL PRIMARY KEY,
+    event_id     VARCHAR(255) UNIQUE NOT NULL,
+    event_type   VARCHAR(100) NOT NULL,
+    payload      JSONB NOT NULL,
+    processed_at TIMESTAMP DEFAULT NOW()
+);
+
+ALTER TABLE payments
+    ADD COLUMN subscription_id BIGINT REFERENCES subscriptions(id);
+
+CREATE INDEX idx_payments_subscription_id ON payments(subscription_id);
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS invoices_immutable ON invoices;
+DROP FUNCTION IF EXISTS prevent_invoice_update();
+
+DROP INDEX IF EXISTS idx_invoices_user_id;
+DROP TABLE IF EXISTS invoices;
+
+DROP TABLE IF EXISTS invoice_sequences;
+DROP TABLE IF EXISTS coach_billing_details;
@@ -0,0 +1,58 @@
+CREATE TABLE coach_billing_details (
+    coach_id   BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    legal_name VARCHAR(200),
+    tax_id     VARCHAR(50),
+    address    TEXT,
+    tax_rate   DECIMAL(5,2) NOT NULL DEFAULT 0 CHECK (tax_rate >= 0 AND tax_rate < 100),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE invoice_sequences (
+    coach_id    BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    last_number INT NOT NULL
+);
+
+CREATE TABLE invoices (
+    id              BIGSERIAL PRIMARY KEY,
+    invoice_number  VARCHAR(50) UNIQUE NOT NULL,
+    kind            VARCHAR(20) NOT NULL CHECK (kind IN ('invoice', 'credit_note')),
+    payment_id      BIGINT NOT NULL REFERENCES payments(id),
+    coach_id        BIGINT NOT NULL REFERENCES users(id),
+    user_id         BIGINT NOT NULL REFERENCES users(id),
+    sequence_number INT NOT NULL,
+    description     TEXT NOT NULL,
+    subtotal        DECIMAL(10,2) NOT NULL,
+    tax_rate        DECIMAL(5,2) NOT NULL,
+    tax_amount      DECIMAL(10,2) NOT NULL,
+    total           DECIMAL(10,2) NOT NULL,
+    seller_name     VARCHAR(200) NOT NULL,
+    seller_email    VARCHAR(255) NOT NULL,
+    seller_tax_id   VARCHAR(50),
+    seller_address  TEXT,
+    buyer_name      VARCHAR(200) NOT NULL,
+    buyer_email     VARCHAR(255) NOT NULL,
+    pdf_path        VARCHAR(500),
+    issued_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (coach_id, sequence_number),
+    UNIQUE (payment_id, kind)
+);
+
+CREATE INDEX idx_invoices_user_id ON invoices(user_id);
+
+-- Issued invoices are immutable; only the rendered PDF location may be filled in once.
+CREATE OR REPLACE FUNCTION prevent_invoice_update()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF OLD.pdf_path IS NULL
+        AND (to_jsonb(NEW) - 'pdf_path') = (to_jsonb(OLD) - 'pdf_path') THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'invoice % is immutable', OLD.invoice_number;
+END;
+$$;
+
+CREATE TRIGGER invoices_immutable
+    BEFORE UPDATE ON invoices
+    FOR EACH ROW EXECUTE FUNCTION prevent_invoice_update();
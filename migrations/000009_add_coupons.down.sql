@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_coupon_redemptions_coupon_user;
+DROP TABLE IF EXISTS coupon_redemptions;
+
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS coupon_id,
+    DROP COLUMN IF EXISTS discount_amount,
+    DROP COLUMN IF EXISTS original_amount;
+
+DROP INDEX IF EXISTS idx_coupons_coach_id;
+DROP INDEX IF EXISTS idx_coupons_code;
+DROP TABLE IF EXISTS coupons;
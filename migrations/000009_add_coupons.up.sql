@@ -0,0 +1,37 @@
+CREATE TABLE coupons (
+    id                 BIGSERIAL PRIMARY KEY,
+    code               VARCHAR(50) NOT NULL,
+    coach_id           BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    discount_type      VARCHAR(20) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    discount_value     DECIMAL(10,2) NOT NULL CHECK (discount_value > 0),
+    max_redemptions    INT CHECK (max_redemptions > 0),
+    per_user_limit     INT CHECK (per_user_limit > 0),
+    redemption_count   INT NOT NULL DEFAULT 0 CHECK (redemption_count >= 0),
+    first_session_only BOOLEAN NOT NULL DEFAULT FALSE,
+    valid_from         TIMESTAMP,
+    valid_until        TIMESTAMP,
+    is_active          BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at         TIMESTAMP DEFAULT NOW(),
+    updated_at         TIMESTAMP DEFAULT NOW(),
+    CHECK (discount_type <> 'percent' OR discount_value <= 100),
+    CHECK (max_redemptions IS NULL OR redemption_count <= max_redemptions),
+    CHECK (valid_until IS NULL OR valid_from IS NULL OR valid_until > valid_from)
+);
+
+CREATE UNIQUE INDEX idx_coupons_code ON coupons(UPPER(code));
+CREATE INDEX idx_coupons_coach_id ON coupons(coach_id);
+
+ALTER TABLE payments
+    ADD COLUMN original_amount DECIMAL(10,2),
+    ADD COLUMN discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN coupon_id BIGINT REFERENCES coupons(id);
+
+CREATE TABLE coupon_redemptions (
+    id         BIGSERIAL PRIMARY KEY,
+    coupon_id  BIGINT NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    user_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payment_id BIGINT NOT NULL UNIQUE REFERENCES payments(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_coupon_redemptions_coupon_user ON coupon_redemptions(coupon_id, user_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payments_created_at;
+DROP INDEX IF EXISTS idx_payments_coach_created;
+DROP INDEX IF EXISTS idx_payments_user_created;
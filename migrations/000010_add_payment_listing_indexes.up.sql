@@ -0,0 +1,8 @@
+CREATE INDEX IF NOT EXISTS idx_payments_user_created
+    ON payments (user_id, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_payments_coach_created
+    ON payments (coach_id, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_payments_created_at
+    ON payments (created_at);
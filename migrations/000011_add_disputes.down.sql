@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS ledger_adjustments;
+DROP TABLE IF EXISTS disputes;
+
+ALTER TABLE payments
+    DROP CONSTRAINT IF EXISTS payments_status_check;
+
+UPDATE payments
+SET status = 'paid'
+WHERE status = 'disputed';
+
+UPDATE payments
+SET status = 'refunded'
+WHERE status = 'charged_back';
+
+ALTER TABLE payments
+    ADD CONSTRAINT payments_status_check
+        CHECK (status IN ('placeholder', 'paid', 'refunded'));
@@ -0,0 +1,42 @@
+ALTER TABLE payments
+    DROP CONSTRAINT IF EXISTS payments_status_check;
+
+ALTER TABLE payments
+    ADD CONSTRAINT payments_status_check
+        CHECK (status IN ('placeholder', 'paid', 'refunded', 'disputed', 'charged_back'));
+
+CREATE TABLE disputes (
+    id                    BIGSERIAL PRIMARY KEY,
+    gateway_dispute_id    VARCHAR(255) UNIQUE NOT NULL,
+    payment_id            BIGINT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    user_id               BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    coach_id              BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount                DECIMAL(10,2) NOT NULL,
+    reason                VARCHAR(100),
+    status                VARCHAR(20) NOT NULL DEFAULT 'needs_response'
+        CHECK (status IN ('needs_response', 'under_review', 'won', 'lost')),
+    evidence              JSONB,
+    evidence_due_by       TIMESTAMP,
+    evidence_submitted_at TIMESTAMP,
+    closed_at             TIMESTAMP,
+    created_at            TIMESTAMP DEFAULT NOW(),
+    updated_at            TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_disputes_payment_id ON disputes(payment_id);
+CREATE INDEX idx_disputes_user_id ON disputes(user_id, created_at DESC);
+CREATE INDEX idx_disputes_coach_id ON disputes(coach_id, created_at DESC);
+
+-- Signed adjustments to a coach's earnings that are not represented by a payment row.
+CREATE TABLE ledger_adjustments (
+    id         BIGSERIAL PRIMARY KEY,
+    coach_id   BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payment_id BIGINT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    dispute_id BIGINT REFERENCES disputes(id) ON DELETE CASCADE,
+    kind       VARCHAR(30) NOT NULL CHECK (kind IN ('chargeback', 'dispute_fee')),
+    amount     DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (dispute_id, kind)
+);
+
+CREATE INDEX idx_ledger_adjustments_coach_id ON ledger_adjustments(coach_id, created_at DESC);
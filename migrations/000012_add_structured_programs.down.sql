@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS program_exercises;
+DROP TABLE IF EXISTS program_days;
+DROP TABLE IF EXISTS program_weeks;
+DROP TABLE IF EXISTS program_phases;
+
+ALTER TABLE workout_programs
+    DROP COLUMN IF EXISTS updated_at;
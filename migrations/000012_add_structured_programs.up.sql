@@ -0,0 +1,47 @@
+ALTER TABLE workout_programs
+    ADD COLUMN updated_at TIMESTAMP DEFAULT NOW();
+
+UPDATE workout_programs
+SET updated_at = created_at;
+
+CREATE TABLE program_phases (
+    id         BIGSERIAL PRIMARY KEY,
+    program_id BIGINT NOT NULL REFERENCES workout_programs(id) ON DELETE CASCADE,
+    position   INT NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    notes      TEXT,
+    UNIQUE (program_id, position)
+);
+
+CREATE TABLE program_weeks (
+    id          BIGSERIAL PRIMARY KEY,
+    phase_id    BIGINT NOT NULL REFERENCES program_phases(id) ON DELETE CASCADE,
+    week_number INT NOT NULL CHECK (week_number > 0),
+    notes       TEXT,
+    UNIQUE (phase_id, week_number)
+);
+
+CREATE TABLE program_days (
+    id         BIGSERIAL PRIMARY KEY,
+    week_id    BIGINT NOT NULL REFERENCES program_weeks(id) ON DELETE CASCADE,
+    day_number INT NOT NULL CHECK (day_number BETWEEN 1 AND 7),
+    name       VARCHAR(100),
+    notes      TEXT,
+    UNIQUE (week_id, day_number)
+);
+
+CREATE TABLE program_exercises (
+    id             BIGSERIAL PRIMARY KEY,
+    day_id         BIGINT NOT NULL REFERENCES program_days(id) ON DELETE CASCADE,
+    position       INT NOT NULL,
+    name           VARCHAR(150) NOT NULL,
+    superset_group VARCHAR(10),
+    sets           INT CHECK (sets > 0),
+    reps           VARCHAR(20),
+    weight_kg      DECIMAL(6,2) CHECK (weight_kg >= 0),
+    tempo          VARCHAR(20),
+    rest_seconds   INT CHECK (rest_seconds >= 0),
+    rpe            DECIMAL(3,1) CHECK (rpe BETWEEN 1 AND 10),
+    notes          TEXT,
+    UNIQUE (day_id, position)
+);
@@ -0,0 +1,6 @@
+ALTER TABLE program_exercises
+    DROP COLUMN IF EXISTS exercise_id;
+
+DROP TRIGGER IF EXISTS trg_refresh_exercise_search_vector ON exercises;
+DROP FUNCTION IF EXISTS refresh_exercise_search_vector();
+DROP TABLE IF EXISTS exercises;
@@ -0,0 +1,43 @@
+-- coach_id is NULL for the shared catalog and set for a coach's private exercises.
+CREATE TABLE exercises (
+    id            BIGSERIAL PRIMARY KEY,
+    coach_id      BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    name          VARCHAR(150) NOT NULL,
+    aliases       TEXT[] NOT NULL DEFAULT '{}',
+    muscle_groups TEXT[] NOT NULL DEFAULT '{}',
+    equipment     TEXT[] NOT NULL DEFAULT '{}',
+    difficulty    VARCHAR(20) NOT NULL DEFAULT 'beginner'
+                  CHECK (difficulty IN ('beginner', 'intermediate', 'advanced')),
+    instructions  TEXT,
+    media_url     TEXT,
+    search_vector TSVECTOR NOT NULL DEFAULT ''::tsvector,
+    created_at    TIMESTAMP DEFAULT NOW(),
+    updated_at    TIMESTAMP DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_exercises_catalog_name ON exercises (LOWER(name)) WHERE coach_id IS NULL;
+CREATE UNIQUE INDEX idx_exercises_coach_name ON exercises (coach_id, LOWER(name)) WHERE coach_id IS NOT NULL;
+CREATE INDEX idx_exercises_search_vector ON exercises USING GIN (search_vector);
+CREATE INDEX idx_exercises_muscle_groups ON exercises USING GIN (muscle_groups);
+
+CREATE OR REPLACE FUNCTION refresh_exercise_search_vector()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('english', NEW.name), 'A') ||
+        setweight(to_tsvector('english', array_to_string(NEW.aliases, ' ')), 'A') ||
+        setweight(to_tsvector('english', array_to_string(NEW.muscle_groups || NEW.equipment, ' ')), 'B') ||
+        setweight(to_tsvector('english', COALESCE(NEW.instructions, '')), 'C');
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER trg_refresh_exercise_search_vector
+BEFORE INSERT OR UPDATE OF name, aliases, muscle_groups, equipment, instructions ON exercises
+FOR EACH ROW
+EXECUTE FUNCTION refresh_exercise_search_vector();
+
+ALTER TABLE program_exercises
+    ADD COLUMN exercise_id BIGINT REFERENCES exercises(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS workout_log_sets;
+DROP TABLE IF EXISTS workout_logs;
@@ -0,0 +1,35 @@
+-- Logs reference the program day by its natural key rather than program_days.id, because
+-- replacing a program's structure recreates its day rows.
+CREATE TABLE workout_logs (
+    id               BIGSERIAL PRIMARY KEY,
+    program_id       BIGINT NOT NULL REFERENCES workout_programs(id) ON DELETE CASCADE,
+    user_id          BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    coach_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    phase_position   INT NOT NULL CHECK (phase_position > 0),
+    week_number      INT NOT NULL CHECK (week_number > 0),
+    day_number       INT NOT NULL CHECK (day_number BETWEEN 1 AND 7),
+    performed_at     TIMESTAMP NOT NULL,
+    duration_minutes INT CHECK (duration_minutes > 0),
+    session_rpe      DECIMAL(3,1) CHECK (session_rpe BETWEEN 1 AND 10),
+    notes            TEXT,
+    created_at       TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_workout_logs_user_performed_at ON workout_logs (user_id, performed_at DESC);
+CREATE INDEX idx_workout_logs_program_performed_at ON workout_logs (program_id, performed_at DESC);
+
+CREATE TABLE workout_log_sets (
+    id            BIGSERIAL PRIMARY KEY,
+    log_id        BIGINT NOT NULL REFERENCES workout_logs(id) ON DELETE CASCADE,
+    position      INT NOT NULL,
+    exercise_id   BIGINT REFERENCES exercises(id) ON DELETE SET NULL,
+    exercise_name VARCHAR(150) NOT NULL,
+    set_number    INT NOT NULL CHECK (set_number > 0),
+    reps          INT NOT NULL CHECK (reps >= 0),
+    weight_kg     DECIMAL(6,2) CHECK (weight_kg >= 0),
+    rpe           DECIMAL(3,1) CHECK (rpe BETWEEN 1 AND 10),
+    notes         TEXT,
+    UNIQUE (log_id, position)
+);
+
+CREATE INDEX idx_workout_log_sets_exercise_id ON workout_log_sets (exercise_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS progress_photos;
+DROP TABLE IF EXISTS body_measurements;
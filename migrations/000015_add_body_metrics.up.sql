@@ -0,0 +1,42 @@
+CREATE TABLE body_measurements (
+    id                 BIGSERIAL PRIMARY KEY,
+    user_id            BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    measured_at        TIMESTAMP NOT NULL,
+    weight_kg          DECIMAL(5,2) CHECK (weight_kg > 0),
+    body_fat_pct       DECIMAL(4,1) CHECK (body_fat_pct > 0 AND body_fat_pct < 100),
+    chest_cm           DECIMAL(5,1) CHECK (chest_cm > 0),
+    waist_cm           DECIMAL(5,1) CHECK (waist_cm > 0),
+    hips_cm            DECIMAL(5,1) CHECK (hips_cm > 0),
+    arm_cm             DECIMAL(5,1) CHECK (arm_cm > 0),
+    thigh_cm           DECIMAL(5,1) CHECK (thigh_cm > 0),
+    resting_heart_rate INT CHECK (resting_heart_rate > 0),
+    notes              TEXT,
+    visibility         VARCHAR(20) NOT NULL DEFAULT 'coaches'
+                       CHECK (visibility IN ('coaches', 'private')),
+    created_at         TIMESTAMP DEFAULT NOW(),
+    updated_at         TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_body_measurements_user_measured_at ON body_measurements (user_id, measured_at DESC);
+
+-- file_path holds the storage location; clients only ever receive short-lived signed URLs.
+CREATE TABLE progress_photos (
+    id             BIGSERIAL PRIMARY KEY,
+    user_id        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    measurement_id BIGINT REFERENCES body_measurements(id) ON DELETE SET NULL,
+    file_path      VARCHAR(500) NOT NULL,
+    pose           VARCHAR(20) NOT NULL DEFAULT 'other'
+                   CHECK (pose IN ('front', 'side', 'back', 'other')),
+    visibility     VARCHAR(20) NOT NULL DEFAULT 'coaches'
+                   CHECK (visibility IN ('coaches', 'private')),
+    taken_at       TIMESTAMP NOT NULL,
+    created_at     TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_progress_photos_user_taken_at ON progress_photos (user_id, taken_at DESC);
+
+-- Seed the history with the single weight each profile already has.
+INSERT INTO body_measurements (user_id, measured_at, weight_kg)
+SELECT user_id, COALESCE(updated_at, NOW()), weight_kg
+FROM user_profiles
+WHERE weight_kg IS NOT NULL AND weight_kg > 0;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_workout_programs_template_id;
+
+ALTER TABLE workout_programs
+    DROP COLUMN IF EXISTS start_date,
+    DROP COLUMN IF EXISTS template_id;
+
+DROP TABLE IF EXISTS program_templates;
@@ -0,0 +1,19 @@
+-- Templates keep their structure as a JSON snapshot of phases, weeks, days and exercises; it is
+-- expanded into program_phases and friends when a template is assigned to a client.
+CREATE TABLE program_templates (
+    id          BIGSERIAL PRIMARY KEY,
+    coach_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title       VARCHAR(255) NOT NULL,
+    description TEXT,
+    phases      JSONB NOT NULL DEFAULT '[]',
+    created_at  TIMESTAMP DEFAULT NOW(),
+    updated_at  TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_program_templates_coach_id ON program_templates (coach_id, updated_at DESC);
+
+ALTER TABLE workout_programs
+    ADD COLUMN template_id BIGINT REFERENCES program_templates(id) ON DELETE SET NULL,
+    ADD COLUMN start_date DATE;
+
+CREATE INDEX idx_workout_programs_template_id ON workout_programs (template_id);
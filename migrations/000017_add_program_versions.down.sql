@@ -0,0 +1,4 @@
+ALTER TABLE workout_programs
+    DROP COLUMN IF EXISTS current_version;
+
+DROP TABLE IF EXISTS program_versions;
@@ -0,0 +1,78 @@
+-- Every published state of a program is kept as an immutable snapshot. Files of older versions
+-- stay in storage until the program is deleted.
+CREATE TABLE program_versions (
+    id             BIGSERIAL PRIMARY KEY,
+    program_id     BIGINT NOT NULL REFERENCES workout_programs(id) ON DELETE CASCADE,
+    version_number INT NOT NULL CHECK (version_number > 0),
+    title          VARCHAR(255) NOT NULL,
+    description    TEXT,
+    file_url       VARCHAR(500),
+    phases         JSONB NOT NULL DEFAULT '[]',
+    change_note    TEXT,
+    created_by     BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at     TIMESTAMP DEFAULT NOW(),
+    UNIQUE (program_id, version_number)
+);
+
+CREATE INDEX idx_program_versions_program_created ON program_versions (program_id, created_at DESC);
+
+ALTER TABLE workout_programs
+    ADD COLUMN current_version INT NOT NULL DEFAULT 0;
+
+-- Existing programs start at version 1 with their current content.
+INSERT INTO program_versions (
+    program_id, version_number, title, description, file_url, phases, created_by, created_at
+)
+SELECT
+    p.id,
+    1,
+    p.title,
+    p.description,
+    p.file_url,
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'name', ph.name,
+            'notes', ph.notes,
+            'weeks', COALESCE((
+                SELECT jsonb_agg(jsonb_build_object(
+                    'week_number', w.week_number,
+                    'notes', w.notes,
+                    'days', COALESCE((
+                        SELECT jsonb_agg(jsonb_build_object(
+                            'day_number', d.day_number,
+                            'name', d.name,
+                            'notes', d.notes,
+                            'exercises', COALESCE((
+                                SELECT jsonb_agg(jsonb_strip_nulls(jsonb_build_object(
+                                    'exercise_id', e.exercise_id,
+                                    'name', e.name,
+                                    'superset_group', e.superset_group,
+                                    'sets', e.sets,
+                                    'reps', e.reps,
+                                    'weight_kg', e.weight_kg,
+                                    'tempo', e.tempo,
+                                    'rest_seconds', e.rest_seconds,
+                                    'rpe', e.rpe,
+                                    'notes', e.notes
+                                )) ORDER BY e.position)
+                                FROM program_exercises e
+                                WHERE e.day_id = d.id
+                            ), '[]'::jsonb)
+                        ) ORDER BY d.day_number)
+                        FROM program_days d
+                        WHERE d.week_id = w.id
+                    ), '[]'::jsonb)
+                ) ORDER BY w.week_number)
+                FROM program_weeks w
+                WHERE w.phase_id = ph.id
+            ), '[]'::jsonb)
+        ) ORDER BY ph.position)
+        FROM program_phases ph
+        WHERE ph.program_id = p.id
+    ), '[]'::jsonb),
+    p.coach_id,
+    COALESCE(p.updated_at, p.created_at, NOW())
+FROM workout_programs p;
+
+UPDATE workout_programs
+SET current_version = 1;
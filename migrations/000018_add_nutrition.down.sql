@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS meal_logs;
+DROP TABLE IF EXISTS nutrition_plans;
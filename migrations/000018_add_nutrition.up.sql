@@ -0,0 +1,38 @@
+-- Meal templates are stored as a JSON list on the plan; they are guidance only and are not
+-- matched against logged meals.
+CREATE TABLE nutrition_plans (
+    id            BIGSERIAL PRIMARY KEY,
+    coach_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title         VARCHAR(255) NOT NULL,
+    notes         TEXT,
+    calories_kcal INT NOT NULL CHECK (calories_kcal > 0),
+    protein_g     DECIMAL(6,1) NOT NULL CHECK (protein_g >= 0),
+    carbs_g       DECIMAL(6,1) NOT NULL CHECK (carbs_g >= 0),
+    fat_g         DECIMAL(6,1) NOT NULL CHECK (fat_g >= 0),
+    meals         JSONB NOT NULL DEFAULT '[]',
+    start_date    DATE NOT NULL,
+    end_date      DATE CHECK (end_date IS NULL OR end_date >= start_date),
+    created_at    TIMESTAMP DEFAULT NOW(),
+    updated_at    TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_nutrition_plans_user_start ON nutrition_plans (user_id, start_date DESC);
+CREATE INDEX idx_nutrition_plans_coach_user ON nutrition_plans (coach_id, user_id);
+
+CREATE TABLE meal_logs (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    meal          VARCHAR(20) NOT NULL CHECK (meal IN ('breakfast', 'lunch', 'dinner', 'snack')),
+    food_name     VARCHAR(150) NOT NULL,
+    quantity      VARCHAR(50),
+    calories_kcal INT NOT NULL CHECK (calories_kcal >= 0),
+    protein_g     DECIMAL(6,1) NOT NULL DEFAULT 0 CHECK (protein_g >= 0),
+    carbs_g       DECIMAL(6,1) NOT NULL DEFAULT 0 CHECK (carbs_g >= 0),
+    fat_g         DECIMAL(6,1) NOT NULL DEFAULT 0 CHECK (fat_g >= 0),
+    eaten_at      TIMESTAMP NOT NULL,
+    notes         TEXT,
+    created_at    TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_meal_logs_user_eaten ON meal_logs (user_id, eaten_at DESC);
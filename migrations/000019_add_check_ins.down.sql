@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS check_in_submissions;
+DROP TABLE IF EXISTS check_in_schedules;
+DROP TABLE IF EXISTS check_in_forms;
@@ -0,0 +1,45 @@
+-- Questions are stored as a JSON list on the form. Submissions keep a copy of the questions they
+-- answered so later edits to the form do not change how old answers read.
+CREATE TABLE check_in_forms (
+    id          BIGSERIAL PRIMARY KEY,
+    coach_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title       VARCHAR(255) NOT NULL,
+    description TEXT,
+    questions   JSONB NOT NULL DEFAULT '[]',
+    created_at  TIMESTAMP DEFAULT NOW(),
+    updated_at  TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_check_in_forms_coach_id ON check_in_forms (coach_id, updated_at DESC);
+
+CREATE TABLE check_in_schedules (
+    id            BIGSERIAL PRIMARY KEY,
+    form_id       BIGINT NOT NULL REFERENCES check_in_forms(id) ON DELETE CASCADE,
+    coach_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    interval_days INT NOT NULL CHECK (interval_days BETWEEN 1 AND 90),
+    next_due_on   DATE NOT NULL,
+    active        BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at    TIMESTAMP DEFAULT NOW(),
+    updated_at    TIMESTAMP DEFAULT NOW(),
+    UNIQUE (form_id, user_id)
+);
+
+CREATE INDEX idx_check_in_schedules_coach_due ON check_in_schedules (coach_id, next_due_on) WHERE active;
+CREATE INDEX idx_check_in_schedules_user_id ON check_in_schedules (user_id);
+
+CREATE TABLE check_in_submissions (
+    id           BIGSERIAL PRIMARY KEY,
+    schedule_id  BIGINT REFERENCES check_in_schedules(id) ON DELETE SET NULL,
+    form_id      BIGINT REFERENCES check_in_forms(id) ON DELETE SET NULL,
+    coach_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    form_title   VARCHAR(255) NOT NULL,
+    questions    JSONB NOT NULL,
+    answers      JSONB NOT NULL,
+    due_on       DATE NOT NULL,
+    submitted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_check_in_submissions_coach ON check_in_submissions (coach_id, submitted_at DESC);
+CREATE INDEX idx_check_in_submissions_user ON check_in_submissions (user_id, submitted_at DESC);
@@ -0,0 +1,34 @@
+-- Imports are remembered by file hash so the same file is only processed once per user.
+CREATE TABLE activity_imports (
+    id                  BIGSERIAL PRIMARY KEY,
+    user_id             BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename            VARCHAR(255) NOT NULL,
+    file_sha256         CHAR(64) NOT NULL,
+    activities_found    INT NOT NULL DEFAULT 0,
+    activities_imported INT NOT NULL DEFAULT 0,
+    duplicates_skipped  INT NOT NULL DEFAULT 0,
+    created_at          TIMESTAMP DEFAULT NOW(),
+    UNIQUE (user_id, file_sha256)
+);
+
+CREATE INDEX idx_activity_imports_user ON activity_imports (user_id, created_at DESC);
+
+-- The same workout often arrives from several sources (a watch file and a phone export), so
+-- activities of one type starting within a couple of minutes of each other are treated as one.
+CREATE TABLE activities (
+    id               BIGSERIAL PRIMARY KEY,
+    user_id          BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    import_id        BIGINT REFERENCES activity_imports(id) ON DELETE SET NULL,
+    source           VARCHAR(20) NOT NULL,
+    activity_type    VARCHAR(20) NOT NULL,
+    started_at       TIMESTAMP NOT NULL,
+    duration_seconds INT NOT NULL CHECK (duration_seconds >= 0),
+    distance_m       DECIMAL(10,1),
+    avg_heart_rate   INT,
+    max_heart_rate   INT,
+    heart_rate_zones JSONB,
+    created_at       TIMESTAMP DEFAULT NOW(),
+    UNIQUE (user_id, activity_type, started_at)
+);
+
+CREATE INDEX idx_activities_user_started ON activities (user_id, started_at DESC);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_check_in_schedules_relationship_id;
+DROP INDEX IF EXISTS idx_conversations_relationship_id;
+DROP INDEX IF EXISTS idx_workout_programs_relationship_id;
+
+ALTER TABLE check_in_schedules DROP COLUMN IF EXISTS relationship_id;
+ALTER TABLE conversations DROP COLUMN IF EXISTS relationship_id;
+ALTER TABLE workout_programs DROP COLUMN IF EXISTS relationship_id;
+
+DROP TABLE IF EXISTS coaching_relationships;
@@ -0,0 +1,82 @@
+-- A coaching relationship is the explicit link between a coach and a client. Access to a client's
+-- data follows it instead of being derived from bookings and subscriptions, and programs,
+-- conversations and check-ins hang off it, so clients without a booked session can be coached.
+CREATE TABLE coaching_relationships (
+    id         BIGSERIAL PRIMARY KEY,
+    coach_id   BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status     VARCHAR(20) NOT NULL DEFAULT 'active'
+               CHECK (status IN ('active', 'paused', 'ended')),
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ended_at   TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (coach_id, user_id)
+);
+
+CREATE INDEX idx_coaching_relationships_user ON coaching_relationships (user_id, status);
+
+-- Every pair that has booked, subscribed or received a program gets a relationship. Pairs the
+-- previous rules considered active (an open subscription, an upcoming session, or a session
+-- completed in the last 90 days) start active; the rest start ended.
+INSERT INTO coaching_relationships (coach_id, user_id, status, started_at, ended_at)
+SELECT
+    pairs.coach_id,
+    pairs.user_id,
+    CASE WHEN state.active THEN 'active' ELSE 'ended' END,
+    pairs.started_at,
+    CASE WHEN state.active THEN NULL ELSE NOW() END
+FROM (
+    SELECT coach_id, user_id, COALESCE(MIN(created_at), NOW()) AS started_at
+    FROM (
+        SELECT coach_id, user_id, created_at FROM subscriptions
+        UNION ALL
+        SELECT coach_id, user_id, created_at FROM bookings WHERE status <> 'cancelled'
+        UNION ALL
+        SELECT coach_id, user_id, created_at FROM workout_programs
+    ) AS links
+    WHERE coach_id IS NOT NULL AND user_id IS NOT NULL
+    GROUP BY coach_id, user_id
+) AS pairs
+CROSS JOIN LATERAL (
+    SELECT EXISTS (
+        SELECT 1
+        FROM subscriptions
+        WHERE coach_id = pairs.coach_id AND user_id = pairs.user_id
+            AND status IN ('trialing', 'active', 'past_due')
+    ) OR EXISTS (
+        SELECT 1
+        FROM bookings
+        WHERE coach_id = pairs.coach_id AND user_id = pairs.user_id
+            AND (
+                status IN ('pending', 'confirmed')
+                OR (status = 'completed' AND scheduled_at >= NOW() - INTERVAL '90 days')
+            )
+    ) AS active
+) AS state;
+
+ALTER TABLE workout_programs
+    ADD COLUMN relationship_id BIGINT REFERENCES coaching_relationships(id) ON DELETE SET NULL;
+ALTER TABLE conversations
+    ADD COLUMN relationship_id BIGINT REFERENCES coaching_relationships(id) ON DELETE SET NULL;
+ALTER TABLE check_in_schedules
+    ADD COLUMN relationship_id BIGINT REFERENCES coaching_relationships(id) ON DELETE SET NULL;
+
+UPDATE workout_programs p
+SET relationship_id = r.id
+FROM coaching_relationships r
+WHERE r.coach_id = p.coach_id AND r.user_id = p.user_id;
+
+UPDATE conversations c
+SET relationship_id = r.id
+FROM coaching_relationships r
+WHERE r.coach_id = c.coach_id AND r.user_id = c.user_id;
+
+UPDATE check_in_schedules s
+SET relationship_id = r.id
+FROM coaching_relationships r
+WHERE r.coach_id = s.coach_id AND r.user_id = s.user_id;
+
+CREATE INDEX idx_workout_programs_relationship_id ON workout_programs (relationship_id);
+CREATE INDEX idx_conversations_relationship_id ON conversations (relationship_id);
+CREATE INDEX idx_check_in_schedules_relationship_id ON check_in_schedules (relationship_id);
@@ -0,0 +1,16 @@
+-- Files sent in chat. The message content doubles as an optional caption.
+CREATE TABLE message_attachments (
+    id             BIGSERIAL PRIMARY KEY,
+    message_id     BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    kind           VARCHAR(10) NOT NULL CHECK (kind IN ('image', 'video', 'audio', 'file')),
+    file_path      TEXT NOT NULL,
+    thumbnail_path TEXT,
+    filename       VARCHAR(255) NOT NULL,
+    content_type   VARCHAR(100) NOT NULL,
+    size_bytes     BIGINT NOT NULL CHECK (size_bytes > 0),
+    width          INT,
+    height         INT,
+    created_at     TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_message_attachments_message ON message_attachments (message_id);
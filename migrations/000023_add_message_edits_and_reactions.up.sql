@@ -0,0 +1,25 @@
+-- Messages can be edited for a short while after sending and removed at any time. Removed
+-- messages stay as tombstones so replies around them keep their context; their content is
+-- kept for dispute evidence but never returned to participants.
+ALTER TABLE messages
+    ADD COLUMN edited_at  TIMESTAMP,
+    ADD COLUMN deleted_at TIMESTAMP;
+
+-- Each edit keeps the content it replaced.
+CREATE TABLE message_edits (
+    id         BIGSERIAL PRIMARY KEY,
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    content    TEXT NOT NULL,
+    edited_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_message_edits_message ON message_edits (message_id, id);
+
+-- One emoji reaction per participant and message; reacting again replaces it.
+CREATE TABLE message_reactions (
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emoji      VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (message_id, user_id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS chat_events;
+DROP TABLE IF EXISTS chat_event_sequences;
@@ -0,0 +1,18 @@
+-- Every chat event a user receives over the WebSocket is numbered per user, so a client that
+-- reconnects can ask for the events after the last one it saw.
+CREATE TABLE chat_event_sequences (
+    user_id  BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    last_seq BIGINT NOT NULL
+);
+
+-- Events are kept for a week; clients that were away longer reload from the messages API.
+CREATE TABLE chat_events (
+    user_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seq        BIGINT NOT NULL,
+    event_type VARCHAR(30) NOT NULL,
+    payload    JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, seq)
+);
+
+CREATE INDEX idx_chat_events_created_at ON chat_events (created_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_messages_conversation_id_id;
+DROP INDEX IF EXISTS idx_messages_search_vector;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,8 @@
+-- Full-text search over chat messages. Edits refresh the vector automatically.
+ALTER TABLE messages
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', content)) STORED;
+
+CREATE INDEX idx_messages_search_vector ON messages USING GIN (search_vector);
+
+-- History is paged by message id instead of offsets.
+CREATE INDEX idx_messages_conversation_id_id ON messages (conversation_id, id);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS conversation_members;
+
+DELETE FROM conversations WHERE kind = 'group';
+
+ALTER TABLE conversations DROP CONSTRAINT IF EXISTS conversations_kind_user_check;
+ALTER TABLE conversations
+    DROP COLUMN IF EXISTS title,
+    DROP COLUMN IF EXISTS kind,
+    ALTER COLUMN user_id SET NOT NULL;
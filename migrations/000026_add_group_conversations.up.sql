@@ -0,0 +1,29 @@
+-- Conversations are either a direct chat between a coach and one client, or a group a coach runs
+-- for several clients. Groups have no user_id; coach_id is the coach who owns the group.
+ALTER TABLE conversations
+    ADD COLUMN kind VARCHAR(10) NOT NULL DEFAULT 'direct' CHECK (kind IN ('direct', 'group')),
+    ADD COLUMN title VARCHAR(100),
+    ALTER COLUMN user_id DROP NOT NULL;
+
+ALTER TABLE conversations
+    ADD CONSTRAINT conversations_kind_user_check CHECK ((kind = 'direct') = (user_id IS NOT NULL));
+
+-- Every participant, direct or group, has a membership row. Members who leave keep their row with
+-- left_at set. Group reads are tracked per member by the newest message they have read; direct
+-- conversations keep using messages.is_read.
+CREATE TABLE conversation_members (
+    conversation_id      BIGINT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id              BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role                 VARCHAR(10) NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'member')),
+    last_read_message_id BIGINT NOT NULL DEFAULT 0,
+    joined_at            TIMESTAMP NOT NULL DEFAULT NOW(),
+    left_at              TIMESTAMP,
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX idx_conversation_members_user_id ON conversation_members (user_id) WHERE left_at IS NULL;
+
+INSERT INTO conversation_members (conversation_id, user_id, joined_at)
+SELECT id, user_id, COALESCE(created_at, NOW()) FROM conversations
+UNION
+SELECT id, coach_id, COALESCE(created_at, NOW()) FROM conversations;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS chat_broadcast_recipients;
+DROP TABLE IF EXISTS chat_broadcasts;
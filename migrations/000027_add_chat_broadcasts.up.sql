@@ -0,0 +1,40 @@
+-- A broadcast is one message a coach sends to a segment of their clients. The recipients are
+-- resolved when it is created; a background worker then posts the message into each client's
+-- direct conversation and records the outcome per recipient.
+CREATE TABLE chat_broadcasts (
+    id                  BIGSERIAL PRIMARY KEY,
+    coach_id            BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content             TEXT NOT NULL,
+    segment             VARCHAR(20) NOT NULL
+                        CHECK (segment IN ('active_clients', 'session_range', 'program')),
+    sessions_from       TIMESTAMP,
+    sessions_to         TIMESTAMP,
+    program_template_id BIGINT REFERENCES program_templates(id) ON DELETE SET NULL,
+    status              VARCHAR(20) NOT NULL DEFAULT 'pending'
+                        CHECK (status IN ('pending', 'running', 'completed')),
+    total_count         INT NOT NULL DEFAULT 0,
+    sent_count          INT NOT NULL DEFAULT 0,
+    failed_count        INT NOT NULL DEFAULT 0,
+    -- The instance delivering a broadcast holds it until lease_expires_at; another one picks it up
+    -- if that instance stops.
+    lease_expires_at    TIMESTAMP,
+    created_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at          TIMESTAMP,
+    completed_at        TIMESTAMP
+);
+
+CREATE INDEX idx_chat_broadcasts_coach_id ON chat_broadcasts (coach_id, created_at DESC);
+CREATE INDEX idx_chat_broadcasts_unfinished ON chat_broadcasts (id) WHERE status <> 'completed';
+
+CREATE TABLE chat_broadcast_recipients (
+    broadcast_id BIGINT NOT NULL REFERENCES chat_broadcasts(id) ON DELETE CASCADE,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status       VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    message_id   BIGINT REFERENCES messages(id) ON DELETE SET NULL,
+    error        TEXT,
+    processed_at TIMESTAMP,
+    PRIMARY KEY (broadcast_id, user_id)
+);
+
+CREATE INDEX idx_chat_broadcast_recipients_pending
+    ON chat_broadcast_recipients (broadcast_id, user_id) WHERE status = 'pending';
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS user_blocks;
+DROP TABLE IF EXISTS chat_moderation_items;
+
+-- Admin accounts cannot exist under the old role constraint.
+DELETE FROM users WHERE role = 'admin';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'coach'));
@@ -0,0 +1,42 @@
+-- Admins review the moderation queue. They are provisioned by the server, never through sign-up.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'coach', 'admin'));
+
+-- The moderation queue holds reports filed by participants and messages the chat filters caught.
+-- content is a snapshot of the text as sent, since messages can later be masked, edited or removed;
+-- a blocked message was never stored, so its item has no message_id.
+CREATE TABLE chat_moderation_items (
+    id              BIGSERIAL PRIMARY KEY,
+    source          VARCHAR(10) NOT NULL CHECK (source IN ('report', 'filter')),
+    conversation_id BIGINT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    message_id      BIGINT REFERENCES messages(id) ON DELETE SET NULL,
+    sender_id       BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    reporter_id     BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    reason          VARCHAR(30)
+                    CHECK (reason IN ('spam', 'harassment', 'contact_info', 'off_platform_payment', 'inappropriate', 'other')),
+    details         TEXT,
+    content         TEXT NOT NULL DEFAULT '',
+    rules           TEXT[] NOT NULL DEFAULT '{}',
+    action          VARCHAR(10) CHECK (action IN ('flag', 'mask', 'block')),
+    status          VARCHAR(10) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'dismissed')),
+    reviewed_by     BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    review_note     TEXT,
+    reviewed_at     TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_chat_moderation_items_status ON chat_moderation_items (status, created_at);
+-- A participant has at most one open report per message, or per conversation when no message is named.
+CREATE UNIQUE INDEX idx_chat_moderation_items_open_report
+    ON chat_moderation_items (reporter_id, conversation_id, COALESCE(message_id, 0))
+    WHERE source = 'report' AND status = 'open';
+
+CREATE TABLE user_blocks (
+    blocker_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_user_blocks_blocked_id ON user_blocks (blocked_id);
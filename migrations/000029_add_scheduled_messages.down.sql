@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS coach_auto_replies;
+DROP TABLE IF EXISTS chat_scheduled_messages;
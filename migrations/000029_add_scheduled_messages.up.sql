@@ -0,0 +1,46 @@
+-- Scheduled messages are posted into their conversation at send_at by a background worker, as if
+-- the sender had sent them then. Auto-replies go through the same queue: auto_reply_window is the
+-- start of the coach's away window, and a conversation gets at most one auto-reply per window.
+CREATE TABLE chat_scheduled_messages (
+    id                BIGSERIAL PRIMARY KEY,
+    conversation_id   BIGINT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content           TEXT NOT NULL,
+    send_at           TIMESTAMP NOT NULL,
+    kind              VARCHAR(20) NOT NULL DEFAULT 'scheduled' CHECK (kind IN ('scheduled', 'auto_reply')),
+    auto_reply_window TIMESTAMP,
+    status            VARCHAR(10) NOT NULL DEFAULT 'pending'
+                      CHECK (status IN ('pending', 'sent', 'failed', 'cancelled')),
+    message_id        BIGINT REFERENCES messages(id) ON DELETE SET NULL,
+    error             TEXT,
+    lease_expires_at  TIMESTAMP,
+    created_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    processed_at      TIMESTAMP,
+    CHECK ((kind = 'auto_reply') = (auto_reply_window IS NOT NULL))
+);
+
+CREATE INDEX idx_chat_scheduled_messages_sender ON chat_scheduled_messages (sender_id, send_at DESC)
+    WHERE kind = 'scheduled';
+CREATE INDEX idx_chat_scheduled_messages_due ON chat_scheduled_messages (send_at, id)
+    WHERE status = 'pending';
+CREATE UNIQUE INDEX idx_chat_scheduled_messages_auto_reply
+    ON chat_scheduled_messages (conversation_id, auto_reply_window)
+    WHERE kind = 'auto_reply';
+
+-- A coach's auto-reply answers clients who write while the coach is away: outside the working
+-- hours, which are the same on each working day, or during an explicit away period.
+-- working_days holds weekdays with 0 for Sunday; hours are HH:MM in the coach's time zone.
+CREATE TABLE coach_auto_replies (
+    coach_id     BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    enabled      BOOLEAN NOT NULL DEFAULT FALSE,
+    message      TEXT NOT NULL,
+    timezone     TEXT NOT NULL DEFAULT 'UTC',
+    working_days INT[] NOT NULL DEFAULT '{}',
+    work_start   VARCHAR(5) NOT NULL DEFAULT '09:00',
+    work_end     VARCHAR(5) NOT NULL DEFAULT '17:00',
+    away_from    TIMESTAMP,
+    away_until   TIMESTAMP,
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (work_start < work_end),
+    CHECK ((away_from IS NULL) = (away_until IS NULL) AND (away_from IS NULL OR away_until > away_from))
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_payments_subscription_period;
+
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS billing_period_start;
@@ -0,0 +1,8 @@
+ALTER TABLE payments
+    ADD COLUMN billing_period_start TIMESTAMP;
+
+-- Only a subscription's period charges carry the period they pay for; covered bookings and
+-- proration charges leave it empty.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_subscription_period
+    ON payments (subscription_id, billing_period_start)
+    WHERE billing_period_start IS NOT NULL;
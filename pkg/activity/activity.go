@@ -0,0 +1,186 @@
+// Package activity parses workout files from watches and fitness apps into a common shape.
+// It understands GPX, TCX and FIT activity files, Apple Health export.xml files, and zip
+// archives containing any of them (Apple Health exports and Google Takeout Fit archives).
+package activity
+
+import (
+	"errors"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sources identify the file format an activity came from.
+const (
+	SourceGPX         = "gpx"
+	SourceTCX         = "tcx"
+	SourceFIT         = "fit"
+	SourceAppleHealth = "apple_health"
+)
+
+// Types are the normalized activity types.
+const (
+	TypeRun      = "run"
+	TypeRide     = "ride"
+	TypeWalk     = "walk"
+	TypeHike     = "hike"
+	TypeSwim     = "swim"
+	TypeRow      = "row"
+	TypeStrength = "strength"
+	TypeYoga     = "yoga"
+	TypeOther    = "other"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported activity file format")
+	ErrInvalidFile       = errors.New("invalid activity file")
+)
+
+// maxSampleGap caps how long one heart-rate sample counts for, so pauses in recording do not
+// inflate time in zone.
+const maxSampleGap = 30 * time.Second
+
+// HeartRateSample is one heart-rate reading in beats per minute.
+type HeartRateSample struct {
+	Time time.Time
+	BPM  int
+}
+
+// Activity is a workout parsed from a file. DistanceM, AvgHeartRate and MaxHeartRate are zero
+// when the file does not record them.
+type Activity struct {
+	Source       string
+	Type         string
+	StartedAt    time.Time
+	Duration     time.Duration
+	DistanceM    float64
+	AvgHeartRate int
+	MaxHeartRate int
+	HeartRate    []HeartRateSample
+}
+
+// Parse reads every activity in the named file. The format is chosen by file extension.
+func Parse(filename string, r io.ReaderAt, size int64) ([]Activity, error) {
+	var (
+		activities []Activity
+		err        error
+	)
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".gpx":
+		activities, err = parseGPX(io.NewSectionReader(r, 0, size))
+	case ".tcx":
+		activities, err = parseTCX(io.NewSectionReader(r, 0, size))
+	case ".fit":
+		activities, err = parseFIT(io.NewSectionReader(r, 0, size))
+	case ".xml":
+		activities, err = parseAppleHealth(func() (io.ReadCloser, error) {
+			return io.NopCloser(io.NewSectionReader(r, 0, size)), nil
+		})
+	case ".zip":
+		activities, err = parseArchive(r, size)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range activities {
+		activities[i].finish()
+	}
+	sort.SliceStable(activities, func(i, j int) bool {
+		return activities[i].StartedAt.Before(activities[j].StartedAt)
+	})
+	return activities, nil
+}
+
+// HeartRateZones returns the seconds spent in each of five zones, split at 50, 60, 70, 80 and
+// 90 percent of maxHR. Time below zone 1 is not counted. It returns nil without samples.
+func (a Activity) HeartRateZones(maxHR int) []int {
+	if len(a.HeartRate) == 0 || maxHR <= 0 {
+		return nil
+	}
+
+	seconds := make([]float64, 5)
+	for i, sample := range a.HeartRate {
+		span := time.Second
+		if i+1 < len(a.HeartRate) {
+			span = min(a.HeartRate[i+1].Time.Sub(sample.Time), maxSampleGap)
+		}
+		zone := int(float64(sample.BPM)/float64(maxHR)*10) - 5
+		if zone < 0 || span <= 0 {
+			continue
+		}
+		seconds[min(zone, 4)] += span.Seconds()
+	}
+
+	zones := make([]int, 5)
+	for i, value := range seconds {
+		zones[i] = int(math.Round(value))
+	}
+	return zones
+}
+
+// finish sorts the samples and fills in heart-rate figures the file did not summarize.
+func (a *Activity) finish() {
+	a.StartedAt = a.StartedAt.UTC()
+	sort.SliceStable(a.HeartRate, func(i, j int) bool {
+		return a.HeartRate[i].Time.Before(a.HeartRate[j].Time)
+	})
+	if len(a.HeartRate) == 0 {
+		return
+	}
+
+	total, peak := 0, 0
+	for _, sample := range a.HeartRate {
+		total += sample.BPM
+		peak = max(peak, sample.BPM)
+	}
+	if a.AvgHeartRate == 0 {
+		a.AvgHeartRate = int(math.Round(float64(total) / float64(len(a.HeartRate))))
+	}
+	if a.MaxHeartRate == 0 {
+		a.MaxHeartRate = peak
+	}
+}
+
+// NormalizeType maps a sport name from any supported format onto one of the Type constants.
+func NormalizeType(raw string) string {
+	name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(raw), "HKWorkoutActivityType"))
+	switch {
+	case name == "":
+		return TypeOther
+	case strings.Contains(name, "run"):
+		return TypeRun
+	case strings.Contains(name, "cycl"), strings.Contains(name, "bik"), strings.Contains(name, "ride"):
+		return TypeRide
+	case strings.Contains(name, "walk"):
+		return TypeWalk
+	case strings.Contains(name, "hik"):
+		return TypeHike
+	case strings.Contains(name, "swim"):
+		return TypeSwim
+	case strings.Contains(name, "row"):
+		return TypeRow
+	case strings.Contains(name, "strength"), strings.Contains(name, "weight"), strings.Contains(name, "training"):
+		return TypeStrength
+	case strings.Contains(name, "yoga"):
+		return TypeYoga
+	default:
+		return TypeOther
+	}
+}
+
+// haversineMeters is the great-circle distance between two coordinates.
+func haversineMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusM = 6371000
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusM * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,217 @@
+package activity
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testGPX = `<?xml version="1.0"?>
+<gpx xmlns="http://www.topografix.com/GPX/1/1" xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1">
+  <trk>
+    <type>running</type>
+    <trkseg>
+      <trkpt lat="52.0000" lon="4.0000"><time>2030-03-01T07:00:00Z</time>
+        <extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>120</gpxtpx:hr></gpxtpx:TrackPointExtension></extensions></trkpt>
+      <trkpt lat="52.0090" lon="4.0000"><time>2030-03-01T07:05:00Z</time>
+        <extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>160</gpxtpx:hr></gpxtpx:TrackPointExtension></extensions></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+const testTCX = `<?xml version="1.0"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+  <Activities>
+    <Activity Sport="Biking">
+      <Id>2030-03-02T18:00:00Z</Id>
+      <Lap StartTime="2030-03-02T18:00:00Z">
+        <TotalTimeSeconds>1800</TotalTimeSeconds>
+        <DistanceMeters>15000</DistanceMeters>
+        <AverageHeartRateBpm><Value>140</Value></AverageHeartRateBpm>
+        <MaximumHeartRateBpm><Value>171</Value></MaximumHeartRateBpm>
+      </Lap>
+      <Lap StartTime="2030-03-02T18:30:00Z">
+        <TotalTimeSeconds>600</TotalTimeSeconds>
+        <DistanceMeters>4000</DistanceMeters>
+        <AverageHeartRateBpm><Value>120</Value></AverageHeartRateBpm>
+      </Lap>
+    </Activity>
+  </Activities>
+</TrainingCenterDatabase>`
+
+const testAppleExport = `<?xml version="1.0" encoding="UTF-8"?>
+<HealthData locale="en_US">
+  <Record type="HKQuantityTypeIdentifierHeartRate" unit="count/min" startDate="2030-03-03 08:10:00 +0100" endDate="2030-03-03 08:10:00 +0100" value="150"/>
+  <Record type="HKQuantityTypeIdentifierHeartRate" unit="count/min" startDate="2030-03-03 09:10:00 +0100" endDate="2030-03-03 09:10:00 +0100" value="70"/>
+  <Workout workoutActivityType="HKWorkoutActivityTypeWalking" duration="30" durationUnit="min" startDate="2030-03-03 08:00:00 +0100" endDate="2030-03-03 08:30:00 +0100">
+    <WorkoutStatistics type="HKQuantityTypeIdentifierDistanceWalkingRunning" sum="2.5" unit="km"/>
+  </Workout>
+</HealthData>`
+
+func TestParseGPX(t *testing.T) {
+	activities, err := Parse("morning.gpx", bytes.NewReader([]byte(testGPX)), int64(len(testGPX)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(activities) != 1 {
+		t.Fatalf("expected one activity, got %d", len(activities))
+	}
+	run := activities[0]
+	if run.Type != TypeRun || run.Source != SourceGPX || run.Duration != 5*time.Minute {
+		t.Fatalf("unexpected activity: %+v", run)
+	}
+	if run.DistanceM < 990 || run.DistanceM > 1010 {
+		t.Fatalf("expected about 1km, got %.1f", run.DistanceM)
+	}
+	if run.AvgHeartRate != 140 || run.MaxHeartRate != 160 {
+		t.Fatalf("unexpected heart rate: avg %d max %d", run.AvgHeartRate, run.MaxHeartRate)
+	}
+}
+
+func TestParseTCX(t *testing.T) {
+	activities, err := Parse("ride.TCX", bytes.NewReader([]byte(testTCX)), int64(len(testTCX)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	ride := activities[0]
+	if ride.Type != TypeRide || ride.Duration != 40*time.Minute || ride.DistanceM != 19000 {
+		t.Fatalf("unexpected activity: %+v", ride)
+	}
+	if ride.AvgHeartRate != 135 || ride.MaxHeartRate != 171 {
+		t.Fatalf("expected lap-weighted heart rate, got avg %d max %d", ride.AvgHeartRate, ride.MaxHeartRate)
+	}
+}
+
+func TestParseAppleHealthArchive(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"apple_health_export/export.xml":     testAppleExport,
+		"apple_health_export/export_cda.xml": "<ClinicalDocument/>",
+		"Takeout/Fit/Activities/ride.tcx":    testTCX,
+	} {
+		entry, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		_, _ = entry.Write([]byte(content))
+	}
+	archive.Close()
+
+	activities, err := Parse("export.zip", bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(activities) != 2 {
+		t.Fatalf("expected walk and ride, got %+v", activities)
+	}
+	walk := activities[1]
+	if walk.Source != SourceAppleHealth || walk.Type != TypeWalk || walk.Duration != 30*time.Minute {
+		t.Fatalf("unexpected workout: %+v", walk)
+	}
+	if !walk.StartedAt.Equal(time.Date(2030, 3, 3, 7, 0, 0, 0, time.UTC)) || walk.DistanceM != 2500 {
+		t.Fatalf("unexpected workout start or distance: %+v", walk)
+	}
+	if len(walk.HeartRate) != 1 || walk.AvgHeartRate != 150 {
+		t.Fatalf("expected only the in-workout heart rate sample, got %+v", walk.HeartRate)
+	}
+}
+
+func TestParseFIT(t *testing.T) {
+	start := uint32(time.Date(2030, 3, 4, 6, 0, 0, 0, time.UTC).Sub(fitEpoch) / time.Second)
+
+	var data bytes.Buffer
+	// Definition for local type 0: record with timestamp and heart rate.
+	data.Write([]byte{0x40, 0, 0})
+	_ = binary.Write(&data, binary.LittleEndian, uint16(fitMessageRecord))
+	data.Write([]byte{2, fitFieldTimestamp, 4, 0x86, fitFieldRecordHeartRate, 1, 0x02})
+	for i, bpm := range []byte{150, 180, 0xFF} {
+		data.WriteByte(0x00)
+		_ = binary.Write(&data, binary.LittleEndian, start+uint32(i*10))
+		data.WriteByte(bpm)
+	}
+	// Definition for local type 1: session.
+	data.Write([]byte{0x41, 0, 0})
+	_ = binary.Write(&data, binary.LittleEndian, uint16(fitMessageSession))
+	data.Write([]byte{4,
+		fitFieldSessionStartTime, 4, 0x86,
+		fitFieldSessionSport, 1, 0x00,
+		fitFieldSessionElapsed, 4, 0x86,
+		fitFieldSessionDistance, 4, 0x86,
+	})
+	data.WriteByte(0x01)
+	_ = binary.Write(&data, binary.LittleEndian, start)
+	data.WriteByte(1)
+	_ = binary.Write(&data, binary.LittleEndian, uint32(1200*1000))
+	_ = binary.Write(&data, binary.LittleEndian, uint32(400000))
+
+	file := []byte{12, 0x20, 0, 0}
+	file = binary.LittleEndian.AppendUint32(file, uint32(data.Len()))
+	file = append(file, ".FIT"...)
+	file = append(file, data.Bytes()...)
+	file = append(file, 0, 0)
+
+	activities, err := Parse("watch.fit", bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(activities) != 1 {
+		t.Fatalf("expected one session, got %d", len(activities))
+	}
+	run := activities[0]
+	if run.Type != TypeRun || run.Duration != 20*time.Minute || run.DistanceM != 4000 {
+		t.Fatalf("unexpected session: %+v", run)
+	}
+	if len(run.HeartRate) != 2 || run.AvgHeartRate != 165 || run.MaxHeartRate != 180 {
+		t.Fatalf("unexpected heart rate: %+v", run)
+	}
+}
+
+func TestParseRejectsUnknownFiles(t *testing.T) {
+	if _, err := Parse("notes.txt", bytes.NewReader(nil), 0); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("expected unsupported format, got %v", err)
+	}
+	if _, err := Parse("broken.fit", bytes.NewReader([]byte("nope")), 4); !errors.Is(err, ErrInvalidFile) {
+		t.Fatalf("expected invalid file, got %v", err)
+	}
+}
+
+func TestHeartRateZones(t *testing.T) {
+	start := time.Date(2030, 3, 1, 7, 0, 0, 0, time.UTC)
+	activity := Activity{HeartRate: []HeartRateSample{
+		{Time: start, BPM: 90},
+		{Time: start.Add(10 * time.Second), BPM: 125},
+		{Time: start.Add(20 * time.Second), BPM: 175},
+		{Time: start.Add(30 * time.Minute), BPM: 195},
+	}}
+
+	zones := activity.HeartRateZones(200)
+	want := []int{0, 10, 0, 30, 1}
+	for i := range want {
+		if zones[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, zones)
+		}
+	}
+	if (Activity{}).HeartRateZones(200) != nil {
+		t.Fatalf("expected no zones without samples")
+	}
+}
+
+func TestNormalizeType(t *testing.T) {
+	cases := map[string]string{
+		"HKWorkoutActivityTypeRunning":                     TypeRun,
+		"HKWorkoutActivityTypeTraditionalStrengthTraining": TypeStrength,
+		"Biking": TypeRide,
+		"hiking": TypeHike,
+		"Other":  TypeOther,
+		"":       TypeOther,
+	}
+	for raw, want := range cases {
+		if got := NormalizeType(raw); got != want {
+			t.Fatalf("NormalizeType(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
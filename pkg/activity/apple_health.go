@@ -0,0 +1,245 @@
+package activity
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	appleHealthTimeLayout     = "2006-01-02 15:04:05 -0700"
+	appleHeartRateType        = "HKQuantityTypeIdentifierHeartRate"
+	appleHealthExportFilename = "export.xml"
+)
+
+// parseAppleHealth reads an Apple Health export.xml. Workouts come first in one pass; heart-rate
+// records inside workout windows are collected in a second pass, since exports list records
+// before workouts.
+func parseAppleHealth(open func() (io.ReadCloser, error)) ([]Activity, error) {
+	workouts, err := readAppleWorkouts(open)
+	if err != nil {
+		return nil, err
+	}
+	if len(workouts) == 0 {
+		return workouts, nil
+	}
+	if err := attachAppleHeartRate(open, workouts); err != nil {
+		return nil, err
+	}
+	return workouts, nil
+}
+
+func readAppleWorkouts(open func() (io.ReadCloser, error)) ([]Activity, error) {
+	reader, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var workouts []Activity
+	var current *Activity
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			attrs := xmlAttrs(element)
+			switch element.Name.Local {
+			case "Workout":
+				workout, ok := appleWorkout(attrs)
+				if ok {
+					current = &workout
+				}
+			case "WorkoutStatistics":
+				if current != nil {
+					applyAppleWorkoutStatistics(current, attrs)
+				}
+			}
+		case xml.EndElement:
+			if element.Name.Local == "Workout" && current != nil {
+				workouts = append(workouts, *current)
+				current = nil
+			}
+		}
+	}
+	return workouts, nil
+}
+
+func attachAppleHeartRate(open func() (io.ReadCloser, error), workouts []Activity) error {
+	reader, err := open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	sort.SliceStable(workouts, func(i, j int) bool {
+		return workouts[i].StartedAt.Before(workouts[j].StartedAt)
+	})
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+
+		element, ok := token.(xml.StartElement)
+		if !ok || element.Name.Local != "Record" {
+			continue
+		}
+		attrs := xmlAttrs(element)
+		if attrs["type"] != appleHeartRateType {
+			continue
+		}
+		at, err := time.Parse(appleHealthTimeLayout, attrs["startDate"])
+		if err != nil {
+			continue
+		}
+		bpm, err := strconv.ParseFloat(attrs["value"], 64)
+		if err != nil || bpm <= 0 {
+			continue
+		}
+
+		// Find the last workout starting at or before the sample.
+		i := sort.Search(len(workouts), func(i int) bool { return workouts[i].StartedAt.After(at) }) - 1
+		if i >= 0 && !at.After(workouts[i].StartedAt.Add(workouts[i].Duration)) {
+			workouts[i].HeartRate = append(workouts[i].HeartRate, HeartRateSample{Time: at, BPM: int(bpm + 0.5)})
+		}
+	}
+}
+
+func appleWorkout(attrs map[string]string) (Activity, bool) {
+	start, err := time.Parse(appleHealthTimeLayout, attrs["startDate"])
+	if err != nil {
+		return Activity{}, false
+	}
+	workout := Activity{
+		Source:    SourceAppleHealth,
+		Type:      NormalizeType(attrs["workoutActivityType"]),
+		StartedAt: start,
+	}
+
+	if end, err := time.Parse(appleHealthTimeLayout, attrs["endDate"]); err == nil && end.After(start) {
+		workout.Duration = end.Sub(start)
+	}
+	if value, err := strconv.ParseFloat(attrs["duration"], 64); err == nil {
+		workout.Duration = appleDuration(value, attrs["durationUnit"])
+	}
+	if value, err := strconv.ParseFloat(attrs["totalDistance"], 64); err == nil {
+		workout.DistanceM = appleMeters(value, attrs["totalDistanceUnit"])
+	}
+	return workout, true
+}
+
+// applyAppleWorkoutStatistics reads the per-workout totals newer exports nest inside Workout.
+func applyAppleWorkoutStatistics(workout *Activity, attrs map[string]string) {
+	statistic := attrs["type"]
+	switch {
+	case statistic == appleHeartRateType:
+		if value, err := strconv.ParseFloat(attrs["average"], 64); err == nil {
+			workout.AvgHeartRate = int(value + 0.5)
+		}
+		if value, err := strconv.ParseFloat(attrs["maximum"], 64); err == nil {
+			workout.MaxHeartRate = int(value + 0.5)
+		}
+	case strings.HasPrefix(statistic, "HKQuantityTypeIdentifierDistance") && workout.DistanceM == 0:
+		if value, err := strconv.ParseFloat(attrs["sum"], 64); err == nil {
+			workout.DistanceM = appleMeters(value, attrs["unit"])
+		}
+	}
+}
+
+func appleDuration(value float64, unit string) time.Duration {
+	switch unit {
+	case "s":
+		return time.Duration(value * float64(time.Second))
+	case "hr", "h":
+		return time.Duration(value * float64(time.Hour))
+	default:
+		return time.Duration(value * float64(time.Minute))
+	}
+}
+
+func appleMeters(value float64, unit string) float64 {
+	switch unit {
+	case "km":
+		return value * 1000
+	case "mi":
+		return value * 1609.344
+	case "yd":
+		return value * 0.9144
+	default:
+		return value
+	}
+}
+
+func xmlAttrs(element xml.StartElement) map[string]string {
+	attrs := make(map[string]string, len(element.Attr))
+	for _, attr := range element.Attr {
+		attrs[attr.Name.Local] = attr.Value
+	}
+	return attrs
+}
+
+// parseArchive reads every supported file inside a zip archive, such as an Apple Health export
+// or a Google Takeout archive whose Fit folder holds TCX activities.
+func parseArchive(r io.ReaderAt, size int64) ([]Activity, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	var activities []Activity
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		name := strings.ToLower(path.Base(file.Name))
+		var parsed []Activity
+		switch {
+		case name == appleHealthExportFilename:
+			parsed, err = parseAppleHealth(file.Open)
+		case strings.HasSuffix(name, ".gpx"), strings.HasSuffix(name, ".tcx"), strings.HasSuffix(name, ".fit"):
+			parsed, err = parseArchiveEntry(file, name)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		activities = append(activities, parsed...)
+	}
+	return activities, nil
+}
+
+func parseArchiveEntry(file *zip.File, name string) ([]Activity, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	defer reader.Close()
+
+	switch path.Ext(name) {
+	case ".gpx":
+		return parseGPX(reader)
+	case ".tcx":
+		return parseTCX(reader)
+	default:
+		return parseFIT(reader)
+	}
+}
@@ -0,0 +1,254 @@
+package activity
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// FIT global message numbers and field numbers used here. See the Garmin FIT SDK profile.
+const (
+	fitMessageSession = 18
+	fitMessageRecord  = 20
+
+	fitFieldTimestamp        = 253
+	fitFieldRecordHeartRate  = 3
+	fitFieldSessionStartTime = 2
+	fitFieldSessionSport     = 5
+	fitFieldSessionElapsed   = 7
+	fitFieldSessionDistance  = 9
+	fitFieldSessionAvgHR     = 16
+	fitFieldSessionMaxHR     = 17
+)
+
+// fitEpoch is the FIT timestamp origin, 1989-12-31 00:00:00 UTC.
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// fitSports names the FIT sport enum values this package recognizes.
+var fitSports = map[uint64]string{
+	1:  "running",
+	2:  "cycling",
+	5:  "swimming",
+	10: "training",
+	11: "walking",
+	15: "rowing",
+	17: "hiking",
+}
+
+type fitFieldDefinition struct {
+	number uint8
+	size   uint8
+}
+
+type fitDefinition struct {
+	global    uint16
+	byteOrder binary.ByteOrder
+	fields    []fitFieldDefinition
+	extraSize int
+}
+
+type fitSession struct {
+	start    time.Time
+	elapsed  time.Duration
+	sport    string
+	distance float64
+	avgHR    int
+	maxHR    int
+}
+
+// parseFIT decodes session and record messages. Each session becomes an activity and receives
+// the heart-rate records inside its time window; files without sessions become one activity.
+func parseFIT(r io.Reader) ([]Activity, error) {
+	reader := bufio.NewReader(r)
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, fmt.Errorf("%w: short FIT header", ErrInvalidFile)
+	}
+	headerSize := int(header[0])
+	if headerSize < 12 || string(header[8:12]) != ".FIT" {
+		return nil, fmt.Errorf("%w: missing FIT signature", ErrInvalidFile)
+	}
+	if _, err := reader.Discard(headerSize - 12); err != nil {
+		return nil, fmt.Errorf("%w: short FIT header", ErrInvalidFile)
+	}
+	data := io.LimitReader(reader, int64(binary.LittleEndian.Uint32(header[4:8])))
+
+	definitions := make(map[uint8]*fitDefinition)
+	var sessions []fitSession
+	var samples []HeartRateSample
+	var lastTimestamp uint32
+
+	for {
+		recordHeader := make([]byte, 1)
+		if _, err := io.ReadFull(data, recordHeader); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+
+		var localType uint8
+		var compressedTimestamp *uint32
+		switch {
+		case recordHeader[0]&0x80 != 0:
+			localType = (recordHeader[0] >> 5) & 0x03
+			offset := uint32(recordHeader[0] & 0x1F)
+			timestamp := lastTimestamp&^0x1F + offset
+			if offset < lastTimestamp&0x1F {
+				timestamp += 0x20
+			}
+			compressedTimestamp = &timestamp
+		case recordHeader[0]&0x40 != 0:
+			definition, err := readFITDefinition(data, recordHeader[0]&0x20 != 0)
+			if err != nil {
+				return nil, err
+			}
+			definitions[recordHeader[0]&0x0F] = definition
+			continue
+		default:
+			localType = recordHeader[0] & 0x0F
+		}
+
+		definition, ok := definitions[localType]
+		if !ok {
+			return nil, fmt.Errorf("%w: data message without definition", ErrInvalidFile)
+		}
+		values, err := readFITValues(data, definition)
+		if err != nil {
+			return nil, err
+		}
+		if timestamp, ok := values[fitFieldTimestamp]; ok {
+			lastTimestamp = uint32(timestamp)
+		} else if compressedTimestamp != nil {
+			lastTimestamp = *compressedTimestamp
+			values[fitFieldTimestamp] = uint64(lastTimestamp)
+		}
+
+		switch definition.global {
+		case fitMessageRecord:
+			timestamp, hasTime := values[fitFieldTimestamp]
+			heartRate, hasHR := values[fitFieldRecordHeartRate]
+			if hasTime && hasHR && heartRate > 0 {
+				samples = append(samples, HeartRateSample{Time: fitTime(timestamp), BPM: int(heartRate)})
+			}
+		case fitMessageSession:
+			session := fitSession{sport: fitSports[values[fitFieldSessionSport]]}
+			if start, ok := values[fitFieldSessionStartTime]; ok {
+				session.start = fitTime(start)
+			}
+			session.elapsed = time.Duration(values[fitFieldSessionElapsed]) * time.Millisecond
+			session.distance = float64(values[fitFieldSessionDistance]) / 100
+			session.avgHR = int(values[fitFieldSessionAvgHR])
+			session.maxHR = int(values[fitFieldSessionMaxHR])
+			if !session.start.IsZero() {
+				sessions = append(sessions, session)
+			}
+		}
+	}
+
+	if len(sessions) == 0 {
+		if len(samples) == 0 {
+			return nil, nil
+		}
+		return []Activity{{
+			Source:    SourceFIT,
+			Type:      TypeOther,
+			StartedAt: samples[0].Time,
+			Duration:  samples[len(samples)-1].Time.Sub(samples[0].Time),
+			HeartRate: samples,
+		}}, nil
+	}
+
+	activities := make([]Activity, 0, len(sessions))
+	for _, session := range sessions {
+		activity := Activity{
+			Source:       SourceFIT,
+			Type:         NormalizeType(session.sport),
+			StartedAt:    session.start,
+			Duration:     session.elapsed,
+			DistanceM:    session.distance,
+			AvgHeartRate: session.avgHR,
+			MaxHeartRate: session.maxHR,
+		}
+		end := session.start.Add(session.elapsed)
+		for _, sample := range samples {
+			if !sample.Time.Before(session.start) && !sample.Time.After(end) {
+				activity.HeartRate = append(activity.HeartRate, sample)
+			}
+		}
+		activities = append(activities, activity)
+	}
+	return activities, nil
+}
+
+func readFITDefinition(r io.Reader, hasDeveloperFields bool) (*fitDefinition, error) {
+	fixed := make([]byte, 5)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, fmt.Errorf("%w: short definition message", ErrInvalidFile)
+	}
+	definition := &fitDefinition{byteOrder: binary.LittleEndian}
+	if fixed[1] == 1 {
+		definition.byteOrder = binary.BigEndian
+	}
+	definition.global = definition.byteOrder.Uint16(fixed[2:4])
+
+	fields := make([]byte, int(fixed[4])*3)
+	if _, err := io.ReadFull(r, fields); err != nil {
+		return nil, fmt.Errorf("%w: short definition message", ErrInvalidFile)
+	}
+	for i := 0; i < len(fields); i += 3 {
+		definition.fields = append(definition.fields, fitFieldDefinition{number: fields[i], size: fields[i+1]})
+	}
+
+	if hasDeveloperFields {
+		count := make([]byte, 1)
+		if _, err := io.ReadFull(r, count); err != nil {
+			return nil, fmt.Errorf("%w: short definition message", ErrInvalidFile)
+		}
+		developerFields := make([]byte, int(count[0])*3)
+		if _, err := io.ReadFull(r, developerFields); err != nil {
+			return nil, fmt.Errorf("%w: short definition message", ErrInvalidFile)
+		}
+		for i := 0; i < len(developerFields); i += 3 {
+			definition.extraSize += int(developerFields[i+1])
+		}
+	}
+	return definition, nil
+}
+
+// readFITValues reads one data message and returns its 1, 2 and 4 byte unsigned fields.
+// Fields holding the FIT "invalid" value are left out.
+func readFITValues(r io.Reader, definition *fitDefinition) (map[uint8]uint64, error) {
+	values := make(map[uint8]uint64, len(definition.fields))
+	for _, field := range definition.fields {
+		raw := make([]byte, field.size)
+		if _, err := io.ReadFull(r, raw); err != nil {
+			return nil, fmt.Errorf("%w: short data message", ErrInvalidFile)
+		}
+		switch field.size {
+		case 1:
+			if raw[0] != 0xFF {
+				values[field.number] = uint64(raw[0])
+			}
+		case 2:
+			if value := definition.byteOrder.Uint16(raw); value != 0xFFFF {
+				values[field.number] = uint64(value)
+			}
+		case 4:
+			if value := definition.byteOrder.Uint32(raw); value != 0xFFFFFFFF {
+				values[field.number] = uint64(value)
+			}
+		}
+	}
+	if definition.extraSize > 0 {
+		if _, err := io.CopyN(io.Discard, r, int64(definition.extraSize)); err != nil {
+			return nil, fmt.Errorf("%w: short data message", ErrInvalidFile)
+		}
+	}
+	return values, nil
+}
+
+func fitTime(value uint64) time.Time {
+	return fitEpoch.Add(time.Duration(value) * time.Second)
+}
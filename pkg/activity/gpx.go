@@ -0,0 +1,69 @@
+package activity
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type gpxFile struct {
+	Tracks []gpxTrack `xml:"trk"`
+}
+
+type gpxTrack struct {
+	Type     string       `xml:"type"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Lat       float64   `xml:"lat,attr"`
+	Lon       float64   `xml:"lon,attr"`
+	Time      time.Time `xml:"time"`
+	HeartRate int       `xml:"extensions>TrackPointExtension>hr"`
+}
+
+// parseGPX reads each track as one activity. Distance is measured along the track points.
+func parseGPX(r io.Reader) ([]Activity, error) {
+	var file gpxFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	activities := make([]Activity, 0, len(file.Tracks))
+	for _, track := range file.Tracks {
+		activity := Activity{Source: SourceGPX, Type: NormalizeType(track.Type)}
+		var first, last time.Time
+		for _, segment := range track.Segments {
+			for i, point := range segment.Points {
+				if i > 0 {
+					prev := segment.Points[i-1]
+					activity.DistanceM += haversineMeters(prev.Lat, prev.Lon, point.Lat, point.Lon)
+				}
+				if point.Time.IsZero() {
+					continue
+				}
+				if first.IsZero() || point.Time.Before(first) {
+					first = point.Time
+				}
+				if point.Time.After(last) {
+					last = point.Time
+				}
+				if point.HeartRate > 0 {
+					activity.HeartRate = append(activity.HeartRate, HeartRateSample{Time: point.Time, BPM: point.HeartRate})
+				}
+			}
+		}
+		if first.IsZero() {
+			continue
+		}
+		activity.StartedAt = first
+		activity.Duration = last.Sub(first)
+		activities = append(activities, activity)
+	}
+	return activities, nil
+}
@@ -0,0 +1,83 @@
+package activity
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type tcxFile struct {
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string    `xml:"Sport,attr"`
+	ID    time.Time `xml:"Id"`
+	Laps  []tcxLap  `xml:"Lap"`
+}
+
+type tcxLap struct {
+	StartTime        time.Time       `xml:"StartTime,attr"`
+	TotalTimeSeconds float64         `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64         `xml:"DistanceMeters"`
+	AverageHeartRate int             `xml:"AverageHeartRateBpm>Value"`
+	MaximumHeartRate int             `xml:"MaximumHeartRateBpm>Value"`
+	Trackpoints      []tcxTrackpoint `xml:"Track>Trackpoint"`
+}
+
+type tcxTrackpoint struct {
+	Time      time.Time `xml:"Time"`
+	HeartRate int       `xml:"HeartRateBpm>Value"`
+}
+
+// parseTCX reads each Activity element, adding up its laps.
+func parseTCX(r io.Reader) ([]Activity, error) {
+	var file tcxFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	activities := make([]Activity, 0, len(file.Activities))
+	for _, source := range file.Activities {
+		activity := Activity{Source: SourceTCX, Type: NormalizeType(source.Sport), StartedAt: source.ID}
+		var seconds float64
+		for _, lap := range source.Laps {
+			if activity.StartedAt.IsZero() || (!lap.StartTime.IsZero() && lap.StartTime.Before(activity.StartedAt)) {
+				activity.StartedAt = lap.StartTime
+			}
+			seconds += lap.TotalTimeSeconds
+			activity.DistanceM += lap.DistanceMeters
+			activity.MaxHeartRate = max(activity.MaxHeartRate, lap.MaximumHeartRate)
+			for _, point := range lap.Trackpoints {
+				if point.HeartRate > 0 && !point.Time.IsZero() {
+					activity.HeartRate = append(activity.HeartRate, HeartRateSample{Time: point.Time, BPM: point.HeartRate})
+				}
+			}
+		}
+		if activity.StartedAt.IsZero() {
+			continue
+		}
+		activity.Duration = time.Duration(seconds * float64(time.Second))
+		if len(activity.HeartRate) == 0 {
+			activity.AvgHeartRate = weightedLapHeartRate(source.Laps)
+		}
+		activities = append(activities, activity)
+	}
+	return activities, nil
+}
+
+// weightedLapHeartRate averages lap heart rates by lap duration for files without trackpoints.
+func weightedLapHeartRate(laps []tcxLap) int {
+	var weighted, seconds float64
+	for _, lap := range laps {
+		if lap.AverageHeartRate > 0 && lap.TotalTimeSeconds > 0 {
+			weighted += float64(lap.AverageHeartRate) * lap.TotalTimeSeconds
+			seconds += lap.TotalTimeSeconds
+		}
+	}
+	if seconds == 0 {
+		return 0
+	}
+	return int(weighted/seconds + 0.5)
+}
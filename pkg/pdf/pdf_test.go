@@ -0,0 +1,70 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestDocumentBytesProducesValidXref(t *testing.T) {
+	doc := New()
+	first := doc.AddPage()
+	first.Text(50, 60, Bold, 18, "Invoice (INV-1)")
+	first.Line(50, 70, 545, 70, 0.5)
+	doc.AddPage().TextRight(545, 60, Regular, 10, "Total 10.00")
+
+	out := doc.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) {
+		t.Fatalf("expected PDF header")
+	}
+	if !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("expected EOF marker")
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Fatalf("expected two pages")
+	}
+	if !bytes.Contains(out, []byte(`(Invoice \(INV-1\)) Tj`)) {
+		t.Fatalf("expected escaped text in content stream")
+	}
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if match == nil {
+		t.Fatalf("missing startxref")
+	}
+	xrefOffset, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(out[xrefOffset:], []byte("xref\n")) {
+		t.Fatalf("startxref does not point at xref table")
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xrefOffset:], -1)
+	if len(entries) != 8 {
+		t.Fatalf("expected 8 objects, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		expected := fmt.Sprintf("%d 0 obj", i+1)
+		if !bytes.HasPrefix(out[offset:], []byte(expected)) {
+			t.Fatalf("xref entry %d does not point at %q", i+1, expected)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `a\b`, expected: `a\\b`},
+		{input: "line\nbreak", expected: "line break"},
+		{input: "café", expected: `caf\351`},
+		{input: "€", expected: "?"},
+	}
+
+	for _, tt := range tests {
+		if got := escape(tt.input); got != tt.expected {
+			t.Fatalf("escape(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Package thumbnail scales JPEG, PNG and GIF images down to small JPEG previews using only the
+// standard library.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	// Register the decoders accepted by Generate.
+	_ "image/gif"
+	_ "image/png"
+)
+
+// MaxSourcePixels guards against images that are small on disk but huge once decoded.
+const MaxSourcePixels = 40_000_000
+
+var ErrTooLarge = errors.New("thumbnail: image dimensions too large")
+
+type Result struct {
+	JPEG         []byte
+	Width        int
+	Height       int
+	SourceWidth  int
+	SourceHeight int
+}
+
+// Generate decodes the image and returns a JPEG whose longer side is at most maxSide pixels.
+// Images that already fit are re-encoded at their original size.
+func Generate(r io.ReadSeeker, maxSide int) (*Result, error) {
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxSourcePixels {
+		return nil, ErrTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := fit(config.Width, config.Height, maxSide)
+	dst := scale(src, width, height)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return &Result{
+		JPEG:         buf.Bytes(),
+		Width:        width,
+		Height:       height,
+		SourceWidth:  config.Width,
+		SourceHeight: config.Height,
+	}, nil
+}
+
+func fit(width int, height int, maxSide int) (int, int) {
+	if maxSide <= 0 || (width <= maxSide && height <= maxSide) {
+		return width, height
+	}
+	if width >= height {
+		return maxSide, max(1, height*maxSide/width)
+	}
+	return max(1, width*maxSide/height), maxSide
+}
+
+// scale averages a grid of up to 4x4 samples from the source area behind each output pixel,
+// which is enough to avoid the aliasing of nearest-neighbour scaling.
+func scale(src image.Image, width int, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcHeight/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*srcHeight/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcWidth/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*srcWidth/width)
+			dst.SetRGBA(x, y, average(src, x0, x1, y0, y1))
+		}
+	}
+	return dst
+}
+
+func average(src image.Image, x0 int, x1 int, y0 int, y1 int) color.RGBA {
+	stepX := max(1, (x1-x0)/4)
+	stepY := max(1, (y1-y0)/4)
+
+	var r, g, b, a, n uint32
+	for sy := y0; sy < y1; sy += stepY {
+		for sx := x0; sx < x1; sx += stepX {
+			cr, cg, cb, ca := src.At(sx, sy).RGBA()
+			r += cr
+			g += cg
+			b += cb
+			a += ca
+			n++
+		}
+	}
+	// RGBA() returns 16-bit premultiplied channels; shift back to 8 bits.
+	return color.RGBA{
+		R: uint8(r / n >> 8),
+		G: uint8(g / n >> 8),
+		B: uint8(b / n >> 8),
+		A: uint8(a / n >> 8),
+	}
+}
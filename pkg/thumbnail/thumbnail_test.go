@@ -0,0 +1,61 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestGenerateScalesLongerSide(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 800; x++ {
+			src.Set(x, y, color.RGBA{R: 200, G: 40, B: 40, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+
+	result, err := Generate(bytes.NewReader(buf.Bytes()), 200)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if result.Width != 200 || result.Height != 100 || result.SourceWidth != 800 || result.SourceHeight != 400 {
+		t.Fatalf("unexpected dimensions: %+v", result)
+	}
+
+	thumb, err := jpeg.Decode(bytes.NewReader(result.JPEG))
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	r, g, _, _ := thumb.At(100, 50).RGBA()
+	if r>>8 < 180 || g>>8 > 70 {
+		t.Fatalf("expected colour to survive scaling, got r=%d g=%d", r>>8, g>>8)
+	}
+}
+
+func TestGenerateKeepsSmallImages(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 50, 80))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+
+	result, err := Generate(bytes.NewReader(buf.Bytes()), 200)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if result.Width != 50 || result.Height != 80 {
+		t.Fatalf("expected original size, got %dx%d", result.Width, result.Height)
+	}
+}
+
+func TestGenerateRejectsNonImages(t *testing.T) {
+	if _, err := Generate(bytes.NewReader([]byte("%PDF-1.4")), 200); err == nil {
+		t.Fatal("expected an error for non-image input")
+	}
+}